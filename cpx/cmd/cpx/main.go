@@ -4,11 +4,14 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strings"
 
 	"github.com/ozacod/cpx/internal/app/cli"
 	"github.com/ozacod/cpx/internal/app/cli/root"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
 )
 
 func getBcrPath() string {
@@ -36,33 +39,54 @@ func main() {
 	rootCmd.AddCommand(cli.BenchCmd(client))
 	rootCmd.AddCommand(cli.CleanCmd())
 	rootCmd.AddCommand(cli.NewCmd(client))
+	rootCmd.AddCommand(cli.MigrateCmd(client))
+	rootCmd.AddCommand(cli.ConvertCmd(client))
 	rootCmd.AddCommand(cli.AddCmd(client, getBcrPath))
 	rootCmd.AddCommand(cli.RemoveCmd(client))
+	rootCmd.AddCommand(cli.LockCmd(client))
+	rootCmd.AddCommand(cli.RegistryCmd())
 	rootCmd.AddCommand(cli.ListCmd(client))
 	rootCmd.AddCommand(cli.SearchCmd(client))
 	rootCmd.AddCommand(cli.InfoCmd(client))
+	rootCmd.AddCommand(cli.WhyCmd(client))
 	rootCmd.AddCommand(cli.FmtCmd())
 	rootCmd.AddCommand(cli.LintCmd(client))
 	rootCmd.AddCommand(cli.FlawfinderCmd())
 	rootCmd.AddCommand(cli.CppcheckCmd())
 	rootCmd.AddCommand(cli.AnalyzeCmd(client))
+	rootCmd.AddCommand(cli.CompdbCmd())
+	rootCmd.AddCommand(cli.ExplainCmd())
+	rootCmd.AddCommand(cli.SelftestCmd(client))
+	rootCmd.AddCommand(cli.RenameCmd())
+	rootCmd.AddCommand(cli.DoctorCmd(client))
+	rootCmd.AddCommand(cli.ToolchainCmd())
+	rootCmd.AddCommand(cli.PresetCmd())
+	rootCmd.AddCommand(cli.BundleCmd(client))
+	rootCmd.AddCommand(cli.GenCmd())
+	rootCmd.AddCommand(cli.TemplateCmd())
+	rootCmd.AddCommand(cli.EditCmd())
 
 	rootCmd.AddCommand(cli.DocCmd())
 	rootCmd.AddCommand(cli.ReleaseCmd())
 	rootCmd.AddCommand(cli.UpgradeCmd())
 	rootCmd.AddCommand(cli.ConfigCmd())
 	rootCmd.AddCommand(cli.CICmd())
+	rootCmd.AddCommand(cli.VerifyArtifactsCmd())
 	rootCmd.AddCommand(cli.WorkflowCmd())
 	rootCmd.AddCommand(cli.HooksCmd())
-	rootCmd.AddCommand(cli.UpdateCmd())
+	rootCmd.AddCommand(cli.UpdateCmd(client))
+	rootCmd.AddCommand(cli.ExitCodesCmd())
+	rootCmd.AddCommand(cli.CompletionCmd())
 
 	// Handle vcpkg passthrough for unknown commands
 	// Check if command exists before executing
 	if len(os.Args) > 1 {
 		command := os.Args[1]
-		// Skip version/help flags - cobra handles these
-		if command != "-v" && command != "--version" && command != "version" &&
-			command != "-h" && command != "--help" && command != "help" {
+		// Skip flags (--version, --help, global flags such as --offline),
+		// version/help and the requests of completion scripts - cobra
+		// handles these
+		if !strings.HasPrefix(command, "-") && command != "version" && command != "help" &&
+			command != cobra.ShellCompRequestCmd && command != cobra.ShellCompNoDescRequestCmd {
 			// Check if it's a known command
 			found := false
 			for _, c := range rootCmd.Commands() {
@@ -77,13 +101,13 @@ func main() {
 					if err := client.RunCommand(os.Args[1:]); err != nil {
 						fmt.Fprintf(os.Stderr, "%sError:%s Failed to run vcpkg command: %v\n", cli.Red, cli.Reset, err)
 						fmt.Fprintf(os.Stderr, "Make sure vcpkg is installed and configured: cpx config set-vcpkg-root <path>\n")
-						os.Exit(1)
+						os.Exit(int(exitcode.Of(err)))
 					}
 					return
 				}
 				// If client is nil, we can't run vcpkg command
 				fmt.Fprintf(os.Stderr, "%sError:%s Unknown command '%s' and vcpkg client not initialized\n", cli.Red, cli.Reset, command)
-				os.Exit(1)
+				os.Exit(int(exitcode.Usage))
 			}
 		}
 	}
@@ -1,18 +1,27 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/ozacod/cpx/internal/pkg/bazel"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
 
 var addRunVcpkgCommandFunc func([]string) error
+var addVcpkgRootFunc func() (string, error)
 var addGetBcrPathFunc func() string
 
 // Mockable functions for bazel operations (for testing)
@@ -32,6 +41,10 @@ func init() {
 func AddCmd(client *vcpkg.Client, getBcrPath func() string) *cobra.Command {
 	if client != nil {
 		addRunVcpkgCommandFunc = client.RunCommand
+		addVcpkgRootFunc = func() (string, error) {
+			vcpkgPath, err := client.GetPath()
+			return filepath.Dir(vcpkgPath), err
+		}
 	}
 	addGetBcrPathFunc = getBcrPath
 
@@ -41,11 +54,17 @@ func AddCmd(client *vcpkg.Client, getBcrPath func() string) *cobra.Command {
 		Long: `Add a dependency to your project.
 
 For vcpkg projects: passes through to 'vcpkg add port' and prints usage info.
+<port>@<version> pins the port to a version of the vcpkg registry with an
+override in vcpkg.json, adding a builtin-baseline if there is none.
 For Bazel projects: fetches the latest version from BCR and updates MODULE.bazel.`,
+		Example: `  cpx add fmt spdlog
+  cpx add fmt@10.2.1
+  cpx add "curl[ssl]@8.8.0#1"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runAdd(cmd, args, client)
 		},
-		Args: cobra.MinimumNArgs(1),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completePorts,
 	}
 
 	return cmd
@@ -57,6 +76,14 @@ func runAdd(_ *cobra.Command, args []string, client *vcpkg.Client) error {
 		return err
 	}
 
+	if projectType != ProjectTypeVcpkg {
+		for _, arg := range args {
+			if strings.Contains(arg, "@") && !strings.HasPrefix(arg, "-") {
+				return exitcode.Errorf(exitcode.Usage, "version pins like %s are only supported in vcpkg projects", arg)
+			}
+		}
+	}
+
 	switch projectType {
 	case ProjectTypeVcpkg:
 		return runVcpkgAdd(args, client)
@@ -69,11 +96,192 @@ func runAdd(_ *cobra.Command, args []string, client *vcpkg.Client) error {
 	}
 }
 
+// vcpkgPin is a port of cpx add pinned with <port>@<version>
+type vcpkgPin struct {
+	Name    string
+	Version vcpkg.PortVersion
+}
+
+// splitVersionPin splits a cpx add argument into the argument of vcpkg add
+// port and the requested version, empty if not pinned
+func splitVersionPin(arg string) (string, string) {
+	if strings.HasPrefix(arg, "-") {
+		return arg, ""
+	}
+	port, version, _ := strings.Cut(arg, "@")
+	return port, version
+}
+
+// resolveVersionPin finds version in the versions database of the port. A
+// version without a port version takes the latest one.
+func resolveVersionPin(vcpkgRoot, name, version string) (vcpkg.PortVersion, error) {
+	versions, err := vcpkg.PortVersions(vcpkgRoot, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return vcpkg.PortVersion{}, exitcode.Errorf(exitcode.Usage, "no vcpkg port named %q\n  hint: find packages with cpx search %s, or update vcpkg with git -C %s pull", name, name, vcpkgRoot)
+	}
+	if err != nil {
+		return vcpkg.PortVersion{}, err
+	}
+
+	requested, portVersion, hasPortVersion := strings.Cut(version, "#")
+	for _, v := range versions {
+		if v.Version != requested {
+			continue
+		}
+		if !hasPortVersion || strconv.Itoa(v.PortVersion) == portVersion {
+			return v, nil
+		}
+	}
+
+	var available []string
+	for i, v := range versions {
+		if i == 5 {
+			available = append(available, "...")
+			break
+		}
+		available = append(available, v.String())
+	}
+	return vcpkg.PortVersion{}, exitcode.Errorf(exitcode.Usage, "%s has no version %s in the vcpkg registry\n  hint: latest versions: %s", name, version, strings.Join(available, ", "))
+}
+
+// vcpkgPinKey marks the overrides written for <port>@<version>, so that
+// cpx lock --update keeps them. vcpkg ignores fields starting with $.
+const vcpkgPinKey = "$cpx-pinned"
+
+// pinVcpkgVersions writes an override for each of pins into vcpkg.json,
+// replacing earlier overrides of the same ports. Overrides need a
+// builtin-baseline; without one the commit of the vcpkg checkout is used.
+func pinVcpkgVersions(vcpkgRoot string, pins []vcpkgPin) error {
+	manifest, err := vcpkg.ReadManifest("vcpkg.json")
+	if err != nil {
+		return err
+	}
+	if baseline, _ := manifest["builtin-baseline"].(string); baseline == "" {
+		if manifest["builtin-baseline"], err = vcpkgCheckoutCommit(vcpkgRoot); err != nil {
+			return err
+		}
+	}
+
+	overrides, _ := manifest["overrides"].([]any)
+	for _, pin := range pins {
+		entry := map[string]any{"name": pin.Name, "version": pin.Version.Version}
+		if pin.Version.PortVersion > 0 {
+			entry["port-version"] = pin.Version.PortVersion
+		}
+		entry[vcpkgPinKey] = "cpx add " + pin.Name + "@" + pin.Version.String()
+		replaced := false
+		for i, o := range overrides {
+			if existing, ok := o.(map[string]any); ok && existing["name"] == pin.Name {
+				overrides[i] = entry
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			overrides = append(overrides, entry)
+		}
+	}
+	manifest["overrides"] = overrides
+	return vcpkg.WriteManifest("vcpkg.json", manifest)
+}
+
+// vcpkgFeatures are the features of a port requested with <port>[f1,f2]
+type vcpkgFeatures struct {
+	Name     string
+	Features []string
+}
+
+// splitFeatures splits a vcpkg add port argument into the port and its
+// features
+func splitFeatures(arg string) (string, []string, error) {
+	name, rest, found := strings.Cut(arg, "[")
+	if !found || strings.HasPrefix(arg, "-") {
+		return arg, nil, nil
+	}
+	list, ok := strings.CutSuffix(rest, "]")
+	if !ok || name == "" {
+		return "", nil, exitcode.Errorf(exitcode.Usage, "invalid package %q\n  hint: list features as <port>[feature1,feature2]", arg)
+	}
+	var features []string
+	for _, feature := range strings.Split(list, ",") {
+		if feature = strings.TrimSpace(feature); feature != "" {
+			features = append(features, feature)
+		}
+	}
+	return name, features, nil
+}
+
+// checkPortFeatures reports features the port in vcpkgRoot doesn't have.
+// Ports that aren't in the vcpkg checkout, such as ports of other
+// registries, aren't checked.
+func checkPortFeatures(vcpkgRoot, name string, features []string) error {
+	port, err := vcpkg.LoadPort(vcpkgRoot, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	available := []string{"core"}
+	for _, feature := range port.Features {
+		available = append(available, feature.Name)
+	}
+	for _, feature := range features {
+		if !slices.Contains(available, feature) {
+			return exitcode.Errorf(exitcode.Usage, "%s has no feature %q\n  hint: available features: %s", name, feature, strings.Join(available[1:], ", "))
+		}
+	}
+	return nil
+}
+
 func runVcpkgAdd(args []string, client *vcpkg.Client) error {
-	// Directly pass all arguments to vcpkg add command
-	// cpx add <pkg> -> vcpkg add port <pkg>
+	// cpx add <pkg> -> vcpkg add port <pkg>, without the version and the
+	// features, which cpx writes into vcpkg.json afterwards
 	vcpkgArgs := []string{"add", "port"}
-	vcpkgArgs = append(vcpkgArgs, args...)
+	var pins []vcpkgPin
+	var featureDeps []vcpkgFeatures
+	var vcpkgRoot string
+	getVcpkgRoot := func() (string, error) {
+		if vcpkgRoot != "" {
+			return vcpkgRoot, nil
+		}
+		if addVcpkgRootFunc == nil {
+			return "", fmt.Errorf("vcpkg client not initialized")
+		}
+		root, err := addVcpkgRootFunc()
+		vcpkgRoot = root
+		return root, err
+	}
+	for _, arg := range args {
+		port, version := splitVersionPin(arg)
+		name, features, err := splitFeatures(port)
+		if err != nil {
+			return err
+		}
+		vcpkgArgs = append(vcpkgArgs, name)
+
+		if len(features) > 0 {
+			root, err := getVcpkgRoot()
+			if err != nil {
+				return err
+			}
+			if err := checkPortFeatures(root, name, features); err != nil {
+				return err
+			}
+			featureDeps = append(featureDeps, vcpkgFeatures{Name: name, Features: features})
+		}
+		if version != "" {
+			root, err := getVcpkgRoot()
+			if err != nil {
+				return err
+			}
+			resolved, err := resolveVersionPin(root, name, version)
+			if err != nil {
+				return err
+			}
+			pins = append(pins, vcpkgPin{Name: name, Version: resolved})
+		}
+	}
 
 	var runFunc func([]string) error
 	if addRunVcpkgCommandFunc != nil {
@@ -85,18 +293,43 @@ func runVcpkgAdd(args []string, client *vcpkg.Client) error {
 	if runFunc == nil {
 		return fmt.Errorf("vcpkg client not initialized")
 	}
+
+	// Ports from private registries resolve through vcpkg-configuration.json
+	var ports []string
+	for _, arg := range vcpkgArgs[2:] {
+		if !strings.HasPrefix(arg, "-") {
+			ports = append(ports, arg)
+		}
+	}
+	if err := assignRegistryPorts(ports); err != nil {
+		return err
+	}
+
 	if err := runFunc(vcpkgArgs); err != nil {
 		return err
 	}
 
-	// Print usage info for the first package
-	if len(args) > 0 {
-		pkgName := args[0]
-		if !strings.HasPrefix(pkgName, "-") {
-			printVcpkgUsageInfo(pkgName)
+	for _, dep := range featureDeps {
+		if err := vcpkg.SetDependencyFeatures("vcpkg.json", dep.Name, dep.Features); err != nil {
+			return err
+		}
+		logging.Success("✓ Enabled features %s of %s in vcpkg.json", strings.Join(dep.Features, ", "), dep.Name)
+	}
+
+	if len(pins) > 0 {
+		if err := pinVcpkgVersions(vcpkgRoot, pins); err != nil {
+			return err
+		}
+		for _, pin := range pins {
+			logging.Success("✓ Pinned %s to %s in vcpkg.json", pin.Name, pin.Version)
 		}
 	}
 
+	// Print usage info for the first package
+	if len(ports) > 0 && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		printVcpkgUsageInfo(ports[0], client)
+	}
+
 	return nil
 }
 
@@ -114,7 +347,7 @@ func runBazelAdd(args []string) error {
 		// Get latest version (uses mockable function)
 		version, err := bazelGetLatestVersionFunc(bcrPath, pkgName)
 		if err != nil {
-			fmt.Printf("%s✗ Module '%s' not found in BCR%s\n", Red, pkgName, Reset)
+			logging.Error("Module '%s' not found in BCR", pkgName)
 			continue
 		}
 
@@ -123,7 +356,7 @@ func runBazelAdd(args []string) error {
 			return fmt.Errorf("failed to add dependency: %w", err)
 		}
 
-		fmt.Printf("%s✓ Added %s@%s to MODULE.bazel%s\n", Green, pkgName, version, Reset)
+		logging.Success("✓ Added %s@%s to MODULE.bazel", pkgName, version)
 		printBazelUsageInfo(pkgName)
 	}
 
@@ -134,7 +367,7 @@ func runMesonAdd(args []string) error {
 	// Meson uses WrapDB - use 'meson wrap install'
 	// This requires 'meson' to be in PATH
 	if _, err := execLookPath("meson"); err != nil {
-		return fmt.Errorf("meson not found in PATH: %w", err)
+		return exitcode.Errorf(exitcode.ToolchainMissing, "meson not found in PATH: %w", err)
 	}
 
 	for _, pkgName := range args {
@@ -142,7 +375,7 @@ func runMesonAdd(args []string) error {
 			continue
 		}
 
-		fmt.Printf("%sInstalling wrap for %s...%s\n", Cyan, pkgName, Reset)
+		logging.Step("Installing wrap for %s...", pkgName)
 
 		// Create subprojects dir if it doesn't exist (meson wrap install might need it)
 		if err := createDirIfNotExists("subprojects"); err != nil {
@@ -155,19 +388,36 @@ func runMesonAdd(args []string) error {
 		cmd.Stderr = os.Stderr
 
 		if err := cmd.Run(); err != nil {
-			fmt.Printf("%s✗ Failed to install wrap for %s%s\n", Red, pkgName, Reset)
+			logging.Error("Failed to install wrap for %s", pkgName)
 			continue
 		}
 
-		fmt.Printf("%s✓ Added %s%s\n", Green, pkgName, Reset)
+		logging.Success("✓ Added %s", pkgName)
 		printMesonUsageInfo(pkgName)
 	}
 
 	return nil
 }
 
-// printVcpkgUsageInfo fetches and prints usage info from GitHub for vcpkg packages
-func printVcpkgUsageInfo(pkgName string) {
+// printVcpkgUsageInfo fetches and prints usage info from GitHub for vcpkg
+// packages; offline, from the port in the local vcpkg checkout
+func printVcpkgUsageInfo(pkgName string, client *vcpkg.Client) {
+	if offline.Enabled() {
+		if client == nil {
+			return
+		}
+		vcpkgPath, err := client.GetPath()
+		if err != nil {
+			return
+		}
+		if port, err := vcpkg.LoadPort(filepath.Dir(vcpkgPath), pkgName); err == nil && port.Usage != "" {
+			fmt.Printf("\n%sUSAGE INFO FOR %s:%s\n", Cyan, pkgName, Reset)
+			fmt.Println(port.Usage)
+			fmt.Println()
+		}
+		return
+	}
+
 	resp, err := http.Get(fmt.Sprintf("https://raw.githubusercontent.com/microsoft/vcpkg/master/ports/%s/usage", pkgName))
 	if err != nil || resp.StatusCode != 200 {
 		return
@@ -2,9 +2,12 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -228,7 +231,7 @@ func TestPrintVcpkgUsageInfo(t *testing.T) {
 
 			// This is a simple test - the actual HTTP call would need more complex mocking
 			// For now, we just test that the function doesn't crash
-			printVcpkgUsageInfo(tt.pkgName)
+			printVcpkgUsageInfo(tt.pkgName, nil)
 
 			// Restore stdout
 			err := w.Close()
@@ -334,3 +337,109 @@ func TestPrintMesonUsageInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestRunVcpkgAddPinned(t *testing.T) {
+	vcpkgRoot := t.TempDir()
+	baseline := gitCommitFiles(t, vcpkgRoot, map[string]string{
+		"versions/f-/fmt.json":   `{"versions": [{"version": "11.0.2", "port-version": 1}, {"version": "11.0.2", "port-version": 0}, {"version": "10.2.1", "port-version": 0}]}`,
+		"versions/c-/curl.json":  `{"versions": [{"version": "8.8.0", "port-version": 2}, {"version": "8.8.0", "port-version": 1}]}`,
+		"versions/baseline.json": `{"default": {}}`,
+	})
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": ["fmt"], "overrides": [{"name": "fmt", "version": "11.0.2"}]}`), 0644))
+
+	oldRun, oldRoot := addRunVcpkgCommandFunc, addVcpkgRootFunc
+	defer func() { addRunVcpkgCommandFunc, addVcpkgRootFunc = oldRun, oldRoot }()
+	var ran []string
+	addRunVcpkgCommandFunc = func(args []string) error {
+		ran = args
+		return nil
+	}
+	addVcpkgRootFunc = func() (string, error) { return vcpkgRoot, nil }
+
+	require.NoError(t, runVcpkgAdd([]string{"fmt@10.2.1", "curl[ssl]@8.8.0#1", "zlib"}, nil))
+	assert.Equal(t, []string{"add", "port", "fmt", "curl", "zlib"}, ran)
+
+	data, err := os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	var manifest map[string]any
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, baseline, manifest["builtin-baseline"])
+	assert.Equal(t, []any{
+		map[string]any{"name": "fmt", "version": "10.2.1", vcpkgPinKey: "cpx add fmt@10.2.1"},
+		map[string]any{"name": "curl", "version": "8.8.0", "port-version": float64(1), vcpkgPinKey: "cpx add curl@8.8.0#1"},
+	}, manifest["overrides"])
+
+	// Without a port version the latest one is pinned
+	require.NoError(t, runVcpkgAdd([]string{"fmt@11.0.2"}, nil))
+	data, err = os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"port-version": 1`)
+
+	// cpx lock --update keeps the pin over the baseline version
+	gitCommitFiles(t, vcpkgRoot, map[string]string{
+		"versions/baseline.json": `{"default": {"fmt": {"baseline": "11.0.2", "port-version": 1}, "curl": {"baseline": "8.8.0", "port-version": 2}, "zlib": {"baseline": "1.3.1", "port-version": 0}}}`,
+	})
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": ["fmt", "zlib"]}`), 0644))
+	require.NoError(t, runVcpkgAdd([]string{"fmt@10.2.1"}, nil))
+	lock, err := lockVcpkg(vcpkgRoot, true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"fmt": "10.2.1", "zlib": "1.3.1"}, lock.Packages)
+	data, err = os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"version": "10.2.1"`)
+
+	// Unknown versions and ports fail before vcpkg runs
+	ran = nil
+	err = runVcpkgAdd([]string{"fmt@9.9.9"}, nil)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.ErrorContains(t, err, "11.0.2#1, 11.0.2, 10.2.1")
+	err = runVcpkgAdd([]string{"nope@1.0"}, nil)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.Nil(t, ran)
+}
+
+func TestRunVcpkgAddFeatures(t *testing.T) {
+	vcpkgRoot := t.TempDir()
+	portDir := filepath.Join(vcpkgRoot, "ports", "boost")
+	require.NoError(t, os.MkdirAll(portDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(portDir, "vcpkg.json"), []byte(`{"name": "boost", "version": "1.86.0", "features": {"filesystem": {"description": "x"}, "system": {"description": "y"}}}`), 0644))
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": []}`), 0644))
+
+	oldRun, oldRoot := addRunVcpkgCommandFunc, addVcpkgRootFunc
+	defer func() { addRunVcpkgCommandFunc, addVcpkgRootFunc = oldRun, oldRoot }()
+	var ran []string
+	addRunVcpkgCommandFunc = func(args []string) error {
+		ran = args
+		// vcpkg add port writes the plain string form
+		return os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": ["boost"]}`), 0644)
+	}
+	addVcpkgRootFunc = func() (string, error) { return vcpkgRoot, nil }
+
+	require.NoError(t, runVcpkgAdd([]string{"boost[filesystem, system]"}, nil))
+	assert.Equal(t, []string{"add", "port", "boost"}, ran)
+	data, err := os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	var manifest map[string]any
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, []any{map[string]any{"name": "boost", "features": []any{"filesystem", "system"}}}, manifest["dependencies"])
+
+	ran = nil
+	err = runVcpkgAdd([]string{"boost[python]"}, nil)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.ErrorContains(t, err, "filesystem, system")
+	err = runVcpkgAdd([]string{"boost[system"}, nil)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.Nil(t, ran)
+}
@@ -1,8 +1,16 @@
 package cli
 
 import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/quality"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -11,26 +19,99 @@ func AnalyzeCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "analyze",
 		Short: "Run comprehensive code analysis and generate HTML report",
-		Long:  "Run comprehensive code analysis using cppcheck, clang-tidy, and flawfinder. Generates a combined HTML report (analyze.html).",
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Long: `Run comprehensive code analysis using cppcheck, clang-tidy, flawfinder and the Clang Static Analyzer. Findings from the last 'cpx test --memcheck' run are included. Generates a combined HTML report (analyze.html).
+
+The tools check the sources of compile_commands.json (from the project root,
+build/, builddir/ or .cache/native/debug) with the headers of their include
+directories, leaving out generated files and dependencies; without one,
+cppcheck and flawfinder scan the source directories. The tools run
+concurrently, and clang-tidy runs on --jobs files at a time.
+
+The Clang Static Analyzer runs over the compilation database with
+analyze-build (the compilation database mode of scan-build) or CodeChecker,
+whichever is installed; its plist reports are kept in .cache/clang-analyzer.
+On projects depending on Qt (in vcpkg.json, CMakeLists.txt or meson.build),
+the Qt checks of clazy run too. The semgrep rulesets listed under
+analyze.semgrep in cpx.yaml run as well, for checks of your own.
+
+'--baseline create' saves the current findings to .cpx/analysis-baseline.json.
+Later runs leave the findings of the baseline out, so that the findings of
+legacy code don't drown out new ones; --include-baseline shows them all.
+
+--format json writes the findings as JSON instead of HTML, and --max-errors
+and --max-warnings make the command fail (exit code 7) on more findings of
+that severity, to gate CI pipelines. --compare classifies the findings as new,
+fixed or unchanged since a previous JSON report, in the terminal and in the
+report, e.g. to review those of a pull request.`,
+		Example: `  cpx analyze                     # Report the findings that are not in the baseline
+  cpx analyze --baseline create   # Accept the current findings as the baseline
+  cpx analyze --include-baseline  # Report every finding
+  cpx analyze --format json --max-errors 0 --max-warnings 50
+  cpx analyze --compare main-analyze.json  # Show the findings new since main`,
+		RunE: withExitCode(exitcode.QualityGate, func(cmd *cobra.Command, args []string) error {
 			return runAnalyze(cmd, args, client)
-		},
+		}),
 		Args: cobra.ArbitraryArgs,
 	}
 
-	cmd.Flags().String("output", "analyze.html", "Output HTML file path")
+	cmd.Flags().String("output", "", "Output file path (default analyze.html, or analyze.json with --format json)")
+	cmd.Flags().String("format", "html", "Report format: html or json")
+	cmd.Flags().Int("max-errors", -1, "Fail if there are more findings of severity error (-1: no limit)")
+	cmd.Flags().Int("max-warnings", -1, "Fail if there are more findings of severity warning (-1: no limit)")
+	cmd.Flags().IntP("jobs", "j", 0, "Parallel clang-tidy processes (0 = auto)")
 	cmd.Flags().Bool("skip-cppcheck", false, "Skip Cppcheck analysis")
 	cmd.Flags().Bool("skip-lint", false, "Skip clang-tidy analysis")
 	cmd.Flags().Bool("skip-flawfinder", false, "Skip Flawfinder analysis")
+	cmd.Flags().Bool("skip-clang-analyzer", false, "Skip Clang Static Analyzer analysis")
+	cmd.Flags().Bool("skip-clazy", false, "Skip clazy analysis of Qt projects")
+	cmd.Flags().String("compare", "", "Previous JSON report to classify the findings as new, fixed or unchanged against")
+	cmd.Flags().String("baseline", "", "Baseline action: create saves the current findings as the baseline")
+	cmd.Flags().Bool("include-baseline", false, "Report the findings of the baseline too")
+	cmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(quality.ReportFormats, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("baseline", cobra.FixedCompletions([]string{"create"}, cobra.ShellCompDirectiveNoFileComp))
 
 	return cmd
 }
 
 func runAnalyze(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 	output, _ := cmd.Flags().GetString("output")
+	format, _ := cmd.Flags().GetString("format")
+	maxErrors, _ := cmd.Flags().GetInt("max-errors")
+	maxWarnings, _ := cmd.Flags().GetInt("max-warnings")
+	if !slices.Contains(quality.ReportFormats, format) {
+		return exitcode.Errorf(exitcode.Usage, "unknown report format %q\n  hint: use --format html or --format json", format)
+	}
+	if output == "" {
+		output = "analyze." + format
+	}
+	var previous *quality.ComprehensiveAnalysis
+	comparePath, _ := cmd.Flags().GetString("compare")
+	if comparePath != "" {
+		report, err := quality.LoadReport(comparePath)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		previous = &report
+	}
 	skipCppcheck, _ := cmd.Flags().GetBool("skip-cppcheck")
 	skipLint, _ := cmd.Flags().GetBool("skip-lint")
 	skipFlawfinder, _ := cmd.Flags().GetBool("skip-flawfinder")
+	skipClangAnalyzer, _ := cmd.Flags().GetBool("skip-clang-analyzer")
+	skipClazy, _ := cmd.Flags().GetBool("skip-clazy")
+	jobs, _ := cmd.Flags().GetInt("jobs")
+	baseline, _ := cmd.Flags().GetString("baseline")
+	includeBaseline, _ := cmd.Flags().GetBool("include-baseline")
+	project, err := config.LoadProject(config.ProjectFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	var semgrepRulesets []string
+	if err == nil {
+		semgrepRulesets = project.Analyze.Semgrep
+	}
+	if baseline != "" && baseline != "create" {
+		return exitcode.Errorf(exitcode.Usage, "unknown baseline action %q\n  hint: use --baseline create", baseline)
+	}
 
 	// Get remaining args as target directories (default to current directory)
 	targets := args
@@ -38,5 +119,25 @@ func runAnalyze(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 		targets = []string{"."}
 	}
 
-	return quality.RunComprehensiveAnalysis(output, skipCppcheck, skipLint, skipFlawfinder, targets, client)
+	compileDbDir := ""
+	if !skipLint || !skipClangAnalyzer || (!skipClazy && quality.UsesQt()) {
+		switch DetectProjectType() {
+		case ProjectTypeMeson:
+			compileDbDir = mesonBuildDir
+			if err := ensureMesonCompileDatabase(); err != nil {
+				return err
+			}
+		case ProjectTypeBazel:
+			compileDbDir = build.BazelCompdbDir
+			if _, err := generateBazelCompileDatabase("//...", filepath.Join(compileDbDir, "compile_commands.json"), false); err != nil {
+				return err
+			}
+		}
+	}
+
+	analysis, err := quality.RunComprehensiveAnalysis(output, format, skipCppcheck, skipLint, skipFlawfinder, skipClangAnalyzer, skipClazy, jobs, targets, compileDbDir, semgrepRulesets, baseline == "create", includeBaseline, previous, comparePath, client)
+	if err != nil {
+		return err
+	}
+	return quality.CheckThresholds(analysis, maxErrors, maxWarnings)
 }
@@ -5,8 +5,11 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
@@ -19,7 +22,9 @@ func BenchCmd(client *vcpkg.Client) *cobra.Command {
 		Long:  "Build the project benchmarks and run them. Detects vcpkg/CMake or Bazel projects automatically.",
 		Example: `  cpx bench            # Build + run all benchmarks
   cpx bench --verbose  # Show verbose output
-  cpx bench --target //bench:myapp_bench  # Run specific benchmark (Bazel)`,
+  cpx bench --target //bench:myapp_bench  # Run specific benchmark (Bazel)
+  cpx bench --save main                   # Store results as 'main'
+  cpx bench --compare main --threshold 10 # Fail if >10% slower than 'main'`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runBenchCmd(cmd, args, client)
 		},
@@ -27,6 +32,9 @@ func BenchCmd(client *vcpkg.Client) *cobra.Command {
 
 	cmd.Flags().BoolP("verbose", "v", false, "Show verbose build output")
 	cmd.Flags().String("target", "", "Specific benchmark target to run (Bazel projects)")
+	cmd.Flags().String("save", "", "Save results under .cache/bench/<name>.json")
+	cmd.Flags().String("compare", "", "Compare results against saved results <name>")
+	cmd.Flags().Float64("threshold", 5, "Regression threshold in percent for --compare")
 
 	return cmd
 }
@@ -34,23 +42,86 @@ func BenchCmd(client *vcpkg.Client) *cobra.Command {
 func runBenchCmd(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	target, _ := cmd.Flags().GetString("target")
+	save, _ := cmd.Flags().GetString("save")
+	compare, _ := cmd.Flags().GetString("compare")
+	threshold, _ := cmd.Flags().GetFloat64("threshold")
+
+	// Load the baseline up front so a typo fails before the benchmarks run
+	var baseline *build.BenchRun
+	if compare != "" {
+		var err error
+		if baseline, err = build.LoadBenchRun(compare); err != nil {
+			return err
+		}
+	}
+
+	// Benchmarks write JSON results here when saving or comparing
+	var outFile string
+	if save != "" || compare != "" {
+		if err := os.MkdirAll(build.BenchResultsDir, 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", build.BenchResultsDir, err)
+		}
+		// Absolute, since bazel run executes in the runfiles directory
+		outFile, _ = filepath.Abs(filepath.Join(build.BenchResultsDir, "last-raw.json"))
+		os.Remove(outFile)
+	}
 
 	// Detect project type
 	projectType := DetectProjectType()
 
+	var err error
 	switch projectType {
 	case ProjectTypeBazel:
-		return runBazelBench(verbose, target)
+		err = runBazelBench(verbose, target, outFile)
 	case ProjectTypeMeson:
-		return runMesonBench(verbose, target)
+		err = runMesonBench(verbose, target, outFile)
 	default:
 		// Fall back to CMake
-		return build.RunBenchmarks(verbose, client)
+		err = build.RunBenchmarks(verbose, outFile, client)
+	}
+	if err != nil || outFile == "" {
+		return err
+	}
+
+	return processBenchResults(outFile, save, baseline, threshold)
+}
+
+// processBenchResults saves and/or compares the JSON results written by the benchmark
+func processBenchResults(outFile, save string, baseline *build.BenchRun, threshold float64) error {
+	data, err := os.ReadFile(outFile)
+	if err != nil {
+		return fmt.Errorf("benchmark did not write results to %s: %w", outFile, err)
+	}
+	results, err := build.ParseBenchmarkJSON(data)
+	if err != nil {
+		return err
+	}
+
+	if save != "" {
+		run := &build.BenchRun{
+			Name:      save,
+			Framework: build.DetectBenchFramework("."),
+			Created:   time.Now(),
+			Results:   results,
+		}
+		if err := build.SaveBenchRun(run); err != nil {
+			return err
+		}
+		logging.Success("✓ Saved %d results as '%s'", len(results), save)
+	}
+
+	if baseline != nil {
+		deltas := build.CompareBenchRuns(baseline.Results, results, threshold)
+		if regressions := build.PrintBenchComparison(baseline.Name, deltas, threshold); regressions > 0 {
+			return exitcode.Errorf(exitcode.QualityGate, "%d benchmark(s) regressed by more than %.1f%%", regressions, threshold)
+		}
+		logging.Success("✓ No regressions")
 	}
+	return nil
 }
 
-func runBazelBench(verbose bool, target string) error {
-	fmt.Printf("%sRunning Bazel benchmarks...%s\n", Cyan, Reset)
+func runBazelBench(verbose bool, target, outFile string) error {
+	logging.Step("Running Bazel benchmarks...")
 
 	// If no target specified, query for bench targets
 	if target == "" {
@@ -85,7 +156,18 @@ func runBazelBench(verbose bool, target string) error {
 		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
 	}
 
+	benchArgs, benchEnv, err := benchOutputArgs(outFile)
+	if err != nil {
+		return err
+	}
+	if len(benchArgs) > 0 {
+		bazelArgs = append(bazelArgs, "--")
+		bazelArgs = append(bazelArgs, benchArgs...)
+	}
+
 	benchCmd := execCommand("bazel", bazelArgs...)
+	// bazel run passes the client environment through to the binary
+	addEnv(benchCmd, benchEnv)
 	benchCmd.Stdout = os.Stdout
 	benchCmd.Stderr = os.Stderr
 
@@ -93,16 +175,16 @@ func runBazelBench(verbose bool, target string) error {
 		return fmt.Errorf("bazel benchmark failed: %w", err)
 	}
 
-	fmt.Printf("%s✓ Benchmarks complete%s\n", Green, Reset)
+	logging.Success("✓ Benchmarks complete")
 	return nil
 }
 
-func runMesonBench(verbose bool, target string) error {
-	fmt.Printf("%sRunning Meson benchmarks...%s\n", Cyan, Reset)
+func runMesonBench(verbose bool, target, outFile string) error {
+	logging.Step("Running Meson benchmarks...")
 
 	// Ensure builddir exists
 	if _, err := os.Stat("builddir"); os.IsNotExist(err) {
-		if err := runMesonBuild(false, "", false, verbose, "", ""); err != nil {
+		if err := runMesonBuild(false, "", false, verbose, "", "", false, build.Compiler{}, build.CrossToolchain{}); err != nil {
 			return fmt.Errorf("build failed: %w", err)
 		}
 	}
@@ -143,7 +225,13 @@ func runMesonBench(verbose bool, target string) error {
 
 	fmt.Printf("  Running: %s\n", benchPath)
 
-	benchCmd := execCommand(benchPath)
+	benchArgs, benchEnv, err := benchOutputArgs(outFile)
+	if err != nil {
+		return err
+	}
+
+	benchCmd := execCommand(benchPath, benchArgs...)
+	addEnv(benchCmd, benchEnv)
 	benchCmd.Stdout = os.Stdout
 	benchCmd.Stderr = os.Stderr
 
@@ -151,10 +239,19 @@ func runMesonBench(verbose bool, target string) error {
 		return fmt.Errorf("benchmark failed: %w", err)
 	}
 
-	fmt.Printf("%s✓ Benchmarks complete%s\n", Green, Reset)
+	logging.Success("✓ Benchmarks complete")
 	return nil
 }
 
+// benchOutputArgs returns the arguments and environment that make the
+// project's benchmark framework write JSON results to outFile
+func benchOutputArgs(outFile string) ([]string, []string, error) {
+	if outFile == "" {
+		return nil, nil, nil
+	}
+	return build.BenchOutputArgs(build.DetectBenchFramework("."), outFile)
+}
+
 func findBenchTarget() string {
 	// Try to read bench/BUILD.bazel to find a cc_binary target
 	data, err := os.ReadFile("bench/BUILD.bazel")
@@ -63,7 +63,7 @@ func TestRunBazelBench(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			capturedArgs = nil
-			err := runBazelBench(tt.verbose, tt.target)
+			err := runBazelBench(tt.verbose, tt.target, "")
 			assert.NoError(t, err)
 
 			require.GreaterOrEqual(t, len(capturedArgs), 1)
@@ -114,11 +114,11 @@ func TestRunMesonBench(t *testing.T) {
 	benchExe := filepath.Join(benchDir, "myapp_bench")
 	require.NoError(t, os.WriteFile(benchExe, []byte("#!/bin/sh\necho bench"), 0755))
 
-	err = runMesonBench(false, "")
+	err = runMesonBench(false, "", "")
 	assert.NoError(t, err)
 
 	// Test with specific target
-	err = runMesonBench(false, "myapp_bench")
+	err = runMesonBench(false, "myapp_bench", "")
 	assert.NoError(t, err)
 }
 
@@ -1,12 +1,25 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/toolchain"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -17,7 +30,52 @@ func BuildCmd(client *vcpkg.Client) *cobra.Command {
 		Short: "Compile the project",
 		Long: `Compile the project. Automatically detects project type:
   - vcpkg/CMake projects: Uses CMake with vcpkg toolchain
-  - Bazel projects: Uses bazel build`,
+  - Bazel projects: Uses bazel build
+
+On a terminal, cpx shows a progress bar with the file being compiled and
+the elapsed time, and prints the build output only if the build fails. With
+--verbose, in CI (CI is set) or when the output is not a terminal, it
+streams the output of the build tool.
+
+--target embedded cross-compiles the firmware of a project created with
+"cpx new --template embedded" using its arm-none-eabi toolchain file,
+without vcpkg and without the host-only tests.
+
+--timings reads the .ninja_log of the build directory (CMake with the Ninja
+generator, or Meson) and prints the wall and CPU time of the last build and
+its slowest translation units. --timings-html also writes a timeline of the
+build to ` + build.TimingsHTMLFile + ` in the build directory.
+
+After a build with compiler errors or warnings, cpx prints them grouped per
+file, each reported once even if several translation units include the
+header. --diagnostics also writes them to a file for editors and code
+scanning: as SARIF 2.1.0 if the file name ends in .sarif, as JSON otherwise.
+
+--configs builds several configurations one after the other, each into its
+own output directory (.bin/native/debug and .bin/native/release), e.g. for
+packages that ship both.
+
+--compiler (or build.compiler in cpx.yaml) selects the compiler: clang, gcc
+or cl, optionally with a version (clang-17, gcc-13). cpx finds it on PATH
+(clang-17 is clang++-17) or among the toolchains installed with 'cpx
+toolchain install', and passes it to CMake (CMAKE_CXX_COMPILER), Bazel
+(--repo_env CC) or Meson (a native file). Each compiler builds in its own
+directories, e.g. .cache/native/debug-clang-17 and .bin/native/debug-clang-17.
+
+--toolchain cross-compiles CMake and Meson projects with a CMake toolchain
+file or a Meson cross file: a path, or the target triple of a file written
+by 'cpx gen toolchain'. vcpkg projects chainload it from the vcpkg toolchain
+and install the dependencies for the target's triplet. Cross builds go to
+.cache/<target> and .bin/<target>.
+
+--static links a fully static executable with musl on Linux: with the
+compilers of an Alpine host, or a musl cross toolchain (x86_64-linux-musl-g++,
+e.g. from musl.cc). vcpkg dependencies are built for the x64-linux-musl
+triplet. Static builds go to .cache/static and .bin/static; cpx checks each
+executable with file and ldd and reports whether it is truly static.
+
+With --json, cpx prints the status of the build, its output directory, the
+artifacts in it and the compiler diagnostics.`,
 		Example: `  cpx build              # Debug build (default)
   cpx build --release    # Release build (-O2)
   cpx build -O3          # Maximum optimization
@@ -25,20 +83,40 @@ func BuildCmd(client *vcpkg.Client) *cobra.Command {
   cpx build --clean      # Clean rebuild
   cpx build --asan       # Build with AddressSanitizer
   cpx build --tsan       # Build with ThreadSanitizer
-  cpx build --watch      # Watch for changes and rebuild`,
-		RunE: func(cmd *cobra.Command, args []string) error {
+  cpx build --watch      # Watch for changes and rebuild
+  cpx build --unity      # Unity (jumbo) build
+  cpx build --timings    # Report the slowest translation units
+  cpx build --timings-html  # Also write a timeline of the build
+  cpx build --strict-tools  # Fail on tool version mismatches
+  cpx build --json       # Print the status and artifacts as JSON
+  cpx build --configs debug,release  # Build both configurations
+  cpx build --toolchain aarch64-linux-gnu  # Cross-compile (see cpx gen toolchain)
+  cpx build --static --release  # Fully static executable (musl)
+  cpx build --compiler clang-17  # Build with clang 17 in its own build directory
+  cpx build --diagnostics build.sarif  # Write the warnings and errors as SARIF
+  cpx build --target embedded  # Cross-compile the firmware of an embedded project`,
+		RunE: withExitCode(exitcode.BuildFailed, func(cmd *cobra.Command, args []string) error {
 			return runBuild(cmd, args, client)
-		},
+		}),
 	}
 
 	cmd.Flags().BoolP("release", "r", false, "Release build (-O2). Default is debug")
 	cmd.Flags().Bool("debug", false, "Debug build (-O0). Default; kept for compatibility")
 	cmd.Flags().IntP("jobs", "j", 0, "Parallel jobs for build (0 = auto)")
-	cmd.Flags().String("target", "", "Specific target to build")
+	cmd.Flags().String("target", "", "Specific target to build (\"embedded\" cross-compiles embedded projects)")
 	cmd.Flags().BoolP("clean", "c", false, "Clean build directory before building")
 	cmd.Flags().StringP("opt", "O", "", "Override optimization level: 0,1,2,3,s,fast")
 	cmd.Flags().BoolP("watch", "w", false, "Watch for file changes and rebuild automatically")
 	cmd.Flags().Bool("verbose", false, "Show full build output")
+	cmd.Flags().Bool("unity", false, "Unity (jumbo) build: compile sources in batches (build.unity in cpx.yaml enables it permanently)")
+	cmd.Flags().Bool("strict-tools", false, "Fail if tool versions don't match "+config.ToolsFile)
+	cmd.Flags().Bool("timings", false, "Print build time and the slowest translation units (Ninja builds)")
+	cmd.Flags().Bool("timings-html", false, "Like --timings, and write an HTML timeline of the build")
+	cmd.Flags().String("configs", "", "Build several configurations in one run, e.g. debug,release")
+	cmd.Flags().String("toolchain", "", "Cross-compile with a toolchain file, or the target of one generated by 'cpx gen toolchain' (e.g. aarch64-linux-gnu)")
+	cmd.Flags().Bool("static", false, "Link a fully static executable with musl (Linux)")
+	cmd.Flags().String("compiler", "", "Compiler: clang, gcc or cl, optionally versioned (clang-17, gcc-13); overrides build.compiler in cpx.yaml")
+	cmd.Flags().String("diagnostics", "", "Write the compiler diagnostics to a file (SARIF if it ends in .sarif, JSON otherwise)")
 	// Sanitizer flags
 	cmd.Flags().Bool("asan", false, "Build with AddressSanitizer")
 	cmd.Flags().Bool("tsan", false, "Build with ThreadSanitizer")
@@ -48,7 +126,10 @@ func BuildCmd(client *vcpkg.Client) *cobra.Command {
 	return cmd
 }
 
-func runBuild(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
+// timingsTop is how many translation units cpx build --timings lists
+const timingsTop = 10
+
+func runBuild(cmd *cobra.Command, args []string, client *vcpkg.Client) (err error) {
 	release, _ := cmd.Flags().GetBool("release")
 	jobs, _ := cmd.Flags().GetInt("jobs")
 	target, _ := cmd.Flags().GetString("target")
@@ -56,6 +137,15 @@ func runBuild(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 	optLevel, _ := cmd.Flags().GetString("opt")
 	watch, _ := cmd.Flags().GetBool("watch")
 	verbose, _ := cmd.Flags().GetBool("verbose")
+	unity, _ := cmd.Flags().GetBool("unity")
+	strictTools, _ := cmd.Flags().GetBool("strict-tools")
+	timings, _ := cmd.Flags().GetBool("timings")
+	timingsHTML, _ := cmd.Flags().GetBool("timings-html")
+	diagnosticsFile, _ := cmd.Flags().GetString("diagnostics")
+	compilerName, _ := cmd.Flags().GetString("compiler")
+	configsFlag, _ := cmd.Flags().GetString("configs")
+	toolchainFlag, _ := cmd.Flags().GetString("toolchain")
+	static, _ := cmd.Flags().GetBool("static")
 
 	// Parse sanitizer flags
 	asan, _ := cmd.Flags().GetBool("asan")
@@ -86,39 +176,504 @@ func runBuild(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 		return fmt.Errorf("only one sanitizer can be used at a time (got %d)", sanitizerCount)
 	}
 
+	configs, err := parseBuildConfigs(configsFlag)
+	if err != nil {
+		return err
+	}
+	if len(configs) > 0 {
+		switch {
+		case release || optLevel != "":
+			return exitcode.Errorf(exitcode.Usage, "--configs cannot be combined with --release or --opt")
+		case watch:
+			return exitcode.Errorf(exitcode.Usage, "--configs cannot be combined with --watch")
+		case timings || timingsHTML:
+			return exitcode.Errorf(exitcode.Usage, "--configs cannot be combined with --timings")
+		}
+	}
+
+	if err := useInstalledToolchains(); err != nil {
+		return err
+	}
+	if err := checkToolVersions(strictTools); err != nil {
+		return err
+	}
+
 	projectType := DetectProjectType()
+	if err := verifyLockfile(projectType); err != nil {
+		return err
+	}
 
-	switch projectType {
-	case ProjectTypeBazel:
+	embedded := target == build.EmbeddedTarget && projectType != ProjectTypeBazel && projectType != ProjectTypeMeson
+	if embedded {
+		if _, err := os.Stat(templates.EmbeddedToolchainFile); err != nil {
+			return exitcode.Errorf(exitcode.Config, "%s not found\n  hint: --target embedded builds projects created with 'cpx new --template embedded'", templates.EmbeddedToolchainFile)
+		}
+		if sanitizer != "" {
+			return exitcode.Errorf(exitcode.Usage, "sanitizers are not supported for --target %s", build.EmbeddedTarget)
+		}
+		if compilerName != "" || toolchainFlag != "" || static {
+			return exitcode.Errorf(exitcode.Usage, "--compiler, --toolchain and --static are not supported for --target %s\n  hint: the toolchain file of the project selects the cross compiler", build.EmbeddedTarget)
+		}
+	}
+	var cross build.CrossToolchain
+	if static {
+		switch {
+		case toolchainFlag != "" || compilerName != "":
+			return exitcode.Errorf(exitcode.Usage, "--static cannot be combined with --toolchain or --compiler\n  hint: static builds use the musl compilers")
+		case sanitizer != "":
+			return exitcode.Errorf(exitcode.Usage, "sanitizers are not supported with --static")
+		case projectType == ProjectTypeBazel || projectType == ProjectTypeMeson:
+			return exitcode.Errorf(exitcode.Usage, "--static is supported for CMake projects\n  hint: build a static %s project in Docker with 'cpx ci build --target linux-amd64-musl'", projectType)
+		}
+		if cross, err = resolveStaticToolchain(); err != nil {
+			return err
+		}
+	}
+	if toolchainFlag != "" {
+		switch {
+		case compilerName != "":
+			return exitcode.Errorf(exitcode.Usage, "--compiler cannot be combined with --toolchain\n  hint: the toolchain file selects the compiler")
+		case sanitizer != "":
+			return exitcode.Errorf(exitcode.Usage, "sanitizers are not supported with --toolchain")
+		case projectType == ProjectTypeBazel:
+			return exitcode.Errorf(exitcode.Usage, "--toolchain is not supported for Bazel projects\n  hint: use Bazel platforms and toolchains (--platforms)")
+		}
+		if cross, err = resolveCrossToolchain(toolchainFlag, projectType); err != nil {
+			return err
+		}
+	}
+	var compiler build.Compiler
+	if !embedded && cross.Name == "" {
+		if compiler, err = resolveCompiler(compilerName); err != nil {
+			return err
+		}
+	}
+
+	if watch && diagnosticsFile != "" {
+		return exitcode.Errorf(exitcode.Usage, "--diagnostics cannot be combined with --watch")
+	}
+	var diagnostics *build.DiagnosticSet
+	if !watch {
+		diagnostics = build.CollectDiagnostics()
+		defer func() {
+			if logging.Enabled(logging.LevelNormal) {
+				diagnostics.PrintSummary()
+			}
+			if diagnosticsFile == "" {
+				return
+			}
+			if writeErr := diagnostics.WriteFile(diagnosticsFile); writeErr != nil {
+				logging.Warn("failed to write %s: %v", diagnosticsFile, writeErr)
+			} else {
+				logging.Info("  Diagnostics written to %s", diagnosticsFile)
+			}
+		}()
+	}
+
+	if output.JSON() {
 		if watch {
-			fmt.Printf("%sWatch mode not yet supported for Bazel projects%s\n", Yellow, Reset)
-			return nil
+			return exitcode.Errorf(exitcode.Usage, "--json cannot be combined with --watch")
+		}
+		buildSystem := "cmake"
+		if projectType == ProjectTypeBazel || projectType == ProjectTypeMeson {
+			buildSystem = string(projectType)
+		}
+		outputDirOf := func(release bool) string {
+			switch {
+			case embedded:
+				return build.EmbeddedOutputDir(release, optLevel)
+			case cross.Name != "":
+				return cross.OutputDir(release, optLevel)
+			case buildSystem != "cmake":
+				return build.OutputDir(release, optLevel, "", compiler.Name)
+			}
+			return build.OutputDir(release, optLevel, sanitizer, compiler.Name)
+		}
+		start := time.Now()
+		defer func() {
+			if err != nil {
+				return
+			}
+			result := buildResult{
+				Status:      "success",
+				BuildSystem: buildSystem,
+				Embedded:    embedded,
+				OutputDir:   outputDirOf(release),
+				Diagnostics: diagnostics.Diagnostics(),
+				Duration:    time.Since(start).Seconds(),
+			}
+			for _, name := range configs {
+				dir := outputDirOf(name == "release")
+				result.Configs = append(result.Configs, buildConfigResult{Config: name, OutputDir: dir, Artifacts: buildArtifacts(dir)})
+			}
+			if len(result.Configs) > 0 {
+				// The first configuration, for scripts reading output_dir
+				result.OutputDir = result.Configs[0].OutputDir
+			}
+			result.Artifacts = buildArtifacts(result.OutputDir)
+			if static {
+				result.Static = checkStaticArtifacts(result.OutputDir)
+			}
+			err = output.Print(result)
+		}()
+	}
+
+	if (timings || timingsHTML) && !watch {
+		var buildDir string
+		switch projectType {
+		case ProjectTypeBazel:
+			logging.Warn("--timings is not supported for Bazel projects; use bazel's --profile and 'bazel analyze-profile'")
+		case ProjectTypeMeson:
+			buildDir = mesonBuildDirFor(compiler, cross)
+		default:
+			buildDir = build.CMakeBuildDir(release, optLevel, sanitizer, compiler.Name)
+			if embedded {
+				buildDir = build.EmbeddedBuildDir(release, optLevel)
+			} else if cross.Name != "" {
+				buildDir = cross.BuildDir(release, optLevel)
+			}
+		}
+		if buildDir != "" {
+			defer func() {
+				if err != nil {
+					return
+				}
+				htmlPath := ""
+				if timingsHTML {
+					htmlPath = filepath.Join(buildDir, build.TimingsHTMLFile)
+				}
+				if reportErr := build.ReportTimings(buildDir, timingsTop, htmlPath); reportErr != nil {
+					logging.Warn("%v", reportErr)
+				}
+			}()
 		}
-		return runBazelBuild(release, target, clean, verbose, optLevel, sanitizer)
+	}
+
+	// buildConfig builds one configuration, debug or release
+	buildConfig := func(release, clean bool) error {
+		if embedded {
+			if watch {
+				return build.WatchAndRebuild(buildWatchConfig(projectType), func() error {
+					return build.BuildEmbedded(templates.EmbeddedToolchainFile, release, jobs, false, optLevel, verbose, unity)
+				})
+			}
+			return build.BuildEmbedded(templates.EmbeddedToolchainFile, release, jobs, clean, optLevel, verbose, unity)
+		}
+
+		switch projectType {
+		case ProjectTypeBazel:
+			if unity {
+				logging.Warn("unity builds are not supported for Bazel projects; ignoring --unity")
+			}
+			if watch {
+				return build.WatchAndRebuild(buildWatchConfig(projectType), func() error {
+					return runBazelBuild(release, target, false, verbose, optLevel, sanitizer, compiler)
+				})
+			}
+			return runBazelBuild(release, target, clean, verbose, optLevel, sanitizer, compiler)
+		case ProjectTypeMeson:
+			if watch {
+				return build.WatchAndRebuild(buildWatchConfig(projectType), func() error {
+					return runMesonBuild(release, target, false, verbose, optLevel, sanitizer, unity, compiler, cross)
+				})
+			}
+			return runMesonBuild(release, target, clean, verbose, optLevel, sanitizer, unity, compiler, cross)
+		case ProjectTypeVcpkg:
+			if watch {
+				return build.WatchAndBuild(release, jobs, target, optLevel, verbose, sanitizer, unity, compiler, cross, client)
+			}
+			return build.BuildProject(release, jobs, target, clean, optLevel, verbose, sanitizer, unity, compiler, cross, client)
+		default:
+			// Fall back to CMake build even without vcpkg.json
+			if watch {
+				return build.WatchAndBuild(release, jobs, target, optLevel, verbose, sanitizer, unity, compiler, cross, client)
+			}
+			return build.BuildProject(release, jobs, target, clean, optLevel, verbose, sanitizer, unity, compiler, cross, client)
+		}
+	}
+
+	for i, name := range configs {
+		logging.Step("Building configuration %s (%d/%d)...", name, i+1, len(configs))
+		if err := buildConfig(name == "release", clean); err != nil {
+			return fmt.Errorf("%s configuration: %w", name, err)
+		}
+		if static && !output.JSON() {
+			reportStaticArtifacts(cross.OutputDir(name == "release", optLevel))
+		}
+	}
+	if len(configs) > 0 {
+		return nil
+	}
+	if err := buildConfig(release, clean); err != nil {
+		return err
+	}
+	if static && !watch && !output.JSON() {
+		reportStaticArtifacts(cross.OutputDir(release, optLevel))
+	}
+	return nil
+}
+
+// buildResult is the output of cpx build --json
+type buildResult struct {
+	Status      string              `json:"status"`
+	BuildSystem string              `json:"build_system"`
+	Embedded    bool                `json:"embedded,omitempty"`
+	OutputDir   string              `json:"output_dir"`
+	Artifacts   []string            `json:"artifacts"`
+	Configs     []buildConfigResult `json:"configs,omitempty"`
+	Static      []staticArtifact    `json:"static,omitempty"`
+	Diagnostics []events.Diagnostic `json:"diagnostics"`
+	Duration    float64             `json:"duration"`
+}
+
+// buildConfigResult is a configuration built with cpx build --configs
+type buildConfigResult struct {
+	Config    string   `json:"config"`
+	OutputDir string   `json:"output_dir"`
+	Artifacts []string `json:"artifacts"`
+}
+
+// staticArtifact is an executable of cpx build --static --json and whether
+// it is fully static
+type staticArtifact struct {
+	Path      string   `json:"path"`
+	Static    bool     `json:"static"`
+	Libraries []string `json:"libraries,omitempty"`
+}
+
+// buildConfigs are the configurations cpx build --configs accepts
+var buildConfigs = []string{"debug", "release"}
+
+// parseBuildConfigs parses the comma-separated configurations of
+// cpx build --configs, dropping duplicates
+func parseBuildConfigs(value string) ([]string, error) {
+	var configs []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || slices.Contains(configs, name) {
+			continue
+		}
+		if !slices.Contains(buildConfigs, name) {
+			return nil, exitcode.Errorf(exitcode.Usage, "unknown build configuration %q\n  hint: --configs takes %s", name, strings.Join(buildConfigs, ","))
+		}
+		configs = append(configs, name)
+	}
+	return configs, nil
+}
+
+// buildArtifacts returns the files a build copied to outputDir
+func buildArtifacts(outputDir string) []string {
+	artifacts := []string{}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return artifacts
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			artifacts = append(artifacts, filepath.Join(outputDir, entry.Name()))
+		}
+	}
+	return artifacts
+}
+
+// mesonUnityOption returns the configured value of the unity option of a
+// Meson build directory ("on", "off" or "subprojects")
+func mesonUnityOption(buildDir string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(buildDir, "meson-info", "intro-buildoptions.json"))
+	if err != nil {
+		return "", false
+	}
+	var options []struct {
+		Name  string `json:"name"`
+		Value any    `json:"value"`
+	}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return "", false
+	}
+	for _, opt := range options {
+		if opt.Name == "unity" {
+			value, ok := opt.Value.(string)
+			return value, ok
+		}
+	}
+	return "", false
+}
+
+// buildWatchConfig returns the watch configuration for a project type.
+// Bazel and Meson projects also rebuild when their build files change, in
+// the watched directories or the project root.
+func buildWatchConfig(projectType ProjectType) *build.WatchConfig {
+	config := build.DefaultWatchConfig()
+	switch projectType {
+	case ProjectTypeBazel:
+		config.Names = []string{"BUILD", "BUILD.bazel", "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel", ".bazelrc"}
 	case ProjectTypeMeson:
-		if watch {
-			fmt.Printf("%sWatch mode not yet supported for Meson projects%s\n", Yellow, Reset)
-			return nil
+		config.Names = []string{"meson.build", "meson_options.txt"}
+	}
+	return config
+}
+
+// compilerToolchains maps compiler families to the toolchains providing them
+var compilerToolchains = map[string]string{"clang": "llvm", "gcc": "gcc"}
+
+// resolveCompiler returns the compiler selected with --compiler (name) or
+// build.compiler in cpx.yaml, with the full paths of its C and C++
+// compilers: from PATH, or from a toolchain installed with
+// 'cpx toolchain install'. The zero Compiler means the build system's
+// default.
+func resolveCompiler(name string) (build.Compiler, error) {
+	if name == "" {
+		opts, err := build.ProjectOptions()
+		if err != nil {
+			return build.Compiler{}, err
 		}
-		return runMesonBuild(release, target, clean, verbose, optLevel, sanitizer)
-	case ProjectTypeVcpkg:
-		if watch {
-			return build.WatchAndBuild(release, jobs, target, optLevel, verbose, sanitizer, client)
+		name = opts.Compiler
+	}
+	if name == "" {
+		return build.Compiler{}, nil
+	}
+	compiler, err := build.ParseCompiler(name)
+	if err != nil {
+		return build.Compiler{}, exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	cc, ccErr := execLookPath(compiler.CC)
+	cxx, cxxErr := execLookPath(compiler.CXX)
+	if ccErr == nil && cxxErr == nil {
+		compiler.CC, compiler.CXX = cc, cxx
+		return compiler, nil
+	}
+	family, version := compiler.Family()
+	if tool, ok := compilerToolchains[family]; ok {
+		if cc, cxx, found := toolchain.FindCompiler(tool, version); found {
+			compiler.CC, compiler.CXX = cc, cxx
+			return compiler, nil
 		}
-		return build.BuildProject(release, jobs, target, clean, optLevel, verbose, sanitizer, client)
-	default:
-		// Fall back to CMake build even without vcpkg.json
-		if watch {
-			return build.WatchAndBuild(release, jobs, target, optLevel, verbose, sanitizer, client)
+		if version == "" {
+			version = "<version>"
+		}
+		return build.Compiler{}, exitcode.Errorf(exitcode.ToolchainMissing, "compiler %s not found: %s is not in PATH\n  hint: install it, or run 'cpx toolchain install %s %s'", name, compiler.CXX, tool, version)
+	}
+	return build.Compiler{}, exitcode.Errorf(exitcode.ToolchainMissing, "compiler %s not found: %s is not in PATH\n  hint: run cpx from a Visual Studio developer command prompt", name, compiler.CXX)
+}
+
+// resolveCrossToolchain returns the cross toolchain of cpx build
+// --toolchain: a toolchain file (CMake) or cross file (Meson), or the target
+// triple of one written by 'cpx gen toolchain'
+func resolveCrossToolchain(value string, projectType ProjectType) (build.CrossToolchain, error) {
+	path := value
+	if _, err := os.Stat(path); err != nil {
+		path = crossFilePath(value, projectType)
+		if _, err := os.Stat(path); err != nil {
+			return build.CrossToolchain{}, exitcode.Errorf(exitcode.Config, "toolchain %s not found (no file %s or %s)\n  hint: generate it with 'cpx gen toolchain --target %s'", value, value, path, value)
 		}
-		return build.BuildProject(release, jobs, target, clean, optLevel, verbose, sanitizer, client)
 	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return build.CrossToolchain{}, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	cross := build.CrossToolchain{Name: name, File: abs}
+	if target, err := templates.ParseCrossTarget(name); err == nil {
+		cross.VcpkgTriplet = target.VcpkgTriplet()
+	}
+	return cross, nil
+}
+
+// muslLoaderGlob matches the dynamic loader of a musl host (e.g. Alpine)
+var muslLoaderGlob = "/lib/ld-musl-*"
+
+// resolveStaticToolchain returns the toolchain of cpx build --static: the
+// compilers of a musl host, or the musl cross compilers of the host
+// architecture
+func resolveStaticToolchain() (build.CrossToolchain, error) {
+	triple := build.MuslTriple(runtime.GOARCH)
+	if runtime.GOOS != "linux" || triple == "" {
+		return build.CrossToolchain{}, exitcode.Errorf(exitcode.Usage, "--static builds are supported on Linux amd64 and arm64 hosts\n  hint: build in Docker with 'cpx ci build --target linux-amd64-musl'")
+	}
+	candidates := [][2]string{{triple + "-gcc", triple + "-g++"}}
+	if loaders, _ := filepath.Glob(muslLoaderGlob); len(loaders) > 0 {
+		candidates = append([][2]string{{"gcc", "g++"}}, candidates...)
+	}
+	for _, c := range candidates {
+		cc, ccErr := execLookPath(c[0])
+		cxx, cxxErr := execLookPath(c[1])
+		if ccErr == nil && cxxErr == nil {
+			return build.StaticToolchain(cc, cxx, runtime.GOARCH)
+		}
+	}
+	return build.CrossToolchain{}, exitcode.Errorf(exitcode.ToolchainMissing, "no musl compiler found: %s-g++ is not in PATH\n  hint: install a musl cross toolchain (e.g. from https://musl.cc), or build in Docker with 'cpx ci build --target linux-amd64-musl'", triple)
+}
+
+// checkStaticArtifacts checks with file and ldd whether the executables in
+// dir are fully static. Executables neither tool can tell about are left out.
+func checkStaticArtifacts(dir string) []staticArtifact {
+	results := []staticArtifact{}
+	for _, path := range buildArtifacts(dir) {
+		if !isExecutableELF(path) {
+			continue
+		}
+		fileOutput, _ := execCommand("file", "-b", path).Output()
+		// ldd exits non-zero for static executables
+		lddOutput, _ := execCommand("ldd", path).CombinedOutput()
+		linkage, ok := build.ParseLinkage(string(fileOutput), string(lddOutput))
+		if !ok {
+			continue
+		}
+		results = append(results, staticArtifact{Path: path, Static: linkage.Static, Libraries: linkage.Libraries})
+	}
+	return results
+}
+
+// reportStaticArtifacts prints whether the executables of a static build
+// are fully static
+func reportStaticArtifacts(dir string) {
+	results := checkStaticArtifacts(dir)
+	if len(results) == 0 {
+		logging.Warn("could not verify the static build: install file or ldd")
+		return
+	}
+	for _, result := range results {
+		name := filepath.Base(result.Path)
+		if result.Static {
+			logging.Success("%s %s is fully static", IconSuccess, name)
+		} else {
+			logging.Warn("%s is not fully static: it loads %s", name, strings.Join(result.Libraries, ", "))
+		}
+	}
+}
+
+// isExecutableELF reports whether path is an executable ELF file
+func isExecutableELF(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Mode()&0111 == 0 {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return string(magic) == "\x7fELF"
+}
+
+// crossFilePath returns the path cpx gen toolchain writes the toolchain of
+// a target triple to
+func crossFilePath(triple string, projectType ProjectType) string {
+	if projectType == ProjectTypeMeson {
+		return filepath.Join(templates.CrossFileDir, triple+".ini")
+	}
+	return filepath.Join(templates.CrossToolchainDir, triple+".cmake")
 }
 
-func runBazelBuild(release bool, target string, clean bool, verbose bool, optLevel string, sanitizer string) error {
+func runBazelBuild(release bool, target string, clean bool, verbose bool, optLevel string, sanitizer string, compiler build.Compiler) error {
 	// Clean if requested
 	if clean {
-		fmt.Printf("%sCleaning Bazel build...%s\n", Cyan, Reset)
+		logging.Step("Cleaning Bazel build...")
 		cleanCmd := execCommand("bazel", "clean")
 		cleanCmd.Stdout = os.Stdout
 		cleanCmd.Stderr = os.Stderr
@@ -131,6 +686,10 @@ func runBazelBuild(release bool, target string, clean bool, verbose bool, optLev
 
 	// Build args
 	bazelArgs := []string{"build"}
+	if _, err := os.Stat(config.LockFile); err == nil {
+		// Fail instead of updating MODULE.bazel.lock behind cpx lock's back
+		bazelArgs = append(bazelArgs, "--lockfile_mode=error")
+	}
 
 	// Handle optimization level - optLevel takes precedence over release flag
 	optLabel := "debug"
@@ -184,6 +743,11 @@ func runBazelBuild(release bool, target string, clean bool, verbose bool, optLev
 		}
 	}
 
+	if compiler.Name != "" {
+		bazelArgs = append(bazelArgs, compiler.BazelArgs()...)
+		optLabel += ", " + compiler.Name
+	}
+
 	// Add target or default to //...
 	if target != "" {
 		bazelArgs = append(bazelArgs, target)
@@ -191,31 +755,26 @@ func runBazelBuild(release bool, target string, clean bool, verbose bool, optLev
 		bazelArgs = append(bazelArgs, "//...")
 	}
 
-	fmt.Printf("%sBuilding with Bazel [%s]...%s\n", Cyan, optLabel, Reset)
-	if verbose {
-		fmt.Printf("  Running: bazel %v\n", bazelArgs)
-	} else {
+	events.Phase("build")
+	logging.Step("Building with Bazel [%s]...", optLabel)
+	logging.Verbose("  Running: bazel %v", bazelArgs)
+	switch {
+	case build.ProgressUIEnabled(verbose):
+		// Report progress as "[done / total]" lines for the progress view
+		bazelArgs = append(bazelArgs, "--curses=no", "--color=no", "--show_progress_rate_limit=0.2", "--symlink_prefix=.bazel-")
+	case !verbose:
 		// Suppress progress bars for cleaner output (like vcpkg)
 		// Use hidden symlinks (.bazel-bin, .bazel-out, etc.)
 		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
 	}
 
 	buildCmd := execCommand("bazel", bazelArgs...)
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
-
-	if err := buildCmd.Run(); err != nil {
+	if err := build.RunBuildCommand(buildCmd, verbose, "Compiling"); err != nil {
 		return fmt.Errorf("bazel build failed: %w", err)
 	}
 
-	// Determine output directory based on config
-	outDirName := "debug"
-	if optLevel != "" {
-		outDirName = "O" + optLevel
-	} else if release {
-		outDirName = "release"
-	}
-	outputDir := filepath.Join(".bin", "native", outDirName)
+	// Determine output directory based on config; sanitized builds share it
+	outputDir := build.OutputDir(release, optLevel, "", compiler.Name)
 
 	// Copy artifacts to build/<config>/ directory
 	// Remove existing build artifacts for this config first
@@ -225,7 +784,7 @@ func runBazelBuild(release bool, target string, clean bool, verbose bool, optLev
 	}
 
 	// Copy executables and libraries from bazel-bin to build/<config>/
-	fmt.Printf("%sCopying artifacts to %s/...%s\n", Cyan, outputDir, Reset)
+	logging.Step("Copying artifacts to %s/...", outputDir)
 
 	// Create a script to copy with the correct output directory variable
 	script := fmt.Sprintf(`
@@ -265,13 +824,73 @@ func runBazelBuild(release bool, target string, clean bool, verbose bool, optLev
 	copyCmd.Stderr = os.Stderr
 	copyCmd.Run() // Ignore errors - may have no artifacts
 
-	fmt.Printf("%s✓ Build successful%s\n", Green, Reset)
+	// Bazel has no compilation database of its own; export one for clangd
+	if _, err := generateBazelCompileDatabase("//...", "compile_commands.json", verbose); err != nil {
+		logging.Warn("could not export compile_commands.json: %v", err)
+	}
+
+	logging.Success("✓ Build successful")
 	fmt.Printf("  Artifacts in: %s/\n", outputDir)
 	return nil
 }
 
-func runMesonBuild(release bool, target string, clean bool, verbose bool, optLevel string, sanitizer string) error {
-	buildDir := "builddir"
+// mesonBuildDir is the Meson build directory used by cpx
+const mesonBuildDir = "builddir"
+
+// mesonBuildDirFor returns the Meson build directory of a build: builds
+// with --compiler or --toolchain use their own (e.g. builddir-clang-17,
+// builddir-aarch64-linux-gnu)
+func mesonBuildDirFor(compiler build.Compiler, cross build.CrossToolchain) string {
+	switch {
+	case cross.Name != "":
+		return mesonBuildDir + "-" + cross.Name
+	case compiler.Name != "":
+		return mesonBuildDir + "-" + compiler.Name
+	}
+	return mesonBuildDir
+}
+
+// mesonNativeFile is the Meson native file cpx writes to the build directory
+// of a build with --compiler
+const mesonNativeFile = "cpx-native.ini"
+
+// ensureMesonCompileDatabase runs `meson setup` if the build directory has no
+// compile_commands.json yet, and links it into the project root
+func ensureMesonCompileDatabase() error {
+	if _, err := os.Stat(filepath.Join(mesonBuildDir, "compile_commands.json")); os.IsNotExist(err) {
+		if _, err := execLookPath("meson"); err != nil {
+			return exitcode.Errorf(exitcode.ToolchainMissing, "meson not found in PATH: %w", err)
+		}
+		logging.Step("  Generating compile_commands.json (meson setup)...")
+		setupArgs := []string{"setup", mesonBuildDir}
+		if _, err := os.Stat(mesonBuildDir); err == nil {
+			setupArgs = append(setupArgs, "--reconfigure")
+		}
+		setupCmd := execCommand("meson", setupArgs...)
+		setupCmd.Stdout = os.Stdout
+		setupCmd.Stderr = os.Stderr
+		if err := setupCmd.Run(); err != nil {
+			return exitcode.Errorf(exitcode.BuildFailed, "meson setup failed: %w", err)
+		}
+	}
+	return build.LinkCompileDatabase(mesonBuildDir)
+}
+
+// runMesonBuild sets up (or reconfigures) the build directory of the
+// compiler or cross file and compiles. unity enables a unity build
+// regardless of build.unity in cpx.yaml.
+func runMesonBuild(release bool, target string, clean bool, verbose bool, optLevel string, sanitizer string, unity bool, compiler build.Compiler, cross build.CrossToolchain) error {
+	buildDir := mesonBuildDirFor(compiler, cross)
+
+	opts, err := build.ProjectOptions()
+	if err != nil {
+		return err
+	}
+	unity = unity || opts.Unity
+	unityArg := "-Dunity=off"
+	if unity {
+		unityArg = "-Dunity=on"
+	}
 
 	// Determine build type and optimization from flags
 	buildType := "debug"
@@ -321,23 +940,47 @@ func runMesonBuild(release bool, target string, clean bool, verbose bool, optLev
 	if sanitizer != "" {
 		optLabel += "+" + sanitizer
 	}
+	if compiler.Name != "" {
+		optLabel += ", " + compiler.Name
+	}
+	if cross.Name != "" {
+		optLabel += ", " + cross.Name
+	}
 
 	// Clean if requested or if optimization changed
 	if clean {
-		fmt.Printf("%sCleaning Meson build...%s\n", Cyan, Reset)
+		logging.Step("Cleaning Meson build...")
 		os.RemoveAll(buildDir)
 	}
 
+	// A fresh setup or toggling unity builds recompiles everything
+	fullBuild := false
+
 	// Check if build directory exists (needs setup)
 	if _, err := os.Stat(buildDir); os.IsNotExist(err) {
-		fmt.Printf("%sSetting up Meson build directory [%s]...%s\n", Cyan, optLabel, Reset)
+		fullBuild = true
+		logging.Step("Setting up Meson build directory [%s]...", optLabel)
 		setupArgs := []string{"setup", buildDir}
 		setupArgs = append(setupArgs, "--buildtype="+buildType)
-		setupArgs = append(setupArgs, "--optimization="+optimization)
+		setupArgs = append(setupArgs, "--optimization="+optimization, unityArg)
 		if optLevel == "fast" {
 			// Add -ffast-math for -Ofast equivalent
 			setupArgs = append(setupArgs, "-Dc_args=-ffast-math", "-Dcpp_args=-ffast-math")
 		}
+		if compiler.Name != "" {
+			// Meson picks the compilers at setup, from a native file
+			if err := os.MkdirAll(buildDir, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", buildDir, err)
+			}
+			nativeFile := filepath.Join(buildDir, mesonNativeFile)
+			if err := os.WriteFile(nativeFile, []byte(compiler.MesonNativeFile()), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", nativeFile, err)
+			}
+			setupArgs = append(setupArgs, "--native-file", nativeFile)
+		}
+		if cross.File != "" {
+			setupArgs = append(setupArgs, "--cross-file", cross.File)
+		}
 		setupCmd := execCommand("meson", setupArgs...)
 		setupCmd.Stdout = os.Stdout
 		setupCmd.Stderr = os.Stderr
@@ -346,10 +989,13 @@ func runMesonBuild(release bool, target string, clean bool, verbose bool, optLev
 		}
 	} else {
 		// Build directory exists, reconfigure if optimization changed
-		fmt.Printf("%sReconfiguring Meson [%s]...%s\n", Cyan, optLabel, Reset)
+		if current, ok := mesonUnityOption(buildDir); ok && (current == "on") != unity {
+			fullBuild = true
+		}
+		logging.Step("Reconfiguring Meson [%s]...", optLabel)
 		reconfigArgs := []string{"configure", buildDir}
 		reconfigArgs = append(reconfigArgs, "--buildtype="+buildType)
-		reconfigArgs = append(reconfigArgs, "--optimization="+optimization)
+		reconfigArgs = append(reconfigArgs, "--optimization="+optimization, unityArg)
 		if optLevel == "fast" {
 			reconfigArgs = append(reconfigArgs, "-Dc_args=-ffast-math", "-Dcpp_args=-ffast-math")
 		}
@@ -360,8 +1006,13 @@ func runMesonBuild(release bool, target string, clean bool, verbose bool, optLev
 		reconfigCmd.Run()
 	}
 
+	if err := build.LinkCompileDatabase(buildDir); err != nil {
+		logging.Warn("%v", err)
+	}
+
 	// Build
-	fmt.Printf("%sBuilding with Meson...%s\n", Cyan, Reset)
+	events.Phase("build")
+	logging.Step("Building with Meson...")
 	compileArgs := []string{"compile", "-C", buildDir}
 	if target != "" {
 		compileArgs = append(compileArgs, target)
@@ -370,49 +1021,48 @@ func runMesonBuild(release bool, target string, clean bool, verbose bool, optLev
 		compileArgs = append(compileArgs, "-v")
 	}
 	buildCmd := execCommand("meson", compileArgs...)
-	buildCmd.Stdout = os.Stdout
-	buildCmd.Stderr = os.Stderr
 
-	if err := buildCmd.Run(); err != nil {
+	buildStart := time.Now()
+	if err := build.RunBuildCommand(buildCmd, verbose, "Compiling"); err != nil {
 		return fmt.Errorf("meson compile failed: %w", err)
 	}
+	if fullBuild && target == "" {
+		build.ReportFullBuild("meson", unity, time.Since(buildStart))
+	}
 
-	// Determine output directory based on config
-	outDirName := "debug"
-	if optLevel != "" {
-		outDirName = "O" + optLevel
-	} else if release {
-		outDirName = "release"
+	// Determine output directory based on config; sanitized builds share it
+	outputDir := build.OutputDir(release, optLevel, "", compiler.Name)
+	if cross.Name != "" {
+		outputDir = cross.OutputDir(release, optLevel)
 	}
-	outputDir := filepath.Join(".bin", "native", outDirName)
 
 	// Copy artifacts to output directory
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	fmt.Printf("%sCopying artifacts to %s/...%s\n", Cyan, outputDir, Reset)
+	logging.Step("Copying artifacts to %s/...", outputDir)
 	copyCmd := execCommand("bash", "-c", fmt.Sprintf(`
 		# Meson places executables in subdirectories (src/, bench/, etc.)
 		# Search in builddir/src/ first (main executables)
-		if [ -d "builddir/src" ]; then
-			find builddir/src -maxdepth 1 -type f -perm +111 ! -name "*.p" ! -name "*_test" -exec cp {} %[1]s/ \; 2>/dev/null || true
+		if [ -d "%[2]s/src" ]; then
+			find %[2]s/src -maxdepth 1 -type f -perm +111 ! -name "*.p" ! -name "*_test" -exec cp {} %[1]s/ \; 2>/dev/null || true
 		fi
 
 		# Also check builddir root for executables
-		find builddir -maxdepth 1 -type f -perm +111 ! -name "*.p" ! -name "*_test" -exec cp {} %[1]s/ \; 2>/dev/null || true
+		find %[2]s -maxdepth 1 -type f -perm +111 ! -name "*.p" ! -name "*_test" -exec cp {} %[1]s/ \; 2>/dev/null || true
 
 		# Copy libraries from builddir and subdirectories
-		find builddir -maxdepth 2 -type f \( -name "*.a" -o -name "*.so" -o -name "*.dylib" \) -exec cp {} %[1]s/ \; 2>/dev/null || true
+		find %[2]s -maxdepth 2 -type f \( -name "*.a" -o -name "*.so" -o -name "*.dylib" \) -exec cp {} %[1]s/ \; 2>/dev/null || true
 
 		# List what was copied
 		ls %[1]s/ 2>/dev/null || true
-	`, outputDir))
+	`, outputDir, buildDir))
 	copyCmd.Stdout = os.Stdout
 	copyCmd.Stderr = os.Stderr
 	copyCmd.Run()
 
-	fmt.Printf("%s✓ Build successful%s\n", Green, Reset)
+	logging.Success("✓ Build successful")
 	fmt.Printf("  Artifacts in: %s/\n", outputDir)
 	return nil
 }
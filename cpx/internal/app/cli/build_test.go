@@ -3,16 +3,23 @@ package cli
 import (
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestRunBazelBuild(t *testing.T) {
 	// Mock execCommand
-	oldExecCommand := execCommand
-	defer func() { execCommand = oldExecCommand }()
+	oldExecCommand, oldLookPath := execCommand, execLookPath
+	defer func() { execCommand, execLookPath = oldExecCommand, oldLookPath }()
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
 
 	var capturedArgs [][]string
 
@@ -97,7 +104,7 @@ func TestRunBazelBuild(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			capturedArgs = nil
-			err := runBazelBuild(tt.release, tt.target, tt.clean, tt.verbose, "", tt.sanitizer)
+			err := runBazelBuild(tt.release, tt.target, tt.clean, tt.verbose, "", tt.sanitizer, build.Compiler{})
 			assert.NoError(t, err)
 
 			// Check that bazel build was called
@@ -138,4 +145,206 @@ func TestRunBazelBuild(t *testing.T) {
 			}
 		})
 	}
+
+	// The build exports compile_commands.json for clangd
+	data, err := os.ReadFile("compile_commands.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "src/main.cpp")
+}
+
+func TestBuildArtifacts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), ".bin", "native", "debug")
+	assert.Empty(t, buildArtifacts(dir))
+	writeFiles(t, dir, map[string]string{
+		"app":        "",
+		"libapp.a":   "",
+		"obj/main.o": "",
+	})
+	assert.Equal(t, []string{filepath.Join(dir, "app"), filepath.Join(dir, "libapp.a")}, buildArtifacts(dir))
+}
+
+func TestBuildWatchConfigRootBuildFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{
+		"meson.build":   "project('app', 'cpp')\n",
+		"src/main.cpp":  "int main() {}\n",
+		"src/app.cpp":   "",
+		"docs/notes.md": "",
+	})
+	config := buildWatchConfig(ProjectTypeMeson)
+	before, err := build.TakeSnapshot(config)
+	require.NoError(t, err)
+
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes("meson.build", later, later))
+	after, err := build.TakeSnapshot(config)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"meson.build"}, build.DetectChanges(before, after), "touching ./meson.build rebuilds")
+}
+
+func TestResolveCompiler(t *testing.T) {
+	oldExecLookPath := execLookPath
+	defer func() { execLookPath = oldExecLookPath }()
+	execLookPath = func(file string) (string, error) {
+		if file == "clang-17" || file == "clang++-17" {
+			return "/usr/bin/" + file, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	t.Setenv("HOME", t.TempDir())
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	compiler, err := resolveCompiler("")
+	require.NoError(t, err)
+	assert.Equal(t, build.Compiler{}, compiler)
+
+	compiler, err = resolveCompiler("clang-17")
+	require.NoError(t, err)
+	assert.Equal(t, build.Compiler{Name: "clang-17", CC: "/usr/bin/clang-17", CXX: "/usr/bin/clang++-17"}, compiler)
+
+	// build.compiler in cpx.yaml, unless --compiler overrides it
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("build:\n  compiler: gcc-13\n"), 0644))
+	_, err = resolveCompiler("")
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(err))
+	assert.ErrorContains(t, err, "cpx toolchain install gcc 13")
+
+	// Otherwise an installed toolchain provides it
+	gccBin := filepath.Join(os.Getenv("HOME"), ".config", "cpx", "toolchains", "gcc", "13.3.0-1", "bin")
+	require.NoError(t, os.MkdirAll(gccBin, 0755))
+	compiler, err = resolveCompiler("")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(gccBin, "g++"), compiler.CXX)
+
+	_, err = resolveCompiler("msvc")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestResolveStaticToolchain(t *testing.T) {
+	triple := build.MuslTriple(runtime.GOARCH)
+	if runtime.GOOS != "linux" || triple == "" {
+		t.Skip("static builds need a Linux amd64 or arm64 host")
+	}
+	oldExecLookPath := execLookPath
+	oldMuslLoaderGlob := muslLoaderGlob
+	defer func() {
+		execLookPath = oldExecLookPath
+		muslLoaderGlob = oldMuslLoaderGlob
+	}()
+	var available []string
+	execLookPath = func(file string) (string, error) {
+		for _, name := range available {
+			if file == name {
+				return "/usr/bin/" + file, nil
+			}
+		}
+		return "", exec.ErrNotFound
+	}
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	muslLoaderGlob = filepath.Join(t.TempDir(), "ld-musl-*")
+
+	// gcc of a glibc host doesn't link against musl
+	available = []string{"gcc", "g++"}
+	_, err = resolveStaticToolchain()
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(err))
+	assert.ErrorContains(t, err, "cpx ci build --target linux-amd64-musl")
+
+	available = append(available, triple+"-gcc", triple+"-g++")
+	toolchain, err := resolveStaticToolchain()
+	require.NoError(t, err)
+	assert.Equal(t, build.StaticName, toolchain.Name)
+	data, err := os.ReadFile(toolchain.File)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "/usr/bin/"+triple+"-g++")
+
+	// A musl host (Alpine) builds with its own compilers
+	require.NoError(t, os.WriteFile(strings.Replace(muslLoaderGlob, "*", "x86_64.so.1", 1), nil, 0755))
+	toolchain, err = resolveStaticToolchain()
+	require.NoError(t, err)
+	data, err = os.ReadFile(toolchain.File)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "set(CMAKE_CXX_COMPILER /usr/bin/g++)")
+
+	// Static builds are for CMake projects
+	require.NoError(t, os.WriteFile("MODULE.bazel", []byte(`module(name = "app")`), 0644))
+	cmd := BuildCmd(nil)
+	cmd.SetArgs([]string{"--static"})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+}
+
+func TestCheckStaticArtifacts(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	dir := t.TempDir()
+	elf := []byte("\x7fELF\x02\x01\x01")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app"), elf, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tool"), elf, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "libapp.a"), []byte("!<arch>\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\n"), 0755))
+
+	assert.Equal(t, []staticArtifact{
+		{Path: filepath.Join(dir, "app"), Static: true},
+		{Path: filepath.Join(dir, "tool"), Libraries: []string{"libstdc++.so.6"}},
+	}, checkStaticArtifacts(dir))
+}
+
+func TestParseBuildConfigs(t *testing.T) {
+	configs, err := parseBuildConfigs("debug, Release,debug")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"debug", "release"}, configs)
+
+	configs, err = parseBuildConfigs("")
+	require.NoError(t, err)
+	assert.Empty(t, configs)
+
+	_, err = parseBuildConfigs("debug,profile")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestBuildConfigs(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	t.Setenv("HOME", t.TempDir())
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	require.NoError(t, os.WriteFile("MODULE.bazel", []byte(`module(name = "app")`), 0644))
+
+	cmd := BuildCmd(nil)
+	cmd.SetArgs([]string{"--configs", "debug,release"})
+	require.NoError(t, cmd.Execute())
+	var built []string
+	for _, args := range capturedArgs {
+		if args[0] == "bazel" && args[1] == "build" {
+			built = append(built, args[2])
+		}
+	}
+	assert.Equal(t, []string{"--config=debug", "--config=release"}, built)
+	assert.DirExists(t, filepath.Join(".bin", "native", "debug"))
+	assert.DirExists(t, filepath.Join(".bin", "native", "release"))
+
+	cmd = BuildCmd(nil)
+	cmd.SetArgs([]string{"--configs", "debug,release", "--release"})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
 }
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/spf13/cobra"
+)
+
+// BundleCmd creates the bundle command
+func BundleCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bundle",
+		Short: "Export and import dependencies for offline (air-gapped) builds",
+		Long: `Export and import the vcpkg dependencies of a project for offline builds.
+
+'cpx bundle export' installs the dependencies of vcpkg.json and captures the
+source downloads, the binary cache and the registry data in one archive.
+'cpx bundle import' unpacks such an archive into ` + vcpkg.BundleDir + `; while it
+exists, builds take their dependencies from it instead of the network.
+
+Binary packages are only reused on machines with the same triplet and
+compiler; otherwise vcpkg rebuilds them from the bundled downloads.`,
+	}
+
+	exportCmd := &cobra.Command{
+		Use:     "export <file>",
+		Short:   "Export the project's vcpkg dependencies into an archive",
+		Example: "  cpx bundle export deps.tar\n  cpx bundle export deps.tar.gz",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBundleExport(args[0], client)
+		},
+	}
+	cmd.AddCommand(exportCmd)
+
+	importCmd := &cobra.Command{
+		Use:     "import <file>",
+		Short:   "Import a dependency archive for offline builds",
+		Example: "  cpx bundle import deps.tar",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBundleImport(args[0], client)
+		},
+	}
+	cmd.AddCommand(importCmd)
+
+	return cmd
+}
+
+// requireVcpkgManifest fails unless the current directory is a vcpkg project
+func requireVcpkgManifest() error {
+	if _, err := os.Stat("vcpkg.json"); err != nil {
+		return exitcode.Errorf(exitcode.Config, "vcpkg.json not found\n  hint: dependency bundles are only supported for CMake + vcpkg projects; run from the project root")
+	}
+	return nil
+}
+
+func runBundleExport(path string, client *vcpkg.Client) error {
+	if err := requireVcpkgManifest(); err != nil {
+		return err
+	}
+	manifest, err := vcpkg.NewBundleManifest("vcpkg.json")
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	if err := client.SetupEnv(); err != nil {
+		return err
+	}
+
+	staging, err := os.MkdirTemp("", "cpx-bundle-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	// Install into the staging directory so every download, binary package
+	// and registry checkout the manifest needs ends up there
+	for _, sub := range []string{vcpkg.BundleDownloads, vcpkg.BundleBinary, vcpkg.BundleRegistries} {
+		if err := os.MkdirAll(filepath.Join(staging, sub), 0755); err != nil {
+			return fmt.Errorf("failed to create staging directory: %w", err)
+		}
+	}
+	env := vcpkg.BundleEnv(staging)
+	env["VCPKG_BINARY_SOURCES"] = "clear;files," + filepath.Join(staging, vcpkg.BundleBinary) + ",readwrite"
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+
+	logging.Step("Installing dependencies from vcpkg.json...")
+	if err := client.RunCommand([]string{"install", "--x-install-root=" + filepath.Join(staging, "installed")}); err != nil {
+		return exitcode.Errorf(exitcode.BuildFailed, "vcpkg install failed: %v\n  hint: the export machine needs network access to fetch the dependencies", err)
+	}
+
+	if err := vcpkg.WriteBundle(path, staging, manifest); err != nil {
+		return err
+	}
+
+	size := ""
+	if info, err := os.Stat(path); err == nil {
+		size = fmt.Sprintf(" (%.1f MB)", float64(info.Size())/(1<<20))
+	}
+	logging.Success("%s Exported dependency bundle to %s%s", IconSuccess, path, size)
+	fmt.Printf("  Copy it to the offline machine and run: cpx bundle import %s\n", filepath.Base(path))
+	return nil
+}
+
+func runBundleImport(path string, client *vcpkg.Client) error {
+	if err := requireVcpkgManifest(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return exitcode.Errorf(exitcode.Usage, "bundle %s not found", path)
+	}
+
+	if err := os.RemoveAll(vcpkg.BundleDir); err != nil {
+		return fmt.Errorf("failed to remove previous bundle: %w", err)
+	}
+	manifest, err := vcpkg.ExtractBundle(path, vcpkg.BundleDir)
+	if err != nil {
+		os.RemoveAll(vcpkg.BundleDir)
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	logging.Success("%s Imported dependency bundle into %s", IconSuccess, vcpkg.BundleDir)
+	fmt.Printf("  %sExported %s%s\n", Dim, manifest.Created.Local().Format("2006-01-02 15:04"), Reset)
+
+	if ok, err := manifest.Matches("vcpkg.json"); err == nil && !ok {
+		logging.Warn("vcpkg.json changed since the bundle was exported; new dependencies will need network access")
+	}
+	if manifest.Baseline != "" && !vcpkgHasBaseline(client, manifest.Baseline) {
+		logging.Warn("the vcpkg checkout does not contain baseline %s; copy a vcpkg clone that does to this machine", manifest.Baseline)
+	}
+
+	fmt.Printf("  Builds now use the bundle. Remove %s to fetch dependencies online again.\n", vcpkg.BundleDir)
+	return nil
+}
+
+// vcpkgHasBaseline reports whether the vcpkg checkout contains the baseline
+// commit. Returns true if it can't tell (e.g. vcpkg is not configured yet).
+func vcpkgHasBaseline(client *vcpkg.Client, baseline string) bool {
+	if client == nil {
+		return true
+	}
+	vcpkgPath, err := client.GetPath()
+	if err != nil {
+		return true
+	}
+	cmd := execCommand("git", "-C", filepath.Dir(vcpkgPath), "cat-file", "-e", baseline+"^{commit}")
+	return cmd.Run() == nil
+}
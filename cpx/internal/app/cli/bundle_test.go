@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunBundleImport(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// Not a vcpkg project
+	err = runBundleImport("deps.tar", nil)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app"}`), 0644))
+	err = runBundleImport("deps.tar", nil)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+
+	staging := filepath.Join(tmpDir, "staging")
+	require.NoError(t, os.MkdirAll(filepath.Join(staging, vcpkg.BundleDownloads), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(staging, vcpkg.BundleDownloads, "fmt.tar.gz"), []byte("source"), 0644))
+	manifest, err := vcpkg.NewBundleManifest("vcpkg.json")
+	require.NoError(t, err)
+	require.NoError(t, vcpkg.WriteBundle("deps.tar", staging, manifest))
+
+	// A stale file from a previous import is removed
+	require.NoError(t, os.MkdirAll(vcpkg.BundleDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(vcpkg.BundleDir, "stale"), []byte("x"), 0644))
+
+	require.NoError(t, runBundleImport("deps.tar", nil))
+	assert.FileExists(t, filepath.Join(vcpkg.BundleDir, vcpkg.BundleDownloads, "fmt.tar.gz"))
+	assert.NoFileExists(t, filepath.Join(vcpkg.BundleDir, "stale"))
+}
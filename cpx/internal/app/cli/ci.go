@@ -5,9 +5,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -24,11 +31,22 @@ func CICmd() *cobra.Command {
 	buildCmd := &cobra.Command{
 		Use:   "build",
 		Short: "Build for all targets using Docker",
-		Long:  "Build for all targets defined in cpx.ci using Docker containers.",
-		RunE:  runCIBuildCmd,
+		Long: `Build for all targets defined in cpx.ci using Docker containers. With --json, cpx prints the status and artifacts of every target.
+
+--remote user@host builds on another machine instead, e.g. arm64 targets natively on an ARM box rather than under QEMU: cpx syncs the project to ~/.cache/cpx/remote/<project> there with rsync, runs 'cpx ci build' over SSH, and copies the artifacts back. The builder needs cpx and Docker.
+
+After the build, cpx writes SHA256SUMS for the artifacts in the output directory. --sign gpg or --sign cosign also signs it (SHA256SUMS.asc or SHA256SUMS.sig); consumers check both with 'cpx verify-artifacts'.`,
+		Example: `  cpx ci build
+  cpx ci build --target linux-arm64 --remote ci@arm-builder
+  cpx ci build --sign cosign --sign-key cosign.key`,
+		RunE: runCIBuildCmd,
 	}
 	buildCmd.Flags().String("target", "", "Build only specific target (default: all)")
 	buildCmd.Flags().Bool("rebuild", false, "Rebuild Docker images even if they exist")
+	buildCmd.Flags().String("remote", "", "Build on a remote builder over SSH (user@host), e.g. natively on an ARM machine")
+	buildCmd.Flags().String("sign", "", "Sign SHA256SUMS with gpg or cosign")
+	buildCmd.Flags().String("sign-key", "", "Key to sign with: a gpg key ID, or a cosign private key (cosign signs keyless without one)")
+	buildCmd.RegisterFlagCompletionFunc("target", completeCITargets)
 	cmd.AddCommand(buildCmd)
 
 	// Add run subcommand - builds and runs a specific target
@@ -41,6 +59,7 @@ func CICmd() *cobra.Command {
 	runCmd.Flags().String("target", "", "Target to build and run (required)")
 	runCmd.Flags().Bool("rebuild", false, "Rebuild Docker image even if it exists")
 	runCmd.MarkFlagRequired("target")
+	runCmd.RegisterFlagCompletionFunc("target", completeCITargets)
 	cmd.AddCommand(runCmd)
 
 	// Add add-target subcommand
@@ -54,10 +73,11 @@ func CICmd() *cobra.Command {
 
 	// Add rm-target subcommand
 	rmTargetCmd := &cobra.Command{
-		Use:   "rm-target [target...]",
-		Short: "Remove a build target from cpx.ci",
-		Long:  "Remove one or more build targets from cpx.ci configuration.",
-		RunE:  runRemoveTarget,
+		Use:               "rm-target [target...]",
+		Short:             "Remove a build target from cpx.ci",
+		Long:              "Remove one or more build targets from cpx.ci configuration.",
+		RunE:              runRemoveTarget,
+		ValidArgsFunction: completeCITargets,
 	}
 
 	// Add list subcommand to rm-target
@@ -86,7 +106,21 @@ func CICmd() *cobra.Command {
 func runCIBuildCmd(cmd *cobra.Command, _ []string) error {
 	target, _ := cmd.Flags().GetString("target")
 	rebuild, _ := cmd.Flags().GetBool("rebuild")
-	return runCIBuild(target, rebuild, false)
+	signer, _ := cmd.Flags().GetString("sign")
+	signKey, _ := cmd.Flags().GetString("sign-key")
+	if err := checkSigner(signer); err != nil {
+		return err
+	}
+	if cmd.Flags().Changed("remote") {
+		remote, _ := cmd.Flags().GetString("remote")
+		if err := runRemoteCIBuild(remote, target, rebuild); err != nil {
+			return err
+		}
+	} else if err := runCIBuild(target, rebuild, false); err != nil {
+		return err
+	}
+	// Sign locally: the keys of the remote builder aren't the user's
+	return writeArtifactSums(ciOutputDir(), signer, signKey)
 }
 
 func runCIRun(cmd *cobra.Command, _ []string) error {
@@ -169,14 +203,14 @@ func runAddTarget(_ *cobra.Command, args []string) error {
 			}
 			// Skip if already exists
 			if existingTargets[arg] {
-				fmt.Printf("%sTarget %s already in cpx.ci, skipping%s\n", Yellow, arg, Reset)
+				logging.Notice("Target %s already in cpx.ci, skipping", arg)
 				continue
 			}
 			selectedTargets = append(selectedTargets, arg)
 		}
 
 		if len(selectedTargets) == 0 {
-			fmt.Printf("%sNo new targets added%s\n", Yellow, Reset)
+			logging.Notice("No new targets added")
 			return nil
 		}
 
@@ -184,7 +218,7 @@ func runAddTarget(_ *cobra.Command, args []string) error {
 		for _, targetName := range selectedTargets {
 			target := deriveTargetConfig(targetName)
 			ciConfig.Targets = append(ciConfig.Targets, target)
-			fmt.Printf("%s+ Added target: %s%s\n", Green, targetName, Reset)
+			logging.Success("+ Added target: %s", targetName)
 		}
 
 		// Save cpx.ci
@@ -192,7 +226,7 @@ func runAddTarget(_ *cobra.Command, args []string) error {
 			return err
 		}
 
-		fmt.Printf("\n%sSaved cpx.ci with %d target(s)%s\n", Green, len(ciConfig.Targets), Reset)
+		logging.Success("\nSaved cpx.ci with %d target(s)", len(ciConfig.Targets))
 		return nil
 	}
 
@@ -213,7 +247,7 @@ func runAddTarget(_ *cobra.Command, args []string) error {
 	}
 
 	if len(selectedTargets) == 0 {
-		fmt.Printf("%sNo targets selected - clearing all targets%s\n", Yellow, Reset)
+		logging.Notice("No targets selected - clearing all targets")
 	}
 
 	// Calculate changes
@@ -267,13 +301,13 @@ func runAddTarget(_ *cobra.Command, args []string) error {
 
 	// Print summary
 	for _, t := range added {
-		fmt.Printf("%s+ Added target: %s%s\n", Green, t, Reset)
+		logging.Success("+ Added target: %s", t)
 	}
 	for _, t := range removed {
-		fmt.Printf("%s- Removed target: %s%s\n", Red, t, Reset)
+		logging.Info("- Removed target: %s", t)
 	}
 
-	fmt.Printf("\n%sSaved cpx.ci with %d target(s)%s\n", Green, len(ciConfig.Targets), Reset)
+	logging.Success("\nSaved cpx.ci with %d target(s)", len(ciConfig.Targets))
 	return nil
 }
 
@@ -286,7 +320,7 @@ func runRemoveTarget(_ *cobra.Command, args []string) error {
 	}
 
 	if len(ciConfig.Targets) == 0 {
-		fmt.Printf("%sNo targets in cpx.ci to remove%s\n", Yellow, Reset)
+		logging.Notice("No targets in cpx.ci to remove")
 		return nil
 	}
 
@@ -322,7 +356,7 @@ func runRemoveTarget(_ *cobra.Command, args []string) error {
 		}
 
 		if len(selectedToRemove) == 0 {
-			fmt.Printf("%sNo targets selected for removal%s\n", Yellow, Reset)
+			logging.Notice("No targets selected for removal")
 			return nil
 		}
 
@@ -348,7 +382,7 @@ func runRemoveTarget(_ *cobra.Command, args []string) error {
 	}
 
 	if len(removed) == 0 {
-		fmt.Printf("%sNo matching targets found to remove%s\n\n", Yellow, Reset)
+		logging.Notice("No matching targets found to remove\n")
 		fmt.Printf("Available targets in cpx.ci:\n")
 		for _, t := range ciConfig.Targets {
 			fmt.Printf("  - %s\n", t.Name)
@@ -363,9 +397,9 @@ func runRemoveTarget(_ *cobra.Command, args []string) error {
 	}
 
 	for _, name := range removed {
-		fmt.Printf("%s- Removed target: %s%s\n", Red, name, Reset)
+		logging.Info("- Removed target: %s", name)
 	}
-	fmt.Printf("\n%sSaved cpx.ci with %d target(s)%s\n", Green, len(ciConfig.Targets), Reset)
+	logging.Success("\nSaved cpx.ci with %d target(s)", len(ciConfig.Targets))
 	return nil
 }
 
@@ -378,7 +412,7 @@ func runListRemoveTargets(_ *cobra.Command, _ []string) error {
 	}
 
 	if len(ciConfig.Targets) == 0 {
-		fmt.Printf("%sNo targets in cpx.ci to remove%s\n", Yellow, Reset)
+		logging.Notice("No targets in cpx.ci to remove")
 		return nil
 	}
 
@@ -401,7 +435,7 @@ func runListRemoveTargets(_ *cobra.Command, _ []string) error {
 	}
 
 	if len(selectedToRemove) == 0 {
-		fmt.Printf("%sNo targets selected for removal%s\n", Yellow, Reset)
+		logging.Notice("No targets selected for removal")
 		return nil
 	}
 
@@ -424,9 +458,9 @@ func runListRemoveTargets(_ *cobra.Command, _ []string) error {
 	}
 
 	for name := range toRemove {
-		fmt.Printf("%s- Removed target: %s%s\n", Red, name, Reset)
+		logging.Info("- Removed target: %s", name)
 	}
-	fmt.Printf("\n%sSaved cpx.ci with %d target(s)%s\n", Green, len(ciConfig.Targets), Reset)
+	logging.Success("\nSaved cpx.ci with %d target(s)", len(ciConfig.Targets))
 	return nil
 }
 
@@ -475,7 +509,7 @@ func runRegisterTarget(_ *cobra.Command, args []string) error {
 	}
 
 	targetName := strings.TrimPrefix(baseName, "Dockerfile.")
-	fmt.Printf("%sSuccessfully registered target: %s%s\n", Green, targetName, Reset)
+	logging.Success("Successfully registered target: %s", targetName)
 	fmt.Printf("You can now add it using: cpx ci add-target %s\n", targetName)
 
 	return nil
@@ -494,8 +528,9 @@ func describePlatform(name string) string {
 		"linux": "Linux",
 	}
 	archNames := map[string]string{
-		"amd64": "x86_64",
-		"arm64": "ARM64",
+		"amd64":   "x86_64",
+		"arm64":   "ARM64",
+		"riscv64": "RISC-V 64",
 	}
 
 	osName := osNames[os]
@@ -517,33 +552,60 @@ func deriveTargetConfig(name string) config.CITarget {
 	}
 
 	// Derive platform from name
-	// parts := strings.Split(name, "-")
-	// if len(parts) >= 2 {
-	// 	os := parts[0]   // linux
-	// 	arch := parts[1] // amd64, arm64
-	//
-	// 	switch os {
-	// 	case "linux":
-	// 		if arch == "amd64" {
-	// 			// target.Platform = "linux/amd64"
-	// 		} else if arch == "arm64" {
-	// 			// target.Platform = "linux/arm64"
-	// 		}
-	// 	}
-	// }
+	parts := strings.Split(name, "-")
+	if len(parts) >= 2 {
+		os := parts[0]   // linux
+		arch := parts[1] // amd64, arm64, riscv64
+
+		switch os {
+		case "linux":
+			if _, ok := qemuArches[arch]; ok {
+				target.Platform = "linux/" + arch
+			}
+		}
+	}
 
 	return target
 }
 
+// qemuArches maps the architectures of the CI platforms to the QEMU user
+// emulator that runs them on other hosts
+var qemuArches = map[string]string{
+	"amd64":   "x86_64",
+	"arm64":   "aarch64",
+	"riscv64": "riscv64",
+}
+
+// binfmtMiscDir is where Linux registers the interpreters of foreign binaries
+var binfmtMiscDir = "/proc/sys/fs/binfmt_misc"
+
+// checkEmulation checks that the host can run containers of platform: its
+// own architecture, or another one with a QEMU binfmt_misc handler. Docker
+// Desktop on macOS and Windows ships the handlers. It reports whether the
+// platform is emulated.
+func checkEmulation(platform string) (bool, error) {
+	arch := strings.TrimPrefix(platform, "linux/")
+	if platform == "" || arch == runtime.GOARCH {
+		return false, nil
+	}
+	qemuArch, ok := qemuArches[arch]
+	if !ok || runtime.GOOS != "linux" {
+		return true, nil
+	}
+	if _, err := os.Stat(filepath.Join(binfmtMiscDir, "qemu-"+qemuArch)); err != nil {
+		return true, fmt.Errorf("cannot run %s containers on this %s host: no QEMU emulator is registered for %s\n  hint: register it with 'docker run --privileged --rm tonistiigi/binfmt --install %s'", platform, runtime.GOARCH, qemuArch, arch)
+	}
+	return true, nil
+}
+
 var ciCommandExecuted = false
 
-func runCIBuild(targetName string, rebuild bool, executeAfterBuild bool) error {
+func runCIBuild(targetName string, rebuild bool, executeAfterBuild bool) (err error) {
 	if ciCommandExecuted {
-		fmt.Printf("%s[DEBUG] CI command already executed in this process (PID: %d), skipping second invocation.%s\n", Yellow, os.Getpid(), Reset)
+		logging.Debug("CI command already executed in this process (PID: %d), skipping second invocation.", os.Getpid())
 		return nil
 	}
 	ciCommandExecuted = true
-	// fmt.Printf("%s[DEBUG] Starting CI build (PID: %d)%s\n", Yellow, os.Getpid(), Reset)
 
 	// Load cpx.ci configuration
 	ciConfig, err := config.LoadCI("cpx.ci")
@@ -551,24 +613,33 @@ func runCIBuild(targetName string, rebuild bool, executeAfterBuild bool) error {
 		return fmt.Errorf("failed to load cpx.ci: %w\n  Create cpx.ci file or run 'cpx build' for local builds", err)
 	}
 
-	// Filter targets if specific target requested
-	targets := ciConfig.Targets
-	if targetName != "" {
-		found := false
-		for _, t := range ciConfig.Targets {
-			if t.Name == targetName {
-				targets = []config.CITarget{t}
-				found = true
-				break
+	if len(ciConfig.Targets) == 0 {
+		return fmt.Errorf("no targets defined in cpx.ci")
+	}
+
+	// Expand the targets into the cells of their matrix, and filter them if
+	// a target or a single cell is requested
+	var targets []config.CITarget
+	matrix := false
+	for _, t := range ciConfig.Targets {
+		cells := ciConfig.Cells(t)
+		for _, cell := range cells {
+			if targetName == "" || cell.Base == targetName || cell.Name == targetName {
+				targets = append(targets, cell)
+				matrix = matrix || cell.Name != cell.Base
 			}
 		}
-		if !found {
-			return fmt.Errorf("target '%s' not found in cpx.ci", targetName)
-		}
 	}
-
 	if len(targets) == 0 {
-		return fmt.Errorf("no targets defined in cpx.ci")
+		return fmt.Errorf("target '%s' not found in cpx.ci", targetName)
+	}
+	for _, target := range targets {
+		if err := checkMatrixCell(target); err != nil {
+			return err
+		}
+	}
+	if err := checkCICache(ciConfig.Cache); err != nil {
+		return err
 	}
 
 	// Get Dockerfiles directory from config
@@ -598,7 +669,25 @@ func runCIBuild(targetName string, rebuild bool, executeAfterBuild bool) error {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	fmt.Printf("%s Building for %d target(s) using Docker...%s\n", Cyan, len(targets), Reset)
+	// cpx ci build --json reports the targets it got to
+	results := []ciTargetResult{}
+	if output.JSON() && !executeAfterBuild {
+		defer func() {
+			status := "success"
+			if err != nil {
+				status = "failed"
+			}
+			if printErr := output.Print(ciBuildResult{Status: status, OutputDir: outputDir, Targets: results}); err == nil {
+				err = printErr
+			}
+		}()
+	}
+
+	if matrix {
+		logging.Step("Building %d matrix cell(s) using Docker...", len(targets))
+	} else {
+		logging.Step("Building for %d target(s) using Docker...", len(targets))
+	}
 
 	// Get project root
 	projectRoot, err := findProjectRoot()
@@ -619,20 +708,63 @@ func runCIBuild(targetName string, rebuild bool, executeAfterBuild bool) error {
 		}
 	}
 
-	// Build and run for each target
+	// Build and run for each target. A matrix builds all of its cells and
+	// reports the failed ones at the end.
+	builtImages := make(map[string]bool)
+	var failed []string
 	for i, target := range targets {
 		if executeAfterBuild {
-			fmt.Printf("\n%s[%d/%d] Building and running target: %s%s\n", Cyan, i+1, len(targets), target.Name, Reset)
+			logging.Step("\n[%d/%d] Building and running target: %s", i+1, len(targets), target.Name)
 		} else {
-			fmt.Printf("\n%s[%d/%d] Building target: %s%s\n", Cyan, i+1, len(targets), target.Name, Reset)
+			logging.Step("\n[%d/%d] Building target: %s", i+1, len(targets), target.Name)
 		}
 
-		// Build Docker image
+		start := time.Now()
+		if err := buildCITarget(target, absDockerfilesDir, projectRoot, outputDir, ciConfig, rebuild, executeAfterBuild, builtImages); err != nil {
+			results = append(results, newCITargetResult(target, "failed", err))
+			if !matrix {
+				return err
+			}
+			logging.Error("%v", err)
+			failed = append(failed, target.Name)
+			continue
+		}
+		targetOutputDir := filepath.Join(outputDir, target.Name)
+		result := newCITargetResult(target, "success", nil)
+		result.OutputDir = targetOutputDir
+		result.Artifacts = buildArtifacts(targetOutputDir)
+		result.Duration = time.Since(start).Seconds()
+		results = append(results, result)
+
+		if executeAfterBuild {
+			logging.Success("Target %s completed", target.Name)
+		} else {
+			logging.Success("Target %s built successfully", target.Name)
+		}
+	}
+
+	if matrix {
+		printMatrixSummary(results)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d matrix cells failed: %s", len(failed), len(targets), strings.Join(failed, ", "))
+	}
+	if !executeAfterBuild {
+		logging.Success("\nAll targets built successfully!")
+		fmt.Printf("   Artifacts are in: %s\n", outputDir)
+	}
+	return nil
+}
+
+// buildCITarget builds the Docker image of a target, unless an earlier cell
+// of its matrix did, and builds the target in it
+func buildCITarget(target config.CITarget, dockerfilesDir, projectRoot, outputDir string, ciConfig *config.CIConfig, rebuild, executeAfterBuild bool, builtImages map[string]bool) error {
+	if !builtImages[target.Tag] {
 		// Check if Dockerfile exists as specified, or try prepending Dockerfile.
-		dockerfilePath := filepath.Join(absDockerfilesDir, target.Source)
+		dockerfilePath := filepath.Join(dockerfilesDir, target.Source)
 		if _, err := os.Stat(dockerfilePath); os.IsNotExist(err) {
 			// Try prepending Dockerfile.
-			altPath := filepath.Join(absDockerfilesDir, "Dockerfile."+target.Source)
+			altPath := filepath.Join(dockerfilesDir, "Dockerfile."+target.Source)
 			if _, err := os.Stat(altPath); err == nil {
 				dockerfilePath = altPath
 			} else {
@@ -640,29 +772,112 @@ func runCIBuild(targetName string, rebuild bool, executeAfterBuild bool) error {
 			}
 		}
 
-		if err := buildDockerImage(dockerfilePath, target.Tag, rebuild); err != nil {
-			return fmt.Errorf("failed to build Docker image %s: %w", target.Tag, err)
+		emulated, err := checkEmulation(target.Platform)
+		if err != nil {
+			return err
 		}
-
-		// Run build in Docker container
-		if err := runDockerBuild(target, projectRoot, outputDir, ciConfig.Build, executeAfterBuild); err != nil {
-			return fmt.Errorf("failed to build target %s: %w", target.Name, err)
+		if emulated {
+			logging.Notice("  %s runs under QEMU emulation, slower than a native build", target.Platform)
 		}
 
-		if executeAfterBuild {
-			fmt.Printf("%s Target %s completed%s\n", Green, target.Name, Reset)
-		} else {
-			fmt.Printf("%s Target %s built successfully%s\n", Green, target.Name, Reset)
+		if err := buildDockerImage(dockerfilePath, target.Tag, target.Platform, ciConfig.Cache, rebuild); err != nil {
+			return fmt.Errorf("failed to build Docker image %s: %w", target.Tag, err)
 		}
+		builtImages[target.Tag] = true
 	}
 
-	if !executeAfterBuild {
-		fmt.Printf("\n%s All targets built successfully!%s\n", Green, Reset)
-		fmt.Printf("   Artifacts are in: %s\n", outputDir)
+	// Run build in Docker container
+	buildConfig := ciConfig.Build
+	if target.BuildType != "" {
+		buildConfig.Type = target.BuildType
+	}
+	if err := runDockerBuild(target, projectRoot, outputDir, buildConfig, ciConfig.Cache, executeAfterBuild); err != nil {
+		return fmt.Errorf("failed to build target %s: %w", target.Name, err)
+	}
+	return nil
+}
+
+// checkMatrixCell validates the compiler of a matrix cell. The images build
+// with gcc or clang: cl (MSVC) doesn't run in them.
+func checkMatrixCell(target config.CITarget) error {
+	if target.Compiler == "" {
+		return nil
+	}
+	compiler, err := build.ParseCompiler(target.Compiler)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, fmt.Errorf("matrix of %s in cpx.ci: %w", target.Base, err))
+	}
+	if family, _ := compiler.Family(); family == "cl" {
+		return exitcode.Errorf(exitcode.Config, "matrix of %s in cpx.ci: the Docker images can't build with cl\n  hint: use gcc or clang", target.Base)
 	}
 	return nil
 }
 
+// matrixCompiler returns the compiler of a matrix cell, or the zero Compiler
+// for the default compiler of the image
+func matrixCompiler(target config.CITarget) build.Compiler {
+	if target.Compiler == "" {
+		return build.Compiler{}
+	}
+	// checkMatrixCell validated the name
+	compiler, _ := build.ParseCompiler(target.Compiler)
+	return compiler
+}
+
+// newCITargetResult returns the cpx ci build --json result of a target
+func newCITargetResult(target config.CITarget, status string, err error) ciTargetResult {
+	result := ciTargetResult{Name: target.Name, Status: status}
+	if target.Name != target.Base {
+		result.Target = target.Base
+		result.Compiler = target.Compiler
+		result.Standard = target.Standard
+		result.BuildType = target.BuildType
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// printMatrixSummary prints the status of each cell of a matrix build
+func printMatrixSummary(results []ciTargetResult) {
+	width := 0
+	for _, result := range results {
+		width = max(width, len(result.Name))
+	}
+	logging.Step("\nMatrix summary:")
+	for _, result := range results {
+		if result.Status == "success" {
+			fmt.Printf("  %s%s%s %-*s %s%.1fs%s\n", Green, IconSuccess, Reset, width, result.Name, Dim, result.Duration, Reset)
+		} else {
+			fmt.Printf("  %s%s%s %-*s %sfailed%s\n", Red, IconError, Reset, width, result.Name, Red, Reset)
+		}
+	}
+}
+
+// ciBuildResult is the output of cpx ci build --json
+type ciBuildResult struct {
+	Status    string           `json:"status"`
+	OutputDir string           `json:"output_dir"`
+	Targets   []ciTargetResult `json:"targets"`
+}
+
+// ciTargetResult is a target of cpx ci build --json
+type ciTargetResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// The matrix cell: the target of cpx.ci it builds, and its compiler,
+	// C++ standard and build type
+	Target    string   `json:"target,omitempty"`
+	Compiler  string   `json:"compiler,omitempty"`
+	Standard  int      `json:"standard,omitempty"`
+	BuildType string   `json:"build_type,omitempty"`
+	OutputDir string   `json:"output_dir,omitempty"`
+	Artifacts []string `json:"artifacts,omitempty"`
+	Duration  float64  `json:"duration,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
 func findProjectRoot() (string, error) {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -695,18 +910,18 @@ func findProjectRoot() (string, error) {
 	}
 }
 
-func buildDockerImage(dockerfilePath, imageName string, rebuild bool) error {
+func buildDockerImage(dockerfilePath, imageName, platform string, cache *config.CICache, rebuild bool) error {
 	// Check if image already exists
 	if !rebuild {
 		cmd := exec.Command("docker", "images", "-q", imageName)
 		output, err := cmd.Output()
 		if err == nil && len(output) > 0 {
-			fmt.Printf("  %s Docker image %s already exists%s\n", Green, imageName, Reset)
+			logging.Success("  Docker image %s already exists", imageName)
 			return nil
 		}
 	}
 
-	fmt.Printf("  %s Building Docker image: %s...%s\n", Cyan, imageName, Reset)
+	logging.Step("  Building Docker image: %s...", imageName)
 
 	// Get absolute paths
 	absDockerfilePath, err := filepath.Abs(dockerfilePath)
@@ -728,9 +943,16 @@ func buildDockerImage(dockerfilePath, imageName string, rebuild bool) error {
 	// Build Docker image with platform flag if specified
 	// Use buildx for better multi-arch support
 	buildArgs := []string{"buildx", "build", "-f", absDockerfilePath, "-t", imageName}
-	// if platform != "" {
-	// 	buildArgs = append(buildArgs, "--platform", platform)
-	// }
+	if platform != "" {
+		buildArgs = append(buildArgs, "--platform", platform)
+	}
+	if cache != nil && cache.Registry != "" {
+		if err := ensureCacheBuilder(); err != nil {
+			return err
+		}
+		logging.Info("  Using the layer cache in %s", registryCacheRef(cache.Registry, imageName))
+		buildArgs = append(buildArgs, registryCacheArgs(cache, imageName)...)
+	}
 	buildArgs = append(buildArgs, "--load") // Load into local Docker daemon
 	buildArgs = append(buildArgs, dockerfileDir)
 
@@ -740,12 +962,12 @@ func buildDockerImage(dockerfilePath, imageName string, rebuild bool) error {
 
 	// If buildx fails, fall back to regular docker build
 	if err := cmd.Run(); err != nil {
-		fmt.Printf("  %s  docker buildx failed, trying regular docker build...%s\n", Yellow, Reset)
+		logging.Notice("  docker buildx failed, trying regular docker build...")
 		// Fallback to regular docker build
 		buildArgs = []string{"build", "-f", absDockerfilePath, "-t", imageName}
-		// if platform != "" {
-		// 	buildArgs = append(buildArgs, "--platform", platform)
-		// }
+		if platform != "" {
+			buildArgs = append(buildArgs, "--platform", platform)
+		}
 		buildArgs = append(buildArgs, dockerfileDir)
 
 		cmd = exec.Command("docker", buildArgs...)
@@ -756,7 +978,71 @@ func buildDockerImage(dockerfilePath, imageName string, rebuild bool) error {
 		}
 	}
 
-	fmt.Printf("  %s Docker image %s built successfully%s\n", Green, imageName, Reset)
+	logging.Success("  Docker image %s built successfully", imageName)
+	return nil
+}
+
+// cacheBuilder is the buildx builder of images with a registry cache: the
+// default docker driver can't export caches
+const cacheBuilder = "cpx-ci"
+
+// ensureCacheBuilder creates the docker-container builder of registry
+// caches, unless it exists
+func ensureCacheBuilder() error {
+	if err := execCommand("docker", "buildx", "inspect", cacheBuilder).Run(); err == nil {
+		return nil
+	}
+	logging.Step("  Creating buildx builder %s for the registry cache...", cacheBuilder)
+	create := execCommand("docker", "buildx", "create", "--name", cacheBuilder, "--driver", "docker-container")
+	create.Stderr = os.Stderr
+	if err := create.Run(); err != nil {
+		return exitcode.Errorf(exitcode.ToolchainMissing, "failed to create buildx builder %s: %v\n  hint: registry caches need docker buildx", cacheBuilder, err)
+	}
+	return nil
+}
+
+// registryCacheRef returns the reference of the layer cache of an image in
+// a registry: one tag per image
+func registryCacheRef(registry, imageName string) string {
+	tag := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, imageName)
+	return registry + ":" + tag
+}
+
+// registryCacheArgs returns the docker buildx build arguments importing
+// the layer cache of an image from the registry of cache and, unless its
+// mode is read, exporting it with all intermediate layers
+func registryCacheArgs(cache *config.CICache, imageName string) []string {
+	ref := registryCacheRef(cache.Registry, imageName)
+	args := []string{"--builder", cacheBuilder, "--cache-from", "type=registry,ref=" + ref}
+	if cache.Mode != "read" {
+		args = append(args, "--cache-to", "type=registry,ref="+ref+",mode=max")
+	}
+	return args
+}
+
+// checkCICache validates the cache section of cpx.ci
+func checkCICache(cache *config.CICache) error {
+	if cache == nil {
+		return nil
+	}
+	if cache.Mode != "" && cache.Mode != "read" && cache.Mode != "readwrite" {
+		return exitcode.Errorf(exitcode.Config, "invalid cache mode %q in cpx.ci\n  hint: use read or readwrite", cache.Mode)
+	}
+	if registry := cache.Registry; registry != "" {
+		if strings.Contains(registry, "://") || strings.Contains(registry[strings.LastIndex(registry, "/")+1:], ":") || strings.Contains(registry, "@") {
+			return exitcode.Errorf(exitcode.Config, "invalid cache registry %q in cpx.ci\n  hint: use an image repository without a tag, e.g. ghcr.io/acme/app-cache; cpx tags it per image", registry)
+		}
+	}
+	if binary := cache.BinaryCache; binary != nil {
+		if err := checkBinaryCache(binary.Kind, binary.URL, binaryCacheMode(binary)); err != nil {
+			return exitcode.Wrap(exitcode.Config, fmt.Errorf("binary_cache of cpx.ci: %w", err))
+		}
+	}
 	return nil
 }
 
@@ -799,7 +1085,7 @@ func detectProjectType(projectRoot string) (bool, error) {
 	return true, nil
 }
 
-func runDockerBuild(target config.CITarget, projectRoot, outputDir string, buildConfig config.CIBuild, executeAfterBuild bool) error {
+func runDockerBuild(target config.CITarget, projectRoot, outputDir string, buildConfig config.CIBuild, cache *config.CICache, executeAfterBuild bool) error {
 	// Create target-specific output directory
 	targetOutputDir := filepath.Join(outputDir, target.Name)
 	if err := os.MkdirAll(targetOutputDir, 0755); err != nil {
@@ -877,6 +1163,22 @@ func runDockerBuild(target config.CITarget, projectRoot, outputDir string, build
 	// This is more reliable than environment variables
 	cmakeArgs = append(cmakeArgs, "-DVCPKG_DISABLE_REGISTRY_UPDATE=ON")
 
+	// Select the compiler and C++ standard of a matrix cell
+	cmakeArgs = append(cmakeArgs, matrixCompiler(target).CMakeArgs()...)
+	if target.Standard != 0 {
+		// Projects set CMAKE_CXX_STANDARD after project(), which overrides a
+		// cache variable; a compile option included at project() comes after
+		// the standard flag on the command line and wins
+		standardFile := filepath.Join(hostBuildDir, "cpx-matrix.cmake")
+		content := fmt.Sprintf("# C++ standard of the cpx ci matrix cell %s\nadd_compile_options($<$<COMPILE_LANGUAGE:CXX>:-std=c++%d>)\n", target.Name, target.Standard)
+		if err := os.WriteFile(standardFile, []byte(content), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", standardFile, err)
+		}
+		cmakeArgs = append(cmakeArgs,
+			fmt.Sprintf("-DCMAKE_CXX_STANDARD=%d", target.Standard),
+			"-DCMAKE_PROJECT_INCLUDE="+containerBuildDir+"/cpx-matrix.cmake")
+	}
+
 	// Add custom CMake args
 	cmakeArgs = append(cmakeArgs, buildConfig.CMakeArgs...)
 
@@ -957,6 +1259,30 @@ find %s -maxdepth 2 -type f \( -name "lib*.a" -o -name "lib*.so" -o -name "lib*.
 	vcpkgBuildtreesPath := "/tmp/.vcpkg_cache/buildtrees"
 	binaryCachePath := "/tmp/.vcpkg_cache/binary"
 
+	// Use the shared binary cache of cpx.ci, or the one of cpx config
+	// set-binary-cache, as local builds do
+	globalCfg, err := config.LoadGlobal()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	binaryCache := globalCfg.BinaryCache
+	if cache != nil && cache.BinaryCache != nil {
+		shared := *cache.BinaryCache
+		if shared.Kind == "files" && !filepath.IsAbs(shared.URL) {
+			// Relative to the project, and absolute for the Docker mount
+			if shared.URL, err = filepath.Abs(filepath.Join(projectRoot, shared.URL)); err != nil {
+				return fmt.Errorf("failed to get absolute path for binary cache: %w", err)
+			}
+		}
+		binaryCache = &shared
+	}
+	if binaryCache != nil && binaryCache.Kind == "files" {
+		if err := os.MkdirAll(binaryCache.URL, 0755); err != nil {
+			return fmt.Errorf("failed to create binary cache directory: %w", err)
+		}
+	}
+	binarySources, binaryCacheArgs := ciBinaryCache(binaryCache, binaryCachePath)
+
 	// Bash build script for Linux/macOS
 	buildScript := fmt.Sprintf(`#!/bin/bash
 set -e
@@ -974,7 +1300,7 @@ export VCPKG_INSTALLED_DIR=%s
 export VCPKG_DOWNLOADS=%s
 export VCPKG_BUILDTREES_ROOT=%s
 # Configure binary caching to reuse built packages
-export VCPKG_BINARY_SOURCES="files,%s,readwrite"
+export VCPKG_BINARY_SOURCES='%s'
 # Disable metrics to speed up builds
 export VCPKG_DISABLE_METRICS=1
 # Ensure directories exist
@@ -1000,7 +1326,7 @@ mkdir -p /output/%s
 %s
 echo " Build complete!"
 %s
-`, vcpkgInstalledPath, vcpkgDownloadsPath, vcpkgBuildtreesPath, binaryCachePath, binaryCachePath, containerBuildDir, containerBuildDir, strings.Join(cmakeArgs, " "), strings.Join(buildArgs, " "), target.Name, copyCommand, func() string {
+`, vcpkgInstalledPath, vcpkgDownloadsPath, vcpkgBuildtreesPath, binarySources, binaryCachePath, containerBuildDir, containerBuildDir, strings.Join(cmakeArgs, " "), strings.Join(buildArgs, " "), target.Name, copyCommand, func() string {
 		if executeAfterBuild {
 			projectName := filepath.Base(projectRoot)
 			return fmt.Sprintf(`
@@ -1040,10 +1366,10 @@ fi
 	}())
 
 	// Run Docker container
-	fmt.Printf("  %s Running build in Docker container...%s\n", Cyan, Reset)
+	logging.Step("  Running build in Docker container...")
 
 	// Use platform from target config
-	// platform := target.Platform
+	platform := target.Platform
 
 	// Mount only necessary directories:
 	// - Source code (read-only to avoid modifying host files)
@@ -1051,9 +1377,9 @@ fi
 	// - Output directory (for artifacts)
 	// - vcpkg cache directory (from build/.vcpkg_cache to /tmp/.vcpkg_cache)
 	dockerArgs := []string{"run", "--rm"}
-	// if platform != "" {
-	// 	dockerArgs = append(dockerArgs, "--platform", platform)
-	// }
+	if platform != "" {
+		dockerArgs = append(dockerArgs, "--platform", platform)
+	}
 	// Mount paths for Linux/macOS containers
 	// Build directory is mounted to /tmp/build to avoid read-only /workspace mount issues
 	// vcpkg cache is mounted to /tmp/.vcpkg_cache for the same reason
@@ -1075,6 +1401,9 @@ fi
 		"-v", absBuildDir+":"+buildPath, // Mount build directory for caching build artifacts
 		"-v", absOutputDir+":"+outputPath, // Mount output directory for artifacts
 		"-v", absVcpkgCacheDir+":"+cachePath, // Mount vcpkg cache
+	)
+	dockerArgs = append(dockerArgs, binaryCacheArgs...)
+	dockerArgs = append(dockerArgs,
 		"-w", workspacePath,
 		target.Tag,
 		command, "-c", buildScript)
@@ -1091,6 +1420,27 @@ fi
 	return nil
 }
 
+// ciBinaryCache returns VCPKG_BINARY_SOURCES of the Docker build script for
+// the configured binary cache, and the docker run arguments it needs. A files
+// cache is mounted at containerDir, the default cache of the build; the
+// credentials of a remote cache are passed on from the environment.
+func ciBinaryCache(cache *config.BinaryCache, containerDir string) (string, []string) {
+	if cache == nil {
+		return vcpkg.BinarySources(config.BinaryCache{Kind: "files", URL: containerDir}), nil
+	}
+	if cache.Kind == "files" {
+		mounted := config.BinaryCache{Kind: "files", URL: containerDir, Mode: cache.Mode}
+		return vcpkg.BinarySources(mounted), []string{"-v", cache.URL + ":" + containerDir}
+	}
+	var args []string
+	for _, name := range vcpkg.BinaryCacheCredentials[cache.Kind] {
+		if os.Getenv(name) != "" {
+			args = append(args, "-e", name)
+		}
+	}
+	return vcpkg.BinarySources(*cache), args
+}
+
 // runDockerBazelBuild runs a Bazel build inside Docker
 func runDockerBazelBuild(target config.CITarget, projectRoot, outputDir string, buildConfig config.CIBuild) error {
 	// Get absolute paths
@@ -1124,6 +1474,17 @@ func runDockerBazelBuild(target config.CITarget, projectRoot, outputDir string,
 		return fmt.Errorf("failed to create bazel repo cache directory: %w", err)
 	}
 
+	// The compiler and C++ standard of a matrix cell; the --cxxopt comes
+	// after the one of .bazelrc and wins
+	matrixFlags := matrixCompiler(target).BazelArgs()
+	if target.Standard != 0 {
+		matrixFlags = append(matrixFlags, fmt.Sprintf("--cxxopt=-std=c++%d", target.Standard))
+	}
+	bazelMatrixFlags := ""
+	if len(matrixFlags) > 0 {
+		bazelMatrixFlags = " " + strings.Join(matrixFlags, " ")
+	}
+
 	// Create Bazel build script
 	// Use --output_base to keep Bazel's output completely separate from the workspace
 	// Use HOME=/root to reuse Bazel downloaded during Docker image build
@@ -1142,7 +1503,7 @@ mkdir -p "$BAZEL_OUTPUT_BASE"
 # --symlink_prefix=/dev/null: suppress symlinks (workspace is read-only)
 # --spawn_strategy=local: disable sandbox (causes issues in Docker)
 # --repository_cache: persist downloaded dependencies and repo state
-bazel --output_base="$BAZEL_OUTPUT_BASE" build --config=%s --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache //...
+bazel --output_base="$BAZEL_OUTPUT_BASE" build --config=%s --symlink_prefix=/dev/null --spawn_strategy=local --repository_cache=/bazel-repo-cache%s //...
 echo "  Copying artifacts..."
 mkdir -p /output/%s
 # Copy only final executables (exclude object files, dep files, intermediate artifacts)
@@ -1158,16 +1519,16 @@ find "$BAZEL_OUTPUT_BASE" -path "*/bin/*" -type f \( -name "lib*.a" -o -name "li
     ! -name "*.pic.a" \
     -exec cp {} /output/%s/ \; 2>/dev/null || true
 echo "  Build complete!"
-`, bazelConfig, target.Name, target.Name, target.Name)
+`, bazelConfig, bazelMatrixFlags, target.Name, target.Name, target.Name)
 
 	// Run Docker container
-	fmt.Printf("  %s Running Bazel build in Docker container...%s\n", Cyan, Reset)
+	logging.Step("  Running Bazel build in Docker container...")
 
-	// platform := target.Platform
+	platform := target.Platform
 	dockerArgs := []string{"run", "--rm"}
-	// if platform != "" {
-	// 	dockerArgs = append(dockerArgs, "--platform", platform)
-	// }
+	if platform != "" {
+		dockerArgs = append(dockerArgs, "--platform", platform)
+	}
 
 	// Mount workspace as read-only to prevent Bazel from creating files in it
 	// Mount output directory separately
@@ -1242,6 +1603,11 @@ func runDockerMesonBuild(target config.CITarget, projectRoot, outputDir string,
 	// So we typically don't need a cross file unless the image is a cross-compilation toolchain image.
 	// For now, we assume the environment is correct or the image handles it.
 
+	// The C++ standard of a matrix cell overrides the project's default
+	if target.Standard != 0 {
+		setupArgs = append(setupArgs, fmt.Sprintf("-Dcpp_std=c++%d", target.Standard))
+	}
+
 	// Add custom Meson args
 	setupArgs = append(setupArgs, buildConfig.MesonArgs...)
 
@@ -1300,13 +1666,13 @@ echo "  Build complete!"
 `, strings.Join(setupArgs[2:], " "), target.Name, target.Name, target.Name, target.Name, target.Name)
 
 	// Run Docker container
-	fmt.Printf("  %s Running Meson build in Docker container...%s\n", Cyan, Reset)
+	logging.Step("  Running Meson build in Docker container...")
 
-	// platform := target.Platform
+	platform := target.Platform
 	dockerArgs := []string{"run", "--rm"}
-	// if platform != "" {
-	// 	dockerArgs = append(dockerArgs, "--platform", platform)
-	// }
+	if platform != "" {
+		dockerArgs = append(dockerArgs, "--platform", platform)
+	}
 
 	// Mounts
 	dockerArgs = append(dockerArgs,
@@ -1314,6 +1680,12 @@ echo "  Build complete!"
 		"-v", absBuildDir+":/tmp/builddir", // Persistent build dir
 		"-v", absSubprojectsDir+":/workspace/subprojects", // Subprojects read-write for downloading wraps
 		"-v", absOutputDir+":/workspace/out", // Output dir
+	)
+	// Meson takes the compiler of a matrix cell from CC and CXX
+	if compiler := matrixCompiler(target); compiler.Name != "" {
+		dockerArgs = append(dockerArgs, "-e", "CC="+compiler.CC, "-e", "CXX="+compiler.CXX)
+	}
+	dockerArgs = append(dockerArgs,
 		"-w", "/workspace",
 		target.Tag,
 		"bash", "-c", buildScript)
@@ -1,10 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -15,25 +19,36 @@ func TestDeriveTargetConfig(t *testing.T) {
 		name               string
 		targetName         string
 		expectedDockerfile string
-		// expectedPlatform   string
+		expectedPlatform   string
 	}{
 		{
 			name:               "Linux AMD64",
 			targetName:         "linux-amd64",
 			expectedDockerfile: "linux-amd64",
-			// expectedPlatform:   "linux/amd64",
+			expectedPlatform:   "linux/amd64",
 		},
 		{
 			name:               "Linux ARM64",
 			targetName:         "linux-arm64",
 			expectedDockerfile: "linux-arm64",
-			// expectedPlatform:   "linux/arm64",
+			expectedPlatform:   "linux/arm64",
 		},
 		{
 			name:               "Linux AMD64 MUSL",
 			targetName:         "linux-amd64-musl",
 			expectedDockerfile: "linux-amd64-musl",
-			// expectedPlatform:   "linux/amd64",
+			expectedPlatform:   "linux/amd64",
+		},
+		{
+			name:               "Linux RISC-V 64",
+			targetName:         "linux-riscv64",
+			expectedDockerfile: "linux-riscv64",
+			expectedPlatform:   "linux/riscv64",
+		},
+		{
+			name:               "Custom target",
+			targetName:         "custom",
+			expectedDockerfile: "custom",
 		},
 	}
 
@@ -43,13 +58,45 @@ func TestDeriveTargetConfig(t *testing.T) {
 			// deriveTargetConfig only sets Source and Platform
 			// Name and Tag are derived by LoadCI when loading the config
 			assert.Equal(t, tt.expectedDockerfile, result.Source)
-			// assert.Equal(t, tt.expectedPlatform, result.Platform)
+			assert.Equal(t, tt.expectedPlatform, result.Platform)
 			assert.Empty(t, result.Name) // Not set by deriveTargetConfig
 			assert.Empty(t, result.Tag)  // Not set by deriveTargetConfig
 		})
 	}
 }
 
+func TestDescribePlatform(t *testing.T) {
+	assert.Equal(t, "Linux x86_64", describePlatform("linux-amd64"))
+	assert.Equal(t, "Linux ARM64", describePlatform("linux-arm64-musl"))
+	assert.Equal(t, "Linux RISC-V 64", describePlatform("linux-riscv64"))
+	assert.Empty(t, describePlatform("custom"))
+}
+
+func TestCheckEmulation(t *testing.T) {
+	emulated, err := checkEmulation("")
+	assert.NoError(t, err)
+	assert.False(t, emulated)
+	emulated, err = checkEmulation("linux/" + runtime.GOARCH)
+	assert.NoError(t, err)
+	assert.False(t, emulated, "the host runs its own architecture")
+
+	if runtime.GOOS != "linux" || runtime.GOARCH == "riscv64" {
+		t.Skip("binfmt_misc handlers are checked on non-riscv64 Linux hosts")
+	}
+	oldBinfmtMiscDir := binfmtMiscDir
+	defer func() { binfmtMiscDir = oldBinfmtMiscDir }()
+	binfmtMiscDir = t.TempDir()
+
+	emulated, err = checkEmulation("linux/riscv64")
+	assert.True(t, emulated)
+	assert.ErrorContains(t, err, "tonistiigi/binfmt --install riscv64")
+
+	require.NoError(t, os.WriteFile(filepath.Join(binfmtMiscDir, "qemu-riscv64"), []byte("enabled\n"), 0644))
+	emulated, err = checkEmulation("linux/riscv64")
+	assert.NoError(t, err)
+	assert.True(t, emulated)
+}
+
 func TestSaveCIConfig(t *testing.T) {
 	tmpDir := t.TempDir()
 	ciPath := filepath.Join(tmpDir, "cpx.ci")
@@ -62,7 +109,7 @@ func TestSaveCIConfig(t *testing.T) {
 				Source: "linux-amd64",
 				Tag:    "cpx-linux-amd64",
 
-				// Platform:   "linux/amd64",
+				Platform: "linux/amd64",
 			},
 		},
 		Build: config.CIBuild{
@@ -88,6 +135,7 @@ func TestSaveCIConfig(t *testing.T) {
 	// Verify content
 	assert.Len(t, loadedConfig.Targets, 1)
 	assert.Equal(t, "linux-amd64", loadedConfig.Targets[0].Name)
+	assert.Equal(t, "linux/amd64", loadedConfig.Targets[0].Platform)
 	assert.Equal(t, "Release", loadedConfig.Build.Type)
 	assert.Equal(t, ".bin/ci", loadedConfig.Output)
 }
@@ -203,3 +251,160 @@ func TestRunRemoveTarget(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, loaded.Targets, 1) // Should remain unchanged
 }
+
+func TestCIBinaryCache(t *testing.T) {
+	const containerDir = "/tmp/.vcpkg_cache/binary"
+
+	sources, args := ciBinaryCache(nil, containerDir)
+	assert.Equal(t, "clear;files,/tmp/.vcpkg_cache/binary,readwrite", sources)
+	assert.Empty(t, args)
+
+	// A files cache is mounted where the build expects its cache
+	sources, args = ciBinaryCache(&config.BinaryCache{Kind: "files", URL: "/srv/cache", Mode: "read"}, containerDir)
+	assert.Equal(t, "clear;files,/tmp/.vcpkg_cache/binary,read", sources)
+	assert.Equal(t, []string{"-v", "/srv/cache:" + containerDir}, args)
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+	sources, args = ciBinaryCache(&config.BinaryCache{Kind: "s3", URL: "s3://acme/vcpkg"}, containerDir)
+	assert.Equal(t, "clear;x-aws,s3://acme/vcpkg,readwrite", sources)
+	assert.Subset(t, args, []string{"-e", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"})
+	assert.NotContains(t, args, "AWS_SESSION_TOKEN")
+}
+
+func TestRunRemoteCIBuild(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	execLookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+
+	projectDir := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(projectDir))
+
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runRemoteCIBuild("-oProxyCommand=x", "", false)))
+	assert.Error(t, runRemoteCIBuild("ci@arm-builder", "", false), "cpx.ci is required")
+
+	require.NoError(t, os.WriteFile("cpx.ci", []byte("targets:\n  - image: linux-arm64\n"), 0644))
+	require.NoError(t, runRemoteCIBuild("ci@arm-builder", "linux-arm64", true))
+
+	require.Len(t, capturedArgs, 4)
+	assert.Equal(t, []string{"ssh", "ci@arm-builder", "mkdir -p '.cache/cpx/remote/app' && command -v cpx >/dev/null && command -v docker >/dev/null"}, capturedArgs[0])
+	sync := capturedArgs[1]
+	assert.Equal(t, "rsync", sync[0])
+	assert.Contains(t, sync, "--exclude=/.cache")
+	assert.Equal(t, []string{"./", "ci@arm-builder:.cache/cpx/remote/app/"}, sync[len(sync)-2:])
+	assert.Equal(t, []string{"ssh", "ci@arm-builder", "cd '.cache/cpx/remote/app' && cpx ci build --target 'linux-arm64' --rebuild"}, capturedArgs[2])
+	assert.Equal(t, []string{"rsync", "-az", "ci@arm-builder:.cache/cpx/remote/app/.bin/ci/", filepath.Join(".bin", "ci") + "/"}, capturedArgs[3])
+	assert.DirExists(t, filepath.Join(".bin", "ci"))
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, `'my app'`, shellQuote("my app"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
+
+func TestCheckMatrixCell(t *testing.T) {
+	assert.NoError(t, checkMatrixCell(config.CITarget{Name: "linux-amd64", Base: "linux-amd64"}))
+	assert.NoError(t, checkMatrixCell(config.CITarget{Base: "linux-amd64", Compiler: "clang-17"}))
+	assert.Equal(t, exitcode.Config, exitcode.Of(checkMatrixCell(config.CITarget{Base: "linux-amd64", Compiler: "icc"})))
+	assert.Equal(t, exitcode.Config, exitcode.Of(checkMatrixCell(config.CITarget{Base: "linux-amd64", Compiler: "cl"})))
+
+	assert.Equal(t, "clang-17", matrixCompiler(config.CITarget{Compiler: "clang-17"}).Name)
+	assert.Equal(t, "clang++-17", matrixCompiler(config.CITarget{Compiler: "clang-17"}).CXX)
+	assert.Empty(t, matrixCompiler(config.CITarget{}).Name)
+}
+
+func TestNewCITargetResult(t *testing.T) {
+	result := newCITargetResult(config.CITarget{Name: "linux-amd64", Base: "linux-amd64"}, "success", nil)
+	assert.Equal(t, ciTargetResult{Name: "linux-amd64", Status: "success"}, result)
+
+	cell := config.CITarget{Name: "linux-amd64-gcc-cxx20-debug", Base: "linux-amd64", Compiler: "gcc", Standard: 20, BuildType: "Debug"}
+	result = newCITargetResult(cell, "failed", errors.New("docker run failed"))
+	assert.Equal(t, ciTargetResult{
+		Name: "linux-amd64-gcc-cxx20-debug", Status: "failed", Target: "linux-amd64",
+		Compiler: "gcc", Standard: 20, BuildType: "Debug", Error: "docker run failed",
+	}, result)
+}
+
+func TestRunCIBuildUnknownCell(t *testing.T) {
+	oldExecuted := ciCommandExecuted
+	defer func() { ciCommandExecuted = oldExecuted }()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	require.NoError(t, os.WriteFile("cpx.ci", []byte("targets:\n  - image: linux-amd64\nmatrix:\n  compilers: [gcc, msvc]\n"), 0644))
+	ciCommandExecuted = false
+	assert.ErrorContains(t, runCIBuild("linux-amd64-clang", false, false), "not found")
+	ciCommandExecuted = false
+	assert.Equal(t, exitcode.Config, exitcode.Of(runCIBuild("linux-amd64", false, false)))
+}
+
+func TestRegistryCache(t *testing.T) {
+	assert.Equal(t, "ghcr.io/acme/app-cache:cpx-linux-amd64", registryCacheRef("ghcr.io/acme/app-cache", "cpx-linux-amd64"))
+	assert.Equal(t, "ghcr.io/acme/app-cache:acme-builder-1.0", registryCacheRef("ghcr.io/acme/app-cache", "acme/builder:1.0"))
+
+	cache := &config.CICache{Registry: "ghcr.io/acme/app-cache"}
+	assert.Equal(t, []string{
+		"--builder", "cpx-ci",
+		"--cache-from", "type=registry,ref=ghcr.io/acme/app-cache:cpx-linux-arm64",
+		"--cache-to", "type=registry,ref=ghcr.io/acme/app-cache:cpx-linux-arm64,mode=max",
+	}, registryCacheArgs(cache, "cpx-linux-arm64"))
+	cache.Mode = "read"
+	assert.NotContains(t, registryCacheArgs(cache, "cpx-linux-arm64"), "--cache-to")
+}
+
+func TestCheckCICache(t *testing.T) {
+	assert.NoError(t, checkCICache(nil))
+	assert.NoError(t, checkCICache(&config.CICache{
+		Registry:    "localhost:5000/cpx-cache",
+		Mode:        "read",
+		BinaryCache: &config.BinaryCache{Kind: "s3", URL: "s3://acme/vcpkg"},
+	}))
+	for _, cache := range []*config.CICache{
+		{Mode: "write"},
+		{Registry: "ghcr.io/acme/app-cache:latest"},
+		{Registry: "https://ghcr.io/acme/app-cache"},
+		{BinaryCache: &config.BinaryCache{Kind: "s3", URL: "gs://acme/vcpkg"}},
+		{BinaryCache: &config.BinaryCache{Kind: "ftp", URL: "ftp://acme"}},
+	} {
+		assert.Equal(t, exitcode.Config, exitcode.Of(checkCICache(cache)), "%+v", cache)
+	}
+}
+
+func TestEnsureCacheBuilder(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	require.NoError(t, ensureCacheBuilder())
+	require.Len(t, capturedArgs, 2)
+	assert.Equal(t, []string{"docker", "buildx", "inspect", "cpx-ci"}, capturedArgs[0])
+	assert.Equal(t, []string{"docker", "buildx", "create", "--name", "cpx-ci", "--driver", "docker-container"}, capturedArgs[1])
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// remoteSyncExcludes are the directories cpx ci build --remote doesn't copy
+// to the builder. The builder keeps its own build caches in .cache.
+var remoteSyncExcludes = []string{".git", ".cache", ".bin", "build", "builddir", "out"}
+
+// remoteProjectDir returns the directory a project is synced to on the
+// builder, relative to the home directory of the remote user
+func remoteProjectDir(projectDir string) string {
+	return path.Join(".cache", "cpx", "remote", filepath.Base(projectDir))
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runRemoteCIBuild runs cpx ci build on a remote builder over SSH: it syncs
+// the project there with rsync, builds the targets with the builder's Docker
+// and cpx, and copies the artifacts back
+func runRemoteCIBuild(remote, targetName string, rebuild bool) error {
+	if remote == "" || strings.HasPrefix(remote, "-") || strings.ContainsAny(remote, " \t'\"") {
+		return exitcode.Errorf(exitcode.Usage, "invalid remote %q\n  hint: use --remote user@host", remote)
+	}
+	for _, tool := range []string{"ssh", "rsync"} {
+		if _, err := execLookPath(tool); err != nil {
+			return exitcode.Errorf(exitcode.ToolchainMissing, "%s not found: cpx ci build --remote needs ssh and rsync\n  hint: install them on this machine and on %s", tool, remote)
+		}
+	}
+
+	ciConfig, err := config.LoadCI("cpx.ci")
+	if err != nil {
+		return fmt.Errorf("failed to load cpx.ci: %w\n  Create cpx.ci file or run 'cpx build' for local builds", err)
+	}
+	if filepath.IsAbs(ciConfig.Output) {
+		return exitcode.Errorf(exitcode.Config, "output %s of cpx.ci is absolute\n  hint: cpx ci build --remote copies the artifacts of a relative output directory back", ciConfig.Output)
+	}
+	projectDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	remoteDir := remoteProjectDir(projectDir)
+
+	logging.Step("Syncing project to %s:%s...", remote, remoteDir)
+	check := execCommand("ssh", remote, "mkdir -p "+shellQuote(remoteDir)+" && command -v cpx >/dev/null && command -v docker >/dev/null")
+	check.Stderr = os.Stderr
+	if err := check.Run(); err != nil {
+		return exitcode.Errorf(exitcode.ToolchainMissing, "%s cannot build the project: %v\n  hint: the builder needs SSH access, cpx and Docker on the PATH of non-interactive shells", remote, err)
+	}
+	rsyncArgs := []string{"-az", "--delete"}
+	for _, dir := range remoteSyncExcludes {
+		rsyncArgs = append(rsyncArgs, "--exclude=/"+dir)
+	}
+	rsyncArgs = append(rsyncArgs, "./", remote+":"+remoteDir+"/")
+	if err := runStreaming("rsync", rsyncArgs...); err != nil {
+		return fmt.Errorf("failed to sync the project to %s: %w", remote, err)
+	}
+
+	logging.Step("Building on %s...", remote)
+	buildCmd := []string{"cd", shellQuote(remoteDir), "&&", "cpx", "ci", "build"}
+	if targetName != "" {
+		buildCmd = append(buildCmd, "--target", shellQuote(targetName))
+	}
+	if rebuild {
+		buildCmd = append(buildCmd, "--rebuild")
+	}
+	if output.JSON() {
+		buildCmd = append(buildCmd, "--json")
+	}
+	if err := runStreaming("ssh", remote, strings.Join(buildCmd, " ")); err != nil {
+		return exitcode.Errorf(exitcode.BuildFailed, "remote build on %s failed: %v", remote, err)
+	}
+
+	logging.Step("Copying artifacts from %s...", remote)
+	if err := os.MkdirAll(ciConfig.Output, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	remoteOutput := path.Join(remoteDir, filepath.ToSlash(ciConfig.Output))
+	if err := runStreaming("rsync", "-az", remote+":"+remoteOutput+"/", ciConfig.Output+"/"); err != nil {
+		return fmt.Errorf("failed to copy the artifacts from %s: %w", remote, err)
+	}
+	logging.Success("Artifacts of %s are in: %s", remote, ciConfig.Output)
+	return nil
+}
+
+// runStreaming runs a command with its output on the terminal. The remote
+// cpx ci build --json prints its document to stdout.
+func runStreaming(name string, args ...string) error {
+	cmd := execCommand(name, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
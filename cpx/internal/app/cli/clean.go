@@ -2,10 +2,15 @@ package cli
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
 // CleanCmd creates the clean command
@@ -18,165 +23,272 @@ func CleanCmd() *cobra.Command {
   - Meson: removes builddir/
   - CMake/vcpkg: removes build/
 
-Use --all to also remove additional generated files.`,
-		Example: `  cpx clean         # Clean build artifacts
-  cpx clean --all   # Also remove all generated files`,
+--all also removes the caches: .bin/, .cache/ (including the vcpkg packages
+and the Docker CI caches), .vcpkg_cache/ and, for Bazel projects, the output
+base ('bazel clean --expunge'). --ci only removes the caches of the Docker CI
+builds (.cache/ci/).
+
+cpx reports the size of everything it removes. --dry-run only reports what
+would be removed.`,
+		Example: `  cpx clean              # Clean build artifacts
+  cpx clean --all        # Also remove caches and generated files
+  cpx clean --all --dry-run  # Show what --all would remove and its size
+  cpx clean --ci         # Only remove the Docker CI caches`,
 		RunE: runClean,
 	}
 
-	cmd.Flags().Bool("all", false, "Also remove generated files")
+	cmd.Flags().Bool("all", false, "Also remove caches (.cache/, .vcpkg_cache/, Bazel output base) and generated files")
+	cmd.Flags().Bool("ci", false, "Only remove the Docker CI caches (.cache/ci/)")
+	cmd.Flags().Bool("dry-run", false, "Show what would be removed and its size without removing anything")
 
 	return cmd
 }
 
 func runClean(cmd *cobra.Command, _ []string) error {
 	all, _ := cmd.Flags().GetBool("all")
+	ci, _ := cmd.Flags().GetBool("ci")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if all && ci {
+		return exitcode.Errorf(exitcode.Usage, "--all and --ci cannot be combined\n  hint: --all already removes the CI caches")
+	}
+	if ci {
+		return cleanCI(dryRun)
+	}
 
 	projectType := DetectProjectType()
 
 	switch projectType {
 	case ProjectTypeBazel:
-		return cleanBazel(all)
+		return cleanBazel(all, dryRun)
 	case ProjectTypeMeson:
-		return cleanMeson(all)
+		return cleanMeson(all, dryRun)
 	default:
 		// CMake/vcpkg or unknown - clean generic build directory
-		return cleanCMake(all)
+		return cleanCMake(all, dryRun)
 	}
 }
 
-func cleanBazel(all bool) error {
-	fmt.Printf("%sCleaning Bazel project...%s\n", Cyan, Reset)
+// cacheDirs are the caches cpx clean --all removes for every project type
+var cacheDirs = []string{".bin", ".cache", ".vcpkg_cache"}
+
+// cleaner removes paths, or only reports them in a dry run, and totals the
+// size of what it removed
+type cleaner struct {
+	dryRun bool
+	freed  int64
+}
+
+// remove removes path if it exists and reports its size
+func (c *cleaner) remove(path string) {
+	if _, err := os.Lstat(path); err != nil {
+		return
+	}
+	size := pathSize(path)
+	if c.dryRun {
+		logging.Info("  Would remove %s (%s)", path, formatSize(size))
+		c.freed += size
+		return
+	}
+	logging.Step("  Removing %s (%s)...", path, formatSize(size))
+	if err := os.RemoveAll(path); err != nil {
+		logging.Warn("Failed to remove %s: %v", path, err)
+		return
+	}
+	c.freed += size
+}
 
-	// Run bazel clean
-	cleanCmd := execCommand("bazel", "clean")
-	cleanCmd.Stdout = os.Stdout
-	cleanCmd.Stderr = os.Stderr
-	if err := cleanCmd.Run(); err != nil {
-		fmt.Printf("%s⚠ bazel clean failed (may not be initialized)%s\n", Yellow, Reset)
+// removeMatching removes the entries of the project root matching pattern;
+// dirsOnly skips files
+func (c *cleaner) removeMatching(pattern string, dirsOnly bool) {
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if dirsOnly && !entry.IsDir() {
+			continue
+		}
+		if matched, _ := filepath.Match(pattern, entry.Name()); matched {
+			c.remove(entry.Name())
+		}
+	}
+}
+
+// done prints the result of a cleaning
+func (c *cleaner) done(what string) {
+	if c.dryRun {
+		logging.Info("Dry run: would free %s; nothing was removed", formatSize(c.freed))
+		return
+	}
+	logging.Success("✓ %s cleaned (%s freed)", what, formatSize(c.freed))
+}
+
+func cleanCI(dryRun bool) error {
+	logging.Step("Cleaning Docker CI caches...")
+	c := &cleaner{dryRun: dryRun}
+	c.remove(filepath.Join(".cache", "ci"))
+	c.done("CI caches")
+	return nil
+}
+
+func cleanBazel(all, dryRun bool) error {
+	logging.Step("Cleaning Bazel project...")
+	c := &cleaner{dryRun: dryRun}
+
+	// --all expunges the output base, which holds the external repositories
+	// and the action cache of this workspace
+	cleanArgs := []string{"clean"}
+	outputBase := ""
+	if all {
+		cleanArgs = append(cleanArgs, "--expunge")
+		if out, err := execCommand("bazel", "info", "output_base").Output(); err == nil {
+			outputBase = strings.TrimSpace(string(out))
+		}
+	}
+	outputBaseSize := int64(0)
+	if _, err := os.Stat(outputBase); outputBase != "" && err == nil {
+		outputBaseSize = pathSize(outputBase)
+	}
+
+	if dryRun {
+		if all {
+			logging.Info("  Would run bazel clean --expunge (%s, %s)", outputBase, formatSize(outputBaseSize))
+			c.freed += outputBaseSize
+		} else {
+			logging.Info("  Would run bazel clean")
+		}
 	} else {
-		fmt.Printf("%s✓ Ran bazel clean%s\n", Green, Reset)
+		// Run bazel clean
+		cleanCmd := execCommand("bazel", cleanArgs...)
+		cleanCmd.Stdout = os.Stdout
+		cleanCmd.Stderr = os.Stderr
+		if err := cleanCmd.Run(); err != nil {
+			logging.Warn("bazel clean failed (may not be initialized)")
+		} else {
+			logging.Success("✓ Ran bazel %s", strings.Join(cleanArgs, " "))
+			c.freed += outputBaseSize
+		}
 	}
 
 	// Remove common build output directory
-	removeDir("build")
+	c.remove("build")
 
 	// Remove Bazel symlinks
 	// We want to remove .bin, .out, .testlogs which are custom symlinks we might have created
 	// And relying on standard bazel clean to remove bazel-*
 	bazelSymlinks := []string{".bin", ".out", ".testlogs"}
 	for _, symlink := range bazelSymlinks {
-		if _, err := os.Lstat(symlink); err == nil {
-			fmt.Printf("%s  Removing %s...%s\n", Cyan, symlink, Reset)
-			os.RemoveAll(symlink)
-		}
+		c.remove(symlink)
 	}
 
 	// Remove bazel-* symlinks (bazel-bin, bazel-out, bazel-testlogs, bazel-<project>)
-	entries, err := os.ReadDir(".")
-	if err == nil {
-		for _, entry := range entries {
-			matched, _ := filepath.Match("bazel-*", entry.Name())
-			if matched {
-				fmt.Printf("%s  Removing %s...%s\n", Cyan, entry.Name(), Reset)
-				os.RemoveAll(entry.Name())
-			}
-		}
-	}
+	c.removeMatching("bazel-*", false)
 
 	if all {
 		// Remove additional Bazel artifacts
-		removeDir(".bazel")
-		removeDir("external")
+		c.remove(".bazel")
+		c.remove("external")
+		for _, dir := range cacheDirs {
+			c.remove(dir)
+		}
 	}
 
-	fmt.Printf("%s✓ Bazel project cleaned%s\n", Green, Reset)
+	c.done("Bazel project")
 	return nil
 }
 
-func cleanMeson(all bool) error {
-	fmt.Printf("%sCleaning Meson project...%s\n", Cyan, Reset)
+func cleanMeson(all, dryRun bool) error {
+	logging.Step("Cleaning Meson project...")
+	c := &cleaner{dryRun: dryRun}
 
-	// Remove builddir
-	removeDir("builddir")
+	// Remove builddir, and the build directories of --compiler builds
+	c.remove("builddir")
+	c.removeMatching("builddir-*", true)
 
 	// Remove common build output directory
-	removeDir("build")
+	c.remove("build")
 
 	if all {
 		// Remove additional Meson artifacts
-		removeDir("subprojects/packagecache")
+		c.remove("subprojects/packagecache")
 
 		// Remove build-* directories
-		entries, err := os.ReadDir(".")
-		if err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() {
-					matched, _ := filepath.Match("build-*", entry.Name())
-					if matched {
-						fmt.Printf("%s  Removing %s...%s\n", Cyan, entry.Name(), Reset)
-						os.RemoveAll(entry.Name())
-					}
-				}
-			}
+		c.removeMatching("build-*", true)
+
+		for _, dir := range cacheDirs {
+			c.remove(dir)
 		}
 	}
 
-	fmt.Printf("%s✓ Meson project cleaned%s\n", Green, Reset)
+	c.done("Meson project")
 	return nil
 }
 
-func cleanCMake(all bool) error {
-	fmt.Printf("%sCleaning CMake/vcpkg project...%s\n", Cyan, Reset)
+func cleanCMake(all, dryRun bool) error {
+	logging.Step("Cleaning CMake/vcpkg project...")
+	c := &cleaner{dryRun: dryRun}
 
 	// Remove bin directory (artifacts)
-	removeDir(filepath.Join(".bin", "native"))
+	c.remove(filepath.Join(".bin", "native"))
 
-	// Remove intermediate build directories (keep vcpkg_installed unless --all)
-	// We iterate common variants instead of blowing away .cache/native
-	variants := []string{"debug", "release", "O0", "O1", "O2", "O3", "Os", "Ofast"}
-	for _, v := range variants {
-		removeDir(filepath.Join(".cache", "native", v))
+	// Remove intermediate build directories of every variant (sanitizers,
+	// compilers); keep vcpkg_installed unless --all
+	if entries, err := os.ReadDir(filepath.Join(".cache", "native")); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != "vcpkg_installed" {
+				c.remove(filepath.Join(".cache", "native", entry.Name()))
+			}
+		}
 	}
 
 	if all {
 		// Clean everything including vcpkg dependencies and CI artifacts
-		dirsToRemove := []string{
-			filepath.Join(".cache", "native"),
-			filepath.Join(".cache", "ci"),
-			filepath.Join(".bin", "ci"),
+		dirsToRemove := append([]string{
 			"out",
 			"cmake-build-debug",
 			"cmake-build-release",
-		}
+		}, cacheDirs...)
 		for _, dir := range dirsToRemove {
-			removeDir(dir)
+			c.remove(dir)
 		}
 
 		// Remove build-* directories
-		entries, err := os.ReadDir(".")
-		if err == nil {
-			for _, entry := range entries {
-				if entry.IsDir() {
-					matched, _ := filepath.Match("build-*", entry.Name())
-					if matched {
-						fmt.Printf("%s  Removing %s...%s\n", Cyan, entry.Name(), Reset)
-						os.RemoveAll(entry.Name())
-					}
-				}
-			}
-		}
+		c.removeMatching("build-*", true)
 	}
 
-	fmt.Printf("%s✓ CMake project cleaned%s\n", Green, Reset)
+	c.done("CMake project")
 	return nil
 }
 
-func removeDir(path string) {
-	if _, err := os.Stat(path); err == nil {
-		fmt.Printf("%s  Removing %s...%s\n", Cyan, path, Reset)
-		if err := os.RemoveAll(path); err != nil {
-			fmt.Printf("%s⚠ Failed to remove %s: %v%s\n", Yellow, path, err, Reset)
+// pathSize returns the total size of the files under path, without following
+// symlinks
+func pathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
 		}
+		return nil
+	})
+	return size
+}
+
+// formatSize formats a size in bytes, e.g. "1.5 MB"
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
 	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
 }
@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -72,7 +73,7 @@ func TestCleanBazel(t *testing.T) {
 				t.Fatalf("%v", err)
 			}
 
-			err = cleanBazel(tt.all)
+			err = cleanBazel(tt.all, false)
 			assert.NoError(t, err)
 
 			// Verify build directory was removed
@@ -130,7 +131,7 @@ func TestCleanMeson(t *testing.T) {
 			os.MkdirAll("subprojects/packagecache", 0755)
 			os.MkdirAll("build-release", 0755)
 
-			err := cleanMeson(tt.all)
+			err := cleanMeson(tt.all, false)
 			assert.NoError(t, err)
 
 			// Verify expected directories were removed
@@ -183,7 +184,7 @@ func TestCleanCMake(t *testing.T) {
 			os.MkdirAll("cmake-build-debug", 0755)
 			os.MkdirAll("build-release", 0755)
 
-			err := cleanCMake(tt.all)
+			err := cleanCMake(tt.all, false)
 			assert.NoError(t, err)
 
 			// Verify expected directories were removed
@@ -195,7 +196,7 @@ func TestCleanCMake(t *testing.T) {
 	}
 }
 
-func TestRemoveDir(t *testing.T) {
+func TestCleanerRemove(t *testing.T) {
 	// Use temp dir
 	tmpDir := t.TempDir()
 	oldWd, err := os.Getwd()
@@ -226,12 +227,13 @@ func TestRemoveDir(t *testing.T) {
 				require.NoError(t, os.MkdirAll(tt.path, 0755))
 			}
 
-			// removeDir should not panic or error even if dir doesn't exist
-			removeDir(tt.path)
+			// remove should not panic or error even if dir doesn't exist
+			c := &cleaner{}
+			c.remove(tt.path)
 
 			// Verify directory was removed or didn't exist
 			_, err := os.Stat(tt.path)
-			assert.True(t, os.IsNotExist(err), "directory should not exist after removeDir")
+			assert.True(t, os.IsNotExist(err), "directory should not exist after remove")
 		})
 	}
 }
@@ -313,3 +315,58 @@ func TestRunClean(t *testing.T) {
 		})
 	}
 }
+
+func TestCleanDryRunAndCI(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	writeFiles(t, ".", map[string]string{
+		"CMakeLists.txt":                       "cmake_minimum_required(VERSION 3.16)",
+		".cache/native/debug/app.o":            "0123456789",
+		".cache/native/vcpkg_installed/lib.a":  "0123456789",
+		".cache/ci/linux-amd64/.vcpkg_cache/x": "0123456789",
+		".vcpkg_cache/downloads/fmt.tar.gz":    "0123456789",
+		".bin/ci/linux-amd64/app":              "0123456789",
+	})
+	assert.Equal(t, int64(20), pathSize(".cache/native"))
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KB", formatSize(1536))
+	assert.Equal(t, "12.0 MB", formatSize(12<<20))
+
+	// A dry run removes nothing
+	c := &cleaner{dryRun: true}
+	c.remove(".cache")
+	c.remove("missing")
+	assert.Equal(t, int64(30), c.freed)
+	require.NoError(t, cleanCMake(true, true))
+	assert.DirExists(t, ".cache/native/debug")
+	assert.DirExists(t, ".vcpkg_cache")
+
+	// --ci only removes the CI caches
+	cmd := CleanCmd()
+	cmd.SetArgs([]string{"--ci"})
+	require.NoError(t, cmd.Execute())
+	assert.NoDirExists(t, ".cache/ci")
+	assert.DirExists(t, ".cache/native/vcpkg_installed")
+	assert.DirExists(t, ".bin/ci")
+
+	// Without --all, the build directories of every variant go, vcpkg_installed stays
+	writeFiles(t, ".", map[string]string{".cache/native/debug-clang-17/app.o": "x"})
+	require.NoError(t, cleanCMake(false, false))
+	assert.NoDirExists(t, ".cache/native/debug")
+	assert.NoDirExists(t, ".cache/native/debug-clang-17")
+	assert.DirExists(t, ".cache/native/vcpkg_installed")
+
+	cmd = CleanCmd()
+	cmd.SetArgs([]string{"--ci", "--all"})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+
+	// --all removes the caches
+	require.NoError(t, cleanCMake(true, false))
+	for _, dir := range []string{".cache", ".vcpkg_cache", ".bin"} {
+		assert.NoDirExists(t, dir)
+	}
+}
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -165,3 +168,28 @@ func TestRequireVcpkgProject(t *testing.T) {
 		})
 	}
 }
+
+func TestWithExitCode(t *testing.T) {
+	failing := func(err error) func(*cobra.Command, []string) error {
+		return func(*cobra.Command, []string) error { return err }
+	}
+
+	assert.NoError(t, withExitCode(exitcode.TestFailed, failing(nil))(nil, nil))
+
+	err := withExitCode(exitcode.TestFailed, failing(errors.New("tests failed")))(nil, nil)
+	assert.Equal(t, exitcode.TestFailed, exitcode.Of(err))
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	_, err = RequireProject("test")
+	err = withExitCode(exitcode.TestFailed, failing(err))(nil, nil)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+}
+
+func TestExitCodesHelp(t *testing.T) {
+	help := ExitCodesCmd().Long
+	for _, info := range exitcode.All {
+		assert.Contains(t, help, info.Name)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
 // Variables for mocking in tests
@@ -34,17 +37,27 @@ const Version = "1.0.16"
 // DefaultServer is the default server URL
 const DefaultServer = "https://cpx-dev.vercel.app"
 
+// addEnv appends env (KEY=VALUE entries) to the environment cmd runs with
+func addEnv(cmd *exec.Cmd, env []string) {
+	if len(env) == 0 {
+		return
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, env...)
+}
+
 // PrintError prints an error message
 func PrintError(format string, args ...interface{}) {
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(os.Stderr, "%s%s %s%s\n", Red, IconError, msg, Reset)
+	logging.Error(format, args...)
 }
 
 // requireVcpkgProject ensures the current directory has a vcpkg.json manifest.
 func requireVcpkgProject(cmdName string) error {
 	if _, err := os.Stat("vcpkg.json"); err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("%s requires a vcpkg project (vcpkg.json not found)\n  hint: run inside a vcpkg manifest project or create one with cpx new", cmdName)
+			return exitcode.Errorf(exitcode.Config, "%s requires a vcpkg project (vcpkg.json not found)\n  hint: run inside a vcpkg manifest project or create one with cpx new", cmdName)
 		}
 		return fmt.Errorf("failed to check vcpkg manifest: %w", err)
 	}
@@ -79,7 +92,7 @@ func DetectProjectType() ProjectType {
 func RequireProject(cmdName string) (ProjectType, error) {
 	pt := DetectProjectType()
 	if pt == ProjectTypeUnknown {
-		return pt, fmt.Errorf("%s requires a cpx project (vcpkg.json, MODULE.bazel, or meson.build not found)\n  hint: create one with cpx new", cmdName)
+		return pt, exitcode.Errorf(exitcode.Config, "%s requires a cpx project (vcpkg.json, MODULE.bazel, or meson.build not found)\n  hint: create one with cpx new", cmdName)
 	}
 	return pt, nil
 }
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/spf13/cobra"
+)
+
+// CompdbCmd creates the compdb command
+func CompdbCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compdb",
+		Short: "Merge compile_commands.json from all build directories",
+		Long: `Merge the compile_commands.json of every build directory (debug, release,
+sanitizer and optimization variants) into one deduplicated database for
+clangd, clang-tidy and other tooling.
+
+When a file appears in several build directories, --prefer decides which
+entry is kept: a comma-separated list of configs tried in order, or
+"newest" for the most recently configured build.
+
+In Meson projects the databases of builddir and the builddir-<name>
+directories of --compiler and --toolchain builds are merged. In Bazel
+projects the database is generated from the C++ compile actions reported
+by 'bazel aquery' for --target instead.`,
+		Example: `  cpx compdb                        # Prefer debug, then others
+  cpx compdb --prefer release,debug
+  cpx compdb --prefer newest -o build/compile_commands.json
+  cpx compdb --target //src/...     # Bazel: only the src package`,
+		Args: cobra.NoArgs,
+		RunE: runCompdb,
+	}
+
+	cmd.Flags().String("prefer", "debug", `Preferred configs in order, or "newest"`)
+	cmd.Flags().StringP("output", "o", "compile_commands.json", "Output file")
+	cmd.Flags().String("target", "//...", "Bazel target pattern whose compile actions are exported")
+	cmd.Flags().BoolP("verbose", "v", false, "Show bazel output")
+
+	return cmd
+}
+
+func runCompdb(cmd *cobra.Command, _ []string) error {
+	prefer, _ := cmd.Flags().GetString("prefer")
+	output, _ := cmd.Flags().GetString("output")
+
+	if DetectProjectType() == ProjectTypeBazel {
+		target, _ := cmd.Flags().GetString("target")
+		verbose, _ := cmd.Flags().GetBool("verbose")
+		count, err := generateBazelCompileDatabase(target, output, verbose)
+		if err != nil {
+			return err
+		}
+		logging.Success("✓ Wrote %s (%d entries from bazel aquery %s)", output, count, target)
+		return nil
+	}
+
+	find, buildDirs := build.FindCompileDatabases, ".cache/native"
+	if DetectProjectType() == ProjectTypeMeson {
+		find, buildDirs = build.FindMesonCompileDatabases, mesonBuildDir
+	}
+	dbs, err := find()
+	if err != nil {
+		return fmt.Errorf("failed to find compilation databases: %w", err)
+	}
+	if len(dbs) == 0 {
+		return fmt.Errorf("no compile_commands.json found in %s\n  hint: run 'cpx build' first", buildDirs)
+	}
+
+	dbs = build.OrderCompileDatabases(dbs, prefer)
+	entries, err := build.MergeCompileDatabases(dbs)
+	if err != nil {
+		return err
+	}
+	if err := build.WriteCompileDatabase(entries, output); err != nil {
+		return err
+	}
+
+	configs := make([]string, len(dbs))
+	for i, db := range dbs {
+		configs[i] = db.Config
+	}
+	logging.Success("✓ Wrote %s (%d entries from %d build directories)", output, len(entries), len(dbs))
+	fmt.Printf("  %sPreference: %v%s\n", Dim, configs, Reset)
+	return nil
+}
+
+// generateBazelCompileDatabase writes the compilation database for the C++
+// compile actions of target to path and returns the number of entries
+func generateBazelCompileDatabase(target, path string, verbose bool) (int, error) {
+	if _, err := execLookPath("bazel"); err != nil {
+		return 0, exitcode.Errorf(exitcode.ToolchainMissing, "bazel not found in PATH: %w", err)
+	}
+
+	workspace, err := os.Getwd()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	infoCmd := execCommand("bazel", "info", "execution_root")
+	if verbose {
+		infoCmd.Stderr = os.Stderr
+	}
+	out, err := infoCmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("bazel info execution_root failed: %w", err)
+	}
+	execRoot := strings.TrimSpace(string(out))
+
+	logging.Verbose("Querying compile actions for %s...", target)
+	aqueryCmd := execCommand("bazel", build.BazelAqueryArgs(target)...)
+	if verbose {
+		aqueryCmd.Stderr = os.Stderr
+	}
+	data, err := aqueryCmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("bazel aquery failed: %w\n  hint: run with -v to see the bazel output", err)
+	}
+
+	entries, err := build.ParseBazelAquery(data, workspace, execRoot)
+	if err != nil {
+		return 0, err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return 0, fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	if err := build.WriteCompileDatabase(entries, path); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateBazelCompileDatabase(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	execLookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	workspace, err := os.Getwd()
+	require.NoError(t, err)
+
+	path := filepath.Join(build.BazelCompdbDir, "compile_commands.json")
+	count, err := generateBazelCompileDatabase("//...", path, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entries []build.CompileCommand
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, workspace, entries[0].Directory)
+	assert.Equal(t, "src/main.cpp", entries[0].File)
+	assert.Contains(t, entries[0].Arguments, filepath.Join("/exec", "bazel-out/bin"))
+}
+
+func TestCompdbMeson(t *testing.T) {
+	t.Chdir(t.TempDir())
+	writeFiles(t, ".", map[string]string{
+		"meson.build": "project('app', 'cpp')\n",
+		"builddir/compile_commands.json": `[
+  {"directory": "/p/builddir", "file": "../src/main.cpp", "command": "c++ -O0 -c ../src/main.cpp"}
+]`,
+		"builddir-clang-17/compile_commands.json": `[
+  {"directory": "/p/builddir-clang-17", "file": "../src/main.cpp", "command": "clang++ -c ../src/main.cpp"},
+  {"directory": "/p/builddir-clang-17", "file": "../src/clang_only.cpp", "command": "clang++ -c ../src/clang_only.cpp"}
+]`,
+	})
+
+	cmd := CompdbCmd()
+	cmd.SetArgs([]string{})
+	require.NoError(t, cmd.Execute())
+	data, err := os.ReadFile("compile_commands.json")
+	require.NoError(t, err)
+	var entries []build.CompileCommand
+	require.NoError(t, json.Unmarshal(data, &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, "c++ -O0 -c ../src/main.cpp", entries[0].Command)
+	assert.Equal(t, "../src/clang_only.cpp", entries[1].File)
+
+	require.NoError(t, os.RemoveAll("builddir"))
+	require.NoError(t, os.RemoveAll("builddir-clang-17"))
+	cmd = CompdbCmd()
+	cmd.SetArgs([]string{})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	assert.ErrorContains(t, cmd.Execute(), "no compile_commands.json found in builddir")
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// CompletionCmd creates the completion command
+func CompletionCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "completion bash|zsh|fish|powershell",
+		Short: "Generate shell completion scripts",
+		Long: `Generate the completion script of cpx for a shell.
+
+Besides commands and flags, the scripts complete vcpkg package names for
+cpx add, info and why (from the port index cpx search keeps), dependencies
+of vcpkg.json for cpx remove, cpx.ci targets for cpx ci --target and the
+test names found in tests/ for cpx test --filter.
+
+  bash:       source <(cpx completion bash)
+              (or write it to /etc/bash_completion.d/cpx; needs bash-completion)
+  zsh:        cpx completion zsh > "${fpath[1]}/_cpx"
+  fish:       cpx completion fish > ~/.config/fish/completions/cpx.fish
+  powershell: cpx completion powershell | Out-String | Invoke-Expression`,
+		Example: `  cpx completion bash > ~/.local/share/bash-completion/completions/cpx
+  cpx completion zsh > "${fpath[1]}/_cpx"`,
+		ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+			if err := cobra.OnlyValidArgs(cmd, args); err != nil {
+				return exitcode.Errorf(exitcode.Usage, "%v\n  hint: use bash, zsh, fish or powershell", err)
+			}
+			return nil
+		},
+		DisableFlagsInUseLine: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			root := cmd.Root()
+			out := cmd.OutOrStdout()
+			switch args[0] {
+			case "bash":
+				return root.GenBashCompletionV2(out, true)
+			case "zsh":
+				return root.GenZshCompletion(out)
+			case "fish":
+				return root.GenFishCompletion(out, true)
+			default:
+				return root.GenPowerShellCompletionWithDesc(out)
+			}
+		},
+	}
+
+	return cmd
+}
+
+// completePorts completes the names of vcpkg ports from the saved port
+// index. Completion must be fast, so a missing or stale index isn't rebuilt.
+func completePorts(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	path, err := portIndexPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	index, err := vcpkg.LoadIndex(path)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, port := range index.Ports {
+		if strings.HasPrefix(port.Name, toComplete) && !slices.Contains(args, port.Name) {
+			names = append(names, port.Name+"\t"+port.Description)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeDependencies completes the dependencies of vcpkg.json
+func completeDependencies(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	manifest, err := vcpkg.ReadManifest("vcpkg.json")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, dep := range manifestDependencies(manifest) {
+		if strings.HasPrefix(dep.Name, toComplete) && !slices.Contains(args, dep.Name) {
+			names = append(names, dep.Name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeCITargets completes the names of the targets in cpx.ci
+func completeCITargets(_ *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ciConfig, err := config.LoadCI("cpx.ci")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, target := range ciConfig.Targets {
+		if strings.HasPrefix(target.Name, toComplete) && !slices.Contains(args, target.Name) {
+			names = append(names, target.Name+"\t"+target.Source)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTestNames completes the tests found in the sources in tests/
+func completeTestNames(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var names []string
+	for _, name := range testNames("tests") {
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+var (
+	// gtestTestRe matches googletest tests; parameterized ones get
+	// instance prefixes and suffixes, so they're left out
+	gtestTestRe = regexp.MustCompile(`\b(?:TEST|TEST_F|TYPED_TEST)\s*\(\s*(\w+)\s*,\s*(\w+)\s*\)`)
+	// testCaseRe matches Catch2 and doctest test cases, with Catch2 tags
+	testCaseRe = regexp.MustCompile(`\bTEST_CASE(?:_METHOD)?\s*\(\s*(?:\w+\s*,\s*)?"((?:[^"\\]|\\.)*)"(?:\s*,\s*"([^"]*)")?`)
+	testTagRe  = regexp.MustCompile(`\[[^\]]+\]`)
+)
+
+// testNames returns the names --filter accepts for the tests in the sources
+// under dir: Suite.Name and Suite.* for googletest, test case names and tags
+// for Catch2 and doctest
+func testNames(dir string) []string {
+	seen := make(map[string]bool)
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".cpp", ".cc", ".cxx", ".hpp", ".h":
+		default:
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, m := range gtestTestRe.FindAllStringSubmatch(string(data), -1) {
+			seen[m[1]+".*"] = true
+			seen[fmt.Sprintf("%s.%s", m[1], m[2])] = true
+		}
+		for _, m := range testCaseRe.FindAllStringSubmatch(string(data), -1) {
+			seen[strings.ReplaceAll(m[1], `\"`, `"`)] = true
+			for _, tag := range testTagRe.FindAllString(m[2], -1) {
+				seen[tag] = true
+			}
+		}
+		return nil
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
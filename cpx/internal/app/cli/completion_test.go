@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompletionCmd(t *testing.T) {
+	root := &cobra.Command{Use: "cpx"}
+	root.AddCommand(CompletionCmd())
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetArgs([]string{"completion", shell})
+		require.NoError(t, root.Execute(), shell)
+		assert.Contains(t, out.String(), "cpx", shell)
+	}
+
+	root.SetArgs([]string{"completion", "tcsh"})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(root.Execute()))
+}
+
+func TestCompletions(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// Nothing to complete outside a project
+	names, directive := completePorts(nil, nil, "")
+	assert.Empty(t, names)
+	assert.Equal(t, cobra.ShellCompDirectiveNoFileComp, directive)
+	names, _ = completeCITargets(nil, nil, "")
+	assert.Empty(t, names)
+
+	path, err := portIndexPath()
+	require.NoError(t, err)
+	index := &vcpkg.Index{Ports: []vcpkg.Port{
+		{Name: "fmt", Description: "Formatting library"},
+		{Name: "fmtlog"},
+		{Name: "zlib"},
+	}}
+	require.NoError(t, index.Save(path))
+	names, _ = completePorts(nil, []string{"fmtlog"}, "fm")
+	assert.Equal(t, []string{"fmt\tFormatting library"}, names)
+
+	writeFiles(t, ".", map[string]string{
+		"vcpkg.json": `{"dependencies": ["fmt", {"name": "spdlog"}]}`,
+		"cpx.ci": `targets:
+  - name: linux-amd64
+    image: Dockerfile.linux-amd64
+  - name: linux-arm64
+    image: Dockerfile.linux-arm64
+  - name: windows-amd64
+    image: Dockerfile.windows-amd64
+`,
+		"tests/test_math.cpp": `#include <gtest/gtest.h>
+TEST(Math, Adds) {}
+TEST_F(MathFixture, Divides) {}
+TEST_P(Params, Skipped) {}
+`,
+		"tests/catch/test_io.cpp": `#include <catch2/catch_test_macros.hpp>
+TEST_CASE("reads \"quoted\" files", "[io][fast]") {}
+TEST_CASE_METHOD(Fixture, "writes files") {}
+`,
+		"tests/data.json": `TEST(Not, Source)`,
+	})
+
+	names, _ = completeDependencies(nil, []string{"fmt"}, "")
+	assert.Equal(t, []string{"spdlog"}, names)
+	names, _ = completeCITargets(nil, nil, "linux")
+	assert.Equal(t, []string{"linux-amd64\tDockerfile.linux-amd64", "linux-arm64\tDockerfile.linux-arm64"}, names)
+
+	assert.Equal(t, []string{
+		"Math.*", "Math.Adds", "MathFixture.*", "MathFixture.Divides",
+		"[fast]", "[io]", `reads "quoted" files`, "writes files",
+	}, testNames("tests"))
+	names, _ = completeTestNames(nil, nil, "Math.")
+	assert.Equal(t, []string{"Math.*", "Math.Adds"}, names)
+}
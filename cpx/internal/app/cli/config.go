@@ -5,7 +5,14 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/naming"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/telemetry"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -55,6 +62,83 @@ func ConfigCmd() *cobra.Command {
 	}
 	cmd.AddCommand(setWrapdbRootCmd)
 
+	setOfflineCmd := &cobra.Command{
+		Use:   "set-offline <true|false>",
+		Short: "Run every command offline",
+		Long:  "Make every command run as with --offline: no template downloads, registry updates, version checks or vcpkg downloads.",
+		RunE:  runConfigSetOffline,
+		Args:  cobra.ExactArgs(1),
+	}
+	cmd.AddCommand(setOfflineCmd)
+
+	telemetryCmd := &cobra.Command{
+		Use:   "telemetry [on|off]",
+		Short: "Opt in to or out of anonymous usage telemetry",
+		Long: `Turn anonymous usage telemetry on or off; without an argument, show its
+state. Telemetry is off unless turned on here.
+
+Each event holds the command name (e.g. "cpx build", never its arguments),
+its duration, whether it succeeded, and the version, OS and architecture of
+cpx: no project names, paths, dependencies or user data. Events are queued in
+the cpx config directory and sent in batches to telemetry_endpoint (or
+$CPX_TELEMETRY_ENDPOINT) as
+
+  POST {"events": [{"command", "duration_ms", "exit_code", "success",
+                    "os", "arch", "version", "time"}, ...]}
+
+Nothing is sent offline, without an endpoint, or with CPX_TELEMETRY=0 or
+DO_NOT_TRACK=1. Turning telemetry off deletes the queued events.`,
+		Example: `  cpx config telemetry on
+  cpx config telemetry off`,
+		ValidArgs: []string{"on", "off"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+			if err := cobra.OnlyValidArgs(cmd, args); err != nil {
+				return exitcode.Errorf(exitcode.Usage, "%v\n  hint: use on or off", err)
+			}
+			return nil
+		},
+		RunE: runConfigTelemetry,
+	}
+	cmd.AddCommand(telemetryCmd)
+
+	setBinaryCacheCmd := &cobra.Command{
+		Use:   "set-binary-cache <files|nuget|gcs|s3> <url>",
+		Short: "Set the vcpkg binary cache",
+		Long: `Set where vcpkg stores built packages, for local builds and cpx ci builds:
+a directory (files), a NuGet feed (nuget) or a Google Cloud Storage or S3
+bucket (gcs, s3). "cpx config set-binary-cache none" goes back to vcpkg's
+default cache. VCPKG_BINARY_SOURCES, if set, takes precedence.
+
+cpx ci mounts a files cache into the build containers and passes the AWS_*
+credentials of an s3 cache (CLOUDSDK_AUTH_ACCESS_TOKEN for gcs) on to them.`,
+		Example: `  cpx config set-binary-cache files /mnt/shared/vcpkg-cache
+  cpx config set-binary-cache s3 s3://acme-ci/vcpkg --mode read
+  cpx config set-binary-cache none`,
+		RunE: runConfigSetBinaryCache,
+		Args: cobra.RangeArgs(1, 2),
+	}
+	setBinaryCacheCmd.Flags().String("mode", "readwrite", "Cache access: read, write or readwrite")
+	cmd.AddCommand(setBinaryCacheCmd)
+
+	addTemplateRepoCmd := &cobra.Command{
+		Use:   "add-template-repo <git-url>",
+		Short: "Add a repository of project templates",
+		Long: `Add a git repository of project templates for "cpx new --template <repo>:<template>".
+Every top-level directory of the repository is a template. The repository is
+cloned into a local cache; --ref pins a branch or tag. Adding a repository
+again with the same name replaces it and refreshes the cache.`,
+		Example: `  cpx config add-template-repo https://github.com/acme/cpp-templates.git
+  cpx config add-template-repo git@github.com:acme/templates.git --name acme --ref v2.0.0`,
+		RunE: runConfigAddTemplateRepo,
+		Args: cobra.ExactArgs(1),
+	}
+	addTemplateRepoCmd.Flags().String("name", "", "Repository name used in --template <name>:<template> (default: derived from the URL)")
+	addTemplateRepoCmd.Flags().String("ref", "", "Branch or tag to use (default: the default branch)")
+	cmd.AddCommand(addTemplateRepoCmd)
+
 	return cmd
 }
 
@@ -78,6 +162,32 @@ func runConfigSetWrapdbRoot(_ *cobra.Command, args []string) error {
 	return setWrapdbRoot(args[0])
 }
 
+func runConfigSetOffline(_ *cobra.Command, args []string) error {
+	return setOffline(args[0])
+}
+
+func runConfigTelemetry(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return showTelemetry()
+	}
+	return setTelemetry(args[0] == "on")
+}
+
+func runConfigSetBinaryCache(cmd *cobra.Command, args []string) error {
+	mode, _ := cmd.Flags().GetString("mode")
+	url := ""
+	if len(args) > 1 {
+		url = args[1]
+	}
+	return setBinaryCache(args[0], url, mode)
+}
+
+func runConfigAddTemplateRepo(cmd *cobra.Command, args []string) error {
+	name, _ := cmd.Flags().GetString("name")
+	ref, _ := cmd.Flags().GetString("ref")
+	return addTemplateRepo(args[0], name, ref)
+}
+
 func showConfig() error {
 	configPath, err := config.GetConfigPath()
 	if err != nil {
@@ -97,6 +207,21 @@ func showConfig() error {
 	fmt.Printf("  vcpkg_root:  %s\n", cfg.VcpkgRoot)
 	fmt.Printf("  bcr_root:    %s\n", cfg.BcrRoot)
 	fmt.Printf("  wrapdb_root: %s\n", cfg.WrapdbRoot)
+	fmt.Printf("  offline:     %t\n", cfg.Offline)
+	fmt.Printf("  telemetry:   %t\n", cfg.Telemetry)
+	if cfg.BinaryCache != nil {
+		fmt.Printf("  binary_cache: %s %s (%s)\n", cfg.BinaryCache.Kind, cfg.BinaryCache.URL, binaryCacheMode(cfg.BinaryCache))
+	}
+	if len(cfg.TemplateRepos) > 0 {
+		fmt.Printf("  template_repos:\n")
+		for _, repo := range cfg.TemplateRepos {
+			ref := ""
+			if repo.Ref != "" {
+				ref = " @ " + repo.Ref
+			}
+			fmt.Printf("    %s: %s%s\n", repo.Name, repo.URL, ref)
+		}
+	}
 	return nil
 }
 
@@ -116,6 +241,17 @@ func getConfig(key string) error {
 	case "wrapdb_root", "wrapdb-root":
 		fmt.Println(cfg.WrapdbRoot)
 		return nil
+	case "offline":
+		fmt.Println(cfg.Offline)
+		return nil
+	case "telemetry":
+		fmt.Println(cfg.Telemetry)
+		return nil
+	case "binary_cache", "binary-cache":
+		if cfg.BinaryCache != nil {
+			fmt.Println(cfg.BinaryCache.VcpkgSource())
+		}
+		return nil
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
@@ -133,7 +269,7 @@ func setVcpkgRoot(path string) error {
 		vcpkgExe += ".exe"
 	}
 	if _, err := os.Stat(vcpkgExe); os.IsNotExist(err) {
-		fmt.Printf("%s Warning: %s does not appear to be a vcpkg directory%s\n", Yellow, path, Reset)
+		logging.Warn("%s does not appear to be a vcpkg directory", path)
 		fmt.Printf("  (vcpkg executable not found at %s)\n", vcpkgExe)
 	}
 
@@ -154,7 +290,7 @@ func setVcpkgRoot(path string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("%s Set vcpkg_root to %s%s\n", Green, absPath, Reset)
+	logging.Success("Set vcpkg_root to %s", absPath)
 	return nil
 }
 
@@ -167,7 +303,7 @@ func setBcrRoot(path string) error {
 	// Check if it looks like a BCR directory
 	modulesDir := filepath.Join(path, "modules")
 	if _, err := os.Stat(modulesDir); os.IsNotExist(err) {
-		fmt.Printf("%s Warning: %s does not appear to be a BCR directory%s\n", Yellow, path, Reset)
+		logging.Warn("%s does not appear to be a BCR directory", path)
 		fmt.Printf("  (modules directory not found at %s)\n", modulesDir)
 	}
 
@@ -188,7 +324,7 @@ func setBcrRoot(path string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("%s✓ Set bcr_root to %s%s\n", Green, absPath, Reset)
+	logging.Success("✓ Set bcr_root to %s", absPath)
 	return nil
 }
 
@@ -215,6 +351,183 @@ func setWrapdbRoot(path string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("%s✓ Set wrapdb_root to %s%s\n", Green, absPath, Reset)
+	logging.Success("✓ Set wrapdb_root to %s", absPath)
+	return nil
+}
+
+func setOffline(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return exitcode.Errorf(exitcode.Usage, "invalid value %q\n  hint: use true or false", value)
+	}
+
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		cfg = &config.GlobalConfig{}
+	}
+	cfg.Offline = on
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	logging.Success("✓ Set offline to %t", on)
+	return nil
+}
+
+func binaryCacheMode(cache *config.BinaryCache) string {
+	if cache.Mode == "" {
+		return "readwrite"
+	}
+	return cache.Mode
+}
+
+func setBinaryCache(kind, url, mode string) error {
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		cfg = &config.GlobalConfig{}
+	}
+
+	if kind == "none" {
+		if url != "" {
+			return exitcode.Errorf(exitcode.Usage, "none takes no URL")
+		}
+		cfg.BinaryCache = nil
+		if err := config.SaveGlobal(cfg); err != nil {
+			return fmt.Errorf("failed to save config: %w", err)
+		}
+		logging.Success("✓ Removed the binary cache; vcpkg uses its default cache")
+		return nil
+	}
+
+	if _, ok := config.BinaryCacheKinds[kind]; ok && url == "" {
+		return exitcode.Errorf(exitcode.Usage, "missing the URL of the %s cache\n  hint: cpx config set-binary-cache %s <url>", kind, kind)
+	}
+	if err := checkBinaryCache(kind, url, mode); err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if kind == "files" {
+		if url, err = filepath.Abs(url); err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+	}
+
+	cfg.BinaryCache = &config.BinaryCache{Kind: kind, URL: url, Mode: mode}
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	logging.Success("✓ Set binary_cache to %s %s (%s)", kind, url, mode)
+	return nil
+}
+
+// checkBinaryCache validates the kind, URL and mode of a binary cache
+func checkBinaryCache(kind, url, mode string) error {
+	if _, ok := config.BinaryCacheKinds[kind]; !ok {
+		return fmt.Errorf("unknown binary cache kind %q\n  hint: use files, nuget, gcs, s3 or none", kind)
+	}
+	if url == "" {
+		return fmt.Errorf("missing the URL of the %s cache", kind)
+	}
+	if mode != "read" && mode != "write" && mode != "readwrite" {
+		return fmt.Errorf("invalid mode %q\n  hint: use read, write or readwrite", mode)
+	}
+	switch kind {
+	case "gcs":
+		if !strings.HasPrefix(url, "gs://") {
+			return fmt.Errorf("gcs caches are gs://<bucket>/<prefix> URLs, got %q", url)
+		}
+	case "s3":
+		if !strings.HasPrefix(url, "s3://") {
+			return fmt.Errorf("s3 caches are s3://<bucket>/<prefix> URLs, got %q", url)
+		}
+	}
+	return nil
+}
+
+func addTemplateRepo(url, name, ref string) error {
+	if err := offline.Required("adding a template repository"); err != nil {
+		return err
+	}
+	if name == "" {
+		name = templateRepoName(url)
+	}
+	if !naming.IsValidProjectName(name) {
+		return exitcode.Errorf(exitcode.Usage, "invalid template repository name %q\n  hint: pass --name with letters, numbers, hyphens and underscores", name)
+	}
+
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		cfg = &config.GlobalConfig{}
+	}
+
+	repo := config.TemplateRepo{Name: name, URL: url, Ref: ref}
+	dir, err := fetchTemplateRepo(repo, true)
+	if err != nil {
+		return err
+	}
+	names, err := repoTemplates(dir)
+	if err != nil {
+		return err
+	}
+
+	cfg.SetTemplateRepo(repo)
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	logging.Success("✓ Added template repository %s (%d templates)", name, len(names))
+	for _, template := range names {
+		fmt.Printf("  cpx new --template %s:%s <project>\n", name, template)
+	}
+	return nil
+}
+
+func showTelemetry() error {
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	switch {
+	case !cfg.Telemetry:
+		fmt.Println("Telemetry is off")
+		return nil
+	case !telemetry.Enabled(cfg):
+		fmt.Printf("Telemetry is on, but disabled by $%s or $DO_NOT_TRACK\n", telemetry.EnvVar)
+	default:
+		fmt.Println("Telemetry is on")
+	}
+	if endpoint := telemetry.Endpoint(cfg); endpoint != "" {
+		fmt.Printf("  endpoint: %s\n", endpoint)
+	} else {
+		fmt.Printf("  endpoint: none (events stay queued; set telemetry_endpoint or $%s)\n", telemetry.EndpointEnvVar)
+	}
+	queued, err := telemetry.Queued()
+	if err != nil {
+		return fmt.Errorf("failed to read the telemetry queue: %w", err)
+	}
+	fmt.Printf("  queued:   %d events\n", len(queued))
+	return nil
+}
+
+func setTelemetry(on bool) error {
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		cfg = &config.GlobalConfig{}
+	}
+	cfg.Telemetry = on
+	if err := config.SaveGlobal(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if !on {
+		if err := telemetry.Clear(); err != nil {
+			return fmt.Errorf("failed to delete the queued telemetry events: %w", err)
+		}
+		logging.Success("✓ Turned telemetry off")
+		return nil
+	}
+	logging.Success("✓ Turned telemetry on")
+	fmt.Println("  cpx records the command name, duration, exit code, OS and architecture; see 'cpx config telemetry --help'")
 	return nil
 }
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/telemetry"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -368,3 +370,62 @@ func TestSetWrapdbRoot(t *testing.T) {
 		})
 	}
 }
+
+func TestSetBinaryCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	tests := []struct {
+		name string
+		kind string
+		url  string
+		mode string
+		want *config.BinaryCache
+	}{
+		{"files", "files", filepath.Join(tmpDir, "cache"), "readwrite", &config.BinaryCache{Kind: "files", URL: filepath.Join(tmpDir, "cache"), Mode: "readwrite"}},
+		{"s3 read-only", "s3", "s3://acme/vcpkg", "read", &config.BinaryCache{Kind: "s3", URL: "s3://acme/vcpkg", Mode: "read"}},
+		{"none", "none", "", "readwrite", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, setBinaryCache(tt.kind, tt.url, tt.mode))
+			cfg, err := config.LoadGlobal()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, cfg.BinaryCache)
+		})
+	}
+
+	invalid := [][3]string{
+		{"azure", "https://example.com", "readwrite"},
+		{"files", "", "readwrite"},
+		{"gcs", "s3://acme", "readwrite"},
+		{"s3", "s3://acme", "rw"},
+		{"none", "/tmp", "readwrite"},
+	}
+	for _, args := range invalid {
+		err := setBinaryCache(args[0], args[1], args[2])
+		assert.Equal(t, exitcode.Usage, exitcode.Of(err), args)
+	}
+}
+
+func TestSetTelemetry(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	require.NoError(t, setTelemetry(true))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	assert.True(t, cfg.Telemetry)
+	require.NoError(t, showTelemetry())
+
+	path, err := telemetry.QueuePath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`{"command":"cpx build"}`+"\n"), 0644))
+
+	// Opting out drops the queued events
+	require.NoError(t, setTelemetry(false))
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.False(t, cfg.Telemetry)
+	assert.NoFileExists(t, path)
+}
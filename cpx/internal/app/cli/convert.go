@@ -0,0 +1,392 @@
+package cli
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/bazel"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/spf13/cobra"
+)
+
+// ConvertCmd creates the convert command
+func ConvertCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert --to bazel|meson|cmake",
+		Short: "Convert the project to another build system",
+		Long: `Convert the project to another build system.
+
+cpx convert generates the build files of the other system from the layout of
+the project (include/<name>, src, tests and bench) and its dependencies:
+vcpkg.json for CMake, the bazel_dep calls of MODULE.bazel for Bazel and the
+wraps in subprojects for Meson. Known libraries are mapped to their vcpkg
+port, Bazel Central Registry module or Meson wrap; the others are listed at
+the end, to add by hand.
+
+Files that exist already are kept, and the build files of the current system
+are left in place until you remove them.`,
+		Example: `  cpx convert --to bazel
+  cpx convert --to meson
+  cpx convert --to cmake`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			to, _ := cmd.Flags().GetString("to")
+			return runConvert(client, to)
+		},
+	}
+	cmd.Flags().String("to", "", "Build system to convert to: bazel, meson or cmake")
+
+	return cmd
+}
+
+// convertSourceFiles are the build files of each system, left for the user
+// to remove after a conversion
+var convertSourceFiles = map[ProjectType][]string{
+	ProjectTypeVcpkg:   {"vcpkg.json", "CMakeLists.txt"},
+	ProjectTypeBazel:   {"MODULE.bazel", "BUILD.bazel"},
+	ProjectTypeMeson:   {"meson.build"},
+	ProjectTypeUnknown: {"CMakeLists.txt"},
+}
+
+func runConvert(client *vcpkg.Client, to string) error {
+	from := DetectProjectType()
+	if from == ProjectTypeUnknown {
+		if _, err := os.Stat("CMakeLists.txt"); err != nil {
+			return exitcode.Errorf(exitcode.Config, "cpx convert requires a cpx project (vcpkg.json, MODULE.bazel, meson.build or CMakeLists.txt not found)\n  hint: create one with cpx new")
+		}
+	}
+
+	switch to {
+	case "bazel", "meson", "cmake":
+	case "":
+		return exitcode.Errorf(exitcode.Usage, "no build system to convert to\n  hint: use --to bazel, --to meson or --to cmake")
+	default:
+		return exitcode.Errorf(exitcode.Usage, "unknown build system %q\n  hint: use --to bazel, --to meson or --to cmake", to)
+	}
+	if to == "cmake" && (from == ProjectTypeVcpkg || from == ProjectTypeUnknown) ||
+		to == "bazel" && from == ProjectTypeBazel || to == "meson" && from == ProjectTypeMeson {
+		hint := "convert to another build system"
+		if from == ProjectTypeUnknown {
+			hint = "adopt a CMake project with cpx migrate"
+		}
+		return exitcode.Errorf(exitcode.Usage, "the project already builds with %s\n  hint: %s", to, hint)
+	}
+
+	project, unmapped, err := convertedProject(client, from)
+	if err != nil {
+		return err
+	}
+
+	var files map[string]string
+	var wraps []string
+	var missing []string
+	switch to {
+	case "cmake":
+		files, missing = templates.GenerateConvertedCMake(project)
+		manifest, err := migrationManifest(&cmakeProject{Name: project.Name, Version: project.Version}, project.Dependencies)
+		if err != nil {
+			return err
+		}
+		files["vcpkg.json"] = manifest
+	case "bazel":
+		versions, err := bcrVersions(project)
+		if err != nil {
+			return err
+		}
+		files, missing = templates.GenerateConvertedBazel(project, versions)
+	case "meson":
+		files, wraps, missing = templates.GenerateConvertedMeson(project)
+	}
+	unmapped = append(unmapped, missing...)
+
+	logging.Step("Converting %s to %s...", project.Name, to)
+	w := newProjectWriter(".", true)
+	paths := make([]string, 0, len(files))
+	for rel := range files {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+	for _, rel := range paths {
+		if err := w.write(rel, files[rel]); err != nil {
+			return fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+	}
+	if err := w.saveManifest(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", TemplateManifestPath, err)
+	}
+	w.printMergeSummary()
+
+	if len(wraps) > 0 {
+		installConvertedWraps(wraps)
+	}
+
+	if len(unmapped) > 0 {
+		logging.Warn("\ndependencies cpx can't map to %s:\n  %s\n  hint: add them with cpx add, and to the build files by hand", to, strings.Join(unmapped, "\n  "))
+	}
+
+	logging.Success("\n%s Project converted to %s", IconSuccess, to)
+	fmt.Printf("  hint: check the build with the new files, then remove %s\n\n", strings.Join(convertSourceFiles[from], " and "))
+	return nil
+}
+
+// bcrVersions returns the latest versions of the Bazel Central Registry
+// modules of the dependencies of project. Modules missing from the registry
+// are left out.
+func bcrVersions(project *templates.ConvertedProject) (map[string]string, error) {
+	versions := make(map[string]string)
+	var modules []string
+	for _, port := range project.Dependencies {
+		if dep, ok := templates.LookupBuildDependency(port); ok && dep.BCRModule != "" {
+			modules = append(modules, dep.BCRModule)
+		}
+	}
+	if len(modules) == 0 {
+		return versions, nil
+	}
+
+	bcrPath := addGetBcrPathFunc()
+	if bcrPath == "" {
+		return nil, exitcode.Errorf(exitcode.Config, "bazel Central Registry not configured\n  hint: run 'cpx config set-bcr-root <path>' or reinstall cpx")
+	}
+	for _, module := range modules {
+		if version, err := bazelGetLatestVersionFunc(bcrPath, module); err == nil {
+			versions[module] = version
+		}
+	}
+	return versions, nil
+}
+
+// installConvertedWraps installs the wraps of a project converted to Meson
+func installConvertedWraps(wraps []string) {
+	if offline.Enabled() {
+		logging.Notice("Offline: install the wraps later with meson wrap install %s", strings.Join(wraps, " "))
+		return
+	}
+	if err := createDirIfNotExists("subprojects"); err != nil {
+		logging.Warn("failed to create subprojects: %v", err)
+		return
+	}
+	for _, wrap := range wraps {
+		if _, err := os.Stat(filepath.Join("subprojects", wrap+".wrap")); err == nil {
+			continue
+		}
+		if err := downloadMesonWrap(".", wrap); err != nil {
+			logging.Warn("%v", err)
+			fmt.Printf("  hint: install it later with meson wrap install %s\n", wrap)
+		}
+	}
+}
+
+var (
+	bazelModuleRe  = regexp.MustCompile(`module\s*\(\s*name\s*=\s*"([^"]+)"(?:\s*,\s*version\s*=\s*"([^"]+)")?`)
+	mesonProjectRe = regexp.MustCompile(`project\s*\(\s*'([^']+)'`)
+	mesonVersionRe = regexp.MustCompile(`version\s*:\s*'([^']+)'`)
+)
+
+// bazelSupportModules are the modules of Bazel's own rules, which have no
+// counterpart in the other build systems
+var bazelSupportModules = map[string]bool{
+	"rules_cc": true, "platforms": true, "bazel_skylib": true, "rules_foreign_cc": true, "rules_license": true,
+}
+
+// convertedProject reads the project in the current directory, of type from,
+// and returns it with the dependencies that have no vcpkg port
+func convertedProject(client *vcpkg.Client, from ProjectType) (*templates.ConvertedProject, []string, error) {
+	project := &templates.ConvertedProject{CppStandard: detectCppStandard(from)}
+
+	var deps, unmapped []string
+	switch from {
+	case ProjectTypeVcpkg, ProjectTypeUnknown:
+		if cmake, err := analyzeCMakeProject("."); err == nil {
+			project.Name, project.Version = cmake.Name, cmake.Version
+			if cmake.CppStandard > 0 {
+				project.CppStandard = cmake.CppStandard
+			}
+			if from == ProjectTypeUnknown {
+				var index *vcpkg.Index
+				if client != nil {
+					if vcpkgPath, err := client.GetPath(); err == nil {
+						index, _ = loadPortIndex(filepath.Dir(vcpkgPath), false)
+					}
+				}
+				deps, unmapped = migrationDependencies(cmake.Packages, index)
+			}
+		}
+		if from == ProjectTypeVcpkg {
+			manifest, err := vcpkg.ReadManifest("vcpkg.json")
+			if err != nil {
+				return nil, nil, err
+			}
+			if project.Name == "" {
+				project.Name, _ = manifest["name"].(string)
+			}
+			if project.Version == "" {
+				project.Version, _ = manifest["version"].(string)
+			}
+			for _, dep := range manifestDependencies(manifest) {
+				// Host tools such as vcpkg-cmake are vcpkg's own
+				if !dep.Host && !strings.HasPrefix(dep.Name, "vcpkg-") {
+					deps = append(deps, dep.Name)
+				}
+			}
+		}
+	case ProjectTypeBazel:
+		if data, err := os.ReadFile("MODULE.bazel"); err == nil {
+			if m := bazelModuleRe.FindStringSubmatch(string(data)); m != nil {
+				project.Name, project.Version = m[1], m[2]
+			}
+		}
+		modules, err := bazel.ListDependencies("MODULE.bazel")
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, module := range modules {
+			if bazelSupportModules[module.Name] {
+				continue
+			}
+			if port, ok := templates.PortForBCRModule(module.Name); ok {
+				deps = append(deps, port)
+			} else {
+				unmapped = append(unmapped, module.Name)
+			}
+		}
+	case ProjectTypeMeson:
+		if data, err := os.ReadFile("meson.build"); err == nil {
+			if m := mesonProjectRe.FindSubmatch(data); m != nil {
+				project.Name = string(m[1])
+			}
+			if m := mesonVersionRe.FindSubmatch(data); m != nil {
+				project.Version = string(m[1])
+			}
+		}
+		wraps, _ := filepath.Glob(filepath.Join("subprojects", "*.wrap"))
+		for _, wrap := range wraps {
+			name := strings.TrimSuffix(filepath.Base(wrap), ".wrap")
+			if port, ok := templates.PortForWrap(name); ok {
+				deps = append(deps, port)
+			} else {
+				unmapped = append(unmapped, name)
+			}
+		}
+	}
+	if project.Name == "" {
+		if wd, err := os.Getwd(); err == nil {
+			project.Name = filepath.Base(wd)
+		}
+	}
+	if project.Version == "" {
+		project.Version = "0.1.0"
+	}
+
+	sources, err := convertSources("src")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, src := range sources {
+		if src == "main.cpp" {
+			project.IsExe = true
+		} else {
+			project.Sources = append(project.Sources, src)
+		}
+	}
+	if project.TestSources, err = convertSources("tests"); err != nil {
+		return nil, nil, err
+	}
+	if project.BenchSources, err = convertSources("bench"); err != nil {
+		return nil, nil, err
+	}
+	project.TestFramework = detectFramework("tests", testFrameworkIncludes)
+	project.BenchFramework = detectFramework("bench", benchFrameworkIncludes)
+
+	// The build files set up the frameworks themselves
+	frameworks := []string{templates.TestFrameworkPorts[project.TestFramework], templates.BenchFrameworkPorts[project.BenchFramework]}
+	for _, dep := range deps {
+		if !slices.Contains(frameworks, dep) && !slices.Contains(project.Dependencies, dep) {
+			project.Dependencies = append(project.Dependencies, dep)
+		}
+	}
+	return project, unmapped, nil
+}
+
+// convertSources returns the C and C++ sources under dir, relative to it
+func convertSources(dir string) ([]string, error) {
+	var sources []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch filepath.Ext(path) {
+		case ".cpp", ".cc", ".cxx", ".c":
+		default:
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the sources in %s: %w", dir, err)
+	}
+	return sources, nil
+}
+
+// testFrameworkIncludes and benchFrameworkIncludes identify the framework
+// of tests and benchmarks by the headers they include, in order
+var (
+	testFrameworkIncludes = []struct{ header, framework string }{
+		{"gtest/gtest.h", "googletest"},
+		{"catch2/", "catch2"},
+		{"doctest", "doctest"},
+	}
+	benchFrameworkIncludes = []struct{ header, framework string }{
+		{"benchmark/benchmark.h", "google-benchmark"},
+		{"nanobench.h", "nanobench"},
+		{"catch2/", "catch2-benchmark"},
+	}
+)
+
+// detectFramework returns the framework of the first include in the sources
+// under dir that identifies one, or "none"
+func detectFramework(dir string, includes []struct{ header, framework string }) string {
+	sources, err := convertSources(dir)
+	if err != nil {
+		return "none"
+	}
+	for _, src := range sources {
+		data, err := os.ReadFile(filepath.Join(dir, src))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "#include") {
+				continue
+			}
+			for _, inc := range includes {
+				if strings.Contains(line, inc.header) {
+					return inc.framework
+				}
+			}
+		}
+	}
+	return "none"
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(offline.EnvVar, "1")
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	oldGetBcrPathFunc := addGetBcrPathFunc
+	oldGetLatestVersionFunc := bazelGetLatestVersionFunc
+	defer func() {
+		addGetBcrPathFunc = oldGetBcrPathFunc
+		bazelGetLatestVersionFunc = oldGetLatestVersionFunc
+	}()
+	addGetBcrPathFunc = func() string { return "" }
+	bazelGetLatestVersionFunc = func(bcrPath, moduleName string) (string, error) {
+		if moduleName == "fmt" {
+			return "11.0.2", nil
+		}
+		return "", assert.AnError
+	}
+
+	err = runConvert(nil, "bazel")
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+
+	writeFiles(t, ".", map[string]string{
+		"vcpkg.json": `{"name": "app", "version": "2.0.0", "dependencies": [
+  "fmt", "gtest", "acme-log", {"name": "vcpkg-cmake", "host": true}]}`,
+		"CMakeLists.txt":        "cmake_minimum_required(VERSION 3.20)\nproject(app VERSION 2.0.0 LANGUAGES CXX)\nset(CMAKE_CXX_STANDARD 20)\n",
+		"include/app/app.hpp":   "#pragma once\n",
+		"src/app.cpp":           "#include <app/app.hpp>\n",
+		"src/main.cpp":          "int main() {}\n",
+		"tests/main.cpp":        "#include <gtest/gtest.h>\n",
+		"tests/test_parse.cpp":  "#include <gtest/gtest.h>\n",
+		"bench/bench_main.cpp":  "#include <benchmark/benchmark.h>\n",
+		"subprojects/.gitkeep":  "",
+		"tests/data/input.json": "{}",
+	})
+
+	project, unmapped, err := convertedProject(nil, ProjectTypeVcpkg)
+	require.NoError(t, err)
+	assert.Empty(t, unmapped)
+	assert.Equal(t, "app", project.Name)
+	assert.Equal(t, "2.0.0", project.Version)
+	assert.Equal(t, 20, project.CppStandard)
+	assert.True(t, project.IsExe)
+	assert.Equal(t, []string{"app.cpp"}, project.Sources)
+	assert.Equal(t, []string{"main.cpp", "test_parse.cpp"}, project.TestSources)
+	assert.Equal(t, "googletest", project.TestFramework)
+	assert.Equal(t, "google-benchmark", project.BenchFramework)
+	// The test framework and vcpkg's host tools aren't dependencies
+	assert.Equal(t, []string{"fmt", "acme-log"}, project.Dependencies)
+
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runConvert(nil, "cmake")))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runConvert(nil, "make")))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runConvert(nil, "")))
+
+	// Bazel needs the registry for the versions of its modules
+	err = runConvert(nil, "bazel")
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+	addGetBcrPathFunc = func() string { return tmpDir }
+	require.NoError(t, runConvert(nil, "bazel"))
+	data, err := os.ReadFile("MODULE.bazel")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `bazel_dep(name = "fmt", version = "11.0.2")`)
+	assert.Contains(t, string(data), `bazel_dep(name = "googletest"`)
+	assert.FileExists(t, "tests/BUILD.bazel")
+
+	require.NoError(t, runConvert(nil, "meson"))
+	data, err = os.ReadFile("meson.build")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "version : '2.0.0'")
+	assert.Contains(t, string(data), "project_deps = [fmt_dep]")
+
+	// A Meson project converts back to CMake from its wraps
+	for _, file := range []string{"vcpkg.json", "CMakeLists.txt", "MODULE.bazel"} {
+		require.NoError(t, os.Remove(file))
+	}
+	writeFiles(t, ".", map[string]string{
+		"subprojects/fmt.wrap":   "[wrap-file]\n",
+		"subprojects/acme.wrap":  "[wrap-file]\n",
+		"subprojects/gtest.wrap": "[wrap-file]\n",
+	})
+	project, unmapped, err = convertedProject(nil, ProjectTypeMeson)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme"}, unmapped)
+	assert.Equal(t, []string{"fmt"}, project.Dependencies)
+	require.NoError(t, runConvert(nil, "cmake"))
+	data, err = os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"fmt"`)
+	data, err = os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "find_package(fmt REQUIRED)")
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/quality"
 	"github.com/spf13/cobra"
 )
@@ -11,7 +12,7 @@ func CppcheckCmd() *cobra.Command {
 		Use:   "cppcheck",
 		Short: "Run Cppcheck static analysis for C/C++",
 		Long:  "Run Cppcheck static analysis for C/C++. Performs static code analysis on C/C++ code.",
-		RunE:  runCppcheck,
+		RunE:  withExitCode(exitcode.QualityGate, runCppcheck),
 		Args:  cobra.ArbitraryArgs,
 	}
 
@@ -19,7 +20,6 @@ func CppcheckCmd() *cobra.Command {
 	cmd.Flags().String("output", "", "Output file path (for XML/CSV output)")
 	cmd.Flags().Bool("xml", false, "Output results in XML format")
 	cmd.Flags().Bool("csv", false, "Output results in CSV format")
-	cmd.Flags().Bool("quiet", false, "Quiet mode (suppress progress messages)")
 	cmd.Flags().Bool("force", false, "Force checking of all configurations")
 	cmd.Flags().Bool("inline-suppr", false, "Enable inline suppressions")
 	cmd.Flags().String("platform", "", "Target platform (unix32, unix64, win32A, win32W, win64, avr8, etc.)")
@@ -2,13 +2,23 @@ package cli
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 // DocCmd creates the doc command
@@ -16,18 +26,97 @@ func DocCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "doc",
 		Short: "Generate documentation",
-		Long:  "Generate documentation using Doxygen. Use --open to open in browser after generation.",
-		RunE:  runDoc,
+		Long: `Generate documentation with a generator, Doxygen by default, writing its
+configuration first if the project has none:
+
+  doxygen      Doxyfile, HTML in docs/html
+  sphinx       docs/sphinx/conf.py with breathe (reading Doxygen XML), HTML in docs/sphinx/_build/html
+  mkdocs       mkdocs.yml with mkdoxy (running Doxygen), HTML in docs/site
+  standardese  standardese.config, HTML in docs/standardese
+
+Use --open to open the documentation in a browser after generation.`,
+		Example: `  cpx doc --open
+  cpx doc --generator sphinx
+  cpx doc --generator mkdocs --open`,
+		RunE: runDoc,
 	}
 
+	cmd.Flags().String("generator", "doxygen", "Documentation generator: "+strings.Join(docGeneratorNames, ", "))
 	cmd.Flags().Bool("open", false, "Open documentation in browser")
+	cmd.RegisterFlagCompletionFunc("generator", cobra.FixedCompletions(docGeneratorNames, cobra.ShellCompDirectiveNoFileComp))
 
 	return cmd
 }
 
 func runDoc(cmd *cobra.Command, _ []string) error {
+	generator, _ := cmd.Flags().GetString("generator")
 	open, _ := cmd.Flags().GetBool("open")
-	return generateDocs(open)
+	return generateDocs(generator, open)
+}
+
+// docGenerator is a documentation toolchain of cpx doc
+type docGenerator struct {
+	// tools are the executables it runs, and install how to get them
+	tools   []string
+	install string
+	// files returns its configuration files, written if missing or
+	// managed by cpx doc; opts are the Doxyfile settings of cpx.yaml
+	files func(name, version string, opts templates.DoxygenOptions) map[string]string
+	// steps are the commands generating the documentation, in order
+	steps [][]string
+	// index is the start page of the generated documentation
+	index string
+}
+
+var docGeneratorNames = []string{"doxygen", "sphinx", "mkdocs", "standardese"}
+
+var docGenerators = map[string]docGenerator{
+	"doxygen": {
+		tools:   []string{"doxygen"},
+		install: "macOS: brew install doxygen\n  Ubuntu: sudo apt install doxygen",
+		files: func(name, version string, opts templates.DoxygenOptions) map[string]string {
+			return map[string]string{"Doxyfile": templates.GenerateDoxyfile(name, version, opts)}
+		},
+		steps: [][]string{{"doxygen"}},
+		index: filepath.Join("docs", "html", "index.html"),
+	},
+	"sphinx": {
+		tools:   []string{"doxygen", "sphinx-build"},
+		install: "pip install sphinx breathe, and Doxygen (brew install doxygen, sudo apt install doxygen)",
+		files: func(name, version string, _ templates.DoxygenOptions) map[string]string {
+			return map[string]string{
+				filepath.Join("docs", "sphinx", "Doxyfile"):  templates.GenerateSphinxDoxyfile(name, version),
+				filepath.Join("docs", "sphinx", "conf.py"):   templates.GenerateSphinxConf(name, version),
+				filepath.Join("docs", "sphinx", "index.rst"): templates.GenerateSphinxIndex(name),
+			}
+		},
+		steps: [][]string{
+			{"doxygen", filepath.Join("docs", "sphinx", "Doxyfile")},
+			{"sphinx-build", "-b", "html", filepath.Join("docs", "sphinx"), filepath.Join("docs", "sphinx", "_build", "html")},
+		},
+		index: filepath.Join("docs", "sphinx", "_build", "html", "index.html"),
+	},
+	"mkdocs": {
+		tools:   []string{"doxygen", "mkdocs"},
+		install: "pip install mkdocs mkdoxy, and Doxygen (brew install doxygen, sudo apt install doxygen)",
+		files: func(name, version string, _ templates.DoxygenOptions) map[string]string {
+			return map[string]string{
+				"mkdocs.yml": templates.GenerateMkDocsConfig(name, version),
+				filepath.Join("docs", "mkdocs", "index.md"): templates.GenerateMkDocsIndex(name),
+			}
+		},
+		steps: [][]string{{"mkdocs", "build", "--config-file", "mkdocs.yml"}},
+		index: filepath.Join("docs", "site", "index.html"),
+	},
+	"standardese": {
+		tools:   []string{"standardese"},
+		install: "build it from https://github.com/standardese/standardese",
+		files: func(string, string, templates.DoxygenOptions) map[string]string {
+			return map[string]string{"standardese.config": templates.GenerateStandardeseConfig()}
+		},
+		steps: [][]string{{"standardese", "--config", "standardese.config", "include"}},
+		index: filepath.Join("docs", "standardese", "standardese_files.html"),
+	},
 }
 
 // getProjectInfo reads project name and version from CMakeLists.txt or vcpkg.json
@@ -66,46 +155,134 @@ func getProjectInfo() (name string, version string) {
 	return name, version
 }
 
-func generateDocs(openBrowser bool) error {
-	// Check if Doxygen is available
-	if _, err := exec.LookPath("doxygen"); err != nil {
-		return fmt.Errorf("doxygen not found. Please install it first:\n  macOS: brew install doxygen\n  Ubuntu: sudo apt install doxygen")
+// doxygenAwesomeVersion is the release of doxygen-awesome-css of the
+// awesome theme
+const doxygenAwesomeVersion = "v2.3.4"
+
+// doxygenOptions returns the Doxyfile settings of the doc section of
+// cpx.yaml, checking for dot and downloading the theme they need
+func doxygenOptions() (templates.DoxygenOptions, error) {
+	var doc config.ProjectDoc
+	project, err := config.LoadProject(config.ProjectFile)
+	if err == nil {
+		doc = project.Doc
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return templates.DoxygenOptions{}, exitcode.Wrap(exitcode.Config, err)
+	}
+
+	opts := templates.DoxygenOptions{
+		Input:      doc.Input,
+		Exclude:    doc.Exclude,
+		Dot:        doc.Diagrams.Enabled || doc.Diagrams.CallGraphs,
+		CallGraphs: doc.Diagrams.CallGraphs,
+		DotFormat:  doc.Diagrams.Format,
+	}
+	if f := doc.Diagrams.Format; f != "" && f != "svg" && f != "png" {
+		return opts, exitcode.Errorf(exitcode.Config, "invalid doc.diagrams.format %q in %s\n  hint: use svg or png", f, config.ProjectFile)
+	}
+	if opts.Dot {
+		if _, err := execLookPath("dot"); err != nil {
+			return opts, exitcode.Errorf(exitcode.ToolchainMissing, "dot not found: doc.diagrams in %s needs Graphviz\n  macOS: brew install graphviz\n  Ubuntu: sudo apt install graphviz", config.ProjectFile)
+		}
+	}
+
+	switch doc.Theme {
+	case "":
+	case "awesome":
+		if opts.ThemeDir, err = fetchDoxygenAwesome(); err != nil {
+			return opts, err
+		}
+	default:
+		return opts, exitcode.Errorf(exitcode.Config, "unknown doc.theme %q in %s\n  hint: use awesome, or remove it for Doxygen's own theme", doc.Theme, config.ProjectFile)
+	}
+	return opts, nil
+}
+
+// fetchDoxygenAwesome downloads doxygen-awesome-css to .cache, unless it is
+// there, and returns its directory
+func fetchDoxygenAwesome() (string, error) {
+	dir := filepath.Join(".cache", "doxygen-awesome-css")
+	if _, err := os.Stat(filepath.Join(dir, "doxygen-awesome.css")); err == nil {
+		return dir, nil
+	}
+	if err := offline.Required("downloading the doxygen-awesome-css theme"); err != nil {
+		return "", err
+	}
+	logging.Step("Downloading doxygen-awesome-css %s...", doxygenAwesomeVersion)
+	os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	url := "https://github.com/jothepro/doxygen-awesome-css.git"
+	if out, err := execCommand("git", "clone", "--quiet", "--depth", "1", "--branch", doxygenAwesomeVersion, url, dir).CombinedOutput(); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to download doxygen-awesome-css: %w\n%s", err, out)
+	}
+	return dir, nil
+}
+
+func generateDocs(generatorName string, openBrowser bool) error {
+	generator, ok := docGenerators[generatorName]
+	if !ok {
+		return exitcode.Errorf(exitcode.Usage, "unknown documentation generator %q\n  hint: use --generator %s", generatorName, strings.Join(docGeneratorNames, ", "))
+	}
+	for _, tool := range generator.tools {
+		if _, err := execLookPath(tool); err != nil {
+			return exitcode.Errorf(exitcode.ToolchainMissing, "%s not found. Please install it first:\n  %s", tool, generator.install)
+		}
+	}
+
+	// The doc section of cpx.yaml configures the Doxyfile
+	var opts templates.DoxygenOptions
+	if generatorName == "doxygen" {
+		var err error
+		if opts, err = doxygenOptions(); err != nil {
+			return err
+		}
 	}
 
 	projectName, projectVersion := getProjectInfo()
 
-	fmt.Printf("%s Generating documentation...%s\n", Cyan, Reset)
-
-	// Create Doxyfile if it doesn't exist
-	if _, err := os.Stat("Doxyfile"); os.IsNotExist(err) {
-		doxyContent := fmt.Sprintf(`PROJECT_NAME           = "%s"
-PROJECT_NUMBER         = "%s"
-OUTPUT_DIRECTORY       = docs
-INPUT                  = src include
-RECURSIVE              = YES
-EXTRACT_ALL            = YES
-GENERATE_HTML          = YES
-GENERATE_LATEX         = NO
-HTML_OUTPUT            = html
-USE_MDFILE_AS_MAINPAGE = README.md
-`, projectName, projectVersion)
-
-		if err := os.WriteFile("Doxyfile", []byte(doxyContent), 0644); err != nil {
-			return fmt.Errorf("failed to create Doxyfile: %w", err)
+	logging.Step("Generating documentation with %s...", generatorName)
+
+	// Create the configuration files that don't exist, and rewrite the
+	// ones cpx doc manages
+	files := generator.files(projectName, projectVersion, opts)
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	slices.Sort(paths)
+	for _, path := range paths {
+		existing, err := os.ReadFile(path)
+		managed := err == nil && strings.HasPrefix(string(existing), templates.ManagedDocHeader)
+		if err == nil && (!managed || string(existing) == files[path]) {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(files[path]), 0644); err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		if managed {
+			fmt.Printf("    Updated %s from %s\n", path, config.ProjectFile)
+		} else {
+			fmt.Printf("    Created %s\n", path)
 		}
-		fmt.Printf("    Created Doxyfile\n")
 	}
 
-	// Run Doxygen
-	cmd := exec.Command("doxygen")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("doxygen failed: %w", err)
+	for _, step := range generator.steps {
+		cmd := execCommand(step[0], step[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s failed: %w", step[0], err)
+		}
 	}
 
-	indexPath := "docs/html/index.html"
-	fmt.Printf("%s Documentation generated at %s%s\n", Green, indexPath, Reset)
+	indexPath := generator.index
+	logging.Success("Documentation generated at %s", indexPath)
 
 	if openBrowser {
 		var openCmd string
@@ -119,7 +296,7 @@ USE_MDFILE_AS_MAINPAGE = README.md
 		}
 
 		if openCmd != "" {
-			exec.Command(openCmd, indexPath).Start()
+			execCommand(openCmd, indexPath).Start()
 		}
 	}
 
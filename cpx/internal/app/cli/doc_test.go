@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	execLookPath = func(file string) (string, error) {
+		if file == "standardese" {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + file, nil
+	}
+
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(demo VERSION 1.2.3 LANGUAGES CXX)\n"), 0644))
+
+	assert.Equal(t, exitcode.Usage, exitcode.Of(generateDocs("javadoc", false)))
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(generateDocs("standardese", false)))
+
+	require.NoError(t, generateDocs("sphinx", false))
+	conf, err := os.ReadFile(filepath.Join("docs", "sphinx", "conf.py"))
+	require.NoError(t, err)
+	assert.Contains(t, string(conf), `project = "demo"`)
+	assert.Contains(t, string(conf), `"breathe"`)
+	assert.FileExists(t, filepath.Join("docs", "sphinx", "index.rst"))
+	assert.Equal(t, [][]string{
+		{"doxygen", filepath.Join("docs", "sphinx", "Doxyfile")},
+		{"sphinx-build", "-b", "html", filepath.Join("docs", "sphinx"), filepath.Join("docs", "sphinx", "_build", "html")},
+	}, capturedArgs)
+
+	// Existing configuration is kept
+	require.NoError(t, os.WriteFile("mkdocs.yml", []byte("site_name: custom\n"), 0644))
+	capturedArgs = nil
+	require.NoError(t, generateDocs("mkdocs", true))
+	mkdocs, err := os.ReadFile("mkdocs.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "site_name: custom\n", string(mkdocs))
+	assert.FileExists(t, filepath.Join("docs", "mkdocs", "index.md"))
+	require.Len(t, capturedArgs, 2)
+	assert.Equal(t, []string{"mkdocs", "build", "--config-file", "mkdocs.yml"}, capturedArgs[0])
+	assert.Equal(t, filepath.Join("docs", "site", "index.html"), capturedArgs[1][1], "--open opens the index of the generator")
+}
+
+func TestGenerateDocsDoxyfileFromProject(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	lookPath := map[string]bool{"doxygen": true}
+	execLookPath = func(file string) (string, error) {
+		if !lookPath[file] {
+			return "", exec.ErrNotFound
+		}
+		return "/usr/bin/" + file, nil
+	}
+
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("doc:\n  theme: awesome\n  input: [include]\n  exclude: [\"*/detail/*\"]\n  diagrams:\n    enabled: true\n"), 0644))
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(generateDocs("doxygen", false)), "diagrams need dot")
+
+	lookPath["dot"] = true
+	require.NoError(t, generateDocs("doxygen", false))
+	assert.Equal(t, []string{"git", "clone", "--quiet", "--depth", "1", "--branch", doxygenAwesomeVersion, "https://github.com/jothepro/doxygen-awesome-css.git", filepath.Join(".cache", "doxygen-awesome-css")}, capturedArgs[0])
+	assert.Equal(t, []string{"doxygen"}, capturedArgs[1])
+	doxyfile, err := os.ReadFile("Doxyfile")
+	require.NoError(t, err)
+	assert.Contains(t, string(doxyfile), "INPUT                  = include\n")
+	assert.Contains(t, string(doxyfile), "EXCLUDE_PATTERNS       = */detail/*\n")
+	assert.Contains(t, string(doxyfile), "doxygen-awesome-css/doxygen-awesome.css")
+	assert.Contains(t, string(doxyfile), "HAVE_DOT               = YES\n")
+
+	// The managed Doxyfile follows cpx.yaml
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("doc:\n  input: [src]\n"), 0644))
+	require.NoError(t, generateDocs("doxygen", false))
+	doxyfile, err = os.ReadFile("Doxyfile")
+	require.NoError(t, err)
+	assert.Contains(t, string(doxyfile), "INPUT                  = src\n")
+	assert.NotContains(t, string(doxyfile), "doxygen-awesome")
+
+	// A Doxyfile without the header is the project's own
+	require.NoError(t, os.WriteFile("Doxyfile", []byte("INPUT = lib\n"), 0644))
+	require.NoError(t, generateDocs("doxygen", false))
+	doxyfile, err = os.ReadFile("Doxyfile")
+	require.NoError(t, err)
+	assert.Equal(t, "INPUT = lib\n", string(doxyfile))
+
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("doc:\n  theme: material\n"), 0644))
+	assert.Equal(t, exitcode.Config, exitcode.Of(generateDocs("doxygen", false)))
+}
@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/tools"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// DoctorCmd creates the doctor command
+func DoctorCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the installed toolchain against the project's requirements",
+		Long: `Check the installed tools and their versions.
+
+If the project has a ` + config.ToolsFile + ` manifest, every tool listed in it is
+verified against its version constraint. Use --record to create the manifest
+from the tools installed on this machine, and --strict-tools to fail on any
+missing tool or version mismatch (e.g. in CI).
+
+Projects generated with a compute backend (OpenMP, CUDA, SYCL) are also
+checked for the matching toolkit.`,
+		Example: `  cpx doctor                 # Report tool versions
+  cpx doctor --record        # Pin the installed versions in ` + config.ToolsFile + `
+  cpx doctor --strict-tools  # Exit with code 4 on a mismatch`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd, args, client)
+		},
+	}
+
+	cmd.Flags().Bool("strict-tools", false, "Fail if a required tool is missing or at the wrong version")
+	cmd.Flags().Bool("record", false, "Write "+config.ToolsFile+" pinning the installed tool versions")
+
+	return cmd
+}
+
+func runDoctor(cmd *cobra.Command, _ []string, client *vcpkg.Client) error {
+	strict, _ := cmd.Flags().GetBool("strict-tools")
+	record, _ := cmd.Flags().GetBool("record")
+
+	if record {
+		return recordToolVersions()
+	}
+
+	manifest, err := loadToolsManifest()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%sToolchain%s\n", Bold, Reset)
+	var failed []tools.Check
+	if manifest != nil {
+		checks, err := tools.Verify(manifest)
+		if err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+		printToolChecks(checks)
+		failed = tools.Failed(checks)
+	} else {
+		for _, tool := range tools.DefaultTools {
+			version, err := tools.DetectVersion(tool)
+			switch {
+			case err != nil:
+				fmt.Printf("  %s-%s %-14s %snot installed%s\n", Dim, Reset, tool, Dim, Reset)
+			case version == "":
+				fmt.Printf("  %s%s%s %-14s %sversion unknown%s\n", Green, IconSuccess, Reset, tool, Yellow, Reset)
+			default:
+				fmt.Printf("  %s%s%s %-14s %s\n", Green, IconSuccess, Reset, tool, version)
+			}
+		}
+		fmt.Printf("  %sNo %s; run 'cpx doctor --record' to pin these versions%s\n", Dim, config.ToolsFile, Reset)
+	}
+
+	if backend := detectComputeBackend(); backend != templates.ComputeNone {
+		fmt.Printf("\n%sCompute backend (%s)%s\n", Bold, templates.ComputeBackendName(backend), Reset)
+		checks := tools.CheckComputeToolkit(backend)
+		printToolChecks(checks)
+		failed = append(failed, tools.Failed(checks)...)
+	}
+
+	fmt.Printf("\n%svcpkg%s\n", Bold, Reset)
+	if path, err := vcpkgPath(client); err != nil {
+		fmt.Printf("  %s-%s not configured %s(cpx config set-vcpkg-root <path>)%s\n", Dim, Reset, Dim, Reset)
+	} else {
+		fmt.Printf("  %s%s%s %s\n", Green, IconSuccess, Reset, path)
+	}
+
+	if len(failed) > 0 && strict {
+		return toolMismatchError(failed)
+	}
+	return nil
+}
+
+// detectComputeBackend returns the compute backend the project was
+// generated with, read from its build files
+func detectComputeBackend() string {
+	for _, file := range []string{"CMakeLists.txt", filepath.Join("src", "meson.build"), filepath.Join("src", "BUILD.bazel")} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if backend := templates.ParseComputeBackend(string(data)); backend != templates.ComputeNone {
+			return backend
+		}
+	}
+	return templates.ComputeNone
+}
+
+func vcpkgPath(client *vcpkg.Client) (string, error) {
+	if client == nil {
+		return "", fmt.Errorf("vcpkg client not initialized")
+	}
+	return client.GetPath()
+}
+
+// loadToolsManifest returns the project's tool manifest, or nil if it has none
+func loadToolsManifest() (*config.ToolsConfig, error) {
+	manifest, err := config.LoadTools(config.ToolsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, exitcode.Wrap(exitcode.Config, err)
+	}
+	return manifest, nil
+}
+
+// checkToolVersions verifies the project's tool manifest before a build.
+// Mismatches are printed as warnings, or returned as an error if strict.
+func checkToolVersions(strict bool) error {
+	manifest, err := loadToolsManifest()
+	if err != nil || manifest == nil {
+		return err
+	}
+	checks, err := tools.Verify(manifest)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	failed := tools.Failed(checks)
+	if len(failed) == 0 {
+		return nil
+	}
+	if strict {
+		return toolMismatchError(failed)
+	}
+	for _, c := range failed {
+		logging.Warn("%s", describeToolCheck(c))
+	}
+	return nil
+}
+
+func toolMismatchError(failed []tools.Check) error {
+	lines := make([]string, len(failed))
+	for i, c := range failed {
+		lines[i] = "  " + describeToolCheck(c)
+	}
+	return exitcode.Errorf(exitcode.ToolchainMissing, "required tools are missing or at the wrong version:\n%s\n  hint: install the required versions, or update %s",
+		strings.Join(lines, "\n"), config.ToolsFile)
+}
+
+func describeToolCheck(c tools.Check) string {
+	if c.Status == tools.StatusMissing {
+		if c.Constraint == "" {
+			return fmt.Sprintf("%s is required but not installed", c.Tool)
+		}
+		return fmt.Sprintf("%s is required (%s) but not installed", c.Tool, c.Constraint)
+	}
+	return fmt.Sprintf("%s %s does not satisfy %s", c.Tool, c.Version, c.Constraint)
+}
+
+func printToolChecks(checks []tools.Check) {
+	for _, c := range checks {
+		constraint := ""
+		if c.Constraint != "" {
+			constraint = fmt.Sprintf(" %s(%s)%s", Dim, c.Constraint, Reset)
+		}
+		switch c.Status {
+		case tools.StatusOK:
+			fmt.Printf("  %s%s%s %-14s %-10s%s\n", Green, IconSuccess, Reset, c.Tool, c.Version, constraint)
+		case tools.StatusUnknown:
+			fmt.Printf("  %s?%s %-14s %sversion unknown%s%s\n", Yellow, Reset, c.Tool, Yellow, Reset, constraint)
+		case tools.StatusMissing:
+			fmt.Printf("  %s%s%s %-14s %snot installed%s%s\n", Red, IconError, Reset, c.Tool, Red, Reset, constraint)
+		default:
+			fmt.Printf("  %s%s%s %-14s %s%-10s%s %s(requires %s)%s\n", Red, IconError, Reset, c.Tool, Red, c.Version, Reset, Dim, c.Constraint, Reset)
+		}
+	}
+}
+
+// recordToolVersions writes a manifest pinning the installed tools to their
+// major.minor versions
+func recordToolVersions() error {
+	manifest := &config.ToolsConfig{Tools: make(map[string]string)}
+	for _, tool := range tools.DefaultTools {
+		if version, err := tools.DetectVersion(tool); err == nil && version != "" {
+			manifest.Tools[tool] = tools.Pin(version)
+		}
+	}
+	if len(manifest.Tools) == 0 {
+		return exitcode.Errorf(exitcode.ToolchainMissing, "none of %s are installed", strings.Join(tools.DefaultTools, ", "))
+	}
+	if err := config.SaveTools(manifest, config.ToolsFile); err != nil {
+		return err
+	}
+
+	logging.Success("✓ Wrote %s", config.ToolsFile)
+	checks, _ := tools.Verify(manifest)
+	printToolChecks(checks)
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckToolVersions(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// No manifest: nothing to check
+	assert.NoError(t, checkToolVersions(true))
+
+	require.NoError(t, config.SaveTools(&config.ToolsConfig{Tools: map[string]string{
+		"cpx-no-such-tool": "1.0",
+	}}, config.ToolsFile))
+
+	assert.NoError(t, checkToolVersions(false))
+
+	err = checkToolVersions(true)
+	require.Error(t, err)
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(err))
+	assert.Contains(t, err.Error(), "cpx-no-such-tool is required (1.0) but not installed")
+
+}
@@ -0,0 +1,278 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// editClangFormatStyles are the .clang-format styles offered by cpx edit, as
+// by the cpx new wizard
+var editClangFormatStyles = []string{"Google", "LLVM", "Chromium", "Mozilla", "WebKit"}
+
+var basedOnStyleRe = regexp.MustCompile(`(?m)^BasedOnStyle:\s*(\w+)`)
+
+// promptProjectSettings asks for the new project settings; a variable for
+// mocking in tests
+var promptProjectSettings = func(title string, prompts []tui.Prompt) (map[string]string, error) {
+	return tui.RunPrompts(title, prompts)
+}
+
+// projectSettings are the settings of an existing project cpx edit changes
+type projectSettings struct {
+	CppStandard int
+	// ClangFormat is the BasedOnStyle of .clang-format; empty without one
+	ClangFormat string
+	// PCH and Unity are the build options of cpx.yaml (CMake projects)
+	PCH   bool
+	Unity bool
+}
+
+// EditCmd creates the edit command
+func EditCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Change the settings of the current project",
+		Long: `Change the settings chosen when the project was created, in the same
+wizard as cpx new, starting from the current values: the C++ standard, the
+.clang-format style and, for CMake projects, the build options of ` + config.ProjectFile + `.
+
+On save, cpx edit rewrites the affected files: the C++ standard in the build
+files and .clangd, .clang-format, ` + config.ProjectFile + ` and the precompiled header
+section of CMakeLists.txt.`,
+		Example: `  cpx edit`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEdit()
+		},
+	}
+}
+
+func runEdit() error {
+	projectType := DetectProjectType()
+	if projectType == ProjectTypeUnknown {
+		if _, err := os.Stat("CMakeLists.txt"); err != nil {
+			return exitcode.Errorf(exitcode.Config, "no CMakeLists.txt, meson.build or MODULE.bazel found\n  hint: run from the project root")
+		}
+	}
+	isCMake := projectType != ProjectTypeBazel && projectType != ProjectTypeMeson
+
+	current, err := loadProjectSettings(projectType)
+	if err != nil {
+		return err
+	}
+
+	answers, err := promptProjectSettings("cpx edit", settingsPrompts(current, isCMake))
+	if err != nil {
+		return fmt.Errorf("failed to run TUI: %w", err)
+	}
+	if answers == nil {
+		return nil
+	}
+	updated, err := settingsFromAnswers(current, answers)
+	if err != nil {
+		return err
+	}
+	return applyProjectSettings(projectType, current, updated)
+}
+
+// loadProjectSettings reads the current settings from the project files
+func loadProjectSettings(projectType ProjectType) (projectSettings, error) {
+	settings := projectSettings{CppStandard: detectCppStandard(projectType)}
+
+	if data, err := os.ReadFile(".clang-format"); err == nil {
+		settings.ClangFormat = "Google"
+		if m := basedOnStyleRe.FindSubmatch(data); m != nil {
+			settings.ClangFormat = string(m[1])
+		}
+	}
+
+	project, err := config.LoadProject(config.ProjectFile)
+	if err == nil {
+		settings.PCH = project.Build.PCH
+		settings.Unity = project.Build.Unity
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return settings, exitcode.Wrap(exitcode.Config, err)
+	}
+	return settings, nil
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "Yes"
+	}
+	return "No"
+}
+
+// settingsPrompts returns the questions of cpx edit, defaulting to the
+// current settings
+func settingsPrompts(current projectSettings, isCMake bool) []tui.Prompt {
+	standards := make([]string, len(newCppStandards))
+	for i, std := range newCppStandards {
+		standards[i] = strconv.Itoa(std)
+	}
+	prompts := []tui.Prompt{{
+		Name:     "std",
+		Question: "C++ standard?",
+		Default:  strconv.Itoa(current.CppStandard),
+		Choices:  standards,
+	}}
+
+	// Without .clang-format the project opted out of formatting
+	if current.ClangFormat != "" {
+		styles := editClangFormatStyles
+		if !slices.Contains(styles, current.ClangFormat) {
+			styles = append(slices.Clone(styles), current.ClangFormat)
+		}
+		prompts = append(prompts, tui.Prompt{
+			Name:     "clang-format",
+			Question: "Code formatting style?",
+			Default:  current.ClangFormat,
+			Choices:  styles,
+		})
+	}
+
+	if isCMake {
+		prompts = append(prompts,
+			tui.Prompt{Name: "pch", Question: "Precompiled headers?", Default: yesNo(current.PCH), Choices: []string{"Yes", "No"}},
+			tui.Prompt{Name: "unity", Question: "Unity builds?", Default: yesNo(current.Unity), Choices: []string{"Yes", "No"}},
+		)
+	}
+	return prompts
+}
+
+// settingsFromAnswers returns current updated with the answers of the prompts
+func settingsFromAnswers(current projectSettings, answers map[string]string) (projectSettings, error) {
+	updated := current
+	if value, ok := answers["std"]; ok {
+		std, err := strconv.Atoi(value)
+		if err != nil || !slices.Contains(newCppStandards, std) {
+			return updated, exitcode.Errorf(exitcode.Usage, "unsupported C++ standard %q\n  hint: use one of %s", value, joinInts(newCppStandards))
+		}
+		updated.CppStandard = std
+	}
+	if value, ok := answers["clang-format"]; ok {
+		updated.ClangFormat = value
+	}
+	if value, ok := answers["pch"]; ok {
+		updated.PCH = value == "Yes"
+	}
+	if value, ok := answers["unity"]; ok {
+		updated.Unity = value == "Yes"
+	}
+	return updated, nil
+}
+
+// applyProjectSettings writes the files affected by the settings that
+// changed from current to updated
+func applyProjectSettings(projectType ProjectType, current, updated projectSettings) error {
+	if updated == current {
+		fmt.Printf("%sNo changes%s\n", Dim, Reset)
+		return nil
+	}
+
+	if updated.CppStandard != current.CppStandard {
+		file, err := setCppStandard(projectType, updated.CppStandard)
+		if err != nil {
+			return err
+		}
+		logging.Success("%s Updated %s (C++%d)", IconSuccess, file, updated.CppStandard)
+		// .clangd passes the standard to clangd
+		if _, err := os.Stat(".clangd"); err == nil {
+			if err := runGenClangd(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if updated.ClangFormat != current.ClangFormat {
+		if err := os.WriteFile(".clang-format", []byte(templates.GenerateClangFormat(updated.ClangFormat)), 0644); err != nil {
+			return fmt.Errorf("failed to write .clang-format: %w", err)
+		}
+		logging.Success("%s Wrote .clang-format (%s)", IconSuccess, updated.ClangFormat)
+	}
+
+	if updated.PCH != current.PCH || updated.Unity != current.Unity {
+		if updated.PCH {
+			if err := addPCHSection(); err != nil {
+				return err
+			}
+		}
+		if _, err := os.Stat(config.ProjectFile); os.IsNotExist(err) {
+			if err := os.WriteFile(config.ProjectFile, []byte(templates.GenerateCpxYaml(false)), 0644); err != nil {
+				return fmt.Errorf("failed to write %s: %w", config.ProjectFile, err)
+			}
+		}
+		if err := config.SaveProjectBuild(config.ProjectFile, config.ProjectBuild{PCH: updated.PCH, Unity: updated.Unity}); err != nil {
+			return exitcode.Wrap(exitcode.Config, err)
+		}
+		logging.Success("%s Updated %s (pch: %t, unity: %t)", IconSuccess, config.ProjectFile, updated.PCH, updated.Unity)
+	}
+	return nil
+}
+
+// setCppStandard replaces the C++ standard in the build file of the project
+// and returns the file's name
+func setCppStandard(projectType ProjectType, std int) (string, error) {
+	source, ok := cppStandardPatterns[projectType]
+	if !ok {
+		source = cppStandardPatterns[ProjectTypeVcpkg]
+	}
+	data, err := os.ReadFile(source.file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", source.file, err)
+	}
+	if !source.pattern.Match(data) {
+		return "", exitcode.Errorf(exitcode.Config, "%s doesn't set the C++ standard\n  hint: set it by hand, cpx edit only changes an existing setting", source.file)
+	}
+	replacement := []byte(strconv.Itoa(std))
+	data = source.pattern.ReplaceAllFunc(data, func(match []byte) []byte {
+		loc := source.pattern.FindSubmatchIndex(match)
+		return slices.Concat(match[:loc[2]], replacement, match[loc[3]:])
+	})
+	if err := os.WriteFile(source.file, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", source.file, err)
+	}
+	return source.file, nil
+}
+
+// addPCHSection appends the precompiled header section of cpx new to
+// CMakeLists.txt, unless it has the CPX_PCH option already
+func addPCHSection() error {
+	data, err := os.ReadFile("CMakeLists.txt")
+	if err != nil {
+		return fmt.Errorf("failed to read CMakeLists.txt: %w", err)
+	}
+	if regexp.MustCompile(`option\(\s*CPX_PCH\b`).Match(data) {
+		return nil
+	}
+
+	projectName := build.GetProjectNameFromCMakeLists()
+	if projectName == "" {
+		return exitcode.Errorf(exitcode.Config, "no project() in CMakeLists.txt")
+	}
+	header := filepath.Join("include", projectName, projectName+".hpp")
+	if _, err := os.Stat(header); err != nil {
+		return exitcode.Errorf(exitcode.Config, "precompiled headers need %s\n  hint: add target_precompile_headers to CMakeLists.txt by hand", header)
+	}
+
+	data = append(data, templates.GeneratePCHCMake(projectName)...)
+	if err := os.WriteFile("CMakeLists.txt", data, 0644); err != nil {
+		return fmt.Errorf("failed to write CMakeLists.txt: %w", err)
+	}
+	logging.Success("%s Added precompiled headers to CMakeLists.txt", IconSuccess)
+	return nil
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdit(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	files := map[string]string{
+		"CMakeLists.txt":        "cmake_minimum_required(VERSION 3.20)\nproject(demo LANGUAGES CXX)\nset(CMAKE_CXX_STANDARD 17)\nadd_executable(demo src/main.cpp)\n",
+		"include/demo/demo.hpp": "#pragma once\n",
+		".clang-format":         templates.GenerateClangFormat("Mozilla"),
+		config.ProjectFile:      templates.GenerateCpxYaml(false),
+	}
+	for rel, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(rel), 0755))
+		require.NoError(t, os.WriteFile(rel, []byte(content), 0644))
+	}
+
+	oldPrompt := promptProjectSettings
+	defer func() { promptProjectSettings = oldPrompt }()
+	var asked []tui.Prompt
+	answers := map[string]string{"std": "20", "clang-format": "LLVM", "pch": "Yes", "unity": "No"}
+	promptProjectSettings = func(_ string, prompts []tui.Prompt) (map[string]string, error) {
+		asked = prompts
+		return answers, nil
+	}
+
+	require.NoError(t, runEdit())
+
+	// The wizard starts from the current settings
+	defaults := make(map[string]string)
+	for _, p := range asked {
+		defaults[p.Name] = p.Default
+	}
+	assert.Equal(t, map[string]string{"std": "17", "clang-format": "Mozilla", "pch": "No", "unity": "No"}, defaults)
+
+	data, err := os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "set(CMAKE_CXX_STANDARD 20)")
+	assert.Contains(t, string(data), "option(CPX_PCH")
+	data, err = os.ReadFile(".clang-format")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "BasedOnStyle: LLVM")
+	project, err := config.LoadProject(config.ProjectFile)
+	require.NoError(t, err)
+	assert.True(t, project.Build.PCH)
+
+	// Saving the current settings again changes nothing
+	answers = map[string]string{"std": "20", "clang-format": "LLVM", "pch": "Yes", "unity": "No"}
+	require.NoError(t, runEdit())
+	data, err = os.ReadFile("CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "option(CPX_PCH"))
+
+	answers = map[string]string{"std": "98"}
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runEdit()))
+}
+
+func TestSetCppStandard(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile(".bazelrc", []byte("build --cxxopt=-std=c++17\nbuild --host_cxxopt=-std=c++17\n"), 0644))
+	file, err := setCppStandard(ProjectTypeBazel, 23)
+	require.NoError(t, err)
+	assert.Equal(t, ".bazelrc", file)
+	data, err := os.ReadFile(".bazelrc")
+	require.NoError(t, err)
+	assert.Equal(t, "build --cxxopt=-std=c++23\nbuild --host_cxxopt=-std=c++23\n", string(data))
+
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('demo', 'cpp')\n"), 0644))
+	_, err = setCppStandard(ProjectTypeMeson, 20)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+}
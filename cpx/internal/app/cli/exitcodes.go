@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+)
+
+// withExitCode tags errors returned by run with code unless they already
+// carry a more specific one (e.g. a missing tool or project)
+func withExitCode(code exitcode.Code, run func(*cobra.Command, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		return exitcode.Wrap(code, run(cmd, args))
+	}
+}
+
+// ExitCodesCmd creates the `cpx help exit-codes` help topic
+func ExitCodesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "exit-codes",
+		Short: "Exit codes returned by cpx commands",
+		Long:  exitCodesHelp(),
+	}
+}
+
+func exitCodesHelp() string {
+	var b strings.Builder
+	b.WriteString("cpx returns the same exit codes from every command, so CI scripts can branch\non the kind of failure:\n\n")
+	for _, info := range exitcode.All {
+		fmt.Fprintf(&b, "  %3d  %-18s %s\n", info.Code, info.Name, info.Description)
+	}
+	b.WriteString(`
+A more specific code wins: 'cpx test' exits with 5 when the tests fail to
+compile, and 'cpx lint' exits with 4 when clang-tidy is not installed.
+
+Example:
+  cpx test
+  case $? in
+    0) echo "passed" ;;
+    5) echo "does not compile" ;;
+    6) echo "tests failed" ;;
+    *) echo "setup problem" ;;
+  esac`)
+	return b.String()
+}
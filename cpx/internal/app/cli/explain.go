@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/explain"
+	"github.com/spf13/cobra"
+)
+
+// ExplainCmd creates the explain command
+func ExplainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <rule-or-error>",
+		Short: "Explain a diagnostic, tool rule or compiler error",
+		Long: `Print documentation for clang-tidy checks, cppcheck ids, flawfinder CWEs and
+common compiler/linker error messages, with links to the upstream docs.
+
+The rule database is embedded in cpx and refreshed by 'cpx upgrade'.`,
+		Example: `  cpx explain bugprone-use-after-move
+  cpx explain nullPointer
+  cpx explain CWE-120
+  cpx explain "undefined reference to 'foo()'"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: runExplain,
+	}
+
+	return cmd
+}
+
+func runExplain(_ *cobra.Command, args []string) error {
+	query := strings.Join(args, " ")
+	db := explain.Load()
+
+	entries := db.Lookup(query)
+	if len(entries) == 0 {
+		if guess, ok := explain.Guess(query); ok {
+			fmt.Printf("%s%s%s %s(%s)%s\n", Bold, guess.ID, Reset, Dim, guess.Tool, Reset)
+			fmt.Printf("  No local documentation; see %s\n", guess.URL)
+			return nil
+		}
+		return fmt.Errorf("no documentation found for %q\n  hint: pass a clang-tidy check, cppcheck id, CWE number or an error message", query)
+	}
+
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Println()
+		}
+		printExplainEntry(e)
+	}
+	return nil
+}
+
+func printExplainEntry(e explain.Entry) {
+	fmt.Printf("%s%s%s %s(%s)%s\n", Bold, e.ID, Reset, Dim, e.Tool, Reset)
+	fmt.Printf("%s%s%s\n\n", Cyan, e.Title, Reset)
+	fmt.Printf("  %s\n", e.Description)
+	if e.URL != "" {
+		fmt.Printf("\n  %sMore:%s %s\n", Dim, Reset, e.URL)
+	}
+}
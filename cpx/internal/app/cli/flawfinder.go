@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/quality"
 	"github.com/spf13/cobra"
 )
@@ -11,7 +12,7 @@ func FlawfinderCmd() *cobra.Command {
 		Use:   "flawfinder",
 		Short: "Run Flawfinder security analysis for C/C++",
 		Long:  "Run Flawfinder security analysis for C/C++. Scans C/C++ code for security vulnerabilities.",
-		RunE:  runFlawfinder,
+		RunE:  withExitCode(exitcode.QualityGate, runFlawfinder),
 		Args:  cobra.ArbitraryArgs,
 	}
 
@@ -20,7 +21,6 @@ func FlawfinderCmd() *cobra.Command {
 	cmd.Flags().Bool("html", false, "Output results in HTML format")
 	cmd.Flags().String("output", "", "Output file path (required for HTML/CSV output)")
 	cmd.Flags().Bool("dataflow", false, "Enable dataflow analysis")
-	cmd.Flags().Bool("quiet", false, "Quiet mode (minimal output)")
 	cmd.Flags().Bool("singleline", false, "Single line output format")
 	cmd.Flags().Int("context", 2, "Number of lines of context to show")
 
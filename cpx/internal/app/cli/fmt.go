@@ -1,7 +1,12 @@
 package cli
 
 import (
+	"errors"
+	"io/fs"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/quality"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -11,16 +16,38 @@ func FmtCmd() *cobra.Command {
 		Use:     "fmt",
 		Aliases: []string{"format"},
 		Short:   "Format code with clang-format",
-		Long:    "Format code with clang-format. Use --check to verify formatting without modifying files.",
-		RunE:    runFmt,
+		Long: `Format code with clang-format.
+
+With fmt.build_files: true in cpx.yaml, it also formats the build files with
+the formatters that are installed: CMakeLists.txt and *.cmake with gersemi or
+cmake-format, BUILD.bazel, MODULE.bazel and *.bzl with buildifier, and
+meson.build with muon fmt.
+
+--check modifies no file: it prints a unified diff of each file clang-format
+would change and exits with code 7 if there is any, for CI gates.
+
+--staged only formats the files staged for commit and stages the formatting,
+as the pre-commit hook of 'cpx hooks install' does. Staged files that also
+have unstaged changes are checked but not formatted, since staging them
+would commit those changes too.`,
+		Example: `  cpx fmt
+  cpx fmt --check
+  cpx fmt --staged`,
+		RunE: withExitCode(exitcode.QualityGate, runFmt),
 	}
 
-	cmd.Flags().Bool("check", false, "Check formatting without modifying files")
+	cmd.Flags().Bool("check", false, "Print the diffs of unformatted files without modifying them, and fail if there are any")
+	cmd.Flags().Bool("staged", false, "Format only the files staged for commit, and stage the formatting")
 
 	return cmd
 }
 
 func runFmt(cmd *cobra.Command, _ []string) error {
 	check, _ := cmd.Flags().GetBool("check")
-	return quality.FormatCode(check)
+	staged, _ := cmd.Flags().GetBool("staged")
+	project, err := config.LoadProject(config.ProjectFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	return quality.FormatCode(check, err == nil && project.Fmt.BuildFiles, staged)
 }
@@ -0,0 +1,485 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// devcontainerDir holds the generated dev container definition
+const devcontainerDir = ".devcontainer"
+
+// GenCmd creates the gen command
+func GenCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "Generate supporting files (dev container, Nix flake, Dockerfile, clangd config, cross toolchain) for an existing project",
+	}
+
+	devcontainerCmd := &cobra.Command{
+		Use:   "devcontainer",
+		Short: "Generate a VS Code / Codespaces dev container",
+		Long: `Generate .devcontainer/devcontainer.json and a Dockerfile with the project's
+toolchain: the compiler, cmake and ninja (CMake), meson (Meson) or bazelisk
+(Bazel), vcpkg at the builtin-baseline of vcpkg.json, and cpx.
+
+Compiler, compiler version and cmake version are taken from ` + config.ToolsFile + `
+when it pins them (e.g. clang++: "18", cmake: ">=3.30").`,
+		Example: `  cpx gen devcontainer
+  cpx gen devcontainer --compiler clang
+  cpx gen devcontainer --force   # Overwrite an existing .devcontainer`,
+		Args: cobra.NoArgs,
+		RunE: runGenDevcontainer,
+	}
+	devcontainerCmd.Flags().String("compiler", "", "Compiler to install: gcc or clang (default: from "+config.ToolsFile+", else gcc)")
+	devcontainerCmd.Flags().Bool("force", false, "Overwrite existing files")
+	cmd.AddCommand(devcontainerCmd)
+
+	nixCmd := &cobra.Command{
+		Use:   "nix",
+		Short: "Generate a Nix flake with a dev shell and a package",
+		Long: `Generate flake.nix with the project's toolchain and build.
+
+'nix develop' enters a shell with the compiler, cmake and ninja (CMake),
+meson (Meson) or bazelisk (Bazel), clang-tools and a debugger; for vcpkg
+projects it also provides vcpkg and sets VCPKG_ROOT. 'nix build' builds the
+project with the libraries of vcpkg.json taken from nixpkgs. Bazel projects
+get the dev shell only.
+
+The compiler and its version are taken from ` + config.ToolsFile + ` when it
+pins them.`,
+		Example: `  cpx gen nix
+  cpx gen nix --compiler clang
+  cpx gen nix --force   # Overwrite an existing flake.nix`,
+		Args: cobra.NoArgs,
+		RunE: runGenNix,
+	}
+	nixCmd.Flags().String("compiler", "", "Compiler of the dev shell and package: gcc or clang (default: from "+config.ToolsFile+", else gcc)")
+	nixCmd.Flags().Bool("force", false, "Overwrite an existing flake.nix")
+	cmd.AddCommand(nixCmd)
+
+	dockerfileCmd := &cobra.Command{
+		Use:   "dockerfile",
+		Short: "Generate a multi-stage Dockerfile for deploying the project",
+		Long: `Generate a Dockerfile that builds the project's executable in the cpx
+toolchain image of a target (cpx-<target>, built by 'cpx ci') and copies it
+into a minimal runtime image of the same distribution. A .dockerignore that
+keeps local build output out of the build context is written if missing.
+
+The build step matches the project: CMake (with vcpkg dependencies installed
+in their own layer), Meson or Bazel. Bazel is not available on musl targets.`,
+		Example: `  cpx gen dockerfile
+  cpx gen dockerfile --target linux-arm64-musl
+  cpx gen dockerfile --binary server --force`,
+		Args: cobra.NoArgs,
+		RunE: runGenDockerfile,
+	}
+	dockerfileCmd.Flags().String("target", "linux-amd64", "Toolchain image target (e.g. linux-amd64, linux-arm64-musl)")
+	dockerfileCmd.Flags().String("binary", "", "Executable to ship (default: the project name)")
+	dockerfileCmd.Flags().Bool("force", false, "Overwrite an existing Dockerfile")
+	cmd.AddCommand(dockerfileCmd)
+
+	clangdCmd := &cobra.Command{
+		Use:   "clangd",
+		Short: "Generate or refresh the .clangd configuration",
+		Long: `Write .clangd from the project settings: the C++ standard, the include
+directories, the compile_commands.json in the project root and the
+diagnostics listed under clangd.suppress in ` + config.ProjectFile + `.
+
+Include directories are absolute paths, so .clangd is machine-specific (cpx
+new adds it to .gitignore). Run this again after moving the project or
+changing its settings.`,
+		Example: `  cpx gen clangd`,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGenClangd()
+		},
+	}
+	cmd.AddCommand(clangdCmd)
+
+	toolchainCmd := &cobra.Command{
+		Use:   "toolchain",
+		Short: "Generate a cross-compilation toolchain file",
+		Long: `Write a CMake toolchain file (` + filepath.Join(templates.CrossToolchainDir, "<target>.cmake") + `) or, in
+Meson projects, a cross file (` + filepath.Join(templates.CrossFileDir, "<target>.ini") + `) for a GNU target
+triple, to cross-compile without Docker:
+
+  cpx build --toolchain <target>
+
+The file uses the triple's GNU cross compilers (e.g. aarch64-linux-gnu-g++),
+or clang with --target, and the target's headers and libraries from
+--sysroot. vcpkg projects also install their dependencies for the target.`,
+		Example: `  cpx gen toolchain --target aarch64-linux-gnu
+  cpx gen toolchain --target arm-linux-gnueabihf --sysroot /opt/rpi-sysroot
+  cpx gen toolchain --target x86_64-w64-mingw32
+  cpx gen toolchain --target riscv64-linux-gnu --compiler clang`,
+		Args: cobra.NoArgs,
+		RunE: runGenToolchain,
+	}
+	toolchainCmd.Flags().String("target", "", "Target triple (e.g. aarch64-linux-gnu, arm-linux-gnueabihf, x86_64-w64-mingw32)")
+	toolchainCmd.Flags().String("compiler", "gcc", "Compiler: gcc (the triple's cross compilers) or clang")
+	toolchainCmd.Flags().String("sysroot", "", "Root of the target's headers and libraries")
+	toolchainCmd.Flags().Bool("force", false, "Overwrite an existing file")
+	toolchainCmd.MarkFlagRequired("target")
+	cmd.AddCommand(toolchainCmd)
+
+	return cmd
+}
+
+func runGenToolchain(cmd *cobra.Command, _ []string) error {
+	triple, _ := cmd.Flags().GetString("target")
+	compiler, _ := cmd.Flags().GetString("compiler")
+	sysroot, _ := cmd.Flags().GetString("sysroot")
+	force, _ := cmd.Flags().GetBool("force")
+
+	target, err := templates.ParseCrossTarget(triple)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if compiler != "gcc" && compiler != "clang" {
+		return exitcode.Errorf(exitcode.Usage, "unsupported compiler %q\n  hint: use gcc or clang", compiler)
+	}
+	if sysroot != "" {
+		if sysroot, err = filepath.Abs(sysroot); err != nil {
+			return err
+		}
+	}
+
+	projectType := DetectProjectType()
+	if projectType == ProjectTypeBazel {
+		return exitcode.Errorf(exitcode.Usage, "cpx gen toolchain does not support Bazel projects\n  hint: use Bazel platforms and toolchains (--platforms)")
+	}
+	path := crossFilePath(triple, projectType)
+	content := templates.GenerateCrossToolchainFile(target, compiler, sysroot)
+	if projectType == ProjectTypeMeson {
+		content = templates.GenerateMesonCrossFile(target, compiler, sysroot)
+	}
+	if !force {
+		if _, err := os.Stat(path); err == nil {
+			return exitcode.Errorf(exitcode.Usage, "%s already exists\n  hint: use --force to overwrite it", path)
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	logging.Success("%s Generated %s (%s, %s)", IconSuccess, path, target.System, target.Processor)
+	fmt.Printf("  Cross-compile with: cpx build --toolchain %s\n", triple)
+	if _, err := os.Stat("vcpkg.json"); err == nil && projectType != ProjectTypeMeson {
+		if triplet := target.VcpkgTriplet(); triplet != "" {
+			fmt.Printf("  %svcpkg installs the dependencies for %s%s\n", Dim, triplet, Reset)
+		} else {
+			logging.Warn("vcpkg has no triplet for %s; dependencies are built for the host", triple)
+		}
+	}
+	return nil
+}
+
+func runGenClangd() error {
+	projectType := DetectProjectType()
+	if projectType == ProjectTypeUnknown {
+		if _, err := os.Stat("CMakeLists.txt"); err != nil {
+			return exitcode.Errorf(exitcode.Config, "no CMakeLists.txt, meson.build or MODULE.bazel found\n  hint: run from the project root")
+		}
+	}
+
+	var suppress []string
+	project, err := config.LoadProject(config.ProjectFile)
+	if err == nil {
+		suppress = project.Clangd.Suppress
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	cppStandard := detectCppStandard(projectType)
+	content := templates.GenerateClangd(cppStandard, clangdIncludeDirs(cwd), suppress)
+	if err := os.WriteFile(".clangd", []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write .clangd: %w", err)
+	}
+	logging.Success("%s Wrote .clangd (C++%d)", IconSuccess, cppStandard)
+	return nil
+}
+
+// clangdIncludeDirs returns the absolute include directories of the project
+// in root that exist
+func clangdIncludeDirs(root string) []string {
+	var dirs []string
+	for _, name := range []string{"include", "src"} {
+		dir := filepath.Join(root, name)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+var cppStandardPatterns = map[ProjectType]struct {
+	file    string
+	pattern *regexp.Regexp
+}{
+	ProjectTypeVcpkg: {"CMakeLists.txt", regexp.MustCompile(`CMAKE_CXX_STANDARD\s+(\d+)`)},
+	ProjectTypeBazel: {".bazelrc", regexp.MustCompile(`-std=c\+\+(\d+)`)},
+	ProjectTypeMeson: {"meson.build", regexp.MustCompile(`cpp_std=c\+\+(\d+)`)},
+}
+
+// detectCppStandard returns the C++ standard the project's build files set,
+// or 17 (the cpx new default)
+func detectCppStandard(projectType ProjectType) int {
+	source, ok := cppStandardPatterns[projectType]
+	if !ok {
+		// CMake without vcpkg
+		source = cppStandardPatterns[ProjectTypeVcpkg]
+	}
+	if data, err := os.ReadFile(source.file); err == nil {
+		if m := source.pattern.FindSubmatch(data); m != nil {
+			if standard, err := strconv.Atoi(string(m[1])); err == nil {
+				return standard
+			}
+		}
+	}
+	return 17
+}
+
+func runGenDevcontainer(cmd *cobra.Command, _ []string) error {
+	compiler, _ := cmd.Flags().GetString("compiler")
+	force, _ := cmd.Flags().GetBool("force")
+
+	opts, err := projectToolchain(DetectProjectType(), compiler)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		filepath.Join(devcontainerDir, "devcontainer.json"): templates.GenerateDevcontainerJSON(opts),
+		filepath.Join(devcontainerDir, "Dockerfile"):        templates.GenerateDevcontainerDockerfile(opts),
+	}
+	if !force {
+		for path := range files {
+			if _, err := os.Stat(path); err == nil {
+				return exitcode.Errorf(exitcode.Usage, "%s already exists\n  hint: use --force to overwrite it", path)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(devcontainerDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", devcontainerDir, err)
+	}
+	for _, name := range []string{"devcontainer.json", "Dockerfile"} {
+		path := filepath.Join(devcontainerDir, name)
+		if err := os.WriteFile(path, []byte(files[path]), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+
+	version := opts.CompilerVersion
+	if version == "" {
+		version = "default"
+	}
+	fmt.Printf("%s%s Generated %s/ (%s, %s %s", Green, IconSuccess, devcontainerDir, opts.BuildSystem, opts.Compiler, version)
+	if opts.Vcpkg {
+		fmt.Print(", vcpkg")
+	}
+	fmt.Printf(")%s\n", Reset)
+	fmt.Printf("  Open the folder in VS Code and run \"Dev Containers: Reopen in Container\"\n")
+	return nil
+}
+
+func runGenNix(cmd *cobra.Command, _ []string) error {
+	compiler, _ := cmd.Flags().GetString("compiler")
+	force, _ := cmd.Flags().GetBool("force")
+
+	opts, err := projectToolchain(DetectProjectType(), compiler)
+	if err != nil {
+		return err
+	}
+	if !force {
+		if _, err := os.Stat("flake.nix"); err == nil {
+			return exitcode.Errorf(exitcode.Usage, "flake.nix already exists\n  hint: use --force to overwrite it")
+		}
+	}
+	if err := os.WriteFile("flake.nix", []byte(templates.GenerateFlakeNix(opts)), 0644); err != nil {
+		return fmt.Errorf("failed to write flake.nix: %w", err)
+	}
+
+	logging.Success("%s Generated flake.nix (%s, %s)", IconSuccess, opts.BuildSystem, opts.Compiler)
+	for _, port := range opts.Dependencies {
+		if templates.NixPackage(port) == "" {
+			logging.Warn("no nixpkgs package known for vcpkg port %q; add it to buildInputs in flake.nix", port)
+		}
+	}
+	fmt.Printf("  Run \"nix develop\" for a shell with the toolchain")
+	if opts.BuildSystem != "bazel" {
+		fmt.Printf(" or \"nix build\" to build the project")
+	}
+	fmt.Println()
+	fmt.Printf("  %sFlakes only see files tracked by git: git add flake.nix%s\n", Dim, Reset)
+	return nil
+}
+
+func runGenDockerfile(cmd *cobra.Command, _ []string) error {
+	target, _ := cmd.Flags().GetString("target")
+	binary, _ := cmd.Flags().GetString("binary")
+	force, _ := cmd.Flags().GetBool("force")
+
+	toolchain, err := projectToolchain(DetectProjectType(), "")
+	if err != nil {
+		return err
+	}
+	if toolchain.BuildSystem == "bazel" && templates.IsMuslTarget(target) {
+		return exitcode.Errorf(exitcode.Usage, "Bazel is not supported on musl target %s\n  hint: use a glibc target such as linux-amd64", target)
+	}
+	if binary == "" {
+		if _, err := os.Stat(filepath.Join("src", "main.cpp")); err != nil {
+			return exitcode.Errorf(exitcode.Config, "src/main.cpp not found; the project does not seem to build an executable\n  hint: pass --binary <name> for the executable to ship")
+		}
+		binary = toolchain.ProjectName
+	}
+	if !force {
+		if _, err := os.Stat("Dockerfile"); err == nil {
+			return exitcode.Errorf(exitcode.Usage, "Dockerfile already exists\n  hint: use --force to overwrite it")
+		}
+	}
+
+	opts := templates.DeployOptions{ToolchainOptions: toolchain, Binary: binary, Target: target}
+	if err := os.WriteFile("Dockerfile", []byte(templates.GenerateDeployDockerfile(opts)), 0644); err != nil {
+		return fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+	logging.Success("%s Generated Dockerfile (%s, %s, cpx-%s)", IconSuccess, toolchain.BuildSystem, binary, target)
+	if _, err := os.Stat(".dockerignore"); os.IsNotExist(err) {
+		if err := os.WriteFile(".dockerignore", []byte(templates.GenerateDockerignore()), 0644); err != nil {
+			return fmt.Errorf("failed to write .dockerignore: %w", err)
+		}
+		logging.Success("%s Generated .dockerignore", IconSuccess)
+	}
+	fmt.Printf("  Build the toolchain image with \"cpx ci\" (target %s), then: docker build -t %s .\n", target, strings.ToLower(toolchain.ProjectName))
+	return nil
+}
+
+// projectToolchain describes the toolchain of the project in the current
+// directory. compiler overrides the compiler pinned in the tool manifest.
+func projectToolchain(projectType ProjectType, compiler string) (templates.ToolchainOptions, error) {
+	var opts templates.ToolchainOptions
+	switch projectType {
+	case ProjectTypeBazel:
+		opts.BuildSystem = "bazel"
+	case ProjectTypeMeson:
+		opts.BuildSystem = "meson"
+	default:
+		if _, err := os.Stat("CMakeLists.txt"); err != nil {
+			return opts, exitcode.Errorf(exitcode.Config, "no CMakeLists.txt, meson.build or MODULE.bazel found\n  hint: run from the project root")
+		}
+		opts.BuildSystem = "cmake"
+	}
+
+	opts.ProjectName, opts.ProjectVersion = getProjectInfo()
+	if opts.ProjectName == "Project" {
+		if cwd, err := os.Getwd(); err == nil {
+			opts.ProjectName = filepath.Base(cwd)
+		}
+	}
+
+	manifest, err := loadToolsManifest()
+	if err != nil {
+		return opts, err
+	}
+	pinned := map[string]string{}
+	if manifest != nil {
+		pinned = manifest.Tools
+	}
+
+	switch compiler {
+	case "gcc", "clang":
+		opts.Compiler = compiler
+	case "":
+		opts.Compiler = "gcc"
+		if _, ok := pinned["clang++"]; ok {
+			opts.Compiler = "clang"
+		} else if _, ok := pinned["clang"]; ok {
+			opts.Compiler = "clang"
+		}
+	default:
+		return opts, exitcode.Errorf(exitcode.Usage, "invalid --compiler %q: expected gcc or clang", compiler)
+	}
+	tools := []string{"g++", "gcc"}
+	if opts.Compiler == "clang" {
+		tools = []string{"clang++", "clang"}
+	}
+	for _, tool := range tools {
+		if v := pinnedVersion(pinned[tool], 1); v != "" {
+			opts.CompilerVersion = v
+			break
+		}
+	}
+	if opts.BuildSystem == "cmake" {
+		if v := pinnedVersion(pinned["cmake"], 2); v != "" {
+			opts.CMakeVersion = v
+			if strings.HasPrefix(strings.TrimSpace(pinned["cmake"]), ">=") {
+				opts.CMakeVersion = ">=" + v
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("vcpkg.json"); err == nil {
+		opts.Vcpkg = true
+		var manifest struct {
+			Baseline     string            `json:"builtin-baseline"`
+			Dependencies []json.RawMessage `json:"dependencies"`
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return opts, exitcode.Errorf(exitcode.Config, "failed to parse vcpkg.json: %v", err)
+		}
+		opts.VcpkgBaseline = manifest.Baseline
+		for _, raw := range manifest.Dependencies {
+			// A dependency is a port name or an object with a name
+			var name string
+			if json.Unmarshal(raw, &name) != nil {
+				var dep struct {
+					Name string `json:"name"`
+				}
+				_ = json.Unmarshal(raw, &dep)
+				name = dep.Name
+			}
+			if name != "" {
+				opts.Dependencies = append(opts.Dependencies, name)
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+var pinnedVersionRe = regexp.MustCompile(`^(>=|=)?\s*(\d+(?:\.\d+)*)`)
+
+// pinnedVersion returns the first parts components of the version a tool
+// manifest constraint asks for ("17" and ">=17.0.2" give "17" for parts 1),
+// or "" if the constraint does not name a minimum version (e.g. "<18")
+func pinnedVersion(constraint string, parts int) string {
+	m := pinnedVersionRe.FindStringSubmatch(strings.TrimSpace(constraint))
+	if m == nil {
+		return ""
+	}
+	components := strings.Split(m[2], ".")
+	if len(components) > parts {
+		components = components[:parts]
+	}
+	return strings.Join(components, ".")
+}
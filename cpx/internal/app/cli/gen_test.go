@@ -0,0 +1,237 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectToolchain(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	_, err = projectToolchain(ProjectTypeUnknown, "")
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(myapp VERSION 1.0.0)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "myapp", "version": "1.2.0", "builtin-baseline": "abc123", "dependencies": ["fmt", {"name": "spdlog", "features": ["wchar"]}]}`), 0644))
+	require.NoError(t, os.WriteFile(config.ToolsFile, []byte("tools:\n  clang++: \">=18.1\"\n  cmake: \"3.30.2\"\n"), 0644))
+
+	opts, err := projectToolchain(ProjectTypeVcpkg, "")
+	require.NoError(t, err)
+	assert.Equal(t, "myapp", opts.ProjectName)
+	assert.Equal(t, "1.2.0", opts.ProjectVersion)
+	assert.Equal(t, "cmake", opts.BuildSystem)
+	assert.Equal(t, "clang", opts.Compiler)
+	assert.Equal(t, "18", opts.CompilerVersion)
+	assert.Equal(t, "3.30", opts.CMakeVersion)
+	assert.True(t, opts.Vcpkg)
+	assert.Equal(t, "abc123", opts.VcpkgBaseline)
+	assert.Equal(t, []string{"fmt", "spdlog"}, opts.Dependencies)
+
+	// --compiler overrides the manifest; no g++ version is pinned
+	opts, err = projectToolchain(ProjectTypeVcpkg, "gcc")
+	require.NoError(t, err)
+	assert.Equal(t, "gcc", opts.Compiler)
+	assert.Empty(t, opts.CompilerVersion)
+
+	_, err = projectToolchain(ProjectTypeVcpkg, "msvc")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestPinnedVersion(t *testing.T) {
+	assert.Equal(t, "17", pinnedVersion("17", 1))
+	assert.Equal(t, "17", pinnedVersion(">=17.0.2", 1))
+	assert.Equal(t, "3.28", pinnedVersion("3.28.1", 2))
+	assert.Equal(t, "3", pinnedVersion("3", 2))
+	assert.Empty(t, pinnedVersion("<18", 1))
+	assert.Empty(t, pinnedVersion("", 1))
+}
+
+func TestRunGenNix(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(app VERSION 0.2.0)\n"), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": ["fmt", "some-port"]}`), 0644))
+
+	cmd := GenCmd()
+	cmd.SetArgs([]string{"nix"})
+	require.NoError(t, cmd.Execute())
+	data, err := os.ReadFile("flake.nix")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `pname = "app";`)
+	assert.Contains(t, string(data), "buildInputs = with pkgs; [ fmt ];")
+	assert.Contains(t, string(data), `vcpkg port "some-port"`)
+
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"nix"})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"nix", "--force", "--compiler", "clang"})
+	require.NoError(t, cmd.Execute())
+	data, err = os.ReadFile("flake.nix")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "stdenv = pkgs.clangStdenv;")
+}
+
+func TestRunGenDockerfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(server VERSION 1.0.0)\n"), 0644))
+
+	// A library project has nothing to ship without --binary
+	cmd := GenCmd()
+	cmd.SetArgs([]string{"dockerfile"})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	assert.Equal(t, exitcode.Config, exitcode.Of(cmd.Execute()))
+
+	require.NoError(t, os.MkdirAll("src", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("src", "main.cpp"), []byte("int main() {}\n"), 0644))
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"dockerfile", "--target", "linux-arm64"})
+	require.NoError(t, cmd.Execute())
+	data, err := os.ReadFile("Dockerfile")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ARG BUILD_IMAGE=cpx-linux-arm64")
+	assert.Contains(t, string(data), "cmake --build /build --target server")
+	assert.FileExists(t, ".dockerignore")
+
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"dockerfile"})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+}
+
+func TestRunGenDevcontainer(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('app', 'cpp')\n"), 0644))
+
+	cmd := GenCmd()
+	cmd.SetArgs([]string{"devcontainer"})
+	require.NoError(t, cmd.Execute())
+	assert.FileExists(t, filepath.Join(devcontainerDir, "devcontainer.json"))
+	assert.FileExists(t, filepath.Join(devcontainerDir, "Dockerfile"))
+
+	// Existing files are kept unless --force
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"devcontainer"})
+	cmd.SilenceErrors, cmd.SilenceUsage = true, true
+	err = cmd.Execute()
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"devcontainer", "--force", "--compiler", "clang"})
+	require.NoError(t, cmd.Execute())
+	data, err := os.ReadFile(filepath.Join(devcontainerDir, "Dockerfile"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "clang++")
+}
+
+func TestRunGenClangd(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	assert.Equal(t, exitcode.Config, exitcode.Of(runGenClangd()))
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("set(CMAKE_CXX_STANDARD 23)\n"), 0644))
+	require.NoError(t, os.Mkdir("include", 0755))
+	require.NoError(t, os.WriteFile(config.ProjectFile, []byte("clangd:\n  suppress: [unused-includes]\n"), 0644))
+
+	require.NoError(t, runGenClangd())
+	data, err := os.ReadFile(".clangd")
+	require.NoError(t, err)
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "-std=c++23")
+	assert.Contains(t, string(data), "-I"+filepath.Join(cwd, "include"))
+	assert.NotContains(t, string(data), "-I"+filepath.Join(cwd, "src"), "missing directories are skipped")
+	assert.Contains(t, string(data), "- unused-includes")
+}
+
+func TestDetectCppStandard(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	assert.Equal(t, 17, detectCppStandard(ProjectTypeUnknown), "default")
+
+	require.NoError(t, os.WriteFile(".bazelrc", []byte("build --cxxopt=-std=c++20\n"), 0644))
+	assert.Equal(t, 20, detectCppStandard(ProjectTypeBazel))
+
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('app', 'cpp', default_options: ['cpp_std=c++14'])\n"), 0644))
+	assert.Equal(t, 14, detectCppStandard(ProjectTypeMeson))
+}
+
+func TestRunGenToolchain(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(app)\n"), 0644))
+
+	cmd := GenCmd()
+	cmd.SetArgs([]string{"toolchain", "--target", "aarch64-linux-gnu", "--sysroot", "sysroot"})
+	require.NoError(t, cmd.Execute())
+	path := filepath.Join("cmake", "toolchains", "aarch64-linux-gnu.cmake")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "set(CMAKE_CXX_COMPILER aarch64-linux-gnu-g++)")
+	assert.Contains(t, string(data), "set(CMAKE_SYSROOT "+filepath.Join(tmpDir, "sysroot")+")", "the sysroot is made absolute")
+
+	// cpx build --toolchain finds it by its triple
+	cross, err := resolveCrossToolchain("aarch64-linux-gnu", ProjectTypeVcpkg)
+	require.NoError(t, err)
+	assert.Equal(t, "aarch64-linux-gnu", cross.Name)
+	assert.Equal(t, filepath.Join(tmpDir, path), cross.File)
+	assert.Equal(t, "arm64-linux", cross.VcpkgTriplet)
+
+	_, err = resolveCrossToolchain("arm-linux-gnueabihf", ProjectTypeVcpkg)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+
+	for _, args := range [][]string{
+		{"toolchain", "--target", "aarch64-linux-gnu"},
+		{"toolchain", "--target", "sparc-linux-gnu"},
+		{"toolchain", "--target", "aarch64-linux-gnu", "--compiler", "msvc", "--force"},
+	} {
+		cmd = GenCmd()
+		cmd.SetArgs(args)
+		cmd.SilenceErrors, cmd.SilenceUsage = true, true
+		assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()), args)
+	}
+
+	// Meson projects get a cross file
+	require.NoError(t, os.Remove("CMakeLists.txt"))
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('app', 'cpp')\n"), 0644))
+	cmd = GenCmd()
+	cmd.SetArgs([]string{"toolchain", "--target", "aarch64-linux-gnu"})
+	require.NoError(t, cmd.Execute())
+	data, err = os.ReadFile(filepath.Join("cross", "aarch64-linux-gnu.ini"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "cpu_family = 'aarch64'")
+}
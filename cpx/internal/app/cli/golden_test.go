@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata/golden")
+
+// TestNewProjectGolden scaffolds sample projects and compares every generated
+// file with testdata/golden/<case>. Run `go test ./internal/app/cli -run
+// TestNewProjectGolden -update` after an intended template change and review
+// the diff.
+func TestNewProjectGolden(t *testing.T) {
+	goldenRoot, err := filepath.Abs(filepath.Join("testdata", "golden"))
+	require.NoError(t, err)
+
+	tests := []tui.ProjectConfig{
+		{
+			Name:           "cmake-app",
+			PackageManager: "none",
+			CppStandard:    20,
+			TestFramework:  "googletest",
+			Benchmark:      "google-benchmark",
+			ClangFormat:    "Google",
+			PCH:            true,
+			VCS:            "none",
+		},
+		{
+			Name:           "bazel-lib",
+			IsLibrary:      true,
+			PackageManager: "bazel",
+			CppStandard:    17,
+			TestFramework:  "catch2",
+			Benchmark:      "none",
+			ClangFormat:    "LLVM",
+			VCS:            "none",
+		},
+		{
+			Name:           "meson-app",
+			PackageManager: "meson",
+			CppStandard:    17,
+			TestFramework:  "none",
+			Benchmark:      "none",
+			ClangFormat:    "Google",
+			ComputeBackend: "openmp",
+			VCS:            "none",
+		},
+		{
+			Name:           "grpc-service",
+			PackageManager: "vcpkg",
+			CppStandard:    17,
+			TestFramework:  "googletest",
+			Benchmark:      "none",
+			ClangFormat:    "Google",
+			VCS:            "none",
+			Template:       "grpc-service",
+		},
+		{
+			Name:           "cli-app",
+			PackageManager: "none",
+			CppStandard:    17,
+			TestFramework:  "catch2",
+			Benchmark:      "none",
+			ClangFormat:    "Google",
+			VCS:            "none",
+			Template:       "cli-app",
+		},
+		{
+			Name:           "embedded",
+			PackageManager: "none",
+			CppStandard:    17,
+			TestFramework:  "doctest",
+			Benchmark:      "none",
+			ClangFormat:    "Google",
+			VCS:            "none",
+			Template:       "embedded",
+		},
+	}
+
+	for _, config := range tests {
+		t.Run(config.Name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			oldWd, err := os.Getwd()
+			require.NoError(t, err)
+			defer os.Chdir(oldWd)
+			require.NoError(t, os.Chdir(tmpDir))
+
+			require.NoError(t, createProjectFromTUI(config, nil, false))
+
+			generated := readTree(t, filepath.Join(tmpDir, config.Name))
+			goldenDir := filepath.Join(goldenRoot, config.Name)
+			if *updateGolden {
+				require.NoError(t, os.RemoveAll(goldenDir))
+				for rel, content := range generated {
+					path := filepath.Join(goldenDir, rel)
+					require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+					require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+				}
+				return
+			}
+
+			golden := readTree(t, goldenDir)
+			for rel, want := range golden {
+				got, ok := generated[rel]
+				if assert.True(t, ok, "%s is no longer generated", rel) {
+					assert.Equal(t, want, got, "%s differs from the golden file (rerun with -update if intended)", rel)
+				}
+			}
+			for rel := range generated {
+				_, ok := golden[rel]
+				assert.True(t, ok, "%s is generated but has no golden file (rerun with -update)", rel)
+			}
+		})
+	}
+}
+
+// readTree returns the contents of all regular files under root, keyed by
+// slash-separated relative path. Absolute paths to root (e.g. in .clangd)
+// are replaced with <root>.
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	roots := []string{root}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil && resolved != root {
+		roots = append(roots, resolved)
+	}
+	files := make(map[string]string)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		content := string(data)
+		for _, r := range roots {
+			content = strings.ReplaceAll(content, r, "<root>")
+		}
+		files[filepath.ToSlash(rel)] = content
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
@@ -1,7 +1,17 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/git"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +19,7 @@ import (
 func HooksCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "hooks",
-		Short: "Install git hooks",
+		Short: "Manage git hooks",
 		Long: `Install git hooks for code quality and automation:
    pre-commit   - Format code and run linters before commit
    pre-push     - Run tests and security checks before push
@@ -20,15 +30,236 @@ func HooksCmd() *cobra.Command {
 	installCmd := &cobra.Command{
 		Use:   "install",
 		Short: "Install git hooks",
-		Long:  "Install git hooks with default configuration (fmt, lint for pre-commit; test for pre-push).",
-		RunE:  runHooksInstall,
+		Long: `Install the git hooks listed under hooks in cpx.yaml, or the defaults (fmt,
+lint for pre-commit; test for pre-push). Entries other than the cpx checks
+(fmt, lint, test, flawfinder, cppcheck, check) run as commands:
+
+  hooks:
+    precommit: ["fmt", "lint", "./scripts/check_licenses.sh"]
+    prepush: ["test"]`,
+		RunE: runHooksInstall,
 	}
+	installCmd.Flags().Bool("commit-msg", false, "Also install the commit-msg hook checking Conventional Commits messages")
 	cmd.AddCommand(installCmd)
 
+	commitMsgCmd := &cobra.Command{
+		Use:   "commit-msg <file>",
+		Short: "Check a commit message (run by the commit-msg hook)",
+		Long: `Check that the commit message in file follows Conventional Commits
+("type(scope): description") with the types and scopes of cpx.yaml:
+
+  hooks:
+    commit_msg:
+      types: [feat, fix, docs, refactor, test, chore]
+      scopes: [core, cli, build]
+
+Without types, the standard ones (feat, fix, docs, style, refactor, perf,
+test, build, ci, chore, revert) are accepted; without scopes, any scope is.
+Merge, revert, fixup! and squash! messages generated by git always pass.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+			return nil
+		},
+		RunE: runHooksCommitMsg,
+	}
+	cmd.AddCommand(commitMsgCmd)
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "List the installed git hooks and their checks",
+		Args:  cobra.NoArgs,
+		RunE:  runHooksStatus,
+	}
+	cmd.AddCommand(statusCmd)
+
+	uninstallCmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Remove the git hooks installed by cpx",
+		Long: `Remove the git hooks installed by cpx and put back the samples git init
+creates. Hooks cpx did not write are left untouched.`,
+		Args: cobra.NoArgs,
+		RunE: runHooksUninstall,
+	}
+	cmd.AddCommand(uninstallCmd)
+
+	runCmd := &cobra.Command{
+		Use:   "run <hook> [args...]",
+		Short: "Run an installed git hook",
+		Long: `Run an installed git hook from the top of the work tree, as git would,
+e.g. to check the staged files before committing. Arguments are passed to the
+hook: the commit-msg hook takes the file holding the message.`,
+		Example: `  cpx hooks run pre-commit
+  cpx hooks run commit-msg .git/COMMIT_EDITMSG`,
+		ValidArgs: git.ManagedHooks,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MinimumNArgs(1)(cmd, args); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+			if args[0] == "commit-msg" && len(args) < 2 {
+				return exitcode.Errorf(exitcode.Usage, "the commit-msg hook takes the file holding the message\n  hint: cpx hooks run commit-msg .git/COMMIT_EDITMSG")
+			}
+			return nil
+		},
+		RunE: withExitCode(exitcode.QualityGate, runHooksRun),
+	}
+	cmd.AddCommand(runCmd)
+
+	exportCmd := &cobra.Command{
+		Use:   "export pre-commit",
+		Short: "Export the hooks for the pre-commit framework",
+		Long: `Write a .pre-commit-config.yaml running the cpx checks as local hooks, for
+teams that manage their hooks with the pre-commit framework
+(https://pre-commit.com) instead of the scripts of 'cpx hooks install'.
+
+fmt, lint and cppcheck run on commit and test on push; install both with
+  pre-commit install --hook-type pre-commit --hook-type pre-push`,
+		Example: `  cpx hooks export pre-commit
+  cpx hooks export pre-commit --checks fmt,lint --output -`,
+		ValidArgs: []string{"pre-commit"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+			if err := cobra.OnlyValidArgs(cmd, args); err != nil {
+				return exitcode.Errorf(exitcode.Usage, "%v\n  hint: use pre-commit", err)
+			}
+			return nil
+		},
+		RunE: runHooksExport,
+	}
+	exportCmd.Flags().StringSlice("checks", git.PreCommitChecks, "Checks to export (fmt, lint, cppcheck, test)")
+	exportCmd.Flags().StringP("output", "o", git.PreCommitConfigFile, "File to write, or - for stdout")
+	exportCmd.Flags().Bool("force", false, "Overwrite an existing file")
+	exportCmd.RegisterFlagCompletionFunc("checks", cobra.FixedCompletions(git.PreCommitChecks, cobra.ShellCompDirectiveNoFileComp))
+	cmd.AddCommand(exportCmd)
+
 	return cmd
 }
 
-func runHooksInstall(_ *cobra.Command, _ []string) error {
-	// Use default hooks - no cpx.yaml needed
-	return git.InstallHooksWithConfig([]string{"fmt", "lint"}, []string{"test"})
+func runHooksInstall(cmd *cobra.Command, _ []string) error {
+	commitMsg, _ := cmd.Flags().GetBool("commit-msg")
+
+	hooks, err := loadProjectHooks()
+	if err != nil {
+		return err
+	}
+
+	// The hooks of cpx.yaml, if any, replace the defaults
+	preCommit, prePush := []string{"fmt", "lint"}, []string{"test"}
+	if len(hooks.PreCommit) > 0 {
+		preCommit = hooks.PreCommit
+	}
+	if len(hooks.PrePush) > 0 {
+		prePush = hooks.PrePush
+	}
+
+	return git.InstallHooksWithConfig(preCommit, prePush, commitMsg)
+}
+
+func runHooksStatus(_ *cobra.Command, _ []string) error {
+	statuses, err := git.HooksStatus()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	for _, status := range statuses {
+		switch {
+		case !status.Installed:
+			fmt.Printf("  %s%-12s not installed%s\n", Dim, status.Name, Reset)
+		case !status.Managed:
+			fmt.Printf("  %-12s installed, not by cpx\n", status.Name)
+		case status.Checks == nil:
+			fmt.Printf("  %s%-12s%s installed by cpx\n", Green, status.Name, Reset)
+		default:
+			fmt.Printf("  %s%-12s%s %s\n", Green, status.Name, Reset, strings.Join(status.Checks, ", "))
+		}
+	}
+	return nil
+}
+
+func runHooksUninstall(_ *cobra.Command, _ []string) error {
+	removed, kept, err := git.UninstallHooks()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	if len(removed) == 0 {
+		logging.Success("No hooks installed by cpx")
+	} else {
+		logging.Success("%s Removed the %s hooks", IconSuccess, strings.Join(removed, ", "))
+	}
+	if len(kept) > 0 {
+		logging.Notice("  Kept the %s hooks, which were not installed by cpx", strings.Join(kept, ", "))
+	}
+	return nil
+}
+
+func runHooksRun(_ *cobra.Command, args []string) error {
+	hooksDir, err := git.HooksDir()
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if _, err := os.Stat(filepath.Join(hooksDir, args[0])); err != nil {
+		return exitcode.Errorf(exitcode.Usage, "the %s hook is not installed\n  hint: run 'cpx hooks install'", args[0])
+	}
+	return git.RunHook(args[0], args[1:])
+}
+
+func runHooksCommitMsg(_ *cobra.Command, args []string) error {
+	message, err := os.ReadFile(args[0])
+	if err != nil {
+		return exitcode.Errorf(exitcode.Usage, "failed to read the commit message: %v", err)
+	}
+
+	hooks, err := loadProjectHooks()
+	if err != nil {
+		return err
+	}
+	rules := hooks.CommitMsg
+	if err := git.ValidateCommitMessage(string(message), rules.Types, rules.Scopes); err != nil {
+		return exitcode.Wrap(exitcode.QualityGate, err)
+	}
+	return nil
+}
+
+// loadProjectHooks returns the hooks section of cpx.yaml, empty without one
+func loadProjectHooks() (config.ProjectHooks, error) {
+	project, err := config.LoadProject(config.ProjectFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.ProjectHooks{}, nil
+	}
+	if err != nil {
+		return config.ProjectHooks{}, exitcode.Wrap(exitcode.Config, err)
+	}
+	return project.Hooks, nil
+}
+
+func runHooksExport(cmd *cobra.Command, _ []string) error {
+	checks, _ := cmd.Flags().GetStringSlice("checks")
+	output, _ := cmd.Flags().GetString("output")
+	force, _ := cmd.Flags().GetBool("force")
+
+	config, err := git.PreCommitConfig(checks)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if output == "-" {
+		_, err := cmd.OutOrStdout().Write(config)
+		return err
+	}
+
+	if !force {
+		if _, err := os.Stat(output); err == nil {
+			return exitcode.Errorf(exitcode.Usage, "%s already exists\n  hint: use --force to overwrite it, or --output - to print the configuration", output)
+		}
+	}
+	if err := os.WriteFile(output, config, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+
+	logging.Success("%s Generated %s", IconSuccess, output)
+	fmt.Println("  Install the hooks with: pre-commit install --hook-type pre-commit --hook-type pre-push")
+	return nil
 }
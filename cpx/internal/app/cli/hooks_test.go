@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/git"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRunHooksExport(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	execute := func(args ...string) (string, error) {
+		root := &cobra.Command{Use: "cpx"}
+		root.AddCommand(HooksCmd())
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetArgs(append([]string{"hooks", "export"}, args...))
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	_, err := execute("pre-commit")
+	require.NoError(t, err)
+	data, err := os.ReadFile(git.PreCommitConfigFile)
+	require.NoError(t, err)
+
+	var config struct {
+		Repos []struct {
+			Repo  string `yaml:"repo"`
+			Hooks []struct {
+				ID            string   `yaml:"id"`
+				Entry         string   `yaml:"entry"`
+				Language      string   `yaml:"language"`
+				PassFilenames bool     `yaml:"pass_filenames"`
+				Files         string   `yaml:"files"`
+				Stages        []string `yaml:"stages"`
+			} `yaml:"hooks"`
+		} `yaml:"repos"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &config))
+	require.Len(t, config.Repos, 1)
+	assert.Equal(t, "local", config.Repos[0].Repo)
+	hooks := config.Repos[0].Hooks
+	require.Len(t, hooks, 4)
+	for i, want := range []string{"cpx fmt --check", "cpx lint --staged", "cpx cppcheck --quiet", "cpx test"} {
+		assert.Equal(t, want, hooks[i].Entry)
+		assert.Equal(t, "system", hooks[i].Language)
+		assert.False(t, hooks[i].PassFilenames)
+	}
+	assert.NotEmpty(t, hooks[0].Files)
+	assert.Empty(t, hooks[0].Stages)
+	assert.Empty(t, hooks[3].Files)
+	assert.Equal(t, []string{"pre-push"}, hooks[3].Stages)
+
+	// An existing configuration is kept unless forced
+	_, err = execute("pre-commit", "--checks", "fmt")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	_, err = execute("pre-commit", "--checks", "fmt", "--force")
+	require.NoError(t, err)
+	data, err = os.ReadFile(git.PreCommitConfigFile)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "cpx-lint")
+
+	out, err := execute("pre-commit", "--checks", "lint,test", "-o", "-")
+	require.NoError(t, err)
+	assert.Contains(t, out, "id: cpx-lint")
+	assert.NotContains(t, out, "cpx-fmt")
+
+	_, err = execute("pre-commit", "--checks", "flawfinder", "-o", "-")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	_, err = execute("husky")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestRunHooksCommitMsg(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	execute := func(message string) error {
+		require.NoError(t, os.WriteFile("COMMIT_EDITMSG", []byte(message), 0644))
+		root := &cobra.Command{Use: "cpx"}
+		root.AddCommand(HooksCmd())
+		root.SetArgs([]string{"hooks", "commit-msg", "COMMIT_EDITMSG"})
+		return root.Execute()
+	}
+
+	// The standard types without cpx.yaml
+	assert.NoError(t, execute("refactor(parser): split the lexer\n"))
+	assert.Equal(t, exitcode.QualityGate, exitcode.Of(execute("split the lexer\n")))
+
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte(`hooks:
+  commit_msg:
+    types: [feat, fix]
+    scopes: [core]
+`), 0644))
+	assert.NoError(t, execute("fix(core): leak\n"))
+	assert.Equal(t, exitcode.QualityGate, exitcode.Of(execute("refactor(core): split\n")))
+	assert.Equal(t, exitcode.QualityGate, exitcode.Of(execute("fix(cli): leak\n")))
+
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("hooks: [\n"), 0644))
+	assert.Equal(t, exitcode.Config, exitcode.Of(execute("fix: leak\n")))
+}
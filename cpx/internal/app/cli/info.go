@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/output"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
@@ -10,26 +16,136 @@ import (
 // InfoCmd creates the info command
 func InfoCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "info",
+		Use:   "info <package>...",
 		Short: "Show detailed library information",
-		Long:  "Show detailed library information. Passes through to vcpkg show command.",
+		Long: `Show detailed library information from the port in the vcpkg checkout:
+version, description, homepage, license, dependencies, supported triplets,
+features and how to use it from CMake. With --json, cpx prints an object, or
+an array for several packages.`,
+		Example: `  cpx info fmt
+  cpx info fmt spdlog --json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInfo(cmd, args, client)
+			return runInfo(args, client)
 		},
-		Args: cobra.MinimumNArgs(1),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completePorts,
 	}
-
 	return cmd
 }
 
-func runInfo(_ *cobra.Command, args []string, client *vcpkg.Client) error {
-	// Directly pass all arguments to vcpkg show command
-	// cpx info <package> -> vcpkg show <package>
-	vcpkgArgs := []string{"show"}
-	vcpkgArgs = append(vcpkgArgs, args...)
-
+func runInfo(args []string, client *vcpkg.Client) error {
 	if client == nil {
 		return fmt.Errorf("vcpkg client not initialized")
 	}
-	return client.RunCommand(vcpkgArgs)
+	vcpkgPath, err := client.GetPath()
+	if err != nil {
+		return err
+	}
+	vcpkgRoot := filepath.Dir(vcpkgPath)
+
+	// An up-to-date index of cpx search saves reading the ports
+	var index *vcpkg.Index
+	if path, err := portIndexPath(); err == nil {
+		if loaded, err := vcpkg.LoadIndex(path); err == nil && loaded.Fresh(vcpkgRoot) {
+			index = loaded
+		}
+	}
+
+	var ports []*vcpkg.Port
+	for _, name := range args {
+		if index != nil {
+			if port, ok := index.Port(name); ok {
+				ports = append(ports, port)
+				continue
+			}
+		}
+		port, err := vcpkg.LoadPort(vcpkgRoot, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			return exitcode.Errorf(exitcode.Usage, "no vcpkg port named %q\n  hint: find packages with cpx search %s, or update vcpkg with git -C %s pull", name, name, vcpkgRoot)
+		}
+		if err != nil {
+			return err
+		}
+		ports = append(ports, port)
+	}
+
+	if output.JSON() {
+		if len(ports) == 1 {
+			return output.Print(ports[0])
+		}
+		return output.Print(ports)
+	}
+
+	for i, port := range ports {
+		if i > 0 {
+			fmt.Println()
+		}
+		printPortInfo(port)
+	}
+	return nil
+}
+
+// printPortInfo prints a port for cpx info
+func printPortInfo(port *vcpkg.Port) {
+	fmt.Printf("%s%s%s %s\n", Bold, port.Name, Reset, port.Version)
+	if port.Description != "" {
+		fmt.Printf("  %s\n", port.Description)
+	}
+	fmt.Println()
+
+	field := func(label, value string) {
+		fmt.Printf("  %s%-13s%s%s\n", Dim, label, Reset, value)
+	}
+	if port.Homepage != "" {
+		field("Homepage", port.Homepage)
+	}
+	license := port.License
+	if license == "" {
+		license = "not specified"
+	}
+	field("License", license)
+	supports := port.Supports
+	if supports == "" {
+		supports = "all triplets"
+	}
+	field("Supports", supports)
+
+	if len(port.Dependencies) > 0 {
+		var deps []string
+		for _, dep := range port.Dependencies {
+			d := dep.Name
+			if len(dep.Features) > 0 {
+				d += "[" + strings.Join(dep.Features, ",") + "]"
+			}
+			if dep.Host {
+				d += " (host)"
+			}
+			if dep.Platform != "" {
+				d += " (" + dep.Platform + ")"
+			}
+			deps = append(deps, d)
+		}
+		field("Dependencies", deps[0])
+		for _, d := range deps[1:] {
+			field("", d)
+		}
+	}
+
+	if len(port.Features) > 0 {
+		fmt.Printf("\n%sFeatures%s\n", Cyan, Reset)
+		for _, feature := range port.Features {
+			fmt.Printf("  %-20s %s%s%s\n", feature.Name, Dim, feature.Description, Reset)
+		}
+	}
+
+	fmt.Printf("\n%sUsage%s\n", Cyan, Reset)
+	usage := port.Usage
+	if usage == "" {
+		// No usage notes: the usual targets of a CMake config package
+		usage = fmt.Sprintf("find_package(%s CONFIG REQUIRED)\ntarget_link_libraries(main PRIVATE %s::%s)", port.Name, port.Name, port.Name)
+		fmt.Printf("  %sThe port has no usage notes; a typical CMake setup is:%s\n", Dim, Reset)
+	}
+	for _, line := range strings.Split(usage, "\n") {
+		fmt.Printf("  %s\n", line)
+	}
 }
@@ -1,6 +1,12 @@
 package cli
 
 import (
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/quality"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
@@ -11,18 +17,101 @@ func LintCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "lint",
 		Short: "Run clang-tidy static analysis",
-		Long:  "Run clang-tidy static analysis. Use --fix to automatically fix issues.",
-		RunE: func(cmd *cobra.Command, args []string) error {
+		Long: `Run clang-tidy static analysis.
+
+With --fix, clang-tidy applies the fix-its of its findings and formats the
+fixed code with .clang-format, then cpx lists the findings it fixed and those
+that remain. --fix refuses to run on uncommitted changes, so that the fixes
+can be reviewed and reverted with git; --allow-dirty runs it anyway.
+
+With --changed, only the files changed since HEAD (or --since) are linted,
+with the sources of the compilation database including a changed header, so
+that pre-commit hooks stay fast on large codebases. --staged lints the files
+staged for commit instead, as the pre-commit hook of 'cpx hooks install' does.`,
+		Example: `  cpx lint                      # Report the findings of clang-tidy
+  cpx lint --fix                # Apply the fix-its on a clean git tree
+  cpx lint --fix --allow-dirty  # Apply them on uncommitted changes too
+  cpx lint --changed            # Lint the uncommitted changes
+  cpx lint --since origin/main  # Lint the changes of a branch
+  cpx lint --staged             # Lint the files staged for commit`,
+		RunE: withExitCode(exitcode.QualityGate, func(cmd *cobra.Command, args []string) error {
 			return runLint(cmd, args, client)
-		},
+		}),
 	}
 
 	cmd.Flags().Bool("fix", false, "Automatically fix issues")
+	cmd.Flags().Bool("allow-dirty", false, "Let --fix modify a git tree with uncommitted changes")
+	cmd.Flags().Bool("changed", false, "Lint only the files changed since HEAD and the sources including them")
+	cmd.Flags().String("since", "", "Git ref the changes of --changed are taken from (implies --changed)")
+	cmd.Flags().Bool("staged", false, "Lint only the files staged for commit and the sources including them")
 
 	return cmd
 }
 
 func runLint(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 	fix, _ := cmd.Flags().GetBool("fix")
-	return quality.LintCode(fix, client)
+	allowDirty, _ := cmd.Flags().GetBool("allow-dirty")
+	if fix && !allowDirty {
+		if err := checkCleanTree(); err != nil {
+			return err
+		}
+	}
+
+	// nil lints the whole project
+	var changed []string
+	since, _ := cmd.Flags().GetString("since")
+	onlyChanged, _ := cmd.Flags().GetBool("changed")
+	if staged, _ := cmd.Flags().GetBool("staged"); staged {
+		if onlyChanged || since != "" {
+			return exitcode.Errorf(exitcode.Usage, "--staged cannot be combined with --changed or --since")
+		}
+		var err error
+		if changed, err = quality.StagedCppFiles(); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		if len(changed) == 0 {
+			logging.Success("No C/C++ files staged")
+			return nil
+		}
+	} else if onlyChanged || since != "" {
+		if since == "" {
+			since = "HEAD"
+		}
+		var err error
+		if changed, err = quality.ChangedCppFiles(since); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		if len(changed) == 0 {
+			logging.Success("No C/C++ files changed since %s", since)
+			return nil
+		}
+	}
+
+	switch DetectProjectType() {
+	case ProjectTypeMeson:
+		if err := ensureMesonCompileDatabase(); err != nil {
+			return err
+		}
+		return quality.LintWithCompileDatabase(fix, changed, mesonBuildDir)
+	case ProjectTypeBazel:
+		// Bazel has no CMake configure step to export compile commands
+		if _, err := generateBazelCompileDatabase("//...", filepath.Join(build.BazelCompdbDir, "compile_commands.json"), false); err != nil {
+			return err
+		}
+		return quality.LintWithCompileDatabase(fix, changed, build.BazelCompdbDir)
+	}
+	return quality.LintCode(fix, changed, client)
+}
+
+// checkCleanTree fails unless the git tree has no uncommitted changes, so the
+// fixes of cpx lint --fix are the only changes to review
+func checkCleanTree() error {
+	dirty, err := quality.DirtyFiles()
+	if err != nil {
+		return exitcode.Errorf(exitcode.Usage, "cpx lint --fix applies fixes on a clean git tree: %v\n  hint: use --allow-dirty to fix anyway", err)
+	}
+	if len(dirty) > 0 {
+		return exitcode.Errorf(exitcode.Usage, "cpx lint --fix applies fixes on a clean git tree, but %d files have uncommitted changes: %s\n  hint: commit or stash them, or use --allow-dirty", len(dirty), strings.Join(dirty, ", "))
+	}
+	return nil
 }
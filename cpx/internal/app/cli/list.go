@@ -1,8 +1,11 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
 
+	"github.com/ozacod/cpx/internal/pkg/output"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
@@ -12,7 +15,10 @@ func ListCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List available libraries",
-		Long:  "List available libraries. Passes through to vcpkg list command.",
+		Long: `List available libraries. Passes through to vcpkg list command.
+
+With --json, cpx prints an array of the installed packages with their
+triplet, version and features.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runList(cmd, args, client)
 		},
@@ -30,5 +36,63 @@ func runList(_ *cobra.Command, args []string, client *vcpkg.Client) error {
 	if client == nil {
 		return fmt.Errorf("vcpkg client not initialized")
 	}
-	return client.RunCommand(vcpkgArgs)
+	if !output.JSON() {
+		return client.RunCommand(vcpkgArgs)
+	}
+
+	data, err := client.Output(append(vcpkgArgs, "--x-json"))
+	if err != nil {
+		return fmt.Errorf("vcpkg list failed: %w", err)
+	}
+	packages, err := parseInstalledPackages(data)
+	if err != nil {
+		return err
+	}
+	return output.Print(packages)
+}
+
+// installedPackage is a package of cpx list --json
+type installedPackage struct {
+	Name        string   `json:"name"`
+	Triplet     string   `json:"triplet"`
+	Version     string   `json:"version"`
+	PortVersion int      `json:"port_version"`
+	Features    []string `json:"features"`
+}
+
+// parseInstalledPackages parses the output of vcpkg list --x-json, an object
+// keyed by "name:triplet", into packages sorted by name and triplet
+func parseInstalledPackages(data []byte) ([]installedPackage, error) {
+	var listed map[string]struct {
+		Name        string   `json:"package_name"`
+		Triplet     string   `json:"triplet"`
+		Version     string   `json:"version"`
+		PortVersion int      `json:"port_version"`
+		Features    []string `json:"features"`
+	}
+	if err := json.Unmarshal(data, &listed); err != nil {
+		return nil, fmt.Errorf("failed to parse the output of vcpkg list: %w", err)
+	}
+
+	packages := make([]installedPackage, 0, len(listed))
+	for _, p := range listed {
+		features := p.Features
+		if features == nil {
+			features = []string{}
+		}
+		packages = append(packages, installedPackage{
+			Name:        p.Name,
+			Triplet:     p.Triplet,
+			Version:     p.Version,
+			PortVersion: p.PortVersion,
+			Features:    features,
+		})
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Name != packages[j].Name {
+			return packages[i].Name < packages[j].Name
+		}
+		return packages[i].Triplet < packages[j].Triplet
+	})
+	return packages, nil
 }
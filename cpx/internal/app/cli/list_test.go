@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInstalledPackages(t *testing.T) {
+	packages, err := parseInstalledPackages([]byte(`{
+  "zlib:x64-linux": {"package_name": "zlib", "triplet": "x64-linux", "version": "1.3.1", "port_version": 0, "features": []},
+  "fmt:x64-linux": {"package_name": "fmt", "triplet": "x64-linux", "version": "11.0.2", "port_version": 1},
+  "curl:x64-linux": {"package_name": "curl", "triplet": "x64-linux", "version": "8.8.0", "port_version": 0, "features": ["ssl"]}
+}`))
+	require.NoError(t, err)
+	assert.Equal(t, []installedPackage{
+		{Name: "curl", Triplet: "x64-linux", Version: "8.8.0", Features: []string{"ssl"}},
+		{Name: "fmt", Triplet: "x64-linux", Version: "11.0.2", PortVersion: 1, Features: []string{}},
+		{Name: "zlib", Triplet: "x64-linux", Version: "1.3.1", Features: []string{}},
+	}, packages)
+
+	_, err = parseInstalledPackages([]byte("No packages are installed."))
+	assert.Error(t, err)
+}
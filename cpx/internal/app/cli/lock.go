@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// LockCmd creates the lock command
+func LockCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Pin exact dependency versions",
+		Long: `Pin the exact versions of the project's dependencies and record them in
+` + config.LockFile + `, which cpx build verifies:
+
+  vcpkg   sets builtin-baseline in vcpkg.json (the commit of the vcpkg checkout
+          if there is none) and adds an override with the baseline version
+          for every dependency
+  Bazel   updates MODULE.bazel.lock (bazel mod deps --lockfile_mode=update)
+  Meson   pins the revision of every wrap-git wrap to a commit
+
+Dependencies pinned before keep their version; --update moves vcpkg to the
+commit of the vcpkg checkout (keeping the versions pinned with
+'cpx add <port>@<version>') and refreshes MODULE.bazel.lock. cpx build fails
+when vcpkg.json, MODULE.bazel, MODULE.bazel.lock or a wrap changes without
+cpx lock.`,
+		Example: `  cpx lock            # pin new dependencies
+  cpx lock --update   # move to the latest versions of the vcpkg checkout`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			update, _ := cmd.Flags().GetBool("update")
+			return runLock(client, update)
+		},
+	}
+	cmd.Flags().Bool("update", false, "Refresh the pinned versions instead of keeping them")
+
+	return cmd
+}
+
+func runLock(client *vcpkg.Client, update bool) error {
+	projectType, err := RequireProject("cpx lock")
+	if err != nil {
+		return err
+	}
+
+	lock := &config.Lock{}
+	switch projectType {
+	case ProjectTypeVcpkg:
+		if client == nil {
+			return fmt.Errorf("vcpkg client not initialized")
+		}
+		vcpkgPath, err := client.GetPath()
+		if err != nil {
+			return err
+		}
+		if lock.Vcpkg, err = lockVcpkg(filepath.Dir(vcpkgPath), update); err != nil {
+			return err
+		}
+	case ProjectTypeBazel:
+		if err := lockBazel(update); err != nil {
+			return err
+		}
+	case ProjectTypeMeson:
+		if lock.Wraps, err = lockMesonWraps(); err != nil {
+			return err
+		}
+	}
+
+	if lock.Files, err = dependencyFileHashes(projectType); err != nil {
+		return err
+	}
+	if err := config.SaveLock(lock, config.LockFile); err != nil {
+		return err
+	}
+
+	logging.Success("%s Wrote %s", IconSuccess, config.LockFile)
+	if lock.Vcpkg != nil {
+		fmt.Printf("  baseline %s\n", lock.Vcpkg.Baseline)
+		printLockedVersions(lock.Vcpkg.Packages)
+	}
+	printLockedVersions(lock.Wraps)
+	return nil
+}
+
+func printLockedVersions(versions map[string]string) {
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("  %s %s%s%s\n", name, Dim, versions[name], Reset)
+	}
+}
+
+// dependencyFiles returns the files declaring the dependencies of a project
+func dependencyFiles(projectType ProjectType) ([]string, error) {
+	switch projectType {
+	case ProjectTypeVcpkg:
+		return []string{"vcpkg.json"}, nil
+	case ProjectTypeBazel:
+		return []string{"MODULE.bazel", "MODULE.bazel.lock"}, nil
+	case ProjectTypeMeson:
+		return filepath.Glob(filepath.Join("subprojects", "*.wrap"))
+	}
+	return nil, nil
+}
+
+// dependencyFileHashes returns the sha256 of the dependency files that exist
+func dependencyFileHashes(projectType ProjectType) (map[string]string, error) {
+	files, err := dependencyFiles(projectType)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		// Slash-separated, so that the lockfile is the same on every OS
+		hashes[filepath.ToSlash(file)] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
+
+// verifyLockfile checks that the dependency files match cpx.lock, if the
+// project has one
+func verifyLockfile(projectType ProjectType) error {
+	lock, err := config.LoadLock(config.LockFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	current, err := dependencyFileHashes(projectType)
+	if err != nil {
+		return err
+	}
+
+	var changes []string
+	for file, hash := range current {
+		locked, ok := lock.Files[file]
+		if !ok {
+			changes = append(changes, "  "+file+" (not locked)")
+		} else if locked != hash {
+			changes = append(changes, "  "+file+" (changed)")
+		}
+	}
+	for file := range lock.Files {
+		if _, ok := current[file]; !ok {
+			changes = append(changes, "  "+file+" (removed)")
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	sort.Strings(changes)
+	return exitcode.Errorf(exitcode.Config, "dependencies changed since %s was written:\n%s\n  hint: run 'cpx lock' to pin them, or revert the changes", config.LockFile, strings.Join(changes, "\n"))
+}
+
+// vcpkgBaseline is versions/baseline.json of the vcpkg registry
+type vcpkgBaseline struct {
+	Default map[string]struct {
+		Baseline    string `json:"baseline"`
+		PortVersion int    `json:"port-version"`
+	} `json:"default"`
+}
+
+// lockVcpkg pins vcpkg.json to a builtin-baseline of the vcpkg checkout in
+// vcpkgRoot and overrides its dependencies with their versions at the
+// baseline. Existing overrides are kept unless update is set, which also
+// moves the baseline to the commit of the checkout; versions pinned with
+// cpx add <port>@<version> are kept either way.
+func lockVcpkg(vcpkgRoot string, update bool) (*config.VcpkgLock, error) {
+	manifest, err := vcpkg.ReadManifest("vcpkg.json")
+	if err != nil {
+		return nil, err
+	}
+
+	baseline, _ := manifest["builtin-baseline"].(string)
+	if baseline == "" || update {
+		if baseline, err = vcpkgCheckoutCommit(vcpkgRoot); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := execCommand("git", "-C", vcpkgRoot, "show", baseline+":versions/baseline.json").Output()
+	if err != nil {
+		return nil, exitcode.Errorf(exitcode.Config, "builtin-baseline %s is not in %s\n  hint: update vcpkg with 'cpx upgrade vcpkg', or run 'cpx lock --update'", baseline, vcpkgRoot)
+	}
+	var versions vcpkgBaseline
+	if err := json.Unmarshal(out, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse versions/baseline.json: %w", err)
+	}
+
+	// Overrides by name, in their original order
+	overrides, _ := manifest["overrides"].([]any)
+	overrideIndex := make(map[string]int)
+	for i, o := range overrides {
+		if entry, ok := o.(map[string]any); ok {
+			if name, ok := entry["name"].(string); ok {
+				overrideIndex[name] = i
+			}
+		}
+	}
+
+	deps, _ := manifest["dependencies"].([]any)
+	lock := &config.VcpkgLock{Baseline: baseline, Packages: make(map[string]string)}
+	for _, dep := range deps {
+		name, _ := dep.(string)
+		if entry, ok := dep.(map[string]any); ok {
+			name, _ = entry["name"].(string)
+		}
+		if name == "" {
+			continue
+		}
+
+		if i, ok := overrideIndex[name]; ok {
+			entry := overrides[i].(map[string]any)
+			_, pinned := entry[vcpkgPinKey]
+			if !update || pinned {
+				lock.Packages[name] = overrideVersion(entry)
+				if update {
+					logging.Notice("Kept %s %s, pinned with %s", name, lock.Packages[name], entry[vcpkgPinKey])
+				}
+				continue
+			}
+		}
+		version, ok := versions.Default[name]
+		if !ok {
+			return nil, exitcode.Errorf(exitcode.Config, "port %s is not in the vcpkg registry at baseline %s\n  hint: check the name with 'cpx search %s'", name, baseline, name)
+		}
+		entry := map[string]any{"name": name, "version": version.Baseline}
+		if version.PortVersion > 0 {
+			entry["port-version"] = version.PortVersion
+		}
+		if i, ok := overrideIndex[name]; ok {
+			overrides[i] = entry
+		} else {
+			overrideIndex[name] = len(overrides)
+			overrides = append(overrides, entry)
+		}
+		lock.Packages[name] = overrideVersion(entry)
+	}
+
+	manifest["builtin-baseline"] = baseline
+	if len(overrides) > 0 {
+		manifest["overrides"] = overrides
+	}
+	if err := vcpkg.WriteManifest("vcpkg.json", manifest); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// vcpkgCheckoutCommit returns the commit of the vcpkg checkout in vcpkgRoot
+func vcpkgCheckoutCommit(vcpkgRoot string) (string, error) {
+	out, err := execCommand("git", "-C", vcpkgRoot, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", exitcode.Errorf(exitcode.Config, "failed to get the commit of %s: %v\n  hint: vcpkg_root must be a git clone of vcpkg", vcpkgRoot, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// overrideVersion returns the version of a vcpkg.json override, with the
+// port version after a #
+func overrideVersion(entry map[string]any) string {
+	var version string
+	for _, key := range []string{"version", "version-semver", "version-date", "version-string"} {
+		if v, ok := entry[key].(string); ok {
+			version = v
+			break
+		}
+	}
+	if portVersion, ok := entry["port-version"].(float64); ok && portVersion > 0 {
+		version += fmt.Sprintf("#%d", int(portVersion))
+	} else if portVersion, ok := entry["port-version"].(int); ok && portVersion > 0 {
+		version += fmt.Sprintf("#%d", portVersion)
+	}
+	return version
+}
+
+// lockBazel writes MODULE.bazel.lock; update refreshes the registry data
+// that Bazel would otherwise keep
+func lockBazel(update bool) error {
+	if _, err := execLookPath("bazel"); err != nil {
+		return exitcode.Errorf(exitcode.ToolchainMissing, "bazel not found in PATH: %w", err)
+	}
+	mode := "update"
+	if update {
+		mode = "refresh"
+	}
+	out, err := execCommand("bazel", "mod", "deps", "--lockfile_mode="+mode).CombinedOutput()
+	if err != nil {
+		return exitcode.Errorf(exitcode.BuildFailed, "bazel mod deps failed: %v\n%s", err, out)
+	}
+	if _, err := os.Stat("MODULE.bazel.lock"); err != nil {
+		return exitcode.Errorf(exitcode.Config, "bazel did not write MODULE.bazel.lock\n  hint: lockfiles need Bazel 7 or later")
+	}
+	return nil
+}
+
+var commitRe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// lockMesonWraps pins the revision of every wrap-git wrap to a commit and
+// returns the versions of all wraps: the wrapdb_version (or source hash) of
+// wrap-file wraps and the commit of wrap-git wraps
+func lockMesonWraps() (map[string]string, error) {
+	files, err := filepath.Glob(filepath.Join("subprojects", "*.wrap"))
+	if err != nil {
+		return nil, err
+	}
+	wraps := make(map[string]string)
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".wrap")
+		wrap, err := readWrap(file)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case wrap.section == "wrap-git":
+			revision := wrap.values["revision"]
+			if !commitRe.MatchString(revision) {
+				commit, err := resolveGitRevision(wrap.values["url"], revision)
+				if err != nil {
+					return nil, fmt.Errorf("failed to pin %s: %w", file, err)
+				}
+				if err := setWrapValue(file, "revision", commit); err != nil {
+					return nil, err
+				}
+				revision = commit
+			}
+			wraps[name] = revision
+		case wrap.values["wrapdb_version"] != "":
+			wraps[name] = wrap.values["wrapdb_version"]
+		case wrap.values["source_hash"] != "":
+			wraps[name] = "sha256:" + wrap.values["source_hash"]
+		}
+	}
+	return wraps, nil
+}
+
+// wrapFile is the first section of a Meson wrap file
+type wrapFile struct {
+	section string // wrap-file, wrap-git, ...
+	values  map[string]string
+}
+
+func readWrap(path string) (*wrapFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wrap := &wrapFile{values: make(map[string]string)}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if wrap.section != "" {
+				// Only the first section ([wrap-*]) describes the source
+				break
+			}
+			wrap.section = strings.Trim(line, "[]")
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			wrap.values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return wrap, scanner.Err()
+}
+
+// setWrapValue replaces the value of key in the wrap file at path
+func setWrapValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	re := regexp.MustCompile(`(?m)^(\s*` + regexp.QuoteMeta(key) + `\s*=\s*).*$`)
+	data = re.ReplaceAll(data, []byte("${1}"+value))
+	return os.WriteFile(path, data, 0644)
+}
+
+// resolveGitRevision returns the commit a branch or tag of a git remote
+// points to
+func resolveGitRevision(url, revision string) (string, error) {
+	// Meson's "head" is the default branch
+	if revision == "" || revision == "head" {
+		revision = "HEAD"
+	}
+	if err := offline.Required("resolving " + revision + " of " + url); err != nil {
+		return "", err
+	}
+	out, err := execCommand("git", "ls-remote", url, revision, revision+"^{}").Output()
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote %s failed: %w", url, err)
+	}
+	commit := ""
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		// A peeled annotated tag (^{}) names the commit rather than the tag
+		if commit == "" || strings.HasSuffix(fields[1], "^{}") {
+			commit = fields[0]
+		}
+	}
+	if commit == "" {
+		return "", exitcode.Errorf(exitcode.Config, "%s has no branch or tag %q", url, revision)
+	}
+	return commit, nil
+}
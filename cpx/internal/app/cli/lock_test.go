@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitCommitFiles writes files into the git repository dir (initializing it
+// if needed), commits them and returns the commit
+func gitCommitFiles(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+		git("init", "--quiet", "--initial-branch=main")
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "update")
+	return git("rev-parse", "HEAD")
+}
+
+func TestLockVcpkg(t *testing.T) {
+	vcpkgRoot := t.TempDir()
+	first := gitCommitFiles(t, vcpkgRoot, map[string]string{
+		"versions/baseline.json": `{"default": {"fmt": {"baseline": "10.2.1", "port-version": 0}, "zlib": {"baseline": "1.3.1", "port-version": 1}}}`,
+	})
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": ["fmt", {"name": "zlib"}]}`), 0644))
+
+	lock, err := lockVcpkg(vcpkgRoot, false)
+	require.NoError(t, err)
+	assert.Equal(t, &config.VcpkgLock{Baseline: first, Packages: map[string]string{"fmt": "10.2.1", "zlib": "1.3.1#1"}}, lock)
+
+	var manifest struct {
+		Baseline  string           `json:"builtin-baseline"`
+		Overrides []map[string]any `json:"overrides"`
+	}
+	data, err := os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, first, manifest.Baseline)
+	assert.Equal(t, []map[string]any{
+		{"name": "fmt", "version": "10.2.1"},
+		{"name": "zlib", "version": "1.3.1", "port-version": float64(1)},
+	}, manifest.Overrides)
+
+	// A newer vcpkg checkout only moves the pins with --update
+	second := gitCommitFiles(t, vcpkgRoot, map[string]string{
+		"versions/baseline.json": `{"default": {"fmt": {"baseline": "11.0.2", "port-version": 0}, "zlib": {"baseline": "1.3.1", "port-version": 1}}}`,
+	})
+	lock, err = lockVcpkg(vcpkgRoot, false)
+	require.NoError(t, err)
+	assert.Equal(t, first, lock.Baseline)
+	assert.Equal(t, "10.2.1", lock.Packages["fmt"])
+
+	lock, err = lockVcpkg(vcpkgRoot, true)
+	require.NoError(t, err)
+	assert.Equal(t, second, lock.Baseline)
+	assert.Equal(t, "11.0.2", lock.Packages["fmt"])
+
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": ["nope"]}`), 0644))
+	_, err = lockVcpkg(vcpkgRoot, false)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+}
+
+func TestVerifyLockfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app"}`), 0644))
+	// Nothing to verify without a lockfile
+	require.NoError(t, verifyLockfile(ProjectTypeVcpkg))
+
+	files, err := dependencyFileHashes(ProjectTypeVcpkg)
+	require.NoError(t, err)
+	require.NoError(t, config.SaveLock(&config.Lock{Files: files}, config.LockFile))
+	require.NoError(t, verifyLockfile(ProjectTypeVcpkg))
+
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": ["fmt"]}`), 0644))
+	err = verifyLockfile(ProjectTypeVcpkg)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+	assert.ErrorContains(t, err, "vcpkg.json (changed)")
+}
+
+func TestLockMesonWraps(t *testing.T) {
+	upstream := t.TempDir()
+	commit := gitCommitFiles(t, upstream, map[string]string{"meson.build": "project('dep')\n"})
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.MkdirAll("subprojects", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("subprojects", "dep.wrap"), []byte("[wrap-git]\nurl = "+upstream+"\nrevision = main\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join("subprojects", "fmt.wrap"), []byte("[wrap-file]\nsource_hash = abc\nwrapdb_version = 10.2.1-1\n\n[provide]\nfmt = fmt_dep\n"), 0644))
+
+	wraps, err := lockMesonWraps()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"dep": commit, "fmt": "10.2.1-1"}, wraps)
+	data, err := os.ReadFile(filepath.Join("subprojects", "dep.wrap"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "revision = "+commit+"\n")
+}
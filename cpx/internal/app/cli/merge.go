@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/logging"
+)
+
+// TemplateManifestPath records the hash of every file cpx generated, relative
+// to the project root. It is the common base for --force-merge: a file whose
+// content still matches its recorded hash is an unmodified template output.
+const TemplateManifestPath = ".cpx/manifest.json"
+
+// templateManifest is the on-disk form of TemplateManifestPath
+type templateManifest struct {
+	Files map[string]string `json:"files"`
+}
+
+// projectWriter writes generated project files. In merge mode it performs a
+// three-way check against the existing file and the manifest of the previous
+// generation, and only writes files that are missing or unmodified.
+type projectWriter struct {
+	root  string
+	merge bool
+	base  map[string]string // hashes from the previous generation
+	next  map[string]string // hashes of this generation
+
+	Written   []string // new files
+	Updated   []string // unmodified template outputs that were regenerated
+	Unchanged []string // already identical to the template output
+	Conflicts []string // modified or foreign files left untouched
+}
+
+func newProjectWriter(root string, merge bool) *projectWriter {
+	w := &projectWriter{
+		root:  root,
+		merge: merge,
+		base:  make(map[string]string),
+		next:  make(map[string]string),
+	}
+	if merge {
+		if data, err := os.ReadFile(filepath.Join(root, TemplateManifestPath)); err == nil {
+			var m templateManifest
+			if json.Unmarshal(data, &m) == nil && m.Files != nil {
+				w.base = m.Files
+			}
+		}
+	}
+	return w
+}
+
+// write generates the file at rel (relative to the project root)
+func (w *projectWriter) write(rel, content string) error {
+	path := filepath.Join(w.root, rel)
+	hash := contentHash([]byte(content))
+
+	if w.merge {
+		existing, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			w.Written = append(w.Written, rel)
+		case err != nil:
+			return err
+		case bytes.Equal(existing, []byte(content)):
+			w.Unchanged = append(w.Unchanged, rel)
+			w.next[rel] = hash
+			return nil
+		case w.base[rel] == contentHash(existing):
+			w.Updated = append(w.Updated, rel)
+		default:
+			w.Conflicts = append(w.Conflicts, rel)
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return err
+	}
+	w.next[rel] = hash
+	return nil
+}
+
+// exists reports whether rel already exists in the project
+func (w *projectWriter) exists(rel string) bool {
+	_, err := os.Stat(filepath.Join(w.root, rel))
+	return err == nil
+}
+
+// saveManifest records the hashes of the generated files for later merges
+func (w *projectWriter) saveManifest() error {
+	data, err := json.MarshalIndent(templateManifest{Files: w.next}, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(w.root, TemplateManifestPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// printMergeSummary reports what a --force-merge generation did
+func (w *projectWriter) printMergeSummary() {
+	fmt.Printf("\n%sMerge summary:%s %d written, %d updated, %d unchanged, %d conflicts\n",
+		Bold, Reset, len(w.Written), len(w.Updated), len(w.Unchanged), len(w.Conflicts))
+	if len(w.Conflicts) == 0 {
+		return
+	}
+	conflicts := append([]string(nil), w.Conflicts...)
+	sort.Strings(conflicts)
+	logging.Warn("kept existing files that differ from the template:\n  ! %s\n  Review them by hand, or delete a file and re-run to regenerate it", strings.Join(conflicts, "\n  ! "))
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// isEmptyDir reports whether path is a directory with no entries
+func isEmptyDir(path string) bool {
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) == 0
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectWriterMerge(t *testing.T) {
+	root := t.TempDir()
+
+	// Previous generation
+	w := newProjectWriter(root, false)
+	require.NoError(t, w.write("unmodified.txt", "v1\n"))
+	require.NoError(t, w.write("modified.txt", "v1\n"))
+	require.NoError(t, w.write("same.txt", "v2\n"))
+	require.NoError(t, w.saveManifest())
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "modified.txt"), []byte("user edit\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "foreign.txt"), []byte("mine\n"), 0644))
+
+	// Regenerate with the template at v2
+	w = newProjectWriter(root, true)
+	for _, rel := range []string{"unmodified.txt", "modified.txt", "same.txt", "foreign.txt", "new/file.txt"} {
+		require.NoError(t, w.write(rel, "v2\n"))
+	}
+	require.NoError(t, w.saveManifest())
+
+	assert.Equal(t, []string{"new/file.txt"}, w.Written)
+	assert.Equal(t, []string{"unmodified.txt"}, w.Updated)
+	assert.Equal(t, []string{"same.txt"}, w.Unchanged)
+	assert.Equal(t, []string{"modified.txt", "foreign.txt"}, w.Conflicts)
+
+	content, err := os.ReadFile(filepath.Join(root, "unmodified.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2\n", string(content))
+	content, err = os.ReadFile(filepath.Join(root, "modified.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "user edit\n", string(content))
+
+	// Conflicting files are not recorded as template outputs
+	w = newProjectWriter(root, true)
+	assert.NotContains(t, w.base, "modified.txt")
+	assert.Contains(t, w.base, "new/file.txt")
+}
+
+func TestCreateProjectForceMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	config := tui.ProjectConfig{
+		Name:           "merge-proj",
+		PackageManager: "bazel",
+		CppStandard:    17,
+		TestFramework:  "googletest",
+		Benchmark:      "none",
+		VCS:            "none",
+	}
+
+	require.NoError(t, os.MkdirAll("merge-proj", 0755))
+	require.NoError(t, os.WriteFile("merge-proj/README.md", []byte("# Existing\n"), 0644))
+
+	err = createProjectFromTUI(config, nil, false)
+	assert.ErrorContains(t, err, "--force-merge")
+
+	require.NoError(t, createProjectFromTUI(config, nil, true))
+	assert.FileExists(t, "merge-proj/MODULE.bazel")
+	assert.FileExists(t, "merge-proj/"+TemplateManifestPath)
+	readme, err := os.ReadFile("merge-proj/README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# Existing\n", string(readme))
+}
@@ -4,9 +4,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/build"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,6 +37,66 @@ func TestHelperProcess(t *testing.T) {
 
 	cmd, args := args[0], args[1:]
 	switch cmd {
+	case "bazel":
+		if len(args) > 0 && args[0] == "query" {
+			// Simulate rdeps query results
+			fmt.Println("//tests:unit_test")
+			fmt.Println("//tests:integration_test")
+			os.Exit(0)
+		}
+		if len(args) > 1 && args[0] == "info" && args[1] == "bazel-testlogs" {
+			fmt.Println(os.Getenv("CPX_TEST_TESTLOGS"))
+			os.Exit(0)
+		}
+		if len(args) > 0 && args[0] == "test" && os.Getenv("CPX_TEST_TESTLOGS") != "" {
+			// Simulate a run writing the result of one test target
+			path := filepath.Join(os.Getenv("CPX_TEST_TESTLOGS"), "tests", "unit_test", "test.xml")
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, []byte(`<testsuite name="//tests:unit_test"><testcase name="A.pass"/></testsuite>`), 0644)
+			os.Exit(0)
+		}
+		if len(args) > 1 && args[0] == "info" && args[1] == "execution_root" {
+			fmt.Println("/exec")
+			os.Exit(0)
+		}
+		if len(args) > 0 && args[0] == "aquery" {
+			// Simulate a single compile action
+			fmt.Println(`{"actions": [{"mnemonic": "CppCompile", "arguments": ["gcc", "-iquote", "bazel-out/bin", "-c", "src/main.cpp"]}]}`)
+			os.Exit(0)
+		}
+	case "cmake":
+		// Simulate a configure that fails on message(FATAL_ERROR)
+		if data, err := os.ReadFile("CMakeLists.txt"); err == nil && strings.Contains(string(data), "FATAL_ERROR") {
+			fmt.Fprintln(os.Stderr, "CMake Error at CMakeLists.txt:3 (message)")
+			os.Exit(1)
+		}
+	case "cpx":
+		if len(args) > 0 && args[0] == "test" {
+			// Simulate a failing test run
+			fmt.Fprintln(os.Stderr, "1 test failed")
+			os.Exit(6)
+		}
+	case "docker":
+		if len(args) > 1 && args[0] == "buildx" && args[1] == "inspect" {
+			// Simulate a missing builder
+			fmt.Fprintf(os.Stderr, "ERROR: no builder %q found\n", args[2])
+			os.Exit(1)
+		}
+	case "file":
+		// Simulate a static "app" and a dynamic "tool"
+		if strings.HasSuffix(args[len(args)-1], "tool") {
+			fmt.Println("ELF 64-bit LSB pie executable, x86-64, dynamically linked, interpreter /lib64/ld-linux-x86-64.so.2")
+		} else {
+			fmt.Println("ELF 64-bit LSB executable, x86-64, statically linked, stripped")
+		}
+		os.Exit(0)
+	case "ldd":
+		if strings.HasSuffix(args[len(args)-1], "tool") {
+			fmt.Println("\tlibstdc++.so.6 => /lib/x86_64-linux-gnu/libstdc++.so.6 (0x00007f1)")
+			os.Exit(0)
+		}
+		fmt.Println("\tnot a dynamic executable")
+		os.Exit(1)
 	case "meson":
 		if len(args) > 0 && args[0] == "wrap" && args[1] == "install" {
 			pkg := args[2]
@@ -180,7 +243,7 @@ func TestCreateProjectFromTUI_Meson(t *testing.T) {
 		VCS:            "git",
 	}
 
-	err = createProjectFromTUI(config, nil)
+	err = createProjectFromTUI(config, nil, false)
 	assert.NoError(t, err)
 
 	// Verify files created
@@ -227,7 +290,7 @@ func TestRunMesonBuild_Args(t *testing.T) {
 
 	// Test Debug Build
 	capturedArgs = nil
-	err = runMesonBuild(false, "", false, false, "", "") // release=false
+	err = runMesonBuild(false, "", false, false, "", "", false, build.Compiler{}, build.CrossToolchain{}) // release=false
 	assert.NoError(t, err)
 
 	require.Len(t, capturedArgs, 3) // setup, compile, copy
@@ -235,6 +298,7 @@ func TestRunMesonBuild_Args(t *testing.T) {
 	assert.Equal(t, "meson", capturedArgs[0][0])
 	assert.Equal(t, "setup", capturedArgs[0][1])
 	assert.Contains(t, capturedArgs[0], "--buildtype=debug")
+	assert.Contains(t, capturedArgs[0], "-Dunity=off")
 	// meson compile
 	assert.Equal(t, "meson", capturedArgs[1][0])
 	assert.Equal(t, "compile", capturedArgs[1][1])
@@ -243,7 +307,7 @@ func TestRunMesonBuild_Args(t *testing.T) {
 	// Note: builddir already exists, so setup will be SKIPPED unless we clean or use a fresh dir.
 	// Let's use clean=true to force setup? No, clean=true deletes builddir.
 	capturedArgs = nil
-	err = runMesonBuild(true, "", true, false, "", "") // release=true, clean=true
+	err = runMesonBuild(true, "", true, false, "", "", false, build.Compiler{}, build.CrossToolchain{}) // release=true, clean=true
 	assert.NoError(t, err)
 
 	// With clean=true:
@@ -252,4 +316,88 @@ func TestRunMesonBuild_Args(t *testing.T) {
 	require.Len(t, capturedArgs, 3)
 	assert.Equal(t, "setup", capturedArgs[0][1])
 	assert.Contains(t, capturedArgs[0], "--buildtype=release")
+
+	// --unity reconfigures the existing build directory
+	require.NoError(t, os.MkdirAll("builddir", 0755))
+	capturedArgs = nil
+	err = runMesonBuild(true, "", false, false, "", "", true, build.Compiler{}, build.CrossToolchain{})
+	assert.NoError(t, err)
+	require.NotEmpty(t, capturedArgs)
+	assert.Equal(t, "configure", capturedArgs[0][1])
+	assert.Contains(t, capturedArgs[0], "-Dunity=on")
+
+	// Each compiler gets its own build directory, set up with a native file
+	capturedArgs = nil
+	err = runMesonBuild(false, "", false, false, "", "", false, build.Compiler{Name: "gcc-13", CC: "/usr/bin/gcc-13", CXX: "/usr/bin/g++-13"}, build.CrossToolchain{})
+	assert.NoError(t, err)
+	require.NotEmpty(t, capturedArgs)
+	nativeFile := filepath.Join("builddir-gcc-13", mesonNativeFile)
+	assert.Equal(t, []string{"setup", "builddir-gcc-13"}, capturedArgs[0][1:3])
+	assert.Contains(t, capturedArgs[0], nativeFile)
+	data, err := os.ReadFile(nativeFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "cpp = '/usr/bin/g++-13'")
+
+	// Cross builds set up their own build directory with the cross file
+	capturedArgs = nil
+	cross := build.CrossToolchain{Name: "aarch64-linux-gnu", File: "/src/cross/aarch64-linux-gnu.ini"}
+	err = runMesonBuild(false, "", false, false, "", "", false, build.Compiler{}, cross)
+	assert.NoError(t, err)
+	require.NotEmpty(t, capturedArgs)
+	assert.Equal(t, []string{"setup", "builddir-aarch64-linux-gnu"}, capturedArgs[0][1:3])
+	assert.Contains(t, capturedArgs[0], "--cross-file")
+	assert.Contains(t, capturedArgs[0], cross.File)
+}
+
+func TestMesonUnityOption(t *testing.T) {
+	buildDir := t.TempDir()
+	_, ok := mesonUnityOption(buildDir)
+	assert.False(t, ok)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(buildDir, "meson-info"), 0755))
+	options := `[{"name": "optimization", "value": "0"}, {"name": "unity", "value": "on"}, {"name": "werror", "value": false}]`
+	require.NoError(t, os.WriteFile(filepath.Join(buildDir, "meson-info", "intro-buildoptions.json"), []byte(options), 0644))
+	value, ok := mesonUnityOption(buildDir)
+	assert.True(t, ok)
+	assert.Equal(t, "on", value)
+}
+
+func TestEnsureMesonCompileDatabase(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	execLookPath = func(file string) (string, error) {
+		return "/usr/bin/" + file, nil
+	}
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, ensureMesonCompileDatabase())
+	require.Len(t, capturedArgs, 1)
+	assert.Equal(t, []string{"meson", "setup", "builddir"}, capturedArgs[0])
+
+	// Already configured: only links the database
+	capturedArgs = nil
+	require.NoError(t, os.MkdirAll("builddir", 0755))
+	require.NoError(t, os.WriteFile("builddir/compile_commands.json", []byte("[]"), 0644))
+	require.NoError(t, ensureMesonCompileDatabase())
+	assert.Empty(t, capturedArgs)
+	assert.FileExists(t, "compile_commands.json")
 }
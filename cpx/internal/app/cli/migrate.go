@@ -0,0 +1,772 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/spf13/cobra"
+)
+
+// MigrateCmd creates the migrate command
+func MigrateCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Adopt an existing CMake or Conan project",
+		Long: `Adopt an existing CMake or Conan project in the current directory.
+
+cpx migrate reads CMakeLists.txt and the directories it adds: the project,
+its targets and sources, and its find_package calls. It then writes the files
+cpx works with: cpx.yaml, vcpkg.json with the vcpkg ports of the packages
+found, CMakePresets.json using the vcpkg toolchain, and cpx.ci.
+
+With --from conan the dependencies come from the requirements of
+conanfile.txt or conanfile.py instead, mapped to the vcpkg ports of the
+same libraries. Build tools (tool_requires) are left out; vcpkg.json doesn't
+pin the versions Conan did, which cpx lock can do.
+
+Files that exist already are kept, and CMakeLists.txt is never changed.
+Dependencies cpx can't map to a port are listed, to add by hand with
+cpx add.`,
+		Example: `  cpx migrate --dry-run
+  cpx migrate
+  cpx migrate --from conan`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			from, _ := cmd.Flags().GetString("from")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			return runMigrate(client, from, dryRun)
+		},
+	}
+	cmd.Flags().String("from", "cmake", "Where the dependencies come from: cmake (find_package calls) or conan (conanfile.txt/py)")
+	cmd.Flags().Bool("dry-run", false, "Only show what was found and the dependencies vcpkg.json would get")
+
+	return cmd
+}
+
+func runMigrate(client *vcpkg.Client, from string, dryRun bool) error {
+	var conan *conanFile
+	switch from {
+	case "cmake":
+		if _, err := os.Stat("CMakeLists.txt"); err != nil {
+			return exitcode.Errorf(exitcode.Config, "cpx migrate needs a CMake project (no CMakeLists.txt found)\n  hint: run it in the directory of the top-level CMakeLists.txt")
+		}
+	case "conan":
+		var err error
+		if conan, err = readConanfile("."); err != nil {
+			return err
+		}
+	default:
+		return exitcode.Errorf(exitcode.Usage, "unknown project kind %q\n  hint: use --from cmake or --from conan", from)
+	}
+
+	project := &cmakeProject{}
+	if _, err := os.Stat("CMakeLists.txt"); err == nil {
+		if project, err = analyzeCMakeProject("."); err != nil {
+			return err
+		}
+	} else {
+		logging.Notice("No CMakeLists.txt found; cpx builds with CMake, so the project needs one")
+	}
+
+	// The port index maps packages to ports, and checks the ports exist
+	var index *vcpkg.Index
+	if client != nil {
+		if vcpkgPath, err := client.GetPath(); err == nil {
+			if index, err = loadPortIndex(filepath.Dir(vcpkgPath), false); err != nil {
+				logging.Warn("failed to index the vcpkg ports: %v", err)
+			}
+		}
+	}
+
+	var deps, unresolved []string
+	if conan != nil {
+		if project.Name == "" {
+			project.Name = conan.Name
+		}
+		if project.Version == "" {
+			project.Version = conan.Version
+		}
+		var refs []conanReference
+		deps, refs = conanDependencies(conan.Requires, index)
+		for _, ref := range refs {
+			unresolved = append(unresolved, fmt.Sprintf("%s (%s)", ref, filepath.Base(conan.Path)))
+		}
+	} else {
+		var packages []string
+		deps, packages = migrationDependencies(project.Packages, index)
+		for _, pkg := range packages {
+			unresolved = append(unresolved, fmt.Sprintf("find_package(%s)", pkg))
+		}
+	}
+
+	printCMakeProject(project, deps, unresolved)
+	if dryRun {
+		return nil
+	}
+
+	w := newProjectWriter(".", true)
+	manifest, err := migrationManifest(project, deps)
+	if err != nil {
+		return err
+	}
+	files := []struct{ rel, content string }{
+		{"cpx.yaml", templates.GenerateCpxYaml(false)},
+		{"vcpkg.json", manifest},
+		{"CMakePresets.json", templates.GenerateCMakePresets()},
+		{"cpx.ci", templates.GenerateCpxCI()},
+	}
+	for _, f := range files {
+		if err := w.write(f.rel, f.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.rel, err)
+		}
+	}
+	if err := w.saveManifest(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", TemplateManifestPath, err)
+	}
+	w.printMergeSummary()
+
+	if slices.Contains(w.Conflicts, "CMakePresets.json") {
+		if data, err := os.ReadFile("CMakePresets.json"); err == nil && !strings.Contains(string(data), "vcpkg.cmake") {
+			logging.Notice("CMakePresets.json doesn't use the vcpkg toolchain")
+			fmt.Printf("  hint: set CMAKE_TOOLCHAIN_FILE to $env{VCPKG_ROOT}/scripts/buildsystems/vcpkg.cmake in its configure preset\n")
+		}
+	}
+	if slices.Contains(w.Conflicts, "vcpkg.json") && len(deps) > 0 {
+		fmt.Printf("  hint: add the dependencies found to the existing vcpkg.json with cpx add %s\n", strings.Join(deps, " "))
+	}
+
+	logging.Success("\n%s Project adopted\n", IconSuccess)
+	fmt.Printf("  cpx build\n\n")
+	return nil
+}
+
+// cmakeCommand is a command call of a CMake file
+type cmakeCommand struct {
+	name string // lowercase
+	args []string
+}
+
+// parseCMakeCommands returns the command calls of a CMake file, with their
+// arguments unquoted. Comments are skipped; variables are left unexpanded.
+func parseCMakeCommands(src string) []cmakeCommand {
+	var commands []cmakeCommand
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '#':
+			// Bracket comments #[[ ... ]] span lines
+			if strings.HasPrefix(src[i:], "#[[") {
+				if end := strings.Index(src[i:], "]]"); end >= 0 {
+					i += end + 2
+					continue
+				}
+			}
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			name := src[start:i]
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+				i++
+			}
+			if i < len(src) && src[i] == '(' {
+				var args []string
+				args, i = parseCMakeArgs(src, i+1)
+				commands = append(commands, cmakeCommand{name: strings.ToLower(name), args: args})
+			}
+		default:
+			i++
+		}
+	}
+	return commands
+}
+
+// parseCMakeArgs splits the arguments of a command call starting at i, just
+// after its opening parenthesis, and returns the offset after the call
+func parseCMakeArgs(src string, i int) ([]string, int) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	flush := func() {
+		if inArg {
+			args = append(args, arg.String())
+			arg.Reset()
+			inArg = false
+		}
+	}
+	depth := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == '"':
+			// Quoted argument, with \" escapes
+			i++
+			for i < len(src) && src[i] != '"' {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				arg.WriteByte(src[i])
+				i++
+			}
+			inArg = true
+		case c == '#':
+			flush()
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			flush()
+		case c == ')' && depth == 0:
+			flush()
+			return args, i + 1
+		default:
+			if c == '(' {
+				depth++
+			} else if c == ')' {
+				depth--
+			}
+			arg.WriteByte(c)
+			inArg = true
+		}
+		i++
+	}
+	flush()
+	return args, i
+}
+
+// cmakeTarget is a target of an existing CMake project
+type cmakeTarget struct {
+	Name string
+	// Kind is "executable" or the type of a library ("static", "shared",
+	// "interface", ...; "library" if the type is left to BUILD_SHARED_LIBS)
+	Kind    string
+	Sources []string
+}
+
+// cmakePackage is a find_package call of an existing CMake project
+type cmakePackage struct {
+	Name       string
+	Components []string
+}
+
+// cmakeProject is what cpx migrate finds in the CMake files of a project
+type cmakeProject struct {
+	Name        string
+	Version     string
+	CppStandard int
+	Targets     []cmakeTarget
+	Packages    []cmakePackage
+}
+
+var cxxStdFeatureRe = regexp.MustCompile(`^cxx_std_(\d+)$`)
+
+// findPackageKeywords end the components of a find_package call
+var findPackageKeywords = map[string]bool{
+	"EXACT": true, "QUIET": true, "MODULE": true, "CONFIG": true, "NO_MODULE": true, "REQUIRED": true,
+	"COMPONENTS": true, "OPTIONAL_COMPONENTS": true, "GLOBAL": true, "NO_POLICY_SCOPE": true,
+	"BYPASS_PROVIDER": true, "NAMES": true, "CONFIGS": true, "HINTS": true, "PATHS": true,
+	"REGISTRY_VIEW": true, "PATH_SUFFIXES": true,
+}
+
+// analyzeCMakeProject reads the CMakeLists.txt in root, following the
+// directories it adds with add_subdirectory and the files it includes
+func analyzeCMakeProject(root string) (*cmakeProject, error) {
+	project := &cmakeProject{}
+	targets := make(map[string]int)
+	packages := make(map[string]int)
+	visited := make(map[string]bool)
+
+	var read func(path string, top bool) error
+	read = func(path string, top bool) error {
+		if visited[path] {
+			return nil
+		}
+		visited[path] = true
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if top {
+				return fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			// Generated or optional files
+			return nil
+		}
+		dir := filepath.Dir(path)
+
+		for _, cmd := range parseCMakeCommands(string(data)) {
+			args := cmd.args
+			switch cmd.name {
+			case "project":
+				if project.Name == "" && len(args) > 0 {
+					project.Name = args[0]
+					if n := slices.Index(args, "VERSION"); n >= 0 && n+1 < len(args) {
+						project.Version = args[n+1]
+					}
+				}
+			case "set":
+				if len(args) > 1 && args[0] == "CMAKE_CXX_STANDARD" && project.CppStandard == 0 {
+					project.CppStandard, _ = strconv.Atoi(args[1])
+				}
+			case "target_compile_features":
+				for _, arg := range args {
+					if m := cxxStdFeatureRe.FindStringSubmatch(arg); m != nil && project.CppStandard == 0 {
+						project.CppStandard, _ = strconv.Atoi(m[1])
+					}
+				}
+			case "add_executable", "add_library":
+				if len(args) == 0 || slices.Contains(args, "IMPORTED") || slices.Contains(args, "ALIAS") {
+					continue
+				}
+				target := cmakeTarget{Name: args[0], Kind: "executable"}
+				if cmd.name == "add_library" {
+					target.Kind = "library"
+				}
+				for _, arg := range args[1:] {
+					switch arg {
+					case "STATIC", "SHARED", "MODULE", "OBJECT", "INTERFACE":
+						target.Kind = strings.ToLower(arg)
+					case "WIN32", "MACOSX_BUNDLE", "EXCLUDE_FROM_ALL":
+					default:
+						target.Sources = append(target.Sources, arg)
+					}
+				}
+				if _, ok := targets[target.Name]; !ok {
+					targets[target.Name] = len(project.Targets)
+					project.Targets = append(project.Targets, target)
+				}
+			case "target_sources":
+				if len(args) == 0 {
+					continue
+				}
+				n, ok := targets[args[0]]
+				if !ok {
+					continue
+				}
+				for _, arg := range args[1:] {
+					if arg != "PRIVATE" && arg != "PUBLIC" && arg != "INTERFACE" {
+						project.Targets[n].Sources = append(project.Targets[n].Sources, arg)
+					}
+				}
+			case "find_package":
+				if len(args) == 0 || strings.Contains(args[0], "${") {
+					continue
+				}
+				pkg := cmakePackage{Name: args[0]}
+				collecting := false
+				for _, arg := range args[1:] {
+					if findPackageKeywords[arg] {
+						collecting = arg == "REQUIRED" || arg == "COMPONENTS" || arg == "OPTIONAL_COMPONENTS"
+						continue
+					}
+					if collecting {
+						pkg.Components = append(pkg.Components, arg)
+					}
+				}
+				if n, ok := packages[pkg.Name]; ok {
+					for _, c := range pkg.Components {
+						if !slices.Contains(project.Packages[n].Components, c) {
+							project.Packages[n].Components = append(project.Packages[n].Components, c)
+						}
+					}
+					continue
+				}
+				packages[pkg.Name] = len(project.Packages)
+				project.Packages = append(project.Packages, pkg)
+			case "add_subdirectory":
+				if len(args) > 0 && !strings.Contains(args[0], "${") {
+					if err := read(filepath.Join(dir, args[0], "CMakeLists.txt"), false); err != nil {
+						return err
+					}
+				}
+			case "include":
+				// Module names such as CTest are CMake's own
+				if len(args) > 0 && strings.HasSuffix(args[0], ".cmake") && !strings.Contains(args[0], "${") {
+					if err := read(filepath.Join(dir, args[0]), false); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := read(filepath.Join(root, "CMakeLists.txt"), true); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// cmakeSystemPackages are find_package names of CMake modules for tools and
+// system libraries, which vcpkg doesn't provide
+var cmakeSystemPackages = map[string]bool{
+	"Threads": true, "PkgConfig": true, "Git": true, "Doxygen": true, "Perl": true,
+	"Python": true, "Python2": true, "Python3": true, "PythonInterp": true, "PythonLibs": true,
+	"OpenMP": true, "CUDA": true, "CUDAToolkit": true, "OpenGL": true,
+}
+
+// cmakePackagePorts maps the CMake packages named differently from their
+// vcpkg port
+var cmakePackagePorts = map[string]string{
+	"GTest":         "gtest",
+	"Catch2":        "catch2",
+	"nlohmann_json": "nlohmann-json",
+	"ZLIB":          "zlib",
+	"OpenSSL":       "openssl",
+	"CURL":          "curl",
+	"PNG":           "libpng",
+	"JPEG":          "libjpeg-turbo",
+	"SQLite3":       "sqlite3",
+	"Protobuf":      "protobuf",
+	"gRPC":          "grpc",
+	"Eigen3":        "eigen3",
+	"CLI11":         "cli11",
+	"TBB":           "tbb",
+	"LibXml2":       "libxml2",
+	"BZip2":         "bzip2",
+	"LibLZMA":       "liblzma",
+	"Freetype":      "freetype",
+	"SDL2":          "sdl2",
+	"GLEW":          "glew",
+	"Qt6":           "qtbase",
+}
+
+// migrationDependencies returns the vcpkg ports of the find_package calls
+// of a project, and the calls it can't map. Boost components map to their
+// own ports. With an index, the names of ports and the packages their usage
+// notes find are matched too, and ports missing from it are unresolved.
+func migrationDependencies(packages []cmakePackage, index *vcpkg.Index) ([]string, []string) {
+	// dependency key of a package -> port, from the index
+	indexed := make(map[string]string)
+	if index != nil {
+		for _, port := range index.Ports {
+			indexed[dependencyKey(port.Name)] = port.Name
+		}
+		for _, port := range index.Ports {
+			for _, m := range findPackageRe.FindAllStringSubmatch(port.Usage, -1) {
+				if key := dependencyKey(m[1]); indexed[key] == "" {
+					indexed[key] = port.Name
+				}
+			}
+		}
+	}
+	known := func(port string) bool {
+		if index == nil {
+			return true
+		}
+		_, ok := index.Port(port)
+		return ok
+	}
+
+	var deps, unresolved []string
+	add := func(port string) {
+		if !slices.Contains(deps, port) {
+			deps = append(deps, port)
+		}
+	}
+	for _, pkg := range packages {
+		if cmakeSystemPackages[pkg.Name] {
+			continue
+		}
+		if pkg.Name == "Boost" {
+			if len(pkg.Components) == 0 {
+				add("boost-headers")
+			}
+			for _, c := range pkg.Components {
+				add("boost-" + strings.ReplaceAll(strings.ToLower(c), "_", "-"))
+			}
+			continue
+		}
+
+		port := cmakePackagePorts[pkg.Name]
+		if port == "" {
+			port = indexed[dependencyKey(pkg.Name)]
+		}
+		if port == "" && index == nil {
+			port = strings.ToLower(pkg.Name)
+		}
+		if port == "" || !known(port) {
+			unresolved = append(unresolved, pkg.Name)
+			continue
+		}
+		add(port)
+	}
+	return deps, unresolved
+}
+
+// vcpkgNameRe matches the runs of characters vcpkg.json names can't have
+var vcpkgNameRe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// migrationManifest returns the vcpkg.json of a migrated project, named
+// after the project or else its directory
+func migrationManifest(project *cmakeProject, deps []string) (string, error) {
+	name := project.Name
+	if name == "" {
+		if wd, err := os.Getwd(); err == nil {
+			name = filepath.Base(wd)
+		}
+	}
+	name = strings.Trim(vcpkgNameRe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	manifest := struct {
+		Name         string   `json:"name,omitempty"`
+		Version      string   `json:"version,omitempty"`
+		Dependencies []string `json:"dependencies"`
+	}{Name: name, Version: project.Version, Dependencies: deps}
+	if manifest.Dependencies == nil {
+		manifest.Dependencies = []string{}
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode vcpkg.json: %w", err)
+	}
+	return string(data) + "\n", nil
+}
+
+// printCMakeProject prints what cpx migrate found. unresolved describes the
+// dependencies without a port, such as find_package(Acme).
+func printCMakeProject(project *cmakeProject, deps, unresolved []string) {
+	name := project.Name
+	if name == "" {
+		name = "(no project() call)"
+	}
+	fmt.Printf("%s%s%s", Bold, name, Reset)
+	if project.Version != "" {
+		fmt.Printf(" %s", project.Version)
+	}
+	if project.CppStandard > 0 {
+		fmt.Printf(" %s(C++%d)%s", Dim, project.CppStandard, Reset)
+	}
+	fmt.Println()
+
+	if len(project.Targets) > 0 {
+		fmt.Printf("\n%sTargets%s\n", Cyan, Reset)
+		for _, target := range project.Targets {
+			fmt.Printf("  %-24s %s%s, %d source(s)%s\n", target.Name, Dim, target.Kind, len(target.Sources), Reset)
+		}
+	}
+
+	fmt.Printf("\n%sDependencies%s\n", Cyan, Reset)
+	if len(deps) == 0 && len(unresolved) == 0 {
+		fmt.Printf("  %snone found%s\n", Dim, Reset)
+	}
+	for _, dep := range deps {
+		fmt.Printf("  %s\n", dep)
+	}
+	for _, dep := range unresolved {
+		fmt.Printf("  %s? %s: no matching vcpkg port; add it with cpx add <port>%s\n", Yellow, dep, Reset)
+	}
+}
+
+// conanReference is a requirement of a Conan project, such as fmt/10.2.1
+type conanReference struct {
+	Name    string
+	Version string
+}
+
+func (r conanReference) String() string {
+	if r.Version == "" {
+		return r.Name
+	}
+	return r.Name + "/" + r.Version
+}
+
+// conanFile is what cpx migrate --from conan finds in conanfile.txt or
+// conanfile.py
+type conanFile struct {
+	Path     string
+	Name     string // conanfile.py only
+	Version  string
+	Requires []conanReference
+}
+
+var (
+	// conanReferenceRe matches name/version references, with the user,
+	// channel and revision Conan allows after them
+	conanReferenceRe = regexp.MustCompile(`^([a-z0-9_][a-z0-9_.+-]*)/(\[[^\]]*\]|[^@#\s]+)(?:@\S*)?(?:#\S*)?$`)
+	// conanRequiresRe matches the requirement attributes and methods of a
+	// conanfile.py
+	conanRequiresRe = regexp.MustCompile(`\b(test_requires|tool_requires|build_requires|requires)\b`)
+	conanStringRe   = regexp.MustCompile(`"([^"\n]*)"|'([^'\n]*)'`)
+	conanAttrRe     = regexp.MustCompile(`(?m)^\s+(name|version)\s*=\s*["']([^"']+)["']`)
+)
+
+// readConanfile reads the requirements of the conanfile.txt or conanfile.py
+// in root. Build tools are left out: vcpkg.json lists libraries.
+func readConanfile(root string) (*conanFile, error) {
+	conan := &conanFile{Path: filepath.Join(root, "conanfile.txt")}
+	data, err := os.ReadFile(conan.Path)
+	if err == nil {
+		conan.Requires = parseConanfileTxt(string(data))
+		return conan, nil
+	}
+	conan.Path = filepath.Join(root, "conanfile.py")
+	if data, err = os.ReadFile(conan.Path); err != nil {
+		return nil, exitcode.Errorf(exitcode.Config, "cpx migrate --from conan needs a Conan project (no conanfile.txt or conanfile.py found)\n  hint: run it in the directory of the conanfile")
+	}
+	for _, m := range conanAttrRe.FindAllStringSubmatch(string(data), -1) {
+		if m[1] == "name" && conan.Name == "" {
+			conan.Name = m[2]
+		} else if m[1] == "version" && conan.Version == "" {
+			conan.Version = m[2]
+		}
+	}
+	conan.Requires = parseConanfilePy(string(data))
+	return conan, nil
+}
+
+// parseConanfileTxt returns the requirements in the [requires] and
+// [test_requires] sections of a conanfile.txt
+func parseConanfileTxt(src string) []conanReference {
+	var refs []conanReference
+	section := ""
+	for _, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line
+			continue
+		}
+		if section != "[requires]" && section != "[test_requires]" {
+			continue
+		}
+		if m := conanReferenceRe.FindStringSubmatch(line); m != nil {
+			refs = appendConanReference(refs, conanReference{Name: m[1], Version: m[2]})
+		}
+	}
+	return refs
+}
+
+// parseConanfilePy returns the references a conanfile.py requires, in
+// requires and test_requires attributes or self.requires() calls
+func parseConanfilePy(src string) []conanReference {
+	var refs []conanReference
+	for _, loc := range conanRequiresRe.FindAllStringSubmatchIndex(src, -1) {
+		// Build tools aren't libraries for vcpkg.json
+		if kind := src[loc[2]:loc[3]]; kind != "requires" && kind != "test_requires" {
+			continue
+		}
+		// Comments and strings may mention the keywords
+		lineStart := strings.LastIndex(src[:loc[0]], "\n") + 1
+		if strings.ContainsAny(src[lineStart:loc[0]], "#\"'") {
+			continue
+		}
+		for _, m := range conanStringRe.FindAllStringSubmatch(conanArguments(src[loc[1]:]), -1) {
+			value := m[1] + m[2]
+			if ref := conanReferenceRe.FindStringSubmatch(value); ref != nil {
+				refs = appendConanReference(refs, conanReference{Name: ref[1], Version: ref[2]})
+			}
+		}
+	}
+	return refs
+}
+
+// conanArguments returns the value assigned or the arguments passed after
+// a requirement keyword of a conanfile.py: up to the closing bracket of a
+// call, list or tuple, or else to the end of the line
+func conanArguments(src string) string {
+	i := 0
+	for i < len(src) && (src[i] == ' ' || src[i] == '\t' || src[i] == '=') {
+		i++
+	}
+	if i == len(src) || src[i] != '(' && src[i] != '[' {
+		end := strings.IndexByte(src[i:], '\n')
+		if end < 0 {
+			return src[i:]
+		}
+		return src[i : i+end]
+	}
+	depth := 0
+	var quote byte
+	for j := i; j < len(src); j++ {
+		c := src[j]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			// Comments run to the end of the line
+			for j < len(src) && src[j] != '\n' {
+				j++
+			}
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+			if depth == 0 {
+				return src[i : j+1]
+			}
+		}
+	}
+	return src[i:]
+}
+
+// appendConanReference appends ref unless refs already require its package
+func appendConanReference(refs []conanReference, ref conanReference) []conanReference {
+	for _, r := range refs {
+		if r.Name == ref.Name {
+			return refs
+		}
+	}
+	return append(refs, ref)
+}
+
+// conanPackagePorts maps the Conan packages named differently from their
+// vcpkg port. Others map to their name with dashes for underscores.
+var conanPackagePorts = map[string]string{
+	"eigen":          "eigen3",
+	"glfw":           "glfw3",
+	"libalsa":        "alsa",
+	"libcurl":        "curl",
+	"libjpeg":        "libjpeg-turbo",
+	"libmysqlclient": "libmysql",
+	"libtiff":        "tiff",
+	"msgpack-cxx":    "msgpack",
+	"onetbb":         "tbb",
+	"opencv":         "opencv4",
+	"qt":             "qtbase",
+	"sdl":            "sdl2",
+	"xz_utils":       "liblzma",
+}
+
+// conanDependencies returns the vcpkg ports of the requirements of a Conan
+// project, and the requirements it can't map. With an index, ports missing
+// from it are unresolved.
+func conanDependencies(requires []conanReference, index *vcpkg.Index) ([]string, []conanReference) {
+	var deps []string
+	var unresolved []conanReference
+	for _, ref := range requires {
+		port := conanPackagePorts[ref.Name]
+		if port == "" {
+			port = strings.ReplaceAll(ref.Name, "_", "-")
+		}
+		if index != nil {
+			if _, ok := index.Port(port); !ok {
+				unresolved = append(unresolved, ref)
+				continue
+			}
+		}
+		if !slices.Contains(deps, port) {
+			deps = append(deps, port)
+		}
+	}
+	return deps, unresolved
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCMakeCommands(t *testing.T) {
+	src := `cmake_minimum_required(VERSION 3.20)
+# find_package(Commented)
+#[[ find_package(Bracketed)
+]]
+message(STATUS "find_package(InString) \"quoted\"")
+target_compile_options(app PRIVATE $<$<CONFIG:Debug>:-O0> # trailing
+    -Wall)
+IF (WIN32)
+endif()
+`
+	assert.Equal(t, []cmakeCommand{
+		{name: "cmake_minimum_required", args: []string{"VERSION", "3.20"}},
+		{name: "message", args: []string{"STATUS", `find_package(InString) "quoted"`}},
+		{name: "target_compile_options", args: []string{"app", "PRIVATE", "$<$<CONFIG:Debug>:-O0>", "-Wall"}},
+		{name: "if", args: []string{"WIN32"}},
+		{name: "endif"},
+	}, parseCMakeCommands(src))
+}
+
+func TestMigrate(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = runMigrate(nil, "cmake", false)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+
+	writeFiles(t, ".", map[string]string{
+		"CMakeLists.txt": `cmake_minimum_required(VERSION 3.20)
+project(My_App VERSION 1.2.0 LANGUAGES CXX)
+set(CMAKE_CXX_STANDARD 20)
+find_package(fmt CONFIG REQUIRED)
+find_package(Threads REQUIRED)
+find_package(Boost REQUIRED COMPONENTS filesystem program_options)
+include(cmake/deps.cmake)
+include(CTest)
+add_subdirectory(src)
+add_subdirectory(missing)
+`,
+		"cmake/deps.cmake": "find_package(ZLIB REQUIRED)\nfind_package(Acme CONFIG)\n",
+		"src/CMakeLists.txt": `add_library(core STATIC core.cpp util.cpp)
+add_library(My::core ALIAS core)
+add_executable(app main.cpp)
+target_sources(app PRIVATE cli.cpp)
+target_compile_features(core PUBLIC cxx_std_17)
+find_package(fmt REQUIRED)
+find_package(nlohmann_json 3.11 REQUIRED)
+`,
+		"CMakePresets.json": `{"version": 3}`,
+	})
+
+	project, err := analyzeCMakeProject(".")
+	require.NoError(t, err)
+	assert.Equal(t, "My_App", project.Name)
+	assert.Equal(t, "1.2.0", project.Version)
+	assert.Equal(t, 20, project.CppStandard)
+	assert.Equal(t, []cmakeTarget{
+		{Name: "core", Kind: "static", Sources: []string{"core.cpp", "util.cpp"}},
+		{Name: "app", Kind: "executable", Sources: []string{"main.cpp", "cli.cpp"}},
+	}, project.Targets)
+
+	// Without an index packages map to their lowercase names
+	deps, unresolved := migrationDependencies(project.Packages, nil)
+	assert.Equal(t, []string{"fmt", "boost-filesystem", "boost-program-options", "zlib", "acme", "nlohmann-json"}, deps)
+	assert.Empty(t, unresolved)
+
+	// With one, only to ports it has; usage notes name the packages of ports
+	index := &vcpkg.Index{Ports: []vcpkg.Port{
+		{Name: "boost-filesystem"},
+		{Name: "boost-program-options"},
+		{Name: "fmt"},
+		{Name: "nlohmann-json"},
+		{Name: "zlib"},
+	}}
+	deps, unresolved = migrationDependencies(project.Packages, index)
+	assert.Equal(t, []string{"fmt", "boost-filesystem", "boost-program-options", "zlib", "nlohmann-json"}, deps)
+	assert.Equal(t, []string{"Acme"}, unresolved)
+	index.Ports = append(index.Ports, vcpkg.Port{Name: "zz-acme-sdk", Usage: "find_package(Acme CONFIG REQUIRED)"})
+	deps, unresolved = migrationDependencies(project.Packages, index)
+	assert.Contains(t, deps, "zz-acme-sdk")
+	assert.Empty(t, unresolved)
+
+	require.NoError(t, runMigrate(nil, "cmake", false))
+	for _, file := range []string{"cpx.yaml", "cpx.ci", TemplateManifestPath} {
+		assert.FileExists(t, file)
+	}
+	var manifest map[string]any
+	data, err := os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "my-app", manifest["name"])
+	assert.Equal(t, "1.2.0", manifest["version"])
+	assert.Len(t, manifest["dependencies"], 6)
+	// The project's own presets are kept
+	data, err = os.ReadFile("CMakePresets.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"version": 3}`, string(data))
+}
+
+func TestMigrateConan(t *testing.T) {
+	assert.Equal(t, []conanReference{
+		{Name: "fmt", Version: "10.2.1"},
+		{Name: "boost", Version: "1.83.0"},
+		{Name: "zlib", Version: "[>=1.2.11 <2]"},
+		{Name: "gtest", Version: "1.14.0"},
+	}, parseConanfileTxt(`[requires]
+fmt/10.2.1
+# spdlog/1.12.0
+boost/1.83.0@acme/stable#2a3b
+zlib/[>=1.2.11 <2]
+fmt/10.1.0
+
+[tool_requires]
+cmake/3.27.7
+
+[test_requires]
+gtest/1.14.0
+
+[generators]
+CMakeDeps
+`))
+
+	assert.Equal(t, []conanReference{
+		{Name: "nlohmann_json", Version: "3.11.3"},
+		{Name: "libcurl", Version: "8.6.0"},
+		{Name: "openssl", Version: "[>=3 <4]"},
+		{Name: "catch2", Version: "3.5.2"},
+	}, parseConanfilePy(`from conan import ConanFile
+
+class App(ConanFile):
+    name = "app"
+    version = "0.3.0"
+    exports_sources = "src/*", "CMakeLists.txt"
+    requires = ("nlohmann_json/3.11.3",
+                "libcurl/8.6.0")  # "spdlog/1.12.0"
+    tool_requires = "cmake/3.27.7"
+
+    def requirements(self):
+        # self.requires("zlib/1.3")
+        self.requires("openssl/[>=3 <4]", override=True)
+
+    def build_requirements(self):
+        self.tool_requires("ninja/1.11.1")
+        self.test_requires("catch2/3.5.2")
+`))
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	err = runMigrate(nil, "conan", false)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runMigrate(nil, "bazel", false)))
+
+	writeFiles(t, ".", map[string]string{
+		"conanfile.py": `class App(ConanFile):
+    name = "app"
+    version = "0.3.0"
+    requires = "libcurl/8.6.0", "xz_utils/5.4.5", "acme-sdk/1.0"
+`,
+		"CMakeLists.txt": "cmake_minimum_required(VERSION 3.20)\nproject(App LANGUAGES CXX)\nfind_package(CURL REQUIRED)\n",
+	})
+	conan, err := readConanfile(".")
+	require.NoError(t, err)
+	assert.Equal(t, "app", conan.Name)
+	assert.Equal(t, "0.3.0", conan.Version)
+
+	// Differently named ports are mapped; with an index, unknown ones are unresolved
+	deps, unresolved := conanDependencies(conan.Requires, nil)
+	assert.Equal(t, []string{"curl", "liblzma", "acme-sdk"}, deps)
+	assert.Empty(t, unresolved)
+	index := &vcpkg.Index{Ports: []vcpkg.Port{{Name: "curl"}, {Name: "liblzma"}}}
+	deps, unresolved = conanDependencies(conan.Requires, index)
+	assert.Equal(t, []string{"curl", "liblzma"}, deps)
+	assert.Equal(t, []conanReference{{Name: "acme-sdk", Version: "1.0"}}, unresolved)
+
+	require.NoError(t, runMigrate(nil, "conan", false))
+	var manifest map[string]any
+	data, err := os.ReadFile("vcpkg.json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &manifest))
+	// The name of the CMake project wins, the version comes from Conan
+	assert.Equal(t, "app", manifest["name"])
+	assert.Equal(t, "0.3.0", manifest["version"])
+	assert.Equal(t, []any{"curl", "liblzma", "acme-sdk"}, manifest["dependencies"])
+}
@@ -5,12 +5,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 	"github.com/ozacod/cpx/internal/pkg/git"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/naming"
 	"github.com/ozacod/cpx/internal/pkg/templates"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
@@ -19,23 +22,133 @@ import (
 // NewCmd creates the new command with interactive TUI
 func NewCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "new",
+		Use:   "new [project]",
 		Short: "Create a new C++ project (interactive)",
-		Long:  "Create a new C++ project using an interactive TUI. This will guide you through the project configuration.",
-		Example: `  cpx new            # launch the interactive creator
-  cpx new --help    # view options`,
+		Long: `Create a new C++ project using an interactive TUI. This will guide you through the project configuration.
+
+Given a project name (as argument or with --name), cpx new runs without the
+TUI, for scripts and CI: the project is configured from --lib, --std, --test,
+--bench, --pm and --no-git, and the defaults of the wizard for everything else.
+
+--template <repo>:<template> instantiates a template of a repository added with
+"cpx config add-template-repo" instead; the project name is then required.
+Variables the template declares are asked for, unless given with
+--var name=value (or --yes to accept their defaults). "cpx template list"
+shows the available templates.`,
+		Example: `  cpx new                          # launch the interactive creator
+  cpx new --template grpc-service  # scaffold a gRPC server (proto, codegen, sample service)
+  cpx new --template embedded      # bare-metal firmware (arm-none-eabi toolchain, linker script)
+  cpx new --name mylib --lib --std 20 --test catch2 --pm none --no-git  # no TUI
+  cpx new --template acme:service my-svc  # instantiate a template of the acme repository
+  cpx new --template acme:service my-svc --var port=8080  # answer a template prompt up front
+  cpx new --force-merge            # generate into an existing directory, keeping modified files
+  cpx new --help                   # view options`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runNew(cmd, args, client)
 		},
-		Args: cobra.NoArgs,
-	}
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.Flags().Bool("force-merge", false, "Generate into an existing non-empty directory, only writing missing or unmodified template files")
+	cmd.Flags().String("template", "", "Project template: "+strings.Join(templates.ProjectTemplates, ", ")+", or <repo>:<template>")
+	cmd.Flags().StringArray("var", nil, "Value of a variable of a repository template (name=value, repeatable)")
+	// Non-interactive project options
+	cmd.Flags().String("name", "", "Project name; creates the project without the TUI")
+	cmd.Flags().Bool("lib", false, "Create a library instead of an executable")
+	cmd.Flags().Int("std", 0, "C++ standard: "+joinInts(newCppStandards)+" (default 17)")
+	cmd.Flags().String("test", "", "Test framework: "+strings.Join(newTestFrameworks, ", ")+" (default googletest)")
+	cmd.Flags().String("bench", "", "Benchmark framework: "+strings.Join(newBenchmarks, ", ")+" (default none)")
+	cmd.Flags().String("pm", "", "Package manager / build system: "+strings.Join(newPackageManagers, ", ")+" (default vcpkg)")
+	cmd.Flags().Bool("no-git", false, "Don't initialize a git repository")
+	cmd.Flags().BoolP("yes", "y", false, "Don't ask anything: use the defaults for everything not given (requires a project name)")
 
 	return cmd
 }
 
-func runNew(_ *cobra.Command, _ []string, client *vcpkg.Client) error {
+// Choices of the non-interactive cpx new flags, as offered by the wizard
+var (
+	newCppStandards    = []int{11, 14, 17, 20, 23}
+	newTestFrameworks  = []string{"googletest", "catch2", "doctest", "none"}
+	newBenchmarks      = []string{"google-benchmark", "nanobench", "catch2-benchmark", "none"}
+	newPackageManagers = []string{"vcpkg", "bazel", "meson", "none"}
+)
+
+// newProjectFlags are the flags that configure a project without the TUI
+var newProjectFlags = []string{"lib", "std", "test", "bench", "pm", "no-git"}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runNew(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
+	forceMerge, _ := cmd.Flags().GetBool("force-merge")
+	template, _ := cmd.Flags().GetString("template")
+	varFlags, _ := cmd.Flags().GetStringArray("var")
+	name, _ := cmd.Flags().GetString("name")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	if len(args) > 0 {
+		if name != "" && name != args[0] {
+			return exitcode.Errorf(exitcode.Usage, "project name given twice (%q and --name %q)", args[0], name)
+		}
+		name = args[0]
+	}
+
+	// Templates of template repositories skip the project wizard
+	if strings.Contains(template, ":") {
+		if name == "" {
+			return exitcode.Errorf(exitcode.Usage, "a project name is required with --template %s\n  hint: cpx new --template %s <project>", template, template)
+		}
+		for _, flag := range newProjectFlags {
+			if cmd.Flags().Changed(flag) {
+				return exitcode.Errorf(exitcode.Usage, "--%s is not used with repository templates (--template <repo>:<template>)", flag)
+			}
+		}
+		vars := make(map[string]string)
+		for _, v := range varFlags {
+			name, value, ok := strings.Cut(v, "=")
+			if !ok || name == "" {
+				return exitcode.Errorf(exitcode.Usage, "invalid --var %q\n  hint: use --var name=value", v)
+			}
+			vars[name] = value
+		}
+		return createProjectFromRepoTemplate(template, name, vars, yes, forceMerge)
+	}
+	if len(varFlags) > 0 {
+		return exitcode.Errorf(exitcode.Usage, "--var is only used with repository templates (--template <repo>:<template>)")
+	}
+	if template != "" && !slices.Contains(templates.ProjectTemplates, template) {
+		return exitcode.Errorf(exitcode.Usage, "unknown template %q\n  hint: available templates: %s", template, strings.Join(templates.ProjectTemplates, ", "))
+	}
+
+	// A project name on the command line skips the wizard
+	if name != "" {
+		config, err := projectConfigFromFlags(cmd, name, template)
+		if err != nil {
+			return err
+		}
+		return createProjectFromTUI(config, client, forceMerge)
+	}
+	if yes {
+		return exitcode.Errorf(exitcode.Usage, "--yes requires a project name\n  hint: cpx new --name <project> --yes")
+	}
+	for _, flag := range newProjectFlags {
+		if cmd.Flags().Changed(flag) {
+			return exitcode.Errorf(exitcode.Usage, "--%s requires a project name\n  hint: cpx new --name <project> --%s ...", flag, flag)
+		}
+	}
+
+	model := tui.InitialModel()
+	if template != "" {
+		model = model.WithTemplate(template)
+	}
+
 	// Initialize and run the TUI
-	p := tea.NewProgram(tui.InitialModel())
+	p := tea.NewProgram(model)
 	m, err := p.Run()
 	if err != nil {
 		return fmt.Errorf("failed to run TUI: %w", err)
@@ -56,16 +169,105 @@ func runNew(_ *cobra.Command, _ []string, client *vcpkg.Client) error {
 	config := finalModel.GetConfig()
 
 	// Create the project with the configuration
-	return createProjectFromTUI(config, client)
+	return createProjectFromTUI(config, client, forceMerge)
 }
 
-func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) error {
+// projectConfigFromFlags returns the configuration of a project created
+// without the TUI: the wizard's defaults, overridden by the flags of cmd
+func projectConfigFromFlags(cmd *cobra.Command, name, template string) (tui.ProjectConfig, error) {
+	isLibrary, _ := cmd.Flags().GetBool("lib")
+	std, _ := cmd.Flags().GetInt("std")
+	testFramework, _ := cmd.Flags().GetString("test")
+	benchmark, _ := cmd.Flags().GetString("bench")
+	pm, _ := cmd.Flags().GetString("pm")
+	noGit, _ := cmd.Flags().GetBool("no-git")
+
+	if !naming.IsValidProjectName(name) {
+		return tui.ProjectConfig{}, exitcode.Errorf(exitcode.Usage, "invalid project name %q\n  hint: use letters, numbers, hyphens and underscores", name)
+	}
+
+	// The templates fix the package manager like the wizard does
+	defaultPM := "vcpkg"
+	if template == templates.TemplateEmbedded {
+		defaultPM = "none"
+	}
+	config := tui.ProjectConfig{
+		Name:           name,
+		IsLibrary:      isLibrary,
+		CppStandard:    17,
+		TestFramework:  "googletest",
+		Benchmark:      "none",
+		ClangFormat:    "Google",
+		PackageManager: defaultPM,
+		ComputeBackend: templates.ComputeNone,
+		VCS:            "git",
+		Template:       template,
+	}
+	if noGit {
+		config.VCS = "none"
+	}
+
+	if std != 0 {
+		if !slices.Contains(newCppStandards, std) {
+			return config, exitcode.Errorf(exitcode.Usage, "unsupported C++ standard %d\n  hint: use one of %s", std, joinInts(newCppStandards))
+		}
+		config.CppStandard = std
+	}
+	if template == templates.TemplateGrpcService && config.CppStandard < 17 {
+		return config, exitcode.Errorf(exitcode.Usage, "the %s template needs C++17 or later", templates.TemplateGrpcService)
+	}
+	choices := []struct {
+		flag, value string
+		allowed     []string
+		field       *string
+	}{
+		{"test", testFramework, newTestFrameworks, &config.TestFramework},
+		{"bench", benchmark, newBenchmarks, &config.Benchmark},
+		{"pm", pm, newPackageManagers, &config.PackageManager},
+	}
+	for _, c := range choices {
+		if c.value == "" {
+			continue
+		}
+		if !slices.Contains(c.allowed, c.value) {
+			return config, exitcode.Errorf(exitcode.Usage, "invalid --%s %q\n  hint: use one of %s", c.flag, c.value, strings.Join(c.allowed, ", "))
+		}
+		*c.field = c.value
+	}
+	return config, nil
+}
+
+// createProjectFromTUI generates the project in a new directory named after
+// it. With forceMerge, the directory may already exist: files are then only
+// written if they are missing or unmodified template outputs.
+func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client, forceMerge bool) error {
 	projectName := config.Name
 
+	if config.PackageManager == "bazel" && config.ComputeBackend != "" && config.ComputeBackend != templates.ComputeNone && config.ComputeBackend != templates.ComputeOpenMP {
+		return exitcode.Errorf(exitcode.Usage, "the %s compute backend is not supported for Bazel projects (use OpenMP or none)", templates.ComputeBackendName(config.ComputeBackend))
+	}
+	if config.Template == templates.TemplateGrpcService && (config.PackageManager != "vcpkg" || config.IsLibrary) {
+		return exitcode.Errorf(exitcode.Usage, "the %s template is an executable using vcpkg", templates.TemplateGrpcService)
+	}
+	if config.Template == templates.TemplateCLIApp && config.IsLibrary {
+		return exitcode.Errorf(exitcode.Usage, "the %s template is an executable", templates.TemplateCLIApp)
+	}
+	if config.Template == templates.TemplateEmbedded && (config.PackageManager != "none" || config.IsLibrary) {
+		return exitcode.Errorf(exitcode.Usage, "the %s template is a CMake executable without a package manager", templates.TemplateEmbedded)
+	}
+
 	// Check if directory already exists
-	if _, err := os.Stat(projectName); err == nil {
-		return fmt.Errorf("directory '%s' already exists", projectName)
+	if info, err := os.Stat(projectName); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("'%s' already exists and is not a directory", projectName)
+		}
+		if !forceMerge && !isEmptyDir(projectName) {
+			return fmt.Errorf("directory '%s' already exists\n  hint: use --force-merge to generate into it without overwriting modified files", projectName)
+		}
+	} else {
+		forceMerge = false
 	}
+	w := newProjectWriter(projectName, forceMerge)
 
 	// Create the new directory
 	if err := os.MkdirAll(projectName, 0755); err != nil {
@@ -80,12 +282,15 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 		TestFramework:  config.TestFramework,
 		ClangFormat:    config.ClangFormat,
 		PackageManager: config.PackageManager,
+		ComputeBackend: config.ComputeBackend,
+		PCH:            config.PCH,
 		VCS:            config.VCS,
 		UseHooks:       config.UseHooks,
 		GitHooks:       config.GitHooks,
 		PreCommit:      config.PreCommit,
 		PrePush:        config.PrePush,
 		Benchmark:      config.Benchmark,
+		Template:       config.Template,
 	}
 
 	// Set hooks
@@ -117,6 +322,11 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 		_ = cmd.Run() // Ignore errors silently
 	}
 
+	// Set compute backend default
+	if cfg.ComputeBackend == "" {
+		cfg.ComputeBackend = templates.ComputeNone
+	}
+
 	// Set C++ standard default
 	cppStandard := cfg.CppStandard
 	if cppStandard == 0 {
@@ -128,6 +338,24 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 	// Generate benchmark artifacts if enabled
 	benchSources, _ := templates.GenerateBenchmarkSources(projectName, cfg.Benchmark)
 
+	// Generate the sample service of the gRPC template, the command-line
+	// parsing of the CLI application template or the cross-compilation files
+	// of the embedded template
+	var grpcSources *templates.GrpcSources
+	var cliSources *templates.CLIAppSources
+	var embeddedSources *templates.EmbeddedSources
+	var dependencies []string
+	switch cfg.Template {
+	case templates.TemplateGrpcService:
+		grpcSources = templates.GenerateGrpcSources(projectName, cfg.TestFramework)
+		dependencies = templates.GrpcDependencies
+	case templates.TemplateCLIApp:
+		cliSources = templates.GenerateCLIAppSources(projectName, cfg.TestFramework)
+		dependencies = templates.CLIAppDependencies
+	case templates.TemplateEmbedded:
+		embeddedSources = templates.GenerateEmbeddedSources(projectName)
+	}
+
 	// Create directory structure
 	dirs := []string{
 		"include/" + projectName,
@@ -139,6 +367,12 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 	if benchSources != nil {
 		dirs = append(dirs, "bench")
 	}
+	if grpcSources != nil {
+		dirs = append(dirs, "proto")
+	}
+	if embeddedSources != nil {
+		dirs = append(dirs, "cmake", "linker")
+	}
 	for _, dir := range dirs {
 		dirPath := filepath.Join(projectName, dir)
 		if err := os.MkdirAll(dirPath, 0755); err != nil {
@@ -150,55 +384,66 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 	if cfg.PackageManager == "bazel" {
 		// Generate MODULE.bazel
 		moduleBazel := templates.GenerateModuleBazel(projectName, projectVersion, cfg.TestFramework, cfg.Benchmark)
-		if err := os.WriteFile(filepath.Join(projectName, "MODULE.bazel"), []byte(moduleBazel), 0644); err != nil {
+		if cliSources != nil {
+			moduleBazel += templates.GenerateCLIAppBazelModule()
+		}
+		if err := w.write("MODULE.bazel", moduleBazel); err != nil {
 			return fmt.Errorf("failed to write MODULE.bazel: %w", err)
 		}
 
 		// Generate root BUILD.bazel (aliases)
 		buildBazel := templates.GenerateBuildBazelRoot(projectName, !cfg.IsLibrary)
-		if err := os.WriteFile(filepath.Join(projectName, "BUILD.bazel"), []byte(buildBazel), 0644); err != nil {
+		if err := w.write("BUILD.bazel", buildBazel); err != nil {
 			return fmt.Errorf("failed to write BUILD.bazel: %w", err)
 		}
 
 		// Generate src/BUILD.bazel
 		srcBuild := templates.GenerateBuildBazelSrc(projectName, !cfg.IsLibrary)
-		if err := os.WriteFile(filepath.Join(projectName, "src/BUILD.bazel"), []byte(srcBuild), 0644); err != nil {
+		if cliSources != nil {
+			srcBuild = templates.GenerateCLIAppBazelSrc(projectName)
+		}
+		srcBuild += templates.GenerateBazelComputeSrc(projectName, cfg.ComputeBackend)
+		if err := w.write("src/BUILD.bazel", srcBuild); err != nil {
 			return fmt.Errorf("failed to write src/BUILD.bazel: %w", err)
 		}
 
 		// Generate include/BUILD.bazel
 		includeBuild := templates.GenerateBuildBazelInclude(projectName)
-		if err := os.WriteFile(filepath.Join(projectName, "include/BUILD.bazel"), []byte(includeBuild), 0644); err != nil {
+		if err := w.write("include/BUILD.bazel", includeBuild); err != nil {
 			return fmt.Errorf("failed to write include/BUILD.bazel: %w", err)
 		}
 
 		// Generate .bazelrc
 		bazelrc := templates.GenerateBazelrc(cppStandard)
-		if err := os.WriteFile(filepath.Join(projectName, ".bazelrc"), []byte(bazelrc), 0644); err != nil {
+		if err := w.write(".bazelrc", bazelrc); err != nil {
 			return fmt.Errorf("failed to write .bazelrc: %w", err)
 		}
 
 		// Generate .bazelignore
 		bazelignore := templates.GenerateBazelignore()
-		if err := os.WriteFile(filepath.Join(projectName, ".bazelignore"), []byte(bazelignore), 0644); err != nil {
+		if err := w.write(".bazelignore", bazelignore); err != nil {
 			return fmt.Errorf("failed to write .bazelignore: %w", err)
 		}
 	} else if cfg.PackageManager == "meson" {
 		// Generate meson.build (root)
 		mesonBuild := templates.GenerateMesonBuildRoot(projectName, !cfg.IsLibrary, cppStandard, cfg.TestFramework, cfg.Benchmark)
-		if err := os.WriteFile(filepath.Join(projectName, "meson.build"), []byte(mesonBuild), 0644); err != nil {
+		if err := w.write("meson.build", mesonBuild); err != nil {
 			return fmt.Errorf("failed to write meson.build: %w", err)
 		}
 
 		// Generate src/meson.build
 		srcMeson := templates.GenerateMesonBuildSrc(projectName, !cfg.IsLibrary)
-		if err := os.WriteFile(filepath.Join(projectName, "src/meson.build"), []byte(srcMeson), 0644); err != nil {
+		if cliSources != nil {
+			srcMeson = templates.GenerateCLIAppMesonSrc(projectName)
+		}
+		srcMeson += templates.GenerateMesonComputeSrc(projectName, cfg.ComputeBackend, cppStandard)
+		if err := w.write("src/meson.build", srcMeson); err != nil {
 			return fmt.Errorf("failed to write src/meson.build: %w", err)
 		}
 
 		// Generate meson_options.txt (use _options.txt for wider compatibility)
 		mesonOptions := templates.GenerateMesonOptions()
-		if err := os.WriteFile(filepath.Join(projectName, "meson_options.txt"), []byte(mesonOptions), 0644); err != nil {
+		if err := w.write("meson_options.txt", mesonOptions); err != nil {
 			return fmt.Errorf("failed to write meson_options.txt: %w", err)
 		}
 
@@ -218,9 +463,9 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 			case "doctest":
 				wrapName = "doctest"
 			}
-			if wrapName != "" {
+			if wrapName != "" && !w.exists("subprojects/"+wrapName+".wrap") {
 				if err := downloadMesonWrap(projectName, wrapName); err != nil {
-					fmt.Printf("%sWarning: could not download %s wrap: %v%s\n", Yellow, wrapName, err, Reset)
+					logging.Warn("could not download %s wrap: %v", wrapName, err)
 				}
 			}
 		}
@@ -234,23 +479,48 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 			case "catch2-benchmark":
 				wrapName = "catch2"
 			}
-			if wrapName != "" {
+			if wrapName != "" && !w.exists("subprojects/"+wrapName+".wrap") {
 				if err := downloadMesonWrap(projectName, wrapName); err != nil {
-					fmt.Printf("%sWarning: could not download %s wrap: %v%s\n", Yellow, wrapName, err, Reset)
+					logging.Warn("could not download %s wrap: %v", wrapName, err)
 				}
 			}
 		}
+
+		// Download the CLI11 wrap for the CLI application template
+		if cliSources != nil && !w.exists("subprojects/cli11.wrap") {
+			if err := downloadMesonWrap(projectName, "cli11"); err != nil {
+				logging.Warn("could not download cli11 wrap: %v", err)
+			}
+		}
 	} else {
-		// Generate CMakeLists.txt (vcpkg or none)
-		cmakeLists := templates.GenerateVcpkgCMakeLists(projectName, cppStandard, !cfg.IsLibrary, cfg.TestFramework != "" && cfg.TestFramework != "none", cfg.Benchmark, benchSources != nil, projectVersion)
-		if err := os.WriteFile(filepath.Join(projectName, "CMakeLists.txt"), []byte(cmakeLists), 0644); err != nil {
+		// Generate CMakeLists.txt (vcpkg or none). Embedded projects add
+		// their tests and benchmarks for host builds only.
+		includeTests := cfg.TestFramework != "" && cfg.TestFramework != "none"
+		var cmakeLists string
+		if embeddedSources != nil {
+			cmakeLists = templates.GenerateVcpkgCMakeLists(projectName, cppStandard, !cfg.IsLibrary, false, cfg.Benchmark, false, projectVersion)
+			cmakeLists += templates.GenerateEmbeddedCMake(projectName, includeTests, benchSources != nil)
+		} else {
+			cmakeLists = templates.GenerateVcpkgCMakeLists(projectName, cppStandard, !cfg.IsLibrary, includeTests, cfg.Benchmark, benchSources != nil, projectVersion)
+		}
+		cmakeLists += templates.GenerateComputeCMake(projectName, cfg.ComputeBackend, cppStandard)
+		if grpcSources != nil {
+			cmakeLists += templates.GenerateGrpcCMake(projectName)
+		}
+		if cliSources != nil {
+			cmakeLists += templates.GenerateCLIAppCMake(projectName, cfg.PackageManager == "vcpkg")
+		}
+		if cfg.PCH {
+			cmakeLists += templates.GeneratePCHCMake(projectName)
+		}
+		if err := w.write("CMakeLists.txt", cmakeLists); err != nil {
 			return fmt.Errorf("failed to write CMakeLists.txt: %w", err)
 		}
 
 		// Generate CMakePresets.json for vcpkg
 		if cfg.PackageManager == "" || cfg.PackageManager == "vcpkg" {
 			cmakePresets := templates.GenerateCMakePresets()
-			if err := os.WriteFile(filepath.Join(projectName, "CMakePresets.json"), []byte(cmakePresets), 0644); err != nil {
+			if err := w.write("CMakePresets.json", cmakePresets); err != nil {
 				return fmt.Errorf("failed to write CMakePresets.json: %w", err)
 			}
 		}
@@ -258,53 +528,107 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 
 	// Generate version.hpp
 	versionHpp := templates.GenerateVersionHpp(projectName, projectVersion)
-	if err := os.WriteFile(filepath.Join(projectName, "include/"+projectName+"/version.hpp"), []byte(versionHpp), 0644); err != nil {
+	if err := w.write("include/"+projectName+"/version.hpp", versionHpp); err != nil {
 		return fmt.Errorf("failed to write version.hpp: %w", err)
 	}
 
 	// Generate header file
 	libHeader := templates.GenerateLibHeader(projectName)
-	if err := os.WriteFile(filepath.Join(projectName, "include/"+projectName+"/"+projectName+".hpp"), []byte(libHeader), 0644); err != nil {
+	if err := w.write("include/"+projectName+"/"+projectName+".hpp", libHeader); err != nil {
 		return fmt.Errorf("failed to write header: %w", err)
 	}
 
 	// Generate main.cpp for executables
 	if !cfg.IsLibrary {
 		mainCpp := templates.GenerateMainCpp(projectName)
-		if err := os.WriteFile(filepath.Join(projectName, "src/main.cpp"), []byte(mainCpp), 0644); err != nil {
+		if grpcSources != nil {
+			mainCpp = grpcSources.Main
+		}
+		if cliSources != nil {
+			mainCpp = cliSources.Main
+		}
+		if embeddedSources != nil {
+			mainCpp = embeddedSources.Main
+		}
+		if err := w.write("src/main.cpp", mainCpp); err != nil {
 			return fmt.Errorf("failed to write main.cpp: %w", err)
 		}
 	}
 
 	// Generate library source file
 	libSource := templates.GenerateLibSource(projectName)
-	if err := os.WriteFile(filepath.Join(projectName, "src/"+projectName+".cpp"), []byte(libSource), 0644); err != nil {
+	if embeddedSources != nil {
+		libSource = embeddedSources.Source
+	}
+	if err := w.write("src/"+projectName+".cpp", libSource); err != nil {
 		return fmt.Errorf("failed to write source: %w", err)
 	}
 
+	// Generate the sample parallel kernel for the compute backend
+	if compute := templates.GenerateComputeSources(projectName, cfg.ComputeBackend); compute != nil {
+		if err := w.write("include/"+projectName+"/parallel.hpp", compute.Header); err != nil {
+			return fmt.Errorf("failed to write parallel.hpp: %w", err)
+		}
+		if err := w.write("src/"+compute.SourceFile, compute.Source); err != nil {
+			return fmt.Errorf("failed to write %s: %w", compute.SourceFile, err)
+		}
+	}
+
+	// Generate the proto definition and the service implementation
+	if grpcSources != nil {
+		if err := w.write("proto/"+projectName+".proto", grpcSources.Proto); err != nil {
+			return fmt.Errorf("failed to write %s.proto: %w", projectName, err)
+		}
+		if err := w.write("include/"+projectName+"/greeter_service.hpp", grpcSources.Header); err != nil {
+			return fmt.Errorf("failed to write greeter_service.hpp: %w", err)
+		}
+		if err := w.write("src/greeter_service.cpp", grpcSources.Source); err != nil {
+			return fmt.Errorf("failed to write greeter_service.cpp: %w", err)
+		}
+	}
+
+	// Generate the command-line parser
+	if cliSources != nil {
+		if err := w.write("include/"+projectName+"/cli.hpp", cliSources.Header); err != nil {
+			return fmt.Errorf("failed to write cli.hpp: %w", err)
+		}
+		if err := w.write("src/cli.cpp", cliSources.Source); err != nil {
+			return fmt.Errorf("failed to write cli.cpp: %w", err)
+		}
+	}
+
+	// Generate the toolchain file and linker script of the firmware
+	if embeddedSources != nil {
+		if err := w.write(templates.EmbeddedToolchainFile, embeddedSources.Toolchain); err != nil {
+			return fmt.Errorf("failed to write %s: %w", templates.EmbeddedToolchainFile, err)
+		}
+		if err := w.write("linker/"+projectName+".ld", embeddedSources.LinkerScript); err != nil {
+			return fmt.Errorf("failed to write %s.ld: %w", projectName, err)
+		}
+	}
+
 	// Generate benchmark files if enabled
 	if benchSources != nil {
-		benchPath := filepath.Join(projectName, "bench", "bench_main.cpp")
-		if err := os.WriteFile(benchPath, []byte(benchSources.Main), 0644); err != nil {
+		if err := w.write("bench/bench_main.cpp", benchSources.Main); err != nil {
 			return fmt.Errorf("failed to write bench_main.cpp: %w", err)
 		}
 
 		if cfg.PackageManager == "bazel" {
 			// Generate bench/BUILD.bazel for Bazel projects
 			benchBuild := templates.GenerateBuildBazelBench(projectName, cfg.Benchmark)
-			if err := os.WriteFile(filepath.Join(projectName, "bench/BUILD.bazel"), []byte(benchBuild), 0644); err != nil {
+			if err := w.write("bench/BUILD.bazel", benchBuild); err != nil {
 				return fmt.Errorf("failed to write bench/BUILD.bazel: %w", err)
 			}
 		} else if cfg.PackageManager == "meson" {
 			// Generate bench/meson.build for Meson projects
 			benchMeson := templates.GenerateMesonBuildBench(projectName, cfg.Benchmark)
-			if err := os.WriteFile(filepath.Join(projectName, "bench/meson.build"), []byte(benchMeson), 0644); err != nil {
+			if err := w.write("bench/meson.build", benchMeson); err != nil {
 				return fmt.Errorf("failed to write bench/meson.build: %w", err)
 			}
 		} else {
 			// Generate bench/CMakeLists.txt for CMake projects
 			benchCMake := templates.GenerateBenchCMake(projectName, cfg.Benchmark)
-			if err := os.WriteFile(filepath.Join(projectName, "bench/CMakeLists.txt"), []byte(benchCMake), 0644); err != nil {
+			if err := w.write("bench/CMakeLists.txt", benchCMake); err != nil {
 				return fmt.Errorf("failed to write bench/CMakeLists.txt: %w", err)
 			}
 		}
@@ -319,7 +643,7 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 	} else {
 		readme = templates.GenerateVcpkgReadme(projectName, cppStandard, cfg.IsLibrary)
 	}
-	if err := os.WriteFile(filepath.Join(projectName, "README.md"), []byte(readme), 0644); err != nil {
+	if err := w.write("README.md", readme); err != nil {
 		return fmt.Errorf("failed to write README: %w", err)
 	}
 
@@ -333,7 +657,7 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 		} else {
 			gitignore = templates.GenerateGitignore()
 		}
-		if err := os.WriteFile(filepath.Join(projectName, ".gitignore"), []byte(gitignore), 0644); err != nil {
+		if err := w.write(".gitignore", gitignore); err != nil {
 			return fmt.Errorf("failed to write .gitignore: %w", err)
 		}
 	}
@@ -344,50 +668,93 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 		clangFormatStyle = "Google"
 	}
 	clangFormat := templates.GenerateClangFormat(clangFormatStyle)
-	if err := os.WriteFile(filepath.Join(projectName, ".clang-format"), []byte(clangFormat), 0644); err != nil {
+	if err := w.write(".clang-format", clangFormat); err != nil {
 		return fmt.Errorf("failed to write .clang-format: %w", err)
 	}
 
+	// Generate .clangd unless there is one. It holds absolute include paths,
+	// so it is gitignored and not tracked for merges; cpx gen clangd refreshes it.
+	if !w.exists(".clangd") {
+		projectRoot, err := filepath.Abs(projectName)
+		if err != nil {
+			return err
+		}
+		clangd := templates.GenerateClangd(cppStandard, clangdIncludeDirs(projectRoot), nil)
+		if err := os.WriteFile(filepath.Join(projectRoot, ".clangd"), []byte(clangd), 0644); err != nil {
+			return fmt.Errorf("failed to write .clangd: %w", err)
+		}
+	}
+
 	// Generate test files if test framework is selected
 	if cfg.TestFramework != "" && cfg.TestFramework != "none" {
 		if cfg.PackageManager == "bazel" {
 			// Generate tests/BUILD.bazel for Bazel projects
 			testsBuild := templates.GenerateBuildBazelTests(projectName, cfg.TestFramework)
-			if err := os.WriteFile(filepath.Join(projectName, "tests/BUILD.bazel"), []byte(testsBuild), 0644); err != nil {
+			if cliSources != nil {
+				testsBuild += templates.GenerateCLIAppBazelTests(projectName, cfg.TestFramework)
+			}
+			if err := w.write("tests/BUILD.bazel", testsBuild); err != nil {
 				return fmt.Errorf("failed to write tests/BUILD.bazel: %w", err)
 			}
 		} else if cfg.PackageManager == "meson" {
 			// Generate tests/meson.build for Meson projects
 			testsMeson := templates.GenerateMesonBuildTests(projectName, cfg.TestFramework)
-			if err := os.WriteFile(filepath.Join(projectName, "tests/meson.build"), []byte(testsMeson), 0644); err != nil {
+			if cliSources != nil {
+				testsMeson += templates.GenerateCLIAppMesonTests(projectName, cfg.TestFramework)
+			}
+			if err := w.write("tests/meson.build", testsMeson); err != nil {
 				return fmt.Errorf("failed to write tests/meson.build: %w", err)
 			}
 		} else {
 			// Generate tests/CMakeLists.txt for CMake projects
 			testCMake := templates.GenerateTestCMake(projectName, cfg.TestFramework)
-			if err := os.WriteFile(filepath.Join(projectName, "tests/CMakeLists.txt"), []byte(testCMake), 0644); err != nil {
+			if grpcSources != nil && grpcSources.Test != "" {
+				testCMake += templates.GenerateGrpcTestCMake(projectName)
+			}
+			if cliSources != nil {
+				testCMake += templates.GenerateCLIAppTestCMake(projectName, cfg.TestFramework)
+			}
+			if err := w.write("tests/CMakeLists.txt", testCMake); err != nil {
 				return fmt.Errorf("failed to write tests/CMakeLists.txt: %w", err)
 			}
 		}
 
 		testMain := templates.GenerateTestMain(projectName, cfg.TestFramework)
-		if err := os.WriteFile(filepath.Join(projectName, "tests/test_main.cpp"), []byte(testMain), 0644); err != nil {
+		if err := w.write("tests/test_main.cpp", testMain); err != nil {
 			return fmt.Errorf("failed to write tests/test_main.cpp: %w", err)
 		}
+
+		if grpcSources != nil && grpcSources.Test != "" {
+			if err := w.write("tests/greeter_service_test.cpp", grpcSources.Test); err != nil {
+				return fmt.Errorf("failed to write tests/greeter_service_test.cpp: %w", err)
+			}
+		}
+		if cliSources != nil {
+			if err := w.write("tests/cli_test.cpp", cliSources.Test); err != nil {
+				return fmt.Errorf("failed to write tests/cli_test.cpp: %w", err)
+			}
+		}
 	}
 
 	// Generate cpx.ci file
 	cpxCI := templates.GenerateCpxCI()
-	if err := os.WriteFile(filepath.Join(projectName, "cpx.ci"), []byte(cpxCI), 0644); err != nil {
+	if err := w.write("cpx.ci", cpxCI); err != nil {
 		return fmt.Errorf("failed to write cpx.ci: %w", err)
 	}
 
+	// Generate cpx.yaml for CMake projects
+	if cfg.PackageManager != "bazel" && cfg.PackageManager != "meson" {
+		if err := w.write("cpx.yaml", templates.GenerateCpxYaml(cfg.PCH)); err != nil {
+			return fmt.Errorf("failed to write cpx.yaml: %w", err)
+		}
+	}
+
 	// Setup vcpkg if enabled (skip for bazel)
-	if cfg.PackageManager == "vcpkg" {
+	if cfg.PackageManager == "vcpkg" && !w.exists("vcpkg.json") {
 		if vcpkgClient != nil {
 			vcpkgPath, err := vcpkgClient.GetPath()
 			if err == nil && vcpkgPath != "" {
-				_ = setupVcpkgProject(vcpkgClient, projectName, projectName, cfg.IsLibrary, []string{})
+				_ = setupVcpkgProject(vcpkgClient, projectName, projectName, cfg.IsLibrary, dependencies)
 			}
 		}
 	}
@@ -406,18 +773,30 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 				// Change to project directory to install hooks
 				originalDir, _ := os.Getwd()
 				os.Chdir(projectName)
-				if err := git.InstallHooksWithConfig(cfg.PreCommit, cfg.PrePush); err != nil {
+				if err := git.InstallHooksWithConfig(cfg.PreCommit, cfg.PrePush, false); err != nil {
 					// Non-fatal: just skip hooks if installation fails
-					fmt.Printf("%sWarning: Could not install git hooks: %v%s\n", Yellow, err, Reset)
+					logging.Warn("Could not install git hooks: %v", err)
 				}
 				os.Chdir(originalDir)
 			}
 		}
 	}
 
+	if err := w.saveManifest(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", TemplateManifestPath, err)
+	}
+
+	if forceMerge {
+		w.printMergeSummary()
+	}
+
 	// Show success message
-	fmt.Printf("\n%s✓ Project '%s' created successfully!%s\n\n", Green, projectName, Reset)
-	fmt.Printf("  cd %s && cpx build && cpx run\n\n", projectName)
+	logging.Success("\n✓ Project '%s' created successfully!\n", projectName)
+	if embeddedSources != nil {
+		fmt.Printf("  cd %s && cpx build --target embedded\n\n", projectName)
+	} else {
+		fmt.Printf("  cd %s && cpx build && cpx run\n\n", projectName)
+	}
 
 	return nil
 }
@@ -426,7 +805,7 @@ func createProjectFromTUI(config tui.ProjectConfig, vcpkgClient *vcpkg.Client) e
 func downloadMesonWrap(projectName, wrapName string) error {
 	// Ensure meson is available
 	if _, err := execLookPath("meson"); err != nil {
-		return fmt.Errorf("meson not found in PATH: %w", err)
+		return exitcode.Errorf(exitcode.ToolchainMissing, "meson not found in PATH: %w", err)
 	}
 
 	// We need to run this command inside the project directory
@@ -474,7 +853,7 @@ func setupVcpkgProject(client *vcpkg.Client, targetDir, _ string, _ bool, depend
 	}
 
 	if len(dependencies) > 0 {
-		fmt.Printf("%s Adding dependencies from template...%s\n", Cyan, Reset)
+		logging.Step("Adding dependencies from template...")
 		for _, dep := range dependencies {
 			if dep == "" {
 				continue
@@ -487,7 +866,7 @@ func setupVcpkgProject(client *vcpkg.Client, targetDir, _ string, _ bool, depend
 			addCmd.Stderr = os.Stderr
 			addCmd.Env = vcpkgCmd.Env // Use same environment
 			if err := addCmd.Run(); err != nil {
-				fmt.Printf("%s  Warning: Failed to add dependency '%s': %v%s\n", Yellow, dep, err, Reset)
+				logging.Warn("Failed to add dependency '%s': %v", dep, err)
 				// Continue with other dependencies even if one fails
 			}
 		}
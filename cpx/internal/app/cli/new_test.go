@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateProjectFromTUI_ComputeBackend(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	config := tui.ProjectConfig{
+		Name:           "omp-proj",
+		PackageManager: "bazel",
+		CppStandard:    17,
+		TestFramework:  "none",
+		Benchmark:      "none",
+		ComputeBackend: "openmp",
+		VCS:            "none",
+	}
+	require.NoError(t, createProjectFromTUI(config, nil, false))
+	assert.FileExists(t, "omp-proj/include/omp-proj/parallel.hpp")
+	assert.FileExists(t, "omp-proj/src/parallel.cpp")
+	srcBuild, err := os.ReadFile("omp-proj/src/BUILD.bazel")
+	require.NoError(t, err)
+	assert.Contains(t, string(srcBuild), `name = "omp-proj_parallel"`)
+
+	require.NoError(t, os.Chdir("omp-proj"))
+	assert.Equal(t, "openmp", detectComputeBackend())
+	require.NoError(t, os.Chdir(tmpDir))
+
+	config.Name = "cuda-proj"
+	config.ComputeBackend = "cuda"
+	err = createProjectFromTUI(config, nil, false)
+	assert.ErrorContains(t, err, "not supported for Bazel")
+	assert.NoDirExists(t, "cuda-proj")
+}
+
+func TestCreateProjectFromTUI_PCH(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	config := tui.ProjectConfig{
+		Name:           "pch-proj",
+		PackageManager: "none",
+		CppStandard:    17,
+		TestFramework:  "none",
+		Benchmark:      "none",
+		PCH:            true,
+		VCS:            "none",
+	}
+	require.NoError(t, createProjectFromTUI(config, nil, false))
+
+	cmakeLists, err := os.ReadFile("pch-proj/CMakeLists.txt")
+	require.NoError(t, err)
+	assert.Contains(t, string(cmakeLists), "target_precompile_headers(pch-proj PRIVATE")
+	cpxYaml, err := os.ReadFile("pch-proj/cpx.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(cpxYaml), "pch: true")
+}
+
+func TestNewNonInteractive(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	run := func(args ...string) error {
+		cmd := NewCmd(nil)
+		cmd.SetArgs(args)
+		cmd.SilenceErrors, cmd.SilenceUsage = true, true
+		return cmd.Execute()
+	}
+
+	require.NoError(t, run("--name", "mylib", "--lib", "--std", "20", "--test", "catch2", "--pm", "meson", "--no-git"))
+	assert.FileExists(t, "mylib/meson.build")
+	assert.NoFileExists(t, "mylib/src/main.cpp")
+	assert.NoDirExists(t, "mylib/.git")
+	meson, err := os.ReadFile("mylib/meson.build")
+	require.NoError(t, err)
+	assert.Contains(t, string(meson), "cpp_std=c++20")
+	testsMeson, err := os.ReadFile("mylib/tests/meson.build")
+	require.NoError(t, err)
+	assert.Contains(t, string(testsMeson), "catch2")
+
+	// The positional name works too; the embedded template defaults to plain CMake
+	require.NoError(t, run("fw", "--template", "embedded", "--test", "none", "--no-git", "--yes"))
+	assert.FileExists(t, "fw/cmake/arm-none-eabi.cmake")
+	assert.NoFileExists(t, "fw/vcpkg.json")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unsupported standard", []string{"--name", "x", "--std", "98"}},
+		{"unknown test framework", []string{"--name", "x", "--test", "boost"}},
+		{"unknown package manager", []string{"--name", "x", "--pm", "conan"}},
+		{"invalid name", []string{"--name", "my project"}},
+		{"flag without name", []string{"--lib"}},
+		{"yes without name", []string{"--yes"}},
+		{"name given twice", []string{"a", "--name", "b"}},
+		{"grpc before C++17", []string{"--name", "x", "--template", "grpc-service", "--std", "14"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.args...)
+			assert.Equal(t, exitcode.Usage, exitcode.Of(err), "%v", err)
+			assert.NoDirExists(t, "x")
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// PresetCmd creates the preset command
+func PresetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "preset",
+		Short: "Manage the CMake presets of the project",
+		Long: `List, add and remove the configure, build and test presets of
+CMakePresets.json, and select the configure preset cpx builds with.
+
+'cpx preset add' writes a configure preset and a build and a test preset of
+the same name, inheriting from the "default" preset cpx generates (the vcpkg
+toolchain) unless --inherits says otherwise. 'cpx preset use' records the
+preset in build.preset of cpx.yaml; cpx build, run, test, bench and lint
+then configure CMake with it.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add a configure, build and test preset",
+		Example: `  cpx preset add asan --sanitizer asan
+  cpx preset add clang --compiler clang-17
+  cpx preset add cross-arm64 --toolchain-file cmake/arm64.cmake
+  cpx preset add lto -D CMAKE_INTERPROCEDURAL_OPTIMIZATION=ON`,
+		Args: cobra.ExactArgs(1),
+		RunE: withExitCode(exitcode.Usage, func(cmd *cobra.Command, args []string) error {
+			return addPreset(cmd, args[0])
+		}),
+	}
+	addCmd.Flags().String("inherits", build.DefaultPreset, "Configure preset to inherit from (\"\" for none)")
+	addCmd.Flags().String("description", "", "Description of the preset")
+	addCmd.Flags().String("generator", "", "CMake generator (e.g. Ninja)")
+	addCmd.Flags().String("compiler", "", "Compiler: clang, gcc or cl, optionally versioned (clang-17, gcc-13)")
+	addCmd.Flags().String("sanitizer", "", "Sanitizer: asan, tsan, msan or ubsan")
+	addCmd.Flags().String("toolchain-file", "", "CMake toolchain file, e.g. for cross-compiling (chainloaded by vcpkg in vcpkg projects)")
+	addCmd.Flags().StringArrayP("define", "D", nil, "Cache variable KEY=VALUE (repeatable)")
+	addCmd.Flags().Bool("force", false, "Replace presets of the same name")
+	cmd.AddCommand(addCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the configure, build and test presets",
+		Args:  cobra.NoArgs,
+		RunE: withExitCode(exitcode.Config, func(cmd *cobra.Command, args []string) error {
+			return listPresets()
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a preset and the build and test presets using it",
+		Args:  cobra.ExactArgs(1),
+		RunE: withExitCode(exitcode.Usage, func(cmd *cobra.Command, args []string) error {
+			return removePreset(args[0])
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "use <name>",
+		Short: "Build with a configure preset",
+		Args:  cobra.ExactArgs(1),
+		RunE: withExitCode(exitcode.Usage, func(cmd *cobra.Command, args []string) error {
+			return usePreset(args[0])
+		}),
+	})
+
+	return cmd
+}
+
+// loadPresets reads the CMakePresets.json of the project
+func loadPresets() (*build.Presets, error) {
+	presets, err := build.LoadPresets(build.PresetsFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, exitcode.Errorf(exitcode.Config, "%s not found\n  hint: cpx new and cpx migrate generate it for CMake projects", build.PresetsFile)
+	}
+	if err != nil {
+		return nil, exitcode.Wrap(exitcode.Config, err)
+	}
+	return presets, nil
+}
+
+func addPreset(cmd *cobra.Command, name string) error {
+	inherits, _ := cmd.Flags().GetString("inherits")
+	description, _ := cmd.Flags().GetString("description")
+	generator, _ := cmd.Flags().GetString("generator")
+	compilerName, _ := cmd.Flags().GetString("compiler")
+	sanitizer, _ := cmd.Flags().GetString("sanitizer")
+	toolchainFile, _ := cmd.Flags().GetString("toolchain-file")
+	defines, _ := cmd.Flags().GetStringArray("define")
+	force, _ := cmd.Flags().GetBool("force")
+
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+
+	variables := make(map[string]string)
+	if compilerName != "" {
+		compiler, err := build.ParseCompiler(compilerName)
+		if err != nil {
+			return err
+		}
+		variables["CMAKE_C_COMPILER"] = compiler.CC
+		variables["CMAKE_CXX_COMPILER"] = compiler.CXX
+	}
+	if sanitizer != "" {
+		cxxFlags, linkerFlags := build.GetSanitizerFlags(sanitizer)
+		if cxxFlags == "" {
+			return fmt.Errorf("unknown sanitizer %q\n  hint: use asan, tsan, msan or ubsan", sanitizer)
+		}
+		cxxFlags = strings.TrimSpace(cxxFlags)
+		variables["CMAKE_C_FLAGS"] = cxxFlags
+		variables["CMAKE_CXX_FLAGS"] = cxxFlags
+		variables["CMAKE_EXE_LINKER_FLAGS"] = linkerFlags
+		variables["CMAKE_SHARED_LINKER_FLAGS"] = linkerFlags
+	}
+	if toolchainFile != "" {
+		if !filepath.IsAbs(toolchainFile) {
+			toolchainFile = "${sourceDir}/" + filepath.ToSlash(toolchainFile)
+		}
+		// vcpkg projects keep the vcpkg toolchain, which loads the other one
+		key := "CMAKE_TOOLCHAIN_FILE"
+		if _, err := os.Stat("vcpkg.json"); err == nil {
+			key = "VCPKG_CHAINLOAD_TOOLCHAIN_FILE"
+		}
+		variables[key] = toolchainFile
+	}
+	for _, define := range defines {
+		key, value, ok := strings.Cut(define, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid cache variable %q\n  hint: use -D KEY=VALUE", define)
+		}
+		variables[key] = value
+	}
+	if len(variables) == 0 {
+		variables = nil
+	}
+
+	preset := build.NewPreset{
+		Name:           name,
+		Description:    description,
+		Inherits:       inherits,
+		Generator:      generator,
+		CacheVariables: variables,
+	}
+	if err := presets.Add(preset, force); err != nil {
+		return err
+	}
+	if err := presets.Save(build.PresetsFile); err != nil {
+		return err
+	}
+	logging.Success("✓ Added the %s configure, build and test presets", name)
+	logging.Info("  Build with it: cpx preset use %s", name)
+	return nil
+}
+
+func listPresets() error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	list := presets.List()
+	if output.JSON() {
+		if list == nil {
+			list = []build.PresetInfo{}
+		}
+		return output.Print(list)
+	}
+
+	active, err := build.ActivePreset()
+	if err != nil {
+		return err
+	}
+	for _, info := range list {
+		marker := " "
+		if info.Kind == build.PresetConfigure && info.Name == active {
+			marker = Green + "*" + Reset
+		}
+		detail := info.Description
+		switch {
+		case info.Hidden:
+			detail = strings.TrimSpace("hidden " + detail)
+		case info.ConfigurePreset != "" && info.ConfigurePreset != info.Name:
+			detail = strings.TrimSpace("uses " + info.ConfigurePreset + " " + detail)
+		}
+		fmt.Printf("%s %-9s %-16s %s%s%s\n", marker, info.Kind, info.Name, Dim, detail, Reset)
+	}
+	fmt.Printf("\n%s* used by cpx build%s\n", Dim, Reset)
+	return nil
+}
+
+func removePreset(name string) error {
+	if name == build.DefaultPreset {
+		return fmt.Errorf("cannot remove the %s preset: cpx configures the other presets with it\n  hint: edit %s to change it", name, build.PresetsFile)
+	}
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	if inheritors := presets.Inheritors(name); len(inheritors) > 0 {
+		return fmt.Errorf("presets %s inherit from %s\n  hint: remove them first", strings.Join(inheritors, ", "), name)
+	}
+	if !presets.Remove(name) {
+		return fmt.Errorf("preset %q not found\n  hint: list the presets with 'cpx preset list'", name)
+	}
+	if err := presets.Save(build.PresetsFile); err != nil {
+		return err
+	}
+	logging.Success("✓ Removed the %s presets", name)
+
+	if active, err := build.ActivePreset(); err == nil && active == name {
+		if err := config.SaveProjectPreset(config.ProjectFile, build.DefaultPreset); err != nil {
+			return err
+		}
+		logging.Notice("cpx builds with the %s preset again", build.DefaultPreset)
+	}
+	return nil
+}
+
+func usePreset(name string) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	if !presets.HasConfigure(name) {
+		return fmt.Errorf("configure preset %q not found\n  hint: list the presets with 'cpx preset list'", name)
+	}
+	if _, err := os.Stat(config.ProjectFile); os.IsNotExist(err) {
+		if err := os.WriteFile(config.ProjectFile, nil, 0644); err != nil {
+			return err
+		}
+	}
+	if err := config.SaveProjectPreset(config.ProjectFile, name); err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	logging.Success("✓ cpx builds with the %s preset", name)
+	return nil
+}
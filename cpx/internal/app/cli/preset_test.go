@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runPresetCmd(args ...string) error {
+	cmd := PresetCmd()
+	cmd.SetArgs(args)
+	return cmd.Execute()
+}
+
+func TestPresetCommands(t *testing.T) {
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	assert.Equal(t, exitcode.Config, exitcode.Of(runPresetCmd("list")))
+
+	require.NoError(t, os.WriteFile(build.PresetsFile, []byte(templates.GenerateCMakePresets()), 0644))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app"}`), 0644))
+	require.NoError(t, runPresetCmd("add", "asan", "--sanitizer", "asan"))
+	require.NoError(t, runPresetCmd("add", "cross-arm64", "--compiler", "gcc-13", "--toolchain-file", "cmake/arm64.cmake", "-D", "BUILD_TESTING=OFF"))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runPresetCmd("add", "asan")))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runPresetCmd("add", "tsan", "--sanitizer", "leak")))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runPresetCmd("add", "bad", "-D", "NOVALUE")))
+
+	data, err := os.ReadFile(build.PresetsFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"CMAKE_CXX_FLAGS": "-fsanitize=address -fno-omit-frame-pointer"`)
+	assert.Contains(t, string(data), `"CMAKE_CXX_COMPILER": "g++-13"`)
+	assert.Contains(t, string(data), `"VCPKG_CHAINLOAD_TOOLCHAIN_FILE": "${sourceDir}/cmake/arm64.cmake"`)
+	assert.Contains(t, string(data), `"BUILD_TESTING": "OFF"`)
+
+	require.NoError(t, runPresetCmd("use", "asan"))
+	active, err := build.ActivePreset()
+	require.NoError(t, err)
+	assert.Equal(t, "asan", active)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runPresetCmd("use", "missing")))
+
+	// Removing the preset in use goes back to the default one
+	require.NoError(t, runPresetCmd("remove", "asan"))
+	cfg, err := config.LoadProject(config.ProjectFile)
+	require.NoError(t, err)
+	assert.Equal(t, build.DefaultPreset, cfg.Build.Preset)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runPresetCmd("remove", "asan")))
+	assert.Equal(t, exitcode.Usage, exitcode.Of(runPresetCmd("remove", build.DefaultPreset)))
+
+	presets, err := build.LoadPresets(build.PresetsFile)
+	require.NoError(t, err)
+	assert.Len(t, presets.List(), 4, "the default configure preset and the cross-arm64 presets")
+}
@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/naming"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// VcpkgConfigurationFile holds the registries of a vcpkg project
+const VcpkgConfigurationFile = "vcpkg-configuration.json"
+
+const vcpkgConfigurationSchema = "https://raw.githubusercontent.com/microsoft/vcpkg-tool/main/docs/vcpkg-configuration.schema.json"
+
+// registryNameKey stores the cpx name of a registry. vcpkg ignores fields
+// starting with $.
+const registryNameKey = "$name"
+
+// RegistryCmd creates the registry command
+func RegistryCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Manage the vcpkg registries of the project",
+		Long: `Manage the git registries in ` + VcpkgConfigurationFile + `, such as a team's
+private ports. cpx add looks dependencies up in these registries first and
+lists the ports it finds under the registry's packages.`,
+	}
+
+	addCmd := &cobra.Command{
+		Use:   "add <name> <git-url>",
+		Short: "Add a git registry",
+		Long: `Add a git registry to ` + VcpkgConfigurationFile + `, creating the file if needed.
+The baseline is the registry commit that versions resolve against; it
+defaults to the commit of the registry's default branch.`,
+		Example: `  cpx registry add acme https://github.com/acme/vcpkg-registry.git
+  cpx registry add acme git@github.com:acme/vcpkg-registry.git --baseline 1a2b3c...`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseline, _ := cmd.Flags().GetString("baseline")
+			return addRegistry(args[0], args[1], baseline)
+		},
+	}
+	addCmd.Flags().String("baseline", "", "Registry commit to use (default: the commit of its default branch)")
+	cmd.AddCommand(addCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the registries of the project",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listRegistries()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a registry",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return removeRegistry(args[0])
+		},
+	})
+
+	return cmd
+}
+
+// loadVcpkgConfiguration reads vcpkg-configuration.json; a missing file is
+// an empty configuration
+func loadVcpkgConfiguration() (map[string]any, error) {
+	if data, err := os.ReadFile("vcpkg.json"); err == nil {
+		var manifest map[string]any
+		if json.Unmarshal(data, &manifest) == nil {
+			if _, ok := manifest["vcpkg-configuration"]; ok {
+				return nil, exitcode.Errorf(exitcode.Config, "vcpkg.json embeds its vcpkg-configuration\n  hint: move it to %s to manage registries with cpx", VcpkgConfigurationFile)
+			}
+		}
+	}
+
+	data, err := os.ReadFile(VcpkgConfigurationFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]any{"$schema": vcpkgConfigurationSchema}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg map[string]any
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, exitcode.Errorf(exitcode.Config, "failed to parse %s: %v", VcpkgConfigurationFile, err)
+	}
+	return cfg, nil
+}
+
+func saveVcpkgConfiguration(cfg map[string]any) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", VcpkgConfigurationFile, err)
+	}
+	if err := os.WriteFile(VcpkgConfigurationFile, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", VcpkgConfigurationFile, err)
+	}
+	return nil
+}
+
+// configuredRegistries returns the registries of cfg; entries that aren't
+// objects are left out
+func configuredRegistries(cfg map[string]any) []map[string]any {
+	list, _ := cfg["registries"].([]any)
+	var registries []map[string]any
+	for _, r := range list {
+		if registry, ok := r.(map[string]any); ok {
+			registries = append(registries, registry)
+		}
+	}
+	return registries
+}
+
+// registryName returns the cpx name of a registry, or the name derived from
+// its repository for registries added by hand
+func registryName(registry map[string]any) string {
+	if name, ok := registry[registryNameKey].(string); ok && name != "" {
+		return name
+	}
+	repository, _ := registry["repository"].(string)
+	return templateRepoName(repository)
+}
+
+func registryPackages(registry map[string]any) []string {
+	list, _ := registry["packages"].([]any)
+	var packages []string
+	for _, p := range list {
+		if name, ok := p.(string); ok {
+			packages = append(packages, name)
+		}
+	}
+	return packages
+}
+
+func addRegistry(name, url, baseline string) error {
+	if _, err := RequireProject("cpx registry add"); err != nil {
+		return err
+	}
+	if DetectProjectType() != ProjectTypeVcpkg {
+		return exitcode.Errorf(exitcode.Config, "registries are for vcpkg projects (no vcpkg.json found)")
+	}
+	if !naming.IsValidProjectName(name) {
+		return exitcode.Errorf(exitcode.Usage, "invalid registry name %q\n  hint: use letters, numbers, hyphens and underscores", name)
+	}
+
+	cfg, err := loadVcpkgConfiguration()
+	if err != nil {
+		return err
+	}
+	registries := configuredRegistries(cfg)
+	for _, registry := range registries {
+		if registryName(registry) == name {
+			return exitcode.Errorf(exitcode.Usage, "registry %s already exists\n  hint: remove it first with 'cpx registry remove %s'", name, name)
+		}
+	}
+
+	if baseline == "" {
+		if baseline, err = resolveGitRevision(url, "HEAD"); err != nil {
+			return err
+		}
+	}
+
+	registry := map[string]any{
+		registryNameKey: name,
+		"kind":          "git",
+		"repository":    url,
+		"baseline":      baseline,
+		"packages":      []any{},
+	}
+	list, _ := cfg["registries"].([]any)
+	cfg["registries"] = append(list, registry)
+	if err := saveVcpkgConfiguration(cfg); err != nil {
+		return err
+	}
+
+	logging.Success("%s Added registry %s (baseline %s)", IconSuccess, name, baseline)
+	fmt.Printf("  cpx add <port> now looks in %s first\n", name)
+	return nil
+}
+
+func listRegistries() error {
+	cfg, err := loadVcpkgConfiguration()
+	if err != nil {
+		return err
+	}
+	registries := configuredRegistries(cfg)
+	if len(registries) == 0 {
+		fmt.Printf("%sNo registries; add one with: cpx registry add <name> <git-url>%s\n", Dim, Reset)
+		return nil
+	}
+	for _, registry := range registries {
+		kind, _ := registry["kind"].(string)
+		location, _ := registry["repository"].(string)
+		if location == "" {
+			location, _ = registry["path"].(string)
+		}
+		fmt.Printf("%s%s%s %s(%s %s)%s\n", Bold, registryName(registry), Reset, Dim, kind, location, Reset)
+		if baseline, _ := registry["baseline"].(string); baseline != "" {
+			fmt.Printf("  baseline %s\n", baseline)
+		}
+		if packages := registryPackages(registry); len(packages) > 0 {
+			fmt.Printf("  packages %v\n", packages)
+		}
+	}
+	return nil
+}
+
+func removeRegistry(name string) error {
+	cfg, err := loadVcpkgConfiguration()
+	if err != nil {
+		return err
+	}
+	list, _ := cfg["registries"].([]any)
+	kept := []any{}
+	for _, r := range list {
+		if registry, ok := r.(map[string]any); ok && registryName(registry) == name {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	if len(kept) == len(list) {
+		return exitcode.Errorf(exitcode.Usage, "no registry named %s\n  hint: see the registries with 'cpx registry list'", name)
+	}
+	cfg["registries"] = kept
+	if err := saveVcpkgConfiguration(cfg); err != nil {
+		return err
+	}
+	logging.Success("%s Removed registry %s", IconSuccess, name)
+	return nil
+}
+
+// registryCacheDir returns the directory of the cached clone of a registry
+// repository. It is keyed by URL, as registry names are per project.
+func registryCacheDir(url string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(configDir, "registries", hex.EncodeToString(sum[:8])), nil
+}
+
+// registryBaselinePorts returns the ports of a git registry at its
+// baseline, from versions/baseline.json of a cached partial clone
+func registryBaselinePorts(url, baseline string) (map[string]bool, error) {
+	dir, err := registryCacheDir(url)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if err := offline.Required("cloning registry " + url); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return nil, err
+		}
+		// Blobs are fetched on demand; only versions/baseline.json is read
+		if out, err := execCommand("git", "clone", "--quiet", "--filter=blob:none", "--no-checkout", url, dir).CombinedOutput(); err != nil {
+			os.RemoveAll(dir)
+			return nil, fmt.Errorf("failed to clone registry %s: %w\n%s", url, err, out)
+		}
+	}
+
+	show := func() ([]byte, error) {
+		return execCommand("git", "-C", dir, "show", baseline+":versions/baseline.json").Output()
+	}
+	out, err := show()
+	if err != nil {
+		// The baseline may be newer than the cached clone
+		if err := offline.Required("fetching registry " + url); err != nil {
+			return nil, err
+		}
+		if fetchOut, err := execCommand("git", "-C", dir, "fetch", "--quiet", "origin").CombinedOutput(); err != nil {
+			return nil, fmt.Errorf("failed to fetch registry %s: %w\n%s", url, err, fetchOut)
+		}
+		if out, err = show(); err != nil {
+			return nil, exitcode.Errorf(exitcode.Config, "registry %s has no versions/baseline.json at baseline %s", url, baseline)
+		}
+	}
+
+	var versions vcpkgBaseline
+	if err := json.Unmarshal(out, &versions); err != nil {
+		return nil, fmt.Errorf("failed to parse versions/baseline.json of %s: %w", url, err)
+	}
+	ports := make(map[string]bool)
+	for name := range versions.Default {
+		ports[name] = true
+	}
+	return ports, nil
+}
+
+// assignRegistryPorts adds each of ports that a git registry of
+// vcpkg-configuration.json provides to the registry's packages, so that
+// vcpkg resolves it from there. Ports listed by a registry already are left
+// alone.
+func assignRegistryPorts(ports []string) error {
+	if _, err := os.Stat(VcpkgConfigurationFile); err != nil {
+		return nil
+	}
+	cfg, err := loadVcpkgConfiguration()
+	if err != nil {
+		return err
+	}
+	registries := configuredRegistries(cfg)
+
+	changed := false
+	for _, port := range ports {
+		listed := false
+		for _, registry := range registries {
+			if slices.Contains(registryPackages(registry), port) {
+				listed = true
+				break
+			}
+		}
+		if listed {
+			continue
+		}
+
+		for _, registry := range registries {
+			kind, _ := registry["kind"].(string)
+			url, _ := registry["repository"].(string)
+			baseline, _ := registry["baseline"].(string)
+			if kind != "git" || url == "" || baseline == "" {
+				continue
+			}
+			available, err := registryBaselinePorts(url, baseline)
+			if err != nil {
+				return err
+			}
+			if !available[port] {
+				continue
+			}
+			packages, _ := registry["packages"].([]any)
+			registry["packages"] = append(packages, port)
+			changed = true
+			logging.Step("Using registry %s for %s", registryName(registry), port)
+			break
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+	return saveVcpkgConfiguration(cfg)
+}
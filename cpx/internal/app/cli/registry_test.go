@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readVcpkgConfiguration(t *testing.T) map[string]any {
+	t.Helper()
+	data, err := os.ReadFile(VcpkgConfigurationFile)
+	require.NoError(t, err)
+	var cfg map[string]any
+	require.NoError(t, json.Unmarshal(data, &cfg))
+	return cfg
+}
+
+func TestRegistry(t *testing.T) {
+	registryDir := t.TempDir()
+	baseline := gitCommitFiles(t, registryDir, map[string]string{
+		"versions/baseline.json": `{"default": {"acme-log": {"baseline": "1.0.0", "port-version": 0}}}`,
+	})
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "dependencies": []}`), 0644))
+
+	// Without --baseline the registry's latest commit is used
+	require.NoError(t, addRegistry("acme", registryDir, ""))
+	cfg := readVcpkgConfiguration(t)
+	assert.Equal(t, vcpkgConfigurationSchema, cfg["$schema"])
+	registries := configuredRegistries(cfg)
+	require.Len(t, registries, 1)
+	assert.Equal(t, "acme", registries[0][registryNameKey])
+	assert.Equal(t, "git", registries[0]["kind"])
+	assert.Equal(t, baseline, registries[0]["baseline"])
+
+	err = addRegistry("acme", registryDir, baseline)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	err = addRegistry("bad name", registryDir, baseline)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+
+	// Only ports the registry provides are assigned to it
+	require.NoError(t, assignRegistryPorts([]string{"acme-log", "fmt"}))
+	registries = configuredRegistries(readVcpkgConfiguration(t))
+	assert.Equal(t, []string{"acme-log"}, registryPackages(registries[0]))
+	require.NoError(t, assignRegistryPorts([]string{"acme-log"}))
+	registries = configuredRegistries(readVcpkgConfiguration(t))
+	assert.Equal(t, []string{"acme-log"}, registryPackages(registries[0]))
+
+	// A newer baseline is fetched into the cached clone
+	newer := gitCommitFiles(t, registryDir, map[string]string{
+		"versions/baseline.json": `{"default": {"acme-log": {"baseline": "1.0.0", "port-version": 0}, "acme-net": {"baseline": "2.0.0", "port-version": 0}}}`,
+	})
+	ports, err := registryBaselinePorts(registryDir, newer)
+	require.NoError(t, err)
+	assert.True(t, ports["acme-net"])
+
+	// Offline, the cached clone still answers for known baselines
+	t.Setenv(offline.EnvVar, "1")
+	ports, err = registryBaselinePorts(registryDir, baseline)
+	require.NoError(t, err)
+	assert.False(t, ports["acme-net"])
+	t.Setenv(offline.EnvVar, "")
+
+	require.NoError(t, removeRegistry("acme"))
+	assert.Empty(t, configuredRegistries(readVcpkgConfiguration(t)))
+	err = removeRegistry("acme")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestRegistryEmbeddedConfiguration(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"name": "app", "vcpkg-configuration": {"registries": []}}`), 0644))
+
+	err = addRegistry("acme", "https://example.com/registry.git", "0123abcd")
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+	assert.NoFileExists(t, VcpkgConfigurationFile)
+}
@@ -1,10 +1,15 @@
 package cli
 
 import (
-	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
@@ -12,117 +17,287 @@ import (
 // RemoveCmd creates the remove command
 func RemoveCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "remove",
-		Short:   "Remove a dependency",
-		Long:    "Remove a dependency. Passes through to vcpkg remove command.",
-		Aliases: []string{"rm"},
+		Use:   "remove [pkg...]",
+		Short: "Remove a dependency",
+		Long: `Remove a dependency. Passes through to vcpkg remove command.
+
+--unused removes the vcpkg.json dependencies nothing in the project refers
+to: no find_package call in the CMake files and no #include in the sources.
+Review them first with --dry-run.`,
+		Example: `  cpx remove fmt
+  cpx remove --unused --dry-run`,
+		Aliases:           []string{"rm"},
+		ValidArgsFunction: completeDependencies,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runRemove(cmd, args, client)
 		},
-		Args: cobra.MinimumNArgs(1),
 	}
+	cmd.Flags().Bool("unused", false, "Remove the vcpkg.json dependencies the project doesn't use")
+	cmd.Flags().Bool("dry-run", false, "With --unused, only list the dependencies that would be removed")
 
 	return cmd
 }
 
-func runRemove(_ *cobra.Command, args []string, client *vcpkg.Client) error {
+func runRemove(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
+	unused, _ := cmd.Flags().GetBool("unused")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if unused {
+		if len(args) > 0 {
+			return exitcode.Errorf(exitcode.Usage, "--unused takes no packages")
+		}
+		return runRemoveUnused(client, dryRun)
+	}
+	if dryRun {
+		return exitcode.Errorf(exitcode.Usage, "--dry-run only applies to --unused")
+	}
 	if len(args) == 0 {
-		return fmt.Errorf("argument required (pkg1 pkg2 ...)")
+		return exitcode.Errorf(exitcode.Usage, "argument required (pkg1 pkg2 ...)")
 	}
 
 	// Check for vcpkg.json (Manifest mode)
 	if _, err := os.Stat("vcpkg.json"); err == nil {
-		fmt.Printf("%sDetecting manifest mode (vcpkg.json)...%s\n", Green, Reset)
-
-		// Read manifest
-		data, err := os.ReadFile("vcpkg.json")
+		logging.Step("Detecting manifest mode (vcpkg.json)...")
+		removedCount, err := removeVcpkgDependencies(args)
 		if err != nil {
-			return fmt.Errorf("failed to read vcpkg.json: %w", err)
+			return err
+		}
+		if removedCount == 0 {
+			logging.Notice("No matching dependencies found to remove.")
+			return nil
 		}
+		logging.Success("Successfully removed %d dependency(ies)", removedCount)
+		fmt.Printf("Run 'cpx install' or 'cpx build' to update installed packages.\n")
+		return nil
+	}
+
+	// Legacy mode (Classic mode)
+	// Directly pass all arguments to vcpkg remove command
+	// cpx remove <args> -> vcpkg remove <args>
+	vcpkgArgs := []string{"remove"}
+	vcpkgArgs = append(vcpkgArgs, args...)
+
+	if client == nil {
+		return fmt.Errorf("vcpkg client not initialized")
+	}
+	return client.RunCommand(vcpkgArgs)
+}
 
-		// Define manifest structure dynamically
-		var manifest map[string]interface{}
-		if err := json.Unmarshal(data, &manifest); err != nil {
-			return fmt.Errorf("failed to parse vcpkg.json: %w", err)
+// dependencyName returns the name of a vcpkg.json dependency, which is a
+// port name or an object with a name
+func dependencyName(dep any) string {
+	if str, ok := dep.(string); ok {
+		return str
+	}
+	if obj, ok := dep.(map[string]any); ok {
+		name, _ := obj["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// removeVcpkgDependencies removes the dependencies called names from
+// vcpkg.json and returns how many it removed
+func removeVcpkgDependencies(names []string) (int, error) {
+	manifest, err := vcpkg.ReadManifest("vcpkg.json")
+	if err != nil {
+		return 0, err
+	}
+
+	deps, ok := manifest["dependencies"]
+	if !ok {
+		logging.Notice("No dependencies found in vcpkg.json")
+		return 0, nil
+	}
+	depList, ok := deps.([]any)
+	if !ok {
+		return 0, exitcode.Errorf(exitcode.Config, "invalid dependencies format in vcpkg.json")
+	}
+
+	newDeps := make([]any, 0, len(depList))
+	removedCount := 0
+	for _, dep := range depList {
+		depName := dependencyName(dep)
+		shouldRemove := false
+		for _, name := range names {
+			if depName == name {
+				shouldRemove = true
+				logging.Step("Removing %s from vcpkg.json...", depName)
+				removedCount++
+				break
+			}
 		}
+		if !shouldRemove {
+			newDeps = append(newDeps, dep)
+		}
+	}
+	if removedCount == 0 {
+		return 0, nil
+	}
 
-		// Get dependencies
-		deps, ok := manifest["dependencies"]
-		if !ok {
-			fmt.Printf("%sNo dependencies found in vcpkg.json%s\n", Yellow, Reset)
-			return nil
+	manifest["dependencies"] = newDeps
+	if err := vcpkg.WriteManifest("vcpkg.json", manifest); err != nil {
+		return 0, err
+	}
+	return removedCount, nil
+}
+
+func runRemoveUnused(client *vcpkg.Client, dryRun bool) error {
+	if DetectProjectType() != ProjectTypeVcpkg {
+		return exitcode.Errorf(exitcode.Config, "--unused needs a vcpkg project (no vcpkg.json found)")
+	}
+
+	// The usage notes of the ports name their CMake packages
+	vcpkgRoot := ""
+	if client != nil {
+		if vcpkgPath, err := client.GetPath(); err == nil {
+			vcpkgRoot = filepath.Dir(vcpkgPath)
 		}
+	}
+
+	unused, err := findUnusedDependencies(".", vcpkgRoot)
+	if err != nil {
+		return err
+	}
+	if len(unused) == 0 {
+		logging.Success("%s Every dependency is used", IconSuccess)
+		return nil
+	}
 
-		// Convert dependencies to slice of interface{} to handle mix of strings and objects
-		depList, ok := deps.([]interface{})
-		if !ok {
-			return fmt.Errorf("invalid dependencies format in vcpkg.json")
+	if dryRun {
+		fmt.Printf("%sUnused dependencies (nothing calls find_package for or includes them):%s\n", Bold, Reset)
+		for _, name := range unused {
+			fmt.Printf("  %s\n", name)
 		}
+		fmt.Printf("%sRun 'cpx remove --unused' to remove them%s\n", Dim, Reset)
+		return nil
+	}
 
-		// Process removals
-		newDeps := make([]interface{}, 0, len(depList))
-		removedCount := 0
+	removedCount, err := removeVcpkgDependencies(unused)
+	if err != nil {
+		return err
+	}
+	logging.Success("Successfully removed %d unused dependency(ies)", removedCount)
+	fmt.Printf("Run 'cpx install' or 'cpx build' to update installed packages.\n")
+	return nil
+}
 
-		for _, dep := range depList {
-			depName := ""
+var (
+	findPackageRe = regexp.MustCompile(`(?i)\bfind_package\s*\(\s*([A-Za-z0-9_.+-]+)`)
+	includeRe     = regexp.MustCompile(`(?m)^\s*#\s*include\s*[<"]([^>"]+)[>"]`)
+)
 
-			// Handle string dependency: "fmt"
-			if str, ok := dep.(string); ok {
-				depName = str
-			} else if obj, ok := dep.(map[string]interface{}); ok {
-				// Handle object dependency: { "name": "fmt", ... }
-				if name, ok := obj["name"].(string); ok {
-					depName = name
-				}
-			}
+// sourceExtensions are the files scanned for #include
+var sourceExtensions = map[string]bool{
+	".c": true, ".cc": true, ".cpp": true, ".cxx": true, ".c++": true, ".cppm": true, ".ixx": true,
+	".h": true, ".hh": true, ".hpp": true, ".hxx": true, ".h++": true, ".ipp": true, ".inl": true, ".tpp": true,
+}
 
-			// Check if this dependency should be removed
-			shouldRemove := false
-			for _, arg := range args {
-				if depName == arg {
-					shouldRemove = true
-					fmt.Printf("%sRemoving %s from vcpkg.json...%s\n", Green, depName, Reset)
-					removedCount++
-					break
-				}
-			}
+// dependencyKey normalizes a port, CMake package or include name for
+// comparison: nlohmann-json, nlohmann_json and NLOHMANN.JSON are the same
+func dependencyKey(name string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "").Replace(strings.ToLower(name))
+}
 
-			if !shouldRemove {
-				newDeps = append(newDeps, dep)
-			}
+// dependencyKeys returns the names a port may be referred to by: the port
+// and its first component (boost for boost-filesystem, nlohmann for
+// nlohmann-json), with or without a lib prefix (png for libpng)
+func dependencyKeys(port string) []string {
+	first, _, _ := strings.Cut(port, "-")
+	var keys []string
+	for _, name := range []string{port, first} {
+		key := dependencyKey(name)
+		keys = append(keys, key)
+		if trimmed, ok := strings.CutPrefix(key, "lib"); ok && trimmed != "" {
+			keys = append(keys, trimmed)
 		}
+	}
+	return keys
+}
 
-		if removedCount == 0 {
-			fmt.Printf("%sNo matching dependencies found to remove.%s\n", Yellow, Reset)
+// projectReferences returns the CMake packages the project's CMake files
+// find and the headers its sources include, as dependency keys: the first
+// directory of includes like <fmt/core.h>, the name of includes like <zlib.h>
+func projectReferences(root string) (map[string]bool, error) {
+	refs := make(map[string]bool)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (renameSkipDirs[name] || strings.HasPrefix(name, "bazel-") || strings.HasPrefix(name, ".bazel-")) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
-		// Update manifest
-		manifest["dependencies"] = newDeps
-
-		// Write back
-		newData, err := json.MarshalIndent(manifest, "", "  ")
+		ext := strings.ToLower(filepath.Ext(name))
+		isCMake := name == "CMakeLists.txt" || ext == ".cmake"
+		if !isCMake && !sourceExtensions[ext] {
+			return nil
+		}
+		data, err := os.ReadFile(path)
 		if err != nil {
-			return fmt.Errorf("failed to encode vcpkg.json: %w", err)
+			return err
 		}
-
-		if err := os.WriteFile("vcpkg.json", newData, 0644); err != nil {
-			return fmt.Errorf("failed to write vcpkg.json: %w", err)
+		if isCMake {
+			for _, m := range findPackageRe.FindAllSubmatch(data, -1) {
+				refs[dependencyKey(string(m[1]))] = true
+			}
+			return nil
+		}
+		for _, m := range includeRe.FindAllSubmatch(data, -1) {
+			header := string(m[1])
+			if dir, _, found := strings.Cut(header, "/"); found {
+				refs[dependencyKey(dir)] = true
+			} else {
+				refs[dependencyKey(strings.TrimSuffix(header, filepath.Ext(header)))] = true
+			}
 		}
-
-		fmt.Printf("%sSuccessfully removed %d dependency(ies)%s\n", Green, removedCount, Reset)
-		fmt.Printf("Run 'cpx install' or 'cpx build' to update installed packages.\n")
 		return nil
+	})
+	return refs, err
+}
+
+// findUnusedDependencies returns the vcpkg.json dependencies of the project
+// in root that none of its CMake files and sources refer to. The usage notes
+// of the ports in vcpkgRoot, if given, add the CMake packages they provide.
+// Host dependencies (build tools) are never reported.
+func findUnusedDependencies(root, vcpkgRoot string) ([]string, error) {
+	manifest, err := vcpkg.ReadManifest(filepath.Join(root, "vcpkg.json"))
+	if err != nil {
+		return nil, err
+	}
+	refs, err := projectReferences(root)
+	if err != nil {
+		return nil, err
 	}
 
-	// Legacy mode (Classic mode)
-	// Directly pass all arguments to vcpkg remove command
-	// cpx remove <args> -> vcpkg remove <args>
-	vcpkgArgs := []string{"remove"}
-	vcpkgArgs = append(vcpkgArgs, args...)
+	deps, _ := manifest["dependencies"].([]any)
+	var unused []string
+	for _, dep := range deps {
+		name := dependencyName(dep)
+		if obj, ok := dep.(map[string]any); ok && obj["host"] == true {
+			continue
+		}
+		if name == "" || strings.HasPrefix(name, "vcpkg-") {
+			continue
+		}
 
-	if client == nil {
-		return fmt.Errorf("vcpkg client not initialized")
+		used := false
+		for _, key := range dependencyKeys(name) {
+			used = used || refs[key]
+		}
+		if !used && vcpkgRoot != "" {
+			if usage, err := os.ReadFile(filepath.Join(vcpkgRoot, "ports", name, "usage")); err == nil {
+				for _, m := range findPackageRe.FindAllSubmatch(usage, -1) {
+					used = used || refs[dependencyKey(string(m[1]))]
+				}
+			}
+		}
+		if !used {
+			unused = append(unused, name)
+		}
 	}
-	return client.RunCommand(vcpkgArgs)
+	return unused, nil
 }
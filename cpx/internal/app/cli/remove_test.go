@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"os"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindUnusedDependencies(t *testing.T) {
+	vcpkgRoot := t.TempDir()
+	writeFiles(t, vcpkgRoot, map[string]string{
+		"ports/abseil/usage": "abseil provides CMake targets:\n\n  find_package(absl CONFIG REQUIRED)\n",
+	})
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"vcpkg.json": `{"name": "app", "dependencies": ["fmt", "nlohmann-json", "boost-filesystem", "libpng", "zlib", "abseil", "spdlog",
+  {"name": "vcpkg-cmake", "host": true}, {"name": "protobuf", "host": true}]}`,
+		"CMakeLists.txt":        "find_package(fmt CONFIG REQUIRED)\nFIND_PACKAGE(Boost REQUIRED COMPONENTS filesystem)\n",
+		"cmake/deps.cmake":      "find_package(absl CONFIG REQUIRED)\n",
+		"src/main.cpp":          "#include <nlohmann/json.hpp>\n#include <vector>\n",
+		"include/app/image.hpp": "#  include \"png.h\"\n",
+		// Build output isn't scanned
+		".cache/native/gen.cpp": "#include <spdlog/spdlog.h>\n",
+		"src/notes.txt":         "#include <zlib.h>\n",
+	})
+
+	unused, err := findUnusedDependencies(root, vcpkgRoot)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zlib", "spdlog"}, unused)
+
+	// Without the usage notes abseil can't be matched to absl
+	unused, err = findUnusedDependencies(root, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zlib", "abseil", "spdlog"}, unused)
+}
+
+func TestRemoveUnused(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	writeFiles(t, tmpDir, map[string]string{
+		"vcpkg.json":   `{"name": "app", "dependencies": ["fmt", {"name": "zlib", "features": []}]}`,
+		"src/main.cpp": "#include <fmt/core.h>\n",
+	})
+
+	cmd := RemoveCmd(nil)
+	cmd.SetArgs([]string{"--unused", "--dry-run"})
+	require.NoError(t, cmd.Execute())
+	manifest, err := vcpkg.ReadManifest("vcpkg.json")
+	require.NoError(t, err)
+	assert.Len(t, manifest["dependencies"], 2)
+
+	cmd = RemoveCmd(nil)
+	cmd.SetArgs([]string{"--unused"})
+	require.NoError(t, cmd.Execute())
+	manifest, err = vcpkg.ReadManifest("vcpkg.json")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"fmt"}, manifest["dependencies"])
+
+	cmd = RemoveCmd(nil)
+	cmd.SetArgs([]string{"--unused", "fmt"})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+	cmd = RemoveCmd(nil)
+	cmd.SetArgs([]string{})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/naming"
+	"github.com/spf13/cobra"
+)
+
+// RenameCmd creates the rename command
+func RenameCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rename <new-name>",
+		Short: "Rename the project across sources and build files",
+		Long: `Rename the project: the include directory, namespace, CMake/Bazel/Meson
+target names, vcpkg.json name, include guards and version macros.
+
+The current name is read from CMakeLists.txt, MODULE.bazel or meson.build.
+Use --dry-run to review the changes as a diff before applying them.`,
+		Example: `  cpx rename mylib --dry-run   # Show what would change
+  cpx rename mylib             # Apply the rename
+  cpx rename mylib --from app  # Override the detected current name`,
+		Args: cobra.ExactArgs(1),
+		RunE: runRename,
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Print the changes as a diff without applying them")
+	cmd.Flags().String("from", "", "Current project name (detected from the build files by default)")
+
+	return cmd
+}
+
+// renameEdit is a file whose content changes
+type renameEdit struct {
+	Path    string
+	Old     string
+	Updated string
+}
+
+// renameMove is a file whose path changes
+type renameMove struct {
+	From string
+	To   string
+}
+
+// renamePlan lists the changes a rename makes, with paths relative to the project root
+type renamePlan struct {
+	Edits []renameEdit
+	Moves []renameMove
+}
+
+// renameSkipDirs are build output and dependency directories left untouched
+var renameSkipDirs = map[string]bool{
+	".git":            true,
+	".cache":          true,
+	".bin":            true,
+	"builddir":        true,
+	"subprojects":     true,
+	"vcpkg_installed": true,
+	"out":             true,
+}
+
+// renameMaxFileSize skips large (likely generated or binary) files
+const renameMaxFileSize = 1 << 20
+
+func runRename(cmd *cobra.Command, args []string) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	oldName, _ := cmd.Flags().GetString("from")
+	newName := args[0]
+
+	if !naming.IsValidProjectName(newName) {
+		return exitcode.Errorf(exitcode.Usage, "invalid project name %q: use letters, digits, '-' and '_'", newName)
+	}
+	if oldName == "" {
+		oldName = detectProjectName()
+		if oldName == "" {
+			return exitcode.Errorf(exitcode.Config, "could not detect the current project name\n  hint: pass it with --from <name>")
+		}
+	}
+	if oldName == newName {
+		return fmt.Errorf("project is already named '%s'", newName)
+	}
+
+	plan, err := planRename(".", oldName, newName)
+	if err != nil {
+		return err
+	}
+	if len(plan.Edits) == 0 && len(plan.Moves) == 0 {
+		logging.Notice("No references to '%s' found", oldName)
+		return nil
+	}
+
+	if dryRun {
+		printRenamePlan(plan)
+		fmt.Printf("\n%sDry run: %d files would change, %d would move%s\n", Dim, len(plan.Edits), len(plan.Moves), Reset)
+		return nil
+	}
+
+	if err := applyRename(".", plan); err != nil {
+		return err
+	}
+
+	logging.Success("✓ Renamed '%s' to '%s' (%d files changed, %d moved)", oldName, newName, len(plan.Edits), len(plan.Moves))
+	fmt.Printf("  Run 'cpx clean' so the next build doesn't reuse stale targets\n")
+	return nil
+}
+
+// detectProjectName reads the project name from the build files in the
+// current directory. Returns "" if none is found.
+func detectProjectName() string {
+	if name := build.GetProjectNameFromCMakeLists(); name != "" {
+		return name
+	}
+	patterns := map[string]*regexp.Regexp{
+		"MODULE.bazel": regexp.MustCompile(`module\(\s*name\s*=\s*"([^"]+)"`),
+		"meson.build":  regexp.MustCompile(`project\(\s*'([^']+)'`),
+	}
+	for _, file := range []string{"MODULE.bazel", "meson.build"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if m := patterns[file].FindSubmatch(data); m != nil {
+			return string(m[1])
+		}
+	}
+	return ""
+}
+
+// planRename finds the content changes and file moves renaming oldName to
+// newName under root
+func planRename(root, oldName, newName string) (*renamePlan, error) {
+	reps := naming.RenameReplacements(oldName, newName)
+	plan := &renamePlan{}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (renameSkipDirs[name] || strings.HasPrefix(name, "bazel-") || strings.HasPrefix(name, ".bazel-")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if moved := renamePath(rel, reps); moved != rel {
+			plan.Moves = append(plan.Moves, renameMove{From: rel, To: moved})
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > renameMaxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			// Binary file
+			return nil
+		}
+		if updated := naming.ReplaceName(string(data), reps); updated != string(data) {
+			plan.Edits = append(plan.Edits, renameEdit{Path: rel, Old: string(data), Updated: updated})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, move := range plan.Moves {
+		if _, err := os.Stat(filepath.Join(root, move.To)); err == nil {
+			return nil, fmt.Errorf("cannot move %s: %s already exists", move.From, move.To)
+		}
+	}
+	return plan, nil
+}
+
+// renamePath applies the replacements to each component of a relative path
+func renamePath(rel string, reps []naming.Replacement) string {
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		parts[i] = naming.ReplaceName(part, reps)
+	}
+	return filepath.Join(parts...)
+}
+
+// applyRename writes the content changes, then moves files and removes the
+// directories left empty
+func applyRename(root string, plan *renamePlan) error {
+	for _, edit := range plan.Edits {
+		path := filepath.Join(root, edit.Path)
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(edit.Updated), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("failed to write %s: %w", edit.Path, err)
+		}
+	}
+
+	oldDirs := make(map[string]bool)
+	for _, move := range plan.Moves {
+		to := filepath.Join(root, move.To)
+		if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(move.To), err)
+		}
+		if err := os.Rename(filepath.Join(root, move.From), to); err != nil {
+			return fmt.Errorf("failed to move %s: %w", move.From, err)
+		}
+		for dir := filepath.Dir(move.From); dir != "."; dir = filepath.Dir(dir) {
+			oldDirs[dir] = true
+		}
+	}
+
+	// Remove emptied directories, deepest first
+	dirs := make([]string, 0, len(oldDirs))
+	for dir := range oldDirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool { return len(dirs[i]) > len(dirs[j]) })
+	for _, dir := range dirs {
+		_ = os.Remove(filepath.Join(root, dir)) // fails if not empty
+	}
+	return nil
+}
+
+// printRenamePlan prints the moves and a line diff of every content change
+func printRenamePlan(plan *renamePlan) {
+	for _, move := range plan.Moves {
+		fmt.Printf("%srename %s → %s%s\n", Cyan, move.From, move.To, Reset)
+	}
+	for _, edit := range plan.Edits {
+		fmt.Printf("\n%s--- a/%s\n+++ b/%s%s\n", Bold, edit.Path, edit.Path, Reset)
+		// Replacements never add or remove lines, so lines pair up
+		oldLines := strings.Split(edit.Old, "\n")
+		newLines := strings.Split(edit.Updated, "\n")
+		for i := range oldLines {
+			if i < len(newLines) && oldLines[i] != newLines[i] {
+				fmt.Printf("%s@@ %d @@%s\n", Dim, i+1, Reset)
+				fmt.Printf("%s-%s%s\n", Red, oldLines[i], Reset)
+				fmt.Printf("%s+%s%s\n", Green, newLines[i], Reset)
+			}
+		}
+	}
+}
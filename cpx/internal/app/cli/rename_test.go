@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles writes files (relative paths to contents) under root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, os.WriteFile(full, []byte(content), 0644))
+	}
+}
+
+func TestPlanAndApplyRename(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"CMakeLists.txt":               "project(my-lib)\nadd_library(my_lib src/my_lib.cpp)\n",
+		"vcpkg.json":                   `{"name": "my-lib"}`,
+		"include/my_lib/my_lib.hpp":    "#ifndef MY_LIB_HPP\n#define MY_LIB_HPP\nnamespace my_lib {}\n#endif\n",
+		"src/my_lib.cpp":               "#include <my_lib/my_lib.hpp>\n",
+		"README.md":                    "Nothing to see\n",
+		".cache/native/debug/my_lib.o": "my_lib",
+	})
+
+	plan, err := planRename(root, "my-lib", "other")
+	require.NoError(t, err)
+
+	edited := make(map[string]bool)
+	for _, e := range plan.Edits {
+		edited[e.Path] = true
+	}
+	assert.True(t, edited["CMakeLists.txt"])
+	assert.True(t, edited["vcpkg.json"])
+	assert.False(t, edited["README.md"])
+	assert.False(t, edited[filepath.Join(".cache", "native", "debug", "my_lib.o")])
+	assert.ElementsMatch(t, []renameMove{
+		{From: filepath.Join("include", "my_lib", "my_lib.hpp"), To: filepath.Join("include", "other", "other.hpp")},
+		{From: filepath.Join("src", "my_lib.cpp"), To: filepath.Join("src", "other.cpp")},
+	}, plan.Moves)
+
+	require.NoError(t, applyRename(root, plan))
+
+	header, err := os.ReadFile(filepath.Join(root, "include", "other", "other.hpp"))
+	require.NoError(t, err)
+	assert.Equal(t, "#ifndef OTHER_HPP\n#define OTHER_HPP\nnamespace other {}\n#endif\n", string(header))
+
+	cmake, err := os.ReadFile(filepath.Join(root, "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "project(other)\nadd_library(other src/other.cpp)\n", string(cmake))
+
+	assert.NoDirExists(t, filepath.Join(root, "include", "my_lib"))
+	assert.NoFileExists(t, filepath.Join(root, "src", "my_lib.cpp"))
+}
+
+func TestPlanRenameConflict(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"src/app.cpp":   "int main() {}\n",
+		"src/other.cpp": "int f() {}\n",
+	})
+
+	_, err := planRename(root, "app", "other")
+	assert.ErrorContains(t, err, "already exists")
+}
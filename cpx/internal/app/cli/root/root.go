@@ -2,8 +2,17 @@ package root
 
 import (
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ozacod/cpx/internal/app/cli"
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/internal/pkg/telemetry"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -17,13 +26,148 @@ Generate, build, lint, test, and ship CMake/vcpkg-based C++ projects with sensib
 	// Don't show usage on errors by default
 	SilenceUsage:  true,
 	SilenceErrors: true, // handle printing ourselves in Execute
+	// cobra's default, which SuggestionsFor doesn't apply itself
+	SuggestionsMinimumDistance: 2,
+	// Commands are looked up before the arguments are validated, so the
+	// arguments left for the root are unknown commands
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return nil
+		}
+		hint := "run 'cpx --help' for the list of commands"
+		if suggestions := cmd.SuggestionsFor(args[0]); len(suggestions) > 0 {
+			hint = "did you mean '" + strings.Join(suggestions, "' or '") + "'?"
+		}
+		return exitcode.Errorf(exitcode.Usage, "unknown command %q for %q\n  hint: %s", args[0], cmd.CommandPath(), hint)
+	},
+	// A runnable root validates its arguments; without a command it prints
+	// the help as before
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return cmd.Help()
+	},
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := setupLogging(cmd); err != nil {
+			return err
+		}
+
+		if on, _ := cmd.Flags().GetBool("offline"); on {
+			offline.Enable()
+		} else if cfg, err := config.LoadGlobal(); err == nil && cfg.Offline {
+			offline.Enable()
+		}
+
+		target, _ := cmd.Flags().GetString("events")
+		if target == "" {
+			target = os.Getenv(events.EnvVar)
+		}
+		if on, _ := cmd.Flags().GetBool("json"); on {
+			if target == "stdout" || target == "-" {
+				return exitcode.Errorf(exitcode.Usage, "--json and --events stdout both write to stdout\n  hint: send the events to a socket with --events unix:PATH or tcp:HOST:PORT")
+			}
+			output.EnableJSON()
+		}
+		if target == "" {
+			return nil
+		}
+		if err := events.Open(target); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		events.Start(cmd.CommandPath())
+		return nil
+	},
 }
 
-// Execute runs the root command
+func init() {
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return exitcode.Wrap(exitcode.Usage, err)
+	})
+	rootCmd.PersistentFlags().String("events", "", "Emit NDJSON progress events for editors to stdout, unix:PATH or tcp:HOST:PORT (or set $"+events.EnvVar+")")
+	rootCmd.PersistentFlags().Bool("json", false, "Print a JSON document to stdout for tooling (list, search, info, test, build, ci build); other output goes to stderr")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Print only warnings and errors")
+	rootCmd.PersistentFlags().Bool("verbose", false, "Print details such as the commands cpx runs")
+	rootCmd.PersistentFlags().Bool("debug", false, "Print debug messages (or set $"+logging.DebugEnvVar+"); build, run and test use --debug for themselves")
+	rootCmd.PersistentFlags().Bool("timestamps", false, "Prefix messages with the time")
+	rootCmd.PersistentFlags().String("log-file", "", "Append every message, debug ones included, to the given file")
+	rootCmd.PersistentFlags().Bool("offline", false, "Don't use the network: work from caches and fail fast when a download is required (or set $"+offline.EnvVar+")")
+}
+
+// setupLogging applies the logging flags. Commands may define --verbose,
+// --quiet or --debug themselves: a local --verbose or --quiet means the
+// same, but a local --debug (a debug build, or running under a debugger)
+// doesn't turn on debug messages.
+func setupLogging(cmd *cobra.Command) error {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	debug := logging.DebugFromEnv()
+	if flag := cmd.Flags().Lookup("debug"); flag == cmd.Root().PersistentFlags().Lookup("debug") && flag.Value.String() == "true" {
+		debug = true
+	}
+	if quiet && (verbose || debug) {
+		return exitcode.Errorf(exitcode.Usage, "--quiet cannot be combined with --verbose or --debug")
+	}
+
+	switch {
+	case debug:
+		logging.SetLevel(logging.LevelDebug)
+	case verbose:
+		logging.SetLevel(logging.LevelVerbose)
+	case quiet:
+		logging.SetLevel(logging.LevelQuiet)
+	}
+	timestamps, _ := cmd.Flags().GetBool("timestamps")
+	logging.SetTimestamps(timestamps)
+	if path, _ := cmd.Flags().GetString("log-file"); path != "" {
+		if err := logging.OpenFile(path); err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+	}
+	return nil
+}
+
+// Execute runs the root command and exits with the code matching the
+// error (see `cpx help exit-codes`)
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	defer func() {
+		if r := recover(); r != nil {
+			cli.PrintError("internal error: %v\n  hint: please report this at https://github.com/ozacod/cpx/issues", r)
+			os.Exit(int(exitcode.Internal))
+		}
+	}()
+
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	recordTelemetry(cmd, time.Since(start), err)
+	if events.Enabled() {
+		message := ""
+		if err != nil {
+			message = err.Error()
+		}
+		events.Finish(int(exitcode.Of(err)), message)
+		events.Close()
+	}
+	if err != nil {
+		if output.JSON() && !output.Printed() {
+			output.Print(struct {
+				Status   string `json:"status"`
+				Error    string `json:"error"`
+				ExitCode int    `json:"exit_code"`
+			}{"failed", err.Error(), int(exitcode.Of(err))})
+		}
 		cli.PrintError("%v", err)
-		os.Exit(1)
+		logging.Close()
+		os.Exit(int(exitcode.Of(err)))
+	}
+	logging.Close()
+}
+
+// recordTelemetry queues a usage event for cmd if the user opted in (see
+// cpx config telemetry)
+func recordTelemetry(cmd *cobra.Command, duration time.Duration, err error) {
+	if cmd == nil || cmd == rootCmd || cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return
+	}
+	if err := telemetry.Record(cmd.CommandPath(), cli.Version, duration, int(exitcode.Of(err))); err != nil {
+		logging.Debug("telemetry: %v", err)
 	}
 }
 
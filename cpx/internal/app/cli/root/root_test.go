@@ -0,0 +1,40 @@
+package root
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageErrors(t *testing.T) {
+	rootCmd.AddCommand(&cobra.Command{Use: "build", RunE: func(*cobra.Command, []string) error { return nil }})
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	defer rootCmd.SetOut(nil)
+	defer rootCmd.SetArgs(nil)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--offline", "bogus"}, `unknown command "bogus"`},
+		{[]string{"biuld"}, "did you mean 'build'?"},
+		{[]string{"--bogus"}, "unknown flag: --bogus"},
+		{[]string{"build", "--bogus"}, "unknown flag: --bogus"},
+	}
+	for _, tt := range tests {
+		rootCmd.SetArgs(tt.args)
+		_, err := rootCmd.ExecuteC()
+		assert.Equal(t, exitcode.Usage, exitcode.Of(err), tt.args)
+		assert.ErrorContains(t, err, tt.want)
+	}
+
+	// Without a command the help is printed
+	rootCmd.SetArgs([]string{})
+	_, err := rootCmd.ExecuteC()
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "Available Commands")
+}
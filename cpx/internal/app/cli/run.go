@@ -1,13 +1,19 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -20,11 +26,32 @@ func RunCmd(client *vcpkg.Client) *cobra.Command {
   - vcpkg/CMake projects: Builds with CMake and runs the binary
   - Bazel projects: Uses bazel run
 
-Arguments after -- are passed to the binary.`,
+Arguments after -- are passed to the binary, and --env KEY=VALUE (repeatable)
+adds variables to its environment, for CMake, Bazel and Meson projects alike.
+
+--profile NAME applies a run profile from cpx.yaml: its args come before the
+ones after --, its env is overridden by --env, and its working_dir (relative
+to the project root) becomes the working directory of the binary:
+
+  run:
+    profiles:
+      staging:
+        args: ["--config", "config/staging.toml"]
+        env:
+          LOG_LEVEL: info
+        working_dir: deploy
+
+--debug launches the binary under gdb or lldb (lldb on macOS; override with
+$CPX_DEBUGGER) in the current directory (or the profile's working_dir).
+Optimized builds keep debug info.`,
 		Example: `  cpx run                 # Debug build by default
   cpx run --release        # Release build, then run
   cpx run --asan           # Run with AddressSanitizer
-  cpx run --target app -- --flag value`,
+  cpx run --release --perf-stat  # Print wall time, max RSS and cache misses
+  cpx run --target app -- --flag value
+  cpx run --env LOG_LEVEL=debug --env PORT=8080
+  cpx run --profile staging -- --port 9000
+  cpx run --debug -- --flag value  # Debug under gdb/lldb with arguments`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runRun(cmd, args, client)
 		},
@@ -34,6 +61,10 @@ Arguments after -- are passed to the binary.`,
 	cmd.Flags().String("target", "", "Executable target to run (useful if multiple)")
 	cmd.Flags().StringP("opt", "O", "", "Override optimization level: 0,1,2,3,s,fast")
 	cmd.Flags().Bool("verbose", false, "Show full build output")
+	cmd.Flags().Bool("perf-stat", false, "Run under perf stat (Linux) or /usr/bin/time -l (macOS) and print performance counters")
+	cmd.Flags().Bool("debug", false, "Launch the executable under gdb or lldb")
+	cmd.Flags().StringArrayP("env", "e", nil, "Set an environment variable for the executable (KEY=VALUE, repeatable)")
+	cmd.Flags().StringP("profile", "p", "", "Apply a run profile from "+config.ProjectFile+" (args, env, working_dir)")
 	// Sanitizer flags
 	cmd.Flags().Bool("asan", false, "Run with AddressSanitizer")
 	cmd.Flags().Bool("tsan", false, "Run with ThreadSanitizer")
@@ -48,6 +79,22 @@ func runRun(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 	target, _ := cmd.Flags().GetString("target")
 	optLevel, _ := cmd.Flags().GetString("opt")
 	verbose, _ := cmd.Flags().GetBool("verbose")
+	perfStat, _ := cmd.Flags().GetBool("perf-stat")
+	debug, _ := cmd.Flags().GetBool("debug")
+	envFlags, _ := cmd.Flags().GetStringArray("env")
+	profile, _ := cmd.Flags().GetString("profile")
+
+	env, err := parseEnvFlags(envFlags)
+	if err != nil {
+		return err
+	}
+
+	var dir string
+	if profile != "" {
+		if args, env, dir, err = applyRunProfile(profile, args, env); err != nil {
+			return err
+		}
+	}
 
 	// Parse sanitizer flags
 	asan, _ := cmd.Flags().GetBool("asan")
@@ -78,22 +125,115 @@ func runRun(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 		return fmt.Errorf("only one sanitizer can be used at a time (got %d)", sanitizerCount)
 	}
 
+	var debugger []string
+	if debug {
+		if perfStat {
+			return exitcode.Errorf(exitcode.Usage, "--debug and --perf-stat cannot be combined")
+		}
+		if debugger, err = debuggerPrefix(dir); err != nil {
+			return err
+		}
+	}
+
 	projectType := DetectProjectType()
 
 	switch projectType {
 	case ProjectTypeBazel:
-		return runBazelRun(release, target, args, verbose, optLevel, sanitizer)
+		return runBazelRun(release, target, args, env, dir, verbose, optLevel, sanitizer, perfStat, debugger)
 	case ProjectTypeMeson:
-		return runMesonRun(release, target, args, verbose, optLevel, sanitizer)
+		return runMesonRun(release, target, args, env, dir, verbose, optLevel, sanitizer, perfStat, debugger)
 	case ProjectTypeVcpkg:
-		return build.RunProject(release, target, args, verbose, optLevel, sanitizer, client)
+		return build.RunProject(release, target, args, env, dir, verbose, optLevel, sanitizer, perfStat, debugger, client)
 	default:
 		// Fall back to CMake run even without vcpkg.json
-		return build.RunProject(release, target, args, verbose, optLevel, sanitizer, client)
+		return build.RunProject(release, target, args, env, dir, verbose, optLevel, sanitizer, perfStat, debugger, client)
+	}
+}
+
+// parseEnvFlags validates --env values of the form KEY=VALUE
+func parseEnvFlags(values []string) ([]string, error) {
+	var env []string
+	for _, value := range values {
+		key, _, ok := strings.Cut(value, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, exitcode.Errorf(exitcode.Usage, "invalid --env %q: expected KEY=VALUE", value)
+		}
+		env = append(env, value)
+	}
+	return env, nil
+}
+
+// applyRunProfile merges the named run profile of cpx.yaml with the command
+// line: profile args go before args, --env values override the profile env.
+// It returns the merged args and env and the absolute working directory ("" to
+// keep the current one).
+func applyRunProfile(name string, args, env []string) ([]string, []string, string, error) {
+	cfg, err := config.LoadProject(config.ProjectFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, "", exitcode.Errorf(exitcode.Config, "run profile %q requested but %s not found\n  hint: define it under run.profiles in %s", name, config.ProjectFile, config.ProjectFile)
+	}
+	if err != nil {
+		return nil, nil, "", exitcode.Wrap(exitcode.Config, err)
+	}
+
+	profile, ok := cfg.Run.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(cfg.Run.Profiles))
+		for n := range cfg.Run.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		hint := "no profiles are defined under run.profiles"
+		if len(names) > 0 {
+			hint = "available profiles: " + strings.Join(names, ", ")
+		}
+		return nil, nil, "", exitcode.Errorf(exitcode.Config, "run profile %q not found in %s\n  hint: %s", name, config.ProjectFile, hint)
+	}
+
+	mergedArgs := append(append([]string{}, profile.Args...), args...)
+
+	keys := make([]string, 0, len(profile.Env))
+	for key := range profile.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var mergedEnv []string
+	for _, key := range keys {
+		mergedEnv = append(mergedEnv, key+"="+profile.Env[key])
+	}
+	// Later entries win, so --env overrides the profile
+	mergedEnv = append(mergedEnv, env...)
+
+	dir := ""
+	if profile.WorkingDir != "" {
+		if dir, err = filepath.Abs(profile.WorkingDir); err != nil {
+			return nil, nil, "", err
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, nil, "", exitcode.Errorf(exitcode.Config, "working_dir %q of run profile %q is not a directory", profile.WorkingDir, name)
+		}
+	}
+
+	return mergedArgs, mergedEnv, dir, nil
+}
+
+// debuggerPrefix returns the command line that launches a program under the
+// platform debugger in workDir, or the current directory if empty
+func debuggerPrefix(workDir string) ([]string, error) {
+	debugger, err := build.FindDebugger()
+	if err != nil {
+		return nil, err
+	}
+	if workDir == "" {
+		if workDir, err = os.Getwd(); err != nil {
+			return nil, err
+		}
 	}
+	fmt.Printf("%sDebugging with %s%s\n", Dim, filepath.Base(debugger), Reset)
+	return build.DebuggerPrefix(debugger, workDir), nil
 }
 
-func runBazelRun(release bool, target string, args []string, verbose bool, optLevel string, sanitizer string) error {
+func runBazelRun(release bool, target string, args []string, env []string, dir string, verbose bool, optLevel string, sanitizer string, perfStat bool, debugger []string) error {
 	// Build bazel run args
 	bazelArgs := []string{"run"}
 
@@ -135,6 +275,33 @@ func runBazelRun(release bool, target string, args []string, verbose bool, optLe
 		}
 	}
 
+	// Run the binary under the counter tool; bazel itself is not measured
+	var perf *build.PerfStatRunner
+	if perfStat {
+		var err error
+		perf, err = build.NewPerfStatRunner()
+		if err != nil {
+			return err
+		}
+		bazelArgs = append(bazelArgs, "--run_under="+strings.Join(perf.Prefix(), " "))
+	}
+
+	if len(debugger) > 0 {
+		// Keep symbols in optimized builds too
+		bazelArgs = append(bazelArgs, "--copt=-g", "--strip=never", "--run_under="+build.ShellJoin(debugger))
+	}
+
+	if dir != "" {
+		// bazel runs the binary in its runfiles tree unless told to stay in
+		// the directory it was invoked from
+		bazelArgs = append(bazelArgs, "--run_in_cwd")
+	}
+
+	if !verbose {
+		// Use hidden symlinks (.bazel-bin, .bazel-out, etc.)
+		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
+	}
+
 	// Add target or try to find one
 	if target != "" {
 		if !strings.HasPrefix(target, "//") && !strings.HasPrefix(target, ":") {
@@ -150,31 +317,35 @@ func runBazelRun(release bool, target string, args []string, verbose bool, optLe
 		bazelArgs = append(bazelArgs, mainTarget)
 	}
 
-	// Add -- and user args if present
+	// Add -- and user args last; everything after it goes to the binary
 	if len(args) > 0 {
 		bazelArgs = append(bazelArgs, "--")
 		bazelArgs = append(bazelArgs, args...)
 	}
 
-	fmt.Printf("%sRunning with Bazel...%s\n", Cyan, Reset)
-	if verbose {
-		fmt.Printf("  Running: bazel %v\n", bazelArgs)
-	} else {
-		// Use hidden symlinks (.bazel-bin, .bazel-out, etc.)
-		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
-	}
+	logging.Step("Running with Bazel...")
+	logging.Verbose("  Running: bazel %v", bazelArgs)
 
 	runCmd := execCommand("bazel", bazelArgs...)
+	// bazel run passes the client environment through to the binary
+	addEnv(runCmd, env)
+	runCmd.Dir = dir
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
 	runCmd.Stdin = os.Stdin
 
-	return runCmd.Run()
+	if perf == nil {
+		return runCmd.Run()
+	}
+	perf.Attach(runCmd)
+	runErr := runCmd.Run()
+	build.PrintPerfStats(perf.CollectWrapped(runCmd))
+	return runErr
 }
 
-func runMesonRun(release bool, target string, args []string, verbose bool, optLevel string, sanitizer string) error {
+func runMesonRun(release bool, target string, args []string, env []string, dir string, verbose bool, optLevel string, sanitizer string, perfStat bool, debugger []string) error {
 	// Ensure project is built first
-	if err := runMesonBuild(release, target, false, verbose, optLevel, sanitizer); err != nil {
+	if err := runMesonBuild(release, target, false, verbose, optLevel, sanitizer, false, build.Compiler{}, build.CrossToolchain{}); err != nil {
 		return fmt.Errorf("build failed: %w", err)
 	}
 
@@ -226,8 +397,29 @@ func runMesonRun(release bool, target string, args []string, verbose bool, optLe
 		return fmt.Errorf("no executable found in builddir\n  hint: use --target to specify the executable")
 	}
 
-	fmt.Printf("%sRunning %s...%s\n", Cyan, exePath, Reset)
-	runCmd := execCommand(exePath, args...)
+	logging.Step("Running %s...", exePath)
+	if dir != "" {
+		// The executable path is relative to the project root
+		var err error
+		if exePath, err = filepath.Abs(exePath); err != nil {
+			return err
+		}
+	}
+	if perfStat {
+		return build.RunWithPerfStat(exePath, args, env, dir, execCommand)
+	}
+	name := exePath
+	if len(debugger) > 0 {
+		// Meson's release and minsize build types are built without -g
+		if (release && optLevel == "") || (optLevel != "" && optLevel != "0" && optLevel != "1") {
+			logging.Notice("Note: this build type has no debug info; use -O1 (debugoptimized) to debug optimized code")
+		}
+		name = debugger[0]
+		args = append(append(debugger[1:len(debugger):len(debugger)], exePath), args...)
+	}
+	runCmd := execCommand(name, args...)
+	addEnv(runCmd, env)
+	runCmd.Dir = dir
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
 	runCmd.Stdin = os.Stdin
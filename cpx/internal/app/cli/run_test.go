@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,6 +37,8 @@ func TestRunBazelRun(t *testing.T) {
 		args       []string
 		verbose    bool
 		sanitizer  string
+		debugger   []string
+		dir        string
 		wantConfig string
 	}{
 		{
@@ -70,12 +74,26 @@ func TestRunBazelRun(t *testing.T) {
 			sanitizer:  "asan",
 			wantConfig: "--config=debug",
 		},
+		{
+			name:       "Debug under gdb",
+			release:    true,
+			target:     "app",
+			args:       []string{"--flag"},
+			debugger:   []string{"gdb", "--cd=/work", "--args"},
+			wantConfig: "--config=release",
+		},
+		{
+			name:       "Profile working dir",
+			target:     "app",
+			dir:        t.TempDir(),
+			wantConfig: "--config=debug",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			capturedArgs = nil
-			err := runBazelRun(tt.release, tt.target, tt.args, tt.verbose, "", tt.sanitizer)
+			err := runBazelRun(tt.release, tt.target, tt.args, nil, tt.dir, tt.verbose, "", tt.sanitizer, false, tt.debugger)
 			assert.NoError(t, err)
 
 			require.GreaterOrEqual(t, len(capturedArgs), 1)
@@ -87,6 +105,20 @@ func TestRunBazelRun(t *testing.T) {
 			if tt.sanitizer == "asan" {
 				assert.Contains(t, capturedArgs[0], "--copt=-fsanitize=address")
 			}
+			if len(tt.args) > 0 {
+				// User args must come last, after --
+				got := capturedArgs[0]
+				assert.Equal(t, append([]string{"--"}, tt.args...), got[len(got)-len(tt.args)-1:])
+			}
+			if tt.dir != "" {
+				assert.Contains(t, capturedArgs[0], "--run_in_cwd")
+			} else {
+				assert.NotContains(t, capturedArgs[0], "--run_in_cwd")
+			}
+			if tt.debugger != nil {
+				assert.Contains(t, capturedArgs[0], "--run_under=gdb --cd=/work --args")
+				assert.Contains(t, capturedArgs[0], "--copt=-g")
+			}
 		})
 	}
 }
@@ -119,13 +151,87 @@ func TestRunMesonRun(t *testing.T) {
 	require.NoError(t, os.MkdirAll(srcDir, 0755))
 	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "myapp"), []byte("#!/bin/sh\necho hello"), 0755))
 
-	err = runMesonRun(false, "myapp", nil, false, "", "")
+	err = runMesonRun(false, "myapp", nil, []string{"APP_MODE=test"}, "", false, "", "", false, nil)
 	// Will fail because the mock doesn't actually run meson setup correctly,
 	// but we're testing that the function runs without panic
 	// The actual meson setup calls are mocked
 	assert.NoError(t, err)
 }
 
+func TestParseEnvFlags(t *testing.T) {
+	env, err := parseEnvFlags([]string{"LOG_LEVEL=debug", "EMPTY=", "URL=a=b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"LOG_LEVEL=debug", "EMPTY=", "URL=a=b"}, env)
+
+	for _, invalid := range []string{"NOVALUE", "=value", "MY VAR=1"} {
+		_, err := parseEnvFlags([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestApplyRunProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	_, _, _, err = applyRunProfile("staging", nil, nil)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err), "no cpx.yaml")
+
+	yaml := `run:
+  profiles:
+    staging:
+      args: ["--config", "staging.toml"]
+      env:
+        PORT: "8080"
+        LOG_LEVEL: info
+      working_dir: deploy
+    bench:
+      args: ["--iterations", "1000"]
+`
+	require.NoError(t, os.WriteFile(config.ProjectFile, []byte(yaml), 0644))
+
+	_, _, _, err = applyRunProfile("staging", nil, nil)
+	assert.ErrorContains(t, err, "not a directory")
+	require.NoError(t, os.Mkdir("deploy", 0755))
+
+	args, env, dir, err := applyRunProfile("staging", []string{"--verbose"}, []string{"LOG_LEVEL=debug"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--config", "staging.toml", "--verbose"}, args)
+	// --env comes last so it overrides the profile
+	assert.Equal(t, []string{"LOG_LEVEL=info", "PORT=8080", "LOG_LEVEL=debug"}, env)
+	wantDir, err := filepath.Abs("deploy")
+	require.NoError(t, err)
+	assert.Equal(t, wantDir, dir)
+
+	args, env, dir, err = applyRunProfile("bench", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--iterations", "1000"}, args)
+	assert.Empty(t, env)
+	assert.Empty(t, dir)
+
+	_, _, _, err = applyRunProfile("prod", nil, nil)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+	assert.ErrorContains(t, err, "available profiles: bench, staging")
+}
+
+func TestAddEnv(t *testing.T) {
+	cmd := exec.Command("true")
+	addEnv(cmd, nil)
+	assert.Nil(t, cmd.Env, "no --env keeps the inherited environment")
+
+	addEnv(cmd, []string{"APP_MODE=test"})
+	assert.Contains(t, cmd.Env, "APP_MODE=test")
+	assert.Greater(t, len(cmd.Env), 1, "inherited variables are kept")
+
+	// Variables already set on the command (e.g. by test mocks) are kept
+	cmd = exec.Command("true")
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1"}
+	addEnv(cmd, []string{"APP_MODE=test"})
+	assert.Equal(t, []string{"GO_WANT_HELPER_PROCESS=1", "APP_MODE=test"}, cmd.Env)
+}
+
 func TestFindBazelMainTarget(t *testing.T) {
 	// Use temp dir
 	tmpDir := t.TempDir()
@@ -2,9 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
@@ -13,7 +18,17 @@ func SearchCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search for libraries interactively",
-		Long:  "Search for libraries using an interactive TUI. Select packages to add them to your project.",
+		Long: `Search for libraries using an interactive TUI. Select packages to add them to your project.
+
+Searches use an index of the vcpkg ports in the cpx config directory, rebuilt
+when the vcpkg checkout changes or by cpx update. Names match exactly, by
+prefix, by substring, fuzzily (letters in order or one typo) or through the
+description.
+
+With --json, cpx prints the ports matching the query, best match first,
+instead of starting the TUI; this works outside a project.`,
+		Example: `  cpx search json
+  cpx search --json "http client"`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runSearch(cmd, args, client)
 		},
@@ -29,7 +44,11 @@ func runSearch(_ *cobra.Command, args []string, client *vcpkg.Client) error {
 		query = args[0]
 	}
 
-	if err := requireVcpkgProject("cpx search"); err != nil {
+	if output.JSON() {
+		if query == "" {
+			return exitcode.Errorf(exitcode.Usage, "cpx search --json needs a query\n  hint: cpx search --json <query>")
+		}
+	} else if err := requireVcpkgProject("cpx search"); err != nil {
 		return err
 	}
 
@@ -42,5 +61,67 @@ func runSearch(_ *cobra.Command, args []string, client *vcpkg.Client) error {
 		return fmt.Errorf("failed to get vcpkg path: %w", err)
 	}
 
-	return tui.RunSearch(query, vcpkgPath, client.RunCommand)
+	index, err := loadPortIndex(filepath.Dir(vcpkgPath), false)
+	if err != nil {
+		return err
+	}
+	if output.JSON() {
+		return output.Print(searchResults(index, query))
+	}
+	return tui.RunSearch(query, vcpkgPath, index, client.RunCommand)
+}
+
+// searchResult is a port of cpx search --json
+type searchResult struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description"`
+	Homepage    string `json:"homepage,omitempty"`
+}
+
+// searchResults returns the ports of index matching query, best match first
+func searchResults(index *vcpkg.Index, query string) []searchResult {
+	results := []searchResult{}
+	for _, match := range index.Search(query) {
+		results = append(results, searchResult{
+			Name:        match.Name,
+			Version:     match.Version,
+			Description: match.Description,
+			Homepage:    match.Homepage,
+		})
+	}
+	return results
+}
+
+// portIndexPath returns the path of the port index of cpx search
+func portIndexPath() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, vcpkg.IndexFile), nil
+}
+
+// loadPortIndex returns the port index of the vcpkg checkout in vcpkgRoot,
+// rebuilding it if it is out of date or rebuild is set
+func loadPortIndex(vcpkgRoot string, rebuild bool) (*vcpkg.Index, error) {
+	path, err := portIndexPath()
+	if err != nil {
+		return nil, err
+	}
+	if !rebuild {
+		if index, err := vcpkg.LoadIndex(path); err == nil && index.Fresh(vcpkgRoot) {
+			return index, nil
+		}
+	}
+
+	index, err := vcpkg.BuildIndex(vcpkgRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := index.Save(path); err != nil {
+		// Searching still works, only slower next time
+		logging.Warn("failed to save the port index: %v", err)
+	}
+	return index, nil
 }
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPortIndex(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	vcpkgRoot := t.TempDir()
+	gitCommitFiles(t, vcpkgRoot, map[string]string{
+		"ports/fmt/vcpkg.json": `{"name": "fmt", "version": "11.0.2"}`,
+	})
+
+	index, err := loadPortIndex(vcpkgRoot, false)
+	require.NoError(t, err)
+	assert.Len(t, index.Ports, 1)
+	path, err := portIndexPath()
+	require.NoError(t, err)
+	assert.FileExists(t, path)
+
+	// The saved index is used while the checkout is unchanged
+	require.NoError(t, os.MkdirAll(filepath.Join(vcpkgRoot, "ports", "zlib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(vcpkgRoot, "ports", "zlib", "vcpkg.json"), []byte(`{"name": "zlib", "version": "1.3.1"}`), 0644))
+	index, err = loadPortIndex(vcpkgRoot, false)
+	require.NoError(t, err)
+	assert.Len(t, index.Ports, 1)
+
+	// cpx update rebuilds it
+	index, err = loadPortIndex(vcpkgRoot, true)
+	require.NoError(t, err)
+	assert.Len(t, index.Ports, 2)
+	saved, err := vcpkg.LoadIndex(path)
+	require.NoError(t, err)
+	assert.Len(t, saved.Ports, 2)
+}
+
+func TestSearchJSON(t *testing.T) {
+	index := &vcpkg.Index{Ports: []vcpkg.Port{
+		{Name: "fmt", Version: "11.0.2", Description: "Formatting library", Homepage: "https://fmt.dev"},
+		{Name: "zlib", Version: "1.3.1", Description: "Compression library"},
+	}}
+	assert.Equal(t, []searchResult{
+		{Name: "fmt", Version: "11.0.2", Description: "Formatting library", Homepage: "https://fmt.dev"},
+	}, searchResults(index, "fmt"))
+	assert.Equal(t, []searchResult{}, searchResults(index, "nothing-matches-this"))
+
+	// Without the TUI there's nothing to browse, so a query is required
+	_, err := captureJSON(t, func() error { return runSearch(nil, nil, nil) })
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
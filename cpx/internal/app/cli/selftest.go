@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/spf13/cobra"
+)
+
+// Selftest build systems, matching tui.ProjectConfig.PackageManager
+var selftestBuildSystems = []string{"vcpkg", "bazel", "meson"}
+
+// Selftest test frameworks, matching tui.ProjectConfig.TestFramework
+var selftestFrameworks = []string{"googletest", "catch2", "doctest"}
+
+// selftestCase is one scaffolded project configuration
+type selftestCase struct {
+	Name   string
+	Config tui.ProjectConfig
+}
+
+// selftestResult is the outcome of scaffolding, building and testing one case
+type selftestResult struct {
+	Case     selftestCase
+	Status   string // "pass", "fail" or "skip"
+	Stage    string // stage that failed: scaffold, build or test
+	Reason   string
+	Log      string
+	Duration time.Duration
+}
+
+// SelftestCmd creates the selftest command
+func SelftestCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Scaffold, build and test sample projects to verify the toolchain",
+		Long: `Scaffold representative projects in a temporary directory (executable and
+library, for each build system and test framework), build and test each one,
+and report which combinations work on this machine.
+
+Build systems whose tools are not installed are reported as skipped.`,
+		Example: `  cpx selftest                          # All combinations
+  cpx selftest --build-system meson     # Only Meson projects
+  cpx selftest --framework catch2 --keep  # Keep the projects for inspection`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSelftest(cmd, args, client)
+		},
+	}
+
+	cmd.Flags().StringSlice("build-system", selftestBuildSystems, "Build systems to test: vcpkg, bazel, meson")
+	cmd.Flags().StringSlice("framework", selftestFrameworks, "Test frameworks to test: googletest, catch2, doctest")
+	cmd.Flags().Bool("keep", false, "Keep the generated projects instead of deleting them")
+	cmd.Flags().BoolP("verbose", "v", false, "Print the build and test output of failed combinations")
+
+	return cmd
+}
+
+func runSelftest(cmd *cobra.Command, _ []string, client *vcpkg.Client) error {
+	buildSystems, _ := cmd.Flags().GetStringSlice("build-system")
+	frameworks, _ := cmd.Flags().GetStringSlice("framework")
+	keep, _ := cmd.Flags().GetBool("keep")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	cases, err := selftestCases(buildSystems, frameworks)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate cpx executable: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "cpx-selftest-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	if keep {
+		fmt.Printf("%sProjects are kept in %s%s\n", Dim, workDir, Reset)
+	} else {
+		defer os.RemoveAll(workDir)
+	}
+
+	logging.Step("Running %d selftest combinations...", len(cases))
+
+	var results []selftestResult
+	for i, c := range cases {
+		fmt.Printf("%s[%d/%d]%s %s ", Cyan, i+1, len(cases), Reset, c.Name)
+		result := runSelftestCase(c, workDir, self, client)
+		printSelftestStatus(result)
+		if verbose && result.Status == "fail" && result.Log != "" {
+			fmt.Println(indent(result.Log, "    "))
+		}
+		results = append(results, result)
+	}
+
+	failed := printSelftestSummary(results)
+	if failed > 0 {
+		return exitcode.Errorf(exitcode.TestFailed, "%d of %d selftest combinations failed", failed, len(results))
+	}
+	return nil
+}
+
+// selftestCases returns every exe/lib × build system × framework combination
+func selftestCases(buildSystems, frameworks []string) ([]selftestCase, error) {
+	for _, bs := range buildSystems {
+		if !slices.Contains(selftestBuildSystems, bs) {
+			return nil, fmt.Errorf("unknown build system %q (expected one of %s)", bs, strings.Join(selftestBuildSystems, ", "))
+		}
+	}
+	for _, fw := range frameworks {
+		if !slices.Contains(selftestFrameworks, fw) {
+			return nil, fmt.Errorf("unknown test framework %q (expected one of %s)", fw, strings.Join(selftestFrameworks, ", "))
+		}
+	}
+
+	var cases []selftestCase
+	for _, bs := range buildSystems {
+		for _, fw := range frameworks {
+			for _, isLib := range []bool{false, true} {
+				kind := "exe"
+				if isLib {
+					kind = "lib"
+				}
+				name := fmt.Sprintf("st_%s_%s_%s", kind, bs, fw)
+				cases = append(cases, selftestCase{
+					Name: name,
+					Config: tui.ProjectConfig{
+						Name:           name,
+						IsLibrary:      isLib,
+						CppStandard:    17,
+						TestFramework:  fw,
+						Benchmark:      "none",
+						PackageManager: bs,
+						VCS:            "none",
+					},
+				})
+			}
+		}
+	}
+	return cases, nil
+}
+
+// selftestRequirement returns why a build system cannot run here, or ""
+func selftestRequirement(buildSystem string, client *vcpkg.Client) string {
+	switch buildSystem {
+	case "bazel":
+		if _, err := execLookPath("bazel"); err != nil {
+			return "bazel not installed"
+		}
+	case "meson":
+		for _, tool := range []string{"meson", "ninja"} {
+			if _, err := execLookPath(tool); err != nil {
+				return tool + " not installed"
+			}
+		}
+	default:
+		if _, err := execLookPath("cmake"); err != nil {
+			return "cmake not installed"
+		}
+		if client == nil {
+			return "vcpkg not configured"
+		}
+		if _, err := client.GetPath(); err != nil {
+			return "vcpkg not configured"
+		}
+	}
+	return ""
+}
+
+// runSelftestCase scaffolds c under workDir and runs `cpx build` and
+// `cpx test` in it using the cpx executable at self
+func runSelftestCase(c selftestCase, workDir, self string, client *vcpkg.Client) selftestResult {
+	start := time.Now()
+	result := selftestResult{Case: c, Status: "pass"}
+
+	if reason := selftestRequirement(c.Config.PackageManager, client); reason != "" {
+		result.Status, result.Reason = "skip", reason
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	if err := scaffoldSelftestProject(c, workDir, client); err != nil {
+		result.Status, result.Stage, result.Reason = "fail", "scaffold", err.Error()
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	projectDir := filepath.Join(workDir, c.Name)
+	for _, stage := range []string{"build", "test"} {
+		stageCmd := execCommand(self, stage)
+		stageCmd.Dir = projectDir
+		out, err := stageCmd.CombinedOutput()
+		result.Log = string(out)
+		if err != nil {
+			result.Status, result.Stage = "fail", stage
+			result.Reason = fmt.Sprintf("cpx %s exited with code %d", stage, exitCodeOf(err))
+			break
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
+// scaffoldSelftestProject generates the project like `cpx new` would,
+// discarding its console output
+func scaffoldSelftestProject(c selftestCase, workDir string, client *vcpkg.Client) error {
+	oldWd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(workDir); err != nil {
+		return err
+	}
+	defer os.Chdir(oldWd)
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err == nil {
+		oldStdout := os.Stdout
+		os.Stdout = devNull
+		defer func() {
+			os.Stdout = oldStdout
+			devNull.Close()
+		}()
+	}
+
+	return createProjectFromTUI(c.Config, client, false)
+}
+
+func exitCodeOf(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+func printSelftestStatus(r selftestResult) {
+	switch r.Status {
+	case "pass":
+		fmt.Printf("%s%s%s %s(%s)%s\n", Green, IconSuccess, Reset, Dim, r.Duration.Round(time.Millisecond), Reset)
+	case "skip":
+		fmt.Printf("%sskipped: %s%s\n", Yellow, r.Reason, Reset)
+	default:
+		fmt.Printf("%s%s %s failed: %s%s\n", Red, IconError, r.Stage, r.Reason, Reset)
+	}
+}
+
+// printSelftestSummary prints the results grouped by build system and
+// returns the number of failed combinations
+func printSelftestSummary(results []selftestResult) int {
+	passed, failed, skipped := 0, 0, 0
+	for _, r := range results {
+		switch r.Status {
+		case "pass":
+			passed++
+		case "skip":
+			skipped++
+		default:
+			failed++
+		}
+	}
+
+	fmt.Printf("\n%sSelftest summary%s\n", Bold, Reset)
+	fmt.Printf("  %-34s %-8s %s\n", "Combination", "Result", "Details")
+	for _, r := range results {
+		status := r.Status
+		color := Green
+		details := r.Duration.Round(time.Millisecond).String()
+		switch r.Status {
+		case "skip":
+			color = Yellow
+			details = r.Reason
+		case "fail":
+			color = Red
+			details = r.Stage + ": " + r.Reason
+		}
+		fmt.Printf("  %-34s %s%-8s%s %s\n", r.Case.Name, color, status, Reset, details)
+	}
+	fmt.Printf("\n  %s%d passed%s, %s%d failed%s, %s%d skipped%s\n",
+		Green, passed, Reset, Red, failed, Reset, Yellow, skipped, Reset)
+
+	return failed
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
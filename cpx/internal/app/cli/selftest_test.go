@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelftestCases(t *testing.T) {
+	cases, err := selftestCases(selftestBuildSystems, selftestFrameworks)
+	require.NoError(t, err)
+	assert.Len(t, cases, 2*len(selftestBuildSystems)*len(selftestFrameworks))
+
+	cases, err = selftestCases([]string{"meson"}, []string{"catch2"})
+	require.NoError(t, err)
+	require.Len(t, cases, 2)
+	assert.Equal(t, "st_exe_meson_catch2", cases[0].Name)
+	assert.False(t, cases[0].Config.IsLibrary)
+	assert.Equal(t, "st_lib_meson_catch2", cases[1].Name)
+	assert.True(t, cases[1].Config.IsLibrary)
+	assert.Equal(t, "meson", cases[1].Config.PackageManager)
+	assert.Equal(t, "catch2", cases[1].Config.TestFramework)
+
+	_, err = selftestCases([]string{"scons"}, selftestFrameworks)
+	assert.Error(t, err)
+	_, err = selftestCases(selftestBuildSystems, []string{"boost-test"})
+	assert.Error(t, err)
+}
+
+func TestRunSelftestCase(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	cases, err := selftestCases([]string{"meson"}, []string{"googletest"})
+	require.NoError(t, err)
+
+	t.Run("Missing tool is skipped", func(t *testing.T) {
+		execLookPath = func(file string) (string, error) {
+			return "", fmt.Errorf("%s: not found", file)
+		}
+		result := runSelftestCase(cases[0], t.TempDir(), "cpx", nil)
+		assert.Equal(t, "skip", result.Status)
+		assert.Equal(t, "meson not installed", result.Reason)
+	})
+
+	t.Run("Failing tests are reported", func(t *testing.T) {
+		execLookPath = func(file string) (string, error) {
+			return "/usr/bin/" + file, nil
+		}
+		workDir := t.TempDir()
+		result := runSelftestCase(cases[0], workDir, "cpx", nil)
+		assert.Equal(t, "fail", result.Status)
+		assert.Equal(t, "test", result.Stage)
+		assert.Contains(t, result.Reason, "code 6")
+		assert.Contains(t, result.Log, "1 test failed")
+		assert.FileExists(t, filepath.Join(workDir, cases[0].Name, "meson.build"))
+	})
+
+	assert.Equal(t, 1, printSelftestSummary([]selftestResult{
+		{Case: cases[0], Status: "pass"},
+		{Case: cases[1], Status: "fail", Stage: "build", Reason: "cpx build exited with code 5"},
+	}))
+}
@@ -0,0 +1,750 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/naming"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/templates"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Placeholders replaced in the paths and contents of repository templates
+const (
+	placeholderProjectName  = "{{project_name}}"
+	placeholderProjectIdent = "{{project_ident}}"
+)
+
+// TemplateMetadataFile declares the description and variables of a
+// repository template. It is read from the template directory and not
+// copied into the project.
+const TemplateMetadataFile = "cpx-template.yaml"
+
+// templateMetadata is the content of TemplateMetadataFile
+type templateMetadata struct {
+	Description string             `yaml:"description"`
+	Variables   []templateVariable `yaml:"variables"`
+}
+
+// templateVariable is a value a template asks for when it is instantiated
+type templateVariable struct {
+	Name    string   `yaml:"name"`
+	Prompt  string   `yaml:"prompt"`
+	Default string   `yaml:"default"`
+	Choices []string `yaml:"choices"`
+}
+
+// variableNameRe matches the variable names usable as {{.name}}
+var variableNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// promptTemplateVariables asks for the values of template variables; a
+// variable for mocking in tests
+var promptTemplateVariables = func(title string, prompts []tui.Prompt) (map[string]string, error) {
+	return tui.RunPrompts(title, prompts)
+}
+
+// TemplateCmd creates the template command
+func TemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage project templates",
+		Long: `Manage the project templates of cpx new.
+
+Besides the built-in templates, cpx new instantiates templates from git
+repositories registered with "cpx config add-template-repo". Every top-level
+directory of such a repository is a template, used as
+"cpx new --template <repo>:<template> <project>". In its paths and files,
+` + placeholderProjectName + ` is replaced with the project name and ` + placeholderProjectIdent + `
+with the project name as a C++ identifier.
+
+A template can declare variables in ` + TemplateMetadataFile + `:
+
+  description: gRPC microservice
+  variables:
+    - name: port
+      prompt: Which port should the service listen on?
+      default: "50051"
+    - name: license
+      prompt: Which license?
+      choices: [MIT, Apache-2.0]
+
+cpx new asks for them (or takes them from --var name=value), and the paths
+and files of the template are then rendered with Go's text/template:
+{{.port}}, {{.license}}, {{.project_name}} and {{.project_ident}}.`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List built-in and repository templates",
+		Long: `List the built-in templates and the templates of every registered repository.
+Repositories are cloned into a local cache on first use; --refresh fetches
+them again.`,
+		Example: `  cpx template list            # list templates
+  cpx template list --refresh  # update the cached repositories first`,
+		RunE: runTemplateList,
+		Args: cobra.NoArgs,
+	}
+	listCmd.Flags().Bool("refresh", false, "Fetch the template repositories again instead of using the cache")
+	cmd.AddCommand(listCmd)
+
+	lintCmd := &cobra.Command{
+		Use:   "lint <dir|repo:template>",
+		Short: "Check a template for errors",
+		Long: `Check a template directory, or a template of a registered repository, before
+publishing it. The template must have a build file (CMakeLists.txt,
+meson.build or MODULE.bazel) and a C or C++ source file, every variable its
+files and paths use must be declared in ` + TemplateMetadataFile + `, and a project
+rendered from it with the variable defaults must configure in a temporary
+directory. Exits non-zero if any check fails.`,
+		Example: `  cpx template lint ./service              # lint a template directory
+  cpx template lint acme:service           # lint a cached repository template
+  cpx template lint ./service --no-configure`,
+		RunE: withExitCode(exitcode.QualityGate, runTemplateLint),
+		Args: cobra.ExactArgs(1),
+	}
+	lintCmd.Flags().Bool("no-configure", false, "Skip configuring a project rendered from the template")
+	cmd.AddCommand(lintCmd)
+
+	return cmd
+}
+
+func runTemplateList(cmd *cobra.Command, _ []string) error {
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fmt.Printf("%sBuilt-in templates%s\n", Bold, Reset)
+	for _, name := range templates.ProjectTemplates {
+		fmt.Printf("  %s\n", name)
+	}
+
+	for _, repo := range cfg.TemplateRepos {
+		ref := ""
+		if repo.Ref != "" {
+			ref = "@" + repo.Ref
+		}
+		fmt.Printf("\n%s%s%s %s(%s%s)%s\n", Bold, repo.Name, Reset, Dim, repo.URL, ref, Reset)
+
+		dir, err := fetchTemplateRepo(repo, refresh)
+		if err != nil {
+			fmt.Printf("  %sError: %v%s\n", Red, err, Reset)
+			continue
+		}
+		names, err := repoTemplates(dir)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Printf("  %s(no templates)%s\n", Dim, Reset)
+		}
+		for _, name := range names {
+			meta, err := loadTemplateMetadata(filepath.Join(dir, name))
+			if err != nil {
+				fmt.Printf("  %s:%s %s(%v)%s\n", repo.Name, name, Red, err, Reset)
+				continue
+			}
+			if meta != nil && meta.Description != "" {
+				fmt.Printf("  %-30s %s%s%s\n", repo.Name+":"+name, Dim, meta.Description, Reset)
+			} else {
+				fmt.Printf("  %s:%s\n", repo.Name, name)
+			}
+		}
+	}
+
+	if len(cfg.TemplateRepos) == 0 {
+		fmt.Printf("\n%sAdd template repositories with: cpx config add-template-repo <git-url>%s\n", Dim, Reset)
+	}
+	return nil
+}
+
+// templateRepoName derives the name of a template repository from its URL
+// ("https://github.com/acme/cpp-templates.git" is "cpp-templates")
+func templateRepoName(url string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// templateRepoDir returns the cache directory of a template repository
+func templateRepoDir(name string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "templates", name), nil
+}
+
+// fetchTemplateRepo returns the cached clone of repo, cloning it first if it
+// isn't cached yet or refresh is set
+func fetchTemplateRepo(repo config.TemplateRepo, refresh bool) (string, error) {
+	dir, err := templateRepoDir(repo.Name)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(dir); err == nil {
+		if !refresh {
+			return dir, nil
+		}
+		if offline.Enabled() {
+			fmt.Printf("%sOffline: using the cached %s repository%s\n", Dim, repo.Name, Reset)
+			return dir, nil
+		}
+	}
+	if err := offline.Required("cloning template repository " + repo.Name); err != nil {
+		return "", err
+	}
+
+	if _, err := execLookPath("git"); err != nil {
+		return "", exitcode.Errorf(exitcode.ToolchainMissing, "git not found in PATH: %w", err)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if repo.Ref != "" {
+		args = append(args, "--branch", repo.Ref)
+	}
+	args = append(args, repo.URL, dir)
+	out, err := execCommand("git", args...).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to clone %s: %w\n%s  hint: check the URL and ref with 'cpx config' and your git credentials", repo.URL, err, out)
+	}
+	return dir, nil
+}
+
+// repoTemplates lists the templates of a cloned repository: its top-level
+// directories, except hidden ones
+func repoTemplates(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// repoTemplateDir returns the directory of the template named by spec
+// ("<repo>:<template>") in the cached clone of its repository
+func repoTemplateDir(spec string) (string, error) {
+	repoName, templateName, _ := strings.Cut(spec, ":")
+	cfg, err := config.LoadGlobal()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+	repo, ok := cfg.TemplateRepo(repoName)
+	if !ok {
+		return "", exitcode.Errorf(exitcode.Config, "unknown template repository %q\n  hint: add it with 'cpx config add-template-repo <git-url> --name %s'", repoName, repoName)
+	}
+	dir, err := fetchTemplateRepo(repo, false)
+	if err != nil {
+		return "", err
+	}
+	srcDir := filepath.Join(dir, templateName)
+	if info, err := os.Stat(srcDir); err != nil || !info.IsDir() || templateName == "" || strings.HasPrefix(templateName, ".") {
+		return "", exitcode.Errorf(exitcode.Usage, "template %q not found in repository %s\n  hint: run 'cpx template list' (--refresh to update the cache)", templateName, repoName)
+	}
+	return srcDir, nil
+}
+
+// expandPlaceholders replaces the template placeholders in s
+func expandPlaceholders(s, projectName string) string {
+	s = strings.ReplaceAll(s, placeholderProjectName, projectName)
+	return strings.ReplaceAll(s, placeholderProjectIdent, naming.SafeIdent(projectName))
+}
+
+// loadTemplateMetadata reads the TemplateMetadataFile of the template in
+// dir; templates without one have nil metadata
+func loadTemplateMetadata(dir string) (*templateMetadata, error) {
+	data, err := os.ReadFile(filepath.Join(dir, TemplateMetadataFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta templateMetadata
+	if err := yaml.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", TemplateMetadataFile, err)
+	}
+	seen := make(map[string]bool)
+	for _, v := range meta.Variables {
+		switch {
+		case !variableNameRe.MatchString(v.Name):
+			return nil, fmt.Errorf("invalid %s: variable name %q is not an identifier", TemplateMetadataFile, v.Name)
+		case v.Name == "project_name" || v.Name == "project_ident":
+			return nil, fmt.Errorf("invalid %s: variable %s is reserved", TemplateMetadataFile, v.Name)
+		case seen[v.Name]:
+			return nil, fmt.Errorf("invalid %s: variable %s is declared twice", TemplateMetadataFile, v.Name)
+		case len(v.Choices) > 0 && v.Default != "" && !slices.Contains(v.Choices, v.Default):
+			return nil, fmt.Errorf("invalid %s: default %q of %s is not one of its choices", TemplateMetadataFile, v.Default, v.Name)
+		}
+		seen[v.Name] = true
+	}
+	return &meta, nil
+}
+
+// templateValues returns the values of the variables of meta: those given
+// in vars, and the answers to prompts for the others (their defaults with
+// useDefaults)
+func templateValues(meta *templateMetadata, spec string, vars map[string]string, useDefaults bool) (map[string]string, error) {
+	values := make(map[string]string)
+	declared := make(map[string]bool)
+	var prompts []tui.Prompt
+	for _, v := range meta.Variables {
+		declared[v.Name] = true
+		if value, ok := vars[v.Name]; ok {
+			if len(v.Choices) > 0 && !slices.Contains(v.Choices, value) {
+				return nil, exitcode.Errorf(exitcode.Usage, "invalid value %q for %s\n  hint: choose one of %s", value, v.Name, strings.Join(v.Choices, ", "))
+			}
+			values[v.Name] = value
+			continue
+		}
+		if useDefaults {
+			values[v.Name] = v.Default
+			continue
+		}
+		question := v.Prompt
+		if question == "" {
+			question = v.Name + ":"
+		}
+		prompts = append(prompts, tui.Prompt{Name: v.Name, Question: question, Default: v.Default, Choices: v.Choices})
+	}
+	for name := range vars {
+		if !declared[name] {
+			return nil, exitcode.Errorf(exitcode.Usage, "template %s has no variable %q", spec, name)
+		}
+	}
+
+	if len(prompts) > 0 {
+		answers, err := promptTemplateVariables("cpx new --template "+spec, prompts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run TUI: %w", err)
+		}
+		if answers == nil {
+			return nil, nil
+		}
+		for name, value := range answers {
+			values[name] = value
+		}
+	}
+	return values, nil
+}
+
+// templateRenderer returns the function that expands a path or file of a
+// template. Templates declaring metadata are rendered with text/template,
+// others only get their placeholders replaced, so that they may contain
+// "{{" in C++ code.
+func templateRenderer(meta *templateMetadata, projectName string, values map[string]string) func(name, s string) (string, error) {
+	if meta == nil {
+		return func(_, s string) (string, error) {
+			return expandPlaceholders(s, projectName), nil
+		}
+	}
+
+	data := map[string]string{
+		"project_name":  projectName,
+		"project_ident": naming.SafeIdent(projectName),
+	}
+	for name, value := range values {
+		data[name] = value
+	}
+	funcs := template.FuncMap{
+		"project_name":  func() string { return data["project_name"] },
+		"project_ident": func() string { return data["project_ident"] },
+	}
+	return func(name, s string) (string, error) {
+		tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(s)
+		if err != nil {
+			return "", err
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	}
+}
+
+// instantiateRepoTemplate writes the files of the template in srcDir through
+// w, expanding their paths and contents with render
+func instantiateRepoTemplate(srcDir string, render func(name, s string) (string, error), w *projectWriter) error {
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == TemplateMetadataFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		target, err := render(rel, rel)
+		if err != nil {
+			return fmt.Errorf("failed to render path %s: %w", rel, err)
+		}
+		// A variable must not move a file out of the project
+		if !filepath.IsLocal(filepath.FromSlash(target)) {
+			return exitcode.Errorf(exitcode.Usage, "template file %s renders to %q, outside the project\n  hint: check the template variables used in its path", rel, target)
+		}
+		content, err := render(rel, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to render %s: %w", rel, err)
+		}
+		if err := w.write(target, content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", target, err)
+		}
+		return nil
+	})
+}
+
+// createProjectFromRepoTemplate generates projectName from the template spec
+// ("<repo>:<template>") of a registered template repository. vars holds
+// values of template variables given on the command line; the others are
+// prompted for, or take their defaults with useDefaults.
+func createProjectFromRepoTemplate(spec, projectName string, vars map[string]string, useDefaults, forceMerge bool) error {
+	if !naming.IsValidProjectName(projectName) {
+		return exitcode.Errorf(exitcode.Usage, "invalid project name %q\n  hint: use letters, numbers, hyphens and underscores", projectName)
+	}
+
+	srcDir, err := repoTemplateDir(spec)
+	if err != nil {
+		return err
+	}
+	meta, err := loadTemplateMetadata(srcDir)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Config, err)
+	}
+	var values map[string]string
+	if meta != nil {
+		values, err = templateValues(meta, spec, vars, useDefaults)
+		if err != nil {
+			return err
+		}
+		if values == nil {
+			return nil // cancelled
+		}
+	} else if len(vars) > 0 {
+		return exitcode.Errorf(exitcode.Usage, "template %s declares no variables (no %s)", spec, TemplateMetadataFile)
+	}
+
+	if info, err := os.Stat(projectName); err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("'%s' already exists and is not a directory", projectName)
+		}
+		if !forceMerge && !isEmptyDir(projectName) {
+			return fmt.Errorf("directory '%s' already exists\n  hint: use --force-merge to generate into it without overwriting modified files", projectName)
+		}
+	} else {
+		forceMerge = false
+	}
+	w := newProjectWriter(projectName, forceMerge)
+
+	if err := os.MkdirAll(projectName, 0755); err != nil {
+		return fmt.Errorf("failed to create directory '%s': %w", projectName, err)
+	}
+	if err := instantiateRepoTemplate(srcDir, templateRenderer(meta, projectName, values), w); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(projectName, ".git")); os.IsNotExist(err) {
+		gitInit := execCommand("git", "init", "--quiet")
+		gitInit.Dir = projectName
+		_ = gitInit.Run() // Ignore errors silently
+	}
+
+	if err := w.saveManifest(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", TemplateManifestPath, err)
+	}
+	if forceMerge {
+		w.printMergeSummary()
+	}
+
+	logging.Success("\n✓ Project '%s' created from %s\n", projectName, spec)
+	fmt.Printf("  cd %s\n\n", projectName)
+	return nil
+}
+
+// templateBuildFiles are the build files a template must have one of, with
+// the command that configures a project using it
+var templateBuildFiles = []struct {
+	file string
+	tool string
+	args []string
+}{
+	{"CMakeLists.txt", "cmake", []string{"-S", ".", "-B", "build"}},
+	{"meson.build", "meson", []string{"setup", "builddir"}},
+	{"MODULE.bazel", "bazel", []string{"build", "--nobuild", "//..."}},
+}
+
+// placeholderRe matches the {{name}} placeholders of templates without metadata
+var placeholderRe = regexp.MustCompile(`\{\{([A-Za-z_][A-Za-z0-9_]*)\}\}`)
+
+func runTemplateLint(cmd *cobra.Command, args []string) error {
+	noConfigure, _ := cmd.Flags().GetBool("no-configure")
+
+	srcDir := args[0]
+	if info, err := os.Stat(srcDir); err != nil || !info.IsDir() {
+		if !strings.Contains(srcDir, ":") {
+			return exitcode.Errorf(exitcode.Usage, "template directory %q not found\n  hint: pass a directory or <repo>:<template>", srcDir)
+		}
+		if srcDir, err = repoTemplateDir(args[0]); err != nil {
+			return err
+		}
+	}
+
+	logging.Step("Linting template %s...", args[0])
+	problems, err := lintTemplate(srcDir, !noConfigure)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Printf("  %s%s%s %s\n", Red, IconError, Reset, p)
+		}
+		return fmt.Errorf("template %s has %d problem(s)", args[0], len(problems))
+	}
+	logging.Success("%s Template %s is valid", IconSuccess, args[0])
+	return nil
+}
+
+// lintTemplate checks the template in srcDir and returns its problems. With
+// configure set, a project rendered from it is configured in a temporary
+// directory.
+func lintTemplate(srcDir string, configure bool) ([]string, error) {
+	meta, err := loadTemplateMetadata(srcDir)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	declared := map[string]bool{"project_name": true, "project_ident": true}
+	values := make(map[string]string)
+	if meta != nil {
+		for _, v := range meta.Variables {
+			declared[v.Name] = true
+			values[v.Name] = v.Default
+			if v.Default == "" && len(v.Choices) > 0 {
+				values[v.Name] = v.Choices[0]
+			}
+		}
+	}
+
+	var problems []string
+	hasBuildFile, hasSource := false, false
+	undeclared := make(map[string]bool)
+	err = filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == TemplateMetadataFile || !d.Type().IsRegular() {
+			return nil
+		}
+		for _, b := range templateBuildFiles {
+			if rel == b.file {
+				hasBuildFile = true
+			}
+		}
+		switch filepath.Ext(rel) {
+		case ".c", ".cc", ".cpp", ".cxx":
+			hasSource = true
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, text := range []struct{ where, s string }{{"path of " + rel, rel}, {rel, string(data)}} {
+			refs, err := templateReferences(meta != nil, text.where, text.s)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", text.where, err))
+				continue
+			}
+			for _, name := range refs {
+				if !declared[name] {
+					problems = append(problems, fmt.Sprintf("%s: {{%s}} is not a declared variable", text.where, name))
+					undeclared[name] = true
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !hasBuildFile {
+		problems = append(problems, "no build file (CMakeLists.txt, meson.build or MODULE.bazel) at the top of the template")
+	}
+	if !hasSource {
+		problems = append(problems, "no C or C++ source file")
+	}
+	if len(undeclared) > 0 && meta == nil {
+		problems = append(problems, fmt.Sprintf("only %s and %s are replaced in templates without %s; declare other variables there", placeholderProjectName, placeholderProjectIdent, TemplateMetadataFile))
+	}
+	if !configure || !hasBuildFile {
+		return problems, nil
+	}
+
+	// Undeclared variables are already reported; render them empty so the
+	// rest of the template can still be configured
+	for name := range undeclared {
+		values[name] = ""
+	}
+	problem, err := configureTemplate(srcDir, meta, values)
+	if err != nil {
+		return nil, err
+	}
+	if problem != "" {
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
+// templateReferences returns the variables s refers to: the {{.name}} fields
+// of a template with metadata, or the {{name}} placeholders of one without
+func templateReferences(withMetadata bool, name, s string) ([]string, error) {
+	if !withMetadata {
+		var refs []string
+		for _, m := range placeholderRe.FindAllStringSubmatch(s, -1) {
+			refs = append(refs, m[1])
+		}
+		return refs, nil
+	}
+
+	funcs := template.FuncMap{"project_name": func() string { return "" }, "project_ident": func() string { return "" }}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	var refs []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, child := range n.Nodes {
+					walk(child)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, c := range n.Cmds {
+					walk(c)
+				}
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			refs = append(refs, n.Ident[0])
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		}
+	}
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root)
+	}
+	return refs, nil
+}
+
+// configureTemplate renders the template in srcDir into a temporary
+// directory and configures it, returning a problem if that fails
+func configureTemplate(srcDir string, meta *templateMetadata, values map[string]string) (string, error) {
+	sandbox, err := os.MkdirTemp("", "cpx-template-lint-")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(sandbox)
+
+	projectDir := filepath.Join(sandbox, "template-lint")
+	w := newProjectWriter(projectDir, false)
+	if err := instantiateRepoTemplate(srcDir, templateRenderer(meta, "template-lint", values), w); err != nil {
+		return err.Error(), nil
+	}
+
+	for _, b := range templateBuildFiles {
+		if _, err := os.Stat(filepath.Join(projectDir, b.file)); err != nil {
+			continue
+		}
+		if _, err := execLookPath(b.tool); err != nil {
+			return "", exitcode.Errorf(exitcode.ToolchainMissing, "%s not found in PATH, needed to configure the template\n  hint: install it, or pass --no-configure", b.tool)
+		}
+		logging.Info("Configuring with %s %s", b.tool, strings.Join(b.args, " "))
+		configure := execCommand(b.tool, b.args...)
+		configure.Dir = projectDir
+		if out, err := configure.CombinedOutput(); err != nil {
+			return fmt.Sprintf("%s configure failed: %v\n%s", b.tool, err, indent(strings.TrimSpace(string(out)), "    ")), nil
+		}
+		return "", nil
+	}
+	return "", nil
+}
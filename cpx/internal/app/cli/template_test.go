@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/app/cli/tui"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateRepoName(t *testing.T) {
+	assert.Equal(t, "cpp-templates", templateRepoName("https://github.com/acme/cpp-templates.git"))
+	assert.Equal(t, "templates", templateRepoName("git@github.com:acme/templates.git"))
+	assert.Equal(t, "local", templateRepoName("/srv/git/local/"))
+}
+
+// initTemplateRepo creates a git repository with a "service" template tagged
+// v1, and a later commit adding a "worker" template
+func initTemplateRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(rel, content string) {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	git("init", "--quiet")
+	write("README.md", "templates\n")
+	write("service/CMakeLists.txt", "project({{project_name}} LANGUAGES CXX)\n")
+	write("service/src/{{project_name}}.cpp", "namespace {{project_ident}} {}\n")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "service")
+	git("tag", "v1")
+	write("worker/main.cpp", "int main() {}\n")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "worker")
+	return dir
+}
+
+func TestCreateProjectFromRepoTemplate(t *testing.T) {
+	repoDir := initTemplateRepo(t)
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// Pinned to v1, which predates the worker template
+	require.NoError(t, addTemplateRepo(repoDir, "acme", "v1"))
+	cfg, err := config.LoadGlobal()
+	require.NoError(t, err)
+	repo, ok := cfg.TemplateRepo("acme")
+	require.True(t, ok)
+	assert.Equal(t, "v1", repo.Ref)
+
+	cacheDir, err := templateRepoDir("acme")
+	require.NoError(t, err)
+	names, err := repoTemplates(cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"service"}, names)
+
+	require.NoError(t, createProjectFromRepoTemplate("acme:service", "my-app", nil, false, false))
+	data, err := os.ReadFile(filepath.Join("my-app", "CMakeLists.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "project(my-app LANGUAGES CXX)\n", string(data))
+	data, err = os.ReadFile(filepath.Join("my-app", "src", "my-app.cpp"))
+	require.NoError(t, err)
+	assert.Equal(t, "namespace my_app {}\n", string(data))
+	assert.FileExists(t, filepath.Join("my-app", TemplateManifestPath))
+
+	err = createProjectFromRepoTemplate("acme:worker", "other", nil, false, false)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	err = createProjectFromRepoTemplate("nope:service", "other", nil, false, false)
+	assert.Equal(t, exitcode.Config, exitcode.Of(err))
+
+	// Re-adding without a ref follows the default branch
+	require.NoError(t, addTemplateRepo(repoDir, "acme", ""))
+	names, err = repoTemplates(cacheDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"service", "worker"}, names)
+	cfg, err = config.LoadGlobal()
+	require.NoError(t, err)
+	assert.Len(t, cfg.TemplateRepos, 1)
+}
+
+func TestTemplateRepoOffline(t *testing.T) {
+	repoDir := initTemplateRepo(t)
+	t.Setenv("HOME", t.TempDir())
+	require.NoError(t, addTemplateRepo(repoDir, "acme", ""))
+
+	t.Setenv(offline.EnvVar, "1")
+	// The cache is used even when a refresh is asked for
+	dir, err := fetchTemplateRepo(config.TemplateRepo{Name: "acme", URL: repoDir}, true)
+	require.NoError(t, err)
+	assert.DirExists(t, filepath.Join(dir, "service"))
+
+	_, err = fetchTemplateRepo(config.TemplateRepo{Name: "other", URL: repoDir}, false)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	err = addTemplateRepo(repoDir, "acme", "")
+	assert.ErrorContains(t, err, "cpx is offline")
+}
+
+func TestLoadTemplateMetadata(t *testing.T) {
+	dir := t.TempDir()
+	meta, err := loadTemplateMetadata(dir)
+	require.NoError(t, err)
+	assert.Nil(t, meta)
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"valid", "description: svc\nvariables:\n  - name: port\n    default: \"80\"\n", ""},
+		{"bad name", "variables:\n  - name: service-port\n", "not an identifier"},
+		{"reserved", "variables:\n  - name: project_name\n", "reserved"},
+		{"duplicate", "variables:\n  - name: a\n  - name: a\n", "declared twice"},
+		{"default not a choice", "variables:\n  - name: license\n    choices: [MIT]\n    default: GPL\n", "not one of its choices"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, TemplateMetadataFile), []byte(tt.content), 0644))
+			meta, err := loadTemplateMetadata(dir)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "svc", meta.Description)
+		})
+	}
+}
+
+func TestParameterizedTemplate(t *testing.T) {
+	srcDir := t.TempDir()
+	files := map[string]string{
+		TemplateMetadataFile: `variables:
+  - name: port
+    prompt: Port?
+    default: "50051"
+  - name: license
+    choices: [MIT, Apache-2.0]
+    default: MIT
+  - name: namespace
+`,
+		"src/{{.project_ident}}.cpp": "namespace {{.namespace}} { constexpr int port = {{.port}}; }\n",
+		"LICENSE":                    "{{.license}}\n",
+		"docs/{{project_name}}.md":   "# {{project_name}}\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(srcDir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	meta, err := loadTemplateMetadata(srcDir)
+	require.NoError(t, err)
+
+	// Variables given with --var aren't prompted for
+	oldPrompt := promptTemplateVariables
+	defer func() { promptTemplateVariables = oldPrompt }()
+	var asked []string
+	promptTemplateVariables = func(_ string, prompts []tui.Prompt) (map[string]string, error) {
+		answers := make(map[string]string)
+		for _, p := range prompts {
+			asked = append(asked, p.Name)
+			answers[p.Name] = p.Default
+		}
+		answers["namespace"] = "acme"
+		return answers, nil
+	}
+	values, err := templateValues(meta, "acme:svc", map[string]string{"port": "8080"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"license", "namespace"}, asked)
+	assert.Equal(t, map[string]string{"port": "8080", "license": "MIT", "namespace": "acme"}, values)
+
+	_, err = templateValues(meta, "acme:svc", map[string]string{"license": "GPL"}, false)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	_, err = templateValues(meta, "acme:svc", map[string]string{"colour": "red"}, false)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+
+	// --yes takes the defaults without prompting
+	asked = nil
+	defaults, err := templateValues(meta, "acme:svc", nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, asked)
+	assert.Equal(t, map[string]string{"port": "50051", "license": "MIT", "namespace": ""}, defaults)
+
+	projectDir := t.TempDir()
+	w := newProjectWriter(projectDir, false)
+	require.NoError(t, instantiateRepoTemplate(srcDir, templateRenderer(meta, "my-svc", values), w))
+
+	data, err := os.ReadFile(filepath.Join(projectDir, "src", "my_svc.cpp"))
+	require.NoError(t, err)
+	assert.Equal(t, "namespace acme { constexpr int port = 8080; }\n", string(data))
+	data, err = os.ReadFile(filepath.Join(projectDir, "LICENSE"))
+	require.NoError(t, err)
+	assert.Equal(t, "MIT\n", string(data))
+	data, err = os.ReadFile(filepath.Join(projectDir, "docs", "my-svc.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# my-svc\n", string(data))
+	assert.NoFileExists(t, filepath.Join(projectDir, TemplateMetadataFile))
+
+	// Unknown variables are errors rather than empty strings
+	render := templateRenderer(meta, "my-svc", values)
+	_, err = render("x", "{{.missing}}")
+	assert.Error(t, err)
+}
+
+func TestRepoTemplatePathOutsideProject(t *testing.T) {
+	srcDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(srcDir, "{{.dir}}.txt"), []byte("x"), 0644))
+	meta := &templateMetadata{Variables: []templateVariable{{Name: "dir"}}}
+
+	root := t.TempDir()
+	projectDir := filepath.Join(root, "a", "project")
+	for _, dir := range []string{"../../escaped", "/tmp/escaped"} {
+		w := newProjectWriter(projectDir, false)
+		err := instantiateRepoTemplate(srcDir, templateRenderer(meta, "project", map[string]string{"dir": dir}), w)
+		assert.Equal(t, exitcode.Usage, exitcode.Of(err), dir)
+		assert.ErrorContains(t, err, "outside the project")
+	}
+	assert.NoFileExists(t, filepath.Join(root, "escaped.txt"))
+}
+
+func TestTemplateLint(t *testing.T) {
+	oldExecCommand, oldLookPath := execCommand, execLookPath
+	defer func() { execCommand, execLookPath = oldExecCommand, oldLookPath }()
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+	execLookPath = func(file string) (string, error) { return "/usr/bin/" + file, nil }
+
+	problems, err := lintTemplate(filepath.Join("testdata", "templates", "service"), true)
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+
+	problems, err = lintTemplate(filepath.Join("testdata", "templates", "broken"), true)
+	require.NoError(t, err)
+	require.Len(t, problems, 3)
+	assert.Equal(t, "CMakeLists.txt: {{namespace}} is not a declared variable", problems[0])
+	assert.Equal(t, "no C or C++ source file", problems[1])
+	assert.Contains(t, problems[2], "cmake configure failed")
+	assert.Contains(t, problems[2], "CMake Error")
+
+	// Without configuring, only the static checks run
+	problems, err = lintTemplate(filepath.Join("testdata", "templates", "broken"), false)
+	require.NoError(t, err)
+	assert.Len(t, problems, 2)
+
+	cmd := TemplateCmd()
+	cmd.SetArgs([]string{"lint", filepath.Join("testdata", "templates", "broken")})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Equal(t, exitcode.QualityGate, exitcode.Of(cmd.Execute()))
+
+	cmd.SetArgs([]string{"lint", filepath.Join("testdata", "templates", "missing")})
+	assert.Equal(t, exitcode.Usage, exitcode.Of(cmd.Execute()))
+}
+
+func TestTemplateLintPlaceholders(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "meson.build"), []byte("project('{{project_name}}', '{{version}}')\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.cpp"), []byte("int main() { int a[1][1] = {{0}}; }\n"), 0644))
+
+	problems, err := lintTemplate(dir, false)
+	require.NoError(t, err)
+	require.Len(t, problems, 2)
+	assert.Equal(t, "meson.build: {{version}} is not a declared variable", problems[0])
+	assert.Contains(t, problems[1], "declare other variables there")
+
+	// Unknown functions are parse errors in templates with metadata
+	require.NoError(t, os.WriteFile(filepath.Join(dir, TemplateMetadataFile), []byte("variables:\n  - name: version\n"), 0644))
+	problems, err = lintTemplate(dir, false)
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0], `function "version" not defined`)
+}
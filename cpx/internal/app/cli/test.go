@@ -3,8 +3,17 @@ package cli
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/internal/pkg/quality"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
@@ -14,17 +23,41 @@ func TestCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "test",
 		Short: "Build and run tests",
-		Long:  "Build the project tests and run them. Detects vcpkg/CMake or Bazel projects automatically.",
+		Long: `Build the project tests and run them. Detects vcpkg/CMake, Bazel or Meson projects automatically.
+
+--filter is translated to the test framework's own syntax:
+  - googletest: --gtest_filter (e.g. MySuite.*)
+  - Catch2: test spec, names or [tags]
+  - doctest: --test-case
+  - otherwise: ctest -R regex, or meson test name
+Bazel target patterns (//pkg:target) select targets instead of cases.
+
+Arguments after -- are passed to the test executable. --debug runs the tests
+under gdb or lldb (lldb on macOS; override with $CPX_DEBUGGER).
+
+With --json, cpx prints the totals and every test case with its status from
+the JUnit results (also written to --report, if given).`,
 		Example: `  cpx test                 # Build + run all tests
   cpx test --verbose       # Show verbose output
-  cpx test --filter MySuite.*`,
-		RunE: func(cmd *cobra.Command, args []string) error {
+  cpx test --filter MySuite.*
+  cpx test --filter "[fast]"   # Catch2 tag
+  cpx test --report junit.xml   # Write JUnit XML results for CI
+  cpx test --json          # Print the results as JSON
+  cpx test --watch         # Re-run affected tests on every change
+  cpx test --memcheck      # Run tests under valgrind
+  cpx test --debug --filter MySuite.Crashes  # Debug one test under gdb/lldb`,
+		RunE: withExitCode(exitcode.TestFailed, func(cmd *cobra.Command, args []string) error {
 			return runTest(cmd, args, client)
-		},
+		}),
 	}
 
 	cmd.Flags().BoolP("verbose", "v", false, "Show verbose test output")
-	cmd.Flags().String("filter", "", "Filter tests by name (ctest regex or bazel target)")
+	cmd.Flags().String("filter", "", "Filter tests by name, mapped to the framework's filter syntax")
+	cmd.Flags().String("report", "", "Write test results as JUnit XML to the given file")
+	cmd.Flags().BoolP("watch", "w", false, "Watch source and test files and re-run tests on changes")
+	cmd.Flags().Bool("memcheck", false, "Run tests under valgrind and record leaks/errors for 'cpx analyze'")
+	cmd.Flags().Bool("debug", false, "Run the tests under gdb or lldb")
+	cmd.RegisterFlagCompletionFunc("filter", completeTestNames)
 
 	return cmd
 }
@@ -32,31 +65,234 @@ func TestCmd(client *vcpkg.Client) *cobra.Command {
 func runTest(cmd *cobra.Command, args []string, client *vcpkg.Client) error {
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	filter, _ := cmd.Flags().GetString("filter")
+	report, _ := cmd.Flags().GetString("report")
+	watch, _ := cmd.Flags().GetBool("watch")
+	memcheck, _ := cmd.Flags().GetBool("memcheck")
+	debug, _ := cmd.Flags().GetBool("debug")
 
 	// Detect project type
 	projectType := DetectProjectType()
 
+	if debug {
+		if output.JSON() {
+			return exitcode.Errorf(exitcode.Usage, "--json cannot be combined with --debug")
+		}
+		if watch || memcheck {
+			return exitcode.Errorf(exitcode.Usage, "--debug cannot be combined with --watch or --memcheck")
+		}
+		return runDebugTest(projectType, filter, args, client)
+	}
+
+	if watch {
+		if output.JSON() {
+			return exitcode.Errorf(exitcode.Usage, "--json cannot be combined with --watch")
+		}
+		return runTestWatch(projectType, verbose, filter, report, client)
+	}
+
+	if output.JSON() {
+		return runTestJSON(report, func(report string) error {
+			return runTestOnce(projectType, verbose, filter, report, memcheck, args, client)
+		})
+	}
+	return runTestOnce(projectType, verbose, filter, report, memcheck, args, client)
+}
+
+// runTestOnce builds and runs the tests, under valgrind with memcheck
+func runTestOnce(projectType ProjectType, verbose bool, filter, report string, memcheck bool, args []string, client *vcpkg.Client) error {
+	if memcheck {
+		return runMemcheckTest(projectType, verbose, filter, report, client)
+	}
+
 	switch projectType {
 	case ProjectTypeBazel:
-		return runBazelTest(verbose, filter)
+		var testArgs []string
+		for _, arg := range args {
+			testArgs = append(testArgs, "--test_arg="+arg)
+		}
+		return runBazelTestWith(verbose, filter, report, testArgs...)
 	case ProjectTypeMeson:
-		return runMesonTest(verbose, filter)
+		return runMesonTestWith(verbose, filter, report, args)
 	default:
 		// CMake/vcpkg
-		return build.RunTests(verbose, filter, client)
+		return build.RunTests(verbose, filter, report, nil, args, client)
 	}
 }
 
-func runBazelTest(verbose bool, filter string) error {
-	fmt.Printf("%sRunning Bazel tests...%s\n", Cyan, Reset)
+// runDebugTest runs the tests under the platform debugger
+func runDebugTest(projectType ProjectType, filter string, args []string, client *vcpkg.Client) error {
+	debugger, err := debuggerPrefix("")
+	if err != nil {
+		return err
+	}
 
-	bazelArgs := []string{"test"}
+	switch projectType {
+	case ProjectTypeBazel:
+		return runBazelDebugTest(filter, args, debugger)
+	case ProjectTypeMeson:
+		// meson test --gdb runs tests interactively without timeouts
+		return runMesonTestWith(false, filter, "", args, "--gdb", "--gdb-path="+debugger[0])
+	default:
+		return build.RunTests(false, filter, "", debugger, args, client)
+	}
+}
 
-	// Add filter if provided (bazel target pattern)
-	if filter != "" {
-		bazelArgs = append(bazelArgs, filter)
+// runBazelDebugTest runs a single test target with bazel run, since bazel
+// test has no terminal for an interactive debugger
+func runBazelDebugTest(filter string, args []string, debugger []string) error {
+	target := ""
+	if isBazelTargetPattern(filter) {
+		target, filter = filter, ""
 	} else {
-		bazelArgs = append(bazelArgs, "//...")
+		out, err := execCommand("bazel", "query", `kind(".*_test rule", //...)`, "--output=label").Output()
+		if err != nil {
+			return fmt.Errorf("failed to list test targets: %w", err)
+		}
+		var targets []string
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "//") {
+				targets = append(targets, line)
+			}
+		}
+		switch len(targets) {
+		case 0:
+			return fmt.Errorf("no test targets found")
+		case 1:
+			target = targets[0]
+		default:
+			return exitcode.Errorf(exitcode.Usage, "multiple test targets found: %s\n  hint: pick one with --filter //pkg:target", strings.Join(targets, ", "))
+		}
+	}
+
+	bazelArgs := []string{"run", "--config=debug", "--strip=never", "--run_under=" + build.ShellJoin(debugger), target}
+	binaryArgs := append(build.TestFilterArgs(build.DetectTestFramework("."), filter), args...)
+	if len(binaryArgs) > 0 {
+		bazelArgs = append(bazelArgs, "--")
+		bazelArgs = append(bazelArgs, binaryArgs...)
+	}
+
+	logging.Step("Debugging %s...", target)
+	runCmd := execCommand("bazel", bazelArgs...)
+	runCmd.Stdout = os.Stdout
+	runCmd.Stderr = os.Stderr
+	runCmd.Stdin = os.Stdin
+	return runCmd.Run()
+}
+
+// runMemcheckTest runs the tests under valgrind and stores the findings
+// where 'cpx analyze' picks them up
+func runMemcheckTest(projectType ProjectType, verbose bool, filter, report string, client *vcpkg.Client) error {
+	xmlDir := filepath.Join(".cache", "memcheck", "xml")
+	wrapper, err := quality.MemcheckPrefix(xmlDir)
+	if err != nil {
+		return err
+	}
+
+	var runErr error
+	switch projectType {
+	case ProjectTypeBazel:
+		absDir, _ := filepath.Abs(xmlDir)
+		runErr = runBazelTestWith(verbose, filter, report,
+			"--run_under="+strings.Join(wrapper, " "), "--sandbox_writable_path="+absDir)
+	case ProjectTypeMeson:
+		runErr = runMesonTestWith(verbose, filter, report, nil, "--wrapper="+strings.Join(wrapper, " "))
+	default:
+		runErr = build.RunTests(verbose, filter, report, wrapper, nil, client)
+	}
+
+	results := quality.CollectMemcheckResults(xmlDir)
+	if err := quality.SaveMemcheckResults(results); err != nil {
+		return err
+	}
+	quality.PrintMemcheckSummary(results)
+
+	return runErr
+}
+
+// runTestWatch re-runs the tests whenever a watched file changes
+func runTestWatch(projectType ProjectType, verbose bool, filter, report string, client *vcpkg.Client) error {
+	config := buildWatchConfig(projectType)
+
+	switch projectType {
+	case ProjectTypeBazel:
+		return build.WatchAndTest(config, func(changes []string) error {
+			// Only re-run the test targets that depend on the changed files
+			if targets := affectedBazelTestTargets(changes); len(targets) > 0 && !isBazelTargetPattern(filter) {
+				return runBazelTestTargets(targets, filter, verbose, report)
+			}
+			return runBazelTest(verbose, filter, report)
+		})
+	case ProjectTypeMeson:
+		return build.WatchAndTest(config, func(_ []string) error {
+			// meson test rebuilds outdated targets before running
+			return runMesonTest(verbose, filter, report)
+		})
+	default:
+		// CMake projects build a single <project>_tests target, so every
+		// change re-runs it
+		return build.WatchAndTest(config, func(_ []string) error {
+			return build.RunTests(verbose, filter, report, nil, nil, client)
+		})
+	}
+}
+
+// affectedBazelTestTargets asks bazel which test targets depend on the
+// changed files. Returns nil if the query fails or nothing matched.
+func affectedBazelTestTargets(changes []string) []string {
+	var files []string
+	for _, change := range changes {
+		// Deleted files can't be queried
+		if strings.HasSuffix(change, " (deleted)") {
+			return nil
+		}
+		files = append(files, change)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`kind(".*_test rule", rdeps(//..., set(%s)))`, strings.Join(files, " "))
+	out, err := execCommand("bazel", "query", query, "--output=label", "--keep_going").Output()
+	if err != nil && len(out) == 0 {
+		return nil
+	}
+
+	var targets []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "//") {
+			targets = append(targets, line)
+		}
+	}
+	return targets
+}
+
+// isBazelTargetPattern reports whether filter selects targets rather than test cases
+func isBazelTargetPattern(filter string) bool {
+	return strings.HasPrefix(filter, "//") || strings.HasPrefix(filter, ":")
+}
+
+func runBazelTest(verbose bool, filter, report string) error {
+	return runBazelTestWith(verbose, filter, report)
+}
+
+// runBazelTestWith runs bazel test with extra flags (e.g. --run_under)
+func runBazelTestWith(verbose bool, filter, report string, extraArgs ...string) error {
+	// Target patterns select targets; anything else filters test cases
+	if isBazelTargetPattern(filter) {
+		return runBazelTestTargets([]string{filter}, "", verbose, report, extraArgs...)
+	}
+	return runBazelTestTargets([]string{"//..."}, filter, verbose, report, extraArgs...)
+}
+
+func runBazelTestTargets(targets []string, caseFilter string, verbose bool, report string, extraArgs ...string) error {
+	events.Phase("test")
+	logging.Step("Running Bazel tests...")
+
+	bazelArgs := []string{"test"}
+	bazelArgs = append(bazelArgs, targets...)
+	bazelArgs = append(bazelArgs, extraArgs...)
+	if caseFilter != "" {
+		bazelArgs = append(bazelArgs, bazelTestFilterArgs(build.DetectTestFramework("."), caseFilter)...)
 	}
 
 	// Add verbose flag
@@ -68,25 +304,51 @@ func runBazelTest(verbose bool, filter string) error {
 		bazelArgs = append(bazelArgs, "--noshow_progress", "--symlink_prefix=.bazel-")
 	}
 
+	if report != "" {
+		// Cached results would be missing from the report, as their
+		// test.xml files predate this run
+		bazelArgs = append(bazelArgs, "--nocache_test_results")
+	}
+
 	testCmd := execCommand("bazel", bazelArgs...)
 	testCmd.Stdout = os.Stdout
 	testCmd.Stderr = os.Stderr
 
-	if err := testCmd.Run(); err != nil {
-		return fmt.Errorf("bazel test failed: %w", err)
+	start := time.Now()
+	runErr := testCmd.Run()
+	if report != "" {
+		// The testlogs symlink depends on --symlink_prefix, so ask Bazel
+		out, err := execCommand("bazel", "info", "bazel-testlogs").Output()
+		if err != nil {
+			return fmt.Errorf("bazel info bazel-testlogs failed: %w", err)
+		}
+		// Skip the test.xml files left by earlier runs
+		if err := writeJUnitReport(strings.TrimSpace(string(out)), "test.xml", report, start); err != nil {
+			return err
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("bazel test failed: %w", runErr)
 	}
 
-	fmt.Printf("%s✓ Tests passed%s\n", Green, Reset)
+	logging.Success("✓ Tests passed")
 	return nil
 }
 
-func runMesonTest(verbose bool, filter string) error {
-	fmt.Printf("%sRunning Meson tests...%s\n", Cyan, Reset)
+func runMesonTest(verbose bool, filter, report string) error {
+	return runMesonTestWith(verbose, filter, report, nil)
+}
+
+// runMesonTestWith runs meson test with testArgs passed to the test
+// executables and extra meson flags (e.g. --wrapper)
+func runMesonTestWith(verbose bool, filter, report string, testArgs []string, extraArgs ...string) error {
+	events.Phase("test")
+	logging.Step("Running Meson tests...")
 
 	// Ensure builddir exists
 	if _, err := os.Stat("builddir"); os.IsNotExist(err) {
 		// Need to setup first
-		if err := runMesonBuild(false, "", false, verbose, "", ""); err != nil {
+		if err := runMesonBuild(false, "", false, verbose, "", "", false, build.Compiler{}, build.CrossToolchain{}); err != nil {
 			return fmt.Errorf("build failed: %w", err)
 		}
 	}
@@ -105,19 +367,167 @@ func runMesonTest(verbose bool, filter string) error {
 	} else {
 		mesonArgs = append(mesonArgs, "--quiet")
 	}
+	mesonArgs = append(mesonArgs, extraArgs...)
 
-	if filter != "" {
+	// Meson registers one test per binary, so pass case filters through
+	// to the framework and treat anything else as a meson test name
+	frameworkArgs := build.TestFilterArgs(build.DetectTestFramework("."), filter)
+	if filter != "" && len(frameworkArgs) == 0 {
 		mesonArgs = append(mesonArgs, filter)
 	}
+	if binaryArgs := append(frameworkArgs, testArgs...); len(binaryArgs) > 0 {
+		// meson splits --test-args shell-style, so quote names containing spaces
+		for i, arg := range binaryArgs {
+			binaryArgs[i] = strconv.Quote(arg)
+		}
+		mesonArgs = append(mesonArgs, "--test-args="+strings.Join(binaryArgs, " "))
+	}
 
 	testCmd := execCommand("meson", mesonArgs...)
 	testCmd.Stdout = os.Stdout
 	testCmd.Stderr = os.Stderr
 
-	if err := testCmd.Run(); err != nil {
-		return fmt.Errorf("meson test failed: %w", err)
+	runErr := testCmd.Run()
+	if report != "" {
+		if err := writeJUnitReport(filepath.Join("builddir", "meson-logs"), "testlog.junit.xml", report, time.Time{}); err != nil {
+			return err
+		}
+	}
+	if runErr != nil {
+		return fmt.Errorf("meson test failed: %w", runErr)
 	}
 
-	fmt.Printf("%s✓ Tests passed%s\n", Green, Reset)
+	logging.Success("✓ Tests passed")
 	return nil
 }
+
+// bazelTestFilterArgs maps a test case filter to bazel test flags.
+// googletest and Catch2 read --test_filter via TESTBRIDGE_TEST_ONLY;
+// doctest does not, so its filter is passed as a test argument.
+func bazelTestFilterArgs(framework, filter string) []string {
+	if framework == build.TestFrameworkDoctest {
+		return []string{"--test_arg=--test-case=" + filter}
+	}
+	return []string{"--test_filter=" + filter}
+}
+
+// writeJUnitReport merges the JUnit XML files named name found under dir
+// into a single report at reportPath
+func writeJUnitReport(dir, name, reportPath string, since time.Time) error {
+	files, err := build.FindJUnitFiles(dir, name, since)
+	if err != nil {
+		return fmt.Errorf("failed to collect test results: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no test results found in %s", dir)
+	}
+
+	merged, err := build.MergeJUnitFiles(files)
+	if err != nil {
+		return fmt.Errorf("failed to merge test results: %w", err)
+	}
+	if err := build.WriteJUnitReport(merged, reportPath); err != nil {
+		return err
+	}
+
+	fmt.Printf("%sJUnit report written to %s (%d tests, %d failures)%s\n", Dim, reportPath, merged.Tests, merged.Failures+merged.Errors, Reset)
+	return nil
+}
+
+// testResults is the output of cpx test --json
+type testResults struct {
+	Passed   bool         `json:"passed"`
+	Tests    int          `json:"tests"`
+	Failures int          `json:"failures"`
+	Errors   int          `json:"errors"`
+	Skipped  int          `json:"skipped"`
+	Time     float64      `json:"time"`
+	Cases    []testResult `json:"cases"`
+}
+
+// testResult is a test case of cpx test --json
+type testResult struct {
+	Suite   string  `json:"suite"`
+	Name    string  `json:"name"`
+	Status  string  `json:"status"` // passed, failed, error or skipped
+	Time    float64 `json:"time"`
+	Message string  `json:"message,omitempty"`
+}
+
+// runTestJSON runs the tests with a JUnit report, in a temporary file unless
+// --report names one, and prints its results as JSON. Without a report
+// (e.g. the build failed), the error is all there is to print.
+func runTestJSON(report string, run func(report string) error) error {
+	if report == "" {
+		dir, err := os.MkdirTemp("", "cpx-test-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		report = filepath.Join(dir, "junit.xml")
+	} else {
+		// The report of an earlier run would pass for this one if the
+		// build fails
+		os.Remove(report)
+	}
+
+	runErr := run(report)
+	data, err := os.ReadFile(report)
+	if err != nil {
+		return runErr
+	}
+	suites, err := build.ParseJUnitXML(data)
+	if err != nil {
+		if runErr != nil {
+			return runErr
+		}
+		return err
+	}
+	results := junitTestResults(suites)
+	// A crash can fail the run without failing a recorded case
+	results.Passed = results.Passed && runErr == nil
+	if err := output.Print(results); err != nil {
+		return err
+	}
+	return runErr
+}
+
+// junitTestResults converts a JUnit report into the output of cpx test
+// --json, counting the cases since not every framework fills in the totals
+func junitTestResults(suites *build.JUnitTestSuites) testResults {
+	results := testResults{Cases: []testResult{}}
+	for _, suite := range suites.Suites {
+		for _, c := range suite.Cases {
+			result := testResult{Suite: suite.Name, Name: c.Name, Status: "passed", Time: c.Time}
+			if c.ClassName != "" {
+				result.Suite = c.ClassName
+			}
+			var message *build.JUnitMessage
+			switch {
+			case c.Failure != nil:
+				result.Status, message = "failed", c.Failure
+				results.Failures++
+			case c.Error != nil:
+				result.Status, message = "error", c.Error
+				results.Errors++
+			case c.Skipped != nil:
+				result.Status, message = "skipped", c.Skipped
+				results.Skipped++
+			}
+			if message != nil {
+				result.Message = message.Message
+				if result.Message == "" {
+					result.Message = strings.TrimSpace(message.Body)
+				}
+			}
+			results.Tests++
+			results.Time += c.Time
+			results.Cases = append(results.Cases, result)
+		}
+	}
+	if suites.Time > 0 {
+		results.Time = suites.Time
+	}
+	results.Passed = results.Failures == 0 && results.Errors == 0
+	return results
+}
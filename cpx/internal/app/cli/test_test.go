@@ -1,10 +1,15 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/output"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -51,12 +56,18 @@ func TestRunBazelTest(t *testing.T) {
 			filter:     "//tests:unit_test",
 			wantOutput: "//tests:unit_test",
 		},
+		{
+			name:       "Filter test cases",
+			verbose:    false,
+			filter:     "MySuite.*",
+			wantOutput: "--test_filter=MySuite.*",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			capturedArgs = nil
-			err := runBazelTest(tt.verbose, tt.filter)
+			err := runBazelTest(tt.verbose, tt.filter, "")
 			assert.NoError(t, err)
 
 			require.GreaterOrEqual(t, len(capturedArgs), 1)
@@ -122,7 +133,7 @@ func TestRunMesonTest(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			capturedArgs = nil
-			err := runMesonTest(tt.verbose, tt.filter)
+			err := runMesonTest(tt.verbose, tt.filter, "")
 			assert.NoError(t, err)
 
 			require.GreaterOrEqual(t, len(capturedArgs), 1)
@@ -143,3 +154,204 @@ func TestRunMesonTest(t *testing.T) {
 		})
 	}
 }
+
+func TestRunMesonTestReport(t *testing.T) {
+	// Mock execCommand
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	require.NoError(t, os.WriteFile("meson.build", []byte("project('test', 'cpp')"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join("builddir", "meson-logs"), 0755))
+
+	// No results yet: report should fail
+	err = runMesonTest(false, "", "junit.xml")
+	assert.Error(t, err)
+
+	// Meson writes testlog.junit.xml after each run
+	require.NoError(t, os.WriteFile(filepath.Join("builddir", "meson-logs", "testlog.junit.xml"), []byte(`<testsuites>
+  <testsuite name="test" tests="1"><testcase name="unit" classname="test"/></testsuite>
+</testsuites>`), 0644))
+
+	err = runMesonTest(false, "", filepath.Join("reports", "junit.xml"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join("reports", "junit.xml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<testcase name="unit" classname="test"`)
+}
+
+func TestBazelTestReport(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	t.Chdir(t.TempDir())
+	testlogs := t.TempDir()
+	t.Setenv("CPX_TEST_TESTLOGS", testlogs)
+
+	// A target that didn't run this time left its result behind
+	stale := filepath.Join(testlogs, "tests", "old_test", "test.xml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	require.NoError(t, os.WriteFile(stale, []byte(`<testsuite name="//tests:old_test"><testcase name="B.fail"><failure/></testcase></testsuite>`), 0644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(stale, old, old))
+
+	require.NoError(t, runBazelTest(false, "//tests:unit_test", "junit.xml"))
+	assert.Contains(t, capturedArgs[0], "--nocache_test_results")
+	assert.Equal(t, []string{"bazel", "info", "bazel-testlogs"}, capturedArgs[1])
+
+	data, err := os.ReadFile("junit.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "//tests:unit_test")
+	assert.NotContains(t, string(data), "//tests:old_test")
+}
+
+func TestAffectedBazelTestTargets(t *testing.T) {
+	// Mock execCommand
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+
+	var capturedArgs [][]string
+
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	targets := affectedBazelTestTargets([]string{"src/lib.cpp", "include/lib.hpp"})
+	assert.Equal(t, []string{"//tests:unit_test", "//tests:integration_test"}, targets)
+	require.Len(t, capturedArgs, 1)
+	assert.Contains(t, capturedArgs[0][2], "set(src/lib.cpp include/lib.hpp)")
+
+	// Deleted files can't be queried, so everything is re-run
+	capturedArgs = nil
+	assert.Nil(t, affectedBazelTestTargets([]string{"src/old.cpp (deleted)"}))
+	assert.Empty(t, capturedArgs)
+}
+
+func TestRunBazelDebugTest(t *testing.T) {
+	// Mock execCommand
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+
+	var capturedArgs [][]string
+
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		args := append([]string{name}, arg...)
+		capturedArgs = append(capturedArgs, args)
+
+		cs := []string{"-test.run=TestHelperProcess", "--", name}
+		cs = append(cs, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	debugger := []string{"gdb", "--cd=/work", "--args"}
+
+	// The helper's query reports two test targets
+	err := runBazelDebugTest("", nil, debugger)
+	assert.ErrorContains(t, err, "multiple test targets found")
+
+	capturedArgs = nil
+	err = runBazelDebugTest("//tests:unit_test", []string{"--verbose"}, debugger)
+	require.NoError(t, err)
+	require.Len(t, capturedArgs, 1)
+	args := capturedArgs[0]
+	assert.Equal(t, []string{"bazel", "run"}, args[:2])
+	assert.Contains(t, args, "--run_under=gdb --cd=/work --args")
+	assert.Contains(t, args, "//tests:unit_test")
+	assert.Equal(t, []string{"--", "--verbose"}, args[len(args)-2:])
+}
+
+// captureJSON runs fn in JSON output mode and returns what it printed
+func captureJSON(t *testing.T, fn func() error) ([]byte, error) {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	oldStdout := os.Stdout
+	defer func() { os.Stdout = oldStdout }()
+	os.Stdout = f
+
+	output.EnableJSON()
+	runErr := fn()
+	output.Reset()
+	data, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	return data, runErr
+}
+
+func TestRunTestJSON(t *testing.T) {
+	report := `<testsuites time="0.5">
+  <testsuite name="unit_tests">
+    <testcase name="Adds" classname="Math" time="0.1"/>
+    <testcase name="Divides" classname="Math" time="0.2">
+      <failure message="expected 2, got 3">math_test.cpp:12</failure>
+    </testcase>
+    <testcase name="Slow" classname="Math" time="0"><skipped/></testcase>
+  </testsuite>
+</testsuites>`
+	testErr := errors.New("1 test failed")
+	var reportPath string
+	data, err := captureJSON(t, func() error {
+		return runTestJSON("", func(path string) error {
+			reportPath = path
+			require.NoError(t, os.WriteFile(path, []byte(report), 0644))
+			return testErr
+		})
+	})
+	assert.Equal(t, testErr, err)
+	// The temporary report is removed
+	assert.NoFileExists(t, reportPath)
+
+	var results testResults
+	require.NoError(t, json.Unmarshal(data, &results))
+	assert.False(t, results.Passed)
+	assert.Equal(t, 3, results.Tests)
+	assert.Equal(t, 1, results.Failures)
+	assert.Equal(t, 1, results.Skipped)
+	assert.Equal(t, 0.5, results.Time)
+	assert.Equal(t, []testResult{
+		{Suite: "Math", Name: "Adds", Status: "passed", Time: 0.1},
+		{Suite: "Math", Name: "Divides", Status: "failed", Time: 0.2, Message: "expected 2, got 3"},
+		{Suite: "Math", Name: "Slow", Status: "skipped"},
+	}, results.Cases)
+
+	// Without a report (the build failed) only the error is left; a report
+	// of an earlier run isn't mistaken for this one's
+	stale := filepath.Join(t.TempDir(), "junit.xml")
+	require.NoError(t, os.WriteFile(stale, []byte(report), 0644))
+	buildErr := errors.New("build failed")
+	data, err = captureJSON(t, func() error {
+		return runTestJSON(stale, func(string) error { return buildErr })
+	})
+	assert.Equal(t, buildErr, err)
+	assert.Empty(t, data)
+}
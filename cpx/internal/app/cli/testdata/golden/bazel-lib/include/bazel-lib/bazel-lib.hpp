@@ -0,0 +1,21 @@
+#ifndef BAZEL_LIB_HPP
+#define BAZEL_LIB_HPP
+
+#include <string>
+
+namespace bazel_lib {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace bazel_lib
+
+#endif  // BAZEL_LIB_HPP
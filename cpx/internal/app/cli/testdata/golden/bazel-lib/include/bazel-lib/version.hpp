@@ -0,0 +1,9 @@
+#ifndef BAZEL_LIB_VERSION_H_
+#define BAZEL_LIB_VERSION_H_
+
+#define BAZEL_LIB_VERSION "0.1.0"
+#define BAZEL_LIB_MAJOR_VERSION 0
+#define BAZEL_LIB_MINOR_VERSION 1
+#define BAZEL_LIB_PATCH_VERSION 0
+
+#endif  // BAZEL_LIB_VERSION_H_
@@ -0,0 +1,14 @@
+#include <bazel-lib/bazel-lib.hpp>
+#include <iostream>
+
+namespace bazel_lib {
+
+void greet() {
+    std::cout << "Hello from bazel-lib!" << std::endl;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace bazel_lib
@@ -0,0 +1,10 @@
+#include <catch2/catch_test_macros.hpp>
+#include <bazel-lib/bazel-lib.hpp>
+
+TEST_CASE("bazel_lib::version returns correct version", "[version]") {
+    REQUIRE(bazel_lib::version() == "1.0.0");
+}
+
+TEST_CASE("bazel_lib::greet does not throw", "[greet]") {
+    REQUIRE_NOTHROW(bazel_lib::greet());
+}
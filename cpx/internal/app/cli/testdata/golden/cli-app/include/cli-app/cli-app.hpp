@@ -0,0 +1,21 @@
+#ifndef CLI_APP_HPP
+#define CLI_APP_HPP
+
+#include <string>
+
+namespace cli_app {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace cli_app
+
+#endif  // CLI_APP_HPP
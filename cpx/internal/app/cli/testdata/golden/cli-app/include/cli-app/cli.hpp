@@ -0,0 +1,34 @@
+#ifndef CLI_APP_CLI_HPP
+#define CLI_APP_CLI_HPP
+
+#include <CLI/CLI.hpp>
+
+#include <ostream>
+#include <string>
+
+namespace cli_app {
+
+/**
+ * @brief Parsed command line
+ */
+struct Options {
+    std::string command;  // selected subcommand
+    std::string name = "world";
+    int count = 1;
+    bool shout = false;
+};
+
+/**
+ * @brief Registers the subcommands and flags on app; parsing fills options
+ */
+void setup_cli(CLI::App& app, Options& options);
+
+/**
+ * @brief Runs the parsed command, writing its output to out
+ * @return Process exit code
+ */
+int run_command(const Options& options, std::ostream& out);
+
+}  // namespace cli_app
+
+#endif  // CLI_APP_CLI_HPP
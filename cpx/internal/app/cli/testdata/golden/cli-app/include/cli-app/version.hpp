@@ -0,0 +1,9 @@
+#ifndef CLI_APP_VERSION_H_
+#define CLI_APP_VERSION_H_
+
+#define CLI_APP_VERSION "0.1.0"
+#define CLI_APP_MAJOR_VERSION 0
+#define CLI_APP_MINOR_VERSION 1
+#define CLI_APP_PATCH_VERSION 0
+
+#endif  // CLI_APP_VERSION_H_
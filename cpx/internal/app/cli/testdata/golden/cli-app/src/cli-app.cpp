@@ -0,0 +1,14 @@
+#include <cli-app/cli-app.hpp>
+#include <iostream>
+
+namespace cli_app {
+
+void greet() {
+    std::cout << "Hello from cli-app!" << std::endl;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace cli_app
@@ -0,0 +1,42 @@
+#include <cli-app/cli.hpp>
+#include <cli-app/version.hpp>
+
+#include <algorithm>
+#include <cctype>
+
+namespace cli_app {
+
+void setup_cli(CLI::App& app, Options& options) {
+    app.require_subcommand(1);
+    app.set_version_flag("--version", CLI_APP_VERSION);
+
+    auto* greet = app.add_subcommand("greet", "Print a greeting");
+    greet->add_option("-n,--name", options.name, "Who to greet")->capture_default_str();
+    greet->add_option("-c,--count", options.count, "How many times")
+        ->check(CLI::PositiveNumber)
+        ->capture_default_str();
+    greet->add_flag("-s,--shout", options.shout, "Greet in upper case");
+    greet->callback([&options] { options.command = "greet"; });
+
+    auto* ver = app.add_subcommand("version", "Print the version");
+    ver->callback([&options] { options.command = "version"; });
+}
+
+int run_command(const Options& options, std::ostream& out) {
+    if (options.command == "version") {
+        out << CLI_APP_VERSION << "\n";
+        return 0;
+    }
+
+    std::string greeting = "Hello, " + options.name + "!";
+    if (options.shout) {
+        std::transform(greeting.begin(), greeting.end(), greeting.begin(),
+                       [](unsigned char c) { return static_cast<char>(std::toupper(c)); });
+    }
+    for (int i = 0; i < options.count; ++i) {
+        out << greeting << "\n";
+    }
+    return 0;
+}
+
+}  // namespace cli_app
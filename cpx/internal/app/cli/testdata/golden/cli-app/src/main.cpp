@@ -0,0 +1,11 @@
+#include <cli-app/cli.hpp>
+
+#include <iostream>
+
+int main(int argc, char** argv) {
+    CLI::App app{"cli-app command-line tool", "cli-app"};
+    cli_app::Options options;
+    cli_app::setup_cli(app, options);
+    CLI11_PARSE(app, argc, argv);
+    return cli_app::run_command(options, std::cout);
+}
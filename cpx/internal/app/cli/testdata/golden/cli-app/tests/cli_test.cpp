@@ -0,0 +1,36 @@
+#include <catch2/catch_test_macros.hpp>
+#include <cli-app/cli.hpp>
+
+#include <sstream>
+
+namespace {
+
+cli_app::Options parse(const std::string& args) {
+    CLI::App app;
+    cli_app::Options options;
+    cli_app::setup_cli(app, options);
+    app.parse(args, false);
+    return options;
+}
+
+}  // namespace
+
+TEST_CASE("greet options are parsed", "[cli]") {
+    auto options = parse("greet --name cpx --count 2");
+    REQUIRE(options.command == "greet");
+    REQUIRE(options.name == "cpx");
+    REQUIRE(options.count == 2);
+    REQUIRE_FALSE(options.shout);
+}
+
+TEST_CASE("greet prints the greeting", "[cli]") {
+    std::ostringstream out;
+    REQUIRE(cli_app::run_command(parse("greet -n cpx -c 2 --shout"), out) == 0);
+    REQUIRE(out.str() == "HELLO, CPX!\nHELLO, CPX!\n");
+}
+
+TEST_CASE("invalid arguments are rejected", "[cli]") {
+    REQUIRE_THROWS_AS(parse(""), CLI::RequiredError);
+    REQUIRE_THROWS_AS(parse("greet --count 0"), CLI::ValidationError);
+    REQUIRE_THROWS_AS(parse("greet --unknown"), CLI::ExtrasError);
+}
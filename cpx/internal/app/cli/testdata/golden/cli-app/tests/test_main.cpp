@@ -0,0 +1,10 @@
+#include <catch2/catch_test_macros.hpp>
+#include <cli-app/cli-app.hpp>
+
+TEST_CASE("cli_app::version returns correct version", "[version]") {
+    REQUIRE(cli_app::version() == "1.0.0");
+}
+
+TEST_CASE("cli_app::greet does not throw", "[greet]") {
+    REQUIRE_NOTHROW(cli_app::greet());
+}
@@ -0,0 +1,16 @@
+#include <benchmark/benchmark.h>
+#include <cmake-app/cmake-app.hpp>
+
+static void BM_version(benchmark::State& state) {
+    for (auto _ : state) {
+        benchmark::DoNotOptimize(cmake_app::version());
+    }
+}
+
+BENCHMARK(BM_version);
+
+int main(int argc, char** argv) {
+    benchmark::Initialize(&argc, argv);
+    if (benchmark::ReportUnrecognizedArguments(argc, argv)) return 1;
+    benchmark::RunSpecifiedBenchmarks();
+}
@@ -0,0 +1,21 @@
+#ifndef CMAKE_APP_HPP
+#define CMAKE_APP_HPP
+
+#include <string>
+
+namespace cmake_app {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace cmake_app
+
+#endif  // CMAKE_APP_HPP
@@ -0,0 +1,9 @@
+#ifndef CMAKE_APP_VERSION_H_
+#define CMAKE_APP_VERSION_H_
+
+#define CMAKE_APP_VERSION "0.1.0"
+#define CMAKE_APP_MAJOR_VERSION 0
+#define CMAKE_APP_MINOR_VERSION 1
+#define CMAKE_APP_PATCH_VERSION 0
+
+#endif  // CMAKE_APP_VERSION_H_
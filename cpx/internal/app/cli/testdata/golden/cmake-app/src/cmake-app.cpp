@@ -0,0 +1,14 @@
+#include <cmake-app/cmake-app.hpp>
+#include <iostream>
+
+namespace cmake_app {
+
+void greet() {
+    std::cout << "Hello from cmake-app!" << std::endl;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace cmake_app
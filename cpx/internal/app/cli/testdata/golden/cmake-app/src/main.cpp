@@ -0,0 +1,7 @@
+#include <cmake-app/cmake-app.hpp>
+#include <iostream>
+
+int main() {
+    cmake_app::greet();
+    return 0;
+}
@@ -0,0 +1,11 @@
+#include <gtest/gtest.h>
+#include <cmake-app/cmake-app.hpp>
+
+TEST(Cmake_appTest, VersionTest) {
+    EXPECT_EQ(cmake_app::version(), "1.0.0");
+}
+
+TEST(Cmake_appTest, GreetTest) {
+    // Should not throw
+    EXPECT_NO_THROW(cmake_app::greet());
+}
@@ -0,0 +1,27 @@
+# Bare-metal ARM toolchain for embedded, used by "cpx build --target embedded"
+set(CMAKE_SYSTEM_NAME Generic)
+set(CMAKE_SYSTEM_PROCESSOR arm)
+
+set(CMAKE_C_COMPILER arm-none-eabi-gcc)
+set(CMAKE_CXX_COMPILER arm-none-eabi-g++)
+set(CMAKE_ASM_COMPILER arm-none-eabi-gcc)
+set(CMAKE_OBJCOPY arm-none-eabi-objcopy CACHE FILEPATH "")
+set(CMAKE_SIZE arm-none-eabi-size CACHE FILEPATH "")
+
+# Compiler checks can't link an executable without the project's linker script
+set(CMAKE_TRY_COMPILE_TARGET_TYPE STATIC_LIBRARY)
+
+# TODO: set the core and FPU of your microcontroller
+set(MCU_FLAGS "-mcpu=cortex-m4 -mthumb")
+
+set(CMAKE_C_FLAGS_INIT "${MCU_FLAGS} -ffunction-sections -fdata-sections")
+set(CMAKE_CXX_FLAGS_INIT "${MCU_FLAGS} -ffunction-sections -fdata-sections -fno-exceptions -fno-rtti -fno-threadsafe-statics")
+set(CMAKE_ASM_FLAGS_INIT "${MCU_FLAGS}")
+# nosys.specs stubs out the system calls newlib expects from an OS
+set(CMAKE_EXE_LINKER_FLAGS_INIT "${MCU_FLAGS} --specs=nosys.specs -Wl,--gc-sections")
+
+# Only search the toolchain's sysroot for libraries and headers
+set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)
+set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)
@@ -0,0 +1,21 @@
+#ifndef EMBEDDED_HPP
+#define EMBEDDED_HPP
+
+#include <string>
+
+namespace embedded {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace embedded
+
+#endif  // EMBEDDED_HPP
@@ -0,0 +1,9 @@
+#ifndef EMBEDDED_VERSION_H_
+#define EMBEDDED_VERSION_H_
+
+#define EMBEDDED_VERSION "0.1.0"
+#define EMBEDDED_MAJOR_VERSION 0
+#define EMBEDDED_MINOR_VERSION 1
+#define EMBEDDED_PATCH_VERSION 0
+
+#endif  // EMBEDDED_VERSION_H_
@@ -0,0 +1,20 @@
+#include <embedded/embedded.hpp>
+
+#include <cstdint>
+
+namespace embedded {
+
+namespace {
+// Stand-in for a peripheral; replace it with your board's UART or GPIO
+volatile std::uint32_t greet_count = 0;
+}  // namespace
+
+void greet() {
+    greet_count = greet_count + 1;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace embedded
@@ -0,0 +1,12 @@
+#include <embedded/embedded.hpp>
+
+int main() {
+    embedded::greet();
+
+#if defined(__arm__)
+    // Firmware never returns: run the application loop here
+    for (;;) {
+    }
+#endif
+    return 0;
+}
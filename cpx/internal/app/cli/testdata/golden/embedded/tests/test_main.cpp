@@ -0,0 +1,11 @@
+#define DOCTEST_CONFIG_IMPLEMENT_WITH_MAIN
+#include <doctest/doctest.h>
+#include <embedded/embedded.hpp>
+
+TEST_CASE("testing version") {
+    CHECK(embedded::version() == "1.0.0");
+}
+
+TEST_CASE("testing greet") {
+    CHECK_NOTHROW(embedded::greet());
+}
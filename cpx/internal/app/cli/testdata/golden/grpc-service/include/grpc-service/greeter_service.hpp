@@ -0,0 +1,21 @@
+#ifndef GRPC_SERVICE_GREETER_SERVICE_HPP
+#define GRPC_SERVICE_GREETER_SERVICE_HPP
+
+#include <grpcpp/grpcpp.h>
+
+#include "grpc-service.grpc.pb.h"
+
+namespace grpc_service {
+
+/**
+ * @brief Implementation of the Greeter service from proto/grpc-service.proto
+ */
+class GreeterService final : public Greeter::Service {
+public:
+    grpc::Status SayHello(grpc::ServerContext* context, const HelloRequest* request,
+                          HelloReply* reply) override;
+};
+
+}  // namespace grpc_service
+
+#endif  // GRPC_SERVICE_GREETER_SERVICE_HPP
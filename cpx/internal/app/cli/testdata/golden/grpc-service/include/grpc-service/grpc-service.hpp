@@ -0,0 +1,21 @@
+#ifndef GRPC_SERVICE_HPP
+#define GRPC_SERVICE_HPP
+
+#include <string>
+
+namespace grpc_service {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace grpc_service
+
+#endif  // GRPC_SERVICE_HPP
@@ -0,0 +1,9 @@
+#ifndef GRPC_SERVICE_VERSION_H_
+#define GRPC_SERVICE_VERSION_H_
+
+#define GRPC_SERVICE_VERSION "0.1.0"
+#define GRPC_SERVICE_MAJOR_VERSION 0
+#define GRPC_SERVICE_MINOR_VERSION 1
+#define GRPC_SERVICE_PATCH_VERSION 0
+
+#endif  // GRPC_SERVICE_VERSION_H_
@@ -0,0 +1,14 @@
+#include <grpc-service/greeter_service.hpp>
+
+namespace grpc_service {
+
+grpc::Status GreeterService::SayHello(grpc::ServerContext* /*context*/, const HelloRequest* request,
+                                      HelloReply* reply) {
+    if (request->name().empty()) {
+        return {grpc::StatusCode::INVALID_ARGUMENT, "name must not be empty"};
+    }
+    reply->set_message("Hello, " + request->name() + "!");
+    return grpc::Status::OK;
+}
+
+}  // namespace grpc_service
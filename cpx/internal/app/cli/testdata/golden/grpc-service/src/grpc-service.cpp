@@ -0,0 +1,14 @@
+#include <grpc-service/grpc-service.hpp>
+#include <iostream>
+
+namespace grpc_service {
+
+void greet() {
+    std::cout << "Hello from grpc-service!" << std::endl;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace grpc_service
@@ -0,0 +1,29 @@
+#include <grpc-service/greeter_service.hpp>
+
+#include <grpcpp/ext/proto_server_reflection_plugin.h>
+#include <grpcpp/health_check_service_interface.h>
+
+#include <iostream>
+#include <memory>
+#include <string>
+
+int main(int argc, char** argv) {
+    const std::string address = argc > 1 ? argv[1] : "0.0.0.0:50051";
+
+    grpc_service::GreeterService service;
+    grpc::EnableDefaultHealthCheckService(true);
+    grpc::reflection::InitProtoReflectionServerBuilderPlugin();
+
+    grpc::ServerBuilder builder;
+    builder.AddListeningPort(address, grpc::InsecureServerCredentials());
+    builder.RegisterService(&service);
+    std::unique_ptr<grpc::Server> server = builder.BuildAndStart();
+    if (!server) {
+        std::cerr << "Failed to listen on " << address << std::endl;
+        return 1;
+    }
+
+    std::cout << "grpc-service listening on " << address << std::endl;
+    server->Wait();
+    return 0;
+}
@@ -0,0 +1,24 @@
+#include <gtest/gtest.h>
+#include <grpc-service/greeter_service.hpp>
+
+TEST(GreeterServiceTest, SayHello) {
+    grpc_service::GreeterService service;
+    grpc::ServerContext context;
+    grpc_service::HelloRequest request;
+    grpc_service::HelloReply reply;
+
+    request.set_name("cpx");
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    ASSERT_TRUE(status.ok());
+    EXPECT_EQ(reply.message(), "Hello, cpx!");
+}
+
+TEST(GreeterServiceTest, SayHelloRejectsEmptyName) {
+    grpc_service::GreeterService service;
+    grpc::ServerContext context;
+    grpc_service::HelloRequest request;
+    grpc_service::HelloReply reply;
+
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    EXPECT_EQ(status.error_code(), grpc::StatusCode::INVALID_ARGUMENT);
+}
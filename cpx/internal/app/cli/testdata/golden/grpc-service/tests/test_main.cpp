@@ -0,0 +1,11 @@
+#include <gtest/gtest.h>
+#include <grpc-service/grpc-service.hpp>
+
+TEST(Grpc_serviceTest, VersionTest) {
+    EXPECT_EQ(grpc_service::version(), "1.0.0");
+}
+
+TEST(Grpc_serviceTest, GreetTest) {
+    // Should not throw
+    EXPECT_NO_THROW(grpc_service::greet());
+}
@@ -0,0 +1,21 @@
+#ifndef MESON_APP_HPP
+#define MESON_APP_HPP
+
+#include <string>
+
+namespace meson_app {
+
+/**
+ * @brief Greet function
+ */
+void greet();
+
+/**
+ * @brief Get the library version
+ * @return Version string
+ */
+std::string version();
+
+}  // namespace meson_app
+
+#endif  // MESON_APP_HPP
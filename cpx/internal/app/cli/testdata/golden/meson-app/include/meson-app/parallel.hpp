@@ -0,0 +1,17 @@
+#ifndef MESON_APP_PARALLEL_HPP
+#define MESON_APP_PARALLEL_HPP
+
+#include <cstddef>
+
+namespace meson_app {
+
+/**
+ * @brief Element-wise vector addition: out[i] = a[i] + b[i]
+ *
+ * Sample parallel kernel running on the OpenMP backend.
+ */
+void vector_add(const float* a, const float* b, float* out, std::size_t n);
+
+}  // namespace meson_app
+
+#endif  // MESON_APP_PARALLEL_HPP
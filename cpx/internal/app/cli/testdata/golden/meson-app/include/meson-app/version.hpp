@@ -0,0 +1,9 @@
+#ifndef MESON_APP_VERSION_H_
+#define MESON_APP_VERSION_H_
+
+#define MESON_APP_VERSION "0.1.0"
+#define MESON_APP_MAJOR_VERSION 0
+#define MESON_APP_MINOR_VERSION 1
+#define MESON_APP_PATCH_VERSION 0
+
+#endif  // MESON_APP_VERSION_H_
@@ -0,0 +1,7 @@
+#include <meson-app/meson-app.hpp>
+#include <iostream>
+
+int main() {
+    meson_app::greet();
+    return 0;
+}
@@ -0,0 +1,14 @@
+#include <meson-app/meson-app.hpp>
+#include <iostream>
+
+namespace meson_app {
+
+void greet() {
+    std::cout << "Hello from meson-app!" << std::endl;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace meson_app
@@ -0,0 +1,15 @@
+#include <meson-app/parallel.hpp>
+
+#include <cstddef>
+
+namespace meson_app {
+
+void vector_add(const float* a, const float* b, float* out, std::size_t n) {
+    const auto count = static_cast<std::ptrdiff_t>(n);
+#pragma omp parallel for
+    for (std::ptrdiff_t i = 0; i < count; ++i) {
+        out[i] = a[i] + b[i];
+    }
+}
+
+}  // namespace meson_app
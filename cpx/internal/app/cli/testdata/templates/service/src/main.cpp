@@ -0,0 +1,3 @@
+// {{.license}}
+{{if .port}}constexpr int port = {{.port}};{{end}}
+int main() { return 0; }
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/output"
+	"github.com/ozacod/cpx/internal/pkg/toolchain"
+	"github.com/spf13/cobra"
+)
+
+// ToolchainCmd creates the toolchain command
+func ToolchainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "toolchain",
+		Short: "Install and manage CMake, Ninja, LLVM and GCC toolchains",
+		Long: `Download toolchain binaries to ~/.config/cpx/toolchains so a new
+machine can build without a system package manager.
+
+cpx build puts the installed toolchains first on PATH: for each tool, the
+newest installed version that satisfies the project's .cpx-tools.yaml, or
+the newest one if the project doesn't constrain the tool. Unless CC or CXX
+is set, it also compiles with an installed LLVM or GCC: the one
+.cpx-tools.yaml constrains (clang/clang++ or gcc/g++), or the only one
+installed.
+
+LLVM is installed from its release archives (18.1.8 and later), GCC from
+the xPack builds ("14.2.0" installs xPack release 14.2.0-1).
+
+Archives are verified against the SHA-256 their project publishes (CMake's
+SHA-256.txt, xPack's .sha files, the GitHub release digests of Ninja and
+LLVM); cpx refuses to install one that doesn't match, or one it can't
+verify unless --sha256 pins the expected checksum.`,
+	}
+
+	installCmd := &cobra.Command{
+		Use:   "install <" + strings.Join(toolchain.Tools, "|") + "> <version>",
+		Short: "Download and install a toolchain",
+		Example: `  cpx toolchain install cmake 3.30.2
+  cpx toolchain install ninja 1.12.1
+  cpx toolchain install llvm 19.1.7
+  cpx toolchain install gcc 14.2.0`,
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: toolchain.Tools,
+		RunE: withExitCode(exitcode.ToolchainMissing, func(cmd *cobra.Command, args []string) error {
+			checksum, _ := cmd.Flags().GetString("sha256")
+			return installToolchain(args[0], args[1], checksum)
+		}),
+	}
+	installCmd.Flags().String("sha256", "", "Expected SHA-256 of the archive, instead of the checksum the project publishes")
+	cmd.AddCommand(installCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the installed toolchains",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listToolchains()
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:       "remove <tool> <version>",
+		Short:     "Remove an installed toolchain",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: toolchain.Tools,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := toolchain.Remove(args[0], args[1]); err != nil {
+				return err
+			}
+			logging.Success("✓ Removed %s %s", args[0], toolchain.NormalizeVersion(args[0], args[1]))
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func installToolchain(tool, version, checksum string) error {
+	if !toolchain.Valid(tool) {
+		return exitcode.Errorf(exitcode.Usage, "unknown toolchain %q\n  hint: cpx installs %s", tool, strings.Join(toolchain.Tools, ", "))
+	}
+	logging.Step("Installing %s %s...", tool, toolchain.NormalizeVersion(tool, version))
+	tc, err := toolchain.Install(tool, version, checksum)
+	if err != nil {
+		return err
+	}
+	logging.Success("✓ Installed %s %s", tc.Tool, tc.Version)
+	logging.Info("  %s", tc.BinDir())
+	return nil
+}
+
+func listToolchains() error {
+	installed, err := toolchain.Installed()
+	if err != nil {
+		return err
+	}
+	if output.JSON() {
+		if installed == nil {
+			installed = []toolchain.Toolchain{}
+		}
+		return output.Print(installed)
+	}
+	if len(installed) == 0 {
+		logging.Info("No toolchains installed")
+		logging.Info("  Install one with: cpx toolchain install cmake <version>")
+		return nil
+	}
+
+	manifest, _ := loadToolsManifest()
+	used := make(map[toolchain.Toolchain]bool)
+	for _, tc := range toolchain.Select(installed, manifest) {
+		used[tc] = true
+	}
+	for _, tc := range installed {
+		marker := " "
+		if used[tc] {
+			marker = Green + "*" + Reset
+		}
+		fmt.Printf("%s %-6s %-12s %s%s%s\n", marker, tc.Tool, tc.Version, Dim, tc.Path, Reset)
+	}
+	fmt.Printf("\n%s* used by cpx build in this directory%s\n", Dim, Reset)
+	return nil
+}
+
+// useInstalledToolchains puts the toolchains installed with
+// 'cpx toolchain install' on PATH for a build
+func useInstalledToolchains() error {
+	installed, err := toolchain.Installed()
+	if err != nil || len(installed) == 0 {
+		return nil
+	}
+	manifest, err := loadToolsManifest()
+	if err != nil {
+		return err
+	}
+	selected := toolchain.Select(installed, manifest)
+	for _, tc := range selected {
+		logging.Verbose("  Using %s %s from %s", tc.Tool, tc.Version, tc.BinDir())
+	}
+	toolchain.Activate(selected, manifest)
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/ozacod/cpx/internal/pkg/naming"
 )
 
 // Step represents the current step in the project creation flow
@@ -21,6 +22,8 @@ const (
 	StepBenchmark
 	StepClangFormat
 	StepPackageManager
+	StepComputeBackend
+	StepPrecompiledHeaders
 	StepGitHooks
 	StepPreCommit
 	StepPrePush
@@ -44,11 +47,14 @@ type ProjectConfig struct {
 	Benchmark      string
 	ClangFormat    string
 	PackageManager string // "vcpkg" or "none"
+	ComputeBackend string // "none", "openmp", "cuda" or "sycl"
+	PCH            bool   // precompiled headers (CMake projects only)
 	VCS            string // "git" or "none"
 	UseHooks       bool
 	GitHooks       []string
 	PreCommit      []string
 	PrePush        []string
+	Template       string // project template, e.g. "grpc-service"; empty for the plain project
 }
 
 // CreationMsg indicates project creation started
@@ -86,11 +92,16 @@ type Model struct {
 	benchmarkOptions      []string
 	clangFormatOptions    []string
 	packageManagerOptions []string
+	computeBackendOptions []string
 	preCommitOptions      []string
 	prePushOptions        []string
 	selectedPreCommit     map[int]bool
 	selectedPrePush       map[int]bool
 
+	// fixedPackageManager skips the package manager and compute backend
+	// questions (set by templates that need a specific one)
+	fixedPackageManager bool
+
 	// Creation result
 	creationResult string
 }
@@ -123,6 +134,7 @@ func InitialModel() Model {
 		benchmarkOptions:      []string{"Google Benchmark", "nanobench", "Catch2 benchmark", "None"},
 		clangFormatOptions:    []string{"Google", "LLVM", "Chromium", "Mozilla", "WebKit"},
 		packageManagerOptions: []string{"vcpkg", "Bazel", "Meson", "None"},
+		computeBackendOptions: []string{"None", "OpenMP", "CUDA", "SYCL"},
 		preCommitOptions:      []string{"format", "lint", "cppcheck", "test"},
 		prePushOptions:        []string{"test", "cppcheck"},
 		selectedPreCommit:     map[int]bool{0: true, 1: true},
@@ -133,6 +145,7 @@ func InitialModel() Model {
 			Benchmark:      "none",
 			ClangFormat:    "Google",
 			PackageManager: "vcpkg",
+			ComputeBackend: "none",
 			IsLibrary:      false,
 			VCS:            "git",
 			UseHooks:       false,
@@ -143,6 +156,37 @@ func InitialModel() Model {
 	}
 }
 
+// WithTemplate starts the flow for a project template. Templates are
+// executables, so the project type question is skipped; the gRPC service
+// template also fixes the package manager (vcpkg), the embedded template
+// builds with plain CMake.
+func (m Model) WithTemplate(name string) Model {
+	m.config.Template = name
+	m.config.IsLibrary = false
+	switch name {
+	case "grpc-service":
+		m.config.PackageManager = "vcpkg"
+		m.fixedPackageManager = true
+		// gRPC needs C++17
+		m.cppStandardOptions = []int{17, 20, 23}
+	case "embedded":
+		// vcpkg ports target the host, not the microcontroller
+		m.config.PackageManager = "none"
+		m.fixedPackageManager = true
+	}
+	return m
+}
+
+// defaultCppStandardCursor returns the cursor position of C++17
+func (m Model) defaultCppStandardCursor() int {
+	for i, std := range m.cppStandardOptions {
+		if std == 17 {
+			return i
+		}
+	}
+	return 0
+}
+
 // Init initializes the model
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(textinput.Blink, m.spinner.Tick)
@@ -225,7 +269,7 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			m.errorMsg = "Project name cannot be empty"
 			return m, nil
 		}
-		if !isValidProjectName(name) {
+		if !naming.IsValidProjectName(name) {
 			m.errorMsg = "Project name can only contain letters, numbers, hyphens, and underscores"
 			return m, nil
 		}
@@ -239,6 +283,18 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			Complete: true,
 		})
 
+		if m.config.Template != "" {
+			m.questions = append(m.questions, Question{
+				Question: "Project template:",
+				Answer:   m.config.Template,
+				Complete: true,
+			})
+			m.currentQuestion = "Which C++ standard would you like to use?"
+			m.step = StepCppStandard
+			m.cursor = m.defaultCppStandardCursor()
+			break
+		}
+
 		m.currentQuestion = "What type of project would you like to create?"
 		m.step = StepProjectType
 		m.cursor = 0
@@ -255,7 +311,7 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 
 		m.currentQuestion = "Which C++ standard would you like to use?"
 		m.step = StepCppStandard
-		m.cursor = 2 // Default to C++17
+		m.cursor = m.defaultCppStandardCursor()
 
 	case StepCppStandard:
 		m.config.CppStandard = m.cppStandardOptions[m.cursor]
@@ -311,6 +367,13 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			Complete: true,
 		})
 
+		if m.fixedPackageManager {
+			m.currentQuestion = "Use precompiled headers?"
+			m.step = StepPrecompiledHeaders
+			m.cursor = 0
+			break
+		}
+
 		m.currentQuestion = "Would you like to use a package manager?"
 		m.step = StepPackageManager
 		m.cursor = 0
@@ -334,6 +397,49 @@ func (m Model) handleEnter() (tea.Model, tea.Cmd) {
 			Complete: true,
 		})
 
+		// Bazel projects only get OpenMP; CUDA and SYCL need extra toolchain rules
+		if m.config.PackageManager == "bazel" {
+			m.computeBackendOptions = []string{"None", "OpenMP"}
+		}
+
+		m.currentQuestion = "Which compute backend would you like?"
+		m.step = StepComputeBackend
+		m.cursor = 0
+
+	case StepComputeBackend:
+		backends := []string{"none", "openmp", "cuda", "sycl"}
+		m.config.ComputeBackend = backends[m.cursor]
+		answer := m.computeBackendOptions[m.cursor]
+
+		m.questions = append(m.questions, Question{
+			Question: m.currentQuestion,
+			Answer:   answer,
+			Complete: true,
+		})
+
+		// Precompiled headers are only generated for CMake projects
+		if m.config.PackageManager == "bazel" || m.config.PackageManager == "meson" {
+			m.currentQuestion = "Initialize a new git repository?"
+			m.step = StepGitHooks
+		} else {
+			m.currentQuestion = "Use precompiled headers?"
+			m.step = StepPrecompiledHeaders
+		}
+		m.cursor = 0
+
+	case StepPrecompiledHeaders:
+		m.config.PCH = m.cursor == 0
+		answer := "Yes"
+		if !m.config.PCH {
+			answer = "No"
+		}
+
+		m.questions = append(m.questions, Question{
+			Question: m.currentQuestion,
+			Answer:   answer,
+			Complete: true,
+		})
+
 		m.currentQuestion = "Initialize a new git repository?"
 		m.step = StepGitHooks
 		m.cursor = 0
@@ -449,7 +555,9 @@ func (m Model) getMaxCursor() int {
 		return len(m.benchmarkOptions) - 1
 	case StepPackageManager:
 		return len(m.packageManagerOptions) - 1
-	case StepGitHooks:
+	case StepComputeBackend:
+		return len(m.computeBackendOptions) - 1
+	case StepPrecompiledHeaders, StepGitHooks:
 		return 1 // Yes or No
 	case StepPreCommit:
 		return len(m.preCommitOptions) - 1
@@ -578,7 +686,18 @@ func (m Model) View() string {
 				s.WriteString(fmt.Sprintf("  %s %s\n", cursor, opt))
 			}
 
-		case StepGitHooks:
+		case StepComputeBackend:
+			s.WriteString(dimStyle.Render(m.computeBackendOptions[m.cursor]))
+			s.WriteString("\n")
+			for i, opt := range m.computeBackendOptions {
+				cursor := " "
+				if m.cursor == i {
+					cursor = selectedStyle.Render("❯")
+				}
+				s.WriteString(fmt.Sprintf("  %s %s\n", cursor, opt))
+			}
+
+		case StepPrecompiledHeaders, StepGitHooks:
 			answer := "Yes"
 			if m.cursor == 1 {
 				answer = "No"
@@ -627,17 +746,3 @@ func (m Model) GetConfig() ProjectConfig {
 func (m Model) IsCancelled() bool {
 	return m.cancelled
 }
-
-// Helper function to validate project name
-func isValidProjectName(name string) bool {
-	if name == "" {
-		return false
-	}
-	for _, r := range name {
-		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
-			(r >= '0' && r <= '9') || r == '-' || r == '_') {
-			return false
-		}
-	}
-	return true
-}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Prompt is a question a project template declares
+type Prompt struct {
+	Name     string // variable the answer is stored in
+	Question string
+	Default  string
+	// Choices limits the answer to a selection; free text if empty
+	Choices []string
+}
+
+// PromptModel asks the questions of a project template one after another
+type PromptModel struct {
+	title     string
+	prompts   []Prompt
+	current   int
+	answers   map[string]string
+	questions []Question
+	textInput textinput.Model
+	cursor    int
+	cancelled bool
+}
+
+// NewPromptModel creates a model asking prompts
+func NewPromptModel(title string, prompts []Prompt) PromptModel {
+	ti := textinput.New()
+	ti.Focus()
+	ti.CharLimit = 256
+	ti.Width = 40
+	ti.PromptStyle = inputPromptStyle
+	ti.TextStyle = inputTextStyle
+	ti.Cursor.Style = cursorStyle
+
+	m := PromptModel{
+		title:     title,
+		prompts:   prompts,
+		answers:   make(map[string]string),
+		textInput: ti,
+	}
+	m.startPrompt()
+	return m
+}
+
+// startPrompt prepares the input for the current prompt
+func (m *PromptModel) startPrompt() {
+	if m.current >= len(m.prompts) {
+		return
+	}
+	p := m.prompts[m.current]
+	m.textInput.SetValue("")
+	m.textInput.Placeholder = p.Default
+	m.cursor = 0
+	for i, choice := range p.Choices {
+		if choice == p.Default {
+			m.cursor = i
+		}
+	}
+}
+
+// Init initializes the model
+func (m PromptModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles messages and updates the model
+func (m PromptModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.current >= len(m.prompts) {
+		return m, tea.Quit
+	}
+	p := m.prompts[m.current]
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.cancelled = true
+			return m, tea.Quit
+
+		case "enter":
+			answer := p.Default
+			if len(p.Choices) > 0 {
+				answer = p.Choices[m.cursor]
+			} else if value := strings.TrimSpace(m.textInput.Value()); value != "" {
+				answer = value
+			}
+			m.answers[p.Name] = answer
+			m.questions = append(m.questions, Question{Question: p.Question, Answer: answer, Complete: true})
+			m.current++
+			if m.current == len(m.prompts) {
+				return m, tea.Quit
+			}
+			m.startPrompt()
+			return m, nil
+
+		case "up", "k":
+			if len(p.Choices) > 0 {
+				if m.cursor > 0 {
+					m.cursor--
+				}
+				return m, nil
+			}
+
+		case "down", "j":
+			if len(p.Choices) > 0 {
+				if m.cursor < len(p.Choices)-1 {
+					m.cursor++
+				}
+				return m, nil
+			}
+		}
+	}
+
+	if len(p.Choices) > 0 {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+// View renders the UI
+func (m PromptModel) View() string {
+	if m.cancelled {
+		return "\n  " + dimStyle.Render("Cancelled.") + "\n\n"
+	}
+
+	var s strings.Builder
+	s.WriteString(cyanBold.Render(m.title) + "\n\n")
+	for _, q := range m.questions {
+		s.WriteString(greenCheck.Render("✔") + " " + dimStyle.Render(q.Question) + " " + cyanBold.Render(q.Answer) + "\n")
+	}
+	if m.current >= len(m.prompts) {
+		return s.String()
+	}
+
+	p := m.prompts[m.current]
+	s.WriteString(questionMark.Render("?") + " " + questionStyle.Render(p.Question) + " ")
+	if len(p.Choices) == 0 {
+		s.WriteString(cyanBold.Render(m.textInput.View()) + "\n")
+		return s.String()
+	}
+	s.WriteString(dimStyle.Render(p.Choices[m.cursor]) + "\n")
+	for i, choice := range p.Choices {
+		cursor := " "
+		if m.cursor == i {
+			cursor = selectedStyle.Render("❯")
+		}
+		s.WriteString(fmt.Sprintf("  %s %s\n", cursor, choice))
+	}
+	return s.String()
+}
+
+// Answers returns the answers by variable name
+func (m PromptModel) Answers() map[string]string {
+	return m.answers
+}
+
+// RunPrompts asks prompts and returns the answers by variable name, or nil
+// if the user cancelled
+func RunPrompts(title string, prompts []Prompt) (map[string]string, error) {
+	p := tea.NewProgram(NewPromptModel(title, prompts))
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, err
+	}
+	pm := finalModel.(PromptModel)
+	if pm.cancelled {
+		return nil, nil
+	}
+	return pm.Answers(), nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 )
 
 // SearchResult represents a single package from vcpkg search
@@ -20,6 +21,10 @@ type SearchResult struct {
 	Name        string
 	Version     string
 	Description string
+	Homepage    string
+	Features    []string // sorted feature names
+	Installed   bool     // already a dependency in the project's vcpkg.json
+	Matched     []int    // offsets of the characters of Name the query matched
 }
 
 // SearchState represents the current state of the search UI
@@ -29,6 +34,8 @@ const (
 	SearchStateInput SearchState = iota
 	SearchStateSearching
 	SearchStateResults
+	SearchStateDetail   // details of the result under the cursor
+	SearchStateFeatures // feature picker of the result under the cursor
 	SearchStateAdding
 	SearchStateDone
 )
@@ -45,7 +52,9 @@ type SearchModel struct {
 	err             error
 	quitting        bool
 	vcpkgPath       string
-	vcpkgRoot       string // VCPKG_ROOT directory (parent of vcpkg executable)
+	vcpkgRoot       string       // VCPKG_ROOT directory (parent of vcpkg executable)
+	index           *vcpkg.Index // nil to index the ports on each search
+	installed       map[string]bool
 	addedPackages   []string
 	failedPackages  map[string]string // package -> error message
 	runVcpkgCommand func([]string) error
@@ -53,6 +62,11 @@ type SearchModel struct {
 	viewportSize    int
 	currentPackage  string   // Package currently being added
 	addOutput       []string // Recent output lines from vcpkg
+	// features are the features picked for each package, written into its
+	// vcpkg.json dependency when it is added
+	features      map[string][]string
+	featureCursor int
+	featurePicks  map[string]bool // picks of the open feature picker
 }
 
 // SearchResultsMsg contains search results
@@ -73,7 +87,7 @@ type AddResultMsg struct {
 type AddCompleteMsg struct{}
 
 // NewSearchModel creates a new search model
-func NewSearchModel(initialQuery string, vcpkgPath string, runVcpkgCommand func([]string) error) SearchModel {
+func NewSearchModel(initialQuery string, vcpkgPath string, index *vcpkg.Index, runVcpkgCommand func([]string) error) SearchModel {
 	ti := textinput.New()
 	ti.Placeholder = "Enter package name to search..."
 	ti.Focus()
@@ -94,8 +108,11 @@ func NewSearchModel(initialQuery string, vcpkgPath string, runVcpkgCommand func(
 		spinner:         s,
 		selected:        make(map[int]bool),
 		failedPackages:  make(map[string]string),
+		features:        make(map[string][]string),
 		vcpkgPath:       vcpkgPath,
 		vcpkgRoot:       filepath.Dir(vcpkgPath), // vcpkg exe is in VCPKG_ROOT
+		index:           index,
+		installed:       manifestDependencies("vcpkg.json"),
 		runVcpkgCommand: runVcpkgCommand,
 		viewportSize:    15,
 		addOutput:       []string{},
@@ -118,94 +135,75 @@ func (m SearchModel) Init() tea.Cmd {
 	return tea.Batch(textinput.Blink, m.spinner.Tick)
 }
 
-// portManifest represents the vcpkg.json file structure
-type portManifest struct {
-	Name        string `json:"name"`
-	Version     string `json:"version"`
-	VersionSem  string `json:"version-semver"`
-	VersionDate string `json:"version-date"`
-	VersionStr  string `json:"version-string"`
-	Description any    `json:"description"` // Can be string or []string
-}
-
-func (p portManifest) getVersion() string {
-	if p.Version != "" {
-		return p.Version
+// manifestDependencies returns the names of the dependencies in the vcpkg.json
+// at path; nil if it can't be read
+func manifestDependencies(path string) map[string]bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
 	}
-	if p.VersionSem != "" {
-		return p.VersionSem
+	var manifest struct {
+		Dependencies []json.RawMessage `json:"dependencies"`
 	}
-	if p.VersionDate != "" {
-		return p.VersionDate
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
 	}
-	return p.VersionStr
-}
 
-func (p portManifest) getDescription() string {
-	switch v := p.Description.(type) {
-	case string:
-		return v
-	case []interface{}:
-		var parts []string
-		for _, s := range v {
-			if str, ok := s.(string); ok {
-				parts = append(parts, str)
+	deps := make(map[string]bool)
+	for _, raw := range manifest.Dependencies {
+		// A dependency is a port name or an object with a name
+		var name string
+		if err := json.Unmarshal(raw, &name); err != nil {
+			var dep struct {
+				Name string `json:"name"`
+			}
+			if json.Unmarshal(raw, &dep) == nil {
+				name = dep.Name
 			}
 		}
-		return strings.Join(parts, " ")
-	default:
+		if name != "" {
+			deps[name] = true
+		}
+	}
+	return deps
+}
+
+// portUsage returns the usage notes vcpkg prints after installing the port,
+// or "" if it has none
+func (m SearchModel) portUsage(name string) string {
+	data, err := os.ReadFile(filepath.Join(m.vcpkgRoot, "ports", name, "usage"))
+	if err != nil {
 		return ""
 	}
+	return strings.TrimSpace(string(data))
 }
 
 func (m SearchModel) doSearch() tea.Cmd {
 	return func() tea.Msg {
-		portsDir := filepath.Join(m.vcpkgRoot, "ports")
-		entries, err := os.ReadDir(portsDir)
-		if err != nil {
-			return SearchResultsMsg{Err: fmt.Errorf("failed to read ports directory: %w", err)}
+		index := m.index
+		if index == nil {
+			var err error
+			if index, err = vcpkg.BuildIndex(m.vcpkgRoot); err != nil {
+				return SearchResultsMsg{Err: err}
+			}
 		}
 
-		queryLower := strings.ToLower(m.query)
 		var results []SearchResult
-
-		for _, entry := range entries {
-			if !entry.IsDir() {
-				continue
-			}
-
-			name := entry.Name()
-			// Skip vcpkg internal packages
-			if strings.HasPrefix(name, "vcpkg-") {
-				continue
+		for _, port := range index.Search(m.query) {
+			features := make([]string, 0, len(port.Features))
+			for _, feature := range port.Features {
+				features = append(features, feature.Name)
 			}
-
-			// Quick name match first (before reading JSON)
-			if !strings.Contains(strings.ToLower(name), queryLower) {
-				continue
-			}
-
-			// Read vcpkg.json for this port
-			manifestPath := filepath.Join(portsDir, name, "vcpkg.json")
-			data, err := os.ReadFile(manifestPath)
-			if err != nil {
-				// Skip ports without vcpkg.json
-				continue
-			}
-
-			var manifest portManifest
-			if err := json.Unmarshal(data, &manifest); err != nil {
-				// Skip invalid manifests
-				continue
-			}
-
 			results = append(results, SearchResult{
-				Name:        manifest.Name,
-				Version:     manifest.getVersion(),
-				Description: manifest.getDescription(),
+				Name:        port.Name,
+				Version:     port.Version,
+				Description: port.Description,
+				Homepage:    port.Homepage,
+				Features:    features,
+				Installed:   m.installed[port.Name],
+				Matched:     port.Matched,
 			})
 		}
-
 		return SearchResultsMsg{Results: results}
 	}
 }
@@ -219,6 +217,12 @@ func (m SearchModel) doAddPackage(pkg string) tea.Cmd {
 		cmd.Stderr = &stderr
 
 		err := cmd.Run()
+		if err == nil && len(m.features[pkg]) > 0 {
+			err = vcpkg.SetDependencyFeatures("vcpkg.json", pkg, m.features[pkg])
+			if err != nil {
+				stderr.WriteString(err.Error())
+			}
+		}
 
 		// Combine stdout and stderr for output
 		output := strings.TrimSpace(stdout.String() + stderr.String())
@@ -242,6 +246,15 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "ctrl+c", "esc":
+			if m.state == SearchStateFeatures && msg.String() == "esc" {
+				// Close the picker without changing the features
+				m.state = SearchStateResults
+				return m, nil
+			}
+			if m.state == SearchStateDetail && msg.String() == "esc" {
+				m.state = SearchStateResults
+				return m, nil
+			}
 			if m.state == SearchStateResults && len(m.selected) > 0 {
 				// Clear selection instead of quitting
 				m.selected = make(map[int]bool)
@@ -260,6 +273,9 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m.handleEnter()
 
 		case "up", "k":
+			if m.state == SearchStateFeatures && m.featureCursor > 0 {
+				m.featureCursor--
+			}
 			if m.state == SearchStateResults && m.cursor > 0 {
 				m.cursor--
 				// Scroll viewport if needed
@@ -269,6 +285,9 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case "down", "j":
+			if m.state == SearchStateFeatures && m.featureCursor < len(m.results[m.cursor].Features)-1 {
+				m.featureCursor++
+			}
 			if m.state == SearchStateResults && m.cursor < len(m.results)-1 {
 				m.cursor++
 				// Scroll viewport if needed
@@ -278,6 +297,10 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		case " ":
+			if m.state == SearchStateFeatures {
+				feature := m.results[m.cursor].Features[m.featureCursor]
+				m.featurePicks[feature] = !m.featurePicks[feature]
+			}
 			// Space to toggle selection
 			if m.state == SearchStateResults {
 				m.selected[m.cursor] = !m.selected[m.cursor]
@@ -298,6 +321,29 @@ func (m SearchModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+		case "i":
+			// 'i' to show or hide the details of the current result
+			if m.state == SearchStateResults && len(m.results) > 0 {
+				m.state = SearchStateDetail
+				return m, nil
+			}
+			if m.state == SearchStateDetail {
+				m.state = SearchStateResults
+				return m, nil
+			}
+
+		case "f":
+			// 'f' to pick the features of the current result
+			if (m.state == SearchStateResults || m.state == SearchStateDetail) && len(m.results) > 0 && len(m.results[m.cursor].Features) > 0 {
+				m.state = SearchStateFeatures
+				m.featureCursor = 0
+				m.featurePicks = make(map[string]bool)
+				for _, feature := range m.features[m.results[m.cursor].Name] {
+					m.featurePicks[feature] = true
+				}
+				return m, nil
+			}
+
 		case "a":
 			// 'a' to select all visible
 			if m.state == SearchStateResults {
@@ -387,7 +433,32 @@ func (m SearchModel) handleEnter() (tea.Model, tea.Cmd) {
 		m.state = SearchStateSearching
 		return m, tea.Batch(m.spinner.Tick, m.doSearch())
 
-	case SearchStateResults:
+	case SearchStateFeatures:
+		// Keep the picked features, in the port's order, and select the package
+		result := m.results[m.cursor]
+		var picked []string
+		for _, feature := range result.Features {
+			if m.featurePicks[feature] {
+				picked = append(picked, feature)
+			}
+		}
+		if len(picked) > 0 {
+			m.features[result.Name] = picked
+			m.selected[m.cursor] = true
+		} else {
+			delete(m.features, result.Name)
+		}
+		m.state = SearchStateResults
+		return m, nil
+
+	case SearchStateResults, SearchStateDetail:
+		if m.state == SearchStateDetail {
+			// Add the package whose details are shown
+			m.selected = map[int]bool{m.cursor: true}
+		}
+		if len(m.results) == 0 {
+			return m, nil
+		}
 		if len(m.selected) == 0 {
 			// If nothing selected, select current item
 			m.selected[m.cursor] = true
@@ -428,6 +499,12 @@ func (m SearchModel) View() string {
 	case SearchStateResults:
 		s.WriteString(m.renderResults())
 
+	case SearchStateDetail:
+		s.WriteString(m.renderDetail())
+
+	case SearchStateFeatures:
+		s.WriteString(m.renderFeatures())
+
 	case SearchStateAdding:
 		s.WriteString(fmt.Sprintf("%s Adding packages...\n", m.spinner.View()))
 		for _, pkg := range m.addedPackages {
@@ -437,7 +514,7 @@ func (m SearchModel) View() string {
 	case SearchStateDone:
 		s.WriteString(greenCheck.Render("✓") + " Done!\n\n")
 		for _, pkg := range m.addedPackages {
-			s.WriteString("  • " + pkg + "\n")
+			s.WriteString("  • " + m.packageWithFeatures(pkg) + "\n")
 		}
 		if len(m.addedPackages) > 0 {
 			s.WriteString("\n" + cyanBold.Render("📦 Find sample usage and more info at:") + "\n")
@@ -494,17 +571,28 @@ func (m SearchModel) renderResults() string {
 		if len(name) > 30 {
 			name = name[:27] + "..."
 		}
+		name = highlightMatches(name, result.Matched, style) + strings.Repeat(" ", 30-len(name))
+
+		version := result.Version
+		if len(version) > 12 {
+			version = version[:9] + "..."
+		}
 
 		desc := result.Description
 		if len(desc) > 45 {
 			desc = desc[:42] + "..."
 		}
 
-		line := fmt.Sprintf("%s%s %-30s %s", prefix, checkbox, name, dimStyle.Render(desc))
-		if i == m.cursor {
-			line = style.Render(fmt.Sprintf("%s%s %-30s", prefix, checkbox, name)) + " " + dimStyle.Render(desc)
+		line := style.Render(prefix+checkbox+" ") + name + " " + dimStyle.Render(fmt.Sprintf("%-12s", version)) + " "
+		if result.Installed {
+			line += greenStyle.Render("installed") + " "
+		}
+		if picked := m.features[result.Name]; len(picked) > 0 {
+			line += greenStyle.Render("["+strings.Join(picked, ",")+"]") + " "
+		} else if len(result.Features) > 0 {
+			line += dimStyle.Render(fmt.Sprintf("[%d features] ", len(result.Features)))
 		}
-		s.WriteString(line + "\n")
+		s.WriteString(line + dimStyle.Render(desc) + "\n")
 	}
 
 	// Show scroll indicator if needed
@@ -518,14 +606,114 @@ func (m SearchModel) renderResults() string {
 	if selectedCount > 0 {
 		s.WriteString(greenStyle.Render(fmt.Sprintf("%d selected", selectedCount)) + " • ")
 	}
-	s.WriteString(dimStyle.Render("Space: toggle • Tab: select & next • Enter: add selected • Esc: back"))
+	s.WriteString(dimStyle.Render("Space: toggle • Tab: select & next • i: details • f: features • Enter: add selected • Esc: back"))
+
+	return s.String()
+}
+
+// highlightMatches renders name in style, with the characters at the
+// offsets of matched highlighted
+func highlightMatches(name string, matched []int, style lipgloss.Style) string {
+	if len(matched) == 0 {
+		return style.Render(name)
+	}
+	highlight := style.Foreground(green).Underline(true)
+	isMatched := make(map[int]bool, len(matched))
+	for _, offset := range matched {
+		isMatched[offset] = true
+	}
+
+	var s strings.Builder
+	start := 0
+	for i := 1; i <= len(name); i++ {
+		// Render runs of matched and unmatched characters
+		if i == len(name) || isMatched[i] != isMatched[start] {
+			run := name[start:i]
+			if isMatched[start] {
+				s.WriteString(highlight.Render(run))
+			} else {
+				s.WriteString(style.Render(run))
+			}
+			start = i
+		}
+	}
+	return s.String()
+}
+
+// maxUsageLines limits the usage notes shown in the detail pane
+const maxUsageLines = 15
+
+func (m SearchModel) renderDetail() string {
+	var s strings.Builder
+	result := m.results[m.cursor]
+
+	s.WriteString(cyanBold.Render(result.Name) + " " + dimStyle.Render(result.Version))
+	if result.Installed {
+		s.WriteString(" " + greenStyle.Render("(in vcpkg.json)"))
+	}
+	s.WriteString("\n\n")
+	if result.Description != "" {
+		s.WriteString(result.Description + "\n\n")
+	}
+	if result.Homepage != "" {
+		s.WriteString(dimStyle.Render("Homepage: ") + result.Homepage + "\n")
+	}
+	if len(result.Features) > 0 {
+		s.WriteString(dimStyle.Render("Features: ") + strings.Join(result.Features, ", ") + "\n")
+	}
+
+	s.WriteString("\n" + cyanBold.Render("Usage") + "\n")
+	if usage := m.portUsage(result.Name); usage != "" {
+		lines := strings.Split(usage, "\n")
+		if len(lines) > maxUsageLines {
+			lines = append(lines[:maxUsageLines], "...")
+		}
+		for _, line := range lines {
+			s.WriteString("  " + line + "\n")
+		}
+	} else {
+		s.WriteString(dimStyle.Render("  No usage notes; see https://cpx-dev.vercel.app/packages#package/"+result.Name) + "\n")
+	}
+
+	s.WriteString("\n" + dimStyle.Render("Enter: add • f: features • i/Esc: back"))
+	return s.String()
+}
+
+// packageWithFeatures returns pkg with its picked features, as in
+// pkg[feature1,feature2]
+func (m SearchModel) packageWithFeatures(pkg string) string {
+	if features := m.features[pkg]; len(features) > 0 {
+		return pkg + "[" + strings.Join(features, ",") + "]"
+	}
+	return pkg
+}
+
+func (m SearchModel) renderFeatures() string {
+	var s strings.Builder
+	result := m.results[m.cursor]
+
+	s.WriteString(cyanBold.Render("Features of "+result.Name) + "\n\n")
+	for i, feature := range result.Features {
+		prefix := "  "
+		style := lipgloss.NewStyle()
+		if i == m.featureCursor {
+			prefix = "▸ "
+			style = selectedStyle
+		}
+		checkbox := "[ ]"
+		if m.featurePicks[feature] {
+			checkbox = greenCheck.Render("[✓]")
+		}
+		s.WriteString(style.Render(prefix+checkbox+" "+feature) + "\n")
+	}
 
+	s.WriteString("\n" + dimStyle.Render("Space: toggle • Enter: done • Esc: cancel"))
 	return s.String()
 }
 
 // RunSearch runs the search TUI and returns selected packages
-func RunSearch(initialQuery string, vcpkgPath string, runVcpkgCommand func([]string) error) error {
-	m := NewSearchModel(initialQuery, vcpkgPath, runVcpkgCommand)
+func RunSearch(initialQuery string, vcpkgPath string, index *vcpkg.Index, runVcpkgCommand func([]string) error) error {
+	m := NewSearchModel(initialQuery, vcpkgPath, index, runVcpkgCommand)
 	p := tea.NewProgram(m)
 	_, err := p.Run()
 	return err
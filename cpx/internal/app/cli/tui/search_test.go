@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchDetails(t *testing.T) {
+	root := t.TempDir()
+	ports := map[string]string{
+		"fmt/vcpkg.json":    `{"name": "fmt", "version": "11.0.2", "description": "Formatting library", "homepage": "https://github.com/fmtlib/fmt"}`,
+		"fmt/usage":         "fmt provides CMake targets:\n\n    find_package(fmt CONFIG REQUIRED)\n",
+		"fmtlog/vcpkg.json": `{"name": "fmtlog", "version-date": "2024-01-01", "description": ["Logging", "library"], "features": {"tests": {"description": "x"}, "benchmarks": {"description": "y"}}}`,
+	}
+	for rel, content := range ports {
+		path := filepath.Join(root, "ports", rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	projectDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(projectDir))
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": ["zlib", {"name": "fmt", "features": []}]}`), 0644))
+
+	m := NewSearchModel("fmt", filepath.Join(root, "vcpkg"), nil, nil)
+	msg := m.doSearch()()
+	results := msg.(SearchResultsMsg)
+	require.NoError(t, results.Err)
+	require.Len(t, results.Results, 2)
+	assert.Equal(t, SearchResult{
+		Name:        "fmt",
+		Version:     "11.0.2",
+		Description: "Formatting library",
+		Homepage:    "https://github.com/fmtlib/fmt",
+		Features:    []string{},
+		Installed:   true,
+		Matched:     []int{0, 1, 2},
+	}, results.Results[0])
+	assert.Equal(t, []string{"benchmarks", "tests"}, results.Results[1].Features)
+	assert.False(t, results.Results[1].Installed)
+
+	model, _ := m.Update(results)
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m = model.(SearchModel)
+	assert.Equal(t, SearchStateDetail, m.state)
+	view := m.View()
+	assert.Contains(t, view, "https://github.com/fmtlib/fmt")
+	assert.Contains(t, view, "find_package(fmt CONFIG REQUIRED)")
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Equal(t, SearchStateResults, model.(SearchModel).state)
+}
+
+func TestSearchFeaturePicker(t *testing.T) {
+	m := NewSearchModel("", filepath.Join(t.TempDir(), "vcpkg"), nil, nil)
+	model, _ := m.Update(SearchResultsMsg{Results: []SearchResult{
+		{Name: "boost-asio"},
+		{Name: "boost", Features: []string{"filesystem", "python", "system"}},
+	}})
+	keys := func(model tea.Model, keys ...tea.KeyMsg) tea.Model {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+
+	// Ports without features have no picker
+	model = keys(model, runes("f"))
+	assert.Equal(t, SearchStateResults, model.(SearchModel).state)
+
+	model = keys(model, runes("j"), runes("f"))
+	assert.Equal(t, SearchStateFeatures, model.(SearchModel).state)
+	assert.Contains(t, model.View(), "Features of boost")
+
+	// Pick system then filesystem; they are kept in the port's order
+	model = keys(model, runes("j"), runes("j"), space, runes("k"), runes("k"), space, tea.KeyMsg{Type: tea.KeyEnter})
+	m = model.(SearchModel)
+	assert.Equal(t, SearchStateResults, m.state)
+	assert.Equal(t, []string{"filesystem", "system"}, m.features["boost"])
+	assert.True(t, m.selected[1])
+	assert.Equal(t, "boost[filesystem,system]", m.packageWithFeatures("boost"))
+	assert.Contains(t, m.View(), "[filesystem,system]")
+
+	// Esc closes the picker without changes
+	model = keys(m, runes("f"), space, tea.KeyMsg{Type: tea.KeyEsc})
+	assert.Equal(t, []string{"filesystem", "system"}, model.(SearchModel).features["boost"])
+}
+
+func TestHighlightMatches(t *testing.T) {
+	plain := lipgloss.NewStyle()
+	highlight := plain.Foreground(green).Underline(true)
+	assert.Equal(t, plain.Render("json"), highlightMatches("json", nil, plain))
+	assert.Equal(t, plain.Render("nlohmann-")+highlight.Render("json"), highlightMatches("nlohmann-json", []int{9, 10, 11, 12}, plain))
+	assert.Equal(t, highlight.Render("s")+plain.Render("p")+highlight.Render("d"), highlightMatches("spd", []int{0, 2}, plain))
+}
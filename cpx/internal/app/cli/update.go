@@ -4,18 +4,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/spf13/cobra"
 )
 
 // UpdateCmd creates the update command
-func UpdateCmd() *cobra.Command {
+func UpdateCmd(client *vcpkg.Client) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "update",
-		Short: "Update dependencies to latest versions",
-		Long:  "Update dependencies to latest versions. Use 'vcpkg upgrade' to update vcpkg packages.",
-		RunE:  runUpdate,
-		Args:  cobra.MaximumNArgs(1),
+		Short: "Refresh the port index and check dependencies",
+		Long: `Rebuild the index of vcpkg ports that cpx search and cpx info use, then list
+the dependencies of vcpkg.json. Use 'vcpkg upgrade' to update vcpkg packages.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpdate(cmd, args, client)
+		},
+		Args: cobra.MaximumNArgs(1),
 	}
 
 	cmd.Flags().StringP("server", "s", DefaultServer, "Server URL")
@@ -23,11 +30,29 @@ func UpdateCmd() *cobra.Command {
 	return cmd
 }
 
-func runUpdate(_ *cobra.Command, args []string) error {
+func runUpdate(_ *cobra.Command, args []string, client *vcpkg.Client) error {
 	var libName string
 	if len(args) > 0 {
 		libName = args[0]
 	}
+
+	if client != nil {
+		vcpkgPath, err := client.GetPath()
+		if err != nil {
+			return err
+		}
+		start := time.Now()
+		index, err := loadPortIndex(filepath.Dir(vcpkgPath), true)
+		if err != nil {
+			return err
+		}
+		logging.Success("✓ Indexed %d ports in %s", len(index.Ports), time.Since(start).Round(time.Millisecond))
+	}
+
+	if _, err := os.Stat("vcpkg.json"); err != nil && client != nil {
+		// Outside a vcpkg project only the index is updated
+		return nil
+	}
 	return updateDependencies(libName)
 }
 
@@ -40,12 +65,12 @@ func updateDependencies(specificLib string) error {
 	}
 
 	if len(deps) == 0 {
-		fmt.Printf("%s No dependencies to update%s\n", Green, Reset)
+		logging.Success("No dependencies to update")
 		return nil
 	}
 
-	fmt.Printf("%s Checking for updates...%s\n", Cyan, Reset)
-	fmt.Printf("%s  Use 'vcpkg upgrade' to update vcpkg packages%s\n", Yellow, Reset)
+	logging.Step("Checking for updates...")
+	logging.Notice("  Use 'vcpkg upgrade' to update vcpkg packages")
 	fmt.Printf("   Dependencies in vcpkg.json:\n")
 	for _, dep := range deps {
 		if specificLib != "" && dep != specificLib {
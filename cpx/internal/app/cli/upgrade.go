@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,19 +12,96 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/explain"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+// Upgrade channels
+const (
+	ChannelStable  = "stable"  // releases
+	ChannelNightly = "nightly" // releases and pre-releases
+)
+
+// releasesURL lists the releases of cpx, newest first
+var releasesURL = "https://api.github.com/repos/ozacod/cpx/releases?per_page=50"
+
+// checksumsAsset is the sha256sum file published with each release
+const checksumsAsset = "checksums.txt"
+
+// explainAsset is the rule database of 'cpx explain' published with each
+// release
+const explainAsset = "explain.json"
+
+// release is a GitHub release of cpx
+type release struct {
+	TagName     string         `json:"tag_name"`
+	HTMLURL     string         `json:"html_url"`
+	Prerelease  bool           `json:"prerelease"`
+	Draft       bool           `json:"draft"`
+	PublishedAt time.Time      `json:"published_at"`
+	Assets      []releaseAsset `json:"assets"`
+}
+
+// releaseAsset is a file published with a release
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// version returns the tag without its v prefix
+func (r release) version() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// assetURL returns the download URL of the asset called name
+func (r release) assetURL(name string) (string, bool) {
+	for _, asset := range r.Assets {
+		if asset.Name == name {
+			return asset.URL, true
+		}
+	}
+	return "", false
+}
+
 // UpgradeCmd creates the upgrade command
 func UpgradeCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "upgrade",
+		Use:   "upgrade [version]",
 		Short: "Upgrade cpx to the latest version",
-		Long:  "Upgrade cpx to the latest version from GitHub releases.",
-		RunE:  runUpgrade,
+		Long: `Upgrade cpx to the latest version from GitHub releases, or to the given
+version.
+
+--channel nightly also considers the pre-releases. --list prints the versions
+of the channel instead of installing one. Downloads are checked against the
+checksums.txt of the release. After an upgrade, the rule database of
+'cpx explain' is refreshed from the installed release.
+
+The binary being replaced is kept under the cpx config directory (bin/), and
+--rollback puts it back; rolling back twice returns to the upgraded version.`,
+		Example: `  cpx upgrade
+  cpx upgrade --channel nightly
+  cpx upgrade --list
+  cpx upgrade 1.0.15
+  cpx upgrade --rollback`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
+				return exitcode.Wrap(exitcode.Usage, err)
+			}
+			return nil
+		},
+		RunE: runUpgrade,
 	}
+	cmd.Flags().String("channel", ChannelStable, "Release channel: stable or nightly (pre-releases too)")
+	cmd.Flags().Bool("list", false, "List the versions available on the channel")
+	cmd.Flags().Bool("rollback", false, "Restore the binary replaced by the last upgrade")
+	cmd.Flags().Bool("no-verify", false, "Install releases that publish no checksums")
+	cmd.RegisterFlagCompletionFunc("channel", cobra.FixedCompletions([]string{ChannelStable, ChannelNightly}, cobra.ShellCompDirectiveNoFileComp))
 
 	// Add vcpkg subcommand
 	vcpkgCmd := &cobra.Command{
@@ -33,134 +112,448 @@ func UpgradeCmd() *cobra.Command {
 	}
 	cmd.AddCommand(vcpkgCmd)
 
+	// Add explain-db subcommand
+	explainCmd := &cobra.Command{
+		Use:   "explain-db",
+		Short: "Refresh the rule database used by 'cpx explain'",
+		Long:  "Download the latest clang-tidy/cppcheck/CWE/compiler error database used by 'cpx explain'.",
+		RunE:  runUpgradeExplainDB,
+	}
+	cmd.AddCommand(explainCmd)
+
 	return cmd
 }
 
-func runUpgrade(_ *cobra.Command, args []string) error {
-	Upgrade(args)
-	return nil
-}
+func runUpgrade(cmd *cobra.Command, args []string) error {
+	channel, _ := cmd.Flags().GetString("channel")
+	list, _ := cmd.Flags().GetBool("list")
+	rollback, _ := cmd.Flags().GetBool("rollback")
+	noVerify, _ := cmd.Flags().GetBool("no-verify")
 
-func Upgrade(_ []string) {
-	fmt.Printf("%s Checking for updates...%s\n", Cyan, Reset)
+	if channel != ChannelStable && channel != ChannelNightly {
+		return exitcode.Errorf(exitcode.Usage, "unknown channel %q\n  hint: use stable or nightly", channel)
+	}
+	if rollback {
+		if list || len(args) > 0 {
+			return exitcode.Errorf(exitcode.Usage, "--rollback cannot be combined with --list or a version")
+		}
+		return rollbackUpgrade()
+	}
+
+	if err := offline.Required("cpx upgrade"); err != nil {
+		return err
+	}
+	if list {
+		return listReleases(channel)
+	}
+
+	version := ""
+	if len(args) > 0 {
+		version = args[0]
+	}
+	return Upgrade(channel, version, !noVerify)
+}
 
-	// Get latest version from GitHub releases API
-	resp, err := http.Get("https://api.github.com/repos/ozacod/cpx/releases/latest")
+// runUpgradeExplainDB downloads the latest rule database for 'cpx explain'
+func runUpgradeExplainDB(_ *cobra.Command, _ []string) error {
+	if err := offline.Required("cpx upgrade explain-db"); err != nil {
+		return err
+	}
+	logging.Step("Refreshing explain database...")
+	db, err := explain.Refresh(explain.DatabaseURL)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to check for updates: %v\n", Red, Reset, err)
-		os.Exit(1)
+		return err
 	}
-	defer resp.Body.Close()
+	logging.Success("Explain database updated (%d entries)", len(db.Entries))
+	return nil
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		fmt.Printf("%s  No releases found. This may be the first version.%s\n", Yellow, Reset)
-		fmt.Printf("   Repository: https://github.com/ozacod/cpx\n")
-		return
+// fetchReleases returns the published releases of cpx, newest first
+func fetchReleases() ([]release, error) {
+	resp, err := http.Get(releasesURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for updates: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to check for updates (status %d): %s\n", Red, Reset, resp.StatusCode, string(body))
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to check for updates (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
-	var release struct {
-		TagName string `json:"tag_name"`
-		HTMLURL string `json:"html_url"`
+	var releases []release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to parse release info: %w", err)
+	}
+	published := releases[:0]
+	for _, r := range releases {
+		if !r.Draft {
+			published = append(published, r)
+		}
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to parse release info: %v\n", Red, Reset, err)
-		os.Exit(1)
+	return published, nil
+}
+
+// channelReleases returns the releases of channel, newest first
+func channelReleases(releases []release, channel string) []release {
+	var selected []release
+	for _, r := range releases {
+		if channel == ChannelNightly || !r.Prerelease {
+			selected = append(selected, r)
+		}
 	}
+	return selected
+}
 
-	latestVersion := strings.TrimPrefix(release.TagName, "v")
-	currentVersion := Version
+// selectRelease returns the release of version, or the newest of channel if
+// version is empty
+func selectRelease(releases []release, channel, version string) (release, error) {
+	if version != "" {
+		version = strings.TrimPrefix(version, "v")
+		for _, r := range releases {
+			if r.version() == version {
+				return r, nil
+			}
+		}
+		return release{}, exitcode.Errorf(exitcode.Usage, "no release %s\n  hint: run 'cpx upgrade --list' to see the available versions", version)
+	}
 
-	if latestVersion == currentVersion {
-		fmt.Printf("%s You're already running the latest version (%s)%s\n", Green, currentVersion, Reset)
-		return
+	candidates := channelReleases(releases, channel)
+	if len(candidates) == 0 {
+		return release{}, fmt.Errorf("no %s release found", channel)
 	}
+	return candidates[0], nil
+}
 
-	fmt.Printf("%s New version available: %s  %s%s\n", Yellow, currentVersion, latestVersion, Reset)
-	fmt.Printf("   Release: %s\n", release.HTMLURL)
+func listReleases(channel string) error {
+	releases, err := fetchReleases()
+	if err != nil {
+		return err
+	}
+	releases = channelReleases(releases, channel)
+	if len(releases) == 0 {
+		logging.Notice("No %s releases found", channel)
+		return nil
+	}
 
-	// Determine platform and architecture
-	goos := runtime.GOOS
-	goarch := runtime.GOARCH
+	for _, r := range releases {
+		line := fmt.Sprintf("  %-16s %s", r.version(), r.PublishedAt.Format("2006-01-02"))
+		if r.Prerelease {
+			line += "  pre-release"
+		}
+		if r.version() == Version {
+			line = fmt.Sprintf("%s%s  (current)%s", Green, line, Reset)
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
 
-	var binaryName string
-	switch goos {
-	case "darwin":
-		binaryName = fmt.Sprintf("cpx-darwin-%s", goarch)
-	case "linux":
-		binaryName = fmt.Sprintf("cpx-linux-%s", goarch)
+// binaryAsset returns the release asset of cpx for this platform
+func binaryAsset() (string, error) {
+	switch runtime.GOOS {
+	case "darwin", "linux":
+		return fmt.Sprintf("cpx-%s-%s", runtime.GOOS, runtime.GOARCH), nil
 	case "windows":
-		binaryName = fmt.Sprintf("cpx-windows-%s.exe", goarch)
+		return fmt.Sprintf("cpx-windows-%s.exe", runtime.GOARCH), nil
 	default:
-		fmt.Fprintf(os.Stderr, "%sError:%s Unsupported platform: %s\n", Red, Reset, goos)
-		os.Exit(1)
+		return "", fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
+}
 
-	downloadURL := fmt.Sprintf("https://github.com/ozacod/cpx/releases/download/%s/%s", release.TagName, binaryName)
-	fmt.Printf("%s Downloading %s...%s\n", Cyan, binaryName, Reset)
+// Upgrade installs the given version of cpx, or the newest release of
+// channel, keeping the current binary for --rollback. With verify, the
+// download must match the checksums.txt of the release.
+func Upgrade(channel, version string, verify bool) error {
+	logging.Step("Checking for updates...")
 
-	// Download the new binary
-	resp, err = http.Get(downloadURL)
+	releases, err := fetchReleases()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to download: %v\n", Red, Reset, err)
-		os.Exit(1)
+		return err
+	}
+	if len(releases) == 0 {
+		logging.Notice("  No releases found. This may be the first version.")
+		fmt.Printf("   Repository: https://github.com/ozacod/cpx\n")
+		return nil
+	}
+	target, err := selectRelease(releases, channel, version)
+	if err != nil {
+		return err
+	}
+
+	if target.version() == Version {
+		if version != "" {
+			logging.Success("You're already running %s", Version)
+		} else {
+			logging.Success("You're already running the latest %s version (%s)", channel, Version)
+		}
+		return nil
+	}
+
+	logging.Notice("Installing %s (current: %s)", target.version(), Version)
+	fmt.Printf("   Release: %s\n", target.HTMLURL)
+
+	binaryName, err := binaryAsset()
+	if err != nil {
+		return err
+	}
+	downloadURL, ok := target.assetURL(binaryName)
+	if !ok {
+		downloadURL = fmt.Sprintf("https://github.com/ozacod/cpx/releases/download/%s/%s", target.TagName, binaryName)
+	}
+
+	logging.Step("Downloading %s...", binaryName)
+	binaryData, err := download(downloadURL)
+	if err != nil {
+		return err
+	}
+
+	var checksums []byte
+	if verify {
+		checksumsURL, ok := target.assetURL(checksumsAsset)
+		if !ok {
+			return fmt.Errorf("release %s publishes no %s to verify the download\n  hint: use --no-verify to install it anyway", target.version(), checksumsAsset)
+		}
+		checksums, err = download(checksumsURL)
+		if err != nil {
+			return err
+		}
+		if err := verifyChecksum(binaryData, checksums, binaryName); err != nil {
+			return err
+		}
+		logging.Info("  Verified the sha256 checksum of %s", binaryName)
+	} else {
+		logging.Warn("not verifying the checksum of %s", binaryName)
+	}
+
+	execPath, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	backupDir, err := upgradeBackupDir()
+	if err != nil {
+		return err
+	}
+	installed, err := replaceExecutable(execPath, binaryData, backupDir, Version)
+	if err != nil || !installed {
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		fmt.Fprintf(os.Stderr, "%sError:%s Download failed with status %d\n", Red, Reset, resp.StatusCode)
-		os.Exit(1)
+	// The explain database follows the installed binary
+	if err := refreshExplainDB(target, checksums); err != nil {
+		logging.Notice("Could not refresh explain database: %v", err)
 	}
 
-	binaryData, err := io.ReadAll(resp.Body)
+	logging.Success("Successfully upgraded to %s!", target.version())
+	fmt.Printf("  Run %scpx version%s to verify, or %scpx upgrade --rollback%s to go back to %s.\n", Cyan, Reset, Cyan, Reset, Version)
+	return nil
+}
+
+// refreshExplainDB installs the explain database of target, checked against
+// its checksums unless they are nil
+func refreshExplainDB(target release, checksums []byte) error {
+	url, ok := target.assetURL(explainAsset)
+	if !ok {
+		return fmt.Errorf("release %s publishes no %s", target.version(), explainAsset)
+	}
+	data, err := download(url)
+	if err != nil {
+		return err
+	}
+	if checksums != nil {
+		if err := verifyChecksum(data, checksums, explainAsset); err != nil {
+			return err
+		}
+	}
+	db, err := explain.Save(data)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to read download: %v\n", Red, Reset, err)
-		os.Exit(1)
+		return err
 	}
+	logging.Info("  Explain database updated (%d entries)", len(db.Entries))
+	return nil
+}
 
-	// Get current executable path
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download: %w", err)
+	}
+	return data, nil
+}
+
+// verifyChecksum checks data against the sha256sum line of name in
+// checksums
+func verifyChecksum(data, checksums []byte, name string) error {
+	for _, line := range strings.Split(string(checksums), "\n") {
+		fields := strings.Fields(line)
+		// sha256sum marks binary mode with a * before the name
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, fields[0]) {
+			return fmt.Errorf("checksum mismatch for %s: expected %s, got %s\n  hint: the download may be corrupted or tampered with; try again later", name, fields[0], actual)
+		}
+		return nil
+	}
+	return fmt.Errorf("%s has no checksum for %s", checksumsAsset, name)
+}
+
+func currentExecutable() (string, error) {
 	execPath, err := os.Executable()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to get executable path: %v\n", Red, Reset, err)
-		os.Exit(1)
+		return "", fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = resolved
+	}
+	return execPath, nil
+}
+
+// upgradeBackupDir is where the binary replaced by an upgrade is kept
+func upgradeBackupDir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "bin"), nil
+}
+
+// previousBinary returns the paths of the kept binary and of the file
+// holding its version
+func previousBinary(backupDir string) (binary, version string) {
+	name := "cpx-previous"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
 	}
-	execPath, _ = filepath.EvalSymlinks(execPath)
+	return filepath.Join(backupDir, name), filepath.Join(backupDir, "cpx-previous.version")
+}
 
-	// Write to temp file first
+// replaceExecutable installs data as execPath, keeping the binary it
+// replaces (of version current) in backupDir. It returns false if execPath
+// is not writable and the binary was left in the temp directory to move by
+// hand; the current binary is kept for --rollback then too.
+func replaceExecutable(execPath string, data []byte, backupDir, current string) (bool, error) {
+	// Write next to the executable first, so the rename cannot cross devices
 	tempPath := execPath + ".new"
-	if err := os.WriteFile(tempPath, binaryData, 0755); err != nil {
+	if err := os.WriteFile(tempPath, data, 0755); err != nil {
 		// Try writing to temp directory instead
 		tempPath = filepath.Join(os.TempDir(), "cpx-new")
-		if err := os.WriteFile(tempPath, binaryData, 0755); err != nil {
-			fmt.Fprintf(os.Stderr, "%sError:%s Failed to write binary: %v\n", Red, Reset, err)
-			os.Exit(1)
+		if err := os.WriteFile(tempPath, data, 0755); err != nil {
+			return false, fmt.Errorf("failed to write binary: %w", err)
+		}
+		if err := backupExecutable(execPath, backupDir, current); err != nil {
+			os.Remove(tempPath)
+			return false, err
 		}
-		fmt.Printf("%s Downloaded to %s%s\n", Green, tempPath, Reset)
+		logging.Success("Downloaded to %s", tempPath)
 		fmt.Printf("\nTo complete the upgrade, run:\n")
 		fmt.Printf("  sudo mv %s %s\n", tempPath, execPath)
-		return
+		fmt.Printf("\n%s is kept; %ssudo cpx upgrade --rollback%s goes back to it after the move.\n", current, Cyan, Reset)
+		return false, nil
 	}
 
-	// Remove old binary and rename new one
-	os.Remove(execPath)
+	if err := backupExecutable(execPath, backupDir, current); err != nil {
+		os.Remove(tempPath)
+		return false, err
+	}
+	return true, swapExecutable(execPath, tempPath)
+}
+
+// swapExecutable moves tempPath over execPath. Windows cannot replace a
+// running executable but can rename it, so execPath is moved aside first.
+func swapExecutable(execPath, tempPath string) error {
+	oldPath := execPath + ".old"
+	os.Remove(oldPath)
+	if err := os.Rename(execPath, oldPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to replace binary: %w", err)
+	}
 	if err := os.Rename(tempPath, execPath); err != nil {
-		fmt.Fprintf(os.Stderr, "%sError:%s Failed to replace binary: %v\n", Red, Reset, err)
-		fmt.Printf("\nTo complete manually, run:\n")
-		fmt.Printf("  sudo mv %s %s\n", tempPath, execPath)
-		os.Exit(1)
+		os.Rename(oldPath, execPath)
+		return fmt.Errorf("failed to replace binary: %w", err)
 	}
+	os.Remove(oldPath)
+	return nil
+}
 
-	fmt.Printf("%s Successfully upgraded to %s!%s\n", Green, latestVersion, Reset)
-	fmt.Printf("  Run %scpx version%s to verify.\n", Cyan, Reset)
+// backupExecutable copies execPath (of version current) to backupDir
+func backupExecutable(execPath, backupDir, current string) error {
+	data, err := os.ReadFile(execPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", execPath, err)
+	}
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", backupDir, err)
+	}
+	binary, version := previousBinary(backupDir)
+	if err := os.WriteFile(binary, data, 0755); err != nil {
+		return fmt.Errorf("failed to keep the current binary: %w", err)
+	}
+	return os.WriteFile(version, []byte(current+"\n"), 0644)
+}
+
+func rollbackUpgrade() error {
+	backupDir, err := upgradeBackupDir()
+	if err != nil {
+		return err
+	}
+	execPath, err := currentExecutable()
+	if err != nil {
+		return err
+	}
+	previous, err := restorePrevious(execPath, backupDir, Version)
+	if err != nil {
+		return err
+	}
+	logging.Success("Rolled back to %s", previous)
+	fmt.Printf("  %s is kept; run %scpx upgrade --rollback%s again to return to it.\n", Version, Cyan, Reset)
+	return nil
+}
+
+// restorePrevious swaps execPath (of version current) with the binary kept
+// in backupDir, and returns the version it restored
+func restorePrevious(execPath, backupDir, current string) (string, error) {
+	binary, versionFile := previousBinary(backupDir)
+	data, err := os.ReadFile(binary)
+	if os.IsNotExist(err) {
+		return "", exitcode.Errorf(exitcode.Usage, "no previous version of cpx to roll back to\n  hint: cpx keeps the binary an upgrade replaces in %s", backupDir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", binary, err)
+	}
+	previous := "the previous version"
+	if v, err := os.ReadFile(versionFile); err == nil && strings.TrimSpace(string(v)) != "" {
+		previous = strings.TrimSpace(string(v))
+	}
+
+	tempPath := execPath + ".new"
+	if err := os.WriteFile(tempPath, data, 0755); err != nil {
+		return "", fmt.Errorf("failed to write binary: %w\n  hint: run 'sudo cpx upgrade --rollback' if cpx is installed in a system directory", err)
+	}
+	if err := backupExecutable(execPath, backupDir, current); err != nil {
+		os.Remove(tempPath)
+		return "", err
+	}
+	if err := swapExecutable(execPath, tempPath); err != nil {
+		return "", err
+	}
+	return previous, nil
 }
 
 // runUpgradeVcpkg updates vcpkg by running git pull in its directory
 func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
+	if err := offline.Required("cpx upgrade vcpkg"); err != nil {
+		return err
+	}
 	// Load global config to get vcpkg root
 	cfg, err := config.LoadGlobal()
 	if err != nil {
@@ -188,7 +581,7 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 		return fmt.Errorf("vcpkg directory is not a git repository: %s", vcpkgRoot)
 	}
 
-	fmt.Printf("%s Updating vcpkg in %s...%s\n", Cyan, vcpkgRoot, Reset)
+	logging.Step("Updating vcpkg in %s...", vcpkgRoot)
 
 	// Run git pull
 	cmd := exec.Command("git", "pull")
@@ -201,7 +594,7 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 	}
 
 	// Run bootstrap to ensure vcpkg binary is up to date
-	fmt.Printf("%s Running bootstrap...%s\n", Cyan, Reset)
+	logging.Step("Running bootstrap...")
 
 	var bootstrapCmd *exec.Cmd
 	if runtime.GOOS == "windows" {
@@ -214,9 +607,9 @@ func runUpgradeVcpkg(_ *cobra.Command, _ []string) error {
 	bootstrapCmd.Stderr = os.Stderr
 
 	if err := bootstrapCmd.Run(); err != nil {
-		fmt.Printf("%s Bootstrap failed (vcpkg may still work): %v%s\n", Yellow, err, Reset)
+		logging.Notice("Bootstrap failed (vcpkg may still work): %v", err)
 	}
 
-	fmt.Printf("%s vcpkg updated successfully!%s\n", Green, Reset)
+	logging.Success("vcpkg updated successfully!")
 	return nil
 }
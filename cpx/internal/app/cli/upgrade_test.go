@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/explain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectRelease(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[
+  {"tag_name": "v1.2.0-nightly.20261015", "prerelease": true},
+  {"tag_name": "v1.2.0-rc.1", "draft": true},
+  {"tag_name": "v1.1.0", "assets": [{"name": "checksums.txt", "browser_download_url": "https://example.com/checksums.txt"}]},
+  {"tag_name": "v1.0.16"}
+]`)
+	}))
+	defer server.Close()
+	oldURL := releasesURL
+	releasesURL = server.URL
+	defer func() { releasesURL = oldURL }()
+
+	releases, err := fetchReleases()
+	require.NoError(t, err)
+	require.Len(t, releases, 3)
+
+	stable, err := selectRelease(releases, ChannelStable, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", stable.version())
+	url, ok := stable.assetURL("checksums.txt")
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com/checksums.txt", url)
+
+	nightly, err := selectRelease(releases, ChannelNightly, "")
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.0-nightly.20261015", nightly.version())
+	assert.Len(t, channelReleases(releases, ChannelStable), 2)
+
+	pinned, err := selectRelease(releases, ChannelStable, "v1.0.16")
+	require.NoError(t, err)
+	assert.Equal(t, "v1.0.16", pinned.TagName)
+	_, err = selectRelease(releases, ChannelStable, "0.9.0")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	_, err = selectRelease(nil, ChannelStable, "")
+	assert.Error(t, err)
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("cpx binary")
+	sum := sha256.Sum256(data)
+	checksums := []byte(hex.EncodeToString(sum[:]) + "  cpx-linux-amd64\n" +
+		hex.EncodeToString(sum[:]) + " *cpx-windows-amd64.exe\n")
+
+	assert.NoError(t, verifyChecksum(data, checksums, "cpx-linux-amd64"))
+	assert.NoError(t, verifyChecksum(data, checksums, "cpx-windows-amd64.exe"))
+	assert.ErrorContains(t, verifyChecksum([]byte("tampered"), checksums, "cpx-linux-amd64"), "checksum mismatch")
+	assert.ErrorContains(t, verifyChecksum(data, checksums, "cpx-darwin-arm64"), "no checksum")
+}
+
+func TestUpgradeRollback(t *testing.T) {
+	dir := t.TempDir()
+	execPath := filepath.Join(dir, "cpx")
+	backupDir := filepath.Join(dir, "config", "bin")
+	require.NoError(t, os.WriteFile(execPath, []byte("v1"), 0755))
+
+	_, err := restorePrevious(execPath, backupDir, "1.0.0")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+
+	installed, err := replaceExecutable(execPath, []byte("v2"), backupDir, "1.0.0")
+	require.NoError(t, err)
+	assert.True(t, installed)
+	assertContent := func(path, want string) {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data))
+	}
+	assertContent(execPath, "v2")
+	binary, _ := previousBinary(backupDir)
+	assertContent(binary, "v1")
+	assert.NoFileExists(t, execPath+".new")
+	assert.NoFileExists(t, execPath+".old")
+
+	// Rolling back swaps the binaries, so it can be undone
+	previous, err := restorePrevious(execPath, backupDir, "1.1.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", previous)
+	assertContent(execPath, "v1")
+	assertContent(binary, "v2")
+
+	previous, err = restorePrevious(execPath, backupDir, "1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1.0", previous)
+	assertContent(execPath, "v2")
+}
+
+func TestUpgradeTempDirFallbackKeepsBackup(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", t.TempDir())
+	execPath := filepath.Join(dir, "cpx")
+	backupDir := filepath.Join(dir, "config", "bin")
+	require.NoError(t, os.WriteFile(execPath, []byte("v1"), 0755))
+	// A directory in the way makes writing next to the executable fail
+	require.NoError(t, os.Mkdir(execPath+".new", 0755))
+
+	installed, err := replaceExecutable(execPath, []byte("v2"), backupDir, "1.0.0")
+	require.NoError(t, err)
+	assert.False(t, installed)
+	data, err := os.ReadFile(filepath.Join(os.TempDir(), "cpx-new"))
+	require.NoError(t, err)
+	assert.Equal(t, "v2", string(data))
+	binary, _ := previousBinary(backupDir)
+	data, err = os.ReadFile(binary)
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data), "the current binary is kept for --rollback")
+}
+
+func TestRefreshExplainDB(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := []byte(`{"version": "2", "entries": [{"id": "new-check", "tool": "clang-tidy", "title": "New"}]}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(db)
+	}))
+	defer server.Close()
+	target := release{TagName: "v1.1.0", Assets: []releaseAsset{{Name: explainAsset, URL: server.URL + "/explain.json"}}}
+	sum := sha256.Sum256(db)
+
+	assert.ErrorContains(t, refreshExplainDB(target, []byte(strings.Repeat("0", 64)+"  explain.json\n")), "checksum mismatch")
+	path, err := explain.DatabasePath()
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+
+	require.NoError(t, refreshExplainDB(target, []byte(hex.EncodeToString(sum[:])+"  explain.json\n")))
+	assert.NotEmpty(t, explain.Load().Lookup("new-check"))
+
+	assert.ErrorContains(t, refreshExplainDB(release{TagName: "v1.0.0"}, nil), "publishes no explain.json")
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+// VerifyArtifactsCmd creates the verify-artifacts command
+func VerifyArtifactsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify-artifacts [dir]",
+		Short: "Check the checksums and signature of build artifacts",
+		Long: `Check the artifacts of a directory against the ` + artifacts.SumsFile + ` written by
+'cpx ci build' (by default the output directory of cpx.ci, or .bin/ci), and
+the signature of ` + artifacts.SumsFile + ` made with --sign:
+
+  gpg     ` + artifacts.GPGSignatureFile + `, checked against the keys of the gpg keyring
+  cosign  ` + artifacts.CosignSignatureFile + `, checked with the public key of --key, or the
+          certificate (` + artifacts.CosignCertificateFile + `) of a keyless signature
+          with --certificate-identity and --certificate-oidc-issuer
+
+Passing any of the cosign flags requires a cosign signature: an unsigned or
+gpg-signed ` + artifacts.SumsFile + ` fails the check.`,
+		Example: `  cpx verify-artifacts
+  cpx verify-artifacts dist --require-signature
+  cpx verify-artifacts --key cosign.pub
+  cpx verify-artifacts --certificate-identity release@example.com --certificate-oidc-issuer https://accounts.google.com`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: runVerifyArtifacts,
+	}
+	cmd.Flags().String("key", "", "Public key of a cosign signature")
+	cmd.Flags().String("certificate-identity", "", "Identity of a keyless cosign signature (e.g. an email)")
+	cmd.Flags().String("certificate-oidc-issuer", "", "OIDC issuer of a keyless cosign signature")
+	cmd.Flags().Bool("require-signature", false, "Fail if "+artifacts.SumsFile+" is not signed (implied by --key and the --certificate flags)")
+	return cmd
+}
+
+func runVerifyArtifacts(cmd *cobra.Command, args []string) error {
+	var opts artifacts.VerifyOptions
+	opts.Key, _ = cmd.Flags().GetString("key")
+	opts.CertificateIdentity, _ = cmd.Flags().GetString("certificate-identity")
+	opts.CertificateOIDCIssuer, _ = cmd.Flags().GetString("certificate-oidc-issuer")
+	requireSignature, _ := cmd.Flags().GetBool("require-signature")
+	// Asking for a cosign signer means the checksums alone aren't enough
+	requireSignature = requireSignature || opts.Cosign()
+
+	dir := ciOutputDir()
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if _, err := os.Stat(filepath.Join(dir, artifacts.SumsFile)); err != nil {
+		return exitcode.Errorf(exitcode.Config, "%s not found in %s\n  hint: 'cpx ci build' writes it next to the artifacts", artifacts.SumsFile, dir)
+	}
+
+	verifyArgs, signer, err := artifacts.VerifyArgs(dir, opts)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	if verifyArgs == nil {
+		if requireSignature {
+			return fmt.Errorf("%s in %s is not signed\n  hint: sign it with 'cpx ci build --sign gpg|cosign'", artifacts.SumsFile, dir)
+		}
+		logging.Warn("%s is not signed; only the checksums are checked", artifacts.SumsFile)
+	} else {
+		logging.Step("Verifying the %s signature of %s...", signer, artifacts.SumsFile)
+		verify := execCommand(verifyArgs[0], verifyArgs[1:]...)
+		verify.Stdout = os.Stdout
+		verify.Stderr = os.Stderr
+		if err := verify.Run(); err != nil {
+			return exitcode.Wrap(exitcode.Failure, fmt.Errorf("the %s signature of %s is not valid: %w", signer, filepath.Join(dir, artifacts.SumsFile), err))
+		}
+	}
+
+	checked, problems, err := artifacts.VerifySums(dir)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		logging.Error("%s: %s", problem.Path, problem.Reason)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d of %d artifacts don't match %s", len(problems), checked, artifacts.SumsFile)
+	}
+	if signer != "" {
+		logging.Success("%s %d artifacts match %s, signed with %s", IconSuccess, checked, artifacts.SumsFile, signer)
+	} else {
+		logging.Success("%s %d artifacts match %s", IconSuccess, checked, artifacts.SumsFile)
+	}
+	return nil
+}
+
+// ciOutputDir returns the output directory of cpx ci build
+func ciOutputDir() string {
+	if ciConfig, err := config.LoadCI("cpx.ci"); err == nil {
+		return ciConfig.Output
+	}
+	return filepath.Join(".bin", "ci")
+}
+
+// checkSigner validates the signer of cpx ci build --sign
+func checkSigner(signer string) error {
+	if signer != "" && !slices.Contains(artifacts.Signers, signer) {
+		return exitcode.Errorf(exitcode.Usage, "unknown signer %q\n  hint: use --sign %s", signer, strings.Join(artifacts.Signers, " or --sign "))
+	}
+	return nil
+}
+
+// writeArtifactSums writes the SHA256SUMS of the artifacts of cpx ci build
+// and signs it with signer, if set
+func writeArtifactSums(dir, signer, key string) error {
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	count, err := artifacts.WriteSums(dir)
+	if err != nil {
+		return err
+	}
+	logging.Success("%s Wrote %s for %d artifacts", IconSuccess, filepath.Join(dir, artifacts.SumsFile), count)
+	if signer == "" {
+		return nil
+	}
+	signArgs, err := artifacts.SignArgs(dir, signer, key)
+	if err != nil {
+		return exitcode.Wrap(exitcode.Usage, err)
+	}
+	sign := execCommand(signArgs[0], signArgs[1:]...)
+	sign.Stdin = os.Stdin
+	sign.Stdout = os.Stdout
+	sign.Stderr = os.Stderr
+	if err := sign.Run(); err != nil {
+		return exitcode.Wrap(exitcode.Failure, fmt.Errorf("failed to sign %s with %s: %w", artifacts.SumsFile, signer, err))
+	}
+	logging.Success("%s Signed %s with %s", IconSuccess, artifacts.SumsFile, signer)
+	return nil
+}
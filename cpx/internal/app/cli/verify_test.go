@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/artifacts"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerifyArtifacts(t *testing.T) {
+	oldExecCommand := execCommand
+	defer func() { execCommand = oldExecCommand }()
+	var capturedArgs [][]string
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		capturedArgs = append(capturedArgs, append([]string{name}, arg...))
+		cs := append([]string{"-test.run=TestHelperProcess", "--", name}, arg...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(t.TempDir()))
+
+	assert.Equal(t, exitcode.Usage, exitcode.Of(checkSigner("minisign")))
+	assert.NoError(t, checkSigner(""))
+	assert.NoError(t, writeArtifactSums(ciOutputDir(), "gpg", ""), "nothing to sum without an output directory")
+	assert.Empty(t, capturedArgs)
+
+	outputDir := filepath.Join(".bin", "ci")
+	require.NoError(t, os.MkdirAll(filepath.Join(outputDir, "linux-amd64"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "linux-amd64", "app"), []byte("app"), 0755))
+
+	// Unsigned: the checksums are checked, unless a signature is required
+	require.NoError(t, writeArtifactSums(ciOutputDir(), "", ""))
+	assert.FileExists(t, filepath.Join(outputDir, artifacts.SumsFile))
+	cmd := VerifyArtifactsCmd()
+	require.NoError(t, cmd.RunE(cmd, nil))
+	require.NoError(t, cmd.Flags().Set("require-signature", "true"))
+	assert.ErrorContains(t, cmd.RunE(cmd, nil), "not signed")
+
+	// A cosign key or identity requires the signature too
+	for flag, value := range map[string]string{"key": "cosign.pub", "certificate-identity": "release@example.com", "certificate-oidc-issuer": "https://accounts.google.com"} {
+		cosignCmd := VerifyArtifactsCmd()
+		require.NoError(t, cosignCmd.Flags().Set(flag, value))
+		assert.ErrorContains(t, cosignCmd.RunE(cosignCmd, nil), "not signed", flag)
+	}
+
+	require.NoError(t, writeArtifactSums(ciOutputDir(), "gpg", "ABCD1234"))
+	require.Len(t, capturedArgs, 1)
+	assert.Equal(t, "gpg", capturedArgs[0][0])
+	assert.Contains(t, capturedArgs[0], "ABCD1234")
+
+	// The mocked gpg doesn't write the signature
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, artifacts.GPGSignatureFile), []byte("sig"), 0644))
+	capturedArgs = nil
+	require.NoError(t, cmd.RunE(cmd, []string{outputDir}))
+	require.Len(t, capturedArgs, 1)
+	assert.Equal(t, []string{"gpg", "--batch", "--verify"}, capturedArgs[0][:3])
+
+	// --key isn't silently ignored for a gpg signature
+	keyCmd := VerifyArtifactsCmd()
+	require.NoError(t, keyCmd.Flags().Set("key", "cosign.pub"))
+	capturedArgs = nil
+	err = keyCmd.RunE(keyCmd, nil)
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.ErrorContains(t, err, "signed with gpg, not cosign")
+	assert.Empty(t, capturedArgs)
+
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "linux-amd64", "app"), []byte("tampered"), 0755))
+	assert.ErrorContains(t, cmd.RunE(cmd, nil), "1 of 1 artifacts don't match")
+
+	assert.Equal(t, exitcode.Config, exitcode.Of(cmd.RunE(cmd, []string{"missing"})))
+}
@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/spf13/cobra"
+)
+
+// WhyCmd creates the why command
+func WhyCmd(client *vcpkg.Client) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "why <package>",
+		Short: "Show why a package is installed",
+		Long: `Show the chains of dependencies that bring a package into the project, like
+cargo tree -i: the package comes first, under it the ports that depend on
+it, and so on up to the dependencies listed in vcpkg.json.
+
+Features enabled by vcpkg.json or by other ports bring in their own
+dependencies, and default features count unless vcpkg.json turns them off.
+Dependencies limited to some platforms are followed and marked with their
+platform expression. Ports shown before are marked (*) and not repeated.`,
+		Example: `  cpx why zlib
+  cpx why vcpkg-cmake`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completePorts,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWhy(args[0], client)
+		},
+	}
+
+	return cmd
+}
+
+func runWhy(name string, client *vcpkg.Client) error {
+	if err := requireVcpkgProject("cpx why"); err != nil {
+		return err
+	}
+	if client == nil {
+		return fmt.Errorf("vcpkg client not initialized")
+	}
+	vcpkgPath, err := client.GetPath()
+	if err != nil {
+		return err
+	}
+	vcpkgRoot := filepath.Dir(vcpkgPath)
+
+	index, err := loadPortIndex(vcpkgRoot, false)
+	if err != nil {
+		return err
+	}
+	manifest, err := vcpkg.ReadManifest("vcpkg.json")
+	if err != nil {
+		return err
+	}
+
+	graph := resolveDependencyGraph(manifest, func(name string) *vcpkg.Port {
+		if port, ok := index.Port(name); ok {
+			return port
+		}
+		// vcpkg's helper ports aren't indexed
+		if port, err := vcpkg.LoadPort(vcpkgRoot, name); err == nil {
+			return port
+		}
+		return nil
+	})
+
+	if _, ok := graph.features[name]; !ok {
+		if _, ok := index.Port(name); !ok {
+			if _, err := vcpkg.LoadPort(vcpkgRoot, name); err != nil {
+				return exitcode.Errorf(exitcode.Usage, "no vcpkg port named %q\n  hint: find packages with cpx search %s", name, name)
+			}
+		}
+		logging.Notice("%s is not a dependency of the project", name)
+		return nil
+	}
+
+	lines := graph.whyTree(name)
+	fmt.Printf("%s%s%s\n", Bold, lines[0], Reset)
+	for _, line := range lines[1:] {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// dependencyGraph holds the ports a vcpkg.json brings in, directly or through
+// other ports, with the features each of them is built with
+type dependencyGraph struct {
+	// ports is nil for ports missing from the vcpkg checkout, such as the
+	// ports of registries
+	ports    map[string]*vcpkg.Port
+	features map[string]map[string]bool
+	// dependents are the edges leading to each port
+	dependents map[string][]dependencyEdge
+}
+
+// dependencyEdge is a dependency on a port, of another port or of vcpkg.json
+// if from is empty
+type dependencyEdge struct {
+	from string
+	// feature is the feature of from that needs the dependency; empty for
+	// the dependencies of the port itself
+	feature  string
+	host     bool
+	platform string
+}
+
+// manifestDependency is a dependency of vcpkg.json
+type manifestDependency struct {
+	vcpkg.PortDependency
+	noDefaultFeatures bool
+}
+
+// manifestDependencies returns the dependencies of a vcpkg.json, which are
+// port names or objects
+func manifestDependencies(manifest map[string]any) []manifestDependency {
+	list, _ := manifest["dependencies"].([]any)
+	var deps []manifestDependency
+	for _, d := range list {
+		dep := manifestDependency{PortDependency: vcpkg.PortDependency{Name: dependencyName(d)}}
+		if dep.Name == "" {
+			continue
+		}
+		if obj, ok := d.(map[string]any); ok {
+			dep.Host, _ = obj["host"].(bool)
+			dep.Platform, _ = obj["platform"].(string)
+			if defaults, ok := obj["default-features"].(bool); ok {
+				dep.noDefaultFeatures = !defaults
+			}
+			features, _ := obj["features"].([]any)
+			for _, f := range features {
+				if feature := dependencyName(f); feature != "" {
+					dep.Features = append(dep.Features, feature)
+				}
+			}
+		}
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+// resolveDependencyGraph follows the dependencies of manifest through the
+// ports lookup returns, and the dependencies of the features they need,
+// until no port needs more features
+func resolveDependencyGraph(manifest map[string]any, lookup func(name string) *vcpkg.Port) *dependencyGraph {
+	g := &dependencyGraph{
+		ports:      make(map[string]*vcpkg.Port),
+		features:   make(map[string]map[string]bool),
+		dependents: make(map[string][]dependencyEdge),
+	}
+	direct := manifestDependencies(manifest)
+	// As in vcpkg, only vcpkg.json can turn the default features of a port off
+	noDefaults := make(map[string]bool)
+	for _, dep := range direct {
+		if dep.noDefaultFeatures {
+			noDefaults[dep.Name] = true
+		}
+	}
+
+	var queue []string
+	enable := func(name string, features []string) {
+		enabled, seen := g.features[name]
+		if !seen {
+			enabled = make(map[string]bool)
+			g.features[name] = enabled
+			g.ports[name] = lookup(name)
+			if port := g.ports[name]; port != nil && !noDefaults[name] {
+				features = append(features, port.DefaultFeatures...)
+			}
+		}
+		changed := !seen
+		for _, feature := range features {
+			if feature == "default" {
+				if port := g.ports[name]; port != nil {
+					for _, f := range port.DefaultFeatures {
+						changed = changed || !enabled[f]
+						enabled[f] = true
+					}
+				}
+				continue
+			}
+			if feature != "core" && !enabled[feature] {
+				enabled[feature] = true
+				changed = true
+			}
+		}
+		if changed {
+			queue = append(queue, name)
+		}
+	}
+
+	for _, dep := range direct {
+		enable(dep.Name, dep.Features)
+		g.addEdge(dep.Name, dependencyEdge{host: dep.Host, platform: dep.Platform})
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		g.eachDependency(name, func(dep vcpkg.PortDependency, _ string) {
+			enable(dep.Name, dep.Features)
+		})
+	}
+
+	names := make([]string, 0, len(g.features))
+	for name := range g.features {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.eachDependency(name, func(dep vcpkg.PortDependency, feature string) {
+			g.addEdge(dep.Name, dependencyEdge{from: name, feature: feature, host: dep.Host, platform: dep.Platform})
+		})
+	}
+	return g
+}
+
+// eachDependency calls fn with each dependency of the port called name and
+// its enabled features, and the feature needing it
+func (g *dependencyGraph) eachDependency(name string, fn func(dep vcpkg.PortDependency, feature string)) {
+	port := g.ports[name]
+	if port == nil {
+		return
+	}
+	for _, dep := range port.Dependencies {
+		fn(dep, "")
+	}
+	for _, feature := range port.Features {
+		if g.features[name][feature.Name] {
+			for _, dep := range feature.Dependencies {
+				fn(dep, feature.Name)
+			}
+		}
+	}
+}
+
+// addEdge adds an edge to the port called name, unless the same port (or
+// vcpkg.json) already leads to it
+func (g *dependencyGraph) addEdge(name string, edge dependencyEdge) {
+	for _, e := range g.dependents[name] {
+		if e.from == edge.from {
+			return
+		}
+	}
+	g.dependents[name] = append(g.dependents[name], edge)
+}
+
+// portLabel returns the name and version of a port
+func (g *dependencyGraph) portLabel(name string) string {
+	if port := g.ports[name]; port != nil && port.Version != "" {
+		return name + " " + port.Version
+	}
+	return name
+}
+
+// whyTree returns the lines of the inverted dependency tree of the port
+// called name: the ports depending on it under it, down to vcpkg.json
+func (g *dependencyGraph) whyTree(name string) []string {
+	lines := []string{g.portLabel(name)}
+	shown := map[string]bool{name: true}
+
+	var walk func(name, indent string)
+	walk = func(name, indent string) {
+		edges := g.dependents[name]
+		for i, edge := range edges {
+			branch, next := "├── ", "│   "
+			if i == len(edges)-1 {
+				branch, next = "└── ", "    "
+			}
+			label := "vcpkg.json"
+			if edge.from != "" {
+				label = g.portLabel(edge.from)
+			}
+			var notes []string
+			if edge.feature != "" {
+				notes = append(notes, "feature "+edge.feature)
+			}
+			if edge.host {
+				notes = append(notes, "host")
+			}
+			if edge.platform != "" {
+				notes = append(notes, "platform "+edge.platform)
+			}
+			if len(notes) > 0 {
+				label += " (" + strings.Join(notes, ", ") + ")"
+			}
+			if edge.from != "" && shown[edge.from] {
+				lines = append(lines, indent+branch+label+" (*)")
+				continue
+			}
+			lines = append(lines, indent+branch+label)
+			if edge.from != "" {
+				shown[edge.from] = true
+				walk(edge.from, indent+next)
+			}
+		}
+	}
+	walk(name, "")
+	return lines
+}
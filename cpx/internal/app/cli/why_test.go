@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhyTree(t *testing.T) {
+	ports := map[string]*vcpkg.Port{
+		"curl": {Name: "curl", Version: "8.8.0",
+			Dependencies:    []vcpkg.PortDependency{{Name: "zlib"}, {Name: "vcpkg-cmake", Host: true}},
+			Features:        []vcpkg.PortFeature{{Name: "ssl", Dependencies: []vcpkg.PortDependency{{Name: "openssl", Platform: "!windows"}}}},
+			DefaultFeatures: []string{"ssl"},
+		},
+		"libpng": {Name: "libpng", Version: "1.6.43",
+			Dependencies: []vcpkg.PortDependency{{Name: "zlib"}},
+		},
+		"cpr": {Name: "cpr", Version: "1.10.5",
+			Dependencies: []vcpkg.PortDependency{{Name: "curl", Features: []string{"core"}}},
+		},
+		"openssl":     {Name: "openssl", Version: "3.3.0", Dependencies: []vcpkg.PortDependency{{Name: "vcpkg-cmake", Host: true}}},
+		"zlib":        {Name: "zlib", Version: "1.3.1", Dependencies: []vcpkg.PortDependency{{Name: "vcpkg-cmake", Host: true}}},
+		"vcpkg-cmake": {Name: "vcpkg-cmake", Version: "2024-04-23"},
+	}
+	lookup := func(name string) *vcpkg.Port { return ports[name] }
+	resolve := func(manifest string) *dependencyGraph {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal([]byte(manifest), &m))
+		return resolveDependencyGraph(m, lookup)
+	}
+
+	g := resolve(`{"dependencies": ["cpr", "libpng", {"name": "acme-log", "platform": "linux"}]}`)
+	assert.Equal(t, []string{
+		"zlib 1.3.1",
+		"├── curl 8.8.0",
+		"│   └── cpr 1.10.5",
+		"│       └── vcpkg.json",
+		"└── libpng 1.6.43",
+		"    └── vcpkg.json",
+	}, g.whyTree("zlib"))
+	// Default features of curl are on
+	assert.Equal(t, []string{
+		"openssl 3.3.0",
+		"└── curl 8.8.0 (feature ssl, platform !windows)",
+		"    └── cpr 1.10.5",
+		"        └── vcpkg.json",
+	}, g.whyTree("openssl"))
+	assert.Equal(t, []string{
+		"vcpkg-cmake 2024-04-23",
+		"├── curl 8.8.0 (host)",
+		"│   └── cpr 1.10.5",
+		"│       └── vcpkg.json",
+		"├── openssl 3.3.0 (host)",
+		"│   └── curl 8.8.0 (feature ssl, platform !windows) (*)",
+		"└── zlib 1.3.1 (host)",
+		"    ├── curl 8.8.0 (*)",
+		"    └── libpng 1.6.43",
+		"        └── vcpkg.json",
+	}, g.whyTree("vcpkg-cmake"))
+	// Ports missing from the checkout are direct dependencies only
+	assert.Equal(t, []string{"acme-log", "└── vcpkg.json (platform linux)"}, g.whyTree("acme-log"))
+
+	// vcpkg.json turns the default features of curl off
+	g = resolve(`{"dependencies": ["cpr", {"name": "curl", "default-features": false}]}`)
+	assert.NotContains(t, g.features, "openssl")
+	assert.Equal(t, []string{
+		"curl 8.8.0",
+		"├── vcpkg.json",
+		"└── cpr 1.10.5",
+		"    └── vcpkg.json",
+	}, g.whyTree("curl"))
+	g = resolve(`{"dependencies": [{"name": "curl", "default-features": false, "features": ["ssl"]}]}`)
+	assert.Contains(t, g.features, "openssl")
+}
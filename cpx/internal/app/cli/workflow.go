@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/pkg/config"
 	"github.com/spf13/cobra"
 )
@@ -42,7 +43,7 @@ func runGenerateGitHub(_ *cobra.Command, _ []string) error {
 	if err := generateGitHubActionsWorkflow(); err != nil {
 		return err
 	}
-	fmt.Printf("%s✓ Created GitHub Actions workflow: .github/workflows/ci.yml%s\n", Green, Reset)
+	logging.Success("✓ Created GitHub Actions workflow: .github/workflows/ci.yml")
 	return nil
 }
 
@@ -50,7 +51,7 @@ func runGenerateGitLab(_ *cobra.Command, _ []string) error {
 	if err := generateGitLabCI(); err != nil {
 		return err
 	}
-	fmt.Printf("%s✓ Created GitLab CI configuration: .gitlab-ci.yml%s\n", Green, Reset)
+	logging.Success("✓ Created GitLab CI configuration: .gitlab-ci.yml")
 	return nil
 }
 
@@ -67,7 +68,7 @@ func generateGitHubActionsWorkflow() error {
 	ciConfig, err := config.LoadCI(ciConfigPath)
 	outputDir := "out"
 	if err != nil {
-		fmt.Printf("%s Warning: cpx.ci not found. Creating basic workflow.%s\n", Yellow, Reset)
+		logging.Warn("cpx.ci not found. Creating basic workflow.")
 		fmt.Printf("  Create cpx.ci to customize build targets and configuration.\n")
 	} else {
 		outputDir = ciConfig.Output
@@ -144,7 +145,7 @@ func generateGitLabCI() error {
 	ciConfig, err := config.LoadCI(ciConfigPath)
 	outputDir := "out"
 	if err != nil {
-		fmt.Printf("%s Warning: cpx.ci not found. Creating basic CI configuration.%s\n", Yellow, Reset)
+		logging.Warn("cpx.ci not found. Creating basic CI configuration.")
 		fmt.Printf("  Create cpx.ci to customize build targets and configuration.\n")
 	} else {
 		outputDir = ciConfig.Output
@@ -0,0 +1,211 @@
+// Package artifacts writes and checks the SHA256SUMS of build artifacts and
+// the commands signing and verifying it with gpg or cosign.
+package artifacts
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SumsFile lists the sha256 of the artifacts of a directory, in the format
+// of sha256sum
+const SumsFile = "SHA256SUMS"
+
+// Signature files of SumsFile
+const (
+	GPGSignatureFile    = SumsFile + ".asc"
+	CosignSignatureFile = SumsFile + ".sig"
+	// CosignCertificateFile is the certificate of a keyless cosign signature
+	CosignCertificateFile = SumsFile + ".pem"
+)
+
+// Signers are the tools cpx signs SumsFile with
+var Signers = []string{"gpg", "cosign"}
+
+// isSumsFile reports whether name is SumsFile or one of its signatures
+func isSumsFile(name string) bool {
+	switch name {
+	case SumsFile, GPGSignatureFile, CosignSignatureFile, CosignCertificateFile:
+		return true
+	}
+	return false
+}
+
+// WriteSums writes the SumsFile of the regular files under dir, with paths
+// relative to dir, and returns the number of files it lists
+func WriteSums(dir string) (int, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && !(filepath.Dir(path) == filepath.Clean(dir) && isSumsFile(d.Name())) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list the artifacts of %s: %w", dir, err)
+	}
+
+	var b strings.Builder
+	for _, path := range paths {
+		sum, err := fileSum(path)
+		if err != nil {
+			return 0, err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprintf(&b, "%s  %s\n", sum, filepath.ToSlash(rel))
+	}
+	sumsPath := filepath.Join(dir, SumsFile)
+	if err := os.WriteFile(sumsPath, []byte(b.String()), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", sumsPath, err)
+	}
+	// A new SHA256SUMS invalidates the signatures of the previous one
+	for _, name := range []string{GPGSignatureFile, CosignSignatureFile, CosignCertificateFile} {
+		os.Remove(filepath.Join(dir, name))
+	}
+	return len(paths), nil
+}
+
+// Problem is an artifact that doesn't match its SumsFile entry
+type Problem struct {
+	Path string
+	// Reason is "missing" or "checksum mismatch"
+	Reason string
+}
+
+// VerifySums checks the artifacts of dir against its SumsFile and returns
+// the number of artifacts checked and those that don't match
+func VerifySums(dir string) (int, []Problem, error) {
+	sumsPath := filepath.Join(dir, SumsFile)
+	f, err := os.Open(sumsPath)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+
+	var problems []Problem
+	checked := 0
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		want, name, ok := strings.Cut(text, "  ")
+		if !ok || len(want) != sha256.Size*2 {
+			return 0, nil, fmt.Errorf("%s:%d: invalid line %q", sumsPath, line, text)
+		}
+		if strings.HasPrefix(name, "/") || strings.Contains("/"+name+"/", "/../") {
+			return 0, nil, fmt.Errorf("%s:%d: path %s is outside %s", sumsPath, line, name, dir)
+		}
+		checked++
+		got, err := fileSum(filepath.Join(dir, filepath.FromSlash(name)))
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, Problem{Path: name, Reason: "missing"})
+		case err != nil:
+			return 0, nil, err
+		case got != strings.ToLower(want):
+			problems = append(problems, Problem{Path: name, Reason: "checksum mismatch"})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, fmt.Errorf("failed to read %s: %w", sumsPath, err)
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Path < problems[j].Path })
+	return checked, problems, nil
+}
+
+func fileSum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SignArgs returns the command signing the SumsFile of dir with signer, gpg
+// or cosign. key selects the gpg key (--local-user) or the cosign private
+// key; without one, gpg uses its default key and cosign signs keyless,
+// writing the certificate next to the signature.
+func SignArgs(dir, signer, key string) ([]string, error) {
+	sums := filepath.Join(dir, SumsFile)
+	switch signer {
+	case "gpg":
+		args := []string{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", filepath.Join(dir, GPGSignatureFile)}
+		if key != "" {
+			args = append(args, "--local-user", key)
+		}
+		return append(args, sums), nil
+	case "cosign":
+		args := []string{"cosign", "sign-blob", "--yes", "--output-signature", filepath.Join(dir, CosignSignatureFile)}
+		if key != "" {
+			args = append(args, "--key", key)
+		} else {
+			args = append(args, "--output-certificate", filepath.Join(dir, CosignCertificateFile))
+		}
+		return append(args, sums), nil
+	}
+	return nil, fmt.Errorf("unknown signer %q\n  hint: use --sign %s", signer, strings.Join(Signers, " or --sign "))
+}
+
+// VerifyOptions select the identity a cosign signature must carry: the
+// public key of a key pair, or the certificate identity and OIDC issuer of a
+// keyless signature
+type VerifyOptions struct {
+	Key                   string
+	CertificateIdentity   string
+	CertificateOIDCIssuer string
+}
+
+// Cosign reports whether opts ask for a cosign signature
+func (opts VerifyOptions) Cosign() bool {
+	return opts.Key != "" || opts.CertificateIdentity != "" || opts.CertificateOIDCIssuer != ""
+}
+
+// VerifyArgs returns the command verifying the signature of the SumsFile of
+// dir, and the signer that made it. It returns no command if dir holds no
+// signature. With cosign options only a cosign signature is accepted.
+func VerifyArgs(dir string, opts VerifyOptions) ([]string, string, error) {
+	sums := filepath.Join(dir, SumsFile)
+	_, gpgErr := os.Stat(filepath.Join(dir, GPGSignatureFile))
+	_, cosignErr := os.Stat(filepath.Join(dir, CosignSignatureFile))
+	switch {
+	case gpgErr != nil && cosignErr != nil:
+		return nil, "", nil
+	case cosignErr != nil && opts.Cosign():
+		return nil, "gpg", fmt.Errorf("%s is signed with gpg, not cosign\n  hint: --key, --certificate-identity and --certificate-oidc-issuer verify cosign signatures; drop them to check the gpg signature against your keyring", SumsFile)
+	case gpgErr == nil && !opts.Cosign():
+		return []string{"gpg", "--batch", "--verify", filepath.Join(dir, GPGSignatureFile), sums}, "gpg", nil
+	}
+	args := []string{"cosign", "verify-blob", "--signature", filepath.Join(dir, CosignSignatureFile)}
+	switch {
+	case opts.Key != "":
+		args = append(args, "--key", opts.Key)
+	case opts.CertificateIdentity != "" && opts.CertificateOIDCIssuer != "":
+		args = append(args, "--certificate", filepath.Join(dir, CosignCertificateFile),
+			"--certificate-identity", opts.CertificateIdentity,
+			"--certificate-oidc-issuer", opts.CertificateOIDCIssuer)
+	default:
+		return nil, "cosign", fmt.Errorf("%s is signed with cosign\n  hint: pass the public key with --key, or --certificate-identity and --certificate-oidc-issuer for a keyless signature", SumsFile)
+	}
+	return append(args, sums), "cosign", nil
+}
@@ -0,0 +1,111 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAndVerifySums(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "linux-amd64"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "linux-amd64", "app"), []byte("app"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), []byte("readme"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GPGSignatureFile), []byte("stale"), 0644))
+
+	count, err := WriteSums(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoFileExists(t, filepath.Join(dir, GPGSignatureFile), "a new SHA256SUMS drops the old signature")
+	sums, err := os.ReadFile(filepath.Join(dir, SumsFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(sums), "  README\n")
+	assert.Contains(t, string(sums), "  linux-amd64/app\n")
+
+	checked, problems, err := VerifySums(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	assert.Empty(t, problems)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "linux-amd64", "app"), []byte("tampered"), 0755))
+	require.NoError(t, os.Remove(filepath.Join(dir, "README")))
+	checked, problems, err = VerifySums(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+	assert.Equal(t, []Problem{
+		{Path: "README", Reason: "missing"},
+		{Path: "linux-amd64/app", Reason: "checksum mismatch"},
+	}, problems)
+}
+
+func TestVerifySumsRejectsPathsOutsideDir(t *testing.T) {
+	dir := t.TempDir()
+	sum := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SumsFile), []byte(sum+"  ../secret\n"), 0644))
+	_, _, err := VerifySums(dir)
+	assert.ErrorContains(t, err, "outside")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SumsFile), []byte("not a checksum\n"), 0644))
+	_, _, err = VerifySums(dir)
+	assert.ErrorContains(t, err, "invalid line")
+}
+
+func TestSignArgs(t *testing.T) {
+	args, err := SignArgs("out", "gpg", "ABCD1234")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", filepath.Join("out", GPGSignatureFile), "--local-user", "ABCD1234", filepath.Join("out", SumsFile)}, args)
+
+	args, err = SignArgs("out", "cosign", "")
+	require.NoError(t, err)
+	assert.Contains(t, args, "--output-certificate")
+
+	args, err = SignArgs("out", "cosign", "cosign.key")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosign", "sign-blob", "--yes", "--output-signature", filepath.Join("out", CosignSignatureFile), "--key", "cosign.key", filepath.Join("out", SumsFile)}, args)
+
+	_, err = SignArgs("out", "minisign", "")
+	assert.Error(t, err)
+}
+
+func TestVerifyArgs(t *testing.T) {
+	dir := t.TempDir()
+	args, signer, err := VerifyArgs(dir, VerifyOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, args, "unsigned")
+	assert.Empty(t, signer)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, CosignSignatureFile), []byte("sig"), 0644))
+	_, signer, err = VerifyArgs(dir, VerifyOptions{})
+	assert.Error(t, err, "cosign needs a key or an identity")
+	assert.Equal(t, "cosign", signer)
+
+	args, _, err = VerifyArgs(dir, VerifyOptions{CertificateIdentity: "release@example.com", CertificateOIDCIssuer: "https://accounts.google.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cosign", "verify-blob", "--signature", filepath.Join(dir, CosignSignatureFile),
+		"--certificate", filepath.Join(dir, CosignCertificateFile),
+		"--certificate-identity", "release@example.com",
+		"--certificate-oidc-issuer", "https://accounts.google.com",
+		filepath.Join(dir, SumsFile)}, args)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, GPGSignatureFile), []byte("sig"), 0644))
+	args, signer, err = VerifyArgs(dir, VerifyOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "gpg", signer)
+	assert.Equal(t, []string{"gpg", "--batch", "--verify", filepath.Join(dir, GPGSignatureFile), filepath.Join(dir, SumsFile)}, args)
+
+	// A cosign key picks the cosign signature over the gpg one...
+	args, signer, err = VerifyArgs(dir, VerifyOptions{Key: "cosign.pub"})
+	require.NoError(t, err)
+	assert.Equal(t, "cosign", signer)
+	assert.Contains(t, args, "cosign.pub")
+
+	// ...and is never ignored for a gpg signature
+	require.NoError(t, os.Remove(filepath.Join(dir, CosignSignatureFile)))
+	args, signer, err = VerifyArgs(dir, VerifyOptions{Key: "cosign.pub"})
+	assert.ErrorContains(t, err, "signed with gpg, not cosign")
+	assert.Nil(t, args)
+	assert.Equal(t, "gpg", signer)
+}
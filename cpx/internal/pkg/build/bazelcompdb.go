@@ -0,0 +1,91 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// BazelCompdbDir holds the compile_commands.json generated for Bazel
+// projects. It sits next to the CMake build directories so that
+// FindCompileDatabases picks it up as the "bazel" config.
+var BazelCompdbDir = filepath.Join(".cache", "native", "bazel")
+
+// bazelAqueryOutput is the subset of `bazel aquery --output=jsonproto` used
+type bazelAqueryOutput struct {
+	Actions []struct {
+		Mnemonic  string   `json:"mnemonic"`
+		Arguments []string `json:"arguments"`
+	} `json:"actions"`
+}
+
+// BazelAqueryArgs returns the bazel arguments that list the C++ compile
+// actions needed to build target
+func BazelAqueryArgs(target string) []string {
+	return []string{
+		"aquery",
+		"--output=jsonproto",
+		"--include_artifacts=false",
+		fmt.Sprintf(`mnemonic("CppCompile", deps(%s))`, target),
+	}
+}
+
+// ParseBazelAquery converts the CppCompile actions of an aquery jsonproto
+// output into compilation database entries. Paths into bazel-out/ and
+// external/ are made absolute under execRoot so that the entries work from
+// the workspace directory; sources of external repositories are skipped.
+func ParseBazelAquery(data []byte, workspace, execRoot string) ([]CompileCommand, error) {
+	var out bazelAqueryOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse bazel aquery output: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var entries []CompileCommand
+	for _, action := range out.Actions {
+		if action.Mnemonic != "CppCompile" || len(action.Arguments) == 0 {
+			continue
+		}
+		source := ""
+		for i, arg := range action.Arguments {
+			if arg == "-c" && i+1 < len(action.Arguments) {
+				source = action.Arguments[i+1]
+				break
+			}
+		}
+		if source == "" || isBazelOutputPath(source) || seen[source] {
+			continue
+		}
+		seen[source] = true
+
+		args := make([]string, len(action.Arguments))
+		for i, arg := range action.Arguments {
+			args[i] = rebaseBazelArg(arg, execRoot)
+		}
+		entries = append(entries, CompileCommand{
+			Directory: workspace,
+			File:      source,
+			Arguments: args,
+		})
+	}
+	return entries, nil
+}
+
+// isBazelOutputPath reports whether path points into the execution root
+// rather than the workspace
+func isBazelOutputPath(path string) bool {
+	return strings.HasPrefix(path, "bazel-out/") || strings.HasPrefix(path, "external/")
+}
+
+// rebaseBazelArg makes a path argument (or the path of an -I/-iquote/-isystem
+// flag) absolute under execRoot if it points into bazel-out/ or external/
+func rebaseBazelArg(arg, execRoot string) string {
+	for _, flag := range []string{"-isystem", "-iquote", "-I", ""} {
+		path, ok := strings.CutPrefix(arg, flag)
+		if ok && isBazelOutputPath(path) {
+			return flag + filepath.Join(execRoot, path)
+		}
+	}
+	return arg
+}
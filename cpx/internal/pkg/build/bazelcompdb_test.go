@@ -0,0 +1,37 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBazelAqueryArgs(t *testing.T) {
+	assert.Equal(t, []string{
+		"aquery", "--output=jsonproto", "--include_artifacts=false",
+		`mnemonic("CppCompile", deps(//...))`,
+	}, BazelAqueryArgs("//..."))
+}
+
+func TestParseBazelAquery(t *testing.T) {
+	data := []byte(`{"actions": [
+		{"mnemonic": "CppCompile", "arguments": ["/usr/bin/gcc", "-iquote", "bazel-out/k8-fastbuild/bin",
+			"-isystemexternal/googletest/include", "-Iinclude", "-c", "src/app.cpp", "-o", "bazel-out/k8-fastbuild/bin/src/app.o"]},
+		{"mnemonic": "CppCompile", "arguments": ["/usr/bin/gcc", "-c", "external/googletest/src/gtest.cc"]},
+		{"mnemonic": "CppCompile", "arguments": ["/usr/bin/gcc", "-c", "src/app.cpp", "-O2"]},
+		{"mnemonic": "CppLink", "arguments": ["/usr/bin/gcc", "-o", "app"]}
+	]}`)
+
+	entries, err := ParseBazelAquery(data, "/ws", "/exec")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/ws", entries[0].Directory)
+	assert.Equal(t, "src/app.cpp", entries[0].File)
+	assert.Equal(t, []string{"/usr/bin/gcc", "-iquote", "/exec/bazel-out/k8-fastbuild/bin",
+		"-isystem/exec/external/googletest/include", "-Iinclude", "-c", "src/app.cpp", "-o", "/exec/bazel-out/k8-fastbuild/bin/src/app.o"},
+		entries[0].Arguments)
+
+	_, err = ParseBazelAquery([]byte("not json"), "/ws", "/exec")
+	assert.Error(t, err)
+}
@@ -6,11 +6,13 @@ import (
 	"os/exec"
 	"path/filepath"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 )
 
-// RunBenchmarks builds and runs the project benchmarks
-func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
+// RunBenchmarks builds and runs the project benchmarks.
+// If outFile is non-empty, the benchmark writes JSON results to it (see BenchOutputArgs).
+func RunBenchmarks(verbose bool, outFile string, vcpkgClient *vcpkg.Client) error {
 	// Set VCPKG_ROOT from cpx config if not already set
 	if err := vcpkgClient.SetupEnv(); err != nil {
 		return err
@@ -20,7 +22,7 @@ func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
 	if projectName == "" {
 		return fmt.Errorf("failed to get project name from CMakeLists.txt")
 	}
-	fmt.Printf("%s Running benchmarks for '%s'...%s\n", "\033[36m", projectName, "\033[0m")
+	logging.Step(" Running benchmarks for '%s'...", projectName)
 
 	// Default to debug for benchmarks if no config specified
 	// Use .cache/native/debug for building benchmarks
@@ -28,6 +30,9 @@ func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
 	benchTarget := projectName + "_bench"
 
 	// Check if configure is needed
+	if err := resetStaleCMakeCache(buildDir); err != nil {
+		return err
+	}
 	needsConfigure := false
 	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
@@ -44,9 +49,9 @@ func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
 	if needsConfigure {
 		currentStep++
 		if verbose {
-			fmt.Printf("%s  Configuring CMake...%s\n", "\033[36m", "\033[0m")
+			logging.Step("  Configuring CMake...")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
 		}
 
 		// Determine absolute path for shared vcpkg_installed directory
@@ -55,23 +60,28 @@ func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
 
 		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			cmd := exec.Command("cmake", "--preset=default", "-B", buildDir, vcpkgInstallArg)
+		if _, err := os.Stat(PresetsFile); err == nil {
+			preset, err := ActivePreset()
+			if err != nil {
+				printProgress("\n")
+				return err
+			}
+			cmd := exec.Command("cmake", "--preset="+preset, "-DCPX_PRESET="+preset, "-B", buildDir, vcpkgInstallArg)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
-				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
+				printProgress("\n")
+				return fmt.Errorf("cmake configure failed (preset '%s'): %w", preset, err)
 			}
 		} else {
 			cmd := exec.Command("cmake", "-B", buildDir, vcpkgInstallArg)
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
+				printProgress("\n")
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
 		if !verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
 		}
 	}
 
@@ -85,9 +95,9 @@ func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
 	// Run benchmarks
 	currentStep++
 	if !verbose {
-		fmt.Printf("%s[%d/%d]%s Running benchmarks...\n", colorCyan, currentStep, totalSteps, colorReset)
+		printProgress("%s[%d/%d]%s Running benchmarks...\n", colorCyan, currentStep, totalSteps, colorReset)
 	} else {
-		fmt.Printf("%s Running benchmarks...%s\n", "\033[36m", "\033[0m")
+		logging.Step(" Running benchmarks...")
 	}
 
 	// Find the benchmark executable
@@ -109,15 +119,25 @@ func RunBenchmarks(verbose bool, vcpkgClient *vcpkg.Client) error {
 		return fmt.Errorf("benchmark executable not found. Tried: %v", possiblePaths)
 	}
 
-	benchCmd := exec.Command(benchPath)
+	var benchArgs, benchEnv []string
+	if outFile != "" {
+		var err error
+		benchArgs, benchEnv, err = BenchOutputArgs(DetectBenchFramework("."), outFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	benchCmd := exec.Command(benchPath, benchArgs...)
+	benchCmd.Env = append(os.Environ(), benchEnv...)
 	benchCmd.Stdout = os.Stdout
 	benchCmd.Stderr = os.Stderr
 
-	fmt.Println() // Add blank line before benchmark output
+	printProgress("\n") // Add blank line before benchmark output
 	if err := benchCmd.Run(); err != nil {
 		return fmt.Errorf("benchmarks failed: %w", err)
 	}
 
-	fmt.Printf("\n%s✓ Benchmarks completed!%s\n", "\033[32m", "\033[0m")
+	logging.Success("\n✓ Benchmarks completed!")
 	return nil
 }
@@ -0,0 +1,262 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Benchmark framework identifiers, matching the values used by `cpx new`
+const (
+	BenchFrameworkGoogle    = "google-benchmark"
+	BenchFrameworkNanobench = "nanobench"
+	BenchFrameworkCatch2    = "catch2-benchmark"
+)
+
+// BenchResultsDir is where `cpx bench --save` stores named results
+var BenchResultsDir = filepath.Join(".cache", "bench")
+
+// BenchResult is a single benchmark measurement normalized to ns per operation
+type BenchResult struct {
+	Name    string  `json:"name"`
+	NsPerOp float64 `json:"ns_per_op"`
+}
+
+// BenchRun is a set of results saved under a name
+type BenchRun struct {
+	Name      string        `json:"name"`
+	Framework string        `json:"framework"`
+	Created   time.Time     `json:"created"`
+	Results   []BenchResult `json:"results"`
+}
+
+// BenchDelta compares one benchmark between a baseline and the current run
+type BenchDelta struct {
+	Name       string
+	Baseline   float64 // ns/op, 0 if new
+	Current    float64 // ns/op, 0 if removed
+	DeltaPct   float64
+	Regression bool
+}
+
+// DetectBenchFramework guesses the benchmark framework from the bench build
+// files and sources. Returns "" if unknown.
+func DetectBenchFramework(dir string) string {
+	candidates := []string{
+		filepath.Join(dir, "bench", "CMakeLists.txt"),
+		filepath.Join(dir, "bench", "BUILD.bazel"),
+		filepath.Join(dir, "bench", "meson.build"),
+	}
+	sources, _ := filepath.Glob(filepath.Join(dir, "bench", "*.cpp"))
+	candidates = append(candidates, sources...)
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		content := strings.ToLower(string(data))
+		switch {
+		case strings.Contains(content, "nanobench"):
+			return BenchFrameworkNanobench
+		case strings.Contains(content, "catch2"):
+			return BenchFrameworkCatch2
+		case strings.Contains(content, "benchmark"):
+			return BenchFrameworkGoogle
+		}
+	}
+	return ""
+}
+
+// BenchOutputArgs returns the arguments and environment that make a benchmark
+// binary write JSON results to outFile (an absolute path)
+func BenchOutputArgs(framework, outFile string) (args []string, env []string, err error) {
+	switch framework {
+	case BenchFrameworkGoogle:
+		return []string{"--benchmark_out=" + outFile, "--benchmark_out_format=json"}, nil, nil
+	case BenchFrameworkNanobench:
+		// The generated nanobench main renders JSON to $CPX_BENCH_OUT
+		return nil, []string{"CPX_BENCH_OUT=" + outFile}, nil
+	default:
+		return nil, nil, fmt.Errorf("saving and comparing results is supported for Google Benchmark and nanobench only")
+	}
+}
+
+// ParseBenchmarkJSON parses Google Benchmark or nanobench JSON output
+func ParseBenchmarkJSON(data []byte) ([]BenchResult, error) {
+	var raw struct {
+		// Google Benchmark
+		Benchmarks []struct {
+			Name     string  `json:"name"`
+			RunType  string  `json:"run_type"`
+			RealTime float64 `json:"real_time"`
+			TimeUnit string  `json:"time_unit"`
+		} `json:"benchmarks"`
+		// nanobench
+		Results []struct {
+			Name    string  `json:"name"`
+			Elapsed float64 `json:"median(elapsed)"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark JSON: %w", err)
+	}
+
+	var results []BenchResult
+	switch {
+	case len(raw.Benchmarks) > 0:
+		// Average repetitions and skip aggregate rows (mean/median/stddev)
+		sums := make(map[string]float64)
+		counts := make(map[string]int)
+		var order []string
+		for _, b := range raw.Benchmarks {
+			if b.RunType == "aggregate" {
+				continue
+			}
+			if counts[b.Name] == 0 {
+				order = append(order, b.Name)
+			}
+			sums[b.Name] += b.RealTime * timeUnitNs(b.TimeUnit)
+			counts[b.Name]++
+		}
+		for _, name := range order {
+			results = append(results, BenchResult{Name: name, NsPerOp: sums[name] / float64(counts[name])})
+		}
+	case len(raw.Results) > 0:
+		for _, r := range raw.Results {
+			results = append(results, BenchResult{Name: r.Name, NsPerOp: r.Elapsed * 1e9})
+		}
+	default:
+		return nil, fmt.Errorf("no benchmark results found")
+	}
+	return results, nil
+}
+
+func timeUnitNs(unit string) float64 {
+	switch unit {
+	case "us":
+		return 1e3
+	case "ms":
+		return 1e6
+	case "s":
+		return 1e9
+	default:
+		return 1
+	}
+}
+
+// benchRunPath returns the file a named run is stored in
+func benchRunPath(name string) string {
+	return filepath.Join(BenchResultsDir, name+".json")
+}
+
+// SaveBenchRun stores run under BenchResultsDir/<name>.json
+func SaveBenchRun(run *BenchRun) error {
+	if err := os.MkdirAll(BenchResultsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", BenchResultsDir, err)
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode benchmark results: %w", err)
+	}
+	if err := os.WriteFile(benchRunPath(run.Name), data, 0644); err != nil {
+		return fmt.Errorf("failed to save benchmark results: %w", err)
+	}
+	return nil
+}
+
+// LoadBenchRun reads the run saved under name
+func LoadBenchRun(name string) (*BenchRun, error) {
+	data, err := os.ReadFile(benchRunPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no saved benchmark results named %q\n  hint: run 'cpx bench --save %s' first", name, name)
+		}
+		return nil, fmt.Errorf("failed to read benchmark results: %w", err)
+	}
+	var run BenchRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", benchRunPath(name), err)
+	}
+	return &run, nil
+}
+
+// CompareBenchRuns returns one delta per benchmark present in either run.
+// A benchmark regresses when it is more than thresholdPct percent slower.
+func CompareBenchRuns(baseline, current []BenchResult, thresholdPct float64) []BenchDelta {
+	base := make(map[string]float64)
+	for _, r := range baseline {
+		base[r.Name] = r.NsPerOp
+	}
+
+	var deltas []BenchDelta
+	seen := make(map[string]bool)
+	for _, r := range current {
+		seen[r.Name] = true
+		d := BenchDelta{Name: r.Name, Baseline: base[r.Name], Current: r.NsPerOp}
+		if d.Baseline > 0 {
+			d.DeltaPct = (d.Current - d.Baseline) / d.Baseline * 100
+			d.Regression = d.DeltaPct > thresholdPct
+		}
+		deltas = append(deltas, d)
+	}
+	for _, r := range baseline {
+		if !seen[r.Name] {
+			deltas = append(deltas, BenchDelta{Name: r.Name, Baseline: r.NsPerOp})
+		}
+	}
+
+	sort.SliceStable(deltas, func(i, j int) bool { return deltas[i].Name < deltas[j].Name })
+	return deltas
+}
+
+// PrintBenchComparison prints the regression table and returns the number of regressions
+func PrintBenchComparison(baselineName string, deltas []BenchDelta, thresholdPct float64) int {
+	nameWidth := len("Benchmark")
+	for _, d := range deltas {
+		if len(d.Name) > nameWidth {
+			nameWidth = len(d.Name)
+		}
+	}
+
+	fmt.Printf("\n%s▸ Comparison with '%s'%s %s[threshold: +%.1f%%]%s\n", colorCyan, baselineName, colorReset, colorGray, thresholdPct, colorReset)
+	fmt.Printf("  %-*s %14s %14s %9s\n", nameWidth, "Benchmark", "Baseline", "Current", "Delta")
+
+	regressions := 0
+	for _, d := range deltas {
+		status := ""
+		delta := fmt.Sprintf("%+8.1f%%", d.DeltaPct)
+		switch {
+		case d.Baseline == 0:
+			delta = "      new"
+		case d.Current == 0:
+			delta = "  removed"
+		case d.Regression:
+			regressions++
+			status = "\033[31m✗ regression\033[0m"
+		case d.DeltaPct < -thresholdPct:
+			status = colorGreen + "✓ faster" + colorReset
+		}
+		fmt.Printf("  %-*s %14s %14s %s %s\n", nameWidth, d.Name, formatNs(d.Baseline), formatNs(d.Current), delta, status)
+	}
+	return regressions
+}
+
+func formatNs(ns float64) string {
+	switch {
+	case ns == 0:
+		return "-"
+	case ns >= 1e9:
+		return fmt.Sprintf("%.2f s", ns/1e9)
+	case ns >= 1e6:
+		return fmt.Sprintf("%.2f ms", ns/1e6)
+	case ns >= 1e3:
+		return fmt.Sprintf("%.2f us", ns/1e3)
+	default:
+		return fmt.Sprintf("%.2f ns", ns)
+	}
+}
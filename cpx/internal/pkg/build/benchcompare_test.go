@@ -0,0 +1,179 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectBenchFramework(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "Google Benchmark via CMake",
+			file:     "CMakeLists.txt",
+			content:  "target_link_libraries(app_bench benchmark::benchmark_main)\n",
+			expected: BenchFrameworkGoogle,
+		},
+		{
+			name:     "nanobench via Bazel",
+			file:     "BUILD.bazel",
+			content:  `deps = ["@nanobench//:nanobench"]`,
+			expected: BenchFrameworkNanobench,
+		},
+		{
+			name:     "Catch2 benchmark source",
+			file:     "bench_main.cpp",
+			content:  "#include <catch2/benchmark/catch_benchmark.hpp>\n",
+			expected: BenchFrameworkCatch2,
+		},
+		{
+			name:     "Unknown",
+			file:     "CMakeLists.txt",
+			content:  "add_executable(app_bench main.cpp)\n",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, "bench"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, "bench", tt.file), []byte(tt.content), 0644))
+			assert.Equal(t, tt.expected, DetectBenchFramework(dir))
+		})
+	}
+}
+
+func TestBenchOutputArgs(t *testing.T) {
+	args, env, err := BenchOutputArgs(BenchFrameworkGoogle, "/tmp/out.json")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"--benchmark_out=/tmp/out.json", "--benchmark_out_format=json"}, args)
+	assert.Empty(t, env)
+
+	args, env, err = BenchOutputArgs(BenchFrameworkNanobench, "/tmp/out.json")
+	require.NoError(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, []string{"CPX_BENCH_OUT=/tmp/out.json"}, env)
+
+	_, _, err = BenchOutputArgs(BenchFrameworkCatch2, "/tmp/out.json")
+	assert.Error(t, err)
+}
+
+func TestParseBenchmarkJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected []BenchResult
+		wantErr  bool
+	}{
+		{
+			name: "Google Benchmark with repetitions and aggregates",
+			data: `{"benchmarks": [
+				{"name": "BM_Sort", "run_type": "iteration", "real_time": 10, "time_unit": "us"},
+				{"name": "BM_Sort", "run_type": "iteration", "real_time": 20, "time_unit": "us"},
+				{"name": "BM_Sort_mean", "run_type": "aggregate", "real_time": 15, "time_unit": "us"},
+				{"name": "BM_Copy", "run_type": "iteration", "real_time": 250, "time_unit": "ns"}
+			]}`,
+			expected: []BenchResult{
+				{Name: "BM_Sort", NsPerOp: 15000},
+				{Name: "BM_Copy", NsPerOp: 250},
+			},
+		},
+		{
+			name: "nanobench",
+			data: `{"results": [{"name": "sort", "median(elapsed)": 0.000002}]}`,
+			expected: []BenchResult{
+				{Name: "sort", NsPerOp: 2000},
+			},
+		},
+		{
+			name:    "No results",
+			data:    `{"context": {}}`,
+			wantErr: true,
+		},
+		{
+			name:    "Invalid JSON",
+			data:    `{`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := ParseBenchmarkJSON([]byte(tt.data))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, results, len(tt.expected))
+			for i := range tt.expected {
+				assert.Equal(t, tt.expected[i].Name, results[i].Name)
+				assert.InDelta(t, tt.expected[i].NsPerOp, results[i].NsPerOp, 1e-6)
+			}
+		})
+	}
+}
+
+func TestCompareBenchRuns(t *testing.T) {
+	baseline := []BenchResult{
+		{Name: "fast", NsPerOp: 100},
+		{Name: "slow", NsPerOp: 100},
+		{Name: "gone", NsPerOp: 50},
+	}
+	current := []BenchResult{
+		{Name: "fast", NsPerOp: 80},
+		{Name: "slow", NsPerOp: 110},
+		{Name: "added", NsPerOp: 10},
+	}
+
+	deltas := CompareBenchRuns(baseline, current, 5)
+	require.Len(t, deltas, 4)
+
+	byName := make(map[string]BenchDelta)
+	for _, d := range deltas {
+		byName[d.Name] = d
+	}
+	assert.InDelta(t, -20, byName["fast"].DeltaPct, 1e-9)
+	assert.False(t, byName["fast"].Regression)
+	assert.InDelta(t, 10, byName["slow"].DeltaPct, 1e-9)
+	assert.True(t, byName["slow"].Regression)
+	assert.Zero(t, byName["added"].Baseline)
+	assert.Zero(t, byName["gone"].Current)
+
+	// A looser threshold tolerates the slowdown
+	for _, d := range CompareBenchRuns(baseline, current, 15) {
+		assert.False(t, d.Regression, d.Name)
+	}
+
+	assert.Equal(t, 1, PrintBenchComparison("main", deltas, 5))
+}
+
+func TestSaveLoadBenchRun(t *testing.T) {
+	oldDir := BenchResultsDir
+	defer func() { BenchResultsDir = oldDir }()
+	BenchResultsDir = filepath.Join(t.TempDir(), "bench")
+
+	_, err := LoadBenchRun("main")
+	assert.ErrorContains(t, err, "cpx bench --save main")
+
+	run := &BenchRun{
+		Name:      "main",
+		Framework: BenchFrameworkGoogle,
+		Results:   []BenchResult{{Name: "BM_Sort", NsPerOp: 42}},
+	}
+	require.NoError(t, SaveBenchRun(run))
+
+	loaded, err := LoadBenchRun("main")
+	require.NoError(t, err)
+	assert.Equal(t, run.Framework, loaded.Framework)
+	assert.Equal(t, run.Results, loaded.Results)
+}
@@ -1,125 +1,69 @@
 package build
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
-	"os/signal"
-	"regexp"
 	"strings"
-	"syscall"
+	"sync"
 
-	"github.com/schollz/progressbar/v3"
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
-var progressRe = regexp.MustCompile(`^\[\s*\d+%]`)
-
-// runCMakeBuild runs "cmake --build" with optional verbose output.
-// If verbose is false, it streams only progress lines like "[ 93%]" and errors.
+// runCMakeBuild runs "cmake --build". Unless verbose, it shows the build
+// progress and prints the rest of the output only if the build fails.
 func runCMakeBuild(buildArgs []string, verbose bool, currentStep, totalSteps int) error {
 	cmd := exec.Command("cmake", buildArgs...)
+	events.Phase("build")
+	return RunBuildCommand(cmd, verbose, fmt.Sprintf("[%d/%d] Compiling", currentStep, totalSteps))
+}
 
-	if verbose {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		return cmd.Run()
-	}
-
-	// Create a progress bar for the build percentage
-	bar := progressbar.NewOptions(100,
-		progressbar.OptionSetWriter(os.Stderr),
-		progressbar.OptionEnableColorCodes(true),
-		progressbar.OptionSetWidth(20),
-		progressbar.OptionSetDescription(fmt.Sprintf("[cyan][%d/%d][reset] Compiling", currentStep, totalSteps)),
-		progressbar.OptionSetTheme(progressbar.Theme{
-			Saucer:        "[cyan]█[reset]",
-			SaucerHead:    "[cyan]▸[reset]",
-			SaucerPadding: "░",
-			BarStart:      "[",
-			BarEnd:        "]",
-		}),
-		progressbar.OptionClearOnFinish(),
-	)
-
-	// Ensure cursor is restored on interrupt
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
-	defer signal.Stop(sigCh)
-	go func() {
-		<-sigCh
-		bar.Clear()
-		fmt.Print("\033[?25h") // Show cursor
-		os.Exit(1)
-	}()
-
-	pr, pw := io.Pipe()
-	cmd.Stdout = pw
-	cmd.Stderr = pw
-
-	if err := cmd.Start(); err != nil {
-		return err
-	}
-
-	var nonProgress bytes.Buffer
-	lastPercent := -1
-
-	waitCh := make(chan error, 1)
-	go func() {
-		waitCh <- cmd.Wait()
-		pw.Close()
-	}()
+// LineWriter passes each complete line written to it to BuildLine. It is
+// safe to share between the stdout and stderr of a command.
+type LineWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
 
-	sc := bufio.NewScanner(pr)
-	sc.Buffer(make([]byte, 0, 64*1024), 512*1024)
-	for sc.Scan() {
-		line := sc.Text()
-		if match := progressRe.FindString(line); match != "" {
-			pct := extractPercent(match)
-			if pct >= 0 && pct != lastPercent {
-				bar.Set(pct)
-				lastPercent = pct
-			}
-			continue
+func (w *LineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf.Write(p)
+	for {
+		line, err := w.buf.ReadString('\n')
+		if err != nil {
+			// Keep the partial line for the next write
+			w.buf.WriteString(line)
+			break
 		}
-		nonProgress.WriteString(line)
-		nonProgress.WriteByte('\n')
+		BuildLine(strings.TrimRight(line, "\r\n"))
 	}
+	return len(p), nil
+}
 
-	err := <-waitCh
-
-	// Complete the progress bar
-	bar.Set(100)
-	bar.Clear()
-
-	if err != nil {
-		if nonProgress.Len() > 0 {
-			fmt.Fprintln(os.Stderr, nonProgress.String())
-		}
-		return err
+// Flush passes a final line without a newline to BuildLine
+func (w *LineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.buf.Len() > 0 {
+		BuildLine(w.buf.String())
+		w.buf.Reset()
 	}
-
-	return nil
 }
 
-func extractPercent(line string) int {
-	// line format: [ 93%] ...
-	start := strings.Index(line, "[")
-	end := strings.Index(line, "%")
-	if start == -1 || end == -1 || end <= start {
-		return -1
-	}
-	var pct int
-	if _, err := fmt.Sscanf(line[start+1:end], "%d", &pct); err != nil {
-		return -1
+// printProgress prints an in-place step line such as "[1/2] Configuring...",
+// which --quiet leaves out
+func printProgress(format string, args ...any) {
+	if logging.Enabled(logging.LevelNormal) {
+		fmt.Printf(format, args...)
 	}
-	return pct
 }
 
 // runCMakeConfigure runs cmake configure quietly unless verbose is true.
 func runCMakeConfigure(cmd *exec.Cmd, verbose bool) error {
+	events.Phase("configure")
 	if verbose {
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -0,0 +1,89 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/logging"
+)
+
+// BuildTimesFile records the duration of the last full build of each variant,
+// with and without unity builds, so that the two can be compared
+var BuildTimesFile = filepath.Join(".cache", "build-times.json")
+
+// buildTimes maps a build variant (e.g. "debug", "meson") to the seconds the
+// last full "unity" and "default" builds took
+type buildTimes map[string]map[string]float64
+
+func unityKey(unity bool) string {
+	if unity {
+		return "unity"
+	}
+	return "default"
+}
+
+// recordBuildTime stores elapsed as the last full build of variant in path
+// and returns the last full build with unity builds toggled, if recorded
+func recordBuildTime(path, variant string, unity bool, elapsed time.Duration) (time.Duration, bool, error) {
+	times := make(buildTimes)
+	if data, err := os.ReadFile(path); err == nil {
+		// A corrupt file is simply overwritten
+		_ = json.Unmarshal(data, &times)
+	}
+	if times[variant] == nil {
+		times[variant] = make(map[string]float64)
+	}
+	times[variant][unityKey(unity)] = elapsed.Seconds()
+
+	data, err := json.MarshalIndent(times, "", "  ")
+	if err != nil {
+		return 0, false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, false, err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return 0, false, err
+	}
+
+	other, ok := times[variant][unityKey(!unity)]
+	return time.Duration(other * float64(time.Second)), ok, nil
+}
+
+// formatUnityComparison describes how much faster or slower a unity build
+// was than a regular one
+func formatUnityComparison(unityTime, defaultTime time.Duration) string {
+	if defaultTime <= 0 {
+		return ""
+	}
+	pct := (1 - unityTime.Seconds()/defaultTime.Seconds()) * 100
+	direction := "faster"
+	if pct < 0 {
+		pct = -pct
+		direction = "slower"
+	}
+	return fmt.Sprintf("unity build %s vs %s without (%.0f%% %s)",
+		unityTime.Round(100*time.Millisecond), defaultTime.Round(100*time.Millisecond), pct, direction)
+}
+
+// ReportFullBuild records the duration of a full (from scratch) build of
+// variant and prints how it compares to the last full build with unity
+// builds toggled
+func ReportFullBuild(variant string, unity bool, elapsed time.Duration) {
+	other, ok, err := recordBuildTime(BuildTimesFile, variant, unity, elapsed)
+	if err != nil || !ok {
+		if unity {
+			logging.Info("  %sRun a full build without --unity to compare compile times%s", colorGray, colorReset)
+		}
+		return
+	}
+
+	unityTime, defaultTime := elapsed, other
+	if !unity {
+		unityTime, defaultTime = other, elapsed
+	}
+	logging.Info("  %sCompile time: %s%s", colorGray, formatUnityComparison(unityTime, defaultTime), colorReset)
+}
@@ -0,0 +1,34 @@
+package build
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordBuildTime(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "build-times.json")
+
+	_, ok, err := recordBuildTime(path, "debug", false, 20*time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	other, ok, err := recordBuildTime(path, "debug", true, 8*time.Second)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, other)
+
+	// Variants are recorded separately
+	_, ok, err = recordBuildTime(path, "release", true, 5*time.Second)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestFormatUnityComparison(t *testing.T) {
+	assert.Equal(t, "unity build 8s vs 20s without (60% faster)", formatUnityComparison(8*time.Second, 20*time.Second))
+	assert.Equal(t, "unity build 12s vs 10s without (20% slower)", formatUnityComparison(12*time.Second, 10*time.Second))
+	assert.Empty(t, formatUnityComparison(time.Second, 0))
+}
@@ -1,15 +1,20 @@
 package build
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
+	"github.com/ozacod/cpx/pkg/config"
 )
 
 // GetProjectNameFromCMakeLists extracts project name from CMakeLists.txt in current directory
@@ -86,8 +91,108 @@ func GetSanitizerFlags(sanitizer string) (string, string) {
 	return cxxFlags, linkerFlags
 }
 
-// BuildProject builds the project using CMake
-func BuildProject(release bool, jobs int, target string, clean bool, optLevel string, verbose bool, sanitizer string, vcpkgClient *vcpkg.Client) error {
+// ProjectOptions returns the build options of cpx.yaml, or the defaults if
+// the project has none
+func ProjectOptions() (config.ProjectBuild, error) {
+	cfg, err := config.LoadProject(config.ProjectFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config.ProjectBuild{}, nil
+	}
+	if err != nil {
+		return config.ProjectBuild{}, err
+	}
+	return cfg.Build, nil
+}
+
+// ProjectConfigureArgs returns the CMake cache arguments for the build
+// options in cpx.yaml. unity enables a unity build regardless of cpx.yaml
+// (cpx build --unity).
+func ProjectConfigureArgs(unity bool) ([]string, error) {
+	opts, err := ProjectOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{"-DCMAKE_UNITY_BUILD=" + onOff(unity || opts.Unity)}
+	// Only projects generated with precompiled headers define CPX_PCH
+	if data, err := os.ReadFile("CMakeLists.txt"); err == nil && strings.Contains(string(data), "CPX_PCH") {
+		args = append(args, "-DCPX_PCH="+onOff(opts.PCH))
+	}
+	return args, nil
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "ON"
+	}
+	return "OFF"
+}
+
+// cmakeCacheValue returns the value of key in the CMakeCache.txt of buildDir
+func cmakeCacheValue(buildDir, key string) (string, bool) {
+	data, err := os.ReadFile(filepath.Join(buildDir, "CMakeCache.txt"))
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		name, rest, ok := strings.Cut(line, ":")
+		if !ok || name != key {
+			continue
+		}
+		if _, value, ok := strings.Cut(rest, "="); ok {
+			return strings.TrimSpace(value), true
+		}
+	}
+	return "", false
+}
+
+// cmakeCacheOutdated reports whether any -DKEY=VALUE argument in args differs
+// from the CMakeCache.txt of buildDir
+func cmakeCacheOutdated(buildDir string, args []string) bool {
+	for _, arg := range args {
+		key, want, ok := strings.Cut(strings.TrimPrefix(arg, "-D"), "=")
+		if !ok {
+			continue
+		}
+		if got, found := cmakeCacheValue(buildDir, key); !found || !strings.EqualFold(got, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildVariant names the build of the given optimization, sanitizer and
+// compiler (e.g. "debug", "O3", "release-asan", "debug-clang-17")
+func buildVariant(release bool, optLevel, sanitizer, compiler string) string {
+	variant := "debug"
+	if optLevel != "" {
+		variant = "O" + optLevel
+	} else if release {
+		variant = "release"
+	}
+	if sanitizer != "" {
+		variant += "-" + sanitizer
+	}
+	if compiler != "" {
+		variant += "-" + compiler
+	}
+	return variant
+}
+
+// CMakeBuildDir returns the CMake build directory BuildProject uses
+func CMakeBuildDir(release bool, optLevel, sanitizer, compiler string) string {
+	return filepath.Join(".cache", "native", buildVariant(release, optLevel, sanitizer, compiler))
+}
+
+// OutputDir returns the directory BuildProject copies the executables and
+// libraries of a build to
+func OutputDir(release bool, optLevel, sanitizer, compiler string) string {
+	return filepath.Join(".bin", "native", buildVariant(release, optLevel, sanitizer, compiler))
+}
+
+// BuildProject builds the project using CMake. The zero compiler builds
+// with the compiler CMake finds, the zero cross toolchain for the host.
+func BuildProject(release bool, jobs int, target string, clean bool, optLevel string, verbose bool, sanitizer string, unity bool, compiler Compiler, cross CrossToolchain, vcpkgClient *vcpkg.Client) error {
 	// Set VCPKG_ROOT from cpx config if not already set
 	if err := vcpkgClient.SetupEnv(); err != nil {
 		return err
@@ -100,26 +205,22 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 	}
 
 	// Determine build output directory based on optimization/release/sanitizer
-	outDirName := "debug"
-	if optLevel != "" {
-		outDirName = "O" + optLevel
-	} else if release {
-		outDirName = "release"
-	}
-	// Append sanitizer suffix
-	if sanitizer != "" {
-		outDirName += "-" + sanitizer
-	}
+	outDirName := buildVariant(release, optLevel, sanitizer, compiler.Name)
 
 	// Use hidden cache directory for build artifacts
 	// .cache/native/<variant>
-	cacheBuildDir := filepath.Join(".cache", "native", outDirName)
+	cacheBuildDir := CMakeBuildDir(release, optLevel, sanitizer, compiler.Name)
 	// Final executables go to .bin/native/<variant>
-	finalBuildDir := filepath.Join(".bin", "native", outDirName)
+	finalBuildDir := OutputDir(release, optLevel, sanitizer, compiler.Name)
+	if cross.Name != "" {
+		// Cross builds go to .cache/<name>/<variant> and .bin/<name>/<variant>
+		cacheBuildDir = cross.BuildDir(release, optLevel)
+		finalBuildDir = cross.OutputDir(release, optLevel)
+	}
 
 	if clean {
 		if verbose {
-			fmt.Printf("%s  Cleaning build directory...%s\n", colorCyan, colorReset)
+			logging.Step("  Cleaning build directory...")
 		}
 		os.RemoveAll(cacheBuildDir)
 		os.RemoveAll(finalBuildDir)
@@ -149,15 +250,41 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 	if sanitizer != "" {
 		optLabel += "+" + sanitizer
 	}
+	if compiler.Name != "" {
+		optLabel += ", " + compiler.Name
+	}
+	if cross.Name != "" {
+		optLabel += ", " + cross.Name
+	}
 
-	fmt.Printf("\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s\n",
+	logging.Info("\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s",
 		colorCyan, colorReset, projectName, colorGray, buildType, colorReset,
 		colorGray, optLabel, colorReset)
 
-	// Configure CMake if needed
+	opts, err := ProjectOptions()
+	if err != nil {
+		return err
+	}
+	unity = unity || opts.Unity
+	projectArgs, err := ProjectConfigureArgs(unity)
+	if err != nil {
+		return err
+	}
+
+	// Configure CMake if needed, or reconfigure if a cpx.yaml option changed.
+	// A fresh configure or toggling unity builds recompiles everything.
+	if err := resetStaleCMakeCache(cacheBuildDir); err != nil {
+		return err
+	}
 	needsConfigure := false
+	fullBuild := false
 	if _, err := os.Stat(filepath.Join(cacheBuildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
+		fullBuild = true
+	} else if cmakeCacheOutdated(cacheBuildDir, projectArgs) {
+		needsConfigure = true
+		cached, _ := cmakeCacheValue(cacheBuildDir, "CMAKE_UNITY_BUILD")
+		fullBuild = strings.EqualFold(cached, "ON") != unity
 	}
 
 	// Determine total steps
@@ -170,9 +297,9 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 	if needsConfigure {
 		currentStep++
 		if verbose {
-			fmt.Printf("%s  • Configuring CMake%s\n", colorCyan, colorReset)
+			logging.Step("  • Configuring CMake")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
 		}
 
 		// Determine absolute path for shared vcpkg_installed directory
@@ -181,11 +308,20 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
 
 		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use "default" preset (VCPKG_ROOT is now set from config)
+		if _, err := os.Stat(PresetsFile); err == nil {
+			// Use the project's preset (VCPKG_ROOT is now set from config)
+			preset, err := ActivePreset()
+			if err != nil {
+				printProgress("\n")
+				return err
+			}
 			// Pass -B explicitly to override preset binaryDir if needed, or ensure it goes to our cache
 			// Also pass VCPKG_INSTALLED_DIR to force shared vcpkg location
-			cmdArgs := []string{"--preset=default", "-B", cacheBuildDir, vcpkgInstallArg}
+			cmdArgs := []string{"--preset=" + preset, "-DCPX_PRESET=" + preset, "-B", cacheBuildDir, vcpkgInstallArg}
+			cmdArgs = append(cmdArgs, projectArgs...)
+			cmdArgs = append(cmdArgs, compiler.CMakeArgs()...)
+			// The preset's vcpkg toolchain chainloads the cross toolchain
+			cmdArgs = append(cmdArgs, cross.CMakeArgs(true)...)
 			if cxxFlags != "" {
 				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
 			}
@@ -195,12 +331,15 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 			cmd := exec.Command("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
-				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
+				printProgress("\n")
+				return fmt.Errorf("cmake configure failed (preset '%s'): %w", preset, err)
 			}
 		} else {
 			// Fallback to traditional cmake configure
 			cmdArgs := []string{"-B", cacheBuildDir, "-DCMAKE_BUILD_TYPE=" + buildType, vcpkgInstallArg}
+			cmdArgs = append(cmdArgs, projectArgs...)
+			cmdArgs = append(cmdArgs, compiler.CMakeArgs()...)
+			cmdArgs = append(cmdArgs, cross.CMakeArgs(false)...)
 			if cxxFlags != "" {
 				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
 			}
@@ -210,16 +349,21 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 			cmd := exec.Command("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
+				printProgress("\n")
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
 		if !verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
 		}
 	}
 
+	// Point compile_commands.json in the project root at this build for clangd
+	if err := LinkCompileDatabase(cacheBuildDir); err != nil {
+		logging.Warn("%v", err)
+	}
+
 	// Build specific target if provided
 	buildStart := time.Now()
 	// Build in .cache directory
@@ -263,7 +407,11 @@ func BuildProject(release bool, jobs int, target string, clean bool, optLevel st
 		}
 	}
 
-	fmt.Printf("%s  ✔ Build complete%s %s[%s]%s\n", colorGreen, colorReset, colorGray, time.Since(buildStart).Round(10*time.Millisecond), colorReset)
-	fmt.Printf("  Artifacts in: %s/\n\n", finalBuildDir)
+	elapsed := time.Since(buildStart)
+	logging.Success("  ✔ Build complete %s[%s]", colorGray, elapsed.Round(10*time.Millisecond))
+	if fullBuild && target == "" {
+		ReportFullBuild(outDirName, unity, elapsed)
+	}
+	logging.Info("  Artifacts in: %s/\n", finalBuildDir)
 	return nil
 }
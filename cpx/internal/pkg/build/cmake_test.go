@@ -1,9 +1,16 @@
 package build
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGetSanitizerFlags(t *testing.T) {
@@ -59,3 +66,103 @@ func TestGetSanitizerFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestProjectConfigureArgs(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	args, err := ProjectConfigureArgs(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-DCMAKE_UNITY_BUILD=OFF"}, args)
+
+	args, err = ProjectConfigureArgs(true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-DCMAKE_UNITY_BUILD=ON"}, args)
+
+	// CPX_PCH is only passed to projects that define it
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("build:\n  pch: true\n  unity: true\n"), 0644))
+	args, err = ProjectConfigureArgs(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-DCMAKE_UNITY_BUILD=ON"}, args)
+
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte(`option(CPX_PCH "Precompile common headers" ON)`), 0644))
+	args, err = ProjectConfigureArgs(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-DCMAKE_UNITY_BUILD=ON", "-DCPX_PCH=ON"}, args)
+
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("build:\n  pch: false\n"), 0644))
+	args, err = ProjectConfigureArgs(false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-DCMAKE_UNITY_BUILD=OFF", "-DCPX_PCH=OFF"}, args)
+}
+
+func TestCMakeCacheOutdated(t *testing.T) {
+	buildDir := t.TempDir()
+	cache := "# This is the CMakeCache file.\nCPX_PCH:BOOL=ON\nCMAKE_UNITY_BUILD:UNINITIALIZED=OFF\n"
+	require.NoError(t, os.WriteFile(filepath.Join(buildDir, "CMakeCache.txt"), []byte(cache), 0644))
+
+	value, ok := cmakeCacheValue(buildDir, "CPX_PCH")
+	assert.True(t, ok)
+	assert.Equal(t, "ON", value)
+
+	assert.False(t, cmakeCacheOutdated(buildDir, []string{"-DCMAKE_UNITY_BUILD=OFF", "-DCPX_PCH=on"}))
+	assert.True(t, cmakeCacheOutdated(buildDir, []string{"-DCMAKE_UNITY_BUILD=ON"}))
+	assert.True(t, cmakeCacheOutdated(buildDir, []string{"-DOTHER=ON"}))
+}
+
+// captureStdout returns what fn prints to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	old := os.Stdout
+	defer func() { os.Stdout = old }()
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+
+	fn()
+	w.Close()
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestBuildProjectQuiet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake cmake is a shell script")
+	}
+	// A cmake that writes a cache on configure and succeeds on --build
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "cmake"), []byte(`#!/bin/sh
+if [ "$1" = "--build" ]; then echo "[100%] Built target app"; exit 0; fi
+while [ $# -gt 0 ]; do
+  if [ "$1" = "-B" ]; then mkdir -p "$2" && echo "CMAKE_UNITY_BUILD:BOOL=OFF" > "$2/CMakeCache.txt"; fi
+  shift
+done
+echo "-- Configuring done"
+`), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("VCPKG_ROOT", t.TempDir())
+	t.Setenv("VCPKG_FEATURE_FLAGS", "manifests")
+	t.Setenv("VCPKG_DISABLE_REGISTRY_UPDATE", "1")
+	client, err := vcpkg.NewClient()
+	require.NoError(t, err)
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("project(app)\n"), 0644))
+
+	build := func() error {
+		return BuildProject(false, 1, "", true, "", false, "", false, Compiler{}, CrossToolchain{}, client)
+	}
+
+	out := captureStdout(t, func() { require.NoError(t, build()) })
+	assert.Contains(t, out, "Build complete")
+	assert.Contains(t, out, "Configured")
+
+	logging.SetLevel(logging.LevelQuiet)
+	defer logging.SetLevel(logging.LevelNormal)
+	out = captureStdout(t, func() { require.NoError(t, build()) })
+	assert.Empty(t, out)
+}
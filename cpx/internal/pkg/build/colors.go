@@ -1,8 +1,10 @@
 package build
 
 const (
-	colorCyan  = "\033[36m"
-	colorGreen = "\033[32m"
-	colorGray  = "\033[90m"
-	colorReset = "\033[0m"
+	colorRed    = "\033[31m"
+	colorCyan   = "\033[36m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorGray   = "\033[90m"
+	colorReset  = "\033[0m"
 )
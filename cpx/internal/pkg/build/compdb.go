@@ -0,0 +1,194 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// CompileCommand is a single entry of a JSON compilation database
+type CompileCommand struct {
+	Directory string   `json:"directory"`
+	File      string   `json:"file"`
+	Command   string   `json:"command,omitempty"`
+	Arguments []string `json:"arguments,omitempty"`
+	Output    string   `json:"output,omitempty"`
+}
+
+// CompileDatabase is a compile_commands.json found in one build directory
+type CompileDatabase struct {
+	Config string // build directory name, e.g. debug, release, O2-asan
+	Path   string
+}
+
+// PreferNewest selects, for each file, the entry from the most recently
+// generated compile_commands.json instead of a fixed config order
+const PreferNewest = "newest"
+
+// FindCompileDatabases returns the compile_commands.json files of all
+// CMake build directories under .cache/native
+func FindCompileDatabases() ([]CompileDatabase, error) {
+	return findCompileDatabases(filepath.Join(".cache", "native", "*", "compile_commands.json"))
+}
+
+// FindMesonCompileDatabases returns the compile_commands.json files of the
+// Meson build directories: builddir, and the builddir-<name> directories of
+// builds with --compiler or --toolchain
+func FindMesonCompileDatabases() ([]CompileDatabase, error) {
+	return findCompileDatabases(filepath.Join("builddir", "compile_commands.json"), filepath.Join("builddir-*", "compile_commands.json"))
+}
+
+// findCompileDatabases returns the files matching patterns, named after
+// their directory
+func findCompileDatabases(patterns ...string) ([]CompileDatabase, error) {
+	var dbs []CompileDatabase
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range matches {
+			dbs = append(dbs, CompileDatabase{
+				Config: filepath.Base(filepath.Dir(path)),
+				Path:   path,
+			})
+		}
+	}
+	return dbs, nil
+}
+
+// OrderCompileDatabases sorts dbs by the merge policy. prefer is either
+// PreferNewest or a comma-separated list of configs (e.g. "debug,release");
+// configs not listed follow in alphabetical order.
+func OrderCompileDatabases(dbs []CompileDatabase, prefer string) []CompileDatabase {
+	ordered := append([]CompileDatabase(nil), dbs...)
+
+	if prefer == PreferNewest {
+		modTime := func(db CompileDatabase) int64 {
+			info, err := os.Stat(db.Path)
+			if err != nil {
+				return 0
+			}
+			return info.ModTime().UnixNano()
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return modTime(ordered[i]) > modTime(ordered[j])
+		})
+		return ordered
+	}
+
+	rank := make(map[string]int)
+	for i, config := range strings.Split(prefer, ",") {
+		if config = strings.TrimSpace(config); config != "" {
+			if _, seen := rank[config]; !seen {
+				rank[config] = i
+			}
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ri, iok := rank[ordered[i].Config]
+		rj, jok := rank[ordered[j].Config]
+		switch {
+		case iok && jok:
+			return ri < rj
+		case iok != jok:
+			return iok
+		default:
+			return ordered[i].Config < ordered[j].Config
+		}
+	})
+	return ordered
+}
+
+// MergeCompileDatabases combines the databases into one with a single entry
+// per source file. Databases are expected in preference order (see
+// OrderCompileDatabases): the first entry seen for a file wins.
+func MergeCompileDatabases(dbs []CompileDatabase) ([]CompileCommand, error) {
+	var merged []CompileCommand
+	seen := make(map[string]bool)
+
+	for _, db := range dbs {
+		data, err := os.ReadFile(db.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", db.Path, err)
+		}
+
+		var entries []CompileCommand
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", db.Path, err)
+		}
+
+		for _, entry := range entries {
+			key := entry.File
+			if !filepath.IsAbs(key) {
+				key = filepath.Join(entry.Directory, key)
+			}
+			key = filepath.Clean(key)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, entry)
+		}
+	}
+
+	return merged, nil
+}
+
+// WriteCompileDatabase writes entries as a compile_commands.json file. A
+// symlink at path (see LinkCompileDatabase) is replaced rather than written
+// through, which would overwrite the database of a build directory.
+func WriteCompileDatabase(entries []CompileCommand, path string) error {
+	if entries == nil {
+		entries = []CompileCommand{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode compilation database: %w", err)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LinkCompileDatabase points compile_commands.json in the project root at
+// the one in buildDir so clangd and clang-tidy find it without flags. It
+// uses a symlink, falling back to a copy where symlinks are unavailable. A
+// regular compile_commands.json already in the root is left untouched.
+func LinkCompileDatabase(buildDir string) error {
+	const name = "compile_commands.json"
+	source := filepath.Join(buildDir, name)
+	if _, err := os.Stat(source); err != nil {
+		return nil // not generated (yet)
+	}
+
+	if info, err := os.Lstat(name); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		if err := os.Remove(name); err != nil {
+			return fmt.Errorf("failed to replace %s: %w", name, err)
+		}
+	}
+
+	if err := os.Symlink(source, name); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
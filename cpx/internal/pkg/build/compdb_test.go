@@ -0,0 +1,136 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderCompileDatabases(t *testing.T) {
+	dbs := []CompileDatabase{
+		{Config: "release"},
+		{Config: "O2-asan"},
+		{Config: "debug"},
+	}
+
+	tests := []struct {
+		name     string
+		prefer   string
+		expected []string
+	}{
+		{"Default debug first", "debug", []string{"debug", "O2-asan", "release"}},
+		{"Explicit order", "release,debug", []string{"release", "debug", "O2-asan"}},
+		{"Unknown config ignored", "tsan", []string{"O2-asan", "debug", "release"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ordered := OrderCompileDatabases(dbs, tt.prefer)
+			var configs []string
+			for _, db := range ordered {
+				configs = append(configs, db.Config)
+			}
+			assert.Equal(t, tt.expected, configs)
+		})
+	}
+}
+
+func TestMergeCompileDatabases(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	writeDB := func(config, content string) {
+		dir := filepath.Join(".cache", "native", config)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "compile_commands.json"), []byte(content), 0644))
+	}
+	writeDB("debug", `[
+  {"directory": "/p/.cache/native/debug", "file": "/p/src/main.cpp", "command": "c++ -O0 -c /p/src/main.cpp"},
+  {"directory": "/p/.cache/native/debug", "file": "../../../src/lib.cpp", "command": "c++ -O0 -c ../../../src/lib.cpp"}
+]`)
+	writeDB("release", `[
+  {"directory": "/p/.cache/native/release", "file": "/p/src/main.cpp", "command": "c++ -O2 -c /p/src/main.cpp"},
+  {"directory": "/p/.cache/native/release", "file": "/p/src/release_only.cpp", "arguments": ["c++", "-O2"]}
+]`)
+
+	dbs, err := FindCompileDatabases()
+	require.NoError(t, err)
+	require.Len(t, dbs, 2)
+
+	entries, err := MergeCompileDatabases(OrderCompileDatabases(dbs, "release"))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "c++ -O2 -c /p/src/main.cpp", entries[0].Command)
+	assert.Equal(t, "/p/src/release_only.cpp", entries[1].File)
+
+	entries, err = MergeCompileDatabases(OrderCompileDatabases(dbs, "debug"))
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "c++ -O0 -c /p/src/main.cpp", entries[0].Command)
+
+	require.NoError(t, WriteCompileDatabase(entries, "compile_commands.json"))
+	data, err := os.ReadFile("compile_commands.json")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"file": "../../../src/lib.cpp"`)
+}
+
+func TestFindMesonCompileDatabases(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for _, dir := range []string{"builddir", "builddir-clang-17", "build"} {
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "compile_commands.json"), []byte("[]"), 0644))
+	}
+
+	dbs, err := FindMesonCompileDatabases()
+	require.NoError(t, err)
+	assert.Equal(t, []CompileDatabase{
+		{Config: "builddir", Path: filepath.Join("builddir", "compile_commands.json")},
+		{Config: "builddir-clang-17", Path: filepath.Join("builddir-clang-17", "compile_commands.json")},
+	}, dbs)
+
+	// Writing the merged database replaces the link to a build directory's
+	require.NoError(t, LinkCompileDatabase("builddir"))
+	require.NoError(t, WriteCompileDatabase([]CompileCommand{{Directory: "/p", File: "main.cpp"}}, "compile_commands.json"))
+	info, err := os.Lstat("compile_commands.json")
+	require.NoError(t, err)
+	assert.True(t, info.Mode().IsRegular())
+	data, err := os.ReadFile(filepath.Join("builddir", "compile_commands.json"))
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+}
+
+func TestLinkCompileDatabase(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	// Nothing to link yet
+	require.NoError(t, LinkCompileDatabase("builddir"))
+	assert.NoFileExists(t, "compile_commands.json")
+
+	require.NoError(t, os.MkdirAll("builddir", 0755))
+	require.NoError(t, os.WriteFile(filepath.Join("builddir", "compile_commands.json"), []byte("[]\n"), 0644))
+	require.NoError(t, LinkCompileDatabase("builddir"))
+	data, err := os.ReadFile("compile_commands.json")
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(data))
+
+	// Re-linking replaces the link
+	require.NoError(t, LinkCompileDatabase("builddir"))
+
+	// A regular file is not overwritten
+	require.NoError(t, os.Remove("compile_commands.json"))
+	require.NoError(t, os.WriteFile("compile_commands.json", []byte("mine"), 0644))
+	require.NoError(t, LinkCompileDatabase("builddir"))
+	data, err = os.ReadFile("compile_commands.json")
+	require.NoError(t, err)
+	assert.Equal(t, "mine", string(data))
+}
@@ -0,0 +1,72 @@
+package build
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Compiler is the C and C++ compiler of a build, selected with cpx build
+// --compiler or build.compiler in cpx.yaml. The zero Compiler is the
+// default compiler of the build system.
+type Compiler struct {
+	// Name is the compiler as selected (e.g. "clang-17"); it names the build
+	// directories so that each compiler builds separately
+	Name string
+	CC   string
+	CXX  string
+}
+
+// compilerRe matches clang, gcc and cl, with an optional version
+var compilerRe = regexp.MustCompile(`^(clang|gcc|cl)(?:-(\d+(?:\.\d+)*))?$`)
+
+// ParseCompiler parses a compiler name: clang, gcc or cl (MSVC), with an
+// optional version for clang and gcc (clang-17, gcc-13)
+func ParseCompiler(name string) (Compiler, error) {
+	m := compilerRe.FindStringSubmatch(name)
+	if m == nil || (m[1] == "cl" && m[2] != "") {
+		return Compiler{}, fmt.Errorf("invalid compiler %q\n  hint: use clang, gcc or cl, optionally with a version: clang-17, gcc-13", name)
+	}
+	suffix := ""
+	if m[2] != "" {
+		suffix = "-" + m[2]
+	}
+	switch m[1] {
+	case "clang":
+		return Compiler{Name: name, CC: "clang" + suffix, CXX: "clang++" + suffix}, nil
+	case "gcc":
+		return Compiler{Name: name, CC: "gcc" + suffix, CXX: "g++" + suffix}, nil
+	default:
+		return Compiler{Name: name, CC: "cl", CXX: "cl"}, nil
+	}
+}
+
+// Family returns "clang", "gcc" or "cl", and the version of the compiler
+// name, if any
+func (c Compiler) Family() (family, version string) {
+	if m := compilerRe.FindStringSubmatch(c.Name); m != nil {
+		return m[1], m[2]
+	}
+	return "", ""
+}
+
+// CMakeArgs returns the CMake cache arguments selecting the compiler
+func (c Compiler) CMakeArgs() []string {
+	if c.Name == "" {
+		return nil
+	}
+	return []string{"-DCMAKE_C_COMPILER=" + c.CC, "-DCMAKE_CXX_COMPILER=" + c.CXX}
+}
+
+// BazelArgs returns the Bazel flags making the C++ toolchain autodetection
+// use the compiler
+func (c Compiler) BazelArgs() []string {
+	if c.Name == "" {
+		return nil
+	}
+	return []string{"--repo_env=CC=" + c.CC, "--repo_env=CXX=" + c.CXX}
+}
+
+// MesonNativeFile returns a Meson native file selecting the compiler
+func (c Compiler) MesonNativeFile() string {
+	return fmt.Sprintf("# Generated by cpx for --compiler %s\n[binaries]\nc = '%s'\ncpp = '%s'\n", c.Name, c.CC, c.CXX)
+}
@@ -0,0 +1,49 @@
+package build
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCompiler(t *testing.T) {
+	tests := []struct {
+		name string
+		want Compiler
+	}{
+		{"clang", Compiler{Name: "clang", CC: "clang", CXX: "clang++"}},
+		{"clang-17", Compiler{Name: "clang-17", CC: "clang-17", CXX: "clang++-17"}},
+		{"gcc-13", Compiler{Name: "gcc-13", CC: "gcc-13", CXX: "g++-13"}},
+		{"cl", Compiler{Name: "cl", CC: "cl", CXX: "cl"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseCompiler(tt.name)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, got)
+	}
+
+	for _, name := range []string{"", "msvc", "cl-19", "clang17", "gcc-"} {
+		_, err := ParseCompiler(name)
+		assert.Error(t, err, name)
+	}
+
+	family, version := Compiler{Name: "gcc-13.2"}.Family()
+	assert.Equal(t, "gcc", family)
+	assert.Equal(t, "13.2", version)
+}
+
+func TestCompilerArgs(t *testing.T) {
+	assert.Nil(t, Compiler{}.CMakeArgs())
+	assert.Nil(t, Compiler{}.BazelArgs())
+
+	c := Compiler{Name: "clang-17", CC: "/usr/bin/clang-17", CXX: "/usr/bin/clang++-17"}
+	assert.Equal(t, []string{"-DCMAKE_C_COMPILER=/usr/bin/clang-17", "-DCMAKE_CXX_COMPILER=/usr/bin/clang++-17"}, c.CMakeArgs())
+	assert.Equal(t, []string{"--repo_env=CC=/usr/bin/clang-17", "--repo_env=CXX=/usr/bin/clang++-17"}, c.BazelArgs())
+	assert.Contains(t, c.MesonNativeFile(), "[binaries]\nc = '/usr/bin/clang-17'\ncpp = '/usr/bin/clang++-17'\n")
+
+	// Each compiler builds in its own directories
+	assert.Equal(t, filepath.Join(".cache", "native", "debug-clang-17"), CMakeBuildDir(false, "", "", "clang-17"))
+	assert.Equal(t, filepath.Join(".bin", "native", "O3-asan-gcc-13"), OutputDir(false, "3", "asan", "gcc-13"))
+}
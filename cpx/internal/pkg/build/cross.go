@@ -0,0 +1,51 @@
+package build
+
+import (
+	"path/filepath"
+)
+
+// CrossToolchain is the toolchain of a cross-compilation with cpx build
+// --toolchain: a CMake toolchain file or a Meson cross file. The zero
+// CrossToolchain builds for the host.
+type CrossToolchain struct {
+	// Name names the build directories, e.g. the target triple
+	Name string
+	// File is the absolute path of the toolchain or cross file
+	File string
+	// VcpkgTriplet is the vcpkg triplet of the target, if vcpkg has one
+	VcpkgTriplet string
+	// TripletsDir holds VcpkgTriplet if it isn't one of vcpkg's triplets
+	TripletsDir string
+}
+
+// BuildDir returns the CMake build directory of a cross build,
+// .cache/<name>/<variant>
+func (c CrossToolchain) BuildDir(release bool, optLevel string) string {
+	return filepath.Join(".cache", c.Name, buildVariant(release, optLevel, "", ""))
+}
+
+// OutputDir returns the directory the executables and libraries of a cross
+// build are copied to, .bin/<name>/<variant>
+func (c CrossToolchain) OutputDir(release bool, optLevel string) string {
+	return filepath.Join(".bin", c.Name, buildVariant(release, optLevel, "", ""))
+}
+
+// CMakeArgs returns the CMake cache arguments selecting the toolchain file.
+// With the vcpkg toolchain (chainload), vcpkg loads the file and builds the
+// dependencies for the target's triplet.
+func (c CrossToolchain) CMakeArgs(chainload bool) []string {
+	if c.File == "" {
+		return nil
+	}
+	if !chainload {
+		return []string{"-DCMAKE_TOOLCHAIN_FILE=" + c.File}
+	}
+	args := []string{"-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE=" + c.File}
+	if c.VcpkgTriplet != "" {
+		args = append(args, "-DVCPKG_TARGET_TRIPLET="+c.VcpkgTriplet)
+	}
+	if c.TripletsDir != "" {
+		args = append(args, "-DVCPKG_OVERLAY_TRIPLETS="+c.TripletsDir)
+	}
+	return args
+}
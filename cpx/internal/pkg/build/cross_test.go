@@ -0,0 +1,23 @@
+package build
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrossToolchain(t *testing.T) {
+	var host CrossToolchain
+	assert.Nil(t, host.CMakeArgs(false))
+
+	cross := CrossToolchain{Name: "aarch64-linux-gnu", File: "/src/cmake/toolchains/aarch64-linux-gnu.cmake", VcpkgTriplet: "arm64-linux"}
+	assert.Equal(t, filepath.Join(".cache", "aarch64-linux-gnu", "release"), cross.BuildDir(true, ""))
+	assert.Equal(t, filepath.Join(".bin", "aarch64-linux-gnu", "O2"), cross.OutputDir(false, "2"))
+	assert.Equal(t, []string{"-DCMAKE_TOOLCHAIN_FILE=" + cross.File}, cross.CMakeArgs(false))
+	assert.Equal(t, []string{"-DVCPKG_CHAINLOAD_TOOLCHAIN_FILE=" + cross.File, "-DVCPKG_TARGET_TRIPLET=arm64-linux"}, cross.CMakeArgs(true))
+
+	// Triplets that aren't vcpkg's come from an overlay
+	cross.TripletsDir = "/src/.cache/static/triplets"
+	assert.Contains(t, cross.CMakeArgs(true), "-DVCPKG_OVERLAY_TRIPLETS=/src/.cache/static/triplets")
+}
@@ -0,0 +1,67 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+)
+
+// DebuggerEnv overrides the debugger picked by FindDebugger
+const DebuggerEnv = "CPX_DEBUGGER"
+
+// FindDebugger returns the debugger to launch programs under: $CPX_DEBUGGER
+// if set, otherwise lldb on macOS and gdb elsewhere, falling back to the other
+// one if the preferred debugger is not installed
+func FindDebugger() (string, error) {
+	if debugger := os.Getenv(DebuggerEnv); debugger != "" {
+		path, err := exec.LookPath(debugger)
+		if err != nil {
+			return "", exitcode.Errorf(exitcode.ToolchainMissing, "%s=%s not found: %w", DebuggerEnv, debugger, err)
+		}
+		return path, nil
+	}
+
+	candidates := []string{"gdb", "lldb"}
+	if runtime.GOOS == "darwin" {
+		candidates = []string{"lldb", "gdb"}
+	}
+	for _, candidate := range candidates {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+
+	hint := "install gdb (e.g. apt install gdb)"
+	if runtime.GOOS == "darwin" {
+		hint = "install the Xcode command line tools (xcode-select --install) for lldb"
+	}
+	return "", exitcode.Errorf(exitcode.ToolchainMissing, "no debugger found (tried %s)\n  hint: %s", strings.Join(candidates, ", "), hint)
+}
+
+// DebuggerPrefix returns the command line that launches a program under
+// debugger with workDir as its working directory. The program and its
+// arguments follow the prefix.
+func DebuggerPrefix(debugger, workDir string) []string {
+	if strings.Contains(filepath.Base(debugger), "lldb") {
+		return []string{debugger, "-O", "platform settings -w " + workDir, "--"}
+	}
+	return []string{debugger, "--cd=" + workDir, "--args"}
+}
+
+// ShellJoin joins args into a single command line, single-quoting arguments
+// that contain spaces or shell metacharacters (e.g. for bazel --run_under)
+func ShellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>()*?[]#~") {
+			quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		} else {
+			quoted[i] = arg
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,61 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebuggerPrefix(t *testing.T) {
+	tests := []struct {
+		name     string
+		debugger string
+		expected []string
+	}{
+		{
+			name:     "gdb",
+			debugger: "/usr/bin/gdb",
+			expected: []string{"/usr/bin/gdb", "--cd=/work", "--args"},
+		},
+		{
+			name:     "lldb",
+			debugger: "/usr/bin/lldb",
+			expected: []string{"/usr/bin/lldb", "-O", "platform settings -w /work", "--"},
+		},
+		{
+			name:     "versioned lldb",
+			debugger: "lldb-17",
+			expected: []string{"lldb-17", "-O", "platform settings -w /work", "--"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, DebuggerPrefix(tt.debugger, "/work"))
+		})
+	}
+}
+
+func TestFindDebuggerOverride(t *testing.T) {
+	t.Setenv(DebuggerEnv, "cpx-no-such-debugger")
+	_, err := FindDebugger()
+	assert.Error(t, err)
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(err))
+}
+
+func TestShellJoin(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"gdb", "--args"}, "gdb --args"},
+		{[]string{"lldb", "-O", "platform settings -w /work", "--"}, "lldb -O 'platform settings -w /work' --"},
+		{[]string{"echo", "it's"}, `echo 'it'\''s'`},
+		{[]string{"echo", ""}, "echo ''"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ShellJoin(tt.args))
+	}
+}
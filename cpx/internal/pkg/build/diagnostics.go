@@ -0,0 +1,298 @@
+package build
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ozacod/cpx/internal/pkg/events"
+)
+
+// DiagnosticSet collects the compiler diagnostics of a build. The same
+// warning in a header is reported once per translation unit including it, so
+// diagnostics are deduplicated by location, severity and message.
+type DiagnosticSet struct {
+	mu    sync.Mutex
+	seen  map[events.Diagnostic]bool
+	items []events.Diagnostic
+}
+
+// NewDiagnosticSet returns an empty DiagnosticSet
+func NewDiagnosticSet() *DiagnosticSet {
+	return &DiagnosticSet{seen: make(map[events.Diagnostic]bool)}
+}
+
+// collected receives the diagnostics of BuildLine, if set
+var (
+	collectedMu sync.Mutex
+	collected   *DiagnosticSet
+)
+
+// CollectDiagnostics starts collecting the diagnostics of the following
+// builds into a new set, and returns it
+func CollectDiagnostics() *DiagnosticSet {
+	collectedMu.Lock()
+	defer collectedMu.Unlock()
+	collected = NewDiagnosticSet()
+	return collected
+}
+
+// BuildLine handles one line of compiler output: it emits the events for it
+// and records the diagnostic it reports, if diagnostics are collected
+func BuildLine(line string) {
+	events.BuildLine(line)
+	collectedMu.Lock()
+	set := collected
+	collectedMu.Unlock()
+	if set != nil {
+		set.Line(line)
+	}
+}
+
+// Line records the diagnostic reported by a line of build output, if any
+func (s *DiagnosticSet) Line(line string) {
+	if d, ok := events.ParseDiagnostic(strings.TrimSpace(line)); ok {
+		s.Add(d)
+	}
+}
+
+// Add records d unless it was already recorded. Absolute paths inside the
+// working directory are made relative to it.
+func (s *DiagnosticSet) Add(d events.Diagnostic) {
+	if filepath.IsAbs(d.File) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, d.File); err == nil && !strings.HasPrefix(rel, "..") {
+				d.File = rel
+			}
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[d] {
+		return
+	}
+	s.seen[d] = true
+	s.items = append(s.items, d)
+}
+
+// Diagnostics returns the recorded diagnostics sorted by file and position
+func (s *DiagnosticSet) Diagnostics() []events.Diagnostic {
+	s.mu.Lock()
+	items := append([]events.Diagnostic{}, s.items...)
+	s.mu.Unlock()
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return items
+}
+
+// Counts returns the number of errors and warnings; notes are not counted
+func (s *DiagnosticSet) Counts() (errors, warnings int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, d := range s.items {
+		switch d.Severity {
+		case "error":
+			errors++
+		case "warning":
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// PrintSummary prints the errors and warnings grouped per file. It prints
+// nothing for a build without errors or warnings.
+func (s *DiagnosticSet) PrintSummary() {
+	errors, warnings := s.Counts()
+	if errors+warnings == 0 {
+		return
+	}
+	color := colorYellow
+	if errors > 0 {
+		color = colorRed
+	}
+	fmt.Printf("\n%s▸ Diagnostics:%s %s\n", color, colorReset, countSummary(errors, warnings))
+
+	var file string
+	var group []events.Diagnostic
+	flush := func() {
+		if len(group) == 0 {
+			return
+		}
+		fileErrors, fileWarnings := 0, 0
+		for _, d := range group {
+			if d.Severity == "error" {
+				fileErrors++
+			} else {
+				fileWarnings++
+			}
+		}
+		fmt.Printf("  %s %s(%s)%s\n", file, colorGray, countSummary(fileErrors, fileWarnings), colorReset)
+		for _, d := range group {
+			severityColor := colorYellow
+			if d.Severity == "error" {
+				severityColor = colorRed
+			}
+			fmt.Printf("    %-8s %s%-7s%s %s\n", position(d), severityColor, d.Severity, colorReset, d.Message)
+		}
+		group = nil
+	}
+	for _, d := range s.Diagnostics() {
+		if d.Severity != "error" && d.Severity != "warning" {
+			continue
+		}
+		if d.File != file {
+			flush()
+			file = d.File
+		}
+		group = append(group, d)
+	}
+	flush()
+}
+
+func countSummary(errors, warnings int) string {
+	return plural(errors, "error") + ", " + plural(warnings, "warning")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, word)
+	}
+	return fmt.Sprintf("%d %ss", n, word)
+}
+
+// position formats the line and column of d as line:col
+func position(d events.Diagnostic) string {
+	if d.Column > 0 {
+		return fmt.Sprintf("%d:%d", d.Line, d.Column)
+	}
+	return fmt.Sprintf("%d", d.Line)
+}
+
+// WriteFile writes the diagnostics to path: as SARIF 2.1.0 if path ends in
+// .sarif or .sarif.json, as JSON otherwise
+func (s *DiagnosticSet) WriteFile(path string) error {
+	var v any
+	if strings.HasSuffix(path, ".sarif") || strings.HasSuffix(path, ".sarif.json") {
+		v = s.sarif()
+	} else {
+		errors, warnings := s.Counts()
+		v = diagnosticsReport{Errors: errors, Warnings: warnings, Diagnostics: s.Diagnostics()}
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// diagnosticsReport is the JSON form of a DiagnosticSet
+type diagnosticsReport struct {
+	Errors      int                 `json:"errors"`
+	Warnings    int                 `json:"warnings"`
+	Diagnostics []events.Diagnostic `json:"diagnostics"`
+}
+
+// The subset of SARIF 2.1.0 (https://docs.oasis-open.org/sarif/sarif/v2.1.0/)
+// cpx writes, which editors and code scanning services read
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string `json:"name"`
+	InformationURI string `json:"informationUri"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// warningFlagRe matches the flag GCC and Clang append to warnings, e.g.
+// "unused variable 'x' [-Wunused-variable]"
+var warningFlagRe = regexp.MustCompile(`\s*\[(-W[\w+=-]+)\]$`)
+
+func (s *DiagnosticSet) sarif() sarifLog {
+	results := []sarifResult{}
+	for _, d := range s.Diagnostics() {
+		result := sarifResult{Level: d.Severity, Message: sarifMessage{Text: d.Message}}
+		if m := warningFlagRe.FindStringSubmatch(d.Message); m != nil {
+			result.RuleID = m[1]
+			result.Message.Text = strings.TrimSuffix(d.Message, m[0])
+		}
+		uri := filepath.ToSlash(d.File)
+		if filepath.IsAbs(d.File) {
+			if !strings.HasPrefix(uri, "/") {
+				uri = "/" + uri
+			}
+			uri = "file://" + uri
+		}
+		result.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: uri},
+			Region:           sarifRegion{StartLine: d.Line, StartColumn: d.Column},
+		}}}
+		results = append(results, result)
+	}
+	return sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "cpx build", InformationURI: "https://github.com/ozacod/cpx"}},
+			Results: results,
+		}},
+	}
+}
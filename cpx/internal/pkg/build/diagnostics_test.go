@@ -0,0 +1,84 @@
+package build
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiagnosticSet(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	set := NewDiagnosticSet()
+	// The header warning is reported by both translation units
+	set.Line(filepath.Join(wd, "include", "util.hpp") + ":3:10: warning: unused parameter 'x' [-Wunused-parameter]")
+	set.Line("[ 50%] Building CXX object CMakeFiles/app.dir/src/main.cpp.o")
+	set.Line("src/main.cpp:12:5: error: use of undeclared identifier 'foo'")
+	set.Line("include/util.hpp:3:10: warning: unused parameter 'x' [-Wunused-parameter]")
+	set.Line("src/main.cpp:2:1: note: in file included from here")
+	set.Line("  src/main.cpp:4:9: warning: unused variable 'y'")
+
+	errors, warnings := set.Counts()
+	assert.Equal(t, 1, errors)
+	assert.Equal(t, 2, warnings)
+	assert.Equal(t, []events.Diagnostic{
+		{File: "include/util.hpp", Line: 3, Column: 10, Severity: "warning", Message: "unused parameter 'x' [-Wunused-parameter]"},
+		{File: "src/main.cpp", Line: 2, Column: 1, Severity: "note", Message: "in file included from here"},
+		{File: "src/main.cpp", Line: 4, Column: 9, Severity: "warning", Message: "unused variable 'y'"},
+		{File: "src/main.cpp", Line: 12, Column: 5, Severity: "error", Message: "use of undeclared identifier 'foo'"},
+	}, set.Diagnostics())
+}
+
+func TestCollectDiagnostics(t *testing.T) {
+	BuildLine("src/a.cpp:1:1: error: not collected")
+	set := CollectDiagnostics()
+	w := &LineWriter{}
+	w.Write([]byte("src/a.cpp:1:1: warning: first\nsrc/b.cpp:2:"))
+	w.Write([]byte("2: error: second"))
+	w.Flush()
+
+	errors, warnings := set.Counts()
+	assert.Equal(t, 1, errors)
+	assert.Equal(t, 1, warnings)
+}
+
+func TestDiagnosticSetWriteFile(t *testing.T) {
+	set := NewDiagnosticSet()
+	set.Line("src/main.cpp:4:9: warning: unused variable 'y' [-Wunused-variable]")
+	set.Line(`src\win.cpp(7): error C2065: 'foo': undeclared identifier`)
+
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "diagnostics.json")
+	require.NoError(t, set.WriteFile(jsonPath))
+	data, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	var report diagnosticsReport
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, 1, report.Errors)
+	assert.Equal(t, 1, report.Warnings)
+	assert.Len(t, report.Diagnostics, 2)
+
+	sarifPath := filepath.Join(dir, "out", "build.sarif")
+	require.NoError(t, set.WriteFile(sarifPath))
+	data, err = os.ReadFile(sarifPath)
+	require.NoError(t, err)
+	var log sarifLog
+	require.NoError(t, json.Unmarshal(data, &log))
+	assert.Equal(t, "2.1.0", log.Version)
+	require.Len(t, log.Runs, 1)
+	results := log.Runs[0].Results
+	require.Len(t, results, 2)
+	assert.Equal(t, "-Wunused-variable", results[0].RuleID)
+	assert.Equal(t, "warning", results[0].Level)
+	assert.Equal(t, "unused variable 'y'", results[0].Message.Text)
+	assert.Equal(t, "src/main.cpp", results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, sarifRegion{StartLine: 4, StartColumn: 9}, results[0].Locations[0].PhysicalLocation.Region)
+	assert.Equal(t, "error", results[1].Level)
+	assert.Empty(t, results[1].RuleID)
+}
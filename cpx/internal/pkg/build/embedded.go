@@ -0,0 +1,167 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/logging"
+)
+
+// EmbeddedTarget is the cpx build --target value that cross-compiles the
+// firmware of an embedded project instead of building a CMake target
+const EmbeddedTarget = "embedded"
+
+// EmbeddedBuildDir returns the CMake build directory BuildEmbedded uses
+func EmbeddedBuildDir(release bool, optLevel string) string {
+	return filepath.Join(".cache", "embedded", buildVariant(release, optLevel, "", ""))
+}
+
+// embeddedConfigureArgs returns the CMake configure arguments of a firmware
+// build. The toolchain file sets the MCU flags in CMAKE_<LANG>_FLAGS_INIT,
+// which CMAKE_<LANG>_FLAGS on the command line would replace, so an -O
+// override goes into the flags of the build type instead.
+func embeddedConfigureArgs(buildDir, toolchainFile, buildType, cxxFlags string, projectArgs []string) []string {
+	args := []string{"-B", buildDir, "-DCMAKE_TOOLCHAIN_FILE=" + toolchainFile, "-DCMAKE_BUILD_TYPE=" + buildType}
+	args = append(args, projectArgs...)
+	if cxxFlags != "" {
+		if buildType != "Debug" {
+			cxxFlags += " -DNDEBUG"
+		}
+		config := strings.ToUpper(buildType)
+		args = append(args, "-DCMAKE_CXX_FLAGS_"+config+"="+cxxFlags, "-DCMAKE_C_FLAGS_"+config+"="+cxxFlags)
+	}
+	return args
+}
+
+// EmbeddedOutputDir returns the directory BuildEmbedded copies the firmware
+// images to
+func EmbeddedOutputDir(release bool, optLevel string) string {
+	return filepath.Join(".bin", "embedded", buildVariant(release, optLevel, "", ""))
+}
+
+// firmwareImages returns the firmware images (.elf, .bin and .hex) in the top
+// level of buildDir
+func firmwareImages(buildDir string) []string {
+	var images []string
+	for _, ext := range []string{".elf", ".bin", ".hex"} {
+		matches, _ := filepath.Glob(filepath.Join(buildDir, "*"+ext))
+		images = append(images, matches...)
+	}
+	return images
+}
+
+// BuildEmbedded cross-compiles the firmware of an embedded project with the
+// CMake toolchain file toolchainFile. vcpkg is not involved, and the project
+// builds its tests only on the host.
+func BuildEmbedded(toolchainFile string, release bool, jobs int, clean bool, optLevel string, verbose bool, unity bool) error {
+	projectName := GetProjectNameFromCMakeLists()
+	if projectName == "" {
+		projectName = "project"
+	}
+
+	cacheBuildDir := EmbeddedBuildDir(release, optLevel)
+	// Firmware images go to .bin/embedded/<variant>
+	finalBuildDir := EmbeddedOutputDir(release, optLevel)
+
+	if clean {
+		if verbose {
+			logging.Step("  Cleaning build directory...")
+		}
+		os.RemoveAll(cacheBuildDir)
+		os.RemoveAll(finalBuildDir)
+	}
+	if err := os.MkdirAll(cacheBuildDir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache build dir: %w", err)
+	}
+
+	buildType, cxxFlags := DetermineBuildType(release, optLevel)
+	logging.Info("\n%s▸ Build%s %s %s(%s)%s %s[target: %s]%s",
+		colorCyan, colorReset, projectName, colorGray, buildType, colorReset,
+		colorGray, EmbeddedTarget, colorReset)
+
+	opts, err := ProjectOptions()
+	if err != nil {
+		return err
+	}
+	projectArgs, err := ProjectConfigureArgs(unity || opts.Unity)
+	if err != nil {
+		return err
+	}
+
+	if err := resetStaleCMakeCache(cacheBuildDir); err != nil {
+		return err
+	}
+	needsConfigure := false
+	if _, err := os.Stat(filepath.Join(cacheBuildDir, "CMakeCache.txt")); os.IsNotExist(err) {
+		needsConfigure = true
+	} else if cmakeCacheOutdated(cacheBuildDir, projectArgs) {
+		needsConfigure = true
+	}
+
+	totalSteps := 1
+	currentStep := 0
+	if needsConfigure {
+		totalSteps = 2
+		currentStep++
+		if verbose {
+			logging.Step("  • Configuring CMake (%s)", toolchainFile)
+		} else {
+			printProgress("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
+		}
+
+		// CMake resolves a relative toolchain file against the build directory
+		absToolchain, err := filepath.Abs(toolchainFile)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command("cmake", embeddedConfigureArgs(cacheBuildDir, absToolchain, buildType, cxxFlags, projectArgs)...)
+		cmd.Env = os.Environ()
+		if err := runCMakeConfigure(cmd, verbose); err != nil {
+			printProgress("\n")
+			return fmt.Errorf("cmake configure failed (%s): %w\n  hint: install the GNU Arm Embedded Toolchain (arm-none-eabi-gcc) and make sure it is in PATH", toolchainFile, err)
+		}
+
+		if !verbose {
+			printProgress("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
+		}
+	}
+
+	buildStart := time.Now()
+	buildArgs := []string{"--build", cacheBuildDir, "--config", buildType}
+	if verbose {
+		buildArgs = append(buildArgs, "--verbose")
+	}
+	if jobs > 0 {
+		buildArgs = append(buildArgs, "--parallel", fmt.Sprintf("%d", jobs))
+	} else {
+		buildArgs = append(buildArgs, "--parallel", fmt.Sprintf("%d", runtime.NumCPU()))
+	}
+
+	currentStep++
+	if err := runCMakeBuild(buildArgs, verbose, currentStep, totalSteps); err != nil {
+		return fmt.Errorf("build failed: %w", err)
+	}
+
+	if err := os.MkdirAll(finalBuildDir, 0755); err != nil {
+		return fmt.Errorf("failed to create final build dir: %w", err)
+	}
+	for _, image := range firmwareImages(cacheBuildDir) {
+		data, err := os.ReadFile(image)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(finalBuildDir, filepath.Base(image)), data, 0644); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", filepath.Base(image), err)
+		}
+	}
+
+	elapsed := time.Since(buildStart)
+	logging.Success("  ✔ Build complete %s[%s]", colorGray, elapsed.Round(10*time.Millisecond))
+	logging.Info("  Firmware in: %s/\n", finalBuildDir)
+	return nil
+}
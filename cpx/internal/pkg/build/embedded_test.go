@@ -0,0 +1,48 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedBuildDir(t *testing.T) {
+	assert.Equal(t, filepath.Join(".cache", "embedded", "debug"), EmbeddedBuildDir(false, ""))
+	assert.Equal(t, filepath.Join(".cache", "embedded", "Os"), EmbeddedBuildDir(true, "s"))
+	assert.Equal(t, filepath.Join(".bin", "embedded", "release"), EmbeddedOutputDir(true, ""))
+	assert.Equal(t, filepath.Join(".bin", "native", "O3-asan"), OutputDir(false, "3", "asan", ""))
+}
+
+func TestEmbeddedConfigureArgs(t *testing.T) {
+	args := embeddedConfigureArgs("out", "/p/cmake/arm-none-eabi.cmake", "Debug", "", []string{"-DCMAKE_UNITY_BUILD=OFF"})
+	assert.Equal(t, []string{
+		"-B", "out",
+		"-DCMAKE_TOOLCHAIN_FILE=/p/cmake/arm-none-eabi.cmake",
+		"-DCMAKE_BUILD_TYPE=Debug",
+		"-DCMAKE_UNITY_BUILD=OFF",
+	}, args)
+
+	// -O overrides must not replace the MCU flags in CMAKE_CXX_FLAGS
+	args = embeddedConfigureArgs("out", "tc.cmake", "MinSizeRel", "-Os", nil)
+	assert.Contains(t, args, "-DCMAKE_CXX_FLAGS_MINSIZEREL=-Os -DNDEBUG")
+	assert.Contains(t, args, "-DCMAKE_C_FLAGS_MINSIZEREL=-Os -DNDEBUG")
+	for _, arg := range args {
+		assert.NotContains(t, arg, "-DCMAKE_CXX_FLAGS=")
+	}
+}
+
+func TestFirmwareImages(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"blinky.elf", "blinky.bin", "blinky.hex", "blinky.map", "CMakeCache.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+	images := firmwareImages(dir)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(dir, "blinky.elf"),
+		filepath.Join(dir, "blinky.bin"),
+		filepath.Join(dir, "blinky.hex"),
+	}, images)
+}
@@ -0,0 +1,156 @@
+package build
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JUnitTestSuites is the root element of a JUnit XML report
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr,omitempty"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+// JUnitTestSuite is a group of test cases, usually one per test binary or target
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []JUnitTestCase `xml:"testcase"`
+}
+
+// JUnitTestCase is a single test result
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr,omitempty"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Error     *JUnitMessage `xml:"error,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+// JUnitMessage holds the details of a failed, errored or skipped test case
+type JUnitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+// ParseJUnitXML parses a JUnit XML document. Both a <testsuites> root and a
+// bare <testsuite> root are accepted, since ctest, googletest, Catch2, doctest,
+// Bazel and Meson do not agree on which one to emit.
+func ParseJUnitXML(data []byte) (*JUnitTestSuites, error) {
+	var suites JUnitTestSuites
+	if err := xml.Unmarshal(data, &suites); err == nil && suites.XMLName.Local == "testsuites" {
+		return &suites, nil
+	}
+
+	var suite JUnitTestSuite
+	if err := xml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse JUnit XML: %w", err)
+	}
+	return &JUnitTestSuites{Suites: []JUnitTestSuite{suite}}, nil
+}
+
+// MergeJUnitFiles reads every JUnit XML file in paths and combines their test
+// suites into a single report with recomputed totals
+func MergeJUnitFiles(paths []string) (*JUnitTestSuites, error) {
+	merged := &JUnitTestSuites{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		report, err := ParseJUnitXML(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		merged.Suites = append(merged.Suites, report.Suites...)
+	}
+	merged.recount()
+	return merged, nil
+}
+
+// recount fills in suite and report totals from the individual test cases.
+// Suites without test cases keep the totals reported by the tool.
+func (r *JUnitTestSuites) recount() {
+	r.Tests, r.Failures, r.Errors, r.Skipped, r.Time = 0, 0, 0, 0, 0
+	for i := range r.Suites {
+		s := &r.Suites[i]
+		if len(s.Cases) > 0 {
+			s.Tests, s.Failures, s.Errors, s.Skipped = len(s.Cases), 0, 0, 0
+			for _, c := range s.Cases {
+				switch {
+				case c.Failure != nil:
+					s.Failures++
+				case c.Error != nil:
+					s.Errors++
+				case c.Skipped != nil:
+					s.Skipped++
+				}
+			}
+		}
+		r.Tests += s.Tests
+		r.Failures += s.Failures
+		r.Errors += s.Errors
+		r.Skipped += s.Skipped
+		r.Time += s.Time
+	}
+}
+
+// WriteJUnitReport writes the report to path, creating parent directories as needed
+func WriteJUnitReport(report *JUnitTestSuites, path string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create report directory: %w", err)
+		}
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
+
+// FindJUnitFiles returns all files named name below root, e.g. Bazel's test.xml
+// files under bazel-testlogs. Files last written before since are left out,
+// so results of earlier runs don't leak in; a zero since keeps them all.
+func FindJUnitFiles(root, name string, since time.Time) ([]string, error) {
+	var files []string
+	// bazel-testlogs is a symlink, and Walk does not follow a symlinked root
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		// File times may only have second granularity
+		if !info.IsDir() && info.Name() == name && !info.ModTime().Before(since.Truncate(time.Second)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
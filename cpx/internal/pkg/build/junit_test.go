@@ -0,0 +1,113 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJUnitXML(t *testing.T) {
+	tests := []struct {
+		name       string
+		xml        string
+		wantSuites int
+		wantCases  int
+		wantErr    bool
+	}{
+		{
+			name: "ctest testsuites root",
+			xml: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="Linux" tests="2" failures="1">
+    <testcase name="MathTest.Add" classname="MathTest.Add" time="0.01"/>
+    <testcase name="MathTest.Sub" classname="MathTest.Sub" time="0.02">
+      <failure message="Failed">expected 1</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`,
+			wantSuites: 1,
+			wantCases:  2,
+		},
+		{
+			name: "bare testsuite root",
+			xml: `<testsuite name="catch2" tests="1">
+  <testcase name="vector grows" time="0.001"/>
+</testsuite>`,
+			wantSuites: 1,
+			wantCases:  1,
+		},
+		{
+			name:    "invalid xml",
+			xml:     `<testsuite`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := ParseJUnitXML([]byte(tt.xml))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, report.Suites, tt.wantSuites)
+			assert.Len(t, report.Suites[0].Cases, tt.wantCases)
+		})
+	}
+}
+
+func TestMergeJUnitFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	start := time.Now()
+
+	first := filepath.Join(tmpDir, "a", "test.xml")
+	second := filepath.Join(tmpDir, "b", "test.xml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(first), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Dir(second), 0755))
+
+	require.NoError(t, os.WriteFile(first, []byte(`<testsuites>
+  <testsuite name="//tests:unit" time="1.5">
+    <testcase name="A.pass"/>
+    <testcase name="A.fail"><failure message="boom"/></testcase>
+  </testsuite>
+</testsuites>`), 0644))
+	require.NoError(t, os.WriteFile(second, []byte(`<testsuite name="//tests:other" time="0.5">
+  <testcase name="B.skip"><skipped/></testcase>
+</testsuite>`), 0644))
+
+	// A result left over from an earlier run is skipped
+	stale := filepath.Join(tmpDir, "c", "test.xml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(stale), 0755))
+	require.NoError(t, os.WriteFile(stale, []byte(`<testsuite name="//tests:stale"/>`), 0644))
+	require.NoError(t, os.Chtimes(stale, start.Add(-time.Hour), start.Add(-time.Hour)))
+
+	files, err := FindJUnitFiles(tmpDir, "test.xml", start)
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	all, err := FindJUnitFiles(tmpDir, "test.xml", time.Time{})
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+
+	merged, err := MergeJUnitFiles(files)
+	require.NoError(t, err)
+	assert.Len(t, merged.Suites, 2)
+	assert.Equal(t, 3, merged.Tests)
+	assert.Equal(t, 1, merged.Failures)
+	assert.Equal(t, 1, merged.Skipped)
+	assert.InDelta(t, 2.0, merged.Time, 0.001)
+
+	reportPath := filepath.Join(tmpDir, "reports", "junit.xml")
+	require.NoError(t, WriteJUnitReport(merged, reportPath))
+
+	data, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	roundTrip, err := ParseJUnitXML(data)
+	require.NoError(t, err)
+	assert.Equal(t, 3, roundTrip.Tests)
+	assert.Contains(t, string(data), `<testsuite name="//tests:unit"`)
+}
@@ -0,0 +1,252 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+)
+
+// PerfStats holds the normalized counters printed by `cpx run --perf-stat`.
+// Counters the platform cannot provide are -1.
+type PerfStats struct {
+	WallTime     time.Duration
+	MaxRSS       int64 // bytes
+	Instructions int64
+	CacheMisses  int64
+}
+
+// PerfStatRunner wraps an executable in `perf stat` (Linux) or
+// `/usr/bin/time -l` (macOS) and collects the results once it exits
+type PerfStatRunner struct {
+	goos    string
+	outFile string
+	stderr  bytes.Buffer
+	start   time.Time
+}
+
+// NewPerfStatRunner checks that the platform's counter tool is available
+func NewPerfStatRunner() (*PerfStatRunner, error) {
+	p := &PerfStatRunner{goos: runtime.GOOS}
+
+	switch p.goos {
+	case "linux":
+		if _, err := exec.LookPath("perf"); err != nil {
+			return nil, exitcode.Errorf(exitcode.ToolchainMissing, "perf not found\n  hint: install it with your distro's linux-tools package (e.g. apt install linux-tools-generic)")
+		}
+		tmp, err := os.CreateTemp("", "cpx-perf-*.csv")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create temp file: %w", err)
+		}
+		tmp.Close()
+		p.outFile = tmp.Name()
+	case "darwin":
+		if _, err := os.Stat("/usr/bin/time"); err != nil {
+			return nil, exitcode.Errorf(exitcode.ToolchainMissing, "/usr/bin/time not found")
+		}
+	default:
+		return nil, fmt.Errorf("--perf-stat is not supported on %s", p.goos)
+	}
+
+	return p, nil
+}
+
+// Prefix returns the command that should precede the executable
+func (p *PerfStatRunner) Prefix() []string {
+	if p.goos == "linux" {
+		return []string{"perf", "stat", "-x", ",", "-o", p.outFile,
+			"-e", "duration_time,instructions,cache-misses", "--"}
+	}
+	return []string{"/usr/bin/time", "-l"}
+}
+
+// Wrap returns the command name and arguments that run name under the counter tool
+func (p *PerfStatRunner) Wrap(name string, args []string) (string, []string) {
+	prefix := p.Prefix()
+	wrapped := append(prefix[1:], name)
+	return prefix[0], append(wrapped, args...)
+}
+
+// Attach must be called before cmd starts. On macOS the counters are
+// written to stderr, so stderr is teed into a buffer for parsing.
+func (p *PerfStatRunner) Attach(cmd *exec.Cmd) {
+	if p.goos == "darwin" {
+		stderr := cmd.Stderr
+		if stderr == nil {
+			stderr = os.Stderr
+		}
+		cmd.Stderr = io.MultiWriter(stderr, &p.stderr)
+	}
+	p.start = time.Now()
+}
+
+// Collect gathers the counters after cmd has exited and removes temp files
+func (p *PerfStatRunner) Collect(cmd *exec.Cmd) PerfStats {
+	return p.collect(cmd, true)
+}
+
+// CollectWrapped is Collect for a program that cmd started rather than ran
+// itself, such as bazel run --run_under. The resource usage of cmd is then
+// not the program's, so the max RSS is only known if the counter tool
+// reports it (macOS).
+func (p *PerfStatRunner) CollectWrapped(cmd *exec.Cmd) PerfStats {
+	return p.collect(cmd, false)
+}
+
+func (p *PerfStatRunner) collect(cmd *exec.Cmd, ownUsage bool) PerfStats {
+	stats := PerfStats{
+		WallTime:     time.Since(p.start),
+		MaxRSS:       -1,
+		Instructions: -1,
+		CacheMisses:  -1,
+	}
+	if ownUsage && cmd.ProcessState != nil {
+		stats.MaxRSS = maxRSSBytes(cmd.ProcessState)
+	}
+
+	switch p.goos {
+	case "linux":
+		defer os.Remove(p.outFile)
+		if data, err := os.ReadFile(p.outFile); err == nil {
+			parsePerfStatCSV(string(data), &stats)
+		}
+	case "darwin":
+		parseTimeOutput(p.stderr.String(), &stats)
+	}
+
+	return stats
+}
+
+// RunWithPerfStat runs name with stdio attached under the counter tool and
+// prints the collected counters, even if the program fails. newCommand creates
+// the process (exec.Command outside of tests).
+func RunWithPerfStat(name string, args []string, env []string, dir string, newCommand func(string, ...string) *exec.Cmd) error {
+	perf, err := NewPerfStatRunner()
+	if err != nil {
+		return err
+	}
+
+	wrapName, wrapArgs := perf.Wrap(name, args)
+	cmd := newCommand(wrapName, wrapArgs...)
+	if len(env) > 0 {
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, env...)
+	}
+	cmd.Dir = dir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	perf.Attach(cmd)
+
+	runErr := cmd.Run()
+	PrintPerfStats(perf.Collect(cmd))
+	return runErr
+}
+
+// parsePerfStatCSV parses `perf stat -x ,` output. Each counter line has the
+// form value,unit,event,... and unsupported counters report "<not supported>".
+func parsePerfStatCSV(output string, stats *PerfStats) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		// Events may carry a modifier suffix such as instructions:u
+		event := strings.SplitN(fields[2], ":", 2)[0]
+		switch event {
+		case "duration_time":
+			stats.WallTime = time.Duration(value)
+		case "instructions":
+			stats.Instructions = int64(value)
+		case "cache-misses":
+			stats.CacheMisses = int64(value)
+		}
+	}
+}
+
+// parseTimeOutput parses the resource summary printed by macOS `/usr/bin/time -l`
+func parseTimeOutput(output string, stats *PerfStats) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		if len(fields) >= 6 && fields[1] == "real" {
+			if secs, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				stats.WallTime = time.Duration(secs * float64(time.Second))
+			}
+			continue
+		}
+		value, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch strings.Join(fields[1:], " ") {
+		case "maximum resident set size":
+			stats.MaxRSS = value
+		case "instructions retired":
+			stats.Instructions = value
+		}
+	}
+}
+
+// PrintPerfStats prints the counters as an aligned table
+func PrintPerfStats(stats PerfStats) {
+	fmt.Println(strings.Repeat("─", 40))
+	fmt.Printf("%s▸ Performance counters%s\n", colorCyan, colorReset)
+	fmt.Printf("  %-14s %s\n", "Wall time", stats.WallTime.Round(time.Microsecond))
+	fmt.Printf("  %-14s %s\n", "Max RSS", formatBytes(stats.MaxRSS))
+	fmt.Printf("  %-14s %s\n", "Instructions", formatCount(stats.Instructions))
+	fmt.Printf("  %-14s %s\n", "Cache misses", formatCount(stats.CacheMisses))
+}
+
+func formatBytes(n int64) string {
+	if n < 0 {
+		return colorGray + "n/a" + colorReset
+	}
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatCount(n int64) string {
+	if n < 0 {
+		return colorGray + "n/a" + colorReset
+	}
+	s := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
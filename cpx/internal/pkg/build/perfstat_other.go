@@ -0,0 +1,10 @@
+//go:build !unix
+
+package build
+
+import "os"
+
+// maxRSSBytes is not available on this platform
+func maxRSSBytes(state *os.ProcessState) int64 {
+	return -1
+}
@@ -0,0 +1,73 @@
+package build
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePerfStatCSV(t *testing.T) {
+	output := `# started on Mon Jan  1 00:00:00 2024
+
+1523400123,ns,duration_time,1523400123,100.00,,
+48213377,,instructions:u,1200000,100.00,1.21,insn per cycle
+<not supported>,,cache-misses,0,100.00,,
+`
+	stats := PerfStats{MaxRSS: -1, Instructions: -1, CacheMisses: -1}
+	parsePerfStatCSV(output, &stats)
+
+	assert.Equal(t, time.Duration(1523400123), stats.WallTime)
+	assert.Equal(t, int64(48213377), stats.Instructions)
+	assert.Equal(t, int64(-1), stats.CacheMisses)
+}
+
+func TestParseTimeOutput(t *testing.T) {
+	output := `hello from app
+        0.52 real         0.31 user         0.05 sys
+             1327104  maximum resident set size
+                   0  average shared memory size
+           912345678  instructions retired
+           123456789  cycles elapsed
+`
+	stats := PerfStats{MaxRSS: -1, Instructions: -1, CacheMisses: -1}
+	parseTimeOutput(output, &stats)
+
+	assert.Equal(t, 520*time.Millisecond, stats.WallTime)
+	assert.Equal(t, int64(1327104), stats.MaxRSS)
+	assert.Equal(t, int64(912345678), stats.Instructions)
+	assert.Equal(t, int64(-1), stats.CacheMisses)
+}
+
+func TestFormatPerfValues(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"small count", formatCount(999), "999"},
+		{"grouped count", formatCount(1234567), "1,234,567"},
+		{"bytes", formatBytes(512), "512 B"},
+		{"kibibytes", formatBytes(1536), "1.5 KiB"},
+		{"mebibytes", formatBytes(12 * 1024 * 1024), "12.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.got)
+		})
+	}
+}
+
+func TestCollectWrapped(t *testing.T) {
+	// The max RSS of bazel run is bazel's, not the program's
+	p := &PerfStatRunner{goos: "linux", outFile: filepath.Join(t.TempDir(), "perf.csv")}
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	p.Attach(cmd)
+	require.NoError(t, cmd.Run())
+	assert.Equal(t, int64(-1), p.CollectWrapped(cmd).MaxRSS)
+}
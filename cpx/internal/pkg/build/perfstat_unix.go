@@ -0,0 +1,22 @@
+//go:build unix
+
+package build
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// maxRSSBytes returns the peak resident set size of an exited process
+func maxRSSBytes(state *os.ProcessState) int64 {
+	usage, ok := state.SysUsage().(*syscall.Rusage)
+	if !ok || usage == nil {
+		return -1
+	}
+	// ru_maxrss is reported in bytes on macOS and kilobytes elsewhere
+	if runtime.GOOS == "darwin" {
+		return int64(usage.Maxrss)
+	}
+	return int64(usage.Maxrss) * 1024
+}
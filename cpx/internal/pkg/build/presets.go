@@ -0,0 +1,350 @@
+package build
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+// PresetsFile is the CMake presets file of a project
+const PresetsFile = "CMakePresets.json"
+
+// DefaultPreset is the configure preset cpx generates and builds with unless
+// cpx.yaml selects another one (cpx preset use)
+const DefaultPreset = "default"
+
+// Presets is a CMakePresets.json document. Presets are kept as raw JSON so
+// that rewriting the file keeps the fields cpx doesn't know about, in their
+// order; so are the top-level keys cpx doesn't know about (e.g. $schema).
+type Presets struct {
+	Version              int               `json:"version"`
+	CMakeMinimumRequired json.RawMessage   `json:"cmakeMinimumRequired,omitempty"`
+	Include              json.RawMessage   `json:"include,omitempty"`
+	Vendor               json.RawMessage   `json:"vendor,omitempty"`
+	ConfigurePresets     []json.RawMessage `json:"configurePresets,omitempty"`
+	BuildPresets         []json.RawMessage `json:"buildPresets,omitempty"`
+	TestPresets          []json.RawMessage `json:"testPresets,omitempty"`
+	PackagePresets       json.RawMessage   `json:"packagePresets,omitempty"`
+	WorkflowPresets      json.RawMessage   `json:"workflowPresets,omitempty"`
+
+	keys  []string                   // top-level keys in the order of the file
+	extra map[string]json.RawMessage // top-level keys not in the fields above
+}
+
+// PresetInfo describes a configure, build or test preset
+type PresetInfo struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Hidden      bool   `json:"hidden,omitempty"`
+	// Inherits lists the presets a configure preset inherits from
+	Inherits []string `json:"inherits,omitempty"`
+	// ConfigurePreset is the configure preset of a build or test preset
+	ConfigurePreset string `json:"configurePreset,omitempty"`
+}
+
+// Preset kinds, as in the array names of CMakePresets.json
+const (
+	PresetConfigure = "configure"
+	PresetBuild     = "build"
+	PresetTest      = "test"
+)
+
+// NewPreset is a configure preset to add with a build and a test preset of
+// the same name
+type NewPreset struct {
+	Name        string
+	Description string
+	Inherits    string
+	Generator   string
+	// CacheVariables are set with -D at configure
+	CacheVariables map[string]string
+}
+
+// LoadPresets reads the CMakePresets.json at path
+func LoadPresets(path string) (*Presets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var presets Presets
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if presets.keys, err = objectKeys(data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &presets.extra); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for key := range presets.extra {
+		if slices.Contains(presetsKeys, key) {
+			delete(presets.extra, key)
+		}
+	}
+	return &presets, nil
+}
+
+// presetsKeys are the top-level keys of the Presets fields, in the order
+// they are written
+var presetsKeys = []string{"version", "cmakeMinimumRequired", "include", "vendor",
+	"configurePresets", "buildPresets", "testPresets", "packagePresets", "workflowPresets"}
+
+// fields returns the encoded Presets fields by key
+func (p *Presets) fields() (map[string]json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(p); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(buf.Bytes(), &fields)
+	return fields, err
+}
+
+// objectKeys returns the keys of the JSON object data, in order
+func objectKeys(data []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var keys []string
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// Save writes the presets to path, indented like the CMakePresets.json cpx
+// generates. Keys keep the order of the file they were loaded from; new
+// ones follow.
+func (p *Presets) Save(path string) error {
+	fields, err := p.fields()
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	for key, value := range p.extra {
+		fields[key] = value
+	}
+
+	var compact bytes.Buffer
+	compact.WriteByte('{')
+	write := func(key string) {
+		value, ok := fields[key]
+		if !ok {
+			return
+		}
+		delete(fields, key)
+		if compact.Len() > 1 {
+			compact.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		compact.Write(name)
+		compact.WriteByte(':')
+		compact.Write(value)
+	}
+	for _, key := range p.keys {
+		write(key)
+	}
+	for _, key := range presetsKeys {
+		write(key)
+	}
+	compact.WriteByte('}')
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact.Bytes(), "", "  "); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", path, err)
+	}
+	buf.WriteByte('\n')
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// presetFields are the fields of a preset cpx reads
+type presetFields struct {
+	Name            string `json:"name"`
+	Description     string `json:"description"`
+	DisplayName     string `json:"displayName"`
+	Hidden          bool   `json:"hidden"`
+	Inherits        any    `json:"inherits"`
+	ConfigurePreset string `json:"configurePreset"`
+}
+
+func parsePreset(raw json.RawMessage) presetFields {
+	var f presetFields
+	json.Unmarshal(raw, &f)
+	return f
+}
+
+// inheritsOf returns the inherits of a preset, a string or a list of strings
+func inheritsOf(f presetFields) []string {
+	switch v := f.Inherits.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		var names []string
+		for _, name := range v {
+			if s, ok := name.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+// List returns the configure, build and test presets, in that order
+func (p *Presets) List() []PresetInfo {
+	var list []PresetInfo
+	for _, kind := range []struct {
+		name    string
+		presets []json.RawMessage
+	}{{PresetConfigure, p.ConfigurePresets}, {PresetBuild, p.BuildPresets}, {PresetTest, p.TestPresets}} {
+		for _, raw := range kind.presets {
+			f := parsePreset(raw)
+			description := f.Description
+			if description == "" {
+				description = f.DisplayName
+			}
+			list = append(list, PresetInfo{
+				Kind:            kind.name,
+				Name:            f.Name,
+				Description:     description,
+				Hidden:          f.Hidden,
+				Inherits:        inheritsOf(f),
+				ConfigurePreset: f.ConfigurePreset,
+			})
+		}
+	}
+	return list
+}
+
+// HasConfigure reports whether a visible configure preset is named name
+func (p *Presets) HasConfigure(name string) bool {
+	for _, raw := range p.ConfigurePresets {
+		if f := parsePreset(raw); f.Name == name && !f.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// Add adds a configure preset, with a build and a test preset of the same
+// name that use it. It fails if any preset already has the name, unless
+// replace is set.
+func (p *Presets) Add(preset NewPreset, replace bool) error {
+	if preset.Inherits != "" && !p.hasPreset(p.ConfigurePresets, preset.Inherits) {
+		return fmt.Errorf("configure preset %q not found\n  hint: list the presets with 'cpx preset list'", preset.Inherits)
+	}
+	if !replace {
+		for _, info := range p.List() {
+			if info.Name == preset.Name {
+				return fmt.Errorf("%s preset %q already exists\n  hint: use --force to replace it", info.Kind, preset.Name)
+			}
+		}
+	}
+	p.Remove(preset.Name)
+
+	configure := struct {
+		Name           string            `json:"name"`
+		Description    string            `json:"description,omitempty"`
+		Inherits       string            `json:"inherits,omitempty"`
+		Generator      string            `json:"generator,omitempty"`
+		CacheVariables map[string]string `json:"cacheVariables,omitempty"`
+	}{preset.Name, preset.Description, preset.Inherits, preset.Generator, preset.CacheVariables}
+	build := struct {
+		Name            string `json:"name"`
+		ConfigurePreset string `json:"configurePreset"`
+	}{preset.Name, preset.Name}
+	test := struct {
+		Name            string `json:"name"`
+		ConfigurePreset string `json:"configurePreset"`
+		Output          any    `json:"output"`
+	}{preset.Name, preset.Name, map[string]bool{"outputOnFailure": true}}
+
+	for _, add := range []struct {
+		list   *[]json.RawMessage
+		preset any
+	}{{&p.ConfigurePresets, configure}, {&p.BuildPresets, build}, {&p.TestPresets, test}} {
+		data, err := json.Marshal(add.preset)
+		if err != nil {
+			return err
+		}
+		*add.list = append(*add.list, data)
+	}
+	// Build and test presets need version 2
+	if p.Version < 2 {
+		p.Version = 2
+	}
+	return nil
+}
+
+// Remove removes the configure, build and test presets named name, and the
+// build and test presets that use the configure preset. It reports whether
+// it removed any.
+func (p *Presets) Remove(name string) bool {
+	removed := false
+	filter := func(presets []json.RawMessage) []json.RawMessage {
+		kept := presets[:0]
+		for _, raw := range presets {
+			if f := parsePreset(raw); f.Name == name || f.ConfigurePreset == name {
+				removed = true
+				continue
+			}
+			kept = append(kept, raw)
+		}
+		return kept
+	}
+	p.ConfigurePresets = filter(p.ConfigurePresets)
+	p.BuildPresets = filter(p.BuildPresets)
+	p.TestPresets = filter(p.TestPresets)
+	return removed
+}
+
+// Inheritors returns the configure presets that inherit from name
+func (p *Presets) Inheritors(name string) []string {
+	var names []string
+	for _, raw := range p.ConfigurePresets {
+		f := parsePreset(raw)
+		for _, parent := range inheritsOf(f) {
+			if parent == name {
+				names = append(names, f.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *Presets) hasPreset(presets []json.RawMessage, name string) bool {
+	for _, raw := range presets {
+		if parsePreset(raw).Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ActivePreset returns the configure preset cpx configures CMake with:
+// build.preset in cpx.yaml, or "default"
+func ActivePreset() (string, error) {
+	opts, err := ProjectOptions()
+	if err != nil {
+		return "", err
+	}
+	if opts.Preset != "" {
+		return opts.Preset, nil
+	}
+	return DefaultPreset, nil
+}
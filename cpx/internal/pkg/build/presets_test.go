@@ -0,0 +1,63 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PresetsFile)
+	content := `{
+  "$schema": "https://cmake.org/cmake/help/latest/_downloads/3e2d73bff478d88a7de0de736ba5e361/schema.json",
+  "version": 3,
+  "vendor": {"example.com/ide": {"theme": "dark"}},
+  "configurePresets": [
+    {"name": "base", "hidden": true, "generator": "Ninja"},
+    {"name": "default", "inherits": "base", "cacheVariables": {"CMAKE_TOOLCHAIN_FILE": "$env{VCPKG_ROOT}/scripts/buildsystems/vcpkg.cmake"}}
+  ],
+  "buildPresets": [{"name": "default", "configurePreset": "default", "jobs": 4}]
+}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	presets, err := LoadPresets(path)
+	require.NoError(t, err)
+	assert.True(t, presets.HasConfigure("default"))
+	assert.False(t, presets.HasConfigure("base"), "hidden presets can't be built")
+	assert.Equal(t, []string{"default"}, presets.Inheritors("base"))
+
+	require.NoError(t, presets.Add(NewPreset{Name: "asan", Inherits: "default", CacheVariables: map[string]string{"CMAKE_CXX_FLAGS": "-fsanitize=address"}}, false))
+	assert.ErrorContains(t, presets.Add(NewPreset{Name: "asan"}, false), "already exists")
+	assert.ErrorContains(t, presets.Add(NewPreset{Name: "x", Inherits: "missing"}, false), "not found")
+	require.NoError(t, presets.Save(path))
+
+	presets, err = LoadPresets(path)
+	require.NoError(t, err)
+	assert.Equal(t, []PresetInfo{
+		{Kind: PresetConfigure, Name: "base", Hidden: true},
+		{Kind: PresetConfigure, Name: "default", Inherits: []string{"base"}},
+		{Kind: PresetConfigure, Name: "asan", Inherits: []string{"default"}},
+		{Kind: PresetBuild, Name: "default", ConfigurePreset: "default"},
+		{Kind: PresetBuild, Name: "asan", ConfigurePreset: "asan"},
+		{Kind: PresetTest, Name: "asan", ConfigurePreset: "asan"},
+	}, presets.List())
+
+	// The fields cpx doesn't know about survive, in their order
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"theme": "dark"`)
+	assert.Contains(t, string(data), `"name": "default",
+      "configurePreset": "default",
+      "jobs": 4`)
+	assert.Contains(t, string(data), "$env{VCPKG_ROOT}")
+	assert.True(t, strings.HasPrefix(string(data), `{
+  "$schema": "https://cmake.org/`), "unknown top-level keys survive, in their order:\n%s", data)
+
+	assert.True(t, presets.Remove("asan"))
+	assert.False(t, presets.Remove("asan"))
+	assert.Len(t, presets.List(), 3)
+}
@@ -0,0 +1,252 @@
+package build
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+)
+
+// Progress is the state of a build reported by one line of its output
+type Progress struct {
+	Done    int // finished steps; 0 if the tool only reports a percentage
+	Total   int
+	Percent int
+	Target  string // what the build is working on, e.g. the source file being compiled
+}
+
+var (
+	// Ninja (CMake and Meson): [12/80] Building CXX object ...
+	ninjaProgressRe = regexp.MustCompile(`^\[(\d+)/(\d+)\]\s*(.*)$`)
+	// Bazel with --curses=no: [1,234 / 2,000] Compiling src/main.cc; 0s linux-sandbox
+	bazelProgressRe = regexp.MustCompile(`^\[([\d,]+) / ([\d,]+)\]\s*(.*)$`)
+	// CMake's Makefile generator: [ 93%] Building CXX object ...
+	makeProgressRe = regexp.MustCompile(`^\[\s*(\d+)%\]\s*(.*)$`)
+	// The object file of a compile step, e.g. "Compiling C++ object src/app.p/main.cpp.o"
+	objectRe = regexp.MustCompile(`\bobject (\S+)`)
+)
+
+// ParseProgress parses a progress line of a Ninja, Make or Bazel build
+func ParseProgress(line string) (Progress, bool) {
+	var p Progress
+	var description string
+	if m := makeProgressRe.FindStringSubmatch(line); m != nil {
+		p.Percent, _ = strconv.Atoi(m[1])
+		description = m[2]
+	} else {
+		m := ninjaProgressRe.FindStringSubmatch(line)
+		if m == nil {
+			m = bazelProgressRe.FindStringSubmatch(line)
+		}
+		if m == nil {
+			return Progress{}, false
+		}
+		p.Done, _ = strconv.Atoi(strings.ReplaceAll(m[1], ",", ""))
+		p.Total, _ = strconv.Atoi(strings.ReplaceAll(m[2], ",", ""))
+		if p.Total > 0 {
+			p.Percent = p.Done * 100 / p.Total
+		}
+		description = m[3]
+	}
+	p.Target = progressTarget(description)
+	return p, true
+}
+
+// progressTarget shortens the description of a build step to what it works
+// on: the source file of a compile step, the rest without Bazel's timing
+func progressTarget(description string) string {
+	if m := objectRe.FindStringSubmatch(description); m != nil {
+		if unit := translationUnit(m[1]); unit != "" {
+			return unit
+		}
+	}
+	description, _, _ = strings.Cut(description, "; ")
+	return strings.TrimSpace(description)
+}
+
+// InCI reports whether cpx runs in a CI job, which sets CI in the environment
+func InCI() bool {
+	ci := strings.ToLower(os.Getenv("CI"))
+	return ci != "" && ci != "false" && ci != "0"
+}
+
+// ProgressUIEnabled reports whether RunBuildCommand shows the interactive
+// progress view: on a terminal, outside CI, and without --verbose or --quiet
+func ProgressUIEnabled(verbose bool) bool {
+	if verbose || InCI() || !logging.Enabled(logging.LevelNormal) || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// RunBuildCommand runs a build tool. On a terminal it shows a progress bar
+// titled title with the current target and the elapsed time, printing the
+// rest of the output only if the build fails. With verbose, in CI or when
+// the output is not a terminal it streams the output. With --quiet it prints
+// the output only if the build fails.
+func RunBuildCommand(cmd *exec.Cmd, verbose bool, title string) error {
+	if !ProgressUIEnabled(verbose) && (verbose || logging.Enabled(logging.LevelNormal)) {
+		w := &LineWriter{}
+		defer w.Flush()
+		cmd.Stdout = io.MultiWriter(os.Stdout, w)
+		cmd.Stderr = io.MultiWriter(os.Stderr, w)
+		return cmd.Run()
+	}
+
+	var ui *tea.Program
+	uiDone := make(chan struct{})
+	if ProgressUIEnabled(verbose) {
+		ui = tea.NewProgram(newProgressModel(title, time.Now()), tea.WithOutput(os.Stderr), tea.WithInput(nil))
+		go func() {
+			ui.Run()
+			close(uiDone)
+		}()
+	} else {
+		close(uiDone)
+	}
+
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	if err := cmd.Start(); err != nil {
+		if ui != nil {
+			ui.Quit()
+			<-uiDone
+		}
+		return err
+	}
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+		pw.Close()
+	}()
+
+	var nonProgress bytes.Buffer
+	sc := bufio.NewScanner(pr)
+	sc.Buffer(make([]byte, 0, 64*1024), 512*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if p, ok := ParseProgress(line); ok {
+			if ui != nil {
+				ui.Send(progressMsg(p))
+			}
+			events.Progress(p.Percent, p.Target)
+			continue
+		}
+		BuildLine(line)
+		nonProgress.WriteString(line)
+		nonProgress.WriteByte('\n')
+	}
+
+	err := <-waitCh
+	if ui != nil {
+		ui.Send(progressDoneMsg{})
+	}
+	<-uiDone
+
+	if err != nil && nonProgress.Len() > 0 {
+		fmt.Fprintln(os.Stderr, nonProgress.String())
+	}
+	return err
+}
+
+var (
+	progressTitleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00D4FF"))
+	progressDimStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#6B7280"))
+)
+
+type (
+	progressMsg     Progress
+	progressTickMsg time.Time
+	progressDoneMsg struct{}
+)
+
+// progressModel is the bubbletea model of the build progress view
+type progressModel struct {
+	title    string
+	bar      progress.Model
+	progress Progress
+	start    time.Time
+	now      time.Time
+	width    int
+	done     bool
+}
+
+func newProgressModel(title string, start time.Time) progressModel {
+	return progressModel{
+		title: title,
+		bar:   progress.New(progress.WithDefaultGradient(), progress.WithWidth(30)),
+		start: start,
+		now:   start,
+		width: 80,
+	}
+}
+
+func progressTick() tea.Cmd {
+	return tea.Tick(200*time.Millisecond, func(t time.Time) tea.Msg {
+		return progressTickMsg(t)
+	})
+}
+
+func (m progressModel) Init() tea.Cmd {
+	return progressTick()
+}
+
+func (m progressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case progressMsg:
+		m.progress = Progress(msg)
+	case progressTickMsg:
+		m.now = time.Time(msg)
+		return m, progressTick()
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case progressDoneMsg:
+		m.done = true
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// View renders "<title> <bar> <percent> <elapsed> <steps> <target>" on one
+// line, and clears it once the build is done
+func (m progressModel) View() string {
+	if m.done {
+		return ""
+	}
+	line := fmt.Sprintf("%s %s %s", progressTitleStyle.Render(m.title),
+		m.bar.ViewAs(float64(m.progress.Percent)/100), formatElapsed(m.now.Sub(m.start)))
+	if m.progress.Total > 0 {
+		line += progressDimStyle.Render(fmt.Sprintf(" [%d/%d]", m.progress.Done, m.progress.Total))
+	}
+	if target := m.progress.Target; target != "" {
+		// Keep the view on one line
+		if room := m.width - lipgloss.Width(line) - 2; room > 3 {
+			if len(target) > room {
+				target = "…" + target[len(target)-room+1:]
+			}
+			line += " " + progressDimStyle.Render(target)
+		}
+	}
+	return line
+}
+
+// formatElapsed formats d as m:ss
+func formatElapsed(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
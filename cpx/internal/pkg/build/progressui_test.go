@@ -0,0 +1,85 @@
+package build
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProgress(t *testing.T) {
+	tests := []struct {
+		line string
+		want Progress
+	}{
+		{
+			line: "[ 93%] Building CXX object CMakeFiles/app.dir/src/main.cpp.o",
+			want: Progress{Percent: 93, Target: "src/main.cpp"},
+		},
+		{
+			line: "[12/80] Building CXX object CMakeFiles/app.dir/src/util.cpp.o",
+			want: Progress{Done: 12, Total: 80, Percent: 15, Target: "src/util.cpp"},
+		},
+		{
+			line: "[3/4] Compiling C++ object src/app.p/main.cpp.o",
+			want: Progress{Done: 3, Total: 4, Percent: 75, Target: "src/main.cpp"},
+		},
+		{
+			line: "[4/4] Linking CXX executable app",
+			want: Progress{Done: 4, Total: 4, Percent: 100, Target: "Linking CXX executable app"},
+		},
+		{
+			line: "[1,234 / 2,000] Compiling src/main.cc; 3s linux-sandbox",
+			want: Progress{Done: 1234, Total: 2000, Percent: 61, Target: "Compiling src/main.cc"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := ParseProgress(tt.line)
+		assert.True(t, ok, tt.line)
+		assert.Equal(t, tt.want, got, tt.line)
+	}
+
+	for _, line := range []string{"src/main.cpp:1:1: error: oops", "ninja: no work to do.", "[DEBUG] x", ""} {
+		_, ok := ParseProgress(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestInCI(t *testing.T) {
+	t.Setenv("CI", "true")
+	assert.True(t, InCI())
+	assert.False(t, ProgressUIEnabled(false))
+	t.Setenv("CI", "false")
+	assert.False(t, InCI())
+	t.Setenv("CI", "")
+	assert.False(t, InCI())
+	assert.False(t, ProgressUIEnabled(true))
+}
+
+func TestProgressModel(t *testing.T) {
+	start := time.Now()
+	var m tea.Model = newProgressModel("Compiling", start)
+	m, _ = m.Update(progressMsg{Done: 3, Total: 4, Percent: 75, Target: "src/main.cpp"})
+	m, cmd := m.Update(progressTickMsg(start.Add(65 * time.Second)))
+	assert.NotNil(t, cmd)
+
+	view := m.View()
+	assert.Contains(t, view, "Compiling")
+	assert.Contains(t, view, "75%")
+	assert.Contains(t, view, "1:05")
+	assert.Contains(t, view, "[3/4]")
+	assert.Contains(t, view, "src/main.cpp")
+	assert.NotContains(t, view, "\n")
+
+	// The target is shortened to keep the view on one line
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 70})
+	m, _ = m.Update(progressMsg{Percent: 10, Target: strings.Repeat("dir/", 20) + "main.cpp"})
+	assert.Contains(t, m.View(), "…")
+	assert.Contains(t, m.View(), "main.cpp")
+
+	m, cmd = m.Update(progressDoneMsg{})
+	assert.NotNil(t, cmd)
+	assert.Empty(t, m.View())
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 )
 
@@ -62,8 +63,12 @@ func FindExecutables(buildDir string) ([]string, error) {
 	return executables, nil
 }
 
-// RunProject builds and runs the project
-func RunProject(release bool, target string, execArgs []string, verbose bool, optLevel string, sanitizer string, vcpkgClient *vcpkg.Client) error {
+// RunProject builds and runs the project.
+// If perfStat is set, the executable runs under perf stat (or /usr/bin/time on macOS).
+// If debugger is non-empty (see DebuggerPrefix), the executable is launched under it
+// and optimized builds keep debug info. env (KEY=VALUE) is added to the executable's
+// environment.
+func RunProject(release bool, target string, execArgs []string, env []string, dir string, verbose bool, optLevel string, sanitizer string, perfStat bool, debugger []string, vcpkgClient *vcpkg.Client) error {
 	// Set VCPKG_ROOT from cpx config if not already set
 	if err := vcpkgClient.SetupEnv(); err != nil {
 		return err
@@ -82,6 +87,12 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 	cxxFlags += sanCFlags
 	linkerFlags := sanLFlags
 
+	// Optimized builds have no debug info by default
+	withDebugInfo := len(debugger) > 0 && buildType != "Debug" && buildType != "RelWithDebInfo"
+	if withDebugInfo {
+		cxxFlags += " -g"
+	}
+
 	optLabel := "default (-O0)"
 	if release {
 		optLabel = "-O2 (Release)"
@@ -93,7 +104,7 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 		optLabel += "+" + sanitizer
 	}
 
-	fmt.Printf("\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s\n",
+	logging.Info("\n%s▸ Build%s %s %s(%s)%s %s[opt: %s]%s",
 		colorCyan, colorReset, projectName, colorGray, buildType, colorReset,
 		colorGray, optLabel, colorReset)
 
@@ -108,8 +119,14 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 	if sanitizer != "" {
 		outDirName += "-" + sanitizer
 	}
+	if withDebugInfo {
+		outDirName += "-g"
+	}
 	cacheBuildDir := filepath.Join(".cache", "native", outDirName)
 	finalBuildDir := filepath.Join(".bin", "native", outDirName)
+	if err := resetStaleCMakeCache(cacheBuildDir); err != nil {
+		return err
+	}
 	needsConfigure := false
 	if _, err := os.Stat(filepath.Join(cacheBuildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
@@ -125,20 +142,31 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 	if needsConfigure {
 		currentStep++
 		if verbose {
-			fmt.Printf("%s  • Configuring CMake%s\n", colorCyan, colorReset)
+			logging.Step("  • Configuring CMake")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
 		}
 
 		// Determine absolute path for shared vcpkg_installed directory
 		cwd, _ := os.Getwd()
 		vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
+		projectArgs, err := ProjectConfigureArgs(false)
+		if err != nil {
+			printProgress("\n")
+			return err
+		}
 
 		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use "default" preset (VCPKG_ROOT is now set from config)
-			cmdArgs := []string{"--preset=default", "-B", cacheBuildDir, vcpkgInstallArg}
+		if _, err := os.Stat(PresetsFile); err == nil {
+			// Use the project's preset (VCPKG_ROOT is now set from config)
+			preset, err := ActivePreset()
+			if err != nil {
+				printProgress("\n")
+				return err
+			}
+			cmdArgs := []string{"--preset=" + preset, "-DCPX_PRESET=" + preset, "-B", cacheBuildDir, vcpkgInstallArg}
+			cmdArgs = append(cmdArgs, projectArgs...)
 			if cxxFlags != "" {
 				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
 			}
@@ -148,12 +176,13 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 			cmd := exec.Command("cmake", cmdArgs...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
-				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
+				printProgress("\n")
+				return fmt.Errorf("cmake configure failed (preset '%s'): %w", preset, err)
 			}
 		} else {
 			// Fallback to traditional cmake configure
 			cmdArgs := []string{"-B", cacheBuildDir, "-DCMAKE_BUILD_TYPE=" + buildType, vcpkgInstallArg}
+			cmdArgs = append(cmdArgs, projectArgs...)
 			if cxxFlags != "" {
 				cmdArgs = append(cmdArgs, "-DCMAKE_CXX_FLAGS="+cxxFlags, "-DCMAKE_C_FLAGS="+cxxFlags)
 			}
@@ -162,13 +191,13 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 			}
 			cmd := exec.Command("cmake", cmdArgs...)
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
+				printProgress("\n")
 				return fmt.Errorf("cmake configure failed: %w", err)
 			}
 		}
 
 		if !verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
 		}
 	}
 
@@ -238,23 +267,44 @@ func RunProject(release bool, target string, execArgs []string, verbose bool, op
 				execPath = executables[0]
 			} else {
 				// Multiple executables found, list them
-				fmt.Printf("%s Multiple executables found:%s\n", colorGray, colorReset)
+				logging.Info("%s Multiple executables found:%s", colorGray, colorReset)
 				for i, executable := range executables {
-					fmt.Printf("  [%d] %s\n", i+1, filepath.Base(executable))
+					logging.Info("  [%d] %s", i+1, filepath.Base(executable))
 				}
-				fmt.Printf("\nUse --target <name> to specify which one to run\n")
+				logging.Info("\nUse --target <name> to specify which one to run")
 				// Run the first one by default
 				execPath = executables[0]
-				fmt.Printf("%s Running first: %s%s\n", "\033[33m", filepath.Base(execPath), "\033[0m")
+				logging.Notice(" Running first: %s", filepath.Base(execPath))
 			}
 		}
 	}
 
-	fmt.Printf("%s  ✔ Build complete%s %s[%s]%s\n", colorGreen, colorReset, colorGray, time.Since(buildStart).Round(10*time.Millisecond), colorReset)
-	fmt.Printf("%s  ▶ Run%s %s%s%s\n\n", colorCyan, colorReset, colorGreen, filepath.Base(execPath), colorReset)
-	fmt.Println(strings.Repeat("─", 40))
+	logging.Success("  ✔ Build complete %s[%s]", colorGray, time.Since(buildStart).Round(10*time.Millisecond))
+	logging.Step("  ▶ Run%s %s%s", colorReset, colorGreen, filepath.Base(execPath))
+	logging.Info("\n%s", strings.Repeat("─", 40))
 
-	runCmd := exec.Command(execPath, execArgs...)
+	if dir != "" {
+		// The executable path is relative to the project root
+		if execPath, err = filepath.Abs(execPath); err != nil {
+			return err
+		}
+	}
+
+	if perfStat {
+		return RunWithPerfStat(execPath, execArgs, env, dir, exec.Command)
+	}
+
+	name, args := execPath, execArgs
+	if len(debugger) > 0 {
+		name = debugger[0]
+		args = append(append(debugger[1:len(debugger):len(debugger)], execPath), execArgs...)
+	}
+
+	runCmd := exec.Command(name, args...)
+	if len(env) > 0 {
+		runCmd.Env = append(os.Environ(), env...)
+	}
+	runCmd.Dir = dir
 	runCmd.Stdout = os.Stdout
 	runCmd.Stderr = os.Stderr
 	runCmd.Stdin = os.Stdin
@@ -0,0 +1,102 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/logging"
+)
+
+// staleCMakeCache reports why the CMakeCache.txt of buildDir can no longer be
+// reused, or "" if it can (or there is none). CMake refuses to change the
+// source directory, compiler or toolchain of an existing cache, so a stale
+// cache fails mid-build with errors about the cache instead of the change.
+func staleCMakeCache(buildDir string) string {
+	cachePath := filepath.Join(buildDir, "CMakeCache.txt")
+	cacheInfo, err := os.Stat(cachePath)
+	if err != nil {
+		return ""
+	}
+
+	if home, ok := cmakeCacheValue(buildDir, "CMAKE_HOME_DIRECTORY"); ok && home != "" {
+		if cwd, err := os.Getwd(); err == nil && !samePath(home, cwd) {
+			return fmt.Sprintf("project moved from %s", home)
+		}
+	}
+
+	if compiler, ok := cmakeCacheValue(buildDir, "CMAKE_CXX_COMPILER"); ok && compiler != "" {
+		info, err := os.Stat(compiler)
+		if err != nil {
+			return fmt.Sprintf("compiler %s no longer exists", compiler)
+		}
+		if info.ModTime().After(cacheInfo.ModTime()) {
+			return fmt.Sprintf("compiler %s was updated", compiler)
+		}
+		if cxx := os.Getenv("CXX"); cxx != "" {
+			if path, err := exec.LookPath(cxx); err == nil && !samePath(path, compiler) {
+				return fmt.Sprintf("CXX changed to %s", cxx)
+			}
+		}
+	}
+
+	if toolchain, ok := cmakeCacheValue(buildDir, "CMAKE_TOOLCHAIN_FILE"); ok && toolchain != "" {
+		if _, err := os.Stat(toolchain); err != nil {
+			return fmt.Sprintf("toolchain file %s no longer exists", toolchain)
+		}
+		// The vcpkg toolchain lives in the vcpkg root that was configured
+		vcpkgToolchain := filepath.Join("scripts", "buildsystems", "vcpkg.cmake")
+		if root := os.Getenv("VCPKG_ROOT"); root != "" && strings.HasSuffix(filepath.Clean(toolchain), vcpkgToolchain) {
+			if !samePath(filepath.Join(root, vcpkgToolchain), toolchain) {
+				return fmt.Sprintf("vcpkg root changed to %s", root)
+			}
+		}
+	}
+
+	// Presets choose the generator and toolchain, which only apply to a new cache
+	if info, err := os.Stat(PresetsFile); err == nil {
+		if info.ModTime().After(cacheInfo.ModTime()) {
+			return "CMakePresets.json changed"
+		}
+		cached, ok := cmakeCacheValue(buildDir, "CPX_PRESET")
+		if !ok {
+			cached = DefaultPreset
+		}
+		if preset, err := ActivePreset(); err == nil && preset != cached {
+			return fmt.Sprintf("preset changed to %s", preset)
+		}
+	}
+
+	return ""
+}
+
+// samePath reports whether a and b name the same file, resolving symlinks
+func samePath(a, b string) bool {
+	if ai, err := os.Stat(a); err == nil {
+		if bi, err := os.Stat(b); err == nil {
+			return os.SameFile(ai, bi)
+		}
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// resetStaleCMakeCache discards the CMake cache of buildDir if it is stale
+// (see staleCMakeCache) so that the next configure starts fresh, like
+// cmake --fresh. Build outputs are kept.
+func resetStaleCMakeCache(buildDir string) error {
+	reason := staleCMakeCache(buildDir)
+	if reason == "" {
+		return nil
+	}
+
+	logging.Notice("Build directory %s is stale (%s); reconfiguring", buildDir, reason)
+	if err := os.Remove(filepath.Join(buildDir, "CMakeCache.txt")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale CMake cache: %w\n  hint: delete %s and build again", err, buildDir)
+	}
+	if err := os.RemoveAll(filepath.Join(buildDir, "CMakeFiles")); err != nil {
+		return fmt.Errorf("failed to remove stale CMake cache: %w\n  hint: delete %s and build again", err, buildDir)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCMakeCache(t *testing.T, buildDir string, entries map[string]string) {
+	t.Helper()
+	var data string
+	for key, value := range entries {
+		data += key + ":STRING=" + value + "\n"
+	}
+	require.NoError(t, os.MkdirAll(buildDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(buildDir, "CMakeCache.txt"), []byte(data), 0644))
+}
+
+func TestStaleCMakeCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+	t.Setenv("CXX", "")
+	t.Setenv("VCPKG_ROOT", "")
+
+	buildDir := filepath.Join(".cache", "native", "debug")
+	assert.Empty(t, staleCMakeCache(buildDir), "no cache yet")
+
+	compiler := filepath.Join(tmpDir, "c++")
+	require.NoError(t, os.WriteFile(compiler, []byte("#!/bin/sh\n"), 0755))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(compiler, old, old))
+	vcpkgRoot := filepath.Join(tmpDir, "vcpkg")
+	toolchain := filepath.Join(vcpkgRoot, "scripts", "buildsystems", "vcpkg.cmake")
+	require.NoError(t, os.MkdirAll(filepath.Dir(toolchain), 0755))
+	require.NoError(t, os.WriteFile(toolchain, nil, 0644))
+
+	valid := map[string]string{
+		"CMAKE_HOME_DIRECTORY": tmpDir,
+		"CMAKE_CXX_COMPILER":   compiler,
+		"CMAKE_TOOLCHAIN_FILE": toolchain,
+	}
+	writeCMakeCache(t, buildDir, valid)
+	assert.Empty(t, staleCMakeCache(buildDir))
+
+	t.Setenv("VCPKG_ROOT", vcpkgRoot)
+	assert.Empty(t, staleCMakeCache(buildDir))
+	t.Setenv("VCPKG_ROOT", filepath.Join(tmpDir, "other-vcpkg"))
+	assert.Contains(t, staleCMakeCache(buildDir), "vcpkg root changed")
+	t.Setenv("VCPKG_ROOT", "")
+
+	moved := map[string]string{"CMAKE_HOME_DIRECTORY": "/elsewhere/project"}
+	writeCMakeCache(t, buildDir, moved)
+	assert.Contains(t, staleCMakeCache(buildDir), "project moved from /elsewhere/project")
+
+	missing := map[string]string{"CMAKE_CXX_COMPILER": filepath.Join(tmpDir, "g++-11")}
+	writeCMakeCache(t, buildDir, missing)
+	assert.Contains(t, staleCMakeCache(buildDir), "no longer exists")
+
+	// A compiler newer than the cache was updated since configuring
+	writeCMakeCache(t, buildDir, valid)
+	require.NoError(t, os.Chtimes(compiler, time.Now().Add(time.Hour), time.Now().Add(time.Hour)))
+	assert.Contains(t, staleCMakeCache(buildDir), "was updated")
+	require.NoError(t, os.Chtimes(compiler, old, old))
+
+	require.NoError(t, os.WriteFile("CMakePresets.json", []byte("{}"), 0644))
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes("CMakePresets.json", future, future))
+	assert.Equal(t, "CMakePresets.json changed", staleCMakeCache(buildDir))
+
+	// Selecting another preset with cpx preset use
+	require.NoError(t, os.Chtimes("CMakePresets.json", old, old))
+	assert.Empty(t, staleCMakeCache(buildDir))
+	require.NoError(t, os.WriteFile("cpx.yaml", []byte("build:\n  preset: asan\n"), 0644))
+	assert.Equal(t, "preset changed to asan", staleCMakeCache(buildDir))
+	writeCMakeCache(t, buildDir, map[string]string{"CPX_PRESET": "asan"})
+	assert.Empty(t, staleCMakeCache(buildDir))
+}
+
+func TestResetStaleCMakeCache(t *testing.T) {
+	buildDir := t.TempDir()
+	writeCMakeCache(t, buildDir, map[string]string{"CMAKE_HOME_DIRECTORY": "/elsewhere/project"})
+	require.NoError(t, os.MkdirAll(filepath.Join(buildDir, "CMakeFiles"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(buildDir, "app"), nil, 0755))
+
+	require.NoError(t, resetStaleCMakeCache(buildDir))
+	assert.NoFileExists(t, filepath.Join(buildDir, "CMakeCache.txt"))
+	assert.NoDirExists(t, filepath.Join(buildDir, "CMakeFiles"))
+	assert.FileExists(t, filepath.Join(buildDir, "app"), "build outputs are kept")
+}
@@ -0,0 +1,134 @@
+package build
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// StaticName names the build directories of cpx build --static,
+// .cache/static/<variant> and .bin/static/<variant>
+const StaticName = "static"
+
+// staticArches maps Go architectures to their musl triple and vcpkg
+// architecture
+var staticArches = map[string]struct{ triple, vcpkgArch string }{
+	"amd64": {"x86_64-linux-musl", "x64"},
+	"arm64": {"aarch64-linux-musl", "arm64"},
+}
+
+// MuslTriple returns the GNU triple of the musl cross compilers for a Go
+// architecture (e.g. x86_64-linux-musl), or "" if cpx can't build it
+// statically
+func MuslTriple(goarch string) string {
+	return staticArches[goarch].triple
+}
+
+// StaticTriplet returns the vcpkg triplet of static musl builds for a Go
+// architecture, e.g. x64-linux-musl
+func StaticTriplet(goarch string) string {
+	if arch := staticArches[goarch].vcpkgArch; arch != "" {
+		return arch + "-linux-musl"
+	}
+	return ""
+}
+
+// StaticToolchain writes the CMake toolchain file and the vcpkg triplet of a
+// fully static build with the musl compilers cc and cxx to .cache/static,
+// and returns the toolchain building with them
+func StaticToolchain(cc, cxx, goarch string) (CrossToolchain, error) {
+	triplet := StaticTriplet(goarch)
+	if triplet == "" {
+		return CrossToolchain{}, fmt.Errorf("static musl builds are not supported on %s", goarch)
+	}
+	dir, err := filepath.Abs(filepath.Join(".cache", StaticName))
+	if err != nil {
+		return CrossToolchain{}, err
+	}
+	tripletsDir := filepath.Join(dir, "triplets")
+	if err := os.MkdirAll(tripletsDir, 0755); err != nil {
+		return CrossToolchain{}, fmt.Errorf("failed to create %s: %w", tripletsDir, err)
+	}
+
+	toolchainFile := filepath.Join(dir, "toolchain.cmake")
+	toolchain := fmt.Sprintf(`# Fully static build with musl, generated by "cpx build --static"
+set(CMAKE_C_COMPILER %s)
+set(CMAKE_CXX_COMPILER %s)
+set(CMAKE_EXE_LINKER_FLAGS_INIT "-static")
+set(CMAKE_FIND_LIBRARY_SUFFIXES .a)
+set(BUILD_SHARED_LIBS OFF CACHE BOOL "")
+`, cc, cxx)
+	tripletFile := fmt.Sprintf(`# vcpkg triplet of "cpx build --static"
+set(VCPKG_TARGET_ARCHITECTURE %s)
+set(VCPKG_CRT_LINKAGE static)
+set(VCPKG_LIBRARY_LINKAGE static)
+set(VCPKG_CMAKE_SYSTEM_NAME Linux)
+set(VCPKG_CHAINLOAD_TOOLCHAIN_FILE %s)
+`, staticArches[goarch].vcpkgArch, filepath.ToSlash(toolchainFile))
+
+	if err := os.WriteFile(toolchainFile, []byte(toolchain), 0644); err != nil {
+		return CrossToolchain{}, fmt.Errorf("failed to write %s: %w", toolchainFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(tripletsDir, triplet+".cmake"), []byte(tripletFile), 0644); err != nil {
+		return CrossToolchain{}, fmt.Errorf("failed to write the %s triplet: %w", triplet, err)
+	}
+	return CrossToolchain{Name: StaticName, File: toolchainFile, VcpkgTriplet: triplet, TripletsDir: tripletsDir}, nil
+}
+
+// Linkage is how an executable is linked, as reported by file and ldd
+type Linkage struct {
+	Static bool
+	// Libraries are the shared libraries a dynamic executable loads
+	Libraries []string
+	// Detail describes the linkage, e.g. "statically linked"
+	Detail string
+}
+
+var lddLibraryPattern = regexp.MustCompile(`^\s*(\S+\.so[.\d]*)\s`)
+
+// ParseLinkage determines the linkage of an executable from the output of
+// "file -b" and "ldd", either of which may be empty if the tool is missing.
+// It reports false if neither tells.
+func ParseLinkage(fileOutput, lddOutput string) (Linkage, bool) {
+	var linkage Linkage
+	known := false
+	switch {
+	case strings.Contains(fileOutput, "statically linked"), strings.Contains(fileOutput, "static-pie linked"):
+		linkage = Linkage{Static: true, Detail: "statically linked"}
+		known = true
+	case strings.Contains(fileOutput, "dynamically linked"):
+		linkage = Linkage{Detail: "dynamically linked"}
+		known = true
+	}
+
+	switch {
+	case strings.Contains(lddOutput, "not a dynamic executable"), strings.Contains(lddOutput, "statically linked"):
+		if !known {
+			linkage = Linkage{Static: true, Detail: "statically linked"}
+			known = true
+		}
+	case lddOutput != "":
+		seen := make(map[string]bool)
+		for _, line := range strings.Split(lddOutput, "\n") {
+			m := lddLibraryPattern.FindStringSubmatch(line)
+			if m == nil || strings.HasPrefix(m[1], "linux-vdso") || strings.HasPrefix(m[1], "linux-gate") {
+				continue
+			}
+			if name := filepath.Base(m[1]); !seen[name] {
+				seen[name] = true
+				linkage.Libraries = append(linkage.Libraries, name)
+			}
+		}
+		if len(linkage.Libraries) > 0 {
+			// The libraries ldd lists outweigh what file says
+			linkage.Static = false
+			sort.Strings(linkage.Libraries)
+			linkage.Detail = "dynamically linked: " + strings.Join(linkage.Libraries, ", ")
+			known = true
+		}
+	}
+	return linkage, known
+}
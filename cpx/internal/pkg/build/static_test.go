@@ -0,0 +1,89 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticTriplet(t *testing.T) {
+	assert.Equal(t, "x64-linux-musl", StaticTriplet("amd64"))
+	assert.Equal(t, "arm64-linux-musl", StaticTriplet("arm64"))
+	assert.Empty(t, StaticTriplet("riscv64"))
+	assert.Equal(t, "x86_64-linux-musl", MuslTriple("amd64"))
+	assert.Empty(t, MuslTriple("386"))
+}
+
+func TestStaticToolchain(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	_, err = StaticToolchain("gcc", "g++", "riscv64")
+	assert.Error(t, err)
+
+	toolchain, err := StaticToolchain("/opt/musl/bin/x86_64-linux-musl-gcc", "/opt/musl/bin/x86_64-linux-musl-g++", "amd64")
+	require.NoError(t, err)
+	assert.Equal(t, StaticName, toolchain.Name)
+	assert.Equal(t, "x64-linux-musl", toolchain.VcpkgTriplet)
+	assert.Equal(t, filepath.Join(".bin", "static", "release"), toolchain.OutputDir(true, ""))
+
+	data, err := os.ReadFile(toolchain.File)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "set(CMAKE_CXX_COMPILER /opt/musl/bin/x86_64-linux-musl-g++)")
+	assert.Contains(t, string(data), `set(CMAKE_EXE_LINKER_FLAGS_INIT "-static")`)
+
+	data, err = os.ReadFile(filepath.Join(toolchain.TripletsDir, "x64-linux-musl.cmake"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "set(VCPKG_LIBRARY_LINKAGE static)")
+	assert.Contains(t, string(data), "set(VCPKG_CHAINLOAD_TOOLCHAIN_FILE "+filepath.ToSlash(toolchain.File)+")")
+}
+
+func TestParseLinkage(t *testing.T) {
+	tests := []struct {
+		name      string
+		file, ldd string
+		want      Linkage
+	}{
+		{
+			name: "static",
+			file: "ELF 64-bit LSB executable, x86-64, version 1 (SYSV), statically linked, stripped",
+			ldd:  "\tnot a dynamic executable\n",
+			want: Linkage{Static: true, Detail: "statically linked"},
+		},
+		{
+			name: "static-pie, ldd missing",
+			file: "ELF 64-bit LSB pie executable, x86-64, version 1 (SYSV), static-pie linked, stripped",
+			want: Linkage{Static: true, Detail: "statically linked"},
+		},
+		{
+			name: "musl ldd, file missing",
+			ldd:  "/lib/ld-musl-x86_64.so.1: app: Not a valid dynamic program\nstatically linked\n",
+			want: Linkage{Static: true, Detail: "statically linked"},
+		},
+		{
+			name: "dynamic",
+			file: "ELF 64-bit LSB pie executable, x86-64, version 1 (SYSV), dynamically linked, interpreter /lib64/ld-linux-x86-64.so.2",
+			ldd: "\tlinux-vdso.so.1 (0x00007ffd)\n" +
+				"\tlibstdc++.so.6 => /lib/x86_64-linux-gnu/libstdc++.so.6 (0x00007f1)\n" +
+				"\tlibc.so.6 => /lib/x86_64-linux-gnu/libc.so.6 (0x00007f2)\n" +
+				"\t/lib64/ld-linux-x86-64.so.2 (0x00007f3)\n",
+			want: Linkage{Libraries: []string{"ld-linux-x86-64.so.2", "libc.so.6", "libstdc++.so.6"}, Detail: "dynamically linked: ld-linux-x86-64.so.2, libc.so.6, libstdc++.so.6"},
+		},
+		{
+			name: "unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			linkage, known := ParseLinkage(tt.file, tt.ldd)
+			assert.Equal(t, tt.name != "unknown", known)
+			assert.Equal(t, tt.want, linkage)
+		})
+	}
+}
@@ -5,12 +5,22 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/events"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 )
 
-// RunTests runs the project tests
-func RunTests(verbose bool, filter string, vcpkgClient *vcpkg.Client) error {
+// RunTests runs the project tests.
+// filter is translated to the detected framework's syntax (see TestFilterArgs),
+// falling back to a ctest -R regex. If reportPath is non-empty, a JUnit XML
+// report is written to that path. If wrapper is non-empty (e.g. valgrind or a
+// debugger) or testArgs are given, the test executable is run directly
+// instead of through ctest.
+func RunTests(verbose bool, filter string, reportPath string, wrapper []string, testArgs []string, vcpkgClient *vcpkg.Client) error {
 	// Set VCPKG_ROOT from cpx config if not already set
 	if err := vcpkgClient.SetupEnv(); err != nil {
 		return err
@@ -20,13 +30,16 @@ func RunTests(verbose bool, filter string, vcpkgClient *vcpkg.Client) error {
 	if projectName == "" {
 		return fmt.Errorf("failed to get project name from CMakeLists.txt")
 	}
-	fmt.Printf("%s Running tests for '%s'...%s\n", "\033[36m", projectName, "\033[0m")
+	logging.Step(" Running tests for '%s'...", projectName)
 
 	// Default to debug for tests if no config specified
 	// Use .cache/native/debug for building tests
 	buildDir := filepath.Join(".cache", "native", "debug")
 
 	// Check if configure is needed
+	if err := resetStaleCMakeCache(buildDir); err != nil {
+		return err
+	}
 	needsConfigure := false
 	if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 		needsConfigure = true
@@ -43,36 +56,46 @@ func RunTests(verbose bool, filter string, vcpkgClient *vcpkg.Client) error {
 	if needsConfigure {
 		currentStep++
 		if verbose {
-			fmt.Printf("%s  Configuring CMake...%s\n", "\033[36m", "\033[0m")
+			logging.Step("  Configuring CMake...")
 		} else {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configuring...", colorCyan, currentStep, totalSteps, colorReset)
 		}
 
 		// Determine absolute path for shared vcpkg_installed directory
 		cwd, _ := os.Getwd()
 		vcpkgInstalledDir := filepath.Join(cwd, ".cache", "native", "vcpkg_installed")
 		vcpkgInstallArg := "-DVCPKG_INSTALLED_DIR=" + vcpkgInstalledDir
+		projectArgs, err := ProjectConfigureArgs(false)
+		if err != nil {
+			printProgress("\n")
+			return err
+		}
 
 		// Check if CMakePresets.json exists, use preset if available
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use "default" preset (VCPKG_ROOT is now set from config)
-			cmd := exec.Command("cmake", "--preset=default", "-B", buildDir, vcpkgInstallArg)
+		if _, err := os.Stat(PresetsFile); err == nil {
+			// Use the project's preset (VCPKG_ROOT is now set from config)
+			preset, err := ActivePreset()
+			if err != nil {
+				printProgress("\n")
+				return err
+			}
+			cmd := exec.Command("cmake", append([]string{"--preset=" + preset, "-DCPX_PRESET=" + preset, "-B", buildDir, vcpkgInstallArg}, projectArgs...)...)
 			cmd.Env = os.Environ()
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
-				return fmt.Errorf("cmake configure failed (preset 'default'): %w", err)
+				printProgress("\n")
+				return exitcode.Errorf(exitcode.BuildFailed, "cmake configure failed (preset '%s'): %w", preset, err)
 			}
 		} else {
 			// Fallback to traditional cmake configure
-			cmd := exec.Command("cmake", "-B", buildDir, vcpkgInstallArg)
+			cmd := exec.Command("cmake", append([]string{"-B", buildDir, vcpkgInstallArg}, projectArgs...)...)
 			if err := runCMakeConfigure(cmd, verbose); err != nil {
-				fmt.Println()
-				return fmt.Errorf("cmake configure failed: %w", err)
+				printProgress("\n")
+				return exitcode.Errorf(exitcode.BuildFailed, "cmake configure failed: %w", err)
 			}
 		}
 
 		if !verbose {
-			fmt.Printf("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
+			printProgress("\r\033[2K%s[%d/%d]%s Configured ✓\n", colorCyan, currentStep, totalSteps, colorReset)
 		}
 	}
 
@@ -80,17 +103,38 @@ func RunTests(verbose bool, filter string, vcpkgClient *vcpkg.Client) error {
 	currentStep++
 	buildArgs := []string{"--build", buildDir, "--target", projectName + "_tests"}
 	if err := runCMakeBuild(buildArgs, verbose, currentStep, totalSteps); err != nil {
-		return fmt.Errorf("failed to build tests: %w", err)
+		return exitcode.Errorf(exitcode.BuildFailed, "failed to build tests: %w", err)
 	}
 
-	// Run tests with CTest
+	// Run tests
 	currentStep++
+	events.Phase("test")
 	if !verbose {
-		fmt.Printf("%s[%d/%d]%s Running tests...\n", colorCyan, currentStep, totalSteps, colorReset)
+		printProgress("%s[%d/%d]%s Running tests...\n", colorCyan, currentStep, totalSteps, colorReset)
+	} else {
+		logging.Step(" Running tests...")
+	}
+
+	// ctest -R can only select whole test binaries for frameworks that
+	// don't register individual cases, so run the binary with the
+	// framework's own filter syntax when we know it
+	framework := DetectTestFramework(".")
+	var runErr error
+	if len(wrapper) > 0 || len(testArgs) > 0 || (filter != "" && framework != "") {
+		runErr = runTestBinary(buildDir, projectName, framework, filter, reportPath, wrapper, testArgs)
 	} else {
-		fmt.Printf("%s Running tests...%s\n", "\033[36m", "\033[0m")
+		runErr = runCTest(buildDir, verbose, filter, reportPath)
+	}
+	if runErr != nil {
+		return exitcode.Errorf(exitcode.TestFailed, "tests failed: %w", runErr)
 	}
 
+	logging.Success(" All tests passed!")
+	return nil
+}
+
+// runCTest runs all registered tests through ctest, using filter as a -R regex
+func runCTest(buildDir string, verbose bool, filter, reportPath string) error {
 	ctestArgs := []string{"--test-dir", buildDir}
 
 	if verbose {
@@ -103,14 +147,86 @@ func RunTests(verbose bool, filter string, vcpkgClient *vcpkg.Client) error {
 		ctestArgs = append(ctestArgs, "--output-on-failure")
 	}
 
+	absReport, err := prepareReportPath(reportPath)
+	if err != nil {
+		return err
+	}
+	if absReport != "" {
+		// ctest resolves --output-junit relative to the test dir, so pass an absolute path
+		ctestArgs = append(ctestArgs, "--output-junit", absReport)
+	}
+
 	ctestCmd := exec.Command("ctest", ctestArgs...)
 	ctestCmd.Stdout = os.Stdout
 	ctestCmd.Stderr = os.Stderr
 
-	if err := ctestCmd.Run(); err != nil {
-		return fmt.Errorf("tests failed: %w", err)
+	runErr := ctestCmd.Run()
+	if reportPath != "" {
+		logging.Info("%s JUnit report written to %s%s", colorGray, reportPath, colorReset)
 	}
+	return runErr
+}
 
-	fmt.Printf("%s All tests passed!%s\n", "\033[32m", "\033[0m")
-	return nil
+// runTestBinary runs the <project>_tests executable directly with the
+// framework-specific filter and report arguments followed by testArgs,
+// optionally under wrapper
+func runTestBinary(buildDir, projectName, framework, filter, reportPath string, wrapper, testArgs []string) error {
+	exeName := projectName + "_tests"
+	if runtime.GOOS == "windows" {
+		exeName += ".exe"
+	}
+
+	exePath := filepath.Join(buildDir, "tests", exeName)
+	if _, err := os.Stat(exePath); os.IsNotExist(err) {
+		exePath = filepath.Join(buildDir, exeName)
+		if _, err := os.Stat(exePath); os.IsNotExist(err) {
+			return fmt.Errorf("test executable %s not found in %s", exeName, buildDir)
+		}
+	}
+
+	absReport, err := prepareReportPath(reportPath)
+	if err != nil {
+		return err
+	}
+
+	args := TestFilterArgs(framework, filter)
+	args = append(args, TestReportArgs(framework, absReport)...)
+
+	if len(args) > 0 {
+		logging.Info("%s Filtering %s tests: %s%s", colorGray, framework, strings.Join(args, " "), colorReset)
+	}
+	args = append(args, testArgs...)
+
+	name := exePath
+	if len(wrapper) > 0 {
+		name = wrapper[0]
+		args = append(append(wrapper[1:len(wrapper):len(wrapper)], exePath), args...)
+	}
+
+	testCmd := exec.Command(name, args...)
+	testCmd.Stdout = os.Stdout
+	testCmd.Stderr = os.Stderr
+	testCmd.Stdin = os.Stdin
+
+	runErr := testCmd.Run()
+	if reportPath != "" {
+		logging.Info("%s JUnit report written to %s%s", colorGray, reportPath, colorReset)
+	}
+	return runErr
+}
+
+// prepareReportPath makes reportPath absolute and creates its directory.
+// Returns "" if no report was requested.
+func prepareReportPath(reportPath string) (string, error) {
+	if reportPath == "" {
+		return "", nil
+	}
+	absReport, err := filepath.Abs(reportPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve report path: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(absReport), 0755); err != nil {
+		return "", fmt.Errorf("failed to create report directory: %w", err)
+	}
+	return absReport, nil
 }
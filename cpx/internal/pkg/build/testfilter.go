@@ -0,0 +1,80 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Test framework identifiers, matching the values used by `cpx new`
+const (
+	TestFrameworkGoogleTest = "googletest"
+	TestFrameworkCatch2     = "catch2"
+	TestFrameworkDoctest    = "doctest"
+)
+
+// DetectTestFramework guesses the test framework used by the project in dir
+// by looking at the test build files and sources. Returns "" if unknown.
+func DetectTestFramework(dir string) string {
+	candidates := []string{
+		filepath.Join(dir, "tests", "CMakeLists.txt"),
+		filepath.Join(dir, "tests", "BUILD.bazel"),
+		filepath.Join(dir, "tests", "meson.build"),
+	}
+	sources, _ := filepath.Glob(filepath.Join(dir, "tests", "*.cpp"))
+	candidates = append(candidates, sources...)
+
+	for _, path := range candidates {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		content := strings.ToLower(string(data))
+		switch {
+		case strings.Contains(content, "gtest"), strings.Contains(content, "googletest"):
+			return TestFrameworkGoogleTest
+		case strings.Contains(content, "catch2"):
+			return TestFrameworkCatch2
+		case strings.Contains(content, "doctest"):
+			return TestFrameworkDoctest
+		}
+	}
+	return ""
+}
+
+// TestFilterArgs returns the arguments that make a test binary of the given
+// framework run only the tests matching filter
+func TestFilterArgs(framework, filter string) []string {
+	if filter == "" {
+		return nil
+	}
+	switch framework {
+	case TestFrameworkGoogleTest:
+		return []string{"--gtest_filter=" + filter}
+	case TestFrameworkCatch2:
+		// Catch2 takes test specs positionally: names, wildcards or [tags]
+		return []string{filter}
+	case TestFrameworkDoctest:
+		return []string{"--test-case=" + filter}
+	default:
+		return nil
+	}
+}
+
+// TestReportArgs returns the arguments that make a test binary of the given
+// framework write a JUnit XML report to path
+func TestReportArgs(framework, path string) []string {
+	if path == "" {
+		return nil
+	}
+	switch framework {
+	case TestFrameworkGoogleTest:
+		return []string{"--gtest_output=xml:" + path}
+	case TestFrameworkCatch2:
+		return []string{"--reporter", "console", "--reporter", "JUnit::out=" + path}
+	case TestFrameworkDoctest:
+		return []string{"--reporters=junit", "--out=" + path}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTestFramework(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "googletest via CMake",
+			file:     "CMakeLists.txt",
+			content:  "FetchContent_Declare(googletest)\ngtest_discover_tests(app_tests)\n",
+			expected: TestFrameworkGoogleTest,
+		},
+		{
+			name:     "Catch2 via Bazel",
+			file:     "BUILD.bazel",
+			content:  `deps = ["@catch2//:catch2_main"]`,
+			expected: TestFrameworkCatch2,
+		},
+		{
+			name:     "doctest via source",
+			file:     "test_main.cpp",
+			content:  "#include <doctest/doctest.h>\n",
+			expected: TestFrameworkDoctest,
+		},
+		{
+			name:     "No framework",
+			file:     "test_main.cpp",
+			content:  "int main() { return 0; }\n",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			testsDir := filepath.Join(tmpDir, "tests")
+			require.NoError(t, os.MkdirAll(testsDir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(testsDir, tt.file), []byte(tt.content), 0644))
+
+			assert.Equal(t, tt.expected, DetectTestFramework(tmpDir))
+		})
+	}
+}
+
+func TestTestFilterArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		framework string
+		filter    string
+		expected  []string
+	}{
+		{"googletest", TestFrameworkGoogleTest, "MySuite.*", []string{"--gtest_filter=MySuite.*"}},
+		{"Catch2 tag", TestFrameworkCatch2, "[fast]", []string{"[fast]"}},
+		{"doctest", TestFrameworkDoctest, "*vector*", []string{"--test-case=*vector*"}},
+		{"Unknown framework", "", "MySuite", nil},
+		{"Empty filter", TestFrameworkGoogleTest, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TestFilterArgs(tt.framework, tt.filter))
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package build
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimingsHTMLFile is the timeline written by cpx build --timings-html, inside
+// the build directory
+const TimingsHTMLFile = "timings.html"
+
+// NinjaStep is one command of a ninja build, as recorded in .ninja_log
+type NinjaStep struct {
+	Output string
+	Start  time.Duration
+	End    time.Duration
+}
+
+// Duration returns how long the step took
+func (s NinjaStep) Duration() time.Duration {
+	return s.End - s.Start
+}
+
+// ParseNinjaLog returns the steps of the last build recorded in the
+// .ninja_log at path. Ninja appends every build to the log with times
+// relative to the start of that build, so a step ending before the previous
+// one starts a new build.
+func ParseNinjaLog(path string) ([]NinjaStep, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var steps []NinjaStep
+	var lastEnd time.Duration
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		// start end mtime output hash
+		fields := strings.Split(line, "\t")
+		if len(fields) < 4 {
+			continue
+		}
+		start, err1 := strconv.ParseInt(fields[0], 10, 64)
+		end, err2 := strconv.ParseInt(fields[1], 10, 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("failed to parse %s: invalid line %q", path, line)
+		}
+
+		step := NinjaStep{
+			Output: fields[3],
+			Start:  time.Duration(start) * time.Millisecond,
+			End:    time.Duration(end) * time.Millisecond,
+		}
+		if step.End < lastEnd {
+			steps = nil
+		}
+		lastEnd = step.End
+		steps = append(steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return steps, nil
+}
+
+// BuildTimings summarizes the steps of a build
+type BuildTimings struct {
+	Steps []NinjaStep
+	// Wall is the elapsed time from the first step to the last one
+	Wall time.Duration
+	// CPU is the sum of the durations of all steps
+	CPU time.Duration
+}
+
+// NewBuildTimings summarizes steps
+func NewBuildTimings(steps []NinjaStep) *BuildTimings {
+	t := &BuildTimings{Steps: steps}
+	if len(steps) == 0 {
+		return t
+	}
+	first, last := steps[0].Start, steps[0].End
+	for _, step := range steps {
+		t.CPU += step.Duration()
+		if step.Start < first {
+			first = step.Start
+		}
+		if step.End > last {
+			last = step.End
+		}
+	}
+	t.Wall = last - first
+	return t
+}
+
+// SlowestUnits returns up to n compile steps, slowest first
+func (t *BuildTimings) SlowestUnits(n int) []NinjaStep {
+	var units []NinjaStep
+	for _, step := range t.Steps {
+		if translationUnit(step.Output) != "" {
+			units = append(units, step)
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool {
+		return units[i].Duration() > units[j].Duration()
+	})
+	if len(units) > n {
+		units = units[:n]
+	}
+	return units
+}
+
+// translationUnit returns the source file an object file output was compiled
+// from, or "" if output is not an object file. CMake writes objects to
+// CMakeFiles/<target>.dir/<source>.o, Meson to <dir>/<target>.p/<source>.o.
+func translationUnit(output string) string {
+	source := strings.TrimSuffix(strings.TrimSuffix(output, ".o"), ".obj")
+	if source == output {
+		return ""
+	}
+	switch filepath.Ext(source) {
+	case ".c", ".cc", ".cpp", ".cxx", ".c++", ".cu", ".m", ".mm":
+	default:
+		return ""
+	}
+
+	parts := strings.Split(filepath.ToSlash(source), "/")
+	for i, part := range parts {
+		if part == "CMakeFiles" && i+1 < len(parts) && strings.HasSuffix(parts[i+1], ".dir") {
+			return strings.Join(parts[i+2:], "/")
+		}
+		if strings.HasSuffix(part, ".p") {
+			return strings.Join(append(append([]string{}, parts[:i]...), parts[i+1:]...), "/")
+		}
+	}
+	return source
+}
+
+// ReportTimings prints the wall and CPU time of the last ninja build in
+// buildDir and its top slowest translation units. If htmlPath is set, it also
+// writes a timeline of the build there.
+func ReportTimings(buildDir string, top int, htmlPath string) error {
+	logPath := filepath.Join(buildDir, ".ninja_log")
+	steps, err := ParseNinjaLog(logPath)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("%s not found\n  hint: build timings need the Ninja generator (e.g. export CMAKE_GENERATOR=Ninja, then cpx build --clean)", logPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	timings := NewBuildTimings(steps)
+	fmt.Printf("\n%s▸ Build timings%s %s(%s)%s\n", colorCyan, colorReset, colorGray, logPath, colorReset)
+	if len(steps) == 0 {
+		fmt.Printf("  No build steps recorded\n")
+		return nil
+	}
+
+	parallelism := 0.0
+	if timings.Wall > 0 {
+		parallelism = timings.CPU.Seconds() / timings.Wall.Seconds()
+	}
+	fmt.Printf("  Wall %s  CPU %s  %s(%d steps, %.1fx parallel)%s\n",
+		formatSeconds(timings.Wall), formatSeconds(timings.CPU), colorGray, len(steps), parallelism, colorReset)
+
+	if units := timings.SlowestUnits(top); len(units) > 0 {
+		fmt.Printf("  Slowest translation units:\n")
+		for _, unit := range units {
+			fmt.Printf("  %8s  %s\n", formatSeconds(unit.Duration()), translationUnit(unit.Output))
+		}
+	}
+
+	if htmlPath != "" {
+		if err := WriteTimingsHTML(htmlPath, timings); err != nil {
+			return err
+		}
+		fmt.Printf("  %sTimeline written to %s%s\n", colorGray, htmlPath, colorReset)
+	}
+	return nil
+}
+
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// timelineLanes assigns steps to the fewest lanes in which no two steps
+// overlap, approximating the parallel jobs of the build
+func timelineLanes(steps []NinjaStep) [][]NinjaStep {
+	sorted := append([]NinjaStep{}, steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var lanes [][]NinjaStep
+	for _, step := range sorted {
+		placed := false
+		for i, lane := range lanes {
+			if lane[len(lane)-1].End <= step.Start {
+				lanes[i] = append(lane, step)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			lanes = append(lanes, []NinjaStep{step})
+		}
+	}
+	return lanes
+}
+
+// WriteTimingsHTML writes a self-contained HTML timeline of timings to path,
+// one row per parallel job
+func WriteTimingsHTML(path string, timings *BuildTimings) error {
+	var b strings.Builder
+	b.WriteString(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>cpx build timings</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+.lane { position: relative; height: 22px; margin: 2px 0; background: #f4f4f4; }
+.step { position: absolute; height: 100%; box-sizing: border-box; border: 1px solid #fff;
+  background: #e8804c; overflow: hidden; white-space: nowrap; font-size: 11px; line-height: 20px; }
+.step.other { background: #7aa6d8; }
+</style></head><body>
+`)
+	fmt.Fprintf(&b, "<h1>Build timings</h1>\n<p>Wall %s, CPU %s, %d steps</p>\n",
+		formatSeconds(timings.Wall), formatSeconds(timings.CPU), len(timings.Steps))
+
+	var first time.Duration
+	if len(timings.Steps) > 0 {
+		first = timings.Steps[0].Start
+		for _, step := range timings.Steps {
+			if step.Start < first {
+				first = step.Start
+			}
+		}
+	}
+	wall := timings.Wall
+	if wall <= 0 {
+		wall = time.Millisecond
+	}
+
+	for _, lane := range timelineLanes(timings.Steps) {
+		b.WriteString(`<div class="lane">`)
+		for _, step := range lane {
+			class := "step"
+			label := translationUnit(step.Output)
+			if label == "" {
+				class += " other"
+				label = step.Output
+			}
+			left := float64(step.Start-first) / float64(wall) * 100
+			width := float64(step.Duration()) / float64(wall) * 100
+			fmt.Fprintf(&b, `<div class="%s" style="left:%.3f%%;width:%.3f%%" title="%s (%s)">%s</div>`,
+				class, left, width, html.EscapeString(label), formatSeconds(step.Duration()), html.EscapeString(label))
+		}
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</body></html>\n")
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package build
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Ninja logs steps as they finish, with times relative to the start of each build
+const ninjaLog = `# ninja log v5
+0	900	0	CMakeFiles/app.dir/src/old.cpp.o	aaa
+900	1000	0	app	bbb
+20	800	0	CMakeFiles/app.dir/src/util.cpp.o	eee
+0	1500	0	CMakeFiles/app.dir/src/main.cpp.o	ccc
+10	2500	0	CMakeFiles/app.dir/src/parser.cpp.o	ddd
+2500	3000	0	app	fff
+`
+
+func TestParseNinjaLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".ninja_log")
+	require.NoError(t, os.WriteFile(path, []byte(ninjaLog), 0644))
+
+	steps, err := ParseNinjaLog(path)
+	require.NoError(t, err)
+	// Only the last build (restarting at 0) is kept
+	require.Len(t, steps, 4)
+	assert.Equal(t, "CMakeFiles/app.dir/src/util.cpp.o", steps[0].Output)
+	assert.Equal(t, 2490*time.Millisecond, steps[2].Duration())
+
+	timings := NewBuildTimings(steps)
+	assert.Equal(t, 3*time.Second, timings.Wall)
+	assert.Equal(t, (1500+2490+780+500)*time.Millisecond, timings.CPU)
+
+	units := timings.SlowestUnits(2)
+	require.Len(t, units, 2)
+	assert.Equal(t, "CMakeFiles/app.dir/src/parser.cpp.o", units[0].Output)
+	assert.Equal(t, "CMakeFiles/app.dir/src/main.cpp.o", units[1].Output)
+
+	_, err = ParseNinjaLog(filepath.Join(t.TempDir(), ".ninja_log"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTranslationUnit(t *testing.T) {
+	assert.Equal(t, "src/main.cpp", translationUnit("CMakeFiles/app.dir/src/main.cpp.o"))
+	assert.Equal(t, "a.cc", translationUnit("src/lib/CMakeFiles/core.dir/a.cc.o"))
+	assert.Equal(t, "src/main.cpp", translationUnit("src/app.p/main.cpp.o"))
+	assert.Equal(t, "kernel.cu", translationUnit("CMakeFiles/app.dir/kernel.cu.obj"))
+	assert.Empty(t, translationUnit("app"))
+	assert.Empty(t, translationUnit("libcore.a"))
+}
+
+func TestTimelineLanes(t *testing.T) {
+	steps := []NinjaStep{
+		{Output: "a", Start: 0, End: 10},
+		{Output: "b", Start: 0, End: 5},
+		{Output: "c", Start: 5, End: 8},
+		{Output: "d", Start: 10, End: 12},
+	}
+	lanes := timelineLanes(steps)
+	require.Len(t, lanes, 2)
+	assert.Equal(t, []string{"a", "d"}, []string{lanes[0][0].Output, lanes[0][1].Output})
+	assert.Equal(t, []string{"b", "c"}, []string{lanes[1][0].Output, lanes[1][1].Output})
+}
+
+func TestReportTimings(t *testing.T) {
+	buildDir := t.TempDir()
+	err := ReportTimings(buildDir, 10, "")
+	assert.ErrorContains(t, err, "Ninja generator")
+
+	require.NoError(t, os.WriteFile(filepath.Join(buildDir, ".ninja_log"), []byte(ninjaLog), 0644))
+	htmlPath := filepath.Join(buildDir, TimingsHTMLFile)
+	require.NoError(t, ReportTimings(buildDir, 10, htmlPath))
+
+	data, err := os.ReadFile(htmlPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "src/parser.cpp")
+	assert.Contains(t, string(data), "Wall 3.0s")
+}
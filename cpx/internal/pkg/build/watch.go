@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ozacod/cpx/internal/pkg/logging"
 	"github.com/ozacod/cpx/internal/pkg/vcpkg"
 )
 
@@ -14,7 +15,9 @@ import (
 type WatchConfig struct {
 	Directories []string
 	Extensions  []string
-	IgnoreDirs  []string
+	Names       []string // file names watched regardless of extension (e.g. meson.build)
+	Root        string   // project root, where Names are also watched without recursing
+	IgnoreDirs  []string // directory names or glob patterns (e.g. bazel-*)
 	Debounce    time.Duration
 }
 
@@ -22,8 +25,9 @@ type WatchConfig struct {
 func DefaultWatchConfig() *WatchConfig {
 	return &WatchConfig{
 		Directories: []string{"src", "include", "tests"},
+		Root:        ".",
 		Extensions:  []string{".cpp", ".hpp", ".c", ".h", ".cc", ".cxx", ".hxx"},
-		IgnoreDirs:  []string{"build", ".git", ".vcpkg", "vcpkg_installed", "out"},
+		IgnoreDirs:  []string{"build", "builddir", "bazel-*", ".git", ".vcpkg", "vcpkg_installed", "out"},
 		Debounce:    500 * time.Millisecond,
 	}
 }
@@ -47,21 +51,14 @@ func TakeSnapshot(config *WatchConfig) (FileSnapshot, error) {
 
 			// Skip ignored directories
 			if info.IsDir() {
-				for _, ignoreDir := range config.IgnoreDirs {
-					if info.Name() == ignoreDir || strings.Contains(path, string(filepath.Separator)+ignoreDir+string(filepath.Separator)) {
-						return filepath.SkipDir
-					}
+				if path != dir && config.isIgnoredDir(info.Name()) {
+					return filepath.SkipDir
 				}
 				return nil
 			}
 
-			// Check if file matches watched extensions
-			ext := strings.ToLower(filepath.Ext(path))
-			for _, watchExt := range config.Extensions {
-				if ext == watchExt {
-					snapshot[path] = info.ModTime()
-					break
-				}
+			if config.isWatchedFile(path) {
+				snapshot[path] = info.ModTime()
 			}
 
 			return nil
@@ -72,9 +69,48 @@ func TakeSnapshot(config *WatchConfig) (FileSnapshot, error) {
 		}
 	}
 
+	if config.Root != "" {
+		for _, name := range config.Names {
+			path := filepath.Join(config.Root, name)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				snapshot[path] = info.ModTime()
+			}
+		}
+	}
+
 	return snapshot, nil
 }
 
+// isIgnoredDir reports whether a directory name matches IgnoreDirs
+func (c *WatchConfig) isIgnoredDir(name string) bool {
+	for _, pattern := range c.IgnoreDirs {
+		if name == pattern {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isWatchedFile reports whether path matches Extensions or Names
+func (c *WatchConfig) isWatchedFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, watchExt := range c.Extensions {
+		if ext == watchExt {
+			return true
+		}
+	}
+	base := filepath.Base(path)
+	for _, name := range c.Names {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
 // DetectChanges compares two snapshots and returns changed files
 func DetectChanges(old, new FileSnapshot) []string {
 	var changed []string
@@ -96,20 +132,9 @@ func DetectChanges(old, new FileSnapshot) []string {
 	return changed
 }
 
-// WatchAndBuild watches for file changes and triggers rebuilds
-func WatchAndBuild(release bool, jobs int, target string, optLevel string, verbose bool, sanitizer string, vcpkgClient *vcpkg.Client) error {
-	config := DefaultWatchConfig()
-
-	fmt.Printf("\033[36m👀 Watching for changes in: %s\033[0m\n", strings.Join(config.Directories, ", "))
-	fmt.Printf("\033[36m   Extensions: %s\033[0m\n", strings.Join(config.Extensions, ", "))
-	fmt.Printf("\033[33m   Press Ctrl+C to stop\033[0m\n\n")
-
-	// Initial build
-	fmt.Printf("\033[36m🔨 Initial build...\033[0m\n")
-	if err := BuildProject(release, jobs, target, false, optLevel, verbose, sanitizer, vcpkgClient); err != nil {
-		fmt.Printf("\033[31m✗ Build failed: %v\033[0m\n", err)
-	}
-
+// WatchLoop polls the watched files every config.Debounce and calls onChange
+// with the changed paths. It only returns if the initial snapshot fails.
+func WatchLoop(config *WatchConfig, onChange func(changes []string)) error {
 	// Take initial snapshot
 	snapshot, err := TakeSnapshot(config)
 	if err != nil {
@@ -123,27 +148,103 @@ func WatchAndBuild(release bool, jobs int, target string, optLevel string, verbo
 	for range ticker.C {
 		newSnapshot, err := TakeSnapshot(config)
 		if err != nil {
-			fmt.Printf("\033[33m⚠ Failed to check for changes: %v\033[0m\n", err)
+			logging.Warn("failed to check for changes: %v", err)
 			continue
 		}
 
 		changes := DetectChanges(snapshot, newSnapshot)
 		if len(changes) > 0 {
-			fmt.Printf("\n\033[36m📝 Changes detected:\033[0m\n")
+			logging.Step("\n📝 Changes detected:")
 			for _, change := range changes {
-				fmt.Printf("   %s\n", change)
+				logging.Info("   %s", change)
 			}
-			fmt.Printf("\n\033[36m🔨 Rebuilding...\033[0m\n")
-
-			if err := BuildProject(release, jobs, target, false, optLevel, verbose, sanitizer, vcpkgClient); err != nil {
-				fmt.Printf("\033[31m✗ Build failed: %v\033[0m\n", err)
-			} else {
-				fmt.Printf("\033[32m✓ Build succeeded\033[0m\n")
-			}
-
+			onChange(changes)
 			snapshot = newSnapshot
 		}
 	}
 
 	return nil
 }
+
+// printWatchBanner prints the watched directories and extensions
+func printWatchBanner(config *WatchConfig) {
+	logging.Step("👀 Watching for changes in: %s", strings.Join(config.Directories, ", "))
+	logging.Step("   Extensions: %s", strings.Join(config.Extensions, ", "))
+	if len(config.Names) > 0 {
+		logging.Step("   Build files: %s (also in the project root)", strings.Join(config.Names, ", "))
+	}
+	logging.Notice("   Press Ctrl+C to stop\n")
+}
+
+// WatchAndBuild watches for file changes and triggers rebuilds
+func WatchAndBuild(release bool, jobs int, target string, optLevel string, verbose bool, sanitizer string, unity bool, compiler Compiler, cross CrossToolchain, vcpkgClient *vcpkg.Client) error {
+	return WatchAndRebuild(DefaultWatchConfig(), func() error {
+		return BuildProject(release, jobs, target, false, optLevel, verbose, sanitizer, unity, compiler, cross, vcpkgClient)
+	})
+}
+
+// WatchAndRebuild runs rebuild once up front and again after every change.
+// It is the build-system independent core of WatchAndBuild, used directly
+// for Bazel and Meson projects.
+func WatchAndRebuild(config *WatchConfig, rebuild func() error) error {
+	printWatchBanner(config)
+
+	// Initial build
+	logging.Step("🔨 Initial build...")
+	if err := rebuild(); err != nil {
+		logging.Error("Build failed: %v", err)
+	}
+
+	return WatchLoop(config, func(changes []string) {
+		logging.Step("\n🔨 Rebuilding...")
+
+		if err := rebuild(); err != nil {
+			logging.Error("Build failed: %v", err)
+		} else {
+			logging.Success("✓ Build succeeded")
+		}
+	})
+}
+
+// WatchAndTest watches for file changes and re-runs the tests after each
+// debounced rebuild. runTests is called once up front and after every change
+// with the changed paths (nil for the initial run).
+func WatchAndTest(config *WatchConfig, runTests func(changes []string) error) error {
+	printWatchBanner(config)
+
+	summary := &TestWatchSummary{}
+	run := func(changes []string) {
+		start := time.Now()
+		err := runTests(changes)
+		summary.Record(err, time.Since(start))
+	}
+
+	logging.Step("🧪 Initial test run...")
+	run(nil)
+
+	return WatchLoop(config, func(changes []string) {
+		logging.Step("\n🧪 Re-running tests...")
+		run(changes)
+	})
+}
+
+// TestWatchSummary tracks pass/fail results across watch runs
+type TestWatchSummary struct {
+	Runs   int
+	Passed int
+	Failed int
+}
+
+// Record stores the result of one run and prints a one-line summary
+func (s *TestWatchSummary) Record(err error, elapsed time.Duration) {
+	s.Runs++
+	if err != nil {
+		s.Failed++
+		logging.Error("\nTests failed %s[run #%d, %s, %d passed / %d failed so far]",
+			colorGray, s.Runs, elapsed.Round(10*time.Millisecond), s.Passed, s.Failed)
+		return
+	}
+	s.Passed++
+	logging.Success("\n✓ Tests passed %s[run #%d, %s, %d passed / %d failed so far]",
+		colorGray, s.Runs, elapsed.Round(10*time.Millisecond), s.Passed, s.Failed)
+}
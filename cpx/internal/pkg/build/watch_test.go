@@ -0,0 +1,90 @@
+package build
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectChanges(t *testing.T) {
+	now := time.Now()
+	old := FileSnapshot{
+		"src/main.cpp":     now,
+		"src/old.cpp":      now,
+		"tests/a_test.cpp": now,
+	}
+	updated := FileSnapshot{
+		"src/main.cpp":     now.Add(time.Second),
+		"tests/a_test.cpp": now,
+		"src/new.cpp":      now,
+	}
+
+	changes := DetectChanges(old, updated)
+	assert.ElementsMatch(t, []string{"src/main.cpp", "src/new.cpp", "src/old.cpp (deleted)"}, changes)
+}
+
+func TestTakeSnapshotIgnoresDirs(t *testing.T) {
+	tmpDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "src", "build"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "src", "main.cpp"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "src", "notes.txt"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "src", "build", "gen.cpp"), []byte(""), 0644))
+
+	config := DefaultWatchConfig()
+	config.Directories = []string{filepath.Join(tmpDir, "src")}
+	config.Root = tmpDir
+
+	snapshot, err := TakeSnapshot(config)
+	require.NoError(t, err)
+	assert.Len(t, snapshot, 1)
+	assert.Contains(t, snapshot, filepath.Join(tmpDir, "src", "main.cpp"))
+}
+
+func TestTakeSnapshotBuildFilesAndGlobs(t *testing.T) {
+	tmpDir := t.TempDir()
+	src := filepath.Join(tmpDir, "src")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "bazel-out"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "builddir"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "main.cpp"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "meson.build"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "bazel-out", "gen.cpp"), []byte(""), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "builddir", "gen.cpp"), []byte(""), 0644))
+
+	// Build files in the root are watched, but not in its other directories
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "meson.build"), []byte(""), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "subprojects", "dep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "subprojects", "dep", "meson.build"), []byte(""), 0644))
+
+	config := DefaultWatchConfig()
+	config.Directories = []string{src}
+	config.Names = []string{"meson.build"}
+	config.Root = tmpDir
+
+	snapshot, err := TakeSnapshot(config)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{filepath.Join(src, "main.cpp"), filepath.Join(src, "meson.build"), filepath.Join(tmpDir, "meson.build")}, keys(snapshot))
+}
+
+func keys(snapshot FileSnapshot) []string {
+	var paths []string
+	for path := range snapshot {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestTestWatchSummary(t *testing.T) {
+	summary := &TestWatchSummary{}
+	summary.Record(nil, time.Second)
+	summary.Record(errors.New("tests failed"), time.Second)
+	summary.Record(nil, time.Second)
+
+	assert.Equal(t, 3, summary.Runs)
+	assert.Equal(t, 2, summary.Passed)
+	assert.Equal(t, 1, summary.Failed)
+}
@@ -0,0 +1,209 @@
+// Package events emits machine-readable progress events during builds and
+// tests so that editor extensions can render progress bars and problem
+// panels. Events are newline-delimited JSON objects written to stdout or a
+// socket; nothing is emitted unless Open was called.
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvVar selects the event target like the --events flag
+const EnvVar = "CPX_EVENTS"
+
+// Event kinds
+const (
+	KindStart      = "start"      // a command started
+	KindPhase      = "phase"      // a step such as configure, build or test started
+	KindProgress   = "progress"   // build progress, with the file being compiled
+	KindDiagnostic = "diagnostic" // a compiler error or warning
+	KindFinish     = "finish"     // the command finished
+)
+
+// Event is one line of the event stream
+type Event struct {
+	Kind    string    `json:"event"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command,omitempty"`
+	Phase   string    `json:"phase,omitempty"`
+	// Percent is set on progress events (0-100)
+	Percent *int `json:"percent,omitempty"`
+	// File is the source file being compiled
+	File       string      `json:"file,omitempty"`
+	Diagnostic *Diagnostic `json:"diagnostic,omitempty"`
+	// ExitCode is set on finish events (see cpx help exit-codes)
+	ExitCode *int   `json:"exit_code,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Diagnostic is a compiler message attached to a source location
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"` // error, warning or note
+	Message  string `json:"message"`
+}
+
+var (
+	mu   sync.Mutex
+	sink io.WriteCloser
+)
+
+// stdoutSink writes events to the real stdout while os.Stdout points at
+// stderr, so that human output doesn't interleave with the events (as with
+// --json). Close points os.Stdout back at stdout.
+type stdoutSink struct{ stdout *os.File }
+
+func (s stdoutSink) Write(p []byte) (int, error) { return s.stdout.Write(p) }
+
+func (s stdoutSink) Close() error {
+	os.Stdout = s.stdout
+	return nil
+}
+
+// Open starts emitting events to target: "stdout" (or "-"), "unix:PATH" or
+// "tcp:HOST:PORT". Sockets must already be listening. With stdout, all
+// other output goes to stderr until Close.
+func Open(target string) error {
+	var w io.WriteCloser
+	toStdout := false
+	switch {
+	case target == "stdout" || target == "-":
+		toStdout = true
+	case strings.HasPrefix(target, "unix:"), strings.HasPrefix(target, "tcp:"):
+		network, address, _ := strings.Cut(target, ":")
+		conn, err := net.DialTimeout(network, address, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("failed to connect to event listener %s: %w", target, err)
+		}
+		w = conn
+	default:
+		return fmt.Errorf("invalid event target %q: expected stdout, unix:PATH or tcp:HOST:PORT", target)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if sink != nil {
+		sink.Close()
+	}
+	if toStdout {
+		w = stdoutSink{os.Stdout}
+		os.Stdout = os.Stderr
+	}
+	sink = w
+	return nil
+}
+
+// Close stops emitting events
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if sink == nil {
+		return nil
+	}
+	err := sink.Close()
+	sink = nil
+	return err
+}
+
+// Enabled reports whether events are being emitted
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return sink != nil
+}
+
+// Emit writes e to the event stream, if open. A listener that went away
+// stops the stream instead of failing the build.
+func Emit(e Event) {
+	mu.Lock()
+	defer mu.Unlock()
+	if sink == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if _, err := sink.Write(append(data, '\n')); err != nil {
+		sink.Close()
+		sink = nil
+	}
+}
+
+// Start emits the start of command
+func Start(command string) {
+	Emit(Event{Kind: KindStart, Command: command})
+}
+
+// Phase emits the start of a step of the running command
+func Phase(name string) {
+	Emit(Event{Kind: KindPhase, Phase: name})
+}
+
+// Progress emits the build progress and the file being compiled, if known
+func Progress(percent int, file string) {
+	Emit(Event{Kind: KindProgress, Percent: &percent, File: file})
+}
+
+// Finish emits the end of the command with its exit code
+func Finish(code int, message string) {
+	Emit(Event{Kind: KindFinish, ExitCode: &code, Message: message})
+}
+
+var (
+	// GCC and Clang: file:line[:col]: severity: message
+	gccDiagRe = regexp.MustCompile(`^(.+?):(\d+):(?:(\d+):)?\s*(fatal error|error|warning|note):\s*(.*)$`)
+	// MSVC: file(line[,col]): severity C1234: message
+	msvcDiagRe = regexp.MustCompile(`^(.+?)\((\d+)(?:,(\d+))?\)\s*:\s*(fatal error|error|warning)\s+\w+:\s*(.*)$`)
+	// CMake's Makefile and Ninja generators announce each compile
+	compileRe = regexp.MustCompile(`Building (?:C|CXX|CUDA|OBJC|OBJCXX) object (\S+)`)
+)
+
+// ParseDiagnostic parses a compiler error, warning or note line
+func ParseDiagnostic(line string) (Diagnostic, bool) {
+	m := gccDiagRe.FindStringSubmatch(line)
+	if m == nil {
+		m = msvcDiagRe.FindStringSubmatch(line)
+	}
+	if m == nil {
+		return Diagnostic{}, false
+	}
+	lineNo, _ := strconv.Atoi(m[2])
+	column, _ := strconv.Atoi(m[3])
+	severity := m[4]
+	if severity == "fatal error" {
+		severity = "error"
+	}
+	return Diagnostic{File: m[1], Line: lineNo, Column: column, Severity: severity, Message: m[5]}, true
+}
+
+// CompiledObject returns the object file a CMake build output line starts
+// compiling (e.g. CMakeFiles/app.dir/src/main.cpp.o), or ""
+func CompiledObject(line string) string {
+	if m := compileRe.FindStringSubmatch(line); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// BuildLine emits the events for one line of build output: the diagnostic
+// it reports, if any
+func BuildLine(line string) {
+	if d, ok := ParseDiagnostic(line); ok {
+		Emit(Event{Kind: KindDiagnostic, Diagnostic: &d})
+	}
+}
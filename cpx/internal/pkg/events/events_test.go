@@ -0,0 +1,142 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDiagnostic(t *testing.T) {
+	tests := []struct {
+		line string
+		want Diagnostic
+	}{
+		{
+			line: "/src/main.cpp:12:5: error: use of undeclared identifier 'foo'",
+			want: Diagnostic{File: "/src/main.cpp", Line: 12, Column: 5, Severity: "error", Message: "use of undeclared identifier 'foo'"},
+		},
+		{
+			line: "src/util.hpp:3: warning: unused parameter 'x'",
+			want: Diagnostic{File: "src/util.hpp", Line: 3, Severity: "warning", Message: "unused parameter 'x'"},
+		},
+		{
+			line: "src/main.cpp:1:10: fatal error: 'missing.h' file not found",
+			want: Diagnostic{File: "src/main.cpp", Line: 1, Column: 10, Severity: "error", Message: "'missing.h' file not found"},
+		},
+		{
+			line: `C:\proj\src\main.cpp(7,3): error C2065: 'foo': undeclared identifier`,
+			want: Diagnostic{File: `C:\proj\src\main.cpp`, Line: 7, Column: 3, Severity: "error", Message: "'foo': undeclared identifier"},
+		},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDiagnostic(tt.line)
+		require.True(t, ok, tt.line)
+		assert.Equal(t, tt.want, got)
+	}
+
+	for _, line := range []string{"[ 50%] Building CXX object CMakeFiles/app.dir/src/main.cpp.o", "ninja: build stopped: subcommand failed.", ""} {
+		_, ok := ParseDiagnostic(line)
+		assert.False(t, ok, line)
+	}
+}
+
+func TestCompiledObject(t *testing.T) {
+	assert.Equal(t, "CMakeFiles/app.dir/src/main.cpp.o", CompiledObject("[ 50%] Building CXX object CMakeFiles/app.dir/src/main.cpp.o"))
+	assert.Empty(t, CompiledObject("[100%] Linking CXX executable app"))
+}
+
+func TestEmitToSocket(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	received := make(chan []Event, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		defer conn.Close()
+		var got []Event
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			var e Event
+			if json.Unmarshal(scanner.Bytes(), &e) == nil {
+				got = append(got, e)
+			}
+		}
+		received <- got
+	}()
+
+	assert.False(t, Enabled())
+	Phase("ignored") // no-op until opened
+
+	require.NoError(t, Open("tcp:"+ln.Addr().String()))
+	assert.True(t, Enabled())
+	Start("cpx build")
+	Phase("build")
+	Progress(0, "src/main.cpp")
+	BuildLine("src/main.cpp:4:1: warning: unused variable 'x'")
+	BuildLine("[ 50%] Building CXX object CMakeFiles/app.dir/src/main.cpp.o")
+	Finish(5, "build failed")
+	require.NoError(t, Close())
+	assert.False(t, Enabled())
+
+	got := <-received
+	require.Len(t, got, 5)
+	assert.Equal(t, KindStart, got[0].Kind)
+	assert.Equal(t, "cpx build", got[0].Command)
+	assert.Equal(t, "build", got[1].Phase)
+	require.NotNil(t, got[2].Percent, "a 0 percent progress is kept")
+	assert.Equal(t, 0, *got[2].Percent)
+	assert.Equal(t, "src/main.cpp", got[2].File)
+	require.NotNil(t, got[3].Diagnostic)
+	assert.Equal(t, "warning", got[3].Diagnostic.Severity)
+	assert.Equal(t, KindFinish, got[4].Kind)
+	assert.Equal(t, 5, *got[4].ExitCode)
+}
+
+func TestOpenInvalidTarget(t *testing.T) {
+	assert.Error(t, Open("file:/tmp/events"))
+	assert.False(t, Enabled())
+}
+
+func TestEmitToStdout(t *testing.T) {
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+	stdout, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(t.TempDir(), "stderr"))
+	require.NoError(t, err)
+	os.Stdout, os.Stderr = stdout, stderr
+
+	require.NoError(t, Open("stdout"))
+	Start("cpx build")
+	// Human output printed while events are on goes to stderr
+	fmt.Println("Building with CMake...")
+	Phase("build")
+	Finish(0, "")
+	require.NoError(t, Close())
+	assert.Same(t, stdout, os.Stdout)
+
+	data, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		var e Event
+		assert.NoError(t, json.Unmarshal([]byte(line), &e), "not an event: %s", line)
+	}
+	data, err = os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "Building with CMake...\n", string(data))
+}
@@ -0,0 +1,95 @@
+// Package exitcode defines the process exit codes returned by cpx so CI
+// scripts can branch on the kind of failure.
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// Code is a process exit code
+type Code int
+
+const (
+	OK               Code = 0
+	Failure          Code = 1  // unclassified error
+	Usage            Code = 2  // invalid command, flag or argument
+	Config           Code = 3  // missing or invalid project configuration
+	ToolchainMissing Code = 4  // a required tool (cmake, bazel, clang-tidy, ...) is not installed
+	BuildFailed      Code = 5  // configure or compile step failed
+	TestFailed       Code = 6  // tests ran and at least one failed
+	QualityGate      Code = 7  // lint, format check, analysis or benchmark regression gate failed
+	Internal         Code = 70 // bug in cpx
+)
+
+// Info describes a code for `cpx help exit-codes`
+type Info struct {
+	Code        Code
+	Name        string
+	Description string
+}
+
+// All lists every exit code in ascending order
+var All = []Info{
+	{OK, "ok", "Success"},
+	{Failure, "failure", "Unclassified error"},
+	{Usage, "usage", "Invalid command, flag or argument"},
+	{Config, "config", "Not a cpx project, or its configuration is missing or invalid"},
+	{ToolchainMissing, "toolchain-missing", "A required tool is not installed, not on PATH, or (with --strict-tools) at the wrong version"},
+	{BuildFailed, "build-failed", "Configure or compile step failed"},
+	{TestFailed, "test-failed", "Tests ran and at least one failed"},
+	{QualityGate, "quality-gate", "Lint, format check, analysis or benchmark regression gate failed"},
+	{Internal, "internal", "Internal error in cpx (please report it)"},
+}
+
+// Error is an error tagged with the exit code it should produce
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap tags err with code. Errors that already carry a code keep it, so the
+// most specific classification wins. Returns nil if err is nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	var tagged *Error
+	if errors.As(err, &tagged) {
+		return err
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		// A command we tried to start is not installed
+		code = ToolchainMissing
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Errorf formats an error like fmt.Errorf and tags it with code
+func Errorf(code Code, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...)}
+}
+
+// Of returns the exit code for err: OK for nil, Failure for untagged errors
+func Of(err error) Code {
+	if err == nil {
+		return OK
+	}
+	var tagged *Error
+	if errors.As(err, &tagged) {
+		return tagged.Code
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return ToolchainMissing
+	}
+	return Failure
+}
@@ -0,0 +1,56 @@
+package exitcode
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Code
+	}{
+		{"nil", nil, OK},
+		{"untagged", errors.New("boom"), Failure},
+		{"tagged", Errorf(BuildFailed, "compile failed"), BuildFailed},
+		{"wrapped tagged", fmt.Errorf("context: %w", Errorf(ToolchainMissing, "cmake not found")), ToolchainMissing},
+		{"executable not found", fmt.Errorf("cmake configure failed: %w", &exec.Error{Name: "cmake", Err: exec.ErrNotFound}), ToolchainMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Of(tt.err))
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	assert.Nil(t, Wrap(TestFailed, nil))
+
+	err := Wrap(TestFailed, errors.New("tests failed"))
+	assert.Equal(t, TestFailed, Of(err))
+	assert.Equal(t, "tests failed", err.Error())
+
+	// The innermost, more specific code is kept
+	inner := fmt.Errorf("failed to build tests: %w", Errorf(BuildFailed, "exit status 2"))
+	assert.Equal(t, BuildFailed, Of(Wrap(TestFailed, inner)))
+
+	missing := &exec.Error{Name: "bazel", Err: exec.ErrNotFound}
+	assert.Equal(t, ToolchainMissing, Of(Wrap(BuildFailed, missing)))
+}
+
+func TestAllIsSortedAndUnique(t *testing.T) {
+	seen := make(map[Code]bool)
+	for i, info := range All {
+		assert.False(t, seen[info.Code], "duplicate code %d", info.Code)
+		seen[info.Code] = true
+		if i > 0 {
+			assert.Greater(t, info.Code, All[i-1].Code)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package explain looks up documentation for clang-tidy checks, cppcheck ids,
+// flawfinder CWEs and common compiler and linker errors.
+package explain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+//go:embed rules.json
+var embeddedDB []byte
+
+// DatabaseURL is where `cpx upgrade` fetches a refreshed rule database from
+const DatabaseURL = "https://github.com/ozacod/cpx/releases/latest/download/explain.json"
+
+// Entry documents a single rule or error pattern
+type Entry struct {
+	ID          string `json:"id"`
+	Tool        string `json:"tool"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+	// Pattern is a regular expression matched against compiler/linker output
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Database is the set of documented rules
+type Database struct {
+	Version string  `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Parse decodes a rule database
+func Parse(data []byte) (*Database, error) {
+	var db Database
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("failed to parse rule database: %w", err)
+	}
+	if len(db.Entries) == 0 {
+		return nil, fmt.Errorf("rule database has no entries")
+	}
+	return &db, nil
+}
+
+// DatabasePath returns the location of the refreshed rule database
+func DatabasePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "explain.json"), nil
+}
+
+// Load returns the database downloaded by `cpx upgrade` if present and
+// valid, otherwise the one embedded in the binary
+func Load() *Database {
+	if path, err := DatabasePath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			if db, err := Parse(data); err == nil {
+				return db
+			}
+		}
+	}
+
+	db, err := Parse(embeddedDB)
+	if err != nil {
+		// The embedded database is validated by tests
+		panic(err)
+	}
+	return db
+}
+
+// Lookup finds entries for query. Exact ids match first (case-insensitive,
+// "CWE-120" also matches "120"); otherwise query is treated as compiler output
+// and matched against error patterns.
+func (db *Database) Lookup(query string) []Entry {
+	q := strings.TrimSpace(query)
+	id := normalizeID(q)
+
+	for _, e := range db.Entries {
+		if normalizeID(e.ID) == id {
+			return []Entry{e}
+		}
+	}
+
+	var matches []Entry
+	for _, e := range db.Entries {
+		if e.Pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + e.Pattern)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(q) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+func normalizeID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	// Accept "cwe120", "cwe-120" and a bare "120" for CWEs
+	id = strings.TrimPrefix(strings.TrimPrefix(id, "cwe-"), "cwe")
+	// clang-tidy prints checks as [bugprone-use-after-move]
+	return strings.Trim(id, "[]")
+}
+
+// Guess returns a documentation link for ids that are not in the database
+// but whose tool can be inferred from their shape
+func Guess(query string) (Entry, bool) {
+	q := strings.Trim(strings.TrimSpace(query), "[]")
+
+	if cwe := strings.TrimPrefix(strings.ToUpper(q), "CWE-"); cwe != strings.ToUpper(q) && isDigits(cwe) {
+		return Entry{
+			ID:   "CWE-" + cwe,
+			Tool: "flawfinder",
+			URL:  fmt.Sprintf("https://cwe.mitre.org/data/definitions/%s.html", cwe),
+		}, true
+	}
+
+	// clang-tidy checks are <group>-<check>; aliases such as cert-err58-cpp
+	// live under the group directory too
+	groups := []string{"abseil", "altera", "android", "boost", "bugprone", "cert", "clang-analyzer",
+		"concurrency", "cppcoreguidelines", "darwin", "fuchsia", "google", "hicpp", "linuxkernel",
+		"llvm", "llvmlibc", "misc", "modernize", "mpi", "objc", "openmp", "performance",
+		"portability", "readability", "zircon"}
+	for _, group := range groups {
+		if check, ok := strings.CutPrefix(q, group+"-"); ok && check != "" {
+			return Entry{
+				ID:   q,
+				Tool: "clang-tidy",
+				URL:  fmt.Sprintf("https://clang.llvm.org/extra/clang-tidy/checks/%s/%s.html", group, check),
+			}, true
+		}
+	}
+
+	return Entry{}, false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Refresh downloads the latest rule database and stores it for Load
+func Refresh(url string) (*Database, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download rule database: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download rule database (status %d)", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule database: %w", err)
+	}
+	return Save(data)
+}
+
+// Save stores data, a downloaded rule database, for Load
+func Save(data []byte) (*Database, error) {
+	db, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := DatabasePath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save rule database: %w", err)
+	}
+	return db, nil
+}
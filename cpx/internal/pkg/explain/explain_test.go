@@ -0,0 +1,93 @@
+package explain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedDatabase(t *testing.T) {
+	db, err := Parse(embeddedDB)
+	require.NoError(t, err)
+
+	seen := make(map[string]bool)
+	for _, e := range db.Entries {
+		assert.NotEmpty(t, e.ID)
+		assert.NotEmpty(t, e.Tool, e.ID)
+		assert.NotEmpty(t, e.Title, e.ID)
+		assert.NotEmpty(t, e.Description, e.ID)
+		assert.False(t, seen[e.ID], "duplicate id %s", e.ID)
+		seen[e.ID] = true
+	}
+}
+
+func TestLookup(t *testing.T) {
+	db, err := Parse(embeddedDB)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		query  string
+		wantID string
+	}{
+		{"clang-tidy check", "bugprone-use-after-move", "bugprone-use-after-move"},
+		{"clang-tidy bracketed", "[modernize-use-nullptr]", "modernize-use-nullptr"},
+		{"cppcheck id case-insensitive", "nullpointer", "nullPointer"},
+		{"CWE with prefix", "CWE-120", "CWE-120"},
+		{"CWE number", "134", "CWE-134"},
+		{"GCC linker error", "main.cpp:(.text+0x5): undefined reference to `foo()'", "undefined-reference"},
+		{"Clang undeclared", "error: use of undeclared identifier 'cout'", "not-declared"},
+		{"Missing header", "fatal error: fmt/core.h: No such file or directory", "header-not-found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := db.Lookup(tt.query)
+			require.NotEmpty(t, entries)
+			assert.Equal(t, tt.wantID, entries[0].ID)
+		})
+	}
+
+	assert.Empty(t, db.Lookup("something-completely-unknown"))
+}
+
+func TestGuess(t *testing.T) {
+	entry, ok := Guess("bugprone-sizeof-expression")
+	require.True(t, ok)
+	assert.Equal(t, "https://clang.llvm.org/extra/clang-tidy/checks/bugprone/sizeof-expression.html", entry.URL)
+
+	entry, ok = Guess("CWE-787")
+	require.True(t, ok)
+	assert.Equal(t, "https://cwe.mitre.org/data/definitions/787.html", entry.URL)
+
+	_, ok = Guess("notARule")
+	assert.False(t, ok)
+}
+
+func TestLoadPrefersRefreshedDatabase(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldHome := os.Getenv("HOME")
+	defer os.Setenv("HOME", oldHome)
+	os.Setenv("HOME", tmpDir)
+
+	// Without a refreshed copy the embedded database is used
+	assert.NotEmpty(t, Load().Lookup("nullPointer"))
+
+	path, err := DatabasePath()
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": "2", "entries": [
+  {"id": "new-check", "tool": "clang-tidy", "title": "New", "description": "Added upstream"}
+]}`), 0644))
+
+	db := Load()
+	assert.Equal(t, "2", db.Version)
+	assert.NotEmpty(t, db.Lookup("new-check"))
+
+	// A corrupt refreshed copy falls back to the embedded one
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+	assert.NotEmpty(t, Load().Lookup("nullPointer"))
+}
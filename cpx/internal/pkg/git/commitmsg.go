@@ -0,0 +1,71 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultCommitTypes are the Conventional Commits types accepted when
+// cpx.yaml lists none
+var DefaultCommitTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert"}
+
+// conventionalHeaderRe matches "type(scope)!: description"
+var conventionalHeaderRe = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]+)\))?(!)?: (\S.*)$`)
+
+// generatedMessagePrefixes start the messages git writes itself, which the
+// commit-msg hook accepts as they are
+var generatedMessagePrefixes = []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "}
+
+// ValidateCommitMessage checks that message follows Conventional Commits
+// (https://www.conventionalcommits.org) with one of types (DefaultCommitTypes
+// if empty) and, if scopes is not empty, one of scopes or none. Comment
+// lines are ignored, as git strips them.
+func ValidateCommitMessage(message string, types, scopes []string) error {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		// Everything below the scissors line of `git commit -v` is the diff
+		if strings.HasPrefix(line, "# ------------------------ >8 ------------------------") {
+			break
+		}
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("empty commit message")
+	}
+	header := lines[0]
+	for _, prefix := range generatedMessagePrefixes {
+		if strings.HasPrefix(header, prefix) {
+			return nil
+		}
+	}
+
+	if len(types) == 0 {
+		types = DefaultCommitTypes
+	}
+	example := fmt.Sprintf("%s: add a summary of the change", types[0])
+	if len(scopes) > 0 {
+		example = fmt.Sprintf("%s(%s): add a summary of the change", types[0], scopes[0])
+	}
+
+	match := conventionalHeaderRe.FindStringSubmatch(header)
+	if match == nil {
+		return fmt.Errorf("commit message %q is not a conventional commit\n  hint: use \"type(scope): description\", e.g. %q", header, example)
+	}
+	if !slices.Contains(types, match[1]) {
+		return fmt.Errorf("unknown commit type %q\n  hint: use one of %s", match[1], strings.Join(types, ", "))
+	}
+	if scope := match[2]; len(scopes) > 0 && scope != "" && !slices.Contains(scopes, scope) {
+		return fmt.Errorf("unknown commit scope %q\n  hint: use one of %s, or no scope", scope, strings.Join(scopes, ", "))
+	}
+	if len(lines) > 1 && lines[1] != "" {
+		return fmt.Errorf("the commit message header must be followed by a blank line\n  hint: keep the summary on the first line and the details after an empty line")
+	}
+	return nil
+}
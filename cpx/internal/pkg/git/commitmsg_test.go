@@ -0,0 +1,45 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateCommitMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		types   []string
+		scopes  []string
+		wantErr string
+	}{
+		{name: "type", message: "feat: add cpx hooks\n"},
+		{name: "scope and breaking", message: "fix(cli)!: drop --legacy\n\nBREAKING CHANGE: removed"},
+		{name: "comments", message: "# Please enter the commit message\n\ndocs: fix typo\n# On branch main\n"},
+		{name: "merge", message: "Merge branch 'main' into topic"},
+		{name: "fixup", message: "fixup! feat: add cpx hooks"},
+		{name: "verbose diff", message: "chore: bump\n# ------------------------ >8 ------------------------\n+added line\n"},
+		{name: "empty", message: "# only comments\n\n", wantErr: "empty commit message"},
+		{name: "no type", message: "Add cpx hooks", wantErr: "not a conventional commit"},
+		{name: "empty scope", message: "feat(): add", wantErr: "not a conventional commit"},
+		{name: "no space", message: "feat:add", wantErr: "not a conventional commit"},
+		{name: "unknown type", message: "feature: add", wantErr: `unknown commit type "feature"`},
+		{name: "custom types", message: "feat: add", types: []string{"add", "fix"}, wantErr: "use one of add, fix"},
+		{name: "custom type", message: "add: hooks", types: []string{"add", "fix"}},
+		{name: "known scope", message: "fix(core): leak", scopes: []string{"core", "cli"}},
+		{name: "optional scope", message: "fix: leak", scopes: []string{"core", "cli"}},
+		{name: "unknown scope", message: "fix(gui): leak", scopes: []string{"core", "cli"}, wantErr: `unknown commit scope "gui"`},
+		{name: "no blank line", message: "fix: leak\nin the parser", wantErr: "blank line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCommitMessage(tt.message, tt.types, tt.scopes)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
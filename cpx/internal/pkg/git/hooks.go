@@ -1,39 +1,21 @@
 package git
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"gopkg.in/yaml.v3"
 )
 
 // InstallHooksWithConfig installs git hooks with specified configuration
-func InstallHooksWithConfig(preCommit []string, prePush []string) error {
-	// Check if we're in a git repository
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("not in a git repository. Run 'git init' first")
-	}
-
-	// Get .git directory
-	cmd = exec.Command("git", "rev-parse", "--git-dir")
-	output, err := cmd.Output()
+func InstallHooksWithConfig(preCommit []string, prePush []string, commitMsg bool) error {
+	hooksDir, err := HooksDir()
 	if err != nil {
-		return fmt.Errorf("failed to get git directory: %w", err)
+		return err
 	}
-	gitDir := strings.TrimSpace(string(output))
-
-	// Convert to absolute path if relative
-	if !filepath.IsAbs(gitDir) {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return fmt.Errorf("failed to get current directory: %w", err)
-		}
-		gitDir = filepath.Join(cwd, gitDir)
-	}
-
-	hooksDir := filepath.Join(gitDir, "hooks")
 	if err := os.MkdirAll(hooksDir, 0755); err != nil {
 		return fmt.Errorf("failed to create hooks directory: %w", err)
 	}
@@ -64,6 +46,13 @@ func InstallHooksWithConfig(preCommit []string, prePush []string) error {
 		fmt.Printf("%s   pre-push%s\n", "\033[32m", "\033[0m")
 	}
 
+	if commitMsg {
+		if err := InstallCommitMsgHook(hooksDir); err != nil {
+			return fmt.Errorf("failed to install commit-msg hook: %w", err)
+		}
+		fmt.Printf("%s   commit-msg%s\n", "\033[32m", "\033[0m")
+	}
+
 	fmt.Printf("%s Git hooks installed successfully!%s\n", "\033[32m", "\033[0m")
 	return nil
 }
@@ -78,21 +67,20 @@ func InstallPreCommitHook(hooksDir string, checks []string) error {
 	}
 
 	var sb strings.Builder
-	sb.WriteString("#!/bin/bash\n")
-	sb.WriteString("# Cpx pre-commit hook\n")
-	sb.WriteString("# Generated by cpx\n\n")
+	sb.WriteString(hookHeader("pre-commit", checks))
 	sb.WriteString("echo \" Running pre-commit checks...\"\n\n")
 
 	// Generate commands based on checks
 	for _, check := range checks {
-		check = strings.TrimSpace(strings.ToLower(check))
-		switch check {
+		check = strings.TrimSpace(check)
+		switch strings.ToLower(check) {
 		case "fmt":
-			sb.WriteString(`# Format code
+			sb.WriteString(`# Format the staged files and stage the formatting
 if command -v cpx &> /dev/null; then
-    echo " Formatting code..."
-    if ! cpx fmt; then
-        echo "  cpx fmt failed, continuing..."
+    echo " Formatting the staged files..."
+    if ! cpx fmt --staged; then
+        echo " Files need formatting. Commit aborted."
+        exit 1
     fi
 else
     echo "  cpx not found, skipping formatting"
@@ -102,8 +90,8 @@ fi
 		case "lint":
 			sb.WriteString(`# Run linter
 if command -v cpx &> /dev/null; then
-    echo " Running linter..."
-    if ! cpx lint; then
+    echo " Running linter on the staged files..."
+    if ! cpx lint --staged; then
         echo "  cpx lint found issues (non-blocking)"
     fi
 else
@@ -160,6 +148,8 @@ else
 fi
 
 `)
+		default:
+			sb.WriteString(customHookCommand(check, "Commit"))
 		}
 	}
 
@@ -178,15 +168,13 @@ func InstallPrePushHook(hooksDir string, checks []string) error {
 	}
 
 	var sb strings.Builder
-	sb.WriteString("#!/bin/bash\n")
-	sb.WriteString("# Cpx pre-push hook\n")
-	sb.WriteString("# Generated by cpx\n\n")
+	sb.WriteString(hookHeader("pre-push", checks))
 	sb.WriteString("echo \" Running pre-push checks...\"\n\n")
 
 	// Generate commands based on checks
 	for _, check := range checks {
-		check = strings.TrimSpace(strings.ToLower(check))
-		switch check {
+		check = strings.TrimSpace(check)
+		switch strings.ToLower(check) {
 		case "test":
 			sb.WriteString(`# Run tests
 if command -v cpx &> /dev/null; then
@@ -248,6 +236,8 @@ else
 fi
 
 `)
+		default:
+			sb.WriteString(customHookCommand(check, "Push"))
 		}
 	}
 
@@ -256,6 +246,54 @@ fi
 	return writeHook(hookPath, sb.String())
 }
 
+// InstallCommitMsgHook installs the commit-msg hook, which checks the
+// message with cpx hooks commit-msg
+func InstallCommitMsgHook(hooksDir string) error {
+	return writeHook(filepath.Join(hooksDir, "commit-msg"), hookHeader("commit-msg", []string{CommitMsgCheck})+`# Validate the commit message against Conventional Commits and the types and
+# scopes of cpx.yaml
+if command -v cpx &> /dev/null; then
+    if ! cpx hooks commit-msg "$1"; then
+        echo " Commit aborted."
+        exit 1
+    fi
+else
+    echo "  cpx not found, skipping commit message check"
+fi
+
+exit 0
+`)
+}
+
+// hookHeader returns the start of a hook script generated by cpx, recording
+// its checks for cpx hooks status
+func hookHeader(name string, checks []string) string {
+	recorded, _ := json.Marshal(checks)
+	return fmt.Sprintf("#!/bin/bash\n# Cpx %s hook\n%s\n%s%s\n\n", name, generatedMarker, checksPrefix, recorded)
+}
+
+// customHookCommand returns the hook section running a command that is not
+// a cpx check, e.g. "./scripts/check_licenses.sh". The command runs in its
+// own bash, quoted so that it cannot break the hook script, and blocks the
+// commit or push when it fails.
+func customHookCommand(command, action string) string {
+	if command == "" {
+		return ""
+	}
+	return fmt.Sprintf(`# Run a custom command
+echo %s
+if ! bash -c %s; then
+    echo %s
+    exit 1
+fi
+
+`, shellQuote(" Running "+command+"..."), shellQuote(command), shellQuote(" "+command+" failed. "+action+" aborted."))
+}
+
+// shellQuote quotes s as a single bash word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // writeHook writes a hook file and makes it executable
 func writeHook(hookPath, content string) error {
 	// Remove any existing .sample file for the same hook
@@ -269,3 +307,74 @@ func writeHook(hookPath, content string) error {
 	}
 	return nil
 }
+
+// PreCommitConfigFile is the configuration of the pre-commit framework
+const PreCommitConfigFile = ".pre-commit-config.yaml"
+
+// PreCommitChecks are the checks cpx hooks export pre-commit can emit, in
+// the order of the configuration
+var PreCommitChecks = []string{"fmt", "lint", "cppcheck", "test"}
+
+type preCommitConfig struct {
+	Repos []preCommitRepo `yaml:"repos"`
+}
+
+type preCommitRepo struct {
+	Repo  string          `yaml:"repo"`
+	Hooks []preCommitHook `yaml:"hooks"`
+}
+
+type preCommitHook struct {
+	ID            string   `yaml:"id"`
+	Name          string   `yaml:"name"`
+	Entry         string   `yaml:"entry"`
+	Language      string   `yaml:"language"`
+	PassFilenames bool     `yaml:"pass_filenames"`
+	Files         string   `yaml:"files,omitempty"`
+	Stages        []string `yaml:"stages,omitempty"`
+}
+
+// preCommitCppFiles matches the files whose changes run the C/C++ checks
+const preCommitCppFiles = `\.(c|cc|cpp|cxx|c\+\+|h|hh|hpp|hxx|h\+\+|ipp|inl|cppm|ixx)$`
+
+// PreCommitConfig returns a .pre-commit-config.yaml running checks with cpx
+// as local hooks: fmt, lint and cppcheck on commit, test on push
+func PreCommitConfig(checks []string) ([]byte, error) {
+	hooks := map[string]preCommitHook{
+		"fmt": {
+			ID: "cpx-fmt", Name: "cpx fmt", Entry: "cpx fmt --check",
+			Files: preCommitCppFiles,
+		},
+		"lint": {
+			ID: "cpx-lint", Name: "cpx lint", Entry: "cpx lint --staged",
+			Files: preCommitCppFiles,
+		},
+		"cppcheck": {
+			ID: "cpx-cppcheck", Name: "cpx cppcheck", Entry: "cpx cppcheck --quiet",
+			Files: preCommitCppFiles,
+		},
+		"test": {
+			ID: "cpx-test", Name: "cpx test", Entry: "cpx test",
+			Stages: []string{"pre-push"},
+		},
+	}
+
+	repo := preCommitRepo{Repo: "local"}
+	for _, check := range checks {
+		hook, ok := hooks[strings.TrimSpace(strings.ToLower(check))]
+		if !ok {
+			return nil, fmt.Errorf("unknown check %q\n  hint: use %s", check, strings.Join(PreCommitChecks, ", "))
+		}
+		// cpx checks the project, not the files pre-commit passes
+		hook.Language = "system"
+		repo.Hooks = append(repo.Hooks, hook)
+	}
+
+	data, err := yaml.Marshal(preCommitConfig{Repos: []preCommitRepo{repo}})
+	if err != nil {
+		return nil, err
+	}
+	header := "# Generated by cpx (cpx hooks export pre-commit)\n" +
+		"# Install with: pre-commit install --hook-type pre-commit --hook-type pre-push\n"
+	return append([]byte(header), data...), nil
+}
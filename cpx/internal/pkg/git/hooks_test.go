@@ -0,0 +1,43 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallHookCustomCommands(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	hooksDir := t.TempDir()
+	workDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workDir, "Check.sh"), []byte("#!/bin/sh\necho checked > out.txt\n"), 0755))
+
+	runHook := func(name string) error {
+		cmd := exec.Command(filepath.Join(hooksDir, name))
+		cmd.Dir = workDir
+		cmd.Env = append(os.Environ(), "PATH=/usr/bin:/bin")
+		return cmd.Run()
+	}
+
+	// Case and quotes are kept; a failing command blocks the commit
+	require.NoError(t, InstallPreCommitHook(hooksDir, []string{" ./Check.sh ", "test -f out.txt && echo 'it''s fine'"}))
+	data, err := os.ReadFile(filepath.Join(hooksDir, "pre-commit"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "bash -c './Check.sh'")
+	require.NoError(t, runHook("pre-commit"))
+	assert.FileExists(t, filepath.Join(workDir, "out.txt"))
+
+	require.NoError(t, InstallPrePushHook(hooksDir, []string{"LINT", "false", "touch never"}))
+	data, err = os.ReadFile(filepath.Join(hooksDir, "pre-push"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "bash -c 'LINT'")
+	assert.Contains(t, string(data), "Push aborted.")
+	assert.Error(t, runHook("pre-push"))
+	assert.NoFileExists(t, filepath.Join(workDir, "never"))
+}
@@ -0,0 +1,181 @@
+package git
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// generatedMarker marks the hooks written by cpx
+	generatedMarker = "# Generated by cpx"
+	// checksPrefix starts the line recording the checks of a hook
+	checksPrefix = "# cpx-checks: "
+)
+
+// CommitMsgCheck is the check the commit-msg hook records
+const CommitMsgCheck = "conventional-commits"
+
+// ManagedHooks are the hooks cpx hooks install writes
+var ManagedHooks = []string{"pre-commit", "pre-push", "commit-msg"}
+
+// HookStatus describes a hook of the repository
+type HookStatus struct {
+	Name      string
+	Path      string
+	Installed bool
+	// Managed is set for the hooks written by cpx
+	Managed bool
+	// Checks are the checks of a managed hook, nil if it predates their
+	// recording
+	Checks []string
+}
+
+// HooksDir returns the hooks directory of the current repository, honoring
+// core.hooksPath
+func HooksDir() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository. Run 'git init' first")
+	}
+	dir := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(dir) {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		dir = filepath.Join(cwd, dir)
+	}
+	return dir, nil
+}
+
+// HooksStatus returns the status of the managed hooks, followed by the other
+// hooks installed in the repository
+func HooksStatus() ([]HookStatus, error) {
+	hooksDir, err := HooksDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []HookStatus
+	known := map[string]bool{}
+	for _, name := range ManagedHooks {
+		statuses = append(statuses, hookStatus(hooksDir, name))
+		known[name] = true
+	}
+
+	entries, _ := os.ReadDir(hooksDir)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || known[name] || strings.HasSuffix(name, ".sample") {
+			continue
+		}
+		statuses = append(statuses, hookStatus(hooksDir, name))
+	}
+	return statuses, nil
+}
+
+func hookStatus(hooksDir, name string) HookStatus {
+	status := HookStatus{Name: name, Path: filepath.Join(hooksDir, name)}
+	file, err := os.Open(status.Path)
+	if err != nil {
+		return status
+	}
+	defer file.Close()
+	status.Installed = true
+
+	// The marker and the checks are in the header of the script
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < 5 && scanner.Scan(); i++ {
+		line := scanner.Text()
+		switch {
+		case line == generatedMarker || strings.HasPrefix(line, generatedMarker+" "):
+			status.Managed = true
+		case strings.HasPrefix(line, checksPrefix):
+			json.Unmarshal([]byte(strings.TrimPrefix(line, checksPrefix)), &status.Checks)
+		}
+	}
+	return status
+}
+
+// UninstallHooks removes the hooks written by cpx and puts back the samples
+// of git's template directory. Hooks cpx did not write are kept and
+// returned.
+func UninstallHooks() (removed, kept []string, err error) {
+	statuses, err := HooksStatus()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	templates := hookTemplatesDir()
+	for _, status := range statuses {
+		if !status.Installed {
+			continue
+		}
+		if !status.Managed {
+			kept = append(kept, status.Name)
+			continue
+		}
+		if err := os.Remove(status.Path); err != nil {
+			return removed, kept, fmt.Errorf("failed to remove the %s hook: %w", status.Name, err)
+		}
+		removed = append(removed, status.Name)
+
+		if templates == "" {
+			continue
+		}
+		sample := status.Name + ".sample"
+		if data, err := os.ReadFile(filepath.Join(templates, sample)); err == nil {
+			os.WriteFile(status.Path+".sample", data, 0755)
+		}
+	}
+	return removed, kept, nil
+}
+
+// hookTemplatesDir returns the directory of the sample hooks git init copies,
+// or "" if it cannot be found
+func hookTemplatesDir() string {
+	dirs := []string{os.Getenv("GIT_TEMPLATE_DIR")}
+	if output, err := exec.Command("git", "config", "--path", "init.templateDir").Output(); err == nil {
+		dirs = append(dirs, strings.TrimSpace(string(output)))
+	}
+	// The default templates are installed next to git's programs, e.g.
+	// /usr/lib/git-core and /usr/share/git-core/templates
+	if output, err := exec.Command("git", "--exec-path").Output(); err == nil {
+		execPath := strings.TrimSpace(string(output))
+		dirs = append(dirs, filepath.Join(execPath, "..", "..", "share", "git-core", "templates"))
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, "hooks")); err == nil {
+			return filepath.Join(dir, "hooks")
+		}
+	}
+	return ""
+}
+
+// RunHook runs the installed hook name with args from the top of the work
+// tree, as git does
+func RunHook(name string, args []string) error {
+	hooksDir, err := HooksDir()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(filepath.Join(hooksDir, name), args...)
+	if output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output(); err == nil {
+		cmd.Dir = strings.TrimSpace(string(output))
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("the %s hook failed: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManageHooks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	repo := t.TempDir()
+	t.Chdir(repo)
+	out, err := exec.Command("git", "init", "-q").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	hooksDir, err := HooksDir()
+	require.NoError(t, err)
+	require.NoError(t, InstallHooksWithConfig([]string{"fmt", "test -f marker"}, nil, true))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "post-merge"), []byte("#!/bin/sh\n"), 0755))
+
+	statuses, err := HooksStatus()
+	require.NoError(t, err)
+	byName := map[string]HookStatus{}
+	for _, status := range statuses {
+		byName[status.Name] = status
+	}
+	assert.Equal(t, []string{"fmt", "test -f marker"}, byName["pre-commit"].Checks)
+	assert.True(t, byName["pre-commit"].Managed)
+	assert.False(t, byName["pre-push"].Installed)
+	assert.Equal(t, []string{CommitMsgCheck}, byName["commit-msg"].Checks)
+	assert.True(t, byName["post-merge"].Installed)
+	assert.False(t, byName["post-merge"].Managed)
+	assert.NotContains(t, byName, "pre-rebase.sample")
+
+	// Hooks run from the top of the work tree; cpx is not on PATH here
+	require.NoError(t, os.Mkdir("src", 0755))
+	t.Chdir(filepath.Join(repo, "src"))
+	t.Setenv("PATH", "/usr/bin:/bin")
+	assert.Error(t, RunHook("pre-commit", nil))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "marker"), nil, 0644))
+	assert.NoError(t, RunHook("pre-commit", nil))
+
+	removed, kept, err := UninstallHooks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pre-commit", "commit-msg"}, removed)
+	assert.Equal(t, []string{"post-merge"}, kept)
+	assert.NoFileExists(t, filepath.Join(hooksDir, "pre-commit"))
+	assert.FileExists(t, filepath.Join(hooksDir, "post-merge"))
+	if hookTemplatesDir() != "" {
+		assert.FileExists(t, filepath.Join(hooksDir, "pre-commit.sample"))
+	}
+}
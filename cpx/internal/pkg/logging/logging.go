@@ -0,0 +1,184 @@
+// Package logging prints the status messages of cpx commands: progress
+// steps, successes, warnings, errors and diagnostics for verbose and debug
+// runs. The level set by --quiet, --verbose or --debug decides which of them
+// reach the terminal; a log file given with --log-file records all of them,
+// with timestamps and without colors.
+//
+// Messages go to os.Stdout as it is when they're printed, so that they
+// follow it to stderr with --json; warnings, errors and debug messages go to
+// stderr.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugEnvVar enables debug messages like the --debug flag
+const DebugEnvVar = "CPX_DEBUG"
+
+// Level selects the messages printed to the terminal
+type Level int
+
+const (
+	// LevelQuiet prints only warnings and errors
+	LevelQuiet Level = iota
+	// LevelNormal also prints progress steps and successes (the default)
+	LevelNormal
+	// LevelVerbose also prints details such as the commands cpx runs
+	LevelVerbose
+	// LevelDebug also prints debug messages
+	LevelDebug
+)
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+	colorDim    = "\033[2m"
+)
+
+var (
+	mu         sync.Mutex
+	level      = LevelNormal
+	timestamps bool
+	file       io.WriteCloser
+	// now is replaced by tests
+	now = time.Now
+)
+
+// SetLevel sets the level of the messages printed to the terminal
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// Enabled reports whether messages of level l are printed to the terminal
+func Enabled(l Level) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return l <= level
+}
+
+// DebugFromEnv reports whether DebugEnvVar is set to a true value
+func DebugFromEnv() bool {
+	on, err := strconv.ParseBool(os.Getenv(DebugEnvVar))
+	return err == nil && on
+}
+
+// SetTimestamps prefixes the messages printed to the terminal with the time
+func SetTimestamps(on bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	timestamps = on
+}
+
+// OpenFile appends every message, whatever the level, to the file at path
+func OpenFile(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+	}
+	file = f
+	return nil
+}
+
+// Close closes the log file
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	if file == nil {
+		return nil
+	}
+	err := file.Close()
+	file = nil
+	return err
+}
+
+// Step prints a progress step, e.g. "Building with Bazel..."
+func Step(format string, args ...any) {
+	logf(LevelNormal, "INFO", os.Stdout, colorCyan, "", format, args)
+}
+
+// Info prints a plain message
+func Info(format string, args ...any) {
+	logf(LevelNormal, "INFO", os.Stdout, "", "", format, args)
+}
+
+// Success prints the successful end of a step
+func Success(format string, args ...any) {
+	logf(LevelNormal, "INFO", os.Stdout, colorGreen, "", format, args)
+}
+
+// Notice prints something the user should notice that isn't a problem,
+// e.g. a target that is skipped
+func Notice(format string, args ...any) {
+	logf(LevelNormal, "INFO", os.Stdout, colorYellow, "", format, args)
+}
+
+// Warn prints a warning; warnings are printed even with --quiet
+func Warn(format string, args ...any) {
+	logf(LevelQuiet, "WARN", os.Stderr, colorYellow, "Warning: ", format, args)
+}
+
+// Error prints an error; errors are printed even with --quiet
+func Error(format string, args ...any) {
+	logf(LevelQuiet, "ERROR", os.Stderr, colorRed, "✗ ", format, args)
+}
+
+// Verbose prints a detail for --verbose, e.g. a command cpx runs
+func Verbose(format string, args ...any) {
+	logf(LevelVerbose, "VERBOSE", os.Stdout, colorDim, "", format, args)
+}
+
+// Debug prints a message for --debug
+func Debug(format string, args ...any) {
+	logf(LevelDebug, "DEBUG", os.Stderr, colorDim, "[DEBUG] ", format, args)
+}
+
+// ansiRe matches the color codes of messages, which the log file leaves out
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func logf(l Level, tag string, w io.Writer, color, prefix, format string, args []any) {
+	msg := fmt.Sprintf(format, args...)
+	// Blank lines before a message stay above its prefix and timestamp
+	trimmed := strings.TrimLeft(msg, "\n")
+	blank := strings.Repeat("\n", len(msg)-len(trimmed))
+	msg = trimmed
+
+	mu.Lock()
+	defer mu.Unlock()
+	t := now()
+	if file != nil {
+		// The tag names the level, so the file does without prefixes
+		for _, line := range strings.Split(ansiRe.ReplaceAllString(msg, ""), "\n") {
+			fmt.Fprintf(file, "%s %-7s %s\n", t.Format(time.RFC3339), tag, line)
+		}
+	}
+	if l > level {
+		return
+	}
+	stamp := ""
+	if timestamps {
+		stamp = colorDim + t.Format("15:04:05") + colorReset + " "
+	}
+	if color == "" {
+		fmt.Fprintf(w, "%s%s%s%s\n", blank, stamp, prefix, msg)
+		return
+	}
+	fmt.Fprintf(w, "%s%s%s%s%s%s\n", blank, stamp, color, prefix, msg, colorReset)
+}
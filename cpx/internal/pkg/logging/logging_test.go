@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capture returns what fn prints to stdout and stderr
+func capture(t *testing.T, fn func()) (string, string) {
+	t.Helper()
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+	stdoutR, stdoutW, err := os.Pipe()
+	require.NoError(t, err)
+	stderrR, stderrW, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout, os.Stderr = stdoutW, stderrW
+
+	fn()
+	stdoutW.Close()
+	stderrW.Close()
+	stdout, err := io.ReadAll(stdoutR)
+	require.NoError(t, err)
+	stderr, err := io.ReadAll(stderrR)
+	require.NoError(t, err)
+	return string(stdout), string(stderr)
+}
+
+func TestLevels(t *testing.T) {
+	defer SetLevel(LevelNormal)
+
+	stdout, stderr := capture(t, func() {
+		Step("Building...")
+		Info("plain")
+		Verbose("hidden")
+		Debug("hidden")
+		Warn("disk almost full")
+	})
+	assert.Equal(t, colorCyan+"Building..."+colorReset+"\nplain\n", stdout)
+	assert.Equal(t, colorYellow+"Warning: disk almost full"+colorReset+"\n", stderr)
+
+	SetLevel(LevelQuiet)
+	stdout, stderr = capture(t, func() {
+		Step("Building...")
+		Success("done")
+		Error("failed")
+	})
+	assert.Empty(t, stdout)
+	assert.Equal(t, colorRed+"✗ failed"+colorReset+"\n", stderr)
+
+	SetLevel(LevelDebug)
+	assert.True(t, Enabled(LevelVerbose))
+	stdout, stderr = capture(t, func() {
+		Verbose("running cmake")
+		Debug("env: %s", "x")
+	})
+	assert.Equal(t, colorDim+"running cmake"+colorReset+"\n", stdout)
+	assert.Equal(t, colorDim+"[DEBUG] env: x"+colorReset+"\n", stderr)
+
+	t.Setenv(DebugEnvVar, "1")
+	assert.True(t, DebugFromEnv())
+	t.Setenv(DebugEnvVar, "")
+	assert.False(t, DebugFromEnv())
+}
+
+func TestTimestampsAndFile(t *testing.T) {
+	oldNow := now
+	defer func() {
+		now = oldNow
+		SetTimestamps(false)
+		SetLevel(LevelNormal)
+		Close()
+	}()
+	now = func() time.Time { return time.Date(2026, 10, 15, 9, 30, 0, 0, time.UTC) }
+
+	SetTimestamps(true)
+	stdout, _ := capture(t, func() { Info("\nstarted") })
+	assert.Equal(t, "\n"+colorDim+"09:30:00"+colorReset+" started\n", stdout)
+
+	// The file records every level, without colors
+	SetTimestamps(false)
+	SetLevel(LevelQuiet)
+	path := filepath.Join(t.TempDir(), "cpx.log")
+	require.NoError(t, OpenFile(path))
+	capture(t, func() {
+		Step("Building...")
+		Debug("cache hit")
+		Warn("two\nlines")
+	})
+	require.NoError(t, Close())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `2026-10-15T09:30:00Z INFO    Building...
+2026-10-15T09:30:00Z DEBUG   cache hit
+2026-10-15T09:30:00Z WARN    two
+2026-10-15T09:30:00Z WARN    lines
+`, string(data))
+
+	assert.Error(t, OpenFile(filepath.Join(t.TempDir(), "missing", "cpx.log")))
+}
@@ -0,0 +1,92 @@
+package naming
+
+import (
+	"sort"
+	"strings"
+)
+
+// Replacement maps one spelling of a project name to its renamed form
+type Replacement struct {
+	Old string
+	New string
+}
+
+// IsValidProjectName reports whether name can be used as a project name:
+// letters, digits, '-' and '_' only.
+func IsValidProjectName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') ||
+			(r >= '0' && r <= '9') || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// RenameReplacements returns every spelling of oldName the templates
+// generate (raw name, identifier, macro prefix, title case) paired with the
+// matching spelling of newName, longest first so that longer spellings are
+// replaced before their substrings.
+func RenameReplacements(oldName, newName string) []Replacement {
+	candidates := []Replacement{
+		{oldName, newName},
+		{SafeIdent(oldName), SafeIdent(newName)},
+		{SafeIdentUpper(oldName), SafeIdentUpper(newName)},
+		{SafeIdentTitle(oldName), SafeIdentTitle(newName)},
+	}
+
+	var reps []Replacement
+	seen := make(map[string]bool)
+	for _, r := range candidates {
+		if r.Old == r.New || seen[r.Old] {
+			continue
+		}
+		seen[r.Old] = true
+		reps = append(reps, r)
+	}
+	sort.SliceStable(reps, func(i, j int) bool { return len(reps[i].Old) > len(reps[j].Old) })
+	return reps
+}
+
+// ReplaceName replaces occurrences of each spelling in s that are not part of
+// a longer word. Underscores count as separators, so "myapp" is replaced in
+// "myapp_tests" and "MYAPP" in "MYAPP_VERSION", but not in "myapplication".
+func ReplaceName(s string, reps []Replacement) string {
+	for _, r := range reps {
+		if r.Old == "" {
+			continue
+		}
+		var b strings.Builder
+		rest := s
+		for {
+			i := strings.Index(rest, r.Old)
+			if i < 0 {
+				b.WriteString(rest)
+				break
+			}
+			end := i + len(r.Old)
+			consumed := len(s) - len(rest)
+			if isNameBoundary(s, consumed+i-1) && isNameBoundary(s, consumed+end) {
+				b.WriteString(rest[:i])
+				b.WriteString(r.New)
+			} else {
+				b.WriteString(rest[:end])
+			}
+			rest = rest[end:]
+		}
+		s = b.String()
+	}
+	return s
+}
+
+// isNameBoundary reports whether the byte at i (if any) does not continue a word
+func isNameBoundary(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return true
+	}
+	c := s[i]
+	return !((c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
+}
@@ -0,0 +1,52 @@
+package naming
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidProjectName(t *testing.T) {
+	assert.True(t, IsValidProjectName("my-app_2"))
+	assert.False(t, IsValidProjectName(""))
+	assert.False(t, IsValidProjectName("my app"))
+	assert.False(t, IsValidProjectName("my.app"))
+}
+
+func TestRenameReplacements(t *testing.T) {
+	reps := RenameReplacements("my-app", "new-lib")
+	assert.Equal(t, []Replacement{
+		{"my-app", "new-lib"},
+		{"my_app", "new_lib"},
+		{"MY_APP", "NEW_LIB"},
+		{"My_app", "New_lib"},
+	}, reps)
+
+	// Spellings that coincide are only listed once
+	reps = RenameReplacements("app", "lib")
+	assert.Equal(t, []Replacement{{"app", "lib"}, {"APP", "LIB"}, {"App", "Lib"}}, reps)
+}
+
+func TestReplaceName(t *testing.T) {
+	reps := RenameReplacements("myapp", "newlib")
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"Namespace", "namespace myapp {", "namespace newlib {"},
+		{"Include path", `#include <myapp/myapp.hpp>`, `#include <newlib/newlib.hpp>`},
+		{"Include guard", "#ifndef MYAPP_HPP", "#ifndef NEWLIB_HPP"},
+		{"Version macro", "#define MYAPP_VERSION_MAJOR 1", "#define NEWLIB_VERSION_MAJOR 1"},
+		{"Target suffix", "add_executable(myapp_tests", "add_executable(newlib_tests"},
+		{"Longer word untouched", "myapplication", "myapplication"},
+		{"Prefixed word untouched", "notmyapp", "notmyapp"},
+		{"Quoted", `"name": "myapp"`, `"name": "newlib"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ReplaceName(tt.input, reps))
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Package offline tracks whether cpx may use the network. In offline mode,
+// commands work from caches (the vcpkg checkout, its downloads and binary
+// cache, cloned template repositories) and operations that can't do without
+// the network fail with Required instead of hanging on a connection.
+package offline
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+)
+
+// EnvVar enables offline mode like the --offline flag
+const EnvVar = "CPX_OFFLINE"
+
+var enabled bool
+
+// Enable turns offline mode on for the rest of the process
+func Enable() {
+	enabled = true
+}
+
+// Enabled reports whether cpx runs offline: Enable was called or EnvVar is
+// set to a true value
+func Enabled() bool {
+	if enabled {
+		return true
+	}
+	on, err := strconv.ParseBool(os.Getenv(EnvVar))
+	return err == nil && on
+}
+
+// Required returns an error if cpx runs offline, for an operation (e.g.
+// "cpx upgrade") that needs the network
+func Required(operation string) error {
+	if !Enabled() {
+		return nil
+	}
+	return exitcode.Errorf(exitcode.Usage, "%s needs network access, but cpx is offline\n  hint: run it without --offline, %s or 'offline: true' in the cpx config", operation, EnvVar)
+}
@@ -0,0 +1,27 @@
+package offline
+
+import (
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOffline(t *testing.T) {
+	defer func() { enabled = false }()
+
+	t.Setenv(EnvVar, "")
+	assert.False(t, Enabled())
+	assert.NoError(t, Required("cpx upgrade"))
+
+	t.Setenv(EnvVar, "1")
+	assert.True(t, Enabled())
+	err := Required("cpx upgrade")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.ErrorContains(t, err, "cpx upgrade needs network access")
+
+	t.Setenv(EnvVar, "false")
+	assert.False(t, Enabled())
+	Enable()
+	assert.True(t, Enabled())
+}
@@ -0,0 +1,62 @@
+// Package output selects between human and machine-readable output. With
+// --json, a command prints one JSON document to stdout for tooling, and
+// everything it would print for humans (progress, build logs, tables) goes
+// to stderr instead so the document stays parseable.
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+var (
+	stdout  io.Writer
+	printed bool
+)
+
+// EnableJSON turns JSON output on for the rest of the process. It keeps the
+// real stdout for Print and points os.Stdout at stderr.
+func EnableJSON() {
+	if stdout != nil {
+		return
+	}
+	stdout = os.Stdout
+	os.Stdout = os.Stderr
+}
+
+// JSON reports whether commands should print JSON
+func JSON() bool {
+	return stdout != nil
+}
+
+// Print writes v as an indented JSON document to stdout
+func Print(v any) error {
+	w := stdout
+	if w == nil {
+		w = os.Stdout
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	printed = true
+	return nil
+}
+
+// Printed reports whether a document was printed, so that a failed command
+// doesn't print a second one for its error
+func Printed() bool {
+	return printed
+}
+
+// Reset turns JSON output off and restores os.Stdout (used by tests)
+func Reset() {
+	if f, ok := stdout.(*os.File); ok {
+		os.Stdout = f
+	}
+	stdout = nil
+	printed = false
+}
@@ -0,0 +1,47 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSON(t *testing.T) {
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+	stdoutR, stdoutW, err := os.Pipe()
+	require.NoError(t, err)
+	stderrR, stderrW, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout, os.Stderr = stdoutW, stderrW
+
+	assert.False(t, JSON())
+	EnableJSON()
+	defer Reset()
+	assert.True(t, JSON())
+	assert.False(t, Printed())
+
+	// Human output moves to stderr
+	os.Stdout.WriteString("Building...\n")
+	require.NoError(t, Print(map[string]any{"status": "success"}))
+	assert.True(t, Printed())
+
+	Reset()
+	assert.Equal(t, stdoutW, os.Stdout)
+	assert.False(t, JSON())
+	stdoutW.Close()
+	stderrW.Close()
+
+	data, err := io.ReadAll(stdoutR)
+	require.NoError(t, err)
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "success", doc["status"])
+	data, err = io.ReadAll(stderrR)
+	require.NoError(t, err)
+	assert.Equal(t, "Building...\n", string(data))
+}
@@ -2,13 +2,23 @@ package quality
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"html/template"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
 // AnalysisResult represents a single finding from any tool
@@ -23,6 +33,10 @@ type AnalysisResult struct {
 	Code      string `json:"code,omitempty"`
 	EndLine   int    `json:"end_line,omitempty"`
 	EndColumn int    `json:"end_column,omitempty"`
+	// CWE is the Common Weakness Enumeration id of the finding (Cppcheck)
+	CWE int `json:"cwe,omitempty"`
+	// Inconclusive findings may be false positives (Cppcheck)
+	Inconclusive bool `json:"inconclusive,omitempty"`
 }
 
 // ToolResults contains all results from a single tool
@@ -42,11 +56,28 @@ type ComprehensiveAnalysis struct {
 		BySeverity    map[string]int `json:"by_severity"`
 		ByTool        map[string]int `json:"by_tool"`
 	} `json:"summary"`
+	// Comparison is set by cpx analyze --compare
+	Comparison *AnalysisComparison `json:"comparison,omitempty"`
 }
 
-// RunComprehensiveAnalysis runs all analysis tools and generates an HTML report
-func RunComprehensiveAnalysis(outputFile string, skipCppcheck, skipLint, skipFlawfinder bool, targets []string, vcpkg VcpkgSetup) error {
-	fmt.Printf("%sRunning comprehensive code analysis...%s\n", Cyan, Reset)
+// ReportFormats are the formats of the report of RunComprehensiveAnalysis
+var ReportFormats = []string{"html", "json"}
+
+// RunComprehensiveAnalysis runs all analysis tools and writes a report in
+// format, html or json, and returns the analysis.
+// The tools run concurrently, clang-tidy with jobs parallel processes (0:
+// one per CPU).
+// The tools check the sources of compile_commands.json, in compileDbDir or,
+// if empty, in the project root or build/, builddir/ or .cache/native/debug
+// (see findCompileDatabase); for CMake projects the vcpkg environment is set
+// up too.
+// The semgrep rulesets of cpx.yaml run when there are any.
+// With createBaseline the findings are saved as the BaselineFile; otherwise
+// the findings of the baseline are left out, unless includeBaseline. With a
+// previous report, the report classifies the findings as new, fixed or
+// unchanged since then; previousPath names it.
+func RunComprehensiveAnalysis(outputFile, format string, skipCppcheck, skipLint, skipFlawfinder, skipClangAnalyzer, skipClazy bool, jobs int, targets []string, compileDbDir string, semgrepRulesets []string, createBaseline, includeBaseline bool, previous *ComprehensiveAnalysis, previousPath string, vcpkg VcpkgSetup) (ComprehensiveAnalysis, error) {
+	logging.Step("Running comprehensive code analysis...")
 
 	analysis := ComprehensiveAnalysis{
 		Timestamp: time.Now(),
@@ -55,45 +86,193 @@ func RunComprehensiveAnalysis(outputFile string, skipCppcheck, skipLint, skipFla
 	analysis.Summary.BySeverity = make(map[string]int)
 	analysis.Summary.ByTool = make(map[string]int)
 
-	// Run Cppcheck
-	if !skipCppcheck {
-		fmt.Printf("%sRunning Cppcheck...%s\n", Cyan, Reset)
-		cppcheckResults := runCppcheckAnalysis(targets)
-		analysis.Tools = append(analysis.Tools, cppcheckResults)
-		updateSummary(&analysis, cppcheckResults)
+	var vcpkgErr error
+	if !skipLint && compileDbDir == "" {
+		// Set up vcpkg environment for clang-tidy on CMake projects
+		vcpkgErr = vcpkg.SetupEnv()
+	}
+	files := discoverAnalysisFiles(compileDbDir, targets)
+	if files.CompileDb != "" {
+		logging.Info("  Analyzing the %d sources of %s", len(files.Sources), files.CompileDb)
+	} else {
+		logging.Info("  No compile_commands.json found: scanning the source directories")
 	}
 
-	// Run clang-tidy
+	// The tools run concurrently; the report lists them in this order
+	var tasks []analysisTask
+	if !skipCppcheck {
+		tasks = append(tasks, analysisTask{"Cppcheck", func() ToolResults { return runCppcheckAnalysis(targets, files) }})
+	}
 	if !skipLint {
-		fmt.Printf("%sRunning clang-tidy...%s\n", Cyan, Reset)
-		lintResults := runLintAnalysis(vcpkg)
-		analysis.Tools = append(analysis.Tools, lintResults)
-		updateSummary(&analysis, lintResults)
+		tasks = append(tasks, analysisTask{"clang-tidy", func() ToolResults {
+			if vcpkgErr != nil {
+				return ToolResults{Tool: "clang-tidy", Status: "error", Results: []AnalysisResult{}, Error: fmt.Sprintf("failed to setup vcpkg: %v", vcpkgErr)}
+			}
+			return runLintAnalysis(files, jobs)
+		}})
 	}
-
-	// Run Flawfinder
 	if !skipFlawfinder {
-		fmt.Printf("%sRunning Flawfinder...%s\n", Cyan, Reset)
-		flawfinderResults := runFlawfinderAnalysis(targets)
-		analysis.Tools = append(analysis.Tools, flawfinderResults)
-		updateSummary(&analysis, flawfinderResults)
+		tasks = append(tasks, analysisTask{"Flawfinder", func() ToolResults { return runFlawfinderAnalysis(targets, files) }})
+	}
+	if !skipClangAnalyzer {
+		tasks = append(tasks, analysisTask{"the Clang Static Analyzer", func() ToolResults { return runClangAnalyzerAnalysis(compileDbDir) }})
+	}
+	// The semgrep rulesets of the project
+	if len(semgrepRulesets) > 0 {
+		tasks = append(tasks, analysisTask{"Semgrep", func() ToolResults { return runSemgrepAnalysis(semgrepRulesets, targets) }})
+	}
+	// clazy on Qt projects
+	if !skipClazy && UsesQt() {
+		tasks = append(tasks, analysisTask{"clazy", func() ToolResults { return runClazyAnalysis(files) }})
+	}
+	analysis.Tools = append(analysis.Tools, runAnalysisTasks(tasks)...)
+
+	// Include findings from the last `cpx test --memcheck` run
+	if memcheckResults, ok := LoadMemcheckResults(); ok {
+		logging.Step("Including Valgrind results from %s", MemcheckResultsFile)
+		analysis.Tools = append(analysis.Tools, memcheckResults)
+	}
+
+	suppressed := 0
+	if createBaseline {
+		baseline := NewBaseline(analysis.Tools)
+		if err := WriteBaseline(baseline); err != nil {
+			return analysis, err
+		}
+		logging.Success("Saved %d findings to the baseline %s", len(baseline.Findings), BaselineFile)
+	} else if !includeBaseline {
+		baseline, ok, err := LoadBaseline()
+		if err != nil {
+			return analysis, err
+		}
+		if ok {
+			suppressed = baseline.Subtract(analysis.Tools)
+		}
+	}
+	for _, toolResults := range analysis.Tools {
+		updateSummary(&analysis, toolResults)
+	}
+	if previous != nil {
+		comparison := CompareAnalyses(*previous, analysis)
+		comparison.Previous = previousPath
+		analysis.Comparison = &comparison
 	}
 
-	// Generate HTML report
-	fmt.Printf("%sGenerating HTML report...%s\n", Cyan, Reset)
-	if err := generateHTMLReport(analysis, outputFile); err != nil {
-		return fmt.Errorf("failed to generate HTML report: %w", err)
+	if format == "json" {
+		logging.Step("Generating JSON report...")
+		if err := writeJSONReport(analysis, outputFile); err != nil {
+			return analysis, fmt.Errorf("failed to generate JSON report: %w", err)
+		}
+	} else {
+		logging.Step("Generating HTML report...")
+		if err := generateHTMLReport(analysis, outputFile); err != nil {
+			return analysis, fmt.Errorf("failed to generate HTML report: %w", err)
+		}
 	}
 
-	fmt.Printf("%sAnalysis complete! Report saved to: %s%s\n", Green, outputFile, Reset)
+	logging.Success("Analysis complete! Report saved to: %s", outputFile)
 	fmt.Printf("   Total findings: %d\n", analysis.Summary.TotalFindings)
 	for tool, count := range analysis.Summary.ByTool {
 		fmt.Printf("   %s: %d findings\n", tool, count)
 	}
+	if suppressed > 0 {
+		fmt.Printf("   %d findings of the baseline %s not shown (use --include-baseline to see them)\n", suppressed, BaselineFile)
+	}
+	if analysis.Comparison != nil {
+		printComparison(*analysis.Comparison)
+	}
+
+	return analysis, nil
+}
+
+// writeJSONReport writes analysis to outputFile as JSON
+func writeJSONReport(analysis ComprehensiveAnalysis, outputFile string) error {
+	data, err := json.MarshalIndent(analysis, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputFile, append(data, '\n'), 0644)
+}
 
+// CheckThresholds fails if analysis has more findings of severity error than
+// maxErrors or of severity warning than maxWarnings. A negative maximum has
+// no limit.
+func CheckThresholds(analysis ComprehensiveAnalysis, maxErrors, maxWarnings int) error {
+	var exceeded []string
+	if count := analysis.Summary.BySeverity["error"]; maxErrors >= 0 && count > maxErrors {
+		exceeded = append(exceeded, fmt.Sprintf("%d errors (--max-errors %d)", count, maxErrors))
+	}
+	if count := analysis.Summary.BySeverity["warning"]; maxWarnings >= 0 && count > maxWarnings {
+		exceeded = append(exceeded, fmt.Sprintf("%d warnings (--max-warnings %d)", count, maxWarnings))
+	}
+	if len(exceeded) > 0 {
+		return exitcode.Errorf(exitcode.QualityGate, "analysis found %s\n  hint: fix them, or accept the current findings with 'cpx analyze --baseline create'", strings.Join(exceeded, " and "))
+	}
 	return nil
 }
 
+// analysisTask runs one analysis tool
+type analysisTask struct {
+	name string
+	run  func() ToolResults
+}
+
+// runAnalysisTasks runs tasks concurrently and returns their results in the
+// order of tasks, logging each tool as it starts and finishes
+func runAnalysisTasks(tasks []analysisTask) []ToolResults {
+	results := make([]ToolResults, len(tasks))
+	var wg sync.WaitGroup
+	for i, task := range tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logging.Step("Running %s...", task.name)
+			start := time.Now()
+			results[i] = task.run()
+			elapsed := time.Since(start).Round(100 * time.Millisecond)
+			switch results[i].Status {
+			case "success":
+				logging.Info("  %s finished in %s: %d findings", task.name, elapsed, len(results[i].Results))
+			case "skipped":
+				logging.Info("  %s skipped: %s", task.name, results[i].Error)
+			default:
+				logging.Info("  %s failed: %s", task.name, results[i].Error)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// forEachFile calls run for each file with jobs concurrent calls (0: one per
+// CPU) and returns the outputs in the order of files, logging the progress
+// of tool
+func forEachFile(tool string, files []string, jobs int, run func(file string) string) []string {
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	outputs := make([]string, len(files))
+	indexes := make(chan int)
+	var done atomic.Int32
+	var wg sync.WaitGroup
+	for range min(jobs, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				outputs[i] = run(files[i])
+				logging.Info("  %s [%d/%d] %s", tool, done.Add(1), len(files), files[i])
+			}
+		}()
+	}
+	for i := range files {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	return outputs
+}
+
 func updateSummary(analysis *ComprehensiveAnalysis, toolResults ToolResults) {
 	if toolResults.Status == "error" {
 		return
@@ -186,7 +365,9 @@ func hasCppFiles(dir string) bool {
 	return false
 }
 
-func runCppcheckAnalysis(targets []string) ToolResults {
+// runCppcheckAnalysis runs cppcheck on the sources of the compilation
+// database, with their compile flags, or on the source directories of targets
+func runCppcheckAnalysis(targets []string, files analysisFiles) ToolResults {
 	result := ToolResults{
 		Tool:    "Cppcheck",
 		Status:  "success",
@@ -202,20 +383,23 @@ func runCppcheckAnalysis(targets []string) ToolResults {
 
 	// Discover source directories to scan
 	// Look for common source directories like src/, include/, lib/, etc.
-	sourceDirs := discoverSourceDirectories(targets)
-	if len(sourceDirs) == 0 {
-		result.Status = "skipped"
-		result.Error = "no source directories found to scan"
-		if os.Getenv("CPX_DEBUG") != "" {
-			fmt.Printf("Debug: cppcheck no source directories found, targets: %v\n", targets)
+	var sourceDirs []string
+	if files.CompileDb == "" {
+		sourceDirs = discoverSourceDirectories(targets)
+		if len(sourceDirs) == 0 {
+			result.Status = "skipped"
+			result.Error = "no source directories found to scan"
+			logging.Debug("cppcheck: no source directories found, targets: %v", targets)
+			return result
 		}
+	} else if len(files.Sources) == 0 {
+		result.Status = "skipped"
+		result.Error = "no sources in " + files.CompileDb
 		return result
 	}
 
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("Debug: cppcheck targets: %v\n", targets)
-		fmt.Printf("Debug: cppcheck sourceDirs: %v\n", sourceDirs)
-	}
+	logging.Debug("cppcheck targets: %v", targets)
+	logging.Debug("cppcheck sourceDirs: %v", sourceDirs)
 
 	// Create temporary XML file
 	tmpXML, err := os.CreateTemp("", "cppcheck-*.xml")
@@ -231,6 +415,15 @@ func runCppcheckAnalysis(targets []string) ToolResults {
 	// Using --xml with --output-file writes XML directly to the file
 	// Pass directories to scan (cppcheck will scan all non-ignored files in those directories)
 	args := []string{"--enable=all", "--xml", "--xml-version=2", "--output-file=" + tmpXML.Name()}
+	if files.CompileDb != "" {
+		// The compilation database gives cppcheck the defines and include
+		// paths of each source; the filters leave out those of dependencies
+		args = append(args, "--project="+files.CompileDb)
+		for _, source := range files.Sources {
+			abs, _ := filepath.Abs(source)
+			args = append(args, "--file-filter="+abs)
+		}
+	}
 	args = append(args, sourceDirs...)
 
 	// Run cppcheck - XML will be written directly to the file
@@ -277,165 +470,99 @@ func runCppcheckAnalysis(targets []string) ToolResults {
 	return result
 }
 
-func parseCppcheckXML(xmlFile string) []AnalysisResult {
-	results := []AnalysisResult{}
+// cppcheckError mirrors an <error> of cppcheck's --xml-version=2 output
+type cppcheckError struct {
+	ID           string             `xml:"id,attr"`
+	Severity     string             `xml:"severity,attr"`
+	Msg          string             `xml:"msg,attr"`
+	Verbose      string             `xml:"verbose,attr"`
+	CWE          int                `xml:"cwe,attr"`
+	Inconclusive bool               `xml:"inconclusive,attr"`
+	File0        string             `xml:"file0,attr"`
+	Locations    []cppcheckLocation `xml:"location"`
+	// File and Line locate the errors of --xml-version=1
+	File string `xml:"file,attr"`
+	Line int    `xml:"line,attr"`
+}
 
-	// Read XML file
+type cppcheckLocation struct {
+	File   string `xml:"file,attr"`
+	Line   int    `xml:"line,attr"`
+	Column int    `xml:"column,attr"`
+}
+
+func parseCppcheckXML(xmlFile string) []AnalysisResult {
 	data, err := os.ReadFile(xmlFile)
 	if err != nil {
-		return results
+		return []AnalysisResult{}
 	}
+	return parseCppcheckXMLData(data)
+}
 
-	// Parse XML - cppcheck XML format has <error> tags with <location> tags inside
-	content := string(data)
-	errorTagCount := 0
-
-	// Find all <error> tags (they can span multiple lines)
-	// Use strings.Index to find all occurrences
-	searchStart := 0
+// parseCppcheckXMLData returns a finding for each location of each <error>.
+// It decodes the <error> elements wherever they are, so that the fragment
+// of a truncated report still yields the errors before the truncation.
+func parseCppcheckXMLData(data []byte) []AnalysisResult {
+	results := []AnalysisResult{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
 	for {
-		// Find next <error tag
-		errorStart := strings.Index(content[searchStart:], "<error")
-		if errorStart == -1 {
-			break
+		tok, err := decoder.Token()
+		if err != nil {
+			return results
 		}
-		errorStart += searchStart
-
-		// Find the closing </error> tag
-		errorEnd := strings.Index(content[errorStart:], "</error>")
-		if errorEnd == -1 {
-			// No closing tag found, skip this one
-			searchStart = errorStart + 7
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "error" {
 			continue
 		}
-		errorEnd += errorStart + 8 // +8 for "</error>" length
-
-		errorTag := content[errorStart:errorEnd]
-		errorTagCount++
-
-		// Parse error tag and extract all locations
-		errorResults := parseCppcheckErrorTag(errorTag)
-		results = append(results, errorResults...)
-
-		// Move search start past this error tag
-		searchStart = errorEnd
+		var e cppcheckError
+		if err := decoder.DecodeElement(&e, &start); err != nil {
+			return results
+		}
+		results = append(results, e.results()...)
 	}
-
-	return results
 }
 
-func parseCppcheckErrorTag(tag string) []AnalysisResult {
-	results := []AnalysisResult{}
-
-	// Extract attributes from the error tag
-	severity := extractXMLAttr(tag, "severity")
-	msg := extractXMLAttr(tag, "msg")
-	if msg == "" {
-		// Try verbose attribute
-		msg = extractXMLAttr(tag, "verbose")
-	}
-	id := extractXMLAttr(tag, "id")
-
-	// Extract file0 attribute as fallback
-	file0 := extractXMLAttr(tag, "file0")
-
-	locationCount := 0
-
-	// Find all <location> tags inside the error tag
-	// Pattern: <location file="..." line="..." column="..." .../>
-	i := 0
-	for i < len(tag) {
-		if i+10 < len(tag) && tag[i:i+10] == "<location" {
-			locationStart := i
-			// Find closing of location tag
-			locationEnd := -1
-			for j := i + 10; j < len(tag); j++ {
-				if j+2 <= len(tag) && tag[j:j+2] == "/>" {
-					locationEnd = j + 2
-					break
-				}
-				if j+11 <= len(tag) && tag[j:j+11] == "</location>" {
-					locationEnd = j + 11
-					break
-				}
-			}
-
-			if locationEnd > 0 {
-				locationCount++
-				locationTag := tag[locationStart:locationEnd]
-				file := extractXMLAttr(locationTag, "file")
-				lineNum := extractXMLInt(locationTag, "line")
-				column := extractXMLInt(locationTag, "column")
-
-				// Use file0 as fallback if location doesn't have file
-				if file == "" {
-					file = file0
-				}
+func (e cppcheckError) results() []AnalysisResult {
+	result := AnalysisResult{
+		Tool:         "Cppcheck",
+		Severity:     strings.ToLower(e.Severity),
+		Message:      e.Msg,
+		Rule:         e.ID,
+		CWE:          e.CWE,
+		Inconclusive: e.Inconclusive,
+	}
+	if result.Message == "" {
+		result.Message = e.Verbose
+	}
 
-				if file != "" && lineNum > 0 {
-					results = append(results, AnalysisResult{
-						Tool:     "Cppcheck",
-						Severity: strings.ToLower(severity),
-						File:     file,
-						Line:     lineNum,
-						Column:   column,
-						Message:  msg,
-						Rule:     id,
-					})
-				}
-				i = locationEnd
-			} else {
-				i++
-			}
-		} else {
-			i++
+	var results []AnalysisResult
+	for _, location := range e.Locations {
+		r := result
+		r.File, r.Line, r.Column = location.File, location.Line, location.Column
+		// Use file0 as fallback if location doesn't have file
+		if r.File == "" {
+			r.File = e.File0
+		}
+		if r.File != "" && r.Line > 0 {
+			results = append(results, r)
 		}
 	}
 
-	// If no locations found but we have file0, create one result
-	if len(results) == 0 && file0 != "" {
-		// Try to extract line from error tag itself (fallback)
-		lineNum := extractXMLInt(tag, "line")
-		if lineNum > 0 {
-			results = append(results, AnalysisResult{
-				Tool:     "Cppcheck",
-				Severity: strings.ToLower(severity),
-				File:     file0,
-				Line:     lineNum,
-				Message:  msg,
-				Rule:     id,
-			})
+	// If no locations found, try the location of the error tag itself
+	if len(results) == 0 && e.Line > 0 {
+		result.File, result.Line = e.File, e.Line
+		if result.File == "" {
+			result.File = e.File0
+		}
+		if result.File != "" {
+			results = append(results, result)
 		}
 	}
-
 	return results
 }
 
-func extractXMLAttr(line, attr string) string {
-	prefix := attr + "=\""
-	start := strings.Index(line, prefix)
-	if start == -1 {
-		return ""
-	}
-	start += len(prefix)
-	end := strings.Index(line[start:], "\"")
-	if end == -1 {
-		return ""
-	}
-	return line[start : start+end]
-}
-
-func extractXMLInt(line, attr string) int {
-	val := extractXMLAttr(line, attr)
-	if val == "" {
-		return 0
-	}
-	var num int
-	fmt.Sscanf(val, "%d", &num)
-	return num
-}
-
-func runLintAnalysis(vcpkg VcpkgSetup) ToolResults {
+// runLintAnalysis runs clang-tidy on the sources of the compilation database
+func runLintAnalysis(files analysisFiles, jobs int) ToolResults {
 	result := ToolResults{
 		Tool:    "clang-tidy",
 		Status:  "success",
@@ -449,71 +576,14 @@ func runLintAnalysis(vcpkg VcpkgSetup) ToolResults {
 		return result
 	}
 
-	// Set up vcpkg environment
-	if err := vcpkg.SetupEnv(); err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("failed to setup vcpkg: %v", err)
-		return result
-	}
-
-	// Verify compile_commands.json exists and get absolute path
-	buildDir, err := filepath.Abs("build")
-	if err != nil {
-		result.Status = "error"
-		result.Error = fmt.Sprintf("failed to get absolute path to build directory: %v", err)
-		return result
-	}
-
-	compileDbPath := filepath.Join(buildDir, "compile_commands.json")
-	if _, err := os.Stat(compileDbPath); os.IsNotExist(err) {
+	if files.CompileDb == "" {
 		result.Status = "skipped"
 		result.Error = "compile_commands.json not found. Run 'cpx build' first."
 		return result
 	}
-
-	// Find source files (same logic as LintCode)
-	var files []string
-	trackedFiles, err := GetGitTrackedCppFiles()
-	if err != nil {
-		// If not in git repo, fall back to scanning src/include directories
-		for _, dir := range []string{".", "src", "include"} {
-			if _, err := os.Stat(dir); os.IsNotExist(err) {
-				continue
-			}
-			filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-				if err != nil || info.IsDir() {
-					return nil
-				}
-				// Skip build directories and other common ignored paths
-				if strings.Contains(path, "/build/") || strings.Contains(path, "\\build\\") {
-					return nil
-				}
-				ext := filepath.Ext(path)
-				if ext == ".cpp" || ext == ".cc" || ext == ".cxx" || ext == ".c++" {
-					files = append(files, path)
-				}
-				return nil
-			})
-		}
-	} else {
-		// Filter out files in build directories and other common ignored paths
-		for _, file := range trackedFiles {
-			// Skip files in build/, out/, bin/, .vcpkg/, etc.
-			if strings.HasPrefix(file, "build/") ||
-				strings.HasPrefix(file, "out/") ||
-				strings.HasPrefix(file, "bin/") ||
-				strings.HasPrefix(file, ".vcpkg/") ||
-				strings.Contains(file, "/build/") ||
-				strings.Contains(file, "\\build\\") {
-				continue
-			}
-			files = append(files, file)
-		}
-	}
-
-	if len(files) == 0 {
+	if len(files.Sources) == 0 {
 		result.Status = "skipped"
-		result.Error = "no source files found"
+		result.Error = "no sources in " + files.CompileDb
 		return result
 	}
 
@@ -522,23 +592,37 @@ func runLintAnalysis(vcpkg VcpkgSetup) ToolResults {
 	systemIncludes := GetSystemIncludePaths()
 
 	// Run clang-tidy with absolute path to build directory
-	tidyArgs := []string{"-p", buildDir}
+	tidyArgs := []string{"-p", filepath.Dir(files.CompileDb)}
 	// Add system include paths as extra arguments
 	for _, include := range systemIncludes {
 		tidyArgs = append(tidyArgs, "--extra-arg=-isystem"+include)
 	}
-	tidyArgs = append(tidyArgs, files...)
 
-	cmd := exec.Command("clang-tidy", tidyArgs...)
-	output, _ := cmd.CombinedOutput()
-
-	// Parse clang-tidy output
-	results := parseClangTidyOutput(string(output))
-	result.Results = results
+	// One clang-tidy per file, so that the files are analyzed in parallel
+	tidyArgs = slices.Clip(tidyArgs)
+	outputs := forEachFile("clang-tidy", files.Sources, jobs, func(file string) string {
+		output, _ := exec.Command("clang-tidy", append(tidyArgs, file)...).CombinedOutput()
+		return string(output)
+	})
+
+	// Headers are reported with each file including them
+	seen := map[AnalysisResult]bool{}
+	for _, output := range outputs {
+		for _, r := range parseClangTidyOutput(output) {
+			if !seen[r] {
+				seen[r] = true
+				result.Results = append(result.Results, r)
+			}
+		}
+	}
 
 	return result
 }
 
+// diagnosticsGeneratedRe matches the summary clang prints after the
+// diagnostics of a file, e.g. "2 warnings generated."
+var diagnosticsGeneratedRe = regexp.MustCompile(`^\d+ (warning|error)s?( and \d+ errors?)? generated\.$`)
+
 func parseClangTidyOutput(output string) []AnalysisResult {
 	results := []AnalysisResult{}
 
@@ -547,7 +631,7 @@ func parseClangTidyOutput(output string) []AnalysisResult {
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		if line == "" {
+		if line == "" || diagnosticsGeneratedRe.MatchString(line) {
 			continue
 		}
 
@@ -633,7 +717,9 @@ func parseClangTidyOutput(output string) []AnalysisResult {
 	return results
 }
 
-func runFlawfinderAnalysis(targets []string) ToolResults {
+// runFlawfinderAnalysis runs flawfinder on the sources and headers of the
+// compilation database, or on the source directories of targets
+func runFlawfinderAnalysis(targets []string, files analysisFiles) ToolResults {
 	result := ToolResults{
 		Tool:    "Flawfinder",
 		Status:  "success",
@@ -648,7 +734,10 @@ func runFlawfinderAnalysis(targets []string) ToolResults {
 	}
 
 	// Discover source directories to scan (same as cppcheck)
-	sourceDirs := discoverSourceDirectories(targets)
+	sourceDirs := slices.Concat(files.Sources, files.Headers)
+	if files.CompileDb == "" {
+		sourceDirs = discoverSourceDirectories(targets)
+	}
 	if len(sourceDirs) == 0 {
 		result.Status = "skipped"
 		result.Error = "no source directories found to scan"
@@ -669,24 +758,19 @@ func runFlawfinderAnalysis(targets []string) ToolResults {
 	// CSV output goes to stdout
 	output := stdout.String()
 
-	// Debug output if enabled
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("Debug: flawfinder sourceDirs: %v\n", sourceDirs)
-		fmt.Printf("Debug: flawfinder stdout length: %d\n", len(output))
-		fmt.Printf("Debug: flawfinder stderr length: %d\n", stderr.Len())
-		if len(output) > 0 {
-			lines := strings.Split(output, "\n")
-			fmt.Printf("Debug: flawfinder CSV lines: %d (first 3: %v)\n", len(lines), lines[:min(3, len(lines))])
-		}
+	logging.Debug("flawfinder sourceDirs: %v", sourceDirs)
+	logging.Debug("flawfinder stdout length: %d", len(output))
+	logging.Debug("flawfinder stderr length: %d", stderr.Len())
+	if len(output) > 0 && logging.Enabled(logging.LevelDebug) {
+		lines := strings.Split(output, "\n")
+		logging.Debug("flawfinder CSV lines: %d (first 3: %v)", len(lines), lines[:min(3, len(lines))])
 	}
 
 	// Parse CSV output
 	results := parseFlawfinderCSV(output)
 	result.Results = results
 
-	if os.Getenv("CPX_DEBUG") != "" {
-		fmt.Printf("Debug: flawfinder parsed results: %d\n", len(results))
-	}
+	logging.Debug("flawfinder parsed results: %d", len(results))
 
 	return result
 }
@@ -1097,6 +1181,13 @@ func generateHTMLReport(analysis ComprehensiveAnalysis, outputFile string) error
             border-radius: 6px;
             display: inline-block;
         }
+        .comparison {
+            margin-bottom: 40px;
+        }
+        .comparison h2 {
+            margin: 24px 0 16px;
+            font-size: 1.3em;
+        }
         .no-findings {
             text-align: center;
             padding: 60px 40px;
@@ -1182,6 +1273,33 @@ func generateHTMLReport(analysis ComprehensiveAnalysis, outputFile string) error
             {{end}}
         </div>
 
+        {{with .Comparison}}
+        <div class="comparison">
+            <div class="summary">
+                <div class="summary-card">
+                    <h3>New since {{.Previous}}</h3>
+                    <div class="value">{{len .New}}</div>
+                </div>
+                <div class="summary-card">
+                    <h3>Fixed</h3>
+                    <div class="value">{{len .Fixed}}</div>
+                </div>
+                <div class="summary-card">
+                    <h3>Unchanged</h3>
+                    <div class="value">{{.Unchanged}}</div>
+                </div>
+            </div>
+            {{if .New}}
+            <h2>New findings</h2>
+            {{template "comparison-table" .New}}
+            {{end}}
+            {{if .Fixed}}
+            <h2>Fixed findings</h2>
+            {{template "comparison-table" .Fixed}}
+            {{end}}
+        </div>
+        {{end}}
+
         <div class="tabs-container">
             <div class="tabs">
                 {{range $index, $tool := .Tools}}
@@ -1243,7 +1361,7 @@ func generateHTMLReport(analysis ComprehensiveAnalysis, outputFile string) error
                             <td><span class="file-path">{{.File}}</span></td>
                             <td><span class="line-number">{{.Line}}</span></td>
                             <td><span class="message">{{.Message}}</span></td>
-                            <td><span class="rule">{{.Rule}}</span></td>
+                            <td><span class="rule">{{.Rule}}{{if .CWE}} (CWE-{{.CWE}}){{end}}{{if .Inconclusive}} (inconclusive){{end}}</span></td>
                         </tr>
                         {{end}}
                     </tbody>
@@ -1269,7 +1387,34 @@ func generateHTMLReport(analysis ComprehensiveAnalysis, outputFile string) error
         }
     </script>
 </body>
-</html>`
+</html>
+{{define "comparison-table"}}
+<table class="findings-table">
+    <thead>
+        <tr>
+            <th>Severity</th>
+            <th>Tool</th>
+            <th>File</th>
+            <th>Line</th>
+            <th>Message</th>
+            <th>Rule</th>
+        </tr>
+    </thead>
+    <tbody>
+        {{range .}}
+        <tr>
+            <td><span class="severity severity-{{.Severity}}">{{.Severity}}</span></td>
+            <td>{{.Tool}}</td>
+            <td><span class="file-path">{{.File}}</span></td>
+            <td><span class="line-number">{{.Line}}</span></td>
+            <td><span class="message">{{.Message}}</span></td>
+            <td><span class="rule">{{.Rule}}</span></td>
+        </tr>
+        {{end}}
+    </tbody>
+</table>
+{{end}}
+`
 
 	tmpl, err := template.New("report").Parse(htmlTemplate)
 	if err != nil {
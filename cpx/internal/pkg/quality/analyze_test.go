@@ -0,0 +1,133 @@
+package quality
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckThresholds(t *testing.T) {
+	var analysis ComprehensiveAnalysis
+	analysis.Summary.BySeverity = map[string]int{"error": 2, "warning": 51, "style": 100}
+
+	assert.NoError(t, CheckThresholds(analysis, -1, -1))
+	assert.NoError(t, CheckThresholds(analysis, 2, 51))
+
+	err := CheckThresholds(analysis, 0, 50)
+	require.Error(t, err)
+	assert.Equal(t, exitcode.QualityGate, exitcode.Of(err))
+	assert.Contains(t, err.Error(), "2 errors (--max-errors 0) and 51 warnings (--max-warnings 50)")
+
+	err = CheckThresholds(analysis, -1, 50)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "errors")
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var analysis ComprehensiveAnalysis
+	analysis.Summary.BySeverity = map[string]int{}
+	analysis.Summary.ByTool = map[string]int{}
+	analysis.Tools = []ToolResults{{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{{Tool: "Cppcheck", Severity: "error", File: "src/main.cpp", Line: 3, Message: "Null pointer dereference", Rule: "nullPointer"}}}}
+	updateSummary(&analysis, analysis.Tools[0])
+
+	path := filepath.Join(t.TempDir(), "analyze.json")
+	require.NoError(t, writeJSONReport(analysis, path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, float64(1), report["summary"].(map[string]any)["total_findings"])
+	assert.Equal(t, "nullPointer", report["tools"].([]any)[0].(map[string]any)["results"].([]any)[0].(map[string]any)["rule"])
+}
+
+func TestRunAnalysisTasks(t *testing.T) {
+	// Each task waits for the other one: they only finish if they run
+	// concurrently
+	first, second := make(chan struct{}), make(chan struct{})
+	results := runAnalysisTasks([]analysisTask{
+		{"first", func() ToolResults {
+			close(first)
+			<-second
+			return ToolResults{Tool: "first", Status: "success"}
+		}},
+		{"second", func() ToolResults {
+			close(second)
+			<-first
+			return ToolResults{Tool: "second", Status: "skipped", Error: "not found"}
+		}},
+	})
+	assert.Equal(t, []ToolResults{{Tool: "first", Status: "success"}, {Tool: "second", Status: "skipped", Error: "not found"}}, results)
+}
+
+func TestForEachFile(t *testing.T) {
+	files := []string{"a.cpp", "b.cpp", "c.cpp", "d.cpp", "e.cpp"}
+	var running, maxRunning atomic.Int32
+	outputs := forEachFile("test", files, 2, func(file string) string {
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return "out " + file
+	})
+	assert.Equal(t, []string{"out a.cpp", "out b.cpp", "out c.cpp", "out d.cpp", "out e.cpp"}, outputs)
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+
+	assert.Empty(t, forEachFile("test", nil, 0, func(string) string { return "" }))
+}
+
+func TestParseCppcheckXML(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []AnalysisResult
+	}{
+		{
+			fixture: "cppcheck-2.13.xml",
+			want: []AnalysisResult{
+				{Tool: "Cppcheck", Severity: "error", File: "src/main.cpp", Line: 7, Column: 13, Message: "Null pointer dereference: p", Rule: "nullPointer", CWE: 476},
+				{Tool: "Cppcheck", Severity: "error", File: "src/main.cpp", Line: 6, Column: 14, Message: "Null pointer dereference: p", Rule: "nullPointer", CWE: 476},
+				{Tool: "Cppcheck", Severity: "warning", File: "include/buffer.hpp", Line: 12, Column: 5, Message: "Member variable 'Buffer::size_' is not initialized in the constructor.", Rule: "uninitMemberVar", CWE: 398, Inconclusive: true},
+				{Tool: "Cppcheck", Severity: "style", File: "src/util.cpp", Line: 21, Column: 11, Message: `Condition "a<b" is always true`, Rule: "knownConditionTrueFalse", CWE: 571},
+			},
+		},
+		{
+			fixture: "attribute-order.xml",
+			want: []AnalysisResult{
+				{Tool: "Cppcheck", Severity: "performance", File: "src/greeter.cpp", Line: 4, Column: 27, Message: "Function parameter 'name' should be passed by const reference.", Rule: "passedByValue", CWE: 398},
+				{Tool: "Cppcheck", Severity: "style", File: "src/greeter.cpp", Line: 9, Message: "The function 'helper' is never used.", Rule: "unusedFunction"},
+			},
+		},
+		{
+			fixture: "version-1.xml",
+			want: []AnalysisResult{
+				{Tool: "Cppcheck", Severity: "error", File: "src/legacy.c", Line: 14, Message: "Buffer is accessed out of bounds: buf", Rule: "bufferAccessOutOfBounds"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseCppcheckXML(filepath.Join("testdata", "cppcheck", tt.fixture)))
+		})
+	}
+
+	// A truncated report keeps the errors before the truncation
+	data, err := os.ReadFile(filepath.Join("testdata", "cppcheck", "cppcheck-2.13.xml"))
+	require.NoError(t, err)
+	truncated := string(data)[:strings.Index(string(data), `<error id="knownConditionTrueFalse"`)+40]
+	assert.Len(t, parseCppcheckXMLData([]byte(truncated)), 3)
+
+	assert.Empty(t, parseCppcheckXML(filepath.Join("testdata", "cppcheck", "missing.xml")))
+}
@@ -0,0 +1,132 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// BaselineFile holds the findings `cpx analyze --baseline create` accepted,
+// which later runs of cpx analyze leave out of the report
+var BaselineFile = filepath.Join(".cpx", "analysis-baseline.json")
+
+// Baseline is the content of BaselineFile
+type Baseline struct {
+	Version  int             `json:"version"`
+	Created  time.Time       `json:"created"`
+	Findings []BaselineEntry `json:"findings"`
+}
+
+// BaselineEntry is a finding of the baseline and its number of occurrences.
+// It has no line: a finding stays in the baseline when code above it moves.
+type BaselineEntry struct {
+	Tool    string `json:"tool"`
+	Rule    string `json:"rule,omitempty"`
+	File    string `json:"file"`
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+func baselineEntryOf(r AnalysisResult) BaselineEntry {
+	file := r.File
+	if filepath.IsAbs(file) {
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, file); err == nil {
+				file = rel
+			}
+		}
+	}
+	return BaselineEntry{Tool: r.Tool, Rule: r.Rule, File: filepath.ToSlash(file), Message: r.Message}
+}
+
+// NewBaseline returns the baseline of the findings of tools
+func NewBaseline(tools []ToolResults) Baseline {
+	counts := map[BaselineEntry]int{}
+	for _, tool := range tools {
+		if tool.Status == "error" {
+			continue
+		}
+		for _, r := range tool.Results {
+			counts[baselineEntryOf(r)]++
+		}
+	}
+	baseline := Baseline{Version: 1, Created: time.Now().UTC(), Findings: []BaselineEntry{}}
+	for entry, count := range counts {
+		entry.Count = count
+		baseline.Findings = append(baseline.Findings, entry)
+	}
+	sort.Slice(baseline.Findings, func(i, j int) bool {
+		a, b := baseline.Findings[i], baseline.Findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Message < b.Message
+	})
+	return baseline
+}
+
+// WriteBaseline writes baseline to BaselineFile
+func WriteBaseline(baseline Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(BaselineFile), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(BaselineFile), err)
+	}
+	if err := os.WriteFile(BaselineFile, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", BaselineFile, err)
+	}
+	return nil
+}
+
+// LoadBaseline reads BaselineFile. It reports false if there is none.
+func LoadBaseline() (Baseline, bool, error) {
+	var baseline Baseline
+	data, err := os.ReadFile(BaselineFile)
+	if os.IsNotExist(err) {
+		return baseline, false, nil
+	}
+	if err != nil {
+		return baseline, false, err
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return baseline, false, fmt.Errorf("invalid %s: %w\n  hint: recreate it with 'cpx analyze --baseline create'", BaselineFile, err)
+	}
+	return baseline, true, nil
+}
+
+// Subtract removes the findings of the baseline from tools, up to the number
+// of occurrences the baseline accepted, and returns the number removed
+func (b Baseline) Subtract(tools []ToolResults) int {
+	remaining := map[BaselineEntry]int{}
+	for _, entry := range b.Findings {
+		count := entry.Count
+		entry.Count = 0
+		remaining[entry] += count
+	}
+	suppressed := 0
+	for i := range tools {
+		kept := tools[i].Results[:0:0]
+		for _, r := range tools[i].Results {
+			key := baselineEntryOf(r)
+			if remaining[key] > 0 {
+				remaining[key]--
+				suppressed++
+				continue
+			}
+			kept = append(kept, r)
+		}
+		tools[i].Results = kept
+	}
+	return suppressed
+}
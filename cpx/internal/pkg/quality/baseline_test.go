@@ -0,0 +1,37 @@
+package quality
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseline(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	_, ok, err := LoadBaseline()
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	legacy := AnalysisResult{Tool: "Cppcheck", Severity: "style", File: "src/legacy.cpp", Line: 10, Message: "Variable 'x' is assigned a value that is never used.", Rule: "unreadVariable"}
+	require.NoError(t, WriteBaseline(NewBaseline([]ToolResults{
+		{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{legacy, legacy}},
+		{Tool: "Flawfinder", Status: "error", Results: []AnalysisResult{{Tool: "Flawfinder", File: "src/main.cpp", Message: "ignored"}}},
+	})))
+
+	baseline, ok, err := LoadBaseline()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, baseline.Findings, 1)
+	assert.Equal(t, 2, baseline.Findings[0].Count)
+
+	// The legacy findings moved down; a third occurrence and a new finding
+	// are reported
+	moved := legacy
+	moved.Line = 14
+	fresh := AnalysisResult{Tool: "Cppcheck", Severity: "error", File: "src/main.cpp", Line: 3, Message: "Null pointer dereference", Rule: "nullPointer"}
+	tools := []ToolResults{{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{moved, fresh, moved, moved}}}
+	assert.Equal(t, 2, baseline.Subtract(tools))
+	assert.Equal(t, []AnalysisResult{fresh, moved}, tools[0].Results)
+}
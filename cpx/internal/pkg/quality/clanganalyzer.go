@@ -0,0 +1,276 @@
+package quality
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// ClangAnalyzerDir is where cpx analyze writes the plist reports of the Clang
+// Static Analyzer
+var ClangAnalyzerDir = filepath.Join(".cache", "clang-analyzer")
+
+// clangAnalyzerCommand returns the command running the Clang Static Analyzer
+// over the compilation database compileDb and writing plist reports to
+// outputDir: analyze-build, the compilation database mode of scan-build, or
+// CodeChecker
+func clangAnalyzerCommand(compileDb, outputDir string) ([]string, bool) {
+	if _, err := exec.LookPath("analyze-build"); err == nil {
+		return []string{"analyze-build", "--cdb", compileDb, "--plist", "--output", outputDir}, true
+	}
+	if _, err := exec.LookPath("CodeChecker"); err == nil {
+		return []string{"CodeChecker", "analyze", compileDb, "--analyzers", "clangsa", "--output", outputDir}, true
+	}
+	return nil, false
+}
+
+// findCompileDatabase returns the compile_commands.json of compileDbDir, or
+// the first one of the project root and the CMake and Meson build directories
+func findCompileDatabase(compileDbDir string) (string, bool) {
+	dirs := []string{".", "build", "builddir", filepath.Join(".cache", "native", "debug")}
+	if compileDbDir != "" {
+		dirs = []string{compileDbDir}
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, "compile_commands.json")
+		if _, err := os.Stat(path); err == nil {
+			abs, err := filepath.Abs(path)
+			return abs, err == nil
+		}
+	}
+	return "", false
+}
+
+func runClangAnalyzerAnalysis(compileDbDir string) ToolResults {
+	result := ToolResults{
+		Tool:    "Clang Static Analyzer",
+		Status:  "success",
+		Results: []AnalysisResult{},
+	}
+
+	compileDb, ok := findCompileDatabase(compileDbDir)
+	if !ok {
+		result.Status = "skipped"
+		result.Error = "compile_commands.json not found. Run 'cpx build' first."
+		return result
+	}
+	outputDir, err := filepath.Abs(ClangAnalyzerDir)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	args, ok := clangAnalyzerCommand(compileDb, outputDir)
+	if !ok {
+		result.Status = "skipped"
+		result.Error = "analyze-build (scan-build) or CodeChecker not found"
+		return result
+	}
+	if err := os.RemoveAll(outputDir); err != nil {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("failed to clear %s: %v", ClangAnalyzerDir, err)
+		return result
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	// Both tools exit non-zero when they report findings
+	runErr := cmd.Run()
+
+	results, err := collectClangAnalyzerResults(outputDir)
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+		return result
+	}
+	if runErr != nil && len(results) == 0 {
+		if _, ok := runErr.(*exec.ExitError); !ok {
+			result.Status = "error"
+			result.Error = fmt.Sprintf("%s failed: %v", args[0], runErr)
+			return result
+		}
+	}
+	result.Results = results
+	return result
+}
+
+// collectClangAnalyzerResults parses the plist reports under dir
+func collectClangAnalyzerResults(dir string) ([]AnalysisResult, error) {
+	var plists []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && filepath.Ext(path) == ".plist" {
+			plists = append(plists, path)
+		}
+		return nil
+	})
+	sort.Strings(plists)
+
+	results := []AnalysisResult{}
+	seen := map[AnalysisResult]bool{}
+	for _, path := range plists {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		found, err := parseClangAnalyzerPlist(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		// A header is analyzed with each source including it
+		for _, r := range found {
+			if !seen[r] {
+				seen[r] = true
+				results = append(results, r)
+			}
+		}
+	}
+	return results, nil
+}
+
+// parseClangAnalyzerPlist returns the diagnostics of a plist report of the
+// Clang Static Analyzer, as written by clang --analyze, scan-build and
+// CodeChecker
+func parseClangAnalyzerPlist(data []byte) ([]AnalysisResult, error) {
+	value, err := decodePlist(xml.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return nil, err
+	}
+	root, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("not a plist report")
+	}
+	files, _ := root["files"].([]any)
+	diagnostics, _ := root["diagnostics"].([]any)
+
+	results := []AnalysisResult{}
+	for _, d := range diagnostics {
+		diag, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+		r := AnalysisResult{
+			Tool:     "Clang Static Analyzer",
+			Severity: "warning",
+			Message:  plistString(diag["description"]),
+			Rule:     plistString(diag["check_name"]),
+		}
+		if r.Rule == "" {
+			r.Rule = plistString(diag["category"])
+		}
+		if location, ok := diag["location"].(map[string]any); ok {
+			r.Line = plistInt(location["line"])
+			r.Column = plistInt(location["col"])
+			if i := plistInt(location["file"]); i >= 0 && i < len(files) {
+				r.File = plistString(files[i])
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func plistString(v any) string {
+	s, _ := v.(string)
+	return s
+}
+
+func plistInt(v any) int {
+	i, ok := v.(int)
+	if !ok {
+		return -1
+	}
+	return i
+}
+
+// decodePlist decodes the first value of an XML plist: dicts as
+// map[string]any, arrays as []any, integers as int and strings, reals and
+// dates as string
+func decodePlist(d *xml.Decoder) (any, error) {
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no plist value")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local != "plist" {
+			return decodePlistValue(d, start)
+		}
+	}
+}
+
+func decodePlistValue(d *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := map[string]any{}
+		key := ""
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := d.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		array := []any{}
+		for {
+			tok, err := d.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := tok.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(d, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := d.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	if start.Name.Local == "integer" {
+		i, err := strconv.Atoi(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", text)
+		}
+		return i, nil
+	}
+	return text, nil
+}
@@ -0,0 +1,82 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const clangAnalyzerPlist = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+ <key>clang_version</key>
+ <string>clang version 18.1.3</string>
+ <key>diagnostics</key>
+ <array>
+  <dict>
+   <key>path</key>
+   <array>
+    <dict>
+     <key>kind</key><string>event</string>
+     <key>location</key>
+     <dict><key>line</key><integer>4</integer><key>col</key><integer>3</integer><key>file</key><integer>0</integer></dict>
+     <key>extended_message</key><string>&apos;p&apos; initialized to a null pointer value</string>
+    </dict>
+   </array>
+   <key>description</key><string>Dereference of null pointer (loaded from variable &apos;p&apos;)</string>
+   <key>category</key><string>Logic error</string>
+   <key>type</key><string>Dereference of null pointer</string>
+   <key>check_name</key><string>core.NullDereference</string>
+   <key>issue_hash_content_of_line_in_context</key><string>4f7e2b1c</string>
+   <key>location</key>
+   <dict><key>line</key><integer>5</integer><key>col</key><integer>10</integer><key>file</key><integer>1</integer></dict>
+   <key>HTMLDiagnostics_files</key><array></array>
+   <key>cfg</key><false/>
+  </dict>
+  <dict>
+   <key>description</key><string>Value stored to &apos;x&apos; is never read</string>
+   <key>category</key><string>Dead store</string>
+   <key>location</key>
+   <dict><key>line</key><integer>9</integer><key>col</key><integer>5</integer><key>file</key><integer>0</integer></dict>
+  </dict>
+ </array>
+ <key>files</key>
+ <array>
+  <string>/p/src/main.cpp</string>
+  <string>/p/include/util.hpp</string>
+ </array>
+</dict>
+</plist>
+`
+
+func TestParseClangAnalyzerPlist(t *testing.T) {
+	results, err := parseClangAnalyzerPlist([]byte(clangAnalyzerPlist))
+	require.NoError(t, err)
+	assert.Equal(t, []AnalysisResult{
+		{Tool: "Clang Static Analyzer", Severity: "warning", File: "/p/include/util.hpp", Line: 5, Column: 10, Message: "Dereference of null pointer (loaded from variable 'p')", Rule: "core.NullDereference"},
+		{Tool: "Clang Static Analyzer", Severity: "warning", File: "/p/src/main.cpp", Line: 9, Column: 5, Message: "Value stored to 'x' is never read", Rule: "Dead store"},
+	}, results)
+
+	_, err = parseClangAnalyzerPlist([]byte("<plist><array></array></plist>"))
+	assert.Error(t, err)
+}
+
+func TestCollectClangAnalyzerResults(t *testing.T) {
+	dir := t.TempDir()
+	// scan-build writes one report per source into a dated directory; the
+	// header finding is reported by both sources
+	for _, name := range []string{"main.plist", "util.plist"} {
+		path := filepath.Join(dir, "scan-build-2026-10-15", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(clangAnalyzerPlist), 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.html"), nil, 0644))
+
+	results, err := collectClangAnalyzerResults(dir)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
@@ -0,0 +1,87 @@
+package quality
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// qtPackageRe matches the vcpkg ports of Qt 5 and 6, not the third-party
+	// ports named after it like qtkeychain
+	qtPackageRe = regexp.MustCompile(`^(qt|qt5|qt5-[a-z0-9]+|qtbase|qtdeclarative|qtsvg|qttools|qtmultimedia|qtcharts|qtwebsockets|qtnetworkauth|qtserialport|qtwebengine|qt5compat|qtimageformats|qtshadertools)$`)
+	// qtBuildRe matches the use of Qt in CMakeLists.txt and meson.build
+	qtBuildRe = regexp.MustCompile(`find_package\s*\(\s*Qt[0-9]?\b|import\s*\(\s*'qt[0-9]'|dependency\s*\(\s*'qt[0-9]'`)
+)
+
+// UsesQt reports whether the project depends on Qt, in its vcpkg.json or
+// its CMakeLists.txt or meson.build
+func UsesQt() bool {
+	if data, err := os.ReadFile("vcpkg.json"); err == nil {
+		var manifest struct {
+			Dependencies []any `json:"dependencies"`
+		}
+		if json.Unmarshal(data, &manifest) == nil {
+			for _, dep := range manifest.Dependencies {
+				name, _ := dep.(string)
+				if m, ok := dep.(map[string]any); ok {
+					name, _ = m["name"].(string)
+				}
+				if qtPackageRe.MatchString(name) {
+					return true
+				}
+			}
+		}
+	}
+	for _, file := range []string{"CMakeLists.txt", "meson.build"} {
+		if data, err := os.ReadFile(file); err == nil && qtBuildRe.Match(data) {
+			return true
+		}
+	}
+	return false
+}
+
+// runClazyAnalysis runs the Qt checks of clazy over the project sources of
+// the compilation database
+func runClazyAnalysis(files analysisFiles) ToolResults {
+	result := ToolResults{
+		Tool:    "clazy",
+		Status:  "success",
+		Results: []AnalysisResult{},
+	}
+
+	if _, err := exec.LookPath("clazy-standalone"); err != nil {
+		result.Status = "skipped"
+		result.Error = "clazy-standalone not found"
+		return result
+	}
+	if files.CompileDb == "" {
+		result.Status = "skipped"
+		result.Error = "compile_commands.json not found. Run 'cpx build' first."
+		return result
+	}
+	if len(files.Sources) == 0 {
+		result.Status = "skipped"
+		result.Error = "no sources in " + files.CompileDb
+		return result
+	}
+
+	args := append([]string{"-p", filepath.Dir(files.CompileDb)}, files.Sources...)
+	output, _ := exec.Command("clazy-standalone", args...).CombinedOutput()
+	result.Results = parseClazyOutput(string(output))
+	return result
+}
+
+// parseClazyOutput parses the diagnostics of clazy, which have the format of
+// those of clang-tidy with the check as a -Wclazy-<check> flag
+func parseClazyOutput(output string) []AnalysisResult {
+	results := parseClangTidyOutput(output)
+	for i := range results {
+		results[i].Tool = "clazy"
+		results[i].Rule = strings.TrimPrefix(results[i].Rule, "-Wclazy-")
+	}
+	return results
+}
@@ -0,0 +1,34 @@
+package quality
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsesQt(t *testing.T) {
+	t.Chdir(t.TempDir())
+	assert.False(t, UsesQt())
+
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": ["fmt", "qtkeychain-qt6"]}`), 0644))
+	assert.False(t, UsesQt(), "qtkeychain is not part of Qt")
+	require.NoError(t, os.WriteFile("vcpkg.json", []byte(`{"dependencies": ["fmt", {"name": "qtbase", "features": ["widgets"]}]}`), 0644))
+	assert.True(t, UsesQt())
+
+	require.NoError(t, os.Remove("vcpkg.json"))
+	require.NoError(t, os.WriteFile("CMakeLists.txt", []byte("find_package(Qt6 REQUIRED COMPONENTS Core)\n"), 0644))
+	assert.True(t, UsesQt())
+}
+
+func TestParseClazyOutput(t *testing.T) {
+	output := `src/window.cpp:12:5: warning: Use multi-arg instead [-Wclazy-qstring-arg]
+src/window.cpp:20:9: warning: c++11 range-loop might detach Qt container (QList) [-Wclazy-range-loop-detach]
+1 warning generated.
+`
+	assert.Equal(t, []AnalysisResult{
+		{Tool: "clazy", Severity: "warning", File: "src/window.cpp", Line: 12, Column: 5, Message: "Use multi-arg instead", Rule: "qstring-arg"},
+		{Tool: "clazy", Severity: "warning", File: "src/window.cpp", Line: 20, Column: 9, Message: "c++11 range-loop might detach Qt container (QList)", Rule: "range-loop-detach"},
+	}, parseClazyOutput(output))
+}
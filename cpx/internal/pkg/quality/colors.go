@@ -1,9 +0,0 @@
-package quality
-
-// Colors for terminal output
-const (
-	Reset  = "\033[0m"
-	Green  = "\033[32m"
-	Yellow = "\033[33m"
-	Cyan   = "\033[36m"
-)
@@ -0,0 +1,99 @@
+package quality
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// AnalysisComparison classifies the findings of an analysis against those of
+// a previous report
+type AnalysisComparison struct {
+	// Previous is the path of the previous JSON report
+	Previous  string           `json:"previous"`
+	New       []AnalysisResult `json:"new"`
+	Fixed     []AnalysisResult `json:"fixed"`
+	Unchanged int              `json:"unchanged"`
+}
+
+// LoadReport reads a JSON report of cpx analyze --format json
+func LoadReport(path string) (ComprehensiveAnalysis, error) {
+	var analysis ComprehensiveAnalysis
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return analysis, err
+	}
+	if err := json.Unmarshal(data, &analysis); err != nil || analysis.Tools == nil {
+		return analysis, fmt.Errorf("%s is not a JSON report of cpx analyze\n  hint: write one with 'cpx analyze --format json'", path)
+	}
+	return analysis, nil
+}
+
+// CompareAnalyses classifies the findings of current as new, fixed or
+// unchanged since previous. Findings are matched like those of the baseline,
+// without their line, and only for the tools that ran in both analyses: a
+// tool skipped in one of them neither fixes nor adds findings.
+func CompareAnalyses(previous, current ComprehensiveAnalysis) AnalysisComparison {
+	ran := func(analysis ComprehensiveAnalysis) map[string][]AnalysisResult {
+		results := map[string][]AnalysisResult{}
+		for _, tool := range analysis.Tools {
+			if tool.Status == "success" {
+				results[tool.Tool] = tool.Results
+			}
+		}
+		return results
+	}
+	previousResults, currentResults := ran(previous), ran(current)
+
+	comparison := AnalysisComparison{New: []AnalysisResult{}, Fixed: []AnalysisResult{}}
+	for _, tool := range current.Tools {
+		before, ok := previousResults[tool.Tool]
+		after, ran := currentResults[tool.Tool]
+		if !ok || !ran {
+			continue
+		}
+		unmatched := map[BaselineEntry][]AnalysisResult{}
+		for _, r := range before {
+			key := baselineEntryOf(r)
+			unmatched[key] = append(unmatched[key], r)
+		}
+		for _, r := range after {
+			key := baselineEntryOf(r)
+			if len(unmatched[key]) > 0 {
+				unmatched[key] = unmatched[key][1:]
+				comparison.Unchanged++
+				continue
+			}
+			comparison.New = append(comparison.New, r)
+		}
+		for _, r := range before {
+			key := baselineEntryOf(r)
+			if len(unmatched[key]) > 0 {
+				comparison.Fixed = append(comparison.Fixed, unmatched[key][0])
+				unmatched[key] = unmatched[key][1:]
+			}
+		}
+	}
+	return comparison
+}
+
+// printComparison prints the findings comparison adds and fixes
+func printComparison(comparison AnalysisComparison) {
+	fmt.Printf("   Compared with %s: %d new, %d fixed, %d unchanged\n", comparison.Previous, len(comparison.New), len(comparison.Fixed), comparison.Unchanged)
+	for _, r := range comparison.New {
+		fmt.Printf("     + %s\n", formatFinding(r))
+	}
+	for _, r := range comparison.Fixed {
+		fmt.Printf("     - %s\n", formatFinding(r))
+	}
+}
+
+func formatFinding(r AnalysisResult) string {
+	s := fmt.Sprintf("%s:%d: %s: %s", r.File, r.Line, r.Severity, r.Message)
+	if r.Rule != "" {
+		s += " [" + r.Tool + " " + r.Rule + "]"
+	} else {
+		s += " [" + r.Tool + "]"
+	}
+	return s
+}
@@ -0,0 +1,69 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareAnalyses(t *testing.T) {
+	unused := AnalysisResult{Tool: "Cppcheck", Severity: "style", File: "src/a.cpp", Line: 4, Message: "Variable 'x' is never used", Rule: "unusedVariable"}
+	null := AnalysisResult{Tool: "Cppcheck", Severity: "error", File: "src/b.cpp", Line: 9, Message: "Null pointer dereference", Rule: "nullPointer"}
+	leak := AnalysisResult{Tool: "Cppcheck", Severity: "error", File: "src/c.cpp", Line: 2, Message: "Memory leak: p", Rule: "memleak"}
+	gets := AnalysisResult{Tool: "Flawfinder", Severity: "error", File: "src/io.c", Line: 3, Message: "gets is unsafe"}
+
+	previous := ComprehensiveAnalysis{Tools: []ToolResults{
+		{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{unused, null}},
+		{Tool: "Flawfinder", Status: "skipped", Results: []AnalysisResult{}},
+	}}
+	moved := unused
+	moved.Line = 6
+	current := ComprehensiveAnalysis{Tools: []ToolResults{
+		{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{moved, leak}},
+		{Tool: "Flawfinder", Status: "success", Results: []AnalysisResult{gets}},
+	}}
+
+	comparison := CompareAnalyses(previous, current)
+	assert.Equal(t, []AnalysisResult{leak}, comparison.New)
+	assert.Equal(t, []AnalysisResult{null}, comparison.Fixed)
+	assert.Equal(t, 1, comparison.Unchanged)
+}
+
+func TestLoadReport(t *testing.T) {
+	dir := t.TempDir()
+	var analysis ComprehensiveAnalysis
+	analysis.Tools = []ToolResults{{Tool: "Cppcheck", Status: "success", Results: []AnalysisResult{}}}
+	path := filepath.Join(dir, "analyze.json")
+	require.NoError(t, writeJSONReport(analysis, path))
+
+	report, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Cppcheck", report.Tools[0].Tool)
+
+	other := filepath.Join(dir, "package.json")
+	require.NoError(t, os.WriteFile(other, []byte(`{"name": "x"}`), 0644))
+	_, err = LoadReport(other)
+	assert.ErrorContains(t, err, "not a JSON report")
+}
+
+func TestGenerateHTMLReportComparison(t *testing.T) {
+	var analysis ComprehensiveAnalysis
+	analysis.Summary.BySeverity = map[string]int{}
+	analysis.Comparison = &AnalysisComparison{
+		Previous: "main.json",
+		New:      []AnalysisResult{{Tool: "Cppcheck", Severity: "error", File: "src/c.cpp", Line: 2, Message: "Memory leak: p", Rule: "memleak"}},
+		Fixed:    []AnalysisResult{},
+	}
+	path := filepath.Join(t.TempDir(), "analyze.html")
+	require.NoError(t, generateHTMLReport(analysis, path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	html := string(data)
+	assert.Contains(t, html, "New since main.json")
+	assert.Contains(t, html, "Memory leak: p")
+	assert.False(t, strings.Contains(html, "Fixed findings"))
+}
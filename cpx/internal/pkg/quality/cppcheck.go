@@ -4,22 +4,25 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
 // RunCppcheck runs Cppcheck static analysis for C/C++
 func RunCppcheck(enable, output string, xml, csv, quiet, force, inlineSuppr bool, platform, std string, targets []string) error {
 	// Check if cppcheck is available
 	if _, err := exec.LookPath("cppcheck"); err != nil {
-		return fmt.Errorf("cppcheck not found. Please install it first:\n  brew install cppcheck\n  or\n  apt-get install cppcheck (Debian/Ubuntu)\n  or\n  Download from https://cppcheck.sourcecpx.io/")
+		return exitcode.Errorf(exitcode.ToolchainMissing, "cppcheck not found. Please install it first:\n  brew install cppcheck\n  or\n  apt-get install cppcheck (Debian/Ubuntu)\n  or\n  Download from https://cppcheck.sourcecpx.io/")
 	}
 
-	fmt.Printf("%s Running Cppcheck analysis...%s\n", Cyan, Reset)
+	logging.Step("Running Cppcheck analysis...")
 
 	// Filter targets to only include git-tracked files (respect .gitignore)
 	filteredTargets, err := FilterGitTrackedFiles(targets)
 	if err != nil {
 		// If git is not available or not in a git repo, use original targets
-		fmt.Printf("%s Warning: Not in a git repository or git not available. Scanning all files.%s\n", Yellow, Reset)
+		logging.Warn("Not in a git repository or git not available. Scanning all files.")
 		filteredTargets = targets
 	} else if len(filteredTargets) == 0 {
 		return fmt.Errorf("no git-tracked C/C++ files found to scan")
@@ -44,7 +47,7 @@ func RunCppcheck(enable, output string, xml, csv, quiet, force, inlineSuppr bool
 	// Output file
 	if output != "" {
 		cppcheckArgs = append(cppcheckArgs, "--output-file="+output)
-		fmt.Printf("%s Writing output to: %s%s\n", Cyan, output, Reset)
+		logging.Step("Writing output to: %s", output)
 	}
 
 	// Quiet mode
@@ -82,17 +85,17 @@ func RunCppcheck(enable, output string, xml, csv, quiet, force, inlineSuppr bool
 	if err := cmd.Run(); err != nil {
 		// Cppcheck returns non-zero on findings, which is normal
 		if output != "" {
-			fmt.Printf("%s  Cppcheck found potential issues (saved to %s)%s\n", Yellow, output, Reset)
+			logging.Notice("  Cppcheck found potential issues (saved to %s)", output)
 		} else {
-			fmt.Printf("%s  Cppcheck found potential issues%s\n", Yellow, Reset)
+			logging.Notice("  Cppcheck found potential issues")
 		}
 		return nil
 	}
 
 	if output != "" {
-		fmt.Printf("%s Analysis complete! Report saved to: %s%s\n", Green, output, Reset)
+		logging.Success("Analysis complete! Report saved to: %s", output)
 	} else {
-		fmt.Printf("%s No issues found!%s\n", Green, Reset)
+		logging.Success("No issues found!")
 	}
 	return nil
 }
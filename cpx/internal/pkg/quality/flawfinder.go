@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
 // RunFlawfinder runs Flawfinder security analysis for C/C++
 func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet, singleline bool, context int, targets []string) error {
 	// Check if flawfinder is available
 	if _, err := exec.LookPath("flawfinder"); err != nil {
-		return fmt.Errorf("flawfinder not found. Please install it first:\n  pip install flawfinder\n  or\n  brew install flawfinder\n  or\n  apt-get install flawfinder (Debian/Ubuntu)")
+		return exitcode.Errorf(exitcode.ToolchainMissing, "flawfinder not found. Please install it first:\n  pip install flawfinder\n  or\n  brew install flawfinder\n  or\n  apt-get install flawfinder (Debian/Ubuntu)")
 	}
 
 	// Validate output file for HTML/CSV
@@ -18,13 +21,13 @@ func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet,
 		return fmt.Errorf("--output file is required when using --html or --csv flags")
 	}
 
-	fmt.Printf("%s Running Flawfinder analysis...%s\n", Cyan, Reset)
+	logging.Step("Running Flawfinder analysis...")
 
 	// Filter targets to only include git-tracked files (respect .gitignore)
 	filteredTargets, err := FilterGitTrackedFiles(targets)
 	if err != nil {
 		// If git is not available or not in a git repo, use original targets
-		fmt.Printf("%s Warning: Not in a git repository or git not available. Scanning all files.%s\n", Yellow, Reset)
+		logging.Warn("Not in a git repository or git not available. Scanning all files.")
 		filteredTargets = targets
 	} else if len(filteredTargets) == 0 {
 		return fmt.Errorf("no git-tracked C/C++ files found to scan")
@@ -78,7 +81,7 @@ func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet,
 		}
 		defer file.Close()
 		cmd.Stdout = file
-		fmt.Printf("%s Writing output to: %s%s\n", Cyan, output, Reset)
+		logging.Step("Writing output to: %s", output)
 	} else {
 		cmd.Stdout = os.Stdout
 	}
@@ -88,17 +91,17 @@ func RunFlawfinder(minLevel int, csv, html bool, output string, dataflow, quiet,
 	if err := cmd.Run(); err != nil {
 		// Flawfinder returns non-zero on findings, which is normal
 		if output != "" {
-			fmt.Printf("%s  Flawfinder found potential issues (saved to %s)%s\n", Yellow, output, Reset)
+			logging.Notice("  Flawfinder found potential issues (saved to %s)", output)
 		} else {
-			fmt.Printf("%s  Flawfinder found potential issues%s\n", Yellow, Reset)
+			logging.Notice("  Flawfinder found potential issues")
 		}
 		return nil
 	}
 
 	if output != "" {
-		fmt.Printf("%s Analysis complete! Report saved to: %s%s\n", Green, output, Reset)
+		logging.Success("Analysis complete! Report saved to: %s", output)
 	} else {
-		fmt.Printf("%s No issues found!%s\n", Green, Reset)
+		logging.Success("No issues found!")
 	}
 	return nil
 }
@@ -1,21 +1,141 @@
 package quality
 
 import (
+	"bytes"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/pmezard/go-difflib/difflib"
 )
 
-// FormatCode formats C++ source files using clang-format
-func FormatCode(checkOnly bool) error {
+// formatter formats files with a tool
+type formatter struct {
+	tool string
+	// write returns the arguments formatting a file in place, print the
+	// arguments printing it formatted; with stdin, print reads the file from
+	// stdin
+	write func(file string) []string
+	print func(file string) []string
+	stdin bool
+}
+
+var clangFormat = formatter{
+	tool:  "clang-format",
+	write: func(file string) []string { return []string{"-style=file", "-i", file} },
+	print: func(file string) []string { return []string{"-style=file", file} },
+}
+
+// buildFileFormatters are the formatters of build files, each with its
+// alternatives in order of preference, and the files they format
+var buildFileFormatters = []struct {
+	kind       string
+	formatters []formatter
+	match      func(name string) bool
+}{
+	{
+		kind: "CMake",
+		formatters: []formatter{
+			{
+				tool:  "gersemi",
+				write: func(file string) []string { return []string{"-i", file} },
+				print: func(file string) []string { return []string{file} },
+			},
+			{
+				tool:  "cmake-format",
+				write: func(file string) []string { return []string{"-i", file} },
+				print: func(file string) []string { return []string{file} },
+			},
+		},
+		match: func(name string) bool { return name == "CMakeLists.txt" || strings.HasSuffix(name, ".cmake") },
+	},
+	{
+		kind: "Bazel",
+		formatters: []formatter{{
+			tool:  "buildifier",
+			write: func(file string) []string { return []string{file} },
+			print: func(file string) []string { return []string{"--path=" + file} },
+			stdin: true,
+		}},
+		match: func(name string) bool {
+			switch name {
+			case "BUILD", "BUILD.bazel", "MODULE.bazel", "WORKSPACE", "WORKSPACE.bazel":
+				return true
+			}
+			return strings.HasSuffix(name, ".bzl")
+		},
+	},
+	{
+		kind: "Meson",
+		formatters: []formatter{{
+			tool:  "muon",
+			write: func(file string) []string { return []string{"fmt", "-i", file} },
+			print: func(file string) []string { return []string{"fmt", file} },
+		}},
+		match: func(name string) bool {
+			return name == "meson.build" || name == "meson_options.txt" || name == "meson.options"
+		},
+	},
+}
+
+// skippedFmtDirs are the directories of build output and downloaded
+// dependencies whose build files cpx fmt leaves alone
+var skippedFmtDirs = map[string]bool{
+	"build": true, "builddir": true, "out": true, "subprojects": true,
+	"vcpkg_installed": true, "node_modules": true,
+}
+
+// findBuildFiles returns the build files under root that match, skipping
+// hidden, build output and dependency directories
+func findBuildFiles(root string, match func(name string) bool) []string {
+	var files []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "bazel-") || skippedFmtDirs[name]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if match(name) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files
+}
+
+// formatJob is the files a formatter formats
+type formatJob struct {
+	formatter formatter
+	files     []string
+}
+
+// FormatCode formats C++ source files using clang-format, and with
+// buildFiles the build files of CMake, Bazel and Meson whose formatter is
+// installed. With checkOnly it modifies no file: it prints a unified diff of
+// each file that would change and fails if there is any. With staged it only
+// formats the files staged for commit and stages them again; files that also
+// have unstaged changes are only checked, so as not to stage those changes.
+func FormatCode(checkOnly, buildFiles, staged bool) error {
 	// Check if clang-format is available
 	if _, err := exec.LookPath("clang-format"); err != nil {
-		return fmt.Errorf("clang-format not found. Please install it first")
+		return exitcode.Errorf(exitcode.ToolchainMissing, "clang-format not found. Please install it first")
 	}
 
-	fmt.Printf("%s Formatting code...%s\n", Cyan, Reset)
+	if checkOnly {
+		logging.Step("Checking formatting...")
+	} else {
+		logging.Step("Formatting code...")
+	}
 
 	// Find all source files
 	var files []string
@@ -39,41 +159,188 @@ func FormatCode(checkOnly bool) error {
 		})
 	}
 
-	if len(files) == 0 {
-		fmt.Printf("%s No source files found%s\n", Green, Reset)
+	jobs := []formatJob{{formatter: clangFormat, files: files}}
+	if buildFiles {
+		for _, kind := range buildFileFormatters {
+			kindFiles := findBuildFiles(".", kind.match)
+			if len(kindFiles) == 0 {
+				continue
+			}
+			var tools []string
+			found := false
+			for _, f := range kind.formatters {
+				tools = append(tools, f.tool)
+				if _, err := exec.LookPath(f.tool); err == nil {
+					jobs = append(jobs, formatJob{formatter: f, files: kindFiles})
+					found = true
+					break
+				}
+			}
+			if !found {
+				logging.Notice("   Skipping %s files: %s not found", kind.kind, strings.Join(tools, " or "))
+			}
+		}
+	}
+
+	// partial are the staged files with unstaged changes, which are checked
+	// only
+	var partial []formatJob
+	if staged {
+		files, partialFiles, err := StagedFiles()
+		if err != nil {
+			return exitcode.Wrap(exitcode.Usage, err)
+		}
+		if checkOnly {
+			partialFiles = nil
+		}
+		jobs, partial = filterFormatJobs(jobs, files, partialFiles)
+	}
+
+	total := countFormatFiles(jobs) + countFormatFiles(partial)
+	if total == 0 {
+		if staged {
+			logging.Success("No staged files to format")
+		} else {
+			logging.Success("No source files found")
+		}
 		return nil
 	}
 
-	// Format each file
-	formatArgs := []string{"-style=file"}
-	if !checkOnly {
-		formatArgs = append(formatArgs, "-i")
-	} else {
-		formatArgs = append(formatArgs, "--dry-run", "--Werror")
+	if checkOnly {
+		hint := "run 'cpx fmt' to fix them"
+		if staged {
+			hint = "run 'cpx fmt --staged' to fix them"
+		}
+		return checkFormat(jobs, total, hint)
 	}
 
-	needsFormat := false
-	for _, file := range files {
-		args := append(formatArgs, file)
-		cmd := exec.Command("clang-format", args...)
-		output, err := cmd.CombinedOutput()
-
-		if checkOnly && err != nil {
-			needsFormat = true
-			fmt.Printf("   %s %s needs formatting%s\n", Yellow, file, Reset)
-		} else if !checkOnly {
+	// Format each file
+	var restage []string
+	for _, job := range jobs {
+		for _, file := range job.files {
+			original, _ := os.ReadFile(file)
+			exec.Command(job.formatter.tool, job.formatter.write(file)...).Run()
 			fmt.Printf("    %s\n", file)
+			if formatted, err := os.ReadFile(file); staged && err == nil && !bytes.Equal(original, formatted) {
+				restage = append(restage, file)
+			}
 		}
+	}
+	if len(restage) > 0 {
+		if err := StageFiles(restage); err != nil {
+			return err
+		}
+		logging.Info("  Staged the formatting of %d files", len(restage))
+	}
 
-		if len(output) > 0 && checkOnly {
-			fmt.Print(string(output))
+	if len(partial) > 0 {
+		n := countFormatFiles(partial)
+		logging.Notice("  Checking %d files with unstaged changes without formatting them", n)
+		if err := checkFormat(partial, n, "stage or stash their unstaged changes, then run 'cpx fmt --staged' again"); err != nil {
+			return err
 		}
 	}
 
-	if checkOnly && needsFormat {
-		return fmt.Errorf("some files need formatting. Run 'cpx fmt' to fix")
+	logging.Success("Formatted %d files", countFormatFiles(jobs))
+	return nil
+}
+
+// filterFormatJobs returns the jobs for the files of files, and apart the
+// jobs for those that are also in partial
+func filterFormatJobs(jobs []formatJob, files, partial []string) (selected, partialJobs []formatJob) {
+	inFiles := map[string]bool{}
+	for _, file := range files {
+		inFiles[filepath.Clean(file)] = true
+	}
+	inPartial := map[string]bool{}
+	for _, file := range partial {
+		inPartial[filepath.Clean(file)] = true
 	}
 
-	fmt.Printf("%s Formatted %d files%s\n", Green, len(files), Reset)
+	for _, job := range jobs {
+		var kept, checked []string
+		for _, file := range job.files {
+			switch file = filepath.Clean(file); {
+			case inPartial[file]:
+				checked = append(checked, file)
+			case inFiles[file]:
+				kept = append(kept, file)
+			}
+		}
+		if len(kept) > 0 {
+			selected = append(selected, formatJob{formatter: job.formatter, files: kept})
+		}
+		if len(checked) > 0 {
+			partialJobs = append(partialJobs, formatJob{formatter: job.formatter, files: checked})
+		}
+	}
+	return selected, partialJobs
+}
+
+func countFormatFiles(jobs []formatJob) int {
+	total := 0
+	for _, job := range jobs {
+		total += len(job.files)
+	}
+	return total
+}
+
+// checkFormat prints a unified diff of each file its formatter would change
+func checkFormat(jobs []formatJob, total int, hint string) error {
+	unformatted := 0
+	for _, job := range jobs {
+		for _, file := range job.files {
+			original, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			cmd := exec.Command(job.formatter.tool, job.formatter.print(file)...)
+			if job.formatter.stdin {
+				cmd.Stdin = bytes.NewReader(original)
+			}
+			cmd.Stderr = os.Stderr
+			formatted, err := cmd.Output()
+			if err != nil {
+				return fmt.Errorf("%s failed on %s: %w", job.formatter.tool, file, err)
+			}
+			if diff := formatDiff(file, string(original), string(formatted)); diff != "" {
+				unformatted++
+				fmt.Print(diff)
+			}
+		}
+	}
+
+	if unformatted > 0 {
+		return fmt.Errorf("%d of %d files need formatting\n  hint: %s", unformatted, total, hint)
+	}
+	logging.Success("All %d files are formatted", total)
 	return nil
 }
+
+// formatDiff returns the unified diff from the original to the formatted
+// content of a file, or "" if they are equal
+func formatDiff(path, original, formatted string) string {
+	if original == formatted {
+		return ""
+	}
+	slashed := filepath.ToSlash(path)
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        diffLines(original),
+		B:        diffLines(formatted),
+		FromFile: "a/" + slashed,
+		ToFile:   "b/" + slashed,
+		Context:  3,
+	})
+	return diff
+}
+
+// diffLines splits content into lines that keep their newline, as difflib
+// expects, ending the last one with a newline if it has none
+func diffLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
@@ -0,0 +1,84 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatDiff(t *testing.T) {
+	assert.Empty(t, formatDiff("src/main.cpp", "int x;\n", "int x;\n"))
+
+	original := "#include <cstdio>\nint main(){\nreturn 0;\n}\n"
+	formatted := "#include <cstdio>\nint main() {\n  return 0;\n}\n"
+	assert.Equal(t, `--- a/src/main.cpp
++++ b/src/main.cpp
+@@ -1,4 +1,4 @@
+ #include <cstdio>
+-int main(){
+-return 0;
++int main() {
++  return 0;
+ }
+`, formatDiff(filepath.Join("src", "main.cpp"), original, formatted))
+}
+
+func TestFindBuildFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{
+		"CMakeLists.txt",
+		"cmake/deps.cmake",
+		"src/CMakeLists.txt",
+		"build/CMakeLists.txt",
+		".cache/CMakeLists.txt",
+		"bazel-out/BUILD.bazel",
+		"MODULE.bazel",
+		"src/BUILD.bazel",
+		"tools/defs.bzl",
+		"meson.build",
+		"subprojects/fmt/meson.build",
+		"src/main.cpp",
+	} {
+		path := filepath.Join(root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+
+	found := map[string][]string{}
+	for _, kind := range buildFileFormatters {
+		for _, path := range findBuildFiles(root, kind.match) {
+			rel, err := filepath.Rel(root, path)
+			assert.NoError(t, err)
+			found[kind.kind] = append(found[kind.kind], filepath.ToSlash(rel))
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"CMake": {"CMakeLists.txt", "cmake/deps.cmake", "src/CMakeLists.txt"},
+		"Bazel": {"MODULE.bazel", "src/BUILD.bazel", "tools/defs.bzl"},
+		"Meson": {"meson.build"},
+	}, found)
+}
+
+func TestFilterFormatJobs(t *testing.T) {
+	cmakeFormat := formatter{tool: "cmake-format"}
+	jobs := []formatJob{
+		{formatter: clangFormat, files: []string{"src/main.cpp", "src/util.cpp", "include/util.hpp"}},
+		{formatter: cmakeFormat, files: []string{"CMakeLists.txt"}},
+	}
+
+	selected, partial := filterFormatJobs(jobs, []string{"src/util.cpp", "./include/util.hpp", "CMakeLists.txt", "README.md"}, []string{"CMakeLists.txt"})
+	require.Len(t, selected, 1)
+	assert.Equal(t, "clang-format", selected[0].formatter.tool)
+	assert.Equal(t, []string{"src/util.cpp", "include/util.hpp"}, selected[0].files)
+	require.Len(t, partial, 1)
+	assert.Equal(t, "cmake-format", partial[0].formatter.tool)
+	assert.Equal(t, []string{"CMakeLists.txt"}, partial[0].files)
+	assert.Equal(t, 3, countFormatFiles(append(selected, partial...)))
+
+	selected, partial = filterFormatJobs(jobs, nil, nil)
+	assert.Empty(t, selected)
+	assert.Empty(t, partial)
+}
@@ -1,18 +1,23 @@
 package quality
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
 )
 
 // GetGitTrackedCppFiles returns all git-tracked C/C++ source files
 func GetGitTrackedCppFiles() ([]string, error) {
 	// Check if we're in a git repository
 	if _, err := exec.LookPath("git"); err != nil {
-		return nil, fmt.Errorf("git not found")
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "git not found")
 	}
 
 	// Check if current directory is a git repo
@@ -61,7 +66,7 @@ func GetGitTrackedCppFiles() ([]string, error) {
 func FilterGitTrackedFiles(targets []string) ([]string, error) {
 	// Check if we're in a git repository
 	if _, err := exec.LookPath("git"); err != nil {
-		return nil, fmt.Errorf("git not found")
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "git not found")
 	}
 
 	// Check if current directory is a git repo
@@ -128,3 +133,196 @@ func FilterGitTrackedFiles(targets []string) ([]string, error) {
 
 	return trackedCppFiles, nil
 }
+
+// DirtyFiles returns the tracked files with uncommitted changes, which
+// clang-tidy -fix would mix its fixes into
+func DirtyFiles() ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "git not found")
+	}
+	output, err := exec.Command("git", "status", "--porcelain", "--untracked-files=no").Output()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository")
+	}
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if len(line) > 3 {
+			files = append(files, line[3:])
+		}
+	}
+	return files, nil
+}
+
+// cppFileExtensions are the extensions of C/C++ sources and headers
+var cppFileExtensions = map[string]bool{
+	".cpp": true, ".cxx": true, ".cc": true, ".c++": true,
+	".hpp": true, ".hxx": true, ".hh": true, ".h++": true,
+	".c": true, ".h": true,
+	".cppm": true, ".ixx": true, // C++20 modules
+}
+
+// ChangedCppFiles returns the C/C++ files changed since the git ref since,
+// in the index or the working tree, relative to the current directory
+func ChangedCppFiles(since string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "git not found")
+	}
+	output, err := exec.Command("git", "diff", "--name-only", "--relative", "--diff-filter=d", since, "--").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("git diff %s failed: %s", since, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git diff %s failed: %w", since, err)
+	}
+	files := []string{}
+	for _, file := range strings.Split(string(output), "\n") {
+		if file != "" && cppFileExtensions[filepath.Ext(file)] {
+			files = append(files, filepath.FromSlash(file))
+		}
+	}
+	return files, nil
+}
+
+// StagedFiles returns the files staged for commit, relative to the current
+// directory, and those of them with unstaged changes too
+func StagedFiles() (staged, partial []string, err error) {
+	if staged, err = gitDiffFiles("--cached"); err != nil {
+		return nil, nil, err
+	}
+	unstaged, err := gitDiffFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	isUnstaged := map[string]bool{}
+	for _, file := range unstaged {
+		isUnstaged[file] = true
+	}
+	for _, file := range staged {
+		if isUnstaged[file] {
+			partial = append(partial, file)
+		}
+	}
+	return staged, partial, nil
+}
+
+// StagedCppFiles returns the C/C++ files staged for commit
+func StagedCppFiles() ([]string, error) {
+	staged, _, err := StagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, file := range staged {
+		if cppFileExtensions[filepath.Ext(file)] {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// StageFiles adds files to the git index
+func StageFiles(files []string) error {
+	if out, err := exec.Command("git", append([]string{"add", "--"}, files...)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("git add failed: %s", strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gitDiffFiles returns the files git diff args lists, except deleted ones
+func gitDiffFiles(args ...string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "git not found")
+	}
+	args = append([]string{"diff", "--name-only", "--relative", "--diff-filter=d"}, args...)
+	output, err := exec.Command("git", append(args, "--")...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("not in a git repository")
+	}
+	var files []string
+	for _, file := range strings.Split(string(output), "\n") {
+		if file != "" {
+			files = append(files, filepath.FromSlash(file))
+		}
+	}
+	return files, nil
+}
+
+var includeRe = regexp.MustCompile(`^\s*#\s*include\s*[<"]([^>"]+)[>"]`)
+
+// AffectedFiles returns the files of files that changed or include a changed
+// header: a changed header selects the sources of the compilation database
+// including it, directly or through other headers of files. Includes are
+// matched by file name, so a header may select a few sources too many.
+func AffectedFiles(files, changed, sources []string) []string {
+	cwd, _ := os.Getwd()
+	clean := func(file string) string {
+		if filepath.IsAbs(file) && cwd != "" {
+			if rel, err := filepath.Rel(cwd, file); err == nil {
+				return rel
+			}
+		}
+		return filepath.Clean(file)
+	}
+
+	isSource := map[string]bool{}
+	for _, source := range sources {
+		isSource[clean(source)] = true
+	}
+
+	// includers maps a header name to the files including it
+	includers := map[string][]string{}
+	candidates := append(append([]string(nil), files...), sources...)
+	scanned := map[string]bool{}
+	for _, file := range candidates {
+		file = clean(file)
+		if scanned[file] {
+			continue
+		}
+		scanned[file] = true
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if m := includeRe.FindStringSubmatch(line); m != nil {
+				name := path.Base(m[1])
+				includers[name] = append(includers[name], file)
+			}
+		}
+	}
+
+	selected := map[string]bool{}
+	var headers []string
+	for _, file := range changed {
+		file = clean(file)
+		selected[file] = true
+		if !isSource[file] {
+			headers = append(headers, file)
+		}
+	}
+	visited := map[string]bool{}
+	for len(headers) > 0 {
+		header := headers[0]
+		headers = headers[1:]
+		if visited[header] {
+			continue
+		}
+		visited[header] = true
+		for _, file := range includers[filepath.Base(header)] {
+			if isSource[file] {
+				selected[file] = true
+			} else {
+				headers = append(headers, file)
+			}
+		}
+	}
+
+	var affected []string
+	for _, file := range files {
+		if selected[clean(file)] {
+			affected = append(affected, file)
+		}
+	}
+	return affected
+}
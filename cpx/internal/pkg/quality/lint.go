@@ -5,7 +5,13 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
 )
 
 // VcpkgSetup is an interface for vcpkg operations needed by lint
@@ -14,14 +20,15 @@ type VcpkgSetup interface {
 	GetPath() (string, error)
 }
 
-// LintCode runs clang-tidy static analysis
-func LintCode(fix bool, vcpkg VcpkgSetup) error {
+// LintCode runs clang-tidy static analysis. With changed, it lints only the
+// changed files and the sources including a changed header.
+func LintCode(fix bool, changed []string, vcpkg VcpkgSetup) error {
 	// Check if clang-tidy is available
 	if _, err := exec.LookPath("clang-tidy"); err != nil {
-		return fmt.Errorf("clang-tidy not found. Please install it first")
+		return exitcode.Errorf(exitcode.ToolchainMissing, "clang-tidy not found. Please install it first")
 	}
 
-	fmt.Printf("%s Running static analysis...%s\n", Cyan, Reset)
+	logging.Step("Running static analysis...")
 
 	// Set up vcpkg environment
 	if err := vcpkg.SetupEnv(); err != nil {
@@ -36,12 +43,12 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 
 	if _, err := os.Stat(compileDb); os.IsNotExist(err) {
 		needsRegenerate = true
-		fmt.Printf("%s  Generating compile_commands.json...%s\n", Cyan, Reset)
+		logging.Step("  Generating compile_commands.json...")
 	} else {
 		// Check if CMakeCache.txt exists - if not, we need to configure
 		if _, err := os.Stat(filepath.Join(buildDir, "CMakeCache.txt")); os.IsNotExist(err) {
 			needsRegenerate = true
-			fmt.Printf("%s  Regenerating compile_commands.json (CMake not configured)...%s\n", Cyan, Reset)
+			logging.Step("  Regenerating compile_commands.json (CMake not configured)...")
 		}
 	}
 
@@ -56,7 +63,7 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 
 		// Check if toolchain file exists
 		if _, err := os.Stat(toolchainFile); os.IsNotExist(err) {
-			return fmt.Errorf("vcpkg toolchain file not found: %s\n  Make sure vcpkg is properly installed", toolchainFile)
+			return exitcode.Errorf(exitcode.ToolchainMissing, "vcpkg toolchain file not found: %s\n  Make sure vcpkg is properly installed", toolchainFile)
 		}
 
 		// Configure CMake with vcpkg toolchain
@@ -73,10 +80,15 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 		}
 
 		// Check if CMakePresets.json exists and use it
-		if _, err := os.Stat("CMakePresets.json"); err == nil {
-			// Use preset if available
+		if _, err := os.Stat(build.PresetsFile); err == nil {
+			// Use the project's preset
+			preset, err := build.ActivePreset()
+			if err != nil {
+				return err
+			}
 			cmakeArgs = []string{
-				"--preset", "default",
+				"--preset", preset,
+				"-DCPX_PRESET=" + preset,
 				"-B", buildDir,
 				"-DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
 				vcpkgInstallArg,
@@ -92,12 +104,31 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 		}
 	}
 
+	return runClangTidy(fix, changed, buildDir)
+}
+
+// LintWithCompileDatabase runs clang-tidy using the compile_commands.json in
+// buildDir, for projects whose database is not generated by CMake (Bazel)
+func LintWithCompileDatabase(fix bool, changed []string, buildDir string) error {
+	if _, err := exec.LookPath("clang-tidy"); err != nil {
+		return exitcode.Errorf(exitcode.ToolchainMissing, "clang-tidy not found. Please install it first")
+	}
+
+	logging.Step("Running static analysis...")
+	return runClangTidy(fix, changed, buildDir)
+}
+
+// runClangTidy runs clang-tidy on the project sources with the compilation
+// database in buildDir, or with changed on those the changes affect
+func runClangTidy(fix bool, changed []string, buildDir string) error {
+	compileDb := filepath.Join(buildDir, "compile_commands.json")
+
 	// Find source files (only git-tracked files, respect .gitignore)
 	var files []string
 	trackedFiles, err := GetGitTrackedCppFiles()
 	if err != nil {
 		// If not in git repo, fall back to scanning src/include directories
-		fmt.Printf("%s Warning: Not in a git repository. Scanning src/, include/, and current directory.%s\n", Yellow, Reset)
+		logging.Warn("Not in a git repository. Scanning src/, include/, and current directory.")
 		for _, dir := range []string{".", "src", "include"} {
 			if _, err := os.Stat(dir); os.IsNotExist(err) {
 				continue
@@ -135,7 +166,7 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 	}
 
 	if len(files) == 0 {
-		fmt.Printf("%s No source files found%s\n", Green, Reset)
+		logging.Success("No source files found")
 		return nil
 	}
 
@@ -144,6 +175,23 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 		return fmt.Errorf("compile_commands.json not found at %s\n  Run 'cpx build' first to generate it", compileDb)
 	}
 
+	if changed != nil {
+		entries, err := build.MergeCompileDatabases([]build.CompileDatabase{{Path: compileDb}})
+		if err != nil {
+			return err
+		}
+		var sources []string
+		for _, entry := range entries {
+			sources = append(sources, entry.File)
+		}
+		files = AffectedFiles(files, changed, sources)
+		if len(files) == 0 {
+			logging.Success("No changed files to lint")
+			return nil
+		}
+		logging.Info("  Linting %d changed or affected files", len(files))
+	}
+
 	// Get system include paths from the compiler to help clang-tidy find standard headers
 	// This is needed because compile_commands.json might not have all system includes
 	systemIncludes := GetSystemIncludePaths()
@@ -152,7 +200,7 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 	absBuildDir, _ := filepath.Abs(buildDir)
 	tidyArgs := []string{"-p", absBuildDir}
 	if fix {
-		tidyArgs = append(tidyArgs, "-fix")
+		tidyArgs = append(tidyArgs, "-fix", "-format-style=file")
 	}
 	// Add system include paths as extra arguments
 	for _, include := range systemIncludes {
@@ -168,6 +216,9 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 
 	// Check if output contains warnings or errors
 	outputStr := string(output)
+	if fix {
+		reportFixes(ParseTidyFindings(outputStr))
+	}
 	hasWarnings := strings.Contains(outputStr, "warning:") ||
 		strings.Contains(outputStr, "error:") ||
 		strings.Contains(outputStr, "note:")
@@ -175,22 +226,105 @@ func LintCode(fix bool, vcpkg VcpkgSetup) error {
 	if err != nil {
 		// clang-tidy returns non-zero on errors or when warnings are treated as errors
 		if hasWarnings {
-			fmt.Printf("%s  Analysis complete with issues found%s\n", Yellow, Reset)
+			logging.Notice("  Analysis complete with issues found")
 		} else {
-			fmt.Printf("%s  Analysis failed%s\n", Yellow, Reset)
+			logging.Notice("  Analysis failed")
 		}
 		return nil
 	}
 
 	if hasWarnings {
-		fmt.Printf("%s  Analysis complete with warnings%s\n", Yellow, Reset)
+		logging.Notice("  Analysis complete with warnings")
 		return nil
 	}
 
-	fmt.Printf("%s No issues found!%s\n", Green, Reset)
+	logging.Success("No issues found!")
 	return nil
 }
 
+// TidyFinding is a warning or error of clang-tidy
+type TidyFinding struct {
+	File     string
+	Line     int
+	Column   int
+	Severity string
+	Message  string
+	Check    string
+	// Fixed reports whether clang-tidy -fix applied the fix-it of the finding
+	Fixed bool
+}
+
+func (f TidyFinding) String() string {
+	s := fmt.Sprintf("%s:%d:%d: %s", f.File, f.Line, f.Column, f.Message)
+	if f.Check != "" {
+		s += " [" + f.Check + "]"
+	}
+	return s
+}
+
+var (
+	tidyDiagnosticRe = regexp.MustCompile(`^(.+?):(\d+):(\d+): (warning|error|note): (.*?)(?: \[([^\]]+)\])?$`)
+	// tidyFixAppliedNote follows each finding whose fix-it clang-tidy -fix
+	// applied
+	tidyFixAppliedNote = "FIX-IT applied suggested code changes"
+)
+
+// ParseTidyFindings returns the findings of the output of clang-tidy, once
+// each: headers are reported by every translation unit including them
+func ParseTidyFindings(output string) []TidyFinding {
+	var findings []TidyFinding
+	seen := map[string]int{}
+	last := -1
+	for _, line := range strings.Split(output, "\n") {
+		m := tidyDiagnosticRe.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		if m[4] == "note" {
+			if last >= 0 && strings.Contains(m[5], tidyFixAppliedNote) {
+				findings[last].Fixed = true
+			}
+			continue
+		}
+		lineNo, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		f := TidyFinding{File: m[1], Line: lineNo, Column: column, Severity: m[4], Message: m[5], Check: m[6]}
+		key := f.String()
+		if i, ok := seen[key]; ok {
+			last = i
+			continue
+		}
+		seen[key] = len(findings)
+		last = len(findings)
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// reportFixes lists the findings clang-tidy -fix fixed and those that remain
+func reportFixes(findings []TidyFinding) {
+	var fixed, remaining []TidyFinding
+	for _, f := range findings {
+		if f.Fixed {
+			fixed = append(fixed, f)
+		} else {
+			remaining = append(remaining, f)
+		}
+	}
+	if len(fixed) > 0 {
+		logging.Success("Auto-fixed %d findings:", len(fixed))
+		for _, f := range fixed {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+	if len(remaining) > 0 {
+		logging.Notice("  %d findings remain to fix by hand:", len(remaining))
+		for _, f := range remaining {
+			fmt.Printf("    %s\n", f)
+		}
+	}
+}
+
 // GetSystemIncludePaths gets system include paths from the compiler
 func GetSystemIncludePaths() []string {
 	var includes []string
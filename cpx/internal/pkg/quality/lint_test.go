@@ -0,0 +1,108 @@
+package quality
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTidyFindings(t *testing.T) {
+	output := `/p/src/main.cpp:3:5: warning: use nullptr [modernize-use-nullptr]
+    3 |     int *p = 0;
+      |              ^
+/p/src/main.cpp:3:14: note: FIX-IT applied suggested code changes
+/p/include/util.hpp:7:1: warning: function 'f' has cognitive complexity of 30 [readability-function-cognitive-complexity]
+/p/include/util.hpp:9:3: note: +1, including nesting penalty of 0
+/p/include/util.hpp:7:1: warning: function 'f' has cognitive complexity of 30 [readability-function-cognitive-complexity]
+/p/src/io.cpp:12:10: error: 'missing.h' file not found [clang-diagnostic-error]
+clang-tidy applied 1 of 1 suggested fixes.
+`
+	findings := ParseTidyFindings(output)
+	require.Len(t, findings, 3)
+	assert.Equal(t, TidyFinding{File: "/p/src/main.cpp", Line: 3, Column: 5, Severity: "warning", Message: "use nullptr", Check: "modernize-use-nullptr", Fixed: true}, findings[0])
+	assert.False(t, findings[1].Fixed)
+	assert.Equal(t, "/p/include/util.hpp:7:1: function 'f' has cognitive complexity of 30 [readability-function-cognitive-complexity]", findings[1].String())
+	assert.Equal(t, "error", findings[2].Severity)
+	assert.False(t, findings[2].Fixed)
+}
+
+func TestGitChanges(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	t.Chdir(t.TempDir())
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=cpx", "-c", "user.email=cpx@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "-q")
+	require.NoError(t, os.WriteFile("main.cpp", []byte("int main() {}\n"), 0644))
+	git("add", "main.cpp")
+	git("commit", "-qm", "init")
+
+	dirty, err := DirtyFiles()
+	require.NoError(t, err)
+	assert.Empty(t, dirty)
+
+	// Untracked files are not touched by the fixes
+	require.NoError(t, os.WriteFile("notes.txt", nil, 0644))
+	require.NoError(t, os.WriteFile("main.cpp", []byte("int main() { return 0; }\n"), 0644))
+	dirty, err = DirtyFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.cpp"}, dirty)
+
+	require.NoError(t, os.WriteFile("util.hpp", nil, 0644))
+	git("add", "util.hpp")
+	changed, err := ChangedCppFiles("HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.cpp", "util.hpp"}, changed)
+
+	_, err = ChangedCppFiles("no-such-ref")
+	assert.Error(t, err)
+
+	// main.cpp is modified but not staged, util.hpp staged, notes.txt staged
+	// then modified again
+	git("add", "notes.txt")
+	require.NoError(t, os.WriteFile("notes.txt", []byte("todo\n"), 0644))
+	staged, partial, err := StagedFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"notes.txt", "util.hpp"}, staged)
+	assert.Equal(t, []string{"notes.txt"}, partial)
+	stagedCpp, err := StagedCppFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"util.hpp"}, stagedCpp)
+
+	require.NoError(t, StageFiles([]string{"main.cpp"}))
+	stagedCpp, err = StagedCppFiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main.cpp", "util.hpp"}, stagedCpp)
+}
+
+func TestAffectedFiles(t *testing.T) {
+	t.Chdir(t.TempDir())
+	for name, content := range map[string]string{
+		"include/util.hpp":  "#pragma once\n",
+		"include/app.hpp":   "#pragma once\n#include \"util.hpp\"\n",
+		"src/main.cpp":      "#include <app.hpp>\n",
+		"src/util.cpp":      "#include \"../include/util.hpp\"\n",
+		"src/other.cpp":     "#include <vector>\n",
+		"tests/test_io.cpp": "#include \"io.hpp\"\n",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, os.WriteFile(name, []byte(content), 0644))
+	}
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	files := []string{"include/app.hpp", "include/util.hpp", "src/main.cpp", "src/other.cpp", "src/util.cpp", "tests/test_io.cpp"}
+	// compile_commands.json holds absolute paths
+	sources := []string{filepath.Join(cwd, "src/main.cpp"), filepath.Join(cwd, "src/other.cpp"), filepath.Join(cwd, "src/util.cpp"), filepath.Join(cwd, "tests/test_io.cpp")}
+
+	assert.Equal(t, []string{"src/other.cpp"}, AffectedFiles(files, []string{"src/other.cpp"}, sources))
+	assert.Equal(t, []string{"include/util.hpp", "src/main.cpp", "src/util.cpp"}, AffectedFiles(files, []string{"include/util.hpp"}, sources))
+	assert.Empty(t, AffectedFiles(files, []string{"docs/notes.cpp"}, sources))
+}
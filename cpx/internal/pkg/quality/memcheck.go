@@ -0,0 +1,221 @@
+package quality
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+)
+
+// MemcheckResultsFile is where `cpx test --memcheck` stores its findings so
+// `cpx analyze` can include them in the report
+var MemcheckResultsFile = filepath.Join(".cache", "memcheck", "results.json")
+
+// valgrindOutput mirrors the parts of valgrind's --xml=yes output we use
+type valgrindOutput struct {
+	Errors []valgrindError `xml:"error"`
+}
+
+type valgrindError struct {
+	Kind  string `xml:"kind"`
+	What  string `xml:"what"`
+	XWhat struct {
+		Text string `xml:"text"`
+	} `xml:"xwhat"`
+	Stack struct {
+		Frames []valgrindFrame `xml:"frame"`
+	} `xml:"stack"`
+}
+
+type valgrindFrame struct {
+	Fn   string `xml:"fn"`
+	Dir  string `xml:"dir"`
+	File string `xml:"file"`
+	Line int    `xml:"line"`
+}
+
+// MemcheckPrefix returns the valgrind command line that test executables
+// should run under. Each process writes its own XML file into xmlDir.
+func MemcheckPrefix(xmlDir string) ([]string, error) {
+	if _, err := exec.LookPath("valgrind"); err != nil {
+		hint := "install valgrind (e.g. apt install valgrind)"
+		if runtime.GOOS == "darwin" {
+			hint = "valgrind is not available on recent macOS; use 'cpx test --asan' instead"
+		}
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "valgrind not found\n  hint: %s", hint)
+	}
+
+	absDir, err := filepath.Abs(xmlDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(absDir); err != nil {
+		return nil, fmt.Errorf("failed to clear %s: %w", xmlDir, err)
+	}
+	if err := os.MkdirAll(absDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", xmlDir, err)
+	}
+
+	return []string{
+		"valgrind",
+		"--tool=memcheck",
+		"--leak-check=full",
+		"--error-exitcode=1",
+		"--xml=yes",
+		"--xml-file=" + filepath.Join(absDir, "vg-%p.xml"),
+	}, nil
+}
+
+// CollectMemcheckResults parses every valgrind XML file in xmlDir
+func CollectMemcheckResults(xmlDir string) ToolResults {
+	result := ToolResults{
+		Tool:    "Valgrind",
+		Status:  "success",
+		Results: []AnalysisResult{},
+	}
+
+	files, err := filepath.Glob(filepath.Join(xmlDir, "*.xml"))
+	if err != nil || len(files) == 0 {
+		result.Status = "skipped"
+		result.Error = "no valgrind output found"
+		return result
+	}
+
+	cwd, _ := os.Getwd()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		results, err := parseValgrindXML(data, cwd)
+		if err != nil {
+			// Processes killed mid-run leave truncated XML
+			continue
+		}
+		result.Results = append(result.Results, results...)
+	}
+
+	return result
+}
+
+func parseValgrindXML(data []byte, projectDir string) ([]AnalysisResult, error) {
+	var output valgrindOutput
+	if err := xml.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("failed to parse valgrind XML: %w", err)
+	}
+
+	results := []AnalysisResult{}
+	for _, e := range output.Errors {
+		message := e.What
+		if message == "" {
+			message = e.XWhat.Text
+		}
+
+		severity := "error"
+		if e.Kind == "Leak_PossiblyLost" || e.Kind == "Leak_StillReachable" {
+			severity = "warning"
+		}
+
+		frame := pickValgrindFrame(e.Stack.Frames, projectDir)
+		file := frame.File
+		if frame.Dir != "" && file != "" {
+			file = filepath.Join(frame.Dir, file)
+			if rel, err := filepath.Rel(projectDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+				file = rel
+			}
+		}
+		if frame.Fn != "" {
+			message += " (in " + frame.Fn + ")"
+		}
+
+		results = append(results, AnalysisResult{
+			Tool:     "Valgrind",
+			Severity: severity,
+			File:     file,
+			Line:     frame.Line,
+			Message:  message,
+			Rule:     e.Kind,
+		})
+	}
+	return results, nil
+}
+
+// pickValgrindFrame returns the first stack frame inside the project, falling
+// back to the first frame with source information
+func pickValgrindFrame(frames []valgrindFrame, projectDir string) valgrindFrame {
+	var fallback *valgrindFrame
+	for i := range frames {
+		f := frames[i]
+		if f.File == "" {
+			continue
+		}
+		if projectDir != "" && strings.HasPrefix(f.Dir, projectDir) &&
+			!strings.Contains(f.Dir, string(filepath.Separator)+"_deps"+string(filepath.Separator)) {
+			return f
+		}
+		if fallback == nil {
+			fallback = &frames[i]
+		}
+	}
+	if fallback != nil {
+		return *fallback
+	}
+	if len(frames) > 0 {
+		return frames[0]
+	}
+	return valgrindFrame{}
+}
+
+// SaveMemcheckResults writes results to MemcheckResultsFile
+func SaveMemcheckResults(results ToolResults) error {
+	if err := os.MkdirAll(filepath.Dir(MemcheckResultsFile), 0755); err != nil {
+		return fmt.Errorf("failed to create memcheck cache dir: %w", err)
+	}
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode memcheck results: %w", err)
+	}
+	return os.WriteFile(MemcheckResultsFile, data, 0644)
+}
+
+// LoadMemcheckResults reads the results of the last memcheck run, if any
+func LoadMemcheckResults() (ToolResults, bool) {
+	var results ToolResults
+	data, err := os.ReadFile(MemcheckResultsFile)
+	if err != nil {
+		return results, false
+	}
+	if err := json.Unmarshal(data, &results); err != nil {
+		return results, false
+	}
+	return results, true
+}
+
+// PrintMemcheckSummary prints the findings of a memcheck run
+func PrintMemcheckSummary(results ToolResults) {
+	if results.Status == "skipped" {
+		logging.Notice("  Memcheck: %s", results.Error)
+		return
+	}
+	if len(results.Results) == 0 {
+		logging.Success("  Memcheck: no leaks or memory errors found")
+		return
+	}
+
+	logging.Notice("  Memcheck: %d findings", len(results.Results))
+	for _, r := range results.Results {
+		location := r.File
+		if r.Line > 0 {
+			location = fmt.Sprintf("%s:%d", r.File, r.Line)
+		}
+		fmt.Printf("    [%s] %s %s\n", r.Rule, location, r.Message)
+	}
+	fmt.Printf("  Saved to %s (included in 'cpx analyze')\n", MemcheckResultsFile)
+}
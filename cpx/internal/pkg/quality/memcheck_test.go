@@ -0,0 +1,78 @@
+package quality
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleValgrindXML = `<?xml version="1.0"?>
+<valgrindoutput>
+<protocolversion>4</protocolversion>
+<error>
+  <unique>0x0</unique>
+  <tid>1</tid>
+  <kind>InvalidRead</kind>
+  <what>Invalid read of size 4</what>
+  <stack>
+    <frame><ip>0x1</ip><obj>/usr/lib/libc.so.6</obj><fn>memcpy</fn></frame>
+    <frame><ip>0x2</ip><fn>Parser::parse()</fn><dir>/work/app/src</dir><file>parser.cpp</file><line>42</line></frame>
+  </stack>
+</error>
+<error>
+  <unique>0x1</unique>
+  <tid>1</tid>
+  <kind>Leak_PossiblyLost</kind>
+  <xwhat><text>16 bytes in 1 blocks are possibly lost in loss record 1 of 2</text><leakedbytes>16</leakedbytes></xwhat>
+  <stack>
+    <frame><ip>0x3</ip><fn>malloc</fn><dir>/build/valgrind</dir><file>vg_replace_malloc.c</file><line>381</line></frame>
+    <frame><ip>0x4</ip><fn>make_node</fn><dir>/work/app/src</dir><file>node.cpp</file><line>7</line></frame>
+  </stack>
+</error>
+</valgrindoutput>`
+
+func TestParseValgrindXML(t *testing.T) {
+	results, err := parseValgrindXML([]byte(sampleValgrindXML), "/work/app")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "error", results[0].Severity)
+	assert.Equal(t, "InvalidRead", results[0].Rule)
+	assert.Equal(t, filepath.Join("src", "parser.cpp"), results[0].File)
+	assert.Equal(t, 42, results[0].Line)
+	assert.Equal(t, "Invalid read of size 4 (in Parser::parse())", results[0].Message)
+
+	assert.Equal(t, "warning", results[1].Severity)
+	assert.Equal(t, filepath.Join("src", "node.cpp"), results[1].File)
+	assert.Contains(t, results[1].Message, "possibly lost")
+}
+
+func TestMemcheckResultsRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	defer os.Chdir(oldWd)
+	require.NoError(t, os.Chdir(tmpDir))
+
+	_, ok := LoadMemcheckResults()
+	assert.False(t, ok)
+
+	xmlDir := filepath.Join(".cache", "memcheck", "xml")
+	require.NoError(t, os.MkdirAll(xmlDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(xmlDir, "vg-1.xml"), []byte(sampleValgrindXML), 0644))
+	// Truncated output from a killed process is ignored
+	require.NoError(t, os.WriteFile(filepath.Join(xmlDir, "vg-2.xml"), []byte("<valgrindoutput><error>"), 0644))
+
+	results := CollectMemcheckResults(xmlDir)
+	assert.Equal(t, "success", results.Status)
+	assert.Len(t, results.Results, 2)
+
+	require.NoError(t, SaveMemcheckResults(results))
+	loaded, ok := LoadMemcheckResults()
+	require.True(t, ok)
+	assert.Equal(t, "Valgrind", loaded.Tool)
+	assert.Len(t, loaded.Results, 2)
+}
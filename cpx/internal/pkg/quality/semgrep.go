@@ -0,0 +1,144 @@
+package quality
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+)
+
+// semgrepOutput mirrors the parts of semgrep's --json output we use
+type semgrepOutput struct {
+	Results []struct {
+		CheckID string          `json:"check_id"`
+		Path    string          `json:"path"`
+		Start   semgrepPosition `json:"start"`
+		End     semgrepPosition `json:"end"`
+		Extra   struct {
+			Message  string `json:"message"`
+			Severity string `json:"severity"`
+			Lines    string `json:"lines"`
+		} `json:"extra"`
+	} `json:"results"`
+	Errors []struct {
+		Level   string `json:"level"`
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type semgrepPosition struct {
+	Line int `json:"line"`
+	Col  int `json:"col"`
+}
+
+// isLocalRuleset reports whether a semgrep ruleset is a file or directory
+// of rules rather than one of the registry (p/c, r/...) or a URL
+func isLocalRuleset(ruleset string) bool {
+	if _, err := os.Stat(ruleset); err == nil {
+		return true
+	}
+	return strings.HasSuffix(ruleset, ".yml") || strings.HasSuffix(ruleset, ".yaml")
+}
+
+// runSemgrepAnalysis runs the semgrep rulesets of cpx.yaml over the source
+// directories of targets
+func runSemgrepAnalysis(rulesets, targets []string) ToolResults {
+	result := ToolResults{
+		Tool:    "Semgrep",
+		Status:  "success",
+		Results: []AnalysisResult{},
+	}
+
+	if _, err := exec.LookPath("semgrep"); err != nil {
+		result.Status = "skipped"
+		result.Error = "semgrep not found"
+		return result
+	}
+
+	args := []string{"scan", "--json", "--quiet", "--metrics=off"}
+	configs := 0
+	for _, ruleset := range rulesets {
+		// Rulesets of the registry are downloaded
+		if offline.Enabled() && !isLocalRuleset(ruleset) {
+			logging.Notice("   Skipping semgrep ruleset %s: cpx is offline", ruleset)
+			continue
+		}
+		args = append(args, "--config", ruleset)
+		configs++
+	}
+	if configs == 0 {
+		result.Status = "skipped"
+		result.Error = "no semgrep ruleset available offline"
+		return result
+	}
+
+	sourceDirs := discoverSourceDirectories(targets)
+	if len(sourceDirs) == 0 {
+		result.Status = "skipped"
+		result.Error = "no source directories found to scan"
+		return result
+	}
+	args = append(args, sourceDirs...)
+
+	cmd := exec.Command("semgrep", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// semgrep exits non-zero on findings with --error only, and on failures
+	runErr := cmd.Run()
+
+	results, err := parseSemgrepJSON(stdout.Bytes())
+	if err != nil {
+		result.Status = "error"
+		result.Error = fmt.Sprintf("semgrep failed: %v", err)
+		if runErr != nil {
+			result.Error = fmt.Sprintf("semgrep failed: %v: %s", runErr, strings.TrimSpace(stderr.String()))
+		}
+		return result
+	}
+	result.Results = results
+	return result
+}
+
+// parseSemgrepJSON returns the findings of semgrep's --json output. Errors
+// semgrep couldn't recover from (an invalid ruleset) fail the run.
+func parseSemgrepJSON(data []byte) ([]AnalysisResult, error) {
+	var output semgrepOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, err
+	}
+	for _, e := range output.Errors {
+		if strings.EqualFold(e.Level, "error") {
+			return nil, fmt.Errorf("%s", strings.TrimSpace(e.Message))
+		}
+	}
+
+	results := []AnalysisResult{}
+	for _, r := range output.Results {
+		severity := "info"
+		switch strings.ToUpper(r.Extra.Severity) {
+		case "ERROR":
+			severity = "error"
+		case "WARNING":
+			severity = "warning"
+		}
+		results = append(results, AnalysisResult{
+			Tool:      "Semgrep",
+			Severity:  severity,
+			File:      r.Path,
+			Line:      r.Start.Line,
+			Column:    r.Start.Col,
+			EndLine:   r.End.Line,
+			EndColumn: r.End.Col,
+			Message:   strings.TrimSpace(r.Extra.Message),
+			Rule:      r.CheckID,
+			Code:      r.Extra.Lines,
+		})
+	}
+	return results, nil
+}
@@ -0,0 +1,39 @@
+package quality
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSemgrepJSON(t *testing.T) {
+	output := `{
+  "results": [
+    {
+      "check_id": "rules.no-raw-new",
+      "path": "src/main.cpp",
+      "start": {"line": 7, "col": 12, "offset": 80},
+      "end": {"line": 7, "col": 24, "offset": 92},
+      "extra": {"message": "Use std::make_unique instead of new\n", "severity": "WARNING", "lines": "  auto *w = new Widget();"}
+    },
+    {
+      "check_id": "p.c.insecure-use-gets-fn",
+      "path": "src/io.c",
+      "start": {"line": 3, "col": 5},
+      "end": {"line": 3, "col": 14},
+      "extra": {"message": "gets() is unsafe", "severity": "ERROR", "lines": "    gets(buf);"}
+    }
+  ],
+  "errors": [{"level": "warn", "message": "Syntax error at line src/odd.cpp:1"}]
+}`
+	results, err := parseSemgrepJSON([]byte(output))
+	require.NoError(t, err)
+	assert.Equal(t, []AnalysisResult{
+		{Tool: "Semgrep", Severity: "warning", File: "src/main.cpp", Line: 7, Column: 12, EndLine: 7, EndColumn: 24, Message: "Use std::make_unique instead of new", Rule: "rules.no-raw-new", Code: "  auto *w = new Widget();"},
+		{Tool: "Semgrep", Severity: "error", File: "src/io.c", Line: 3, Column: 5, EndLine: 3, EndColumn: 14, Message: "gets() is unsafe", Rule: "p.c.insecure-use-gets-fn", Code: "    gets(buf);"},
+	}, results)
+
+	_, err = parseSemgrepJSON([]byte(`{"results": [], "errors": [{"level": "error", "message": "invalid configuration file found (1 configs were invalid)"}]}`))
+	assert.ErrorContains(t, err, "invalid configuration")
+}
@@ -0,0 +1,186 @@
+package quality
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+)
+
+// analysisFiles are the files the analysis tools check, from the entries of
+// a compilation database. Without one (CompileDb empty), the tools fall back
+// to scanning the source directories.
+type analysisFiles struct {
+	// CompileDb is the absolute path of compile_commands.json
+	CompileDb string
+	// Sources are the translation units of the project, relative to the
+	// project root if they are inside it
+	Sources []string
+	// Headers are the headers of the include directories of the project and
+	// of the directories of its sources
+	Headers []string
+}
+
+var headerExtensions = map[string]bool{
+	".h": true, ".hh": true, ".hpp": true, ".hxx": true, ".h++": true, ".inl": true, ".ipp": true,
+}
+
+// dependencyDirs are the directories of build output and dependencies whose
+// files the compilation database lists but the analysis leaves out
+var dependencyDirs = map[string]bool{
+	".cache": true, "build": true, "builddir": true, "out": true, "external": true,
+	"subprojects": true, "_deps": true, "vcpkg_installed": true, "third_party": true,
+}
+
+// isDependencyPath reports whether rel, relative to the project root, is in
+// a directory of build output or dependencies
+func isDependencyPath(rel string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if dependencyDirs[part] || strings.HasPrefix(part, "bazel-") {
+			return true
+		}
+	}
+	return false
+}
+
+// discoverAnalysisFiles returns the sources of the compilation database of
+// compileDbDir (see findCompileDatabase) that are under targets, leaving out
+// the generated files of its build directory and the sources of
+// dependencies. Sources outside the project root are kept.
+func discoverAnalysisFiles(compileDbDir string, targets []string) analysisFiles {
+	compileDb, ok := findCompileDatabase(compileDbDir)
+	if !ok {
+		return analysisFiles{}
+	}
+	entries, err := build.MergeCompileDatabases([]build.CompileDatabase{{Path: compileDb}})
+	if err != nil {
+		return analysisFiles{}
+	}
+	files := analysisFiles{CompileDb: compileDb}
+
+	root, err := os.Getwd()
+	if err != nil {
+		return analysisFiles{}
+	}
+	// The compile_commands.json of the root links to the build directory
+	buildDir := filepath.Dir(compileDb)
+	if resolved, err := filepath.EvalSymlinks(compileDb); err == nil {
+		buildDir = filepath.Dir(resolved)
+	}
+	project := func(path string) (string, bool) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return "", false
+		}
+		if strings.HasPrefix(path, buildDir+string(filepath.Separator)) || isDependencyPath(rel) {
+			return "", false
+		}
+		if strings.HasPrefix(rel, "..") {
+			return path, true
+		}
+		return rel, true
+	}
+
+	var absTargets []string
+	for _, target := range targets {
+		if target == "." {
+			absTargets = nil
+			break
+		}
+		if abs, err := filepath.Abs(target); err == nil {
+			absTargets = append(absTargets, abs)
+		}
+	}
+	inTargets := func(path string) bool {
+		if len(absTargets) == 0 {
+			return true
+		}
+		for _, target := range absTargets {
+			if path == target || strings.HasPrefix(path, target+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+
+	includeDirs := map[string]bool{}
+	for _, entry := range entries {
+		path := entry.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(entry.Directory, path)
+		}
+		path = filepath.Clean(path)
+		source, ok := project(path)
+		if !ok || !inTargets(path) {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		files.Sources = append(files.Sources, source)
+		includeDirs[filepath.Dir(path)] = true
+		for _, dir := range entryIncludeDirs(entry) {
+			if _, ok := project(dir); ok && inTargets(dir) {
+				includeDirs[dir] = true
+			}
+		}
+	}
+	sort.Strings(files.Sources)
+
+	seen := map[string]bool{}
+	for dir := range includeDirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != dir && (strings.HasPrefix(name, ".") || dependencyDirs[name] || strings.HasPrefix(name, "bazel-") || filepath.Clean(path) == buildDir) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			header, ok := project(path)
+			if ok && headerExtensions[filepath.Ext(path)] && !seen[header] {
+				seen[header] = true
+				files.Headers = append(files.Headers, header)
+			}
+			return nil
+		})
+	}
+	sort.Strings(files.Headers)
+	return files
+}
+
+// entryIncludeDirs returns the absolute -I and -iquote directories of a
+// compilation database entry
+func entryIncludeDirs(entry build.CompileCommand) []string {
+	args := entry.Arguments
+	if len(args) == 0 {
+		args = strings.Fields(entry.Command)
+	}
+	var dirs []string
+	for i, arg := range args {
+		for _, flag := range []string{"-I", "-iquote"} {
+			dir, ok := strings.CutPrefix(arg, flag)
+			if !ok {
+				continue
+			}
+			if dir == "" && i+1 < len(args) {
+				dir = args[i+1]
+			}
+			if dir == "" {
+				continue
+			}
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(entry.Directory, dir)
+			}
+			dirs = append(dirs, filepath.Clean(dir))
+			break
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,66 @@
+package quality
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/build"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverAnalysisFiles(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "app")
+	for _, name := range []string{
+		"app/src/main.cpp",
+		"app/src/internal.hpp",
+		"app/include/app/app.hpp",
+		"app/include/app/detail/impl.ipp",
+		"app/tests/test_main.cpp",
+		"app/build/gen/version.cpp",
+		"app/build/_deps/fmt-src/src/format.cc",
+		"app/build/_deps/fmt-src/include/fmt/format.h",
+		"app/docs/notes.txt",
+		"shared/log.cpp",
+	} {
+		path := filepath.Join(parent, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0644))
+	}
+	buildDir := filepath.Join(root, "build")
+	entry := func(file string, args ...string) build.CompileCommand {
+		return build.CompileCommand{Directory: buildDir, File: file, Arguments: append([]string{"c++", "-c", file}, args...)}
+	}
+	entries := []build.CompileCommand{
+		entry(filepath.Join(root, "src/main.cpp"), "-I../include", "-isystem", filepath.Join(buildDir, "_deps/fmt-src/include")),
+		entry("gen/version.cpp"),
+		entry("_deps/fmt-src/src/format.cc", "-I", "_deps/fmt-src/include"),
+		entry(filepath.Join(parent, "shared/log.cpp")),
+		{Directory: buildDir, File: "../tests/test_main.cpp", Command: "c++ -I" + filepath.Join(root, "src") + " -c ../tests/test_main.cpp"},
+		entry(filepath.Join(root, "src/removed.cpp")),
+	}
+	require.NoError(t, build.WriteCompileDatabase(entries, filepath.Join(buildDir, "compile_commands.json")))
+	require.NoError(t, os.Symlink(filepath.Join(buildDir, "compile_commands.json"), filepath.Join(root, "compile_commands.json")))
+	t.Chdir(root)
+
+	files := discoverAnalysisFiles("", []string{"."})
+	assert.Equal(t, filepath.Join(root, "compile_commands.json"), files.CompileDb)
+	assert.Equal(t, []string{filepath.Join(parent, "shared/log.cpp"), "src/main.cpp", "tests/test_main.cpp"}, files.Sources)
+	assert.Equal(t, []string{"include/app/app.hpp", "include/app/detail/impl.ipp", "src/internal.hpp"}, files.Headers)
+
+	files = discoverAnalysisFiles("build", []string{"tests"})
+	assert.Equal(t, []string{"tests/test_main.cpp"}, files.Sources)
+	assert.Empty(t, files.Headers)
+
+	assert.Empty(t, discoverAnalysisFiles("out", []string{"."}).CompileDb)
+}
+
+func TestEntryIncludeDirs(t *testing.T) {
+	data := `{"directory": "/p/build", "file": "../src/a.cpp", "command": "c++ -I../include -iquote /p/src -isystem /usr/include/x -I gen -c ../src/a.cpp"}`
+	var entry build.CompileCommand
+	require.NoError(t, json.Unmarshal([]byte(data), &entry))
+	assert.Equal(t, []string{"/p/include", "/p/src", "/p/build/gen"}, entryIncludeDirs(entry))
+}
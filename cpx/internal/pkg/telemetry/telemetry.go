@@ -0,0 +1,218 @@
+// Package telemetry records anonymous usage events when the user opts in
+// with `cpx config telemetry on`. An event holds the command (e.g.
+// "cpx build", never its arguments), its duration and exit code, and the
+// version, OS and architecture of cpx: no project data, paths or user names.
+//
+// Events are queued in the cpx config directory and sent in batches as
+// JSON to the configured endpoint:
+//
+//	POST <telemetry_endpoint>
+//	Content-Type: application/json
+//
+//	{"events": [{"command": "cpx build", "duration_ms": 5120, "exit_code": 0,
+//	  "success": true, "os": "linux", "arch": "amd64", "version": "1.4.0",
+//	  "time": "2026-10-15T09:30:00Z"}, ...]}
+//
+// A 2xx response removes the batch from the queue. Nothing is sent offline,
+// without an endpoint, or with CPX_TELEMETRY=0 or DO_NOT_TRACK=1.
+package telemetry
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// EnvVar turns telemetry off when set to a false value, whatever the config
+const EnvVar = "CPX_TELEMETRY"
+
+// EndpointEnvVar overrides the telemetry_endpoint of the config
+const EndpointEnvVar = "CPX_TELEMETRY_ENDPOINT"
+
+const (
+	// BatchSize is the number of queued events that triggers a send
+	BatchSize = 20
+	// maxQueued bounds the queue when events cannot be sent
+	maxQueued = 500
+	// sendTimeout bounds the time a command waits for the endpoint
+	sendTimeout = 2 * time.Second
+)
+
+// Event is an anonymous usage event
+type Event struct {
+	Command    string    `json:"command"`
+	DurationMS int64     `json:"duration_ms"`
+	ExitCode   int       `json:"exit_code"`
+	Success    bool      `json:"success"`
+	OS         string    `json:"os"`
+	Arch       string    `json:"arch"`
+	Version    string    `json:"version"`
+	Time       time.Time `json:"time"`
+}
+
+// batch is the body of a telemetry request
+type batch struct {
+	Events []Event `json:"events"`
+}
+
+// QueuePath returns the file holding the events not sent yet
+func QueuePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "telemetry", "queue.jsonl"), nil
+}
+
+// Enabled reports whether the user opted in and did not opt out through the
+// environment
+func Enabled(cfg *config.GlobalConfig) bool {
+	if cfg == nil || !cfg.Telemetry {
+		return false
+	}
+	if on, err := strconv.ParseBool(os.Getenv(EnvVar)); err == nil && !on {
+		return false
+	}
+	if dnt, err := strconv.ParseBool(os.Getenv("DO_NOT_TRACK")); err == nil && dnt {
+		return false
+	}
+	return true
+}
+
+// Endpoint returns the URL events are sent to, "" if none is configured
+func Endpoint(cfg *config.GlobalConfig) string {
+	if url := os.Getenv(EndpointEnvVar); url != "" {
+		return url
+	}
+	if cfg == nil {
+		return ""
+	}
+	return cfg.TelemetryEndpoint
+}
+
+// Record queues an event for command and sends the queue once it holds a
+// batch. It does nothing unless telemetry is enabled, and never fails the
+// command: errors are returned for debug logging only.
+func Record(command, version string, duration time.Duration, exitCode int) error {
+	cfg, err := config.LoadGlobal()
+	if err != nil || !Enabled(cfg) {
+		return nil
+	}
+
+	event := Event{
+		Command:    command,
+		DurationMS: duration.Milliseconds(),
+		ExitCode:   exitCode,
+		Success:    exitCode == 0,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Version:    version,
+		Time:       time.Now().UTC().Truncate(time.Second),
+	}
+	events, err := Queued()
+	if err != nil {
+		return err
+	}
+	events = append(events, event)
+	if len(events) > maxQueued {
+		events = events[len(events)-maxQueued:]
+	}
+
+	if len(events) >= BatchSize && !offline.Enabled() {
+		if url := Endpoint(cfg); url != "" {
+			if err := send(url, events); err != nil {
+				writeQueue(events)
+				return err
+			}
+			events = nil
+		}
+	}
+	return writeQueue(events)
+}
+
+// Queued returns the events waiting to be sent
+func Queued() ([]Event, error) {
+	path, err := QueuePath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event Event
+		// A line cut short by a crash is dropped
+		if json.Unmarshal(scanner.Bytes(), &event) == nil {
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// Clear deletes the events waiting to be sent
+func Clear() error {
+	path, err := QueuePath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func writeQueue(events []Event) error {
+	if len(events) == 0 {
+		return Clear()
+	}
+	path, err := QueuePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func send(url string, events []Event) error {
+	body, err := json.Marshal(batch{Events: events})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: sendTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("failed to send telemetry: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvVar, "")
+	t.Setenv("DO_NOT_TRACK", "")
+
+	var received []batch
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b batch
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&b))
+		received = append(received, b)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	// Nothing is recorded before opting in
+	require.NoError(t, Record("cpx build", "1.0.0", time.Second, 0))
+	queued, err := Queued()
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+
+	require.NoError(t, config.SaveGlobal(&config.GlobalConfig{Telemetry: true, TelemetryEndpoint: server.URL}))
+	for i := 0; i < BatchSize-1; i++ {
+		require.NoError(t, Record("cpx build", "1.0.0", 1500*time.Millisecond, 5))
+	}
+	queued, err = Queued()
+	require.NoError(t, err)
+	require.Len(t, queued, BatchSize-1)
+	assert.Equal(t, Event{
+		Command: "cpx build", DurationMS: 1500, ExitCode: 5, Success: false,
+		OS: runtime.GOOS, Arch: runtime.GOARCH, Version: "1.0.0", Time: queued[0].Time,
+	}, queued[0])
+	assert.Empty(t, received)
+
+	// A failed send keeps the batch queued
+	status = http.StatusServiceUnavailable
+	assert.Error(t, Record("cpx test", "1.0.0", time.Second, 0))
+	queued, err = Queued()
+	require.NoError(t, err)
+	assert.Len(t, queued, BatchSize)
+
+	status = http.StatusAccepted
+	require.NoError(t, Record("cpx test", "1.0.0", time.Second, 0))
+	require.Len(t, received, 2)
+	assert.Len(t, received[1].Events, BatchSize+1)
+	assert.True(t, received[1].Events[BatchSize].Success)
+	queued, err = Queued()
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+
+	// Opting out through the environment wins over the config
+	t.Setenv("DO_NOT_TRACK", "1")
+	require.NoError(t, Record("cpx build", "1.0.0", time.Second, 0))
+	queued, err = Queued()
+	require.NoError(t, err)
+	assert.Empty(t, queued)
+}
+
+func TestQueuedSkipsTruncatedLines(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	path, err := QueuePath()
+	require.NoError(t, err)
+	require.NoError(t, writeQueue([]Event{{Command: "cpx fmt"}}))
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"command": "cpx li`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	queued, err := Queued()
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "cpx fmt", queued[0].Command)
+
+	require.NoError(t, Clear())
+	require.NoError(t, Clear())
+	assert.NoFileExists(t, path)
+}
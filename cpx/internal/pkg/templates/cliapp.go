@@ -0,0 +1,411 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/naming"
+)
+
+// ============================================================================
+// CLI APPLICATION TEMPLATES
+// ============================================================================
+
+// CLI11 release fetched by plain CMake projects and required from the Bazel
+// Central Registry; vcpkg and Meson (wrap) projects use their own pins
+const cli11Version = "2.4.2"
+
+// CLIAppDependencies are the vcpkg ports a CLI application project needs
+var CLIAppDependencies = []string{"cli11"}
+
+// CLIAppSources holds the command-line parsing of a CLI application project
+type CLIAppSources struct {
+	Header string // include/<project>/cli.hpp
+	Source string // src/cli.cpp
+	Main   string // src/main.cpp, parses the command line and runs it
+	// Test is tests/cli_test.cpp, or empty without a test framework
+	Test string
+}
+
+// GenerateCLIAppSources generates a CLI11 parser with a greet subcommand and
+// a version subcommand, a main running it and a test exercising the parser
+func GenerateCLIAppSources(projectName, testFramework string) *CLIAppSources {
+	safeName := naming.SafeIdent(projectName)
+	upperName := naming.SafeIdentUpper(projectName)
+	guard := upperName + "_CLI_HPP"
+
+	header := fmt.Sprintf(`#ifndef %s
+#define %s
+
+#include <CLI/CLI.hpp>
+
+#include <ostream>
+#include <string>
+
+namespace %s {
+
+/**
+ * @brief Parsed command line
+ */
+struct Options {
+    std::string command;  // selected subcommand
+    std::string name = "world";
+    int count = 1;
+    bool shout = false;
+};
+
+/**
+ * @brief Registers the subcommands and flags on app; parsing fills options
+ */
+void setup_cli(CLI::App& app, Options& options);
+
+/**
+ * @brief Runs the parsed command, writing its output to out
+ * @return Process exit code
+ */
+int run_command(const Options& options, std::ostream& out);
+
+}  // namespace %s
+
+#endif  // %s
+`, guard, guard, safeName, safeName, guard)
+
+	source := fmt.Sprintf(`#include <%s/cli.hpp>
+#include <%s/version.hpp>
+
+#include <algorithm>
+#include <cctype>
+
+namespace %s {
+
+void setup_cli(CLI::App& app, Options& options) {
+    app.require_subcommand(1);
+    app.set_version_flag("--version", %s_VERSION);
+
+    auto* greet = app.add_subcommand("greet", "Print a greeting");
+    greet->add_option("-n,--name", options.name, "Who to greet")->capture_default_str();
+    greet->add_option("-c,--count", options.count, "How many times")
+        ->check(CLI::PositiveNumber)
+        ->capture_default_str();
+    greet->add_flag("-s,--shout", options.shout, "Greet in upper case");
+    greet->callback([&options] { options.command = "greet"; });
+
+    auto* ver = app.add_subcommand("version", "Print the version");
+    ver->callback([&options] { options.command = "version"; });
+}
+
+int run_command(const Options& options, std::ostream& out) {
+    if (options.command == "version") {
+        out << %s_VERSION << "\n";
+        return 0;
+    }
+
+    std::string greeting = "Hello, " + options.name + "!";
+    if (options.shout) {
+        std::transform(greeting.begin(), greeting.end(), greeting.begin(),
+                       [](unsigned char c) { return static_cast<char>(std::toupper(c)); });
+    }
+    for (int i = 0; i < options.count; ++i) {
+        out << greeting << "\n";
+    }
+    return 0;
+}
+
+}  // namespace %s
+`, projectName, projectName, safeName, upperName, upperName, safeName)
+
+	main := fmt.Sprintf(`#include <%s/cli.hpp>
+
+#include <iostream>
+
+int main(int argc, char** argv) {
+    CLI::App app{"%s command-line tool", "%s"};
+    %s::Options options;
+    %s::setup_cli(app, options);
+    CLI11_PARSE(app, argc, argv);
+    return %s::run_command(options, std::cout);
+}
+`, projectName, projectName, projectName, safeName, safeName, safeName)
+
+	return &CLIAppSources{
+		Header: header,
+		Source: source,
+		Main:   main,
+		Test:   generateCLIAppTest(projectName, safeName, testFramework),
+	}
+}
+
+// generateCLIAppTest generates tests/cli_test.cpp. The test is an executable
+// of its own, so the doctest variant brings its main.
+func generateCLIAppTest(projectName, safeName, testFramework string) string {
+	// parse runs the parser on a command line (without the program name)
+	helper := fmt.Sprintf(`namespace {
+
+%s::Options parse(const std::string& args) {
+    CLI::App app;
+    %s::Options options;
+    %s::setup_cli(app, options);
+    app.parse(args, false);
+    return options;
+}
+
+}  // namespace
+`, safeName, safeName, safeName)
+
+	switch testFramework {
+	case "googletest":
+		return fmt.Sprintf(`#include <gtest/gtest.h>
+#include <%s/cli.hpp>
+
+#include <sstream>
+
+%s
+TEST(CliTest, ParsesGreetOptions) {
+    auto options = parse("greet --name cpx --count 2");
+    EXPECT_EQ(options.command, "greet");
+    EXPECT_EQ(options.name, "cpx");
+    EXPECT_EQ(options.count, 2);
+    EXPECT_FALSE(options.shout);
+}
+
+TEST(CliTest, RunsGreet) {
+    std::ostringstream out;
+    EXPECT_EQ(%s::run_command(parse("greet -n cpx -c 2 --shout"), out), 0);
+    EXPECT_EQ(out.str(), "HELLO, CPX!\nHELLO, CPX!\n");
+}
+
+TEST(CliTest, RejectsInvalidArguments) {
+    EXPECT_THROW(parse(""), CLI::RequiredError);
+    EXPECT_THROW(parse("greet --count 0"), CLI::ValidationError);
+    EXPECT_THROW(parse("greet --unknown"), CLI::ExtrasError);
+}
+`, projectName, helper, safeName)
+	case "catch2":
+		return fmt.Sprintf(`#include <catch2/catch_test_macros.hpp>
+#include <%s/cli.hpp>
+
+#include <sstream>
+
+%s
+TEST_CASE("greet options are parsed", "[cli]") {
+    auto options = parse("greet --name cpx --count 2");
+    REQUIRE(options.command == "greet");
+    REQUIRE(options.name == "cpx");
+    REQUIRE(options.count == 2);
+    REQUIRE_FALSE(options.shout);
+}
+
+TEST_CASE("greet prints the greeting", "[cli]") {
+    std::ostringstream out;
+    REQUIRE(%s::run_command(parse("greet -n cpx -c 2 --shout"), out) == 0);
+    REQUIRE(out.str() == "HELLO, CPX!\nHELLO, CPX!\n");
+}
+
+TEST_CASE("invalid arguments are rejected", "[cli]") {
+    REQUIRE_THROWS_AS(parse(""), CLI::RequiredError);
+    REQUIRE_THROWS_AS(parse("greet --count 0"), CLI::ValidationError);
+    REQUIRE_THROWS_AS(parse("greet --unknown"), CLI::ExtrasError);
+}
+`, projectName, helper, safeName)
+	case "doctest":
+		return fmt.Sprintf(`#define DOCTEST_CONFIG_IMPLEMENT_WITH_MAIN
+#include <doctest/doctest.h>
+#include <%s/cli.hpp>
+
+#include <sstream>
+
+%s
+TEST_CASE("greet options are parsed") {
+    auto options = parse("greet --name cpx --count 2");
+    CHECK(options.command == "greet");
+    CHECK(options.name == "cpx");
+    CHECK(options.count == 2);
+    CHECK_FALSE(options.shout);
+}
+
+TEST_CASE("greet prints the greeting") {
+    std::ostringstream out;
+    CHECK(%s::run_command(parse("greet -n cpx -c 2 --shout"), out) == 0);
+    CHECK(out.str() == "HELLO, CPX!\nHELLO, CPX!\n");
+}
+
+TEST_CASE("invalid arguments are rejected") {
+    CHECK_THROWS_AS(parse(""), CLI::RequiredError);
+    CHECK_THROWS_AS(parse("greet --count 0"), CLI::ValidationError);
+    CHECK_THROWS_AS(parse("greet --unknown"), CLI::ExtrasError);
+}
+`, projectName, helper, safeName)
+	default:
+		return ""
+	}
+}
+
+// GenerateCLIAppCMake generates the CMake section that builds the parser as
+// <project>_cli and links it into the project executable. Without vcpkg,
+// CLI11 comes from FetchContent.
+func GenerateCLIAppCMake(projectName string, vcpkg bool) string {
+	find := "find_package(CLI11 CONFIG REQUIRED)"
+	if !vcpkg {
+		find = fmt.Sprintf(`include(FetchContent)
+FetchContent_Declare(
+    CLI11
+    GIT_REPOSITORY https://github.com/CLIUtils/CLI11.git
+    GIT_TAG v%s
+)
+FetchContent_MakeAvailable(CLI11)`, cli11Version)
+	}
+	return fmt.Sprintf(`
+# Command-line parsing (CLI11)
+%s
+
+add_library(%s_cli STATIC src/cli.cpp)
+target_include_directories(%s_cli PUBLIC $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>)
+target_link_libraries(%s_cli PUBLIC CLI11::CLI11)
+target_link_libraries(%s PRIVATE %s_cli)
+`, find, projectName, projectName, projectName, projectName, projectName)
+}
+
+// GenerateCLIAppTestCMake generates the tests/CMakeLists.txt section that
+// builds and registers the parser test
+func GenerateCLIAppTestCMake(projectName, testFramework string) string {
+	var link, register string
+	switch testFramework {
+	case "googletest":
+		link, register = " gtest gtest_main", fmt.Sprintf("gtest_discover_tests(%s_cli_tests)", projectName)
+	case "catch2":
+		link, register = " Catch2::Catch2WithMain", fmt.Sprintf("catch_discover_tests(%s_cli_tests)", projectName)
+	case "doctest":
+		link = " doctest::doctest"
+	}
+	if register == "" {
+		register = fmt.Sprintf("add_test(NAME %s_cli_tests COMMAND %s_cli_tests)", projectName, projectName)
+	}
+	return fmt.Sprintf(`
+# CLI parser tests (%s_cli is defined in the root CMakeLists.txt)
+add_executable(%s_cli_tests cli_test.cpp)
+target_link_libraries(%s_cli_tests PRIVATE %s_cli%s)
+%s
+`, projectName, projectName, projectName, projectName, link, register)
+}
+
+// GenerateCLIAppMesonSrc generates src/meson.build for a CLI application:
+// the parser is built as <project>_cli and linked into the executable
+func GenerateCLIAppMesonSrc(projectName string) string {
+	safeName := naming.SafeIdent(projectName)
+	return fmt.Sprintf(`# Command-line parsing (CLI11, subprojects/cli11.wrap)
+cli11_dep = dependency('CLI11', fallback : ['cli11', 'CLI11_dep'])
+
+# Library (for linking by tests/benchmarks)
+%s_lib = static_library('%s_lib',
+  files('%s.cpp'),
+  include_directories : inc_dirs,
+  install : true
+)
+
+# Parser (for linking by tests)
+%s_cli = static_library('%s_cli',
+  files('cli.cpp'),
+  include_directories : inc_dirs,
+  dependencies : cli11_dep
+)
+
+# Executable
+%s_exe = executable('%s',
+  files('main.cpp'),
+  include_directories : inc_dirs,
+  link_with : [%s_cli, %s_lib],
+  dependencies : cli11_dep,
+  install : true
+)
+`, safeName, safeName, projectName, safeName, safeName, safeName, projectName, safeName, safeName)
+}
+
+// GenerateCLIAppMesonTests generates the tests/meson.build section that
+// builds and registers the parser test
+func GenerateCLIAppMesonTests(projectName, testFramework string) string {
+	safeName := naming.SafeIdent(projectName)
+	deps := "cli11_dep"
+	switch testFramework {
+	case "googletest":
+		deps += ", gtest_dep"
+	case "catch2":
+		deps += ", catch2_dep"
+	case "doctest":
+		deps += ", doctest_dep"
+	}
+	return fmt.Sprintf(`
+# CLI parser tests
+cli_test_exe = executable('%s_cli_test',
+  files('cli_test.cpp'),
+  include_directories : inc_dirs,
+  link_with : %s_cli,
+  dependencies : [%s]
+)
+
+test('%s cli tests', cli_test_exe)
+`, projectName, safeName, deps, projectName)
+}
+
+// GenerateCLIAppBazelModule generates the MODULE.bazel line for CLI11
+func GenerateCLIAppBazelModule() string {
+	return fmt.Sprintf("bazel_dep(name = \"cli11\", version = \"%s\")\n", cli11Version)
+}
+
+// GenerateCLIAppBazelSrc generates src/BUILD.bazel for a CLI application:
+// the parser is built as <project>_cli and linked into the binary
+func GenerateCLIAppBazelSrc(projectName string) string {
+	return fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_binary", "cc_library")
+
+# Core library
+cc_library(
+    name = "%s_lib",
+    srcs = ["%s.cpp"],
+    deps = ["//include:%s_headers"],
+    visibility = ["//visibility:public"],
+)
+
+# Command-line parsing (CLI11)
+cc_library(
+    name = "%s_cli",
+    srcs = ["cli.cpp"],
+    deps = [
+        "//include:%s_headers",
+        "@cli11",
+    ],
+    visibility = ["//visibility:public"],
+)
+
+# Main executable
+cc_binary(
+    name = "%s",
+    srcs = ["main.cpp"],
+    deps = [
+        ":%s_cli",
+        ":%s_lib",
+    ],
+    visibility = ["//visibility:public"],
+)
+`, projectName, projectName, projectName, projectName, projectName, projectName, projectName, projectName)
+}
+
+// GenerateCLIAppBazelTests generates the tests/BUILD.bazel target for the
+// parser test
+func GenerateCLIAppBazelTests(projectName, testFramework string) string {
+	framework := ""
+	switch testFramework {
+	case "googletest":
+		framework = "\n        \"@googletest//:gtest_main\","
+	case "catch2":
+		framework = "\n        \"@catch2//:catch2_main\","
+	case "doctest":
+		framework = "\n        \"@doctest//:doctest\","
+	}
+	return fmt.Sprintf(`
+cc_test(
+    name = "%s_cli_test",
+    srcs = ["cli_test.cpp"],
+    deps = [
+        "//src:%s_cli",%s
+    ],
+)
+`, projectName, projectName, framework)
+}
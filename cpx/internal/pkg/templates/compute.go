@@ -0,0 +1,308 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/naming"
+)
+
+// ============================================================================
+// COMPUTE BACKEND TEMPLATES
+// ============================================================================
+
+// Compute backends offered by `cpx new`
+const (
+	ComputeNone   = "none"
+	ComputeOpenMP = "openmp"
+	ComputeCUDA   = "cuda"
+	ComputeSYCL   = "sycl"
+)
+
+// computeMarker starts the comment that introduces the compute backend
+// section of a generated build file; ParseComputeBackend looks for it
+const computeMarker = "# Compute backend: "
+
+// ComputeSources holds the sample parallel kernel for a compute backend
+type ComputeSources struct {
+	Header     string // include/<project>/parallel.hpp
+	Source     string
+	SourceFile string // file name under src/
+}
+
+// ComputeBackendName returns the display name of a compute backend
+func ComputeBackendName(backend string) string {
+	switch backend {
+	case ComputeOpenMP:
+		return "OpenMP"
+	case ComputeCUDA:
+		return "CUDA"
+	case ComputeSYCL:
+		return "SYCL"
+	default:
+		return "None"
+	}
+}
+
+// ParseComputeBackend returns the compute backend recorded in a generated
+// build file (CMakeLists.txt, src/meson.build or src/BUILD.bazel), or
+// ComputeNone
+func ParseComputeBackend(buildFile string) string {
+	for _, line := range strings.Split(buildFile, "\n") {
+		name, ok := strings.CutPrefix(strings.TrimSpace(line), computeMarker)
+		if !ok {
+			continue
+		}
+		name = strings.ToLower(strings.Fields(name + " ")[0])
+		switch name {
+		case ComputeOpenMP, ComputeCUDA, ComputeSYCL:
+			return name
+		}
+	}
+	return ComputeNone
+}
+
+// GenerateComputeSources generates the vector_add sample kernel for backend,
+// or nil if no compute backend is selected
+func GenerateComputeSources(projectName, backend string) *ComputeSources {
+	safeName := naming.SafeIdent(projectName)
+	guard := naming.SafeIdentUpper(projectName) + "_PARALLEL_HPP"
+
+	var source, sourceFile string
+	switch backend {
+	case ComputeOpenMP:
+		source, sourceFile = generateOpenMPKernel(projectName, safeName), "parallel.cpp"
+	case ComputeCUDA:
+		source, sourceFile = generateCUDAKernel(projectName, safeName), "parallel.cu"
+	case ComputeSYCL:
+		source, sourceFile = generateSYCLKernel(projectName, safeName), "parallel.cpp"
+	default:
+		return nil
+	}
+
+	header := fmt.Sprintf(`#ifndef %s
+#define %s
+
+#include <cstddef>
+
+namespace %s {
+
+/**
+ * @brief Element-wise vector addition: out[i] = a[i] + b[i]
+ *
+ * Sample parallel kernel running on the %s backend.
+ */
+void vector_add(const float* a, const float* b, float* out, std::size_t n);
+
+}  // namespace %s
+
+#endif  // %s
+`, guard, guard, safeName, ComputeBackendName(backend), safeName, guard)
+
+	return &ComputeSources{Header: header, Source: source, SourceFile: sourceFile}
+}
+
+func generateOpenMPKernel(projectName, safeName string) string {
+	return fmt.Sprintf(`#include <%s/parallel.hpp>
+
+#include <cstddef>
+
+namespace %s {
+
+void vector_add(const float* a, const float* b, float* out, std::size_t n) {
+    const auto count = static_cast<std::ptrdiff_t>(n);
+#pragma omp parallel for
+    for (std::ptrdiff_t i = 0; i < count; ++i) {
+        out[i] = a[i] + b[i];
+    }
+}
+
+}  // namespace %s
+`, projectName, safeName, safeName)
+}
+
+func generateCUDAKernel(projectName, safeName string) string {
+	return fmt.Sprintf(`#include <%s/parallel.hpp>
+
+#include <cuda_runtime.h>
+
+#include <stdexcept>
+
+namespace %s {
+
+namespace {
+
+__global__ void vector_add_kernel(const float* a, const float* b, float* out, std::size_t n) {
+    const std::size_t i = static_cast<std::size_t>(blockIdx.x) * blockDim.x + threadIdx.x;
+    if (i < n) {
+        out[i] = a[i] + b[i];
+    }
+}
+
+void check(cudaError_t err) {
+    if (err != cudaSuccess) {
+        throw std::runtime_error(cudaGetErrorString(err));
+    }
+}
+
+}  // namespace
+
+void vector_add(const float* a, const float* b, float* out, std::size_t n) {
+    const std::size_t bytes = n * sizeof(float);
+    float* d_a = nullptr;
+    float* d_b = nullptr;
+    float* d_out = nullptr;
+    check(cudaMalloc(&d_a, bytes));
+    check(cudaMalloc(&d_b, bytes));
+    check(cudaMalloc(&d_out, bytes));
+    check(cudaMemcpy(d_a, a, bytes, cudaMemcpyHostToDevice));
+    check(cudaMemcpy(d_b, b, bytes, cudaMemcpyHostToDevice));
+
+    const unsigned int threads = 256;
+    const auto blocks = static_cast<unsigned int>((n + threads - 1) / threads);
+    vector_add_kernel<<<blocks, threads>>>(d_a, d_b, d_out, n);
+    check(cudaGetLastError());
+
+    check(cudaMemcpy(out, d_out, bytes, cudaMemcpyDeviceToHost));
+    cudaFree(d_a);
+    cudaFree(d_b);
+    cudaFree(d_out);
+}
+
+}  // namespace %s
+`, projectName, safeName, safeName)
+}
+
+func generateSYCLKernel(projectName, safeName string) string {
+	return fmt.Sprintf(`#include <%s/parallel.hpp>
+
+#include <sycl/sycl.hpp>
+
+namespace %s {
+
+void vector_add(const float* a, const float* b, float* out, std::size_t n) {
+    sycl::queue queue;
+    {
+        sycl::buffer<float> buf_a(a, sycl::range<1>(n));
+        sycl::buffer<float> buf_b(b, sycl::range<1>(n));
+        sycl::buffer<float> buf_out(out, sycl::range<1>(n));
+
+        queue.submit([&](sycl::handler& h) {
+            sycl::accessor in_a(buf_a, h, sycl::read_only);
+            sycl::accessor in_b(buf_b, h, sycl::read_only);
+            sycl::accessor result(buf_out, h, sycl::write_only, sycl::no_init);
+            h.parallel_for(sycl::range<1>(n), [=](sycl::id<1> i) { result[i] = in_a[i] + in_b[i]; });
+        });
+    }  // buffers copy the result back to out when destroyed
+}
+
+}  // namespace %s
+`, projectName, safeName, safeName)
+}
+
+// cudaStandard returns the C++ standard CUDA sources are compiled with: the
+// project's, capped at C++20, the newest nvcc supports
+func cudaStandard(cppStandard int) int {
+	return min(cppStandard, 20)
+}
+
+// GenerateComputeCMake generates the CMake section that adds the sample
+// kernel and the backend's packages and flags to the project target
+func GenerateComputeCMake(projectName, backend string, cppStandard int) string {
+	switch backend {
+	case ComputeOpenMP:
+		return fmt.Sprintf(`
+%sOpenMP
+find_package(OpenMP REQUIRED)
+target_sources(%s PRIVATE src/parallel.cpp)
+target_link_libraries(%s PUBLIC OpenMP::OpenMP_CXX)
+`, computeMarker, projectName, projectName)
+	case ComputeCUDA:
+		return fmt.Sprintf(`
+%sCUDA
+if(NOT DEFINED CMAKE_CUDA_ARCHITECTURES)
+    set(CMAKE_CUDA_ARCHITECTURES native)
+endif()
+enable_language(CUDA)
+find_package(CUDAToolkit REQUIRED)
+target_sources(%s PRIVATE src/parallel.cu)
+set_target_properties(%s PROPERTIES
+    CUDA_STANDARD %d
+    CUDA_SEPARABLE_COMPILATION ON
+)
+target_link_libraries(%s PUBLIC CUDA::cudart)
+`, computeMarker, projectName, projectName, cudaStandard(cppStandard), projectName)
+	case ComputeSYCL:
+		return fmt.Sprintf(`
+%sSYCL
+# Configure with a SYCL compiler, e.g. CXX=icpx (oneAPI) or CXX=acpp (AdaptiveCpp)
+target_sources(%s PRIVATE src/parallel.cpp)
+target_compile_options(%s PUBLIC -fsycl)
+target_link_options(%s PUBLIC -fsycl)
+`, computeMarker, projectName, projectName, projectName)
+	default:
+		return ""
+	}
+}
+
+// GenerateMesonComputeSrc generates the src/meson.build section that builds
+// the sample kernel as <project>_compute and exposes it as <project>_compute_dep
+func GenerateMesonComputeSrc(projectName, backend string, cppStandard int) string {
+	safeName := naming.SafeIdent(projectName)
+
+	// setup precedes the targets; libArgs and depArgs are extra keyword
+	// arguments of the static_library and declare_dependency calls
+	var setup, source, libArgs, depArgs string
+	switch backend {
+	case ComputeOpenMP:
+		setup = "compute_backend_dep = dependency('openmp')\n"
+		source = "parallel.cpp"
+		libArgs = "\n  dependencies : compute_backend_dep,"
+		depArgs = libArgs
+	case ComputeCUDA:
+		setup = "add_languages('cuda', native : false)\ncompute_backend_dep = dependency('cuda')\n"
+		source = "parallel.cu"
+		libArgs = "\n  dependencies : compute_backend_dep,"
+		depArgs = libArgs
+		libArgs += fmt.Sprintf("\n  override_options : ['cuda_std=c++%d'],", cudaStandard(cppStandard))
+	case ComputeSYCL:
+		setup = "# Configure with a SYCL compiler, e.g. CXX=icpx (oneAPI) or CXX=acpp (AdaptiveCpp)\n"
+		source = "parallel.cpp"
+		libArgs = "\n  cpp_args : ['-fsycl'],"
+		depArgs = "\n  link_args : ['-fsycl'],"
+	default:
+		return ""
+	}
+
+	return fmt.Sprintf(`
+%s%s
+%s%s_compute = static_library('%s_compute',
+  files('%s'),
+  include_directories : inc_dirs,%s
+)
+%s_compute_dep = declare_dependency(
+  link_with : %s_compute,
+  include_directories : inc_dirs,%s
+)
+`, computeMarker, ComputeBackendName(backend), setup, safeName, safeName, source, libArgs, safeName, safeName, depArgs)
+}
+
+// GenerateBazelComputeSrc generates the src/BUILD.bazel target for the
+// sample kernel. Only OpenMP is supported for Bazel projects; CUDA and SYCL
+// need toolchain rules cpx does not set up.
+func GenerateBazelComputeSrc(projectName, backend string) string {
+	if backend != ComputeOpenMP {
+		return ""
+	}
+	return fmt.Sprintf(`
+%sOpenMP
+cc_library(
+    name = "%s_parallel",
+    srcs = ["parallel.cpp"],
+    copts = ["-fopenmp"],
+    linkopts = ["-fopenmp"],
+    deps = ["//include:%s_headers"],
+    visibility = ["//visibility:public"],
+)
+`, computeMarker, projectName, projectName)
+}
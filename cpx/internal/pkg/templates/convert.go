@@ -0,0 +1,441 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/naming"
+)
+
+// ============================================================================
+// BUILD SYSTEM CONVERSION TEMPLATES
+// ============================================================================
+
+// BuildDependency is how each build system refers to a library
+type BuildDependency struct {
+	// Port is the vcpkg port; the build files of CMake projects find
+	// CMakePackage and link CMakeTargets
+	Port         string
+	CMakePackage string
+	CMakeTargets []string
+	// BCRModule is the module of the Bazel Central Registry and BazelLabel
+	// the target to depend on
+	BCRModule  string
+	BazelLabel string
+	// Wrap is the WrapDB wrap and MesonName the name dependency() finds
+	Wrap      string
+	MesonName string
+}
+
+// buildDependencies are the libraries cpx convert maps between build
+// systems, by vcpkg port
+var buildDependencies = map[string]BuildDependency{
+	"benchmark":     {"benchmark", "benchmark", []string{"benchmark::benchmark"}, "google_benchmark", "@google_benchmark//:benchmark", "google-benchmark", "benchmark"},
+	"catch2":        {"catch2", "Catch2", []string{"Catch2::Catch2WithMain"}, "catch2", "@catch2//:catch2_main", "catch2", "catch2-with-main"},
+	"cli11":         {"cli11", "CLI11", []string{"CLI11::CLI11"}, "cli11", "@cli11", "cli11", "CLI11"},
+	"doctest":       {"doctest", "doctest", []string{"doctest::doctest"}, "doctest", "@doctest", "doctest", "doctest"},
+	"eigen3":        {"eigen3", "Eigen3", []string{"Eigen3::Eigen"}, "eigen", "@eigen", "eigen", "eigen3"},
+	"fmt":           {"fmt", "fmt", []string{"fmt::fmt"}, "fmt", "@fmt", "fmt", "fmt"},
+	"gflags":        {"gflags", "gflags", []string{"gflags::gflags"}, "gflags", "@gflags", "gflags", "gflags"},
+	"glog":          {"glog", "glog", []string{"glog::glog"}, "glog", "@glog", "glog", "libglog"},
+	"gtest":         {"gtest", "GTest", []string{"GTest::gtest"}, "googletest", "@googletest//:gtest", "gtest", "gtest"},
+	"libpng":        {"libpng", "PNG", []string{"PNG::PNG"}, "libpng", "@libpng", "libpng", "libpng"},
+	"nlohmann-json": {"nlohmann-json", "nlohmann_json", []string{"nlohmann_json::nlohmann_json"}, "nlohmann_json", "@nlohmann_json//:json", "nlohmann_json", "nlohmann_json"},
+	"openssl":       {"openssl", "OpenSSL", []string{"OpenSSL::SSL", "OpenSSL::Crypto"}, "openssl", "@openssl//:ssl", "openssl", "openssl"},
+	"protobuf":      {"protobuf", "protobuf", []string{"protobuf::libprotobuf"}, "protobuf", "@protobuf", "protobuf", "protobuf"},
+	"re2":           {"re2", "re2", []string{"re2::re2"}, "re2", "@re2", "re2", "re2"},
+	"spdlog":        {"spdlog", "spdlog", []string{"spdlog::spdlog"}, "spdlog", "@spdlog", "spdlog", "spdlog"},
+	"sqlite3":       {"sqlite3", "unofficial-sqlite3", []string{"unofficial::sqlite3::sqlite3"}, "sqlite3", "@sqlite3", "sqlite3", "sqlite3"},
+	"yaml-cpp":      {"yaml-cpp", "yaml-cpp", []string{"yaml-cpp::yaml-cpp"}, "yaml-cpp", "@yaml-cpp", "yaml-cpp", "yaml-cpp"},
+	"zlib":          {"zlib", "ZLIB", []string{"ZLIB::ZLIB"}, "zlib", "@zlib", "zlib", "zlib"},
+}
+
+// LookupBuildDependency returns the build system names of a vcpkg port
+func LookupBuildDependency(port string) (BuildDependency, bool) {
+	dep, ok := buildDependencies[port]
+	return dep, ok
+}
+
+// PortForBCRModule returns the vcpkg port of a Bazel Central Registry module
+func PortForBCRModule(module string) (string, bool) {
+	for port, dep := range buildDependencies {
+		if dep.BCRModule == module {
+			return port, true
+		}
+	}
+	return "", false
+}
+
+// PortForWrap returns the vcpkg port of a WrapDB wrap
+func PortForWrap(wrap string) (string, bool) {
+	for port, dep := range buildDependencies {
+		if dep.Wrap == wrap {
+			return port, true
+		}
+	}
+	return "", false
+}
+
+// TestFrameworkPorts and BenchFrameworkPorts are the vcpkg ports of the
+// frameworks cpx sets up itself in the test and benchmark build files
+var (
+	TestFrameworkPorts  = map[string]string{"googletest": "gtest", "catch2": "catch2", "doctest": "doctest"}
+	BenchFrameworkPorts = map[string]string{"google-benchmark": "benchmark", "catch2-benchmark": "catch2", "nanobench": "nanobench"}
+)
+
+// ConvertedProject is a project in the layout of cpx new, for cpx convert:
+// headers in include/<name>, sources in src, tests in tests and benchmarks
+// in bench
+type ConvertedProject struct {
+	Name        string
+	Version     string
+	CppStandard int
+	// IsExe is set for projects with a src/main.cpp
+	IsExe bool
+	// Sources are the library sources in src, without main.cpp
+	Sources []string
+	// TestSources and BenchSources are the files in tests and bench
+	TestFramework  string
+	TestSources    []string
+	BenchFramework string
+	BenchSources   []string
+	// Dependencies are vcpkg ports; the test and benchmark frameworks are
+	// not among them
+	Dependencies []string
+}
+
+// libTarget returns the name of the library target. Executables keep
+// their sources in <name>_lib, for the tests and benchmarks to link.
+func (p *ConvertedProject) libTarget() string {
+	if p.IsExe {
+		return p.Name + "_lib"
+	}
+	return p.Name
+}
+
+// mapped returns the dependencies known to buildDependencies for which
+// field is set, and the names of the others
+func (p *ConvertedProject) mapped(field func(BuildDependency) string) ([]BuildDependency, []string) {
+	var known []BuildDependency
+	var unmapped []string
+	for _, port := range p.Dependencies {
+		if dep, ok := buildDependencies[port]; ok && field(dep) != "" {
+			known = append(known, dep)
+		} else {
+			unmapped = append(unmapped, port)
+		}
+	}
+	return known, unmapped
+}
+
+// quoteList returns items quoted and indented, one per line, for Bazel and
+// Meson lists
+func quoteList(items []string, quote, indent string) string {
+	var sb strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&sb, "%s%s%s%s,\n", indent, quote, item, quote)
+	}
+	return sb.String()
+}
+
+// GenerateConvertedCMake returns the CMake build files of p by path, and
+// the dependencies it has no CMake package for. Those stay in vcpkg.json
+// but must be found and linked by hand.
+func GenerateConvertedCMake(p *ConvertedProject) (map[string]string, []string) {
+	deps, unmapped := p.mapped(func(d BuildDependency) string { return d.CMakePackage })
+	lib := p.libTarget()
+	files := make(map[string]string)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `cmake_minimum_required(VERSION 3.20)
+project(%s VERSION %s LANGUAGES CXX)
+
+# Set C++ standard
+set(CMAKE_CXX_STANDARD %d)
+set(CMAKE_CXX_STANDARD_REQUIRED ON)
+set(CMAKE_CXX_EXTENSIONS OFF)
+
+# Export compile commands for IDE support
+set(CMAKE_EXPORT_COMPILE_COMMANDS ON)
+
+`, p.Name, p.Version, p.CppStandard)
+
+	var targets []string
+	if len(deps) > 0 {
+		sb.WriteString("# Dependencies (vcpkg.json)\n")
+		for _, dep := range deps {
+			fmt.Fprintf(&sb, "find_package(%s REQUIRED)\n", dep.CMakePackage)
+			targets = append(targets, dep.CMakeTargets...)
+		}
+		sb.WriteString("\n")
+	}
+
+	// Header-only libraries are interface libraries
+	scope := "PUBLIC"
+	if len(p.Sources) == 0 {
+		scope = "INTERFACE"
+		fmt.Fprintf(&sb, "# Library\nadd_library(%s INTERFACE)\n\n", lib)
+	} else {
+		fmt.Fprintf(&sb, "# Library\nadd_library(%s STATIC\n", lib)
+		for _, src := range p.Sources {
+			fmt.Fprintf(&sb, "    src/%s\n", src)
+		}
+		sb.WriteString(")\n\n")
+	}
+	fmt.Fprintf(&sb, `target_include_directories(%s
+    %s
+        $<BUILD_INTERFACE:${CMAKE_CURRENT_SOURCE_DIR}/include>
+        $<INSTALL_INTERFACE:include>
+)
+`, lib, scope)
+	if len(targets) > 0 {
+		fmt.Fprintf(&sb, "target_link_libraries(%s %s %s)\n", lib, scope, strings.Join(targets, " "))
+	}
+
+	if p.IsExe {
+		fmt.Fprintf(&sb, `
+# Executable
+add_executable(%s src/main.cpp)
+target_link_libraries(%s PRIVATE %s)
+`, p.Name, p.Name, lib)
+	}
+
+	if len(p.TestSources) > 0 {
+		sb.WriteString("\n# Testing\nenable_testing()\nadd_subdirectory(tests)\n")
+		files["tests/CMakeLists.txt"] = fmt.Sprintf("# Test configuration for %s\n\nadd_executable(%s_tests\n%s)\n\ntarget_link_libraries(%s_tests PRIVATE %s)\n\n",
+			p.Name, p.Name, quoteList(p.TestSources, "", "    "), p.Name, lib) + cmakeTestFramework(p.Name+"_tests", p.TestFramework)
+	}
+	if len(p.BenchSources) > 0 {
+		sb.WriteString("\n# Benchmarks\nadd_subdirectory(bench)\n")
+		files["bench/CMakeLists.txt"] = fmt.Sprintf("# Benchmark configuration for %s\n\nadd_executable(%s_bench\n%s)\n\ntarget_link_libraries(%s_bench PRIVATE %s)\n\n",
+			p.Name, p.Name, quoteList(p.BenchSources, "", "    "), p.Name, lib) + cmakeBenchFramework(p.Name+"_bench", p.BenchFramework)
+	}
+
+	files["CMakeLists.txt"] = sb.String()
+	files["CMakePresets.json"] = GenerateCMakePresets()
+	return files, unmapped
+}
+
+// bazelTestFrameworkDeps and bazelBenchFrameworkDeps are the targets the
+// Bazel tests and benchmarks of each framework depend on
+var (
+	bazelTestFrameworkDeps  = map[string]string{"googletest": "@googletest//:gtest_main", "catch2": "@catch2//:catch2_main", "doctest": "@doctest//:doctest"}
+	bazelBenchFrameworkDeps = map[string]string{"google-benchmark": "@google_benchmark//:benchmark_main", "nanobench": "@nanobench//:nanobench", "catch2-benchmark": "@catch2//:catch2_main"}
+)
+
+// GenerateConvertedBazel returns the Bazel build files of p by path, and
+// the dependencies that have no Bazel Central Registry module. versions
+// are the versions of the modules to depend on; dependencies on modules
+// without one are left out too.
+func GenerateConvertedBazel(p *ConvertedProject, versions map[string]string) (map[string]string, []string) {
+	deps, unmapped := p.mapped(func(d BuildDependency) string { return versions[d.BCRModule] })
+	lib := p.libTarget()
+	files := make(map[string]string)
+
+	module := GenerateModuleBazel(p.Name, p.Version, p.TestFramework, p.BenchFramework)
+	labels := []string{"//include:" + p.Name + "_headers"}
+	for _, dep := range deps {
+		module += fmt.Sprintf("bazel_dep(name = \"%s\", version = \"%s\")\n", dep.BCRModule, versions[dep.BCRModule])
+		labels = append(labels, dep.BazelLabel)
+	}
+	files["MODULE.bazel"] = module
+	files["BUILD.bazel"] = GenerateBuildBazelRoot(p.Name, p.IsExe)
+	files["include/BUILD.bazel"] = GenerateBuildBazelInclude(p.Name)
+	files[".bazelrc"] = GenerateBazelrc(p.CppStandard)
+	files[".bazelignore"] = GenerateBazelignore()
+
+	rules := "cc_library"
+	if p.IsExe {
+		rules = `cc_binary", "cc_library`
+	}
+	src := fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "%s")
+
+cc_library(
+    name = "%s",
+    srcs = [
+%s    ],
+    deps = [
+%s    ],
+    visibility = ["//visibility:public"],
+)
+`, rules, lib, quoteList(p.Sources, `"`, "        "), quoteList(labels, `"`, "        "))
+	if p.IsExe {
+		src += fmt.Sprintf(`
+cc_binary(
+    name = "%s",
+    srcs = ["main.cpp"],
+    deps = [":%s"],
+    visibility = ["//visibility:public"],
+)
+`, p.Name, lib)
+	}
+	files["src/BUILD.bazel"] = src
+
+	if len(p.TestSources) > 0 {
+		testDeps := []string{"//src:" + lib}
+		if dep := bazelTestFrameworkDeps[p.TestFramework]; dep != "" {
+			testDeps = append(testDeps, dep)
+		}
+		files["tests/BUILD.bazel"] = fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_test")
+
+cc_test(
+    name = "%s_test",
+    srcs = [
+%s    ],
+    deps = [
+%s    ],
+)
+`, p.Name, quoteList(p.TestSources, `"`, "        "), quoteList(testDeps, `"`, "        "))
+	}
+	if len(p.BenchSources) > 0 {
+		benchDeps := []string{"//src:" + lib}
+		if dep := bazelBenchFrameworkDeps[p.BenchFramework]; dep != "" {
+			benchDeps = append(benchDeps, dep)
+		}
+		files["bench/BUILD.bazel"] = fmt.Sprintf(`load("@rules_cc//cc:defs.bzl", "cc_binary")
+
+cc_binary(
+    name = "%s_bench",
+    srcs = [
+%s    ],
+    deps = [
+%s    ],
+)
+`, p.Name, quoteList(p.BenchSources, `"`, "        "), quoteList(benchDeps, `"`, "        "))
+	}
+	return files, unmapped
+}
+
+// GenerateConvertedMeson returns the Meson build files of p by path, the
+// wraps to install (with those of the test and benchmark frameworks), and
+// the dependencies that have no wrap
+func GenerateConvertedMeson(p *ConvertedProject) (map[string]string, []string, []string) {
+	deps, unmapped := p.mapped(func(d BuildDependency) string { return d.Wrap })
+	safeName := naming.SafeIdent(p.Name)
+	files := make(map[string]string)
+
+	wraps := make(map[string]bool)
+	var depVars []string
+	var depLines strings.Builder
+	for _, dep := range deps {
+		wraps[dep.Wrap] = true
+		v := naming.SafeIdent(dep.Port) + "_dep"
+		depVars = append(depVars, v)
+		fmt.Fprintf(&depLines, "%s = dependency('%s')\n", v, dep.MesonName)
+	}
+
+	subdirs := "subdir('src')\n"
+	if len(p.TestSources) > 0 {
+		subdirs += "subdir('tests')\n"
+	}
+	if len(p.BenchSources) > 0 {
+		subdirs += "subdir('bench')\n"
+	}
+	files["meson.build"] = fmt.Sprintf(`project('%s', 'cpp',
+  version : '%s',
+  default_options : [
+    'cpp_std=c++%d',
+    'warning_level=3',
+    'buildtype=debugoptimized'
+  ]
+)
+
+# Include directories
+inc_dirs = include_directories('include')
+
+# Dependencies
+%sproject_deps = [%s]
+
+# Subdirectories
+%s`, p.Name, p.Version, p.CppStandard, depLines.String(), strings.Join(depVars, ", "), subdirs)
+	files["meson_options.txt"] = GenerateMesonOptions()
+
+	var src strings.Builder
+	if len(p.Sources) > 0 {
+		fmt.Fprintf(&src, `# Library (for linking by the executable, tests and benchmarks)
+%s_lib = static_library('%s',
+  files(
+%s  ),
+  include_directories : inc_dirs,
+  dependencies : project_deps,
+  install : true
+)
+
+%s_dep = declare_dependency(
+  link_with : %s_lib,
+  include_directories : inc_dirs,
+  dependencies : project_deps
+)
+`, safeName, p.libTarget(), quoteList(p.Sources, "'", "    "), safeName, safeName)
+	} else {
+		fmt.Fprintf(&src, `# Header-only library
+%s_dep = declare_dependency(
+  include_directories : inc_dirs,
+  dependencies : project_deps
+)
+`, safeName)
+	}
+	if p.IsExe {
+		fmt.Fprintf(&src, `
+# Executable
+%s_exe = executable('%s',
+  files('main.cpp'),
+  dependencies : [%s_dep],
+  install : true
+)
+`, safeName, p.Name, safeName)
+	}
+	files["src/meson.build"] = src.String()
+
+	if len(p.TestSources) > 0 {
+		depLine, depVar := mesonTestFramework(p.TestFramework)
+		if port := TestFrameworkPorts[p.TestFramework]; port != "" {
+			wraps[buildDependencies[port].Wrap] = true
+		}
+		testDeps := safeName + "_dep"
+		if depVar != "" {
+			testDeps += ", " + depVar
+		}
+		files["tests/meson.build"] = fmt.Sprintf(`# Test dependencies
+%s
+
+# Test executable
+test_exe = executable('%s_test',
+  files(
+%s  ),
+  dependencies : [%s]
+)
+
+# Register test
+test('%s tests', test_exe)
+`, depLine, p.Name, quoteList(p.TestSources, "'", "    "), testDeps, p.Name)
+	}
+	if len(p.BenchSources) > 0 {
+		depLine, depVar := mesonBenchFramework(p.BenchFramework)
+		if port := BenchFrameworkPorts[p.BenchFramework]; port != "" && buildDependencies[port].Wrap != "" {
+			wraps[buildDependencies[port].Wrap] = true
+		}
+		benchDeps := safeName + "_dep"
+		if depVar != "" {
+			benchDeps += ", " + depVar
+		}
+		files["bench/meson.build"] = fmt.Sprintf(`# Benchmark dependencies
+%s
+
+# Benchmark executable
+bench_exe = executable('%s_bench',
+  files(
+%s  ),
+  dependencies : [%s]
+)
+`, depLine, p.Name, quoteList(p.BenchSources, "'", "    "), benchDeps)
+	}
+
+	wrapList := make([]string, 0, len(wraps))
+	for wrap := range wraps {
+		wrapList = append(wrapList, wrap)
+	}
+	sort.Strings(wrapList)
+	return files, wrapList, unmapped
+}
@@ -0,0 +1,209 @@
+package templates
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ============================================================================
+// CROSS-COMPILATION TOOLCHAIN TEMPLATES
+// ============================================================================
+
+// CrossToolchainDir holds the CMake toolchain files of cpx gen toolchain
+var CrossToolchainDir = filepath.Join("cmake", "toolchains")
+
+// CrossFileDir holds the Meson cross files of cpx gen toolchain
+const CrossFileDir = "cross"
+
+// CrossTarget is a target of a cross-compilation, described by a GNU target
+// triple such as aarch64-linux-gnu
+type CrossTarget struct {
+	Triple string
+	// System is the CMake system name: Linux, Windows, Darwin, FreeBSD or
+	// Generic (bare metal)
+	System string
+	// Processor is the CMake system processor (e.g. aarch64, x86_64)
+	Processor string
+	// CPUFamily is the Meson CPU family (e.g. aarch64, arm, x86)
+	CPUFamily string
+	Endian    string
+}
+
+// crossArches maps the architecture of a triple to its CMake processor and
+// Meson CPU family
+var crossArches = []struct {
+	re        *regexp.Regexp
+	cpuFamily string
+	endian    string
+}{
+	{regexp.MustCompile(`^(aarch64|arm64)$`), "aarch64", "little"},
+	{regexp.MustCompile(`^aarch64_be$`), "aarch64", "big"},
+	{regexp.MustCompile(`^arm(v\d+\w*)?(hf)?$`), "arm", "little"},
+	{regexp.MustCompile(`^x86_64$`), "x86_64", "little"},
+	{regexp.MustCompile(`^i[3-6]86$`), "x86", "little"},
+	{regexp.MustCompile(`^riscv64$`), "riscv64", "little"},
+	{regexp.MustCompile(`^riscv32$`), "riscv32", "little"},
+	{regexp.MustCompile(`^powerpc64le$`), "ppc64", "little"},
+	{regexp.MustCompile(`^powerpc64$`), "ppc64", "big"},
+	{regexp.MustCompile(`^mips(64)?el$`), "mips", "little"},
+	{regexp.MustCompile(`^s390x$`), "s390x", "big"},
+}
+
+// ParseCrossTarget parses a GNU target triple (arch-vendor-os-abi, the vendor
+// being optional), such as aarch64-linux-gnu, arm-linux-gnueabihf,
+// x86_64-w64-mingw32 or riscv64-unknown-linux-gnu
+func ParseCrossTarget(triple string) (CrossTarget, error) {
+	parts := strings.Split(triple, "-")
+	if len(parts) < 2 {
+		return CrossTarget{}, fmt.Errorf("invalid target triple %q\n  hint: use a GNU triple such as aarch64-linux-gnu or arm-linux-gnueabihf", triple)
+	}
+	target := CrossTarget{Triple: triple, Processor: parts[0]}
+	for _, arch := range crossArches {
+		if arch.re.MatchString(parts[0]) {
+			target.CPUFamily, target.Endian = arch.cpuFamily, arch.endian
+			break
+		}
+	}
+	if target.CPUFamily == "" {
+		return CrossTarget{}, fmt.Errorf("unsupported architecture %q in target %s\n  hint: cpx knows aarch64, arm, x86_64, i686, riscv64, powerpc64le, mips and s390x", parts[0], triple)
+	}
+
+	rest := strings.Join(parts[1:], "-")
+	switch {
+	case strings.Contains(rest, "linux"):
+		target.System = "Linux"
+	case strings.Contains(rest, "mingw") || strings.Contains(rest, "windows"):
+		target.System = "Windows"
+	case strings.Contains(rest, "darwin") || strings.Contains(rest, "apple"):
+		target.System = "Darwin"
+	case strings.Contains(rest, "freebsd"):
+		target.System = "FreeBSD"
+	case strings.Contains(rest, "none") || strings.HasPrefix(parts[len(parts)-1], "eabi") || parts[len(parts)-1] == "elf":
+		target.System = "Generic"
+	default:
+		return CrossTarget{}, fmt.Errorf("unsupported operating system in target %s\n  hint: cpx knows linux, mingw32, darwin, freebsd and bare-metal (none, eabi, elf) triples", triple)
+	}
+	return target, nil
+}
+
+// VcpkgTriplet returns the vcpkg triplet of the target (e.g. arm64-linux),
+// or "" if vcpkg has none
+func (t CrossTarget) VcpkgTriplet() string {
+	arch := map[string]string{"aarch64": "arm64", "arm": "arm", "x86_64": "x64", "x86": "x86", "riscv64": "riscv64", "ppc64": "ppc64le", "s390x": "s390x"}[t.CPUFamily]
+	if arch == "" || (t.Endian == "big" && arch != "s390x") {
+		return ""
+	}
+	switch t.System {
+	case "Linux":
+		return arch + "-linux"
+	case "Windows":
+		return arch + "-mingw-static"
+	case "Darwin":
+		return arch + "-osx"
+	case "FreeBSD":
+		return arch + "-freebsd"
+	}
+	return ""
+}
+
+// crossCompilers returns the C and C++ compilers of the target: the GNU
+// cross compilers of the triple, or clang with --target
+func crossCompilers(t CrossTarget, compiler string) (cc, cxx string) {
+	if compiler == "clang" {
+		return "clang", "clang++"
+	}
+	return t.Triple + "-gcc", t.Triple + "-g++"
+}
+
+// GenerateCrossToolchainFile generates a CMake toolchain file for target.
+// compiler is "gcc" (the triple's GNU cross compilers) or "clang"; sysroot
+// may be empty.
+func GenerateCrossToolchainFile(t CrossTarget, compiler, sysroot string) string {
+	cc, cxx := crossCompilers(t, compiler)
+	var b strings.Builder
+	fmt.Fprintf(&b, `# Cross-compilation toolchain for %[1]s, generated by "cpx gen toolchain"
+#
+#   cpx build --toolchain %[1]s
+set(CMAKE_SYSTEM_NAME %[2]s)
+set(CMAKE_SYSTEM_PROCESSOR %[3]s)
+
+set(CMAKE_C_COMPILER %[4]s)
+set(CMAKE_CXX_COMPILER %[5]s)
+`, t.Triple, t.System, t.Processor, cc, cxx)
+	if compiler == "clang" {
+		fmt.Fprintf(&b, "set(CMAKE_C_COMPILER_TARGET %[1]s)\nset(CMAKE_CXX_COMPILER_TARGET %[1]s)\n", t.Triple)
+	}
+	if t.System == "Generic" {
+		b.WriteString(`
+# Compiler checks can't link an executable without a linker script
+set(CMAKE_TRY_COMPILE_TARGET_TYPE STATIC_LIBRARY)
+`)
+	}
+	if sysroot != "" {
+		fmt.Fprintf(&b, `
+set(CMAKE_SYSROOT %[1]s)
+set(CMAKE_FIND_ROOT_PATH %[1]s)
+`, sysroot)
+	}
+	b.WriteString(`
+# Run build tools from the host, take libraries and headers from the target
+set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)
+set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)
+`)
+	return b.String()
+}
+
+// GenerateMesonCrossFile generates a Meson cross file for target, like
+// GenerateCrossToolchainFile
+func GenerateMesonCrossFile(t CrossTarget, compiler, sysroot string) string {
+	cc, cxx := crossCompilers(t, compiler)
+	tools := t.Triple + "-"
+	if compiler == "clang" {
+		tools = "llvm-"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `# Cross file for %[1]s, generated by "cpx gen toolchain"
+#
+#   cpx build --toolchain %[1]s
+[binaries]
+c = '%[2]s'
+cpp = '%[3]s'
+ar = '%[4]sar'
+strip = '%[4]sstrip'
+pkg-config = 'pkg-config'
+`, t.Triple, cc, cxx, tools)
+
+	var args []string
+	if compiler == "clang" {
+		args = append(args, "'--target="+t.Triple+"'")
+	}
+	if sysroot != "" {
+		args = append(args, "'--sysroot="+sysroot+"'")
+		fmt.Fprintf(&b, "\n[properties]\nsys_root = '%s'\n", sysroot)
+	}
+	if len(args) > 0 {
+		list := "[" + strings.Join(args, ", ") + "]"
+		fmt.Fprintf(&b, "\n[built-in options]\nc_args = %[1]s\ncpp_args = %[1]s\nc_link_args = %[1]s\ncpp_link_args = %[1]s\n", list)
+	}
+
+	fmt.Fprintf(&b, `
+[host_machine]
+system = '%s'
+cpu_family = '%s'
+cpu = '%s'
+endian = '%s'
+`, mesonSystem(t.System), t.CPUFamily, t.Processor, t.Endian)
+	return b.String()
+}
+
+// mesonSystem returns the Meson system name of a CMake system name
+func mesonSystem(system string) string {
+	if system == "Generic" {
+		return "none"
+	}
+	return strings.ToLower(system)
+}
@@ -0,0 +1,115 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// DEPLOYMENT DOCKERFILE TEMPLATES
+// ============================================================================
+
+// DeployOptions describes a deployment image
+type DeployOptions struct {
+	ToolchainOptions
+	// Binary is the executable to ship
+	Binary string
+	// Target names the cpx toolchain image the binary is built in
+	// ("linux-amd64" builds in cpx-linux-amd64)
+	Target string
+}
+
+// IsMuslTarget reports whether target builds against musl (Alpine)
+func IsMuslTarget(target string) bool {
+	return strings.HasSuffix(target, "-musl")
+}
+
+// deployRuntime returns the runtime stage of a deployment image for target:
+// the same distribution as the toolchain image, so that the binary finds a
+// compatible libc and libstdc++
+func deployRuntime(target string) (from, setup string) {
+	platform := ""
+	if arch := strings.TrimSuffix(strings.TrimPrefix(target, "linux-"), "-musl"); arch == "amd64" || arch == "arm64" {
+		platform = "--platform=linux/" + arch + " "
+	}
+	if IsMuslTarget(target) {
+		return platform + "alpine:3.20", `RUN apk add --no-cache libstdc++ \
+ && adduser -D -H app`
+	}
+	return platform + "ubuntu:22.04", `RUN useradd --system --no-create-home app`
+}
+
+// GenerateDeployDockerfile generates a multi-stage Dockerfile that builds
+// opts.Binary in the cpx toolchain image and copies it into a minimal
+// runtime image
+func GenerateDeployDockerfile(opts DeployOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `# Deployment image for %s, generated by cpx gen dockerfile
+#
+#   docker build -t %s .
+#
+# The build stage uses the cpx toolchain image built by "cpx ci"; pass
+# --build-arg BUILD_IMAGE=<image> to use another one.
+ARG BUILD_IMAGE=cpx-%s
+
+FROM ${BUILD_IMAGE} AS build
+WORKDIR /src
+`, opts.ProjectName, strings.ToLower(opts.ProjectName), opts.Target)
+
+	switch opts.BuildSystem {
+	case "meson":
+		fmt.Fprintf(&b, `COPY . .
+RUN meson setup /build --buildtype=release -Denable_tests=false -Denable_benchmarks=false \
+ && meson compile -C /build \
+ && install -D /build/src/%s /out/%s
+`, opts.Binary, opts.Binary)
+	case "bazel":
+		fmt.Fprintf(&b, `COPY . .
+RUN bazel build -c opt //src:%s \
+ && install -D "$(bazel cquery -c opt --output=files //src:%s 2>/dev/null)" /out/%s
+`, opts.Binary, opts.Binary, opts.Binary)
+	default:
+		configure := "cmake -S . -B /build -G Ninja -DCMAKE_BUILD_TYPE=Release"
+		if opts.Vcpkg {
+			// Install the dependencies in their own layer, so that source
+			// changes don't rebuild them
+			b.WriteString(`COPY vcpkg*.json ./
+RUN vcpkg install --x-install-root=/vcpkg_installed
+`)
+			configure += ` \
+    -DCMAKE_TOOLCHAIN_FILE=$VCPKG_ROOT/scripts/buildsystems/vcpkg.cmake \
+    -DVCPKG_INSTALLED_DIR=/vcpkg_installed -DVCPKG_MANIFEST_INSTALL=OFF`
+		}
+		fmt.Fprintf(&b, `COPY . .
+RUN %s \
+ && cmake --build /build --target %s \
+ && install -D /build/%s /out/%s
+`, configure, opts.Binary, opts.Binary, opts.Binary)
+	}
+
+	from, setup := deployRuntime(opts.Target)
+	fmt.Fprintf(&b, `
+FROM %s
+%s
+COPY --from=build /out/%s /usr/local/bin/%s
+USER app
+ENTRYPOINT ["/usr/local/bin/%s"]
+`, from, setup, opts.Binary, opts.Binary, opts.Binary)
+	return b.String()
+}
+
+// GenerateDockerignore generates .dockerignore for deployment images, keeping
+// local build output and caches out of the build context
+func GenerateDockerignore() string {
+	return `.git
+.cache/
+.bin/
+build/
+builddir/
+out/
+bazel-*
+.bazel-*
+vcpkg_installed/
+compile_commands.json
+`
+}
@@ -0,0 +1,173 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// DEVCONTAINER TEMPLATES
+// ============================================================================
+
+// ToolchainOptions describes the toolchain of a project for the dev container
+// and Nix flake templates
+type ToolchainOptions struct {
+	ProjectName    string
+	ProjectVersion string
+	// BuildSystem is "cmake", "meson" or "bazel"
+	BuildSystem string
+	// Compiler is "gcc" or "clang"
+	Compiler string
+	// CompilerVersion is a major version ("13"), or empty for the distro default
+	CompilerVersion string
+	// CMakeVersion pins cmake to a version prefix ("3.30") or a minimum
+	// (">=3.30"), or is empty for the distro package
+	CMakeVersion string
+	// Vcpkg installs vcpkg, checked out at VcpkgBaseline if set
+	Vcpkg         bool
+	VcpkgBaseline string
+	// Dependencies are the vcpkg port names of vcpkg.json
+	Dependencies []string
+}
+
+// devcontainerBaseImage is the image generated dev containers build on
+const devcontainerBaseImage = "ubuntu:24.04"
+
+// compilerPackages returns the apt packages and the C/C++ compiler commands
+// for opts
+func compilerPackages(opts ToolchainOptions) (packages []string, cc, cxx string) {
+	suffix := ""
+	if opts.CompilerVersion != "" {
+		suffix = "-" + opts.CompilerVersion
+	}
+	if opts.Compiler == "clang" {
+		return []string{"clang" + suffix, "clang-format" + suffix, "clang-tidy" + suffix, "lldb" + suffix},
+			"clang" + suffix, "clang++" + suffix
+	}
+	return []string{"gcc" + suffix, "g++" + suffix, "gdb"}, "gcc" + suffix, "g++" + suffix
+}
+
+// GenerateDevcontainerDockerfile generates .devcontainer/Dockerfile
+func GenerateDevcontainerDockerfile(opts ToolchainOptions) string {
+	compilers, cc, cxx := compilerPackages(opts)
+	packages := []string{"build-essential", "ca-certificates", "curl", "git", "pkg-config", "python3", "python3-pip", "tar", "unzip", "zip"}
+	packages = append(packages, compilers...)
+
+	switch opts.BuildSystem {
+	case "meson":
+		packages = append(packages, "meson", "ninja-build")
+	case "bazel":
+	default:
+		packages = append(packages, "ninja-build")
+		if opts.CMakeVersion == "" {
+			packages = append(packages, "cmake")
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `# Dev container for %s, generated by cpx gen devcontainer
+FROM %s
+
+ARG DEBIAN_FRONTEND=noninteractive
+RUN apt-get update && apt-get install -y --no-install-recommends \
+    %s \
+ && rm -rf /var/lib/apt/lists/*
+
+ENV CC=%s CXX=%s
+`, opts.ProjectName, devcontainerBaseImage, strings.Join(packages, " \\\n    "), cc, cxx)
+
+	if opts.BuildSystem == "cmake" && opts.CMakeVersion != "" {
+		requirement := "cmake==" + opts.CMakeVersion + ".*"
+		if strings.HasPrefix(opts.CMakeVersion, ">=") {
+			requirement = "cmake" + opts.CMakeVersion
+		}
+		fmt.Fprintf(&b, `
+# cmake from PyPI (the distro package may be older)
+RUN python3 -m pip install --no-cache-dir --break-system-packages "%s"
+`, requirement)
+	}
+
+	if opts.BuildSystem == "bazel" {
+		b.WriteString(`
+# bazelisk picks the Bazel version from .bazelversion
+RUN curl -fsSL -o /usr/local/bin/bazel \
+    "https://github.com/bazelbuild/bazelisk/releases/latest/download/bazelisk-linux-$(dpkg --print-architecture)" \
+ && chmod +x /usr/local/bin/bazel
+`)
+	}
+
+	if opts.Vcpkg {
+		checkout := ""
+		if opts.VcpkgBaseline != "" {
+			checkout = fmt.Sprintf(" \\\n && git -C /opt/vcpkg checkout %s", opts.VcpkgBaseline)
+		}
+		fmt.Fprintf(&b, `
+# vcpkg at the builtin-baseline of vcpkg.json
+ENV VCPKG_ROOT=/opt/vcpkg VCPKG_FORCE_SYSTEM_BINARIES=1
+RUN git clone https://github.com/microsoft/vcpkg.git /opt/vcpkg%s \
+ && /opt/vcpkg/bootstrap-vcpkg.sh -disableMetrics
+`, checkout)
+	}
+
+	b.WriteString(`
+# cpx itself
+RUN curl -fsSL -o /usr/local/bin/cpx \
+    "https://github.com/ozacod/cpx/releases/latest/download/cpx-linux-$(dpkg --print-architecture)" \
+ && chmod +x /usr/local/bin/cpx
+`)
+	return b.String()
+}
+
+// GenerateDevcontainerJSON generates .devcontainer/devcontainer.json
+func GenerateDevcontainerJSON(opts ToolchainOptions) string {
+	extensions := []string{"llvm-vs-code-extensions.vscode-clangd"}
+	settings := map[string]any{
+		// clangd reads the merged database written by cpx compdb
+		"clangd.arguments": []string{"--compile-commands-dir=${containerWorkspaceFolder}"},
+	}
+	switch opts.BuildSystem {
+	case "meson":
+		extensions = append(extensions, "mesonbuild.mesonbuild")
+	case "bazel":
+		extensions = append(extensions, "BazelBuild.vscode-bazel")
+	default:
+		extensions = append(extensions, "ms-vscode.cmake-tools")
+		settings["cmake.configureOnOpen"] = false
+	}
+	if opts.Compiler == "clang" {
+		extensions = append(extensions, "vadimcn.vscode-lldb")
+	}
+
+	// A first build fetches the dependencies and writes compile_commands.json
+	postCreate := "cpx build && cpx compdb"
+
+	config := map[string]any{
+		"name":  opts.ProjectName,
+		"build": map[string]string{"dockerfile": "Dockerfile", "context": ".."},
+		"customizations": map[string]any{
+			"vscode": map[string]any{
+				"extensions": extensions,
+				"settings":   settings,
+			},
+		},
+		"postCreateCommand": postCreate,
+		// ptrace is needed by gdb/lldb and cpx run --debug
+		"capAdd":      []string{"SYS_PTRACE"},
+		"securityOpt": []string{"seccomp=unconfined"},
+	}
+	if opts.Vcpkg {
+		// Keep the vcpkg binary cache across container rebuilds
+		config["mounts"] = []string{"source=cpx-vcpkg-cache,target=/root/.cache/vcpkg,type=volume"}
+	}
+
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false) // keep && readable
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(config); err != nil {
+		// Only maps, slices and strings are encoded
+		panic(err)
+	}
+	return b.String()
+}
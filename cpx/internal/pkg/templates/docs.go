@@ -0,0 +1,215 @@
+package templates
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ============================================================================
+// DOCUMENTATION TEMPLATES
+// ============================================================================
+
+// ManagedDocHeader starts the documentation configuration cpx doc rewrites
+// on every run; without it, the file is the project's own
+const ManagedDocHeader = "# Generated by cpx doc from the doc section of cpx.yaml; remove this line to edit the file yourself\n"
+
+// DoxygenOptions are the Doxyfile settings of the doc section of cpx.yaml
+type DoxygenOptions struct {
+	// Input are the documented directories and files; src and include if
+	// empty
+	Input []string
+	// Exclude are the EXCLUDE_PATTERNS
+	Exclude []string
+	// ThemeDir is the directory of doxygen-awesome-css, if the theme is on
+	ThemeDir string
+	// Dot draws class, collaboration and include graphs with Graphviz;
+	// CallGraphs adds call and caller graphs
+	Dot        bool
+	CallGraphs bool
+	// DotFormat is the image format of the graphs, svg or png
+	DotFormat string
+}
+
+// doxyList formats a Doxyfile list, quoting values with spaces
+func doxyList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		if strings.ContainsAny(v, " \t") {
+			v = `"` + v + `"`
+		}
+		quoted[i] = v
+	}
+	return strings.Join(quoted, " ")
+}
+
+func doxyBool(b bool) string {
+	if b {
+		return "YES"
+	}
+	return "NO"
+}
+
+// GenerateDoxyfile generates the Doxyfile of cpx doc, an HTML site in
+// docs/html
+func GenerateDoxyfile(projectName, projectVersion string, opts DoxygenOptions) string {
+	input := opts.Input
+	if len(input) == 0 {
+		input = []string{"src", "include"}
+	}
+	var b strings.Builder
+	b.WriteString(ManagedDocHeader)
+	fmt.Fprintf(&b, `PROJECT_NAME           = "%s"
+PROJECT_NUMBER         = "%s"
+OUTPUT_DIRECTORY       = docs
+INPUT                  = %s
+RECURSIVE              = YES
+`, projectName, projectVersion, doxyList(input))
+	if len(opts.Exclude) > 0 {
+		fmt.Fprintf(&b, "EXCLUDE_PATTERNS       = %s\n", doxyList(opts.Exclude))
+	}
+	b.WriteString(`EXTRACT_ALL            = YES
+GENERATE_HTML          = YES
+GENERATE_LATEX         = NO
+HTML_OUTPUT            = html
+USE_MDFILE_AS_MAINPAGE = README.md
+`)
+
+	if opts.ThemeDir != "" {
+		// The settings doxygen-awesome-css recommends for its sidebar layout
+		theme := filepath.ToSlash(opts.ThemeDir)
+		fmt.Fprintf(&b, `
+# doxygen-awesome-css theme
+GENERATE_TREEVIEW      = YES
+DISABLE_INDEX          = NO
+FULL_SIDEBAR           = NO
+HTML_COLORSTYLE        = LIGHT
+HTML_EXTRA_STYLESHEET  = %s
+`, doxyList([]string{theme + "/doxygen-awesome.css", theme + "/doxygen-awesome-sidebar-only.css"}))
+	}
+
+	fmt.Fprintf(&b, "\n# Diagrams (Graphviz dot)\nHAVE_DOT               = %s\n", doxyBool(opts.Dot))
+	if opts.Dot {
+		format := opts.DotFormat
+		if format == "" {
+			format = "svg"
+		}
+		fmt.Fprintf(&b, `CLASS_GRAPH            = YES
+COLLABORATION_GRAPH    = YES
+INCLUDE_GRAPH          = YES
+INCLUDED_BY_GRAPH      = YES
+CALL_GRAPH             = %s
+CALLER_GRAPH           = %s
+DOT_IMAGE_FORMAT       = %s
+INTERACTIVE_SVG        = %s
+`, doxyBool(opts.CallGraphs), doxyBool(opts.CallGraphs), format, doxyBool(format == "svg"))
+	}
+	return b.String()
+}
+
+// GenerateSphinxDoxyfile generates the Doxyfile of cpx doc --generator
+// sphinx: the XML breathe reads, in docs/sphinx/_doxygen/xml
+func GenerateSphinxDoxyfile(projectName, projectVersion string) string {
+	return fmt.Sprintf(`# XML for breathe, generated by "cpx doc --generator sphinx"
+PROJECT_NAME           = "%s"
+PROJECT_NUMBER         = "%s"
+OUTPUT_DIRECTORY       = docs/sphinx/_doxygen
+INPUT                  = src include
+RECURSIVE              = YES
+EXTRACT_ALL            = YES
+GENERATE_HTML          = NO
+GENERATE_LATEX         = NO
+GENERATE_XML           = YES
+XML_OUTPUT             = xml
+`, projectName, projectVersion)
+}
+
+// GenerateSphinxConf generates the conf.py of a Sphinx site documenting the
+// API with breathe
+func GenerateSphinxConf(projectName, projectVersion string) string {
+	return fmt.Sprintf(`# Sphinx configuration, generated by "cpx doc --generator sphinx"
+import os
+
+project = "%s"
+version = release = "%s"
+
+extensions = ["breathe"]
+
+# Doxygen writes the XML with docs/sphinx/Doxyfile before sphinx-build runs
+breathe_projects = {
+    project: os.path.join(os.path.dirname(os.path.abspath(__file__)), "_doxygen", "xml"),
+}
+breathe_default_project = project
+
+exclude_patterns = ["_build", "_doxygen"]
+html_theme = "alabaster"
+`, projectName, projectVersion)
+}
+
+// GenerateSphinxIndex generates the index.rst of a Sphinx site: the API
+// reference of the project
+func GenerateSphinxIndex(projectName string) string {
+	title := projectName + " documentation"
+	return fmt.Sprintf(`%s
+%s
+
+API reference
+-------------
+
+.. doxygenindex::
+`, title, strings.Repeat("=", len(title)))
+}
+
+// GenerateMkDocsConfig generates the mkdocs.yml of an MkDocs site whose API
+// pages mkdoxy generates with Doxygen
+func GenerateMkDocsConfig(projectName, projectVersion string) string {
+	return fmt.Sprintf(`# MkDocs configuration, generated by "cpx doc --generator mkdocs"
+site_name: "%s %s"
+docs_dir: docs/mkdocs
+site_dir: docs/site
+
+plugins:
+  - search
+  - mkdoxy:
+      projects:
+        api:
+          src-dirs: src include
+          full-doc: true
+          doxy-cfg:
+            FILE_PATTERNS: "*.cpp *.cc *.cxx *.hpp *.hh *.h"
+            RECURSIVE: true
+            EXTRACT_ALL: true
+
+nav:
+  - Home: index.md
+  - API:
+      - Classes: api/annotated.md
+      - Namespaces: api/namespaces.md
+      - Files: api/files.md
+`, projectName, projectVersion)
+}
+
+// GenerateMkDocsIndex generates the home page of an MkDocs site
+func GenerateMkDocsIndex(projectName string) string {
+	return fmt.Sprintf(`# %s
+
+See the [API reference](api/annotated.md).
+`, projectName)
+}
+
+// GenerateStandardeseConfig generates the standardese.config of cpx doc
+// --generator standardese, an HTML site in docs/standardese
+func GenerateStandardeseConfig() string {
+	return `# standardese configuration, generated by "cpx doc --generator standardese"
+[input]
+source_ext=.hpp
+source_ext=.h
+
+[compilation]
+include_dir=include
+
+[output]
+format=html
+prefix=docs/standardese/
+`
+}
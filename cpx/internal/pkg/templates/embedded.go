@@ -0,0 +1,232 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/naming"
+)
+
+// ============================================================================
+// EMBEDDED (BARE-METAL) TEMPLATES
+// ============================================================================
+
+// EmbeddedToolchainFile is the CMake toolchain file of an embedded project,
+// relative to the project root
+const EmbeddedToolchainFile = "cmake/arm-none-eabi.cmake"
+
+// EmbeddedSources holds the cross-compilation files of an embedded project
+type EmbeddedSources struct {
+	Toolchain    string // cmake/arm-none-eabi.cmake
+	LinkerScript string // linker/<project>.ld
+	Main         string // src/main.cpp, the firmware entry point
+	// Source replaces src/<project>.cpp with a version that doesn't pull in
+	// iostreams, which hardly fit in the flash of a microcontroller
+	Source string
+}
+
+// GenerateEmbeddedSources generates an arm-none-eabi toolchain file, a
+// placeholder linker script and firmware sources that also build on the
+// host, so that the library code stays unit-testable
+func GenerateEmbeddedSources(projectName string) *EmbeddedSources {
+	safeName := naming.SafeIdent(projectName)
+
+	toolchain := fmt.Sprintf(`# Bare-metal ARM toolchain for %s, used by "cpx build --target embedded"
+set(CMAKE_SYSTEM_NAME Generic)
+set(CMAKE_SYSTEM_PROCESSOR arm)
+
+set(CMAKE_C_COMPILER arm-none-eabi-gcc)
+set(CMAKE_CXX_COMPILER arm-none-eabi-g++)
+set(CMAKE_ASM_COMPILER arm-none-eabi-gcc)
+set(CMAKE_OBJCOPY arm-none-eabi-objcopy CACHE FILEPATH "")
+set(CMAKE_SIZE arm-none-eabi-size CACHE FILEPATH "")
+
+# Compiler checks can't link an executable without the project's linker script
+set(CMAKE_TRY_COMPILE_TARGET_TYPE STATIC_LIBRARY)
+
+# TODO: set the core and FPU of your microcontroller
+set(MCU_FLAGS "-mcpu=cortex-m4 -mthumb")
+
+set(CMAKE_C_FLAGS_INIT "${MCU_FLAGS} -ffunction-sections -fdata-sections")
+set(CMAKE_CXX_FLAGS_INIT "${MCU_FLAGS} -ffunction-sections -fdata-sections -fno-exceptions -fno-rtti -fno-threadsafe-statics")
+set(CMAKE_ASM_FLAGS_INIT "${MCU_FLAGS}")
+# nosys.specs stubs out the system calls newlib expects from an OS
+set(CMAKE_EXE_LINKER_FLAGS_INIT "${MCU_FLAGS} --specs=nosys.specs -Wl,--gc-sections")
+
+# Only search the toolchain's sysroot for libraries and headers
+set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)
+set(CMAKE_FIND_ROOT_PATH_MODE_LIBRARY ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_INCLUDE ONLY)
+set(CMAKE_FIND_ROOT_PATH_MODE_PACKAGE ONLY)
+`, projectName)
+
+	linkerScript := fmt.Sprintf(`/*
+ * Linker script for %s
+ *
+ * TODO: this is a placeholder layout. Set ORIGIN and LENGTH of FLASH and RAM
+ * to the memory map of your microcontroller, and add its startup code and
+ * vector table (section .isr_vector) to the project.
+ */
+ENTRY(_start)
+
+MEMORY
+{
+    FLASH (rx)  : ORIGIN = 0x08000000, LENGTH = 256K
+    RAM   (rwx) : ORIGIN = 0x20000000, LENGTH = 64K
+}
+
+/* The stack grows down from the end of RAM */
+__stack = ORIGIN(RAM) + LENGTH(RAM);
+
+SECTIONS
+{
+    .isr_vector :
+    {
+        . = ALIGN(4);
+        KEEP(*(.isr_vector))
+    } > FLASH
+
+    .text :
+    {
+        *(.text*)
+        *(.rodata*)
+        KEEP(*(.init))
+        KEEP(*(.fini))
+        . = ALIGN(4);
+    } > FLASH
+
+    .ARM.exidx :
+    {
+        __exidx_start = .;
+        *(.ARM.exidx*)
+        __exidx_end = .;
+    } > FLASH
+
+    .preinit_array :
+    {
+        PROVIDE_HIDDEN(__preinit_array_start = .);
+        KEEP(*(.preinit_array*))
+        PROVIDE_HIDDEN(__preinit_array_end = .);
+    } > FLASH
+
+    .init_array :
+    {
+        PROVIDE_HIDDEN(__init_array_start = .);
+        KEEP(*(SORT(.init_array.*)))
+        KEEP(*(.init_array*))
+        PROVIDE_HIDDEN(__init_array_end = .);
+    } > FLASH
+
+    .fini_array :
+    {
+        PROVIDE_HIDDEN(__fini_array_start = .);
+        KEEP(*(SORT(.fini_array.*)))
+        KEEP(*(.fini_array*))
+        PROVIDE_HIDDEN(__fini_array_end = .);
+    } > FLASH
+
+    .data :
+    {
+        . = ALIGN(4);
+        __data_start__ = .;
+        *(.data*)
+        . = ALIGN(4);
+        __data_end__ = .;
+    } > RAM AT > FLASH
+    __data_load__ = LOADADDR(.data);
+
+    .bss (NOLOAD) :
+    {
+        . = ALIGN(4);
+        __bss_start__ = .;
+        *(.bss*)
+        *(COMMON)
+        . = ALIGN(4);
+        __bss_end__ = .;
+    } > RAM
+
+    /* The heap (malloc, via _sbrk of nosys.specs) starts here */
+    end = .;
+    _end = .;
+}
+`, projectName)
+
+	main := fmt.Sprintf(`#include <%s/%s.hpp>
+
+int main() {
+    %s::greet();
+
+#if defined(__arm__)
+    // Firmware never returns: run the application loop here
+    for (;;) {
+    }
+#endif
+    return 0;
+}
+`, projectName, projectName, safeName)
+
+	source := fmt.Sprintf(`#include <%s/%s.hpp>
+
+#include <cstdint>
+
+namespace %s {
+
+namespace {
+// Stand-in for a peripheral; replace it with your board's UART or GPIO
+volatile std::uint32_t greet_count = 0;
+}  // namespace
+
+void greet() {
+    greet_count = greet_count + 1;
+}
+
+std::string version() {
+    return "1.0.0";
+}
+
+}  // namespace %s
+`, projectName, projectName, safeName, safeName)
+
+	return &EmbeddedSources{
+		Toolchain:    toolchain,
+		LinkerScript: linkerScript,
+		Main:         main,
+		Source:       source,
+	}
+}
+
+// GenerateEmbeddedCMake generates the CMake section of an embedded project.
+// Cross-compiled with the toolchain file, the executable is linked with the
+// linker script into <project>.elf, from which .bin and .hex images are
+// extracted. Tests and benchmarks only build on the host.
+func GenerateEmbeddedCMake(projectName string, includeTests, includeBench bool) string {
+	section := fmt.Sprintf(`
+# Firmware: "cpx build --target embedded" configures with %s
+if(CMAKE_CROSSCOMPILING)
+    set(LINKER_SCRIPT ${CMAKE_CURRENT_SOURCE_DIR}/linker/%s.ld)
+    target_link_options(%s PRIVATE
+        -T${LINKER_SCRIPT}
+        -Wl,-Map=${CMAKE_CURRENT_BINARY_DIR}/%s.map
+    )
+    set_target_properties(%s PROPERTIES
+        SUFFIX .elf
+        LINK_DEPENDS ${LINKER_SCRIPT}
+    )
+    add_custom_command(TARGET %s POST_BUILD
+        COMMAND ${CMAKE_OBJCOPY} -O binary $<TARGET_FILE:%s> ${CMAKE_CURRENT_BINARY_DIR}/%s.bin
+        COMMAND ${CMAKE_OBJCOPY} -O ihex $<TARGET_FILE:%s> ${CMAKE_CURRENT_BINARY_DIR}/%s.hex
+        COMMAND ${CMAKE_SIZE} $<TARGET_FILE:%s>
+        VERBATIM
+    )
+`, EmbeddedToolchainFile, projectName, projectName, projectName, projectName, projectName, projectName, projectName, projectName, projectName, projectName)
+
+	if includeTests || includeBench {
+		section += "else()\n    # Host builds only: tests and benchmarks don't run on the microcontroller\n"
+		if includeTests {
+			section += "    enable_testing()\n    add_subdirectory(tests)\n"
+		}
+		if includeBench {
+			section += "    add_subdirectory(bench)\n"
+		}
+	}
+	return section + "endif()\n"
+}
@@ -0,0 +1,237 @@
+package templates
+
+import (
+	"fmt"
+
+	"github.com/ozacod/cpx/internal/pkg/naming"
+)
+
+// ============================================================================
+// GRPC SERVICE TEMPLATES
+// ============================================================================
+
+// GrpcDependencies are the vcpkg ports a gRPC service project needs
+var GrpcDependencies = []string{"grpc", "protobuf"}
+
+// GrpcSources holds the sample Greeter service of a gRPC service project
+type GrpcSources struct {
+	Proto  string // proto/<project>.proto
+	Header string // include/<project>/greeter_service.hpp
+	Source string // src/greeter_service.cpp
+	Main   string // src/main.cpp, runs the server
+	// Test is tests/greeter_service_test.cpp, or empty without a test framework
+	Test string
+}
+
+// GenerateGrpcSources generates the proto definition, the Greeter service
+// implementation, a server main and a test calling the service in-process
+func GenerateGrpcSources(projectName, testFramework string) *GrpcSources {
+	safeName := naming.SafeIdent(projectName)
+	guard := naming.SafeIdentUpper(projectName) + "_GREETER_SERVICE_HPP"
+
+	proto := fmt.Sprintf(`syntax = "proto3";
+
+package %s;
+
+// Sample service; add your RPCs here and implement them in GreeterService
+service Greeter {
+  rpc SayHello(HelloRequest) returns (HelloReply);
+}
+
+message HelloRequest {
+  string name = 1;
+}
+
+message HelloReply {
+  string message = 1;
+}
+`, safeName)
+
+	header := fmt.Sprintf(`#ifndef %s
+#define %s
+
+#include <grpcpp/grpcpp.h>
+
+#include "%s.grpc.pb.h"
+
+namespace %s {
+
+/**
+ * @brief Implementation of the Greeter service from proto/%s.proto
+ */
+class GreeterService final : public Greeter::Service {
+public:
+    grpc::Status SayHello(grpc::ServerContext* context, const HelloRequest* request,
+                          HelloReply* reply) override;
+};
+
+}  // namespace %s
+
+#endif  // %s
+`, guard, guard, projectName, safeName, projectName, safeName, guard)
+
+	source := fmt.Sprintf(`#include <%s/greeter_service.hpp>
+
+namespace %s {
+
+grpc::Status GreeterService::SayHello(grpc::ServerContext* /*context*/, const HelloRequest* request,
+                                      HelloReply* reply) {
+    if (request->name().empty()) {
+        return {grpc::StatusCode::INVALID_ARGUMENT, "name must not be empty"};
+    }
+    reply->set_message("Hello, " + request->name() + "!");
+    return grpc::Status::OK;
+}
+
+}  // namespace %s
+`, projectName, safeName, safeName)
+
+	main := fmt.Sprintf(`#include <%s/greeter_service.hpp>
+
+#include <grpcpp/ext/proto_server_reflection_plugin.h>
+#include <grpcpp/health_check_service_interface.h>
+
+#include <iostream>
+#include <memory>
+#include <string>
+
+int main(int argc, char** argv) {
+    const std::string address = argc > 1 ? argv[1] : "0.0.0.0:50051";
+
+    %s::GreeterService service;
+    grpc::EnableDefaultHealthCheckService(true);
+    grpc::reflection::InitProtoReflectionServerBuilderPlugin();
+
+    grpc::ServerBuilder builder;
+    builder.AddListeningPort(address, grpc::InsecureServerCredentials());
+    builder.RegisterService(&service);
+    std::unique_ptr<grpc::Server> server = builder.BuildAndStart();
+    if (!server) {
+        std::cerr << "Failed to listen on " << address << std::endl;
+        return 1;
+    }
+
+    std::cout << "%s listening on " << address << std::endl;
+    server->Wait();
+    return 0;
+}
+`, projectName, safeName, projectName)
+
+	return &GrpcSources{
+		Proto:  proto,
+		Header: header,
+		Source: source,
+		Main:   main,
+		Test:   generateGrpcTest(projectName, safeName, testFramework),
+	}
+}
+
+func generateGrpcTest(projectName, safeName, testFramework string) string {
+	setup := fmt.Sprintf(`    %s::GreeterService service;
+    grpc::ServerContext context;
+    %s::HelloRequest request;
+    %s::HelloReply reply;
+`, safeName, safeName, safeName)
+
+	switch testFramework {
+	case "googletest":
+		return fmt.Sprintf(`#include <gtest/gtest.h>
+#include <%s/greeter_service.hpp>
+
+TEST(GreeterServiceTest, SayHello) {
+%s
+    request.set_name("cpx");
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    ASSERT_TRUE(status.ok());
+    EXPECT_EQ(reply.message(), "Hello, cpx!");
+}
+
+TEST(GreeterServiceTest, SayHelloRejectsEmptyName) {
+%s
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    EXPECT_EQ(status.error_code(), grpc::StatusCode::INVALID_ARGUMENT);
+}
+`, projectName, setup, setup)
+	case "catch2":
+		return fmt.Sprintf(`#include <catch2/catch_test_macros.hpp>
+#include <%s/greeter_service.hpp>
+
+TEST_CASE("GreeterService::SayHello greets by name", "[grpc]") {
+%s
+    request.set_name("cpx");
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    REQUIRE(status.ok());
+    REQUIRE(reply.message() == "Hello, cpx!");
+}
+
+TEST_CASE("GreeterService::SayHello rejects an empty name", "[grpc]") {
+%s
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    REQUIRE(status.error_code() == grpc::StatusCode::INVALID_ARGUMENT);
+}
+`, projectName, setup, setup)
+	case "doctest":
+		return fmt.Sprintf(`#include <doctest/doctest.h>
+#include <%s/greeter_service.hpp>
+
+TEST_CASE("GreeterService::SayHello greets by name") {
+%s
+    request.set_name("cpx");
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    REQUIRE(status.ok());
+    CHECK(reply.message() == "Hello, cpx!");
+}
+
+TEST_CASE("GreeterService::SayHello rejects an empty name") {
+%s
+    grpc::Status status = service.SayHello(&context, &request, &reply);
+    CHECK(status.error_code() == grpc::StatusCode::INVALID_ARGUMENT);
+}
+`, projectName, setup, setup)
+	default:
+		return ""
+	}
+}
+
+// GenerateGrpcCMake generates the CMake section that compiles the proto
+// files with protoc and the gRPC plugin into <project>_proto and links the
+// service into the project executable
+func GenerateGrpcCMake(projectName string) string {
+	return fmt.Sprintf(`
+# gRPC service: proto/*.proto is compiled into %s_proto
+find_package(protobuf CONFIG REQUIRED)
+find_package(gRPC CONFIG REQUIRED)
+
+set(PROTO_GENERATED_DIR ${CMAKE_CURRENT_BINARY_DIR}/generated)
+file(MAKE_DIRECTORY ${PROTO_GENERATED_DIR})
+file(GLOB PROTO_FILES CONFIGURE_DEPENDS ${CMAKE_CURRENT_SOURCE_DIR}/proto/*.proto)
+
+add_library(%s_proto ${PROTO_FILES})
+target_link_libraries(%s_proto PUBLIC protobuf::libprotobuf gRPC::grpc++)
+target_include_directories(%s_proto PUBLIC $<BUILD_INTERFACE:${PROTO_GENERATED_DIR}>)
+protobuf_generate(TARGET %s_proto LANGUAGE cpp
+    IMPORT_DIRS ${CMAKE_CURRENT_SOURCE_DIR}/proto
+    PROTOC_OUT_DIR ${PROTO_GENERATED_DIR})
+protobuf_generate(TARGET %s_proto LANGUAGE grpc
+    GENERATE_EXTENSIONS .grpc.pb.h .grpc.pb.cc
+    PLUGIN "protoc-gen-grpc=$<TARGET_FILE:gRPC::grpc_cpp_plugin>"
+    IMPORT_DIRS ${CMAKE_CURRENT_SOURCE_DIR}/proto
+    PROTOC_OUT_DIR ${PROTO_GENERATED_DIR})
+
+target_sources(%s PRIVATE src/greeter_service.cpp)
+target_link_libraries(%s PRIVATE %s_proto gRPC::grpc++_reflection)
+`, projectName, projectName, projectName, projectName, projectName, projectName, projectName, projectName, projectName)
+}
+
+// GenerateGrpcTestCMake generates the tests/CMakeLists.txt section that adds
+// the Greeter service test
+func GenerateGrpcTestCMake(projectName string) string {
+	return fmt.Sprintf(`
+# gRPC service test (%s_proto is defined in the root CMakeLists.txt)
+target_sources(%s_tests PRIVATE
+    greeter_service_test.cpp
+    ${CMAKE_CURRENT_SOURCE_DIR}/../src/greeter_service.cpp
+)
+target_link_libraries(%s_tests PRIVATE %s_proto)
+`, projectName, projectName, projectName, projectName)
+}
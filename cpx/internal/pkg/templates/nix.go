@@ -0,0 +1,192 @@
+package templates
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// NIX TEMPLATES
+// ============================================================================
+
+// nixPackages maps vcpkg port names to the nixpkgs attributes that provide
+// the same library. Ports starting with "boost-" map to boost.
+var nixPackages = map[string]string{
+	"abseil":        "abseil-cpp",
+	"benchmark":     "gbenchmark",
+	"boost":         "boost",
+	"catch2":        "catch2_3",
+	"cli11":         "cli11",
+	"curl":          "curl",
+	"doctest":       "doctest",
+	"eigen3":        "eigen",
+	"fmt":           "fmt",
+	"glm":           "glm",
+	"grpc":          "grpc",
+	"gtest":         "gtest",
+	"ms-gsl":        "microsoft-gsl",
+	"nlohmann-json": "nlohmann_json",
+	"openssl":       "openssl",
+	"protobuf":      "protobuf",
+	"range-v3":      "range-v3",
+	"sdl2":          "SDL2",
+	"spdlog":        "spdlog",
+	"sqlite3":       "sqlite",
+	"yaml-cpp":      "yaml-cpp",
+	"zlib":          "zlib",
+}
+
+// NixPackage returns the nixpkgs attribute for a vcpkg port, or "" if there
+// is no known equivalent
+func NixPackage(port string) string {
+	if strings.HasPrefix(port, "boost-") {
+		return "boost"
+	}
+	return nixPackages[port]
+}
+
+// nixStdenv returns the nixpkgs stdenv and debugger for the compiler of opts
+func nixStdenv(opts ToolchainOptions) (stdenv, debugger string) {
+	if opts.Compiler == "clang" {
+		if opts.CompilerVersion != "" {
+			llvm := "pkgs.llvmPackages_" + opts.CompilerVersion
+			return llvm + ".stdenv", llvm + ".lldb"
+		}
+		return "pkgs.clangStdenv", "pkgs.lldb"
+	}
+	if opts.CompilerVersion != "" {
+		return "pkgs.gcc" + opts.CompilerVersion + "Stdenv", "pkgs.gdb"
+	}
+	return "pkgs.stdenv", "pkgs.gdb"
+}
+
+// GenerateFlakeNix generates flake.nix with a dev shell holding the project's
+// toolchain and a package that builds the project. Libraries listed in
+// vcpkg.json are taken from nixpkgs, since vcpkg can't download inside the
+// Nix build sandbox.
+func GenerateFlakeNix(opts ToolchainOptions) string {
+	stdenv, debugger := nixStdenv(opts)
+
+	var libs, unmapped []string
+	seen := map[string]bool{}
+	for _, port := range opts.Dependencies {
+		pkg := NixPackage(port)
+		if pkg == "" {
+			unmapped = append(unmapped, port)
+			continue
+		}
+		if !seen[pkg] {
+			seen[pkg] = true
+			libs = append(libs, pkg)
+		}
+	}
+
+	var tools []string
+	switch opts.BuildSystem {
+	case "meson":
+		tools = []string{"meson", "ninja", "pkg-config"}
+	case "bazel":
+		tools = []string{"bazelisk"}
+	default:
+		tools = []string{"cmake", "ninja"}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `# Nix flake for %s, generated by cpx gen nix
+#
+#   nix develop   # shell with the project's toolchain
+#   nix build     # build the project into ./result
+{
+  description = "%s";
+
+  inputs = {
+    nixpkgs.url = "github:NixOS/nixpkgs/nixos-unstable";
+    flake-utils.url = "github:numtide/flake-utils";
+  };
+
+  outputs = { self, nixpkgs, flake-utils }:
+    flake-utils.lib.eachDefaultSystem (system:
+      let
+        pkgs = nixpkgs.legacyPackages.${system};
+        stdenv = %s;
+
+`, opts.ProjectName, opts.ProjectName, stdenv)
+
+	b.WriteString("        # Libraries from vcpkg.json, provided by nixpkgs\n")
+	for _, port := range unmapped {
+		fmt.Fprintf(&b, "        # TODO: no nixpkgs equivalent known for vcpkg port %q\n", port)
+	}
+	fmt.Fprintf(&b, "        buildInputs = with pkgs; [ %s];\n      in\n      {\n", nixList(libs))
+
+	shellPackages := append(append([]string{}, tools...), "clang-tools")
+	if opts.Vcpkg {
+		shellPackages = append(shellPackages, "vcpkg")
+	}
+	fmt.Fprintf(&b, `        devShells.default = (pkgs.mkShell.override { inherit stdenv; }) {
+          packages = (with pkgs; [ %s]) ++ [ %s ];
+          inherit buildInputs;
+`, nixList(shellPackages), debugger)
+	if opts.Vcpkg {
+		b.WriteString(`          # cpx build still resolves vcpkg.json through vcpkg
+          shellHook = ''
+            export VCPKG_ROOT=${pkgs.vcpkg}/share/vcpkg
+          '';
+`)
+	}
+	b.WriteString("        };\n")
+
+	switch opts.BuildSystem {
+	case "bazel":
+		b.WriteString(`
+        # Bazel fetches its dependencies over the network, which the Nix build
+        # sandbox forbids; build with "bazel build //..." in the dev shell
+`)
+	case "meson":
+		fmt.Fprintf(&b, `
+        packages.default = stdenv.mkDerivation {
+          pname = "%s";
+          version = "%s";
+          src = ./.;
+
+          nativeBuildInputs = with pkgs; [ %s];
+          inherit buildInputs;
+          # Test and benchmark frameworks come from wraps fetched over the network
+          mesonFlags = [ "-Denable_tests=false" "-Denable_benchmarks=false" ];
+        };
+`, opts.ProjectName, opts.ProjectVersion, nixList(tools))
+	default:
+		fmt.Fprintf(&b, `
+        packages.default = stdenv.mkDerivation {
+          pname = "%s";
+          version = "%s";
+          src = ./.;
+
+          nativeBuildInputs = with pkgs; [ %s];
+          inherit buildInputs;
+          # Test and benchmark frameworks come from FetchContent, which needs
+          # network access
+          postPatch = ''
+            sed -i '/add_subdirectory(tests)/d; /add_subdirectory(bench)/d' CMakeLists.txt
+          '';
+          # The project has no install rules for its executable
+          postInstall = ''
+            if [ -x %s ]; then install -Dm755 %s $out/bin/%s; fi
+            mkdir -p $out
+          '';
+        };
+`, opts.ProjectName, opts.ProjectVersion, nixList(tools), opts.ProjectName, opts.ProjectName, opts.ProjectName)
+	}
+
+	b.WriteString("      });\n}\n")
+	return b.String()
+}
+
+// nixList formats names as the items of a Nix list, each followed by a space
+func nixList(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteString(" ")
+	}
+	return b.String()
+}
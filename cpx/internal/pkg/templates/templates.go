@@ -7,6 +7,20 @@ import (
 	"github.com/ozacod/cpx/internal/pkg/naming"
 )
 
+// ============================================================================
+// PROJECT TEMPLATES
+// ============================================================================
+
+// Project templates offered by `cpx new --template`
+const (
+	TemplateGrpcService = "grpc-service"
+	TemplateCLIApp      = "cli-app"
+	TemplateEmbedded    = "embedded"
+)
+
+// ProjectTemplates lists the project templates offered by `cpx new`
+var ProjectTemplates = []string{TemplateGrpcService, TemplateCLIApp, TemplateEmbedded}
+
 // ============================================================================
 // C++ SOURCE TEMPLATES
 // ============================================================================
@@ -233,6 +247,34 @@ add_subdirectory(bench)
 	return sb.String()
 }
 
+// pchHeaders are the standard headers precompiled when build.pch is enabled.
+// All of them are available from C++11 on.
+var pchHeaders = []string{
+	"<algorithm>", "<cstddef>", "<cstdint>", "<functional>", "<iostream>", "<map>",
+	"<memory>", "<string>", "<unordered_map>", "<utility>", "<vector>",
+}
+
+// GeneratePCHCMake generates the CMake section that precompiles common
+// standard headers and the project header for the project target. The
+// CPX_PCH option is set by cpx build from build.pch in cpx.yaml.
+func GeneratePCHCMake(projectName string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`
+# Precompiled headers (build.pch in cpx.yaml)
+option(CPX_PCH "Precompile common headers" ON)
+if(CPX_PCH)
+    target_precompile_headers(%s PRIVATE
+`, projectName))
+	for _, header := range pchHeaders {
+		sb.WriteString("        " + header + "\n")
+	}
+	sb.WriteString(fmt.Sprintf(`        "${PROJECT_SOURCE_DIR}/include/%s/%s.hpp"
+    )
+endif()
+`, projectName, projectName))
+	return sb.String()
+}
+
 // generateCMakePresets generates CMakePresets.json
 // Assumes VCPKG_ROOT environment variable is set
 func GenerateCMakePresets() string {
@@ -256,10 +298,6 @@ func GenerateCMakePresets() string {
 }
 
 func GenerateTestCMake(projectName string, testingFramework string) string {
-	hasGtest := testingFramework == "googletest"
-	hasCatch2 := testingFramework == "catch2"
-	hasDoctest := testingFramework == "doctest"
-
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf(`# Test configuration for %s
 
@@ -275,6 +313,20 @@ target_include_directories(%s_tests
 
 `, projectName, projectName, projectName, projectName))
 
+	sb.WriteString(cmakeTestFramework(projectName+"_tests", testingFramework))
+
+	return sb.String()
+}
+
+// cmakeTestFramework returns the CMake section that fetches a test framework
+// with FetchContent, links it to the test target and registers its tests
+func cmakeTestFramework(target, testingFramework string) string {
+	hasGtest := testingFramework == "googletest"
+	hasCatch2 := testingFramework == "catch2"
+	hasDoctest := testingFramework == "doctest"
+
+	var sb strings.Builder
+
 	// Use FetchContent for testing frameworks
 	if hasGtest {
 		sb.WriteString(`# Fetch googletest
@@ -288,9 +340,9 @@ set(gtest_force_shared_crt ON CACHE BOOL "" FORCE)
 FetchContent_MakeAvailable(googletest)
 
 `)
-		sb.WriteString(fmt.Sprintf("target_link_libraries(%s_tests PRIVATE gtest gtest_main gmock)\n\n", projectName))
+		sb.WriteString(fmt.Sprintf("target_link_libraries(%s PRIVATE gtest gtest_main gmock)\n\n", target))
 		sb.WriteString("include(GoogleTest)\n")
-		sb.WriteString(fmt.Sprintf("gtest_discover_tests(%s_tests)\n", projectName))
+		sb.WriteString(fmt.Sprintf("gtest_discover_tests(%s)\n", target))
 	} else if hasCatch2 {
 		sb.WriteString(`# Fetch Catch2
 include(FetchContent)
@@ -302,10 +354,10 @@ FetchContent_Declare(
 FetchContent_MakeAvailable(Catch2)
 
 `)
-		sb.WriteString(fmt.Sprintf("target_link_libraries(%s_tests PRIVATE Catch2::Catch2WithMain)\n\n", projectName))
+		sb.WriteString(fmt.Sprintf("target_link_libraries(%s PRIVATE Catch2::Catch2WithMain)\n\n", target))
 		sb.WriteString("include(CTest)\n")
 		sb.WriteString("include(Catch)\n")
-		sb.WriteString(fmt.Sprintf("catch_discover_tests(%s_tests)\n", projectName))
+		sb.WriteString(fmt.Sprintf("catch_discover_tests(%s)\n", target))
 	} else if hasDoctest {
 		sb.WriteString(`# Fetch doctest
 include(FetchContent)
@@ -317,11 +369,11 @@ FetchContent_Declare(
 FetchContent_MakeAvailable(doctest)
 
 `)
-		sb.WriteString(fmt.Sprintf("target_link_libraries(%s_tests PRIVATE doctest::doctest)\n\n", projectName))
+		sb.WriteString(fmt.Sprintf("target_link_libraries(%s PRIVATE doctest::doctest)\n\n", target))
 		sb.WriteString("include(CTest)\n")
-		sb.WriteString(fmt.Sprintf("add_test(NAME %s_tests COMMAND %s_tests)\n", projectName, projectName))
+		sb.WriteString(fmt.Sprintf("add_test(NAME %s COMMAND %s)\n", target, target))
 	} else {
-		sb.WriteString(fmt.Sprintf("add_test(NAME %s_tests COMMAND %s_tests)\n", projectName, projectName))
+		sb.WriteString(fmt.Sprintf("add_test(NAME %s COMMAND %s)\n", target, target))
 	}
 
 	return sb.String()
@@ -329,6 +381,29 @@ FetchContent_MakeAvailable(doctest)
 
 // GenerateBenchCMake generates bench/CMakeLists.txt with FetchContent for benchmark frameworks
 func GenerateBenchCMake(projectName string, benchmarkFramework string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(`# Benchmark configuration for %s
+
+add_executable(%s_bench
+    bench_main.cpp
+    ${CMAKE_CURRENT_SOURCE_DIR}/../src/%s.cpp
+)
+
+target_include_directories(%s_bench
+    PRIVATE
+        ${CMAKE_CURRENT_SOURCE_DIR}/../include
+)
+
+`, projectName, projectName, projectName, projectName))
+
+	sb.WriteString(cmakeBenchFramework(projectName+"_bench", benchmarkFramework))
+
+	return sb.String()
+}
+
+// cmakeBenchFramework returns the CMake section that fetches a benchmark
+// framework with FetchContent and links it to the benchmark target
+func cmakeBenchFramework(target, benchmarkFramework string) string {
 	hasGoogleBench := false
 	hasCatch2Bench := false
 	hasNanoBench := false
@@ -344,19 +419,6 @@ func GenerateBenchCMake(projectName string, benchmarkFramework string) string {
 	}
 
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf(`# Benchmark configuration for %s
-
-add_executable(%s_bench
-    bench_main.cpp
-    ${CMAKE_CURRENT_SOURCE_DIR}/../src/%s.cpp
-)
-
-target_include_directories(%s_bench
-    PRIVATE
-        ${CMAKE_CURRENT_SOURCE_DIR}/../include
-)
-
-`, projectName, projectName, projectName, projectName))
 
 	// Use FetchContent for benchmark frameworks
 	if hasGoogleBench {
@@ -372,7 +434,7 @@ set(BENCHMARK_ENABLE_INSTALL OFF CACHE BOOL "" FORCE)
 FetchContent_MakeAvailable(benchmark)
 
 `)
-		sb.WriteString(fmt.Sprintf("target_link_libraries(%s_bench PRIVATE benchmark::benchmark benchmark::benchmark_main)\n", projectName))
+		sb.WriteString(fmt.Sprintf("target_link_libraries(%s PRIVATE benchmark::benchmark benchmark::benchmark_main)\n", target))
 	} else if hasCatch2Bench {
 		sb.WriteString(`# Fetch Catch2 for benchmarking
 include(FetchContent)
@@ -384,7 +446,7 @@ FetchContent_Declare(
 FetchContent_MakeAvailable(Catch2)
 
 `)
-		sb.WriteString(fmt.Sprintf("target_link_libraries(%s_bench PRIVATE Catch2::Catch2WithMain)\n", projectName))
+		sb.WriteString(fmt.Sprintf("target_link_libraries(%s PRIVATE Catch2::Catch2WithMain)\n", target))
 	} else if hasNanoBench {
 		sb.WriteString(`# Fetch nanobench
 include(FetchContent)
@@ -396,7 +458,7 @@ FetchContent_Declare(
 FetchContent_MakeAvailable(nanobench)
 
 `)
-		sb.WriteString(fmt.Sprintf("target_link_libraries(%s_bench PRIVATE nanobench)\n", projectName))
+		sb.WriteString(fmt.Sprintf("target_link_libraries(%s PRIVATE nanobench)\n", target))
 	}
 
 	return sb.String()
@@ -417,6 +479,7 @@ out/
 # IDE
 .idea/
 .vscode/
+.clangd
 *.swp
 *.swo
 *~
@@ -488,6 +551,34 @@ IndentCaseLabels: true
 	}
 }
 
+// GenerateClangd generates the .clangd configuration. clangd resolves
+// include paths against the compile command's directory, so includeDirs must
+// be absolute; the file is therefore machine-specific and regenerated by
+// cpx gen clangd.
+func GenerateClangd(cppStandard int, includeDirs []string, suppress []string) string {
+	var b strings.Builder
+	b.WriteString(`# .clangd - generated by cpx; refresh with: cpx gen clangd
+CompileFlags:
+  # compile_commands.json in the project root: linked to the build directory
+  # by cpx build (exported with bazel aquery for Bazel), or merged by cpx compdb
+  CompilationDatabase: .
+  # Appended to every compile command, including those from the database,
+  # so files it doesn't cover yet, such as new headers, parse too
+`)
+	fmt.Fprintf(&b, "  Add:\n    - -std=c++%d\n", cppStandard)
+	for _, dir := range includeDirs {
+		fmt.Fprintf(&b, "    - -I%s\n", dir)
+	}
+	b.WriteString("Diagnostics:\n  UnusedIncludes: Strict\n")
+	if len(suppress) > 0 {
+		b.WriteString("  # clangd.suppress in cpx.yaml\n  Suppress:\n")
+		for _, id := range suppress {
+			fmt.Fprintf(&b, "    - %s\n", id)
+		}
+	}
+	return b.String()
+}
+
 // generateCpxCI generates a cpx.ci file with empty targets
 func GenerateCpxCI() string {
 	return `# cpx.ci - Cross-compilation configuration
@@ -522,6 +613,19 @@ output: .bin/ci
 `
 }
 
+// GenerateCpxYaml generates the cpx.yaml project configuration
+func GenerateCpxYaml(pch bool) string {
+	return fmt.Sprintf(`# cpx.yaml - Project configuration
+
+build:
+  # Precompile common headers (CMake projects)
+  pch: %t
+
+  # Unity (jumbo) builds: compile sources in batches, like cpx build --unity
+  unity: false
+`, pch)
+}
+
 // ============================================================================
 // DOCUMENTATION TEMPLATES
 // ============================================================================
@@ -907,6 +1011,7 @@ build/
 # IDE
 .idea/
 .vscode/
+.clangd
 *.swp
 *.swo
 *~
@@ -1107,30 +1212,7 @@ src_files = files(
 func GenerateMesonBuildTests(projectName, testFramework string) string {
 	safeName := naming.SafeIdent(projectName)
 
-	var depLine string
-	switch testFramework {
-	case "googletest":
-		depLine = "gtest_dep = dependency('gtest', fallback : ['gtest', 'gtest_main_dep'])"
-	case "catch2":
-		depLine = "catch2_dep = dependency('catch2-with-main', fallback : ['catch2', 'catch2_with_main_dep'])"
-	case "doctest":
-		depLine = "doctest_dep = dependency('doctest', fallback : ['doctest', 'doctest_dep'])"
-	default:
-		depLine = "# No test framework"
-	}
-
-	var depsArg string
-	switch testFramework {
-	case "googletest":
-		depsArg = "gtest_dep"
-	case "catch2":
-		depsArg = "catch2_dep"
-	case "doctest":
-		depsArg = "doctest_dep"
-	default:
-		depsArg = ""
-	}
-
+	depLine, depsArg := mesonTestFramework(testFramework)
 	if depsArg != "" {
 		depsArg = ",\n  dependencies : [" + depsArg + "]"
 	}
@@ -1150,26 +1232,26 @@ test('%s tests', test_exe)
 `, depLine, projectName, safeName, depsArg, projectName)
 }
 
+// mesonTestFramework returns the line declaring the dependency on a test
+// framework, and the variable holding it ("" without a framework)
+func mesonTestFramework(testFramework string) (depLine, depVar string) {
+	switch testFramework {
+	case "googletest":
+		return "gtest_dep = dependency('gtest', fallback : ['gtest', 'gtest_main_dep'])", "gtest_dep"
+	case "catch2":
+		return "catch2_dep = dependency('catch2-with-main', fallback : ['catch2', 'catch2_with_main_dep'])", "catch2_dep"
+	case "doctest":
+		return "doctest_dep = dependency('doctest', fallback : ['doctest', 'doctest_dep'])", "doctest_dep"
+	default:
+		return "# No test framework", ""
+	}
+}
+
 // GenerateMesonBuildBench generates bench/meson.build
 func GenerateMesonBuildBench(projectName, benchmarkFramework string) string {
 	safeName := naming.SafeIdent(projectName)
 
-	var depLine, depsArg string
-	switch benchmarkFramework {
-	case "google-benchmark":
-		depLine = "benchmark_dep = dependency('benchmark', fallback : ['google-benchmark', 'google_benchmark_dep'])"
-		depsArg = "benchmark_dep"
-	case "nanobench":
-		depLine = "# nanobench is header-only"
-		depsArg = ""
-	case "catch2-benchmark":
-		depLine = "catch2_dep = dependency('catch2-with-main', fallback : ['catch2', 'catch2_with_main_dep'])"
-		depsArg = "catch2_dep"
-	default:
-		depLine = "# No benchmark framework"
-		depsArg = ""
-	}
-
+	depLine, depsArg := mesonBenchFramework(benchmarkFramework)
 	if depsArg != "" {
 		depsArg = ",\n  dependencies : [" + depsArg + "]"
 	}
@@ -1188,6 +1270,21 @@ bench_exe = executable('%s_bench',
 `, depLine, projectName, safeName, depsArg)
 }
 
+// mesonBenchFramework returns the line declaring the dependency on a
+// benchmark framework, and the variable holding it ("" if there is none)
+func mesonBenchFramework(benchmarkFramework string) (depLine, depVar string) {
+	switch benchmarkFramework {
+	case "google-benchmark":
+		return "benchmark_dep = dependency('benchmark', fallback : ['google-benchmark', 'google_benchmark_dep'])", "benchmark_dep"
+	case "nanobench":
+		return "# nanobench is header-only", ""
+	case "catch2-benchmark":
+		return "catch2_dep = dependency('catch2-with-main', fallback : ['catch2', 'catch2_with_main_dep'])", "catch2_dep"
+	default:
+		return "# No benchmark framework", ""
+	}
+}
+
 // GenerateMesonOptions generates meson.options
 func GenerateMesonOptions() string {
 	return `# Build options
@@ -1205,10 +1302,12 @@ func GenerateMesonGitignore() string {
 builddir/
 build/
 .bin/
+compile_commands.json
 
 # IDE
 .idea/
 .vscode/
+.clangd
 *.swp
 *.swo
 *~
@@ -1364,6 +1463,8 @@ int main(int argc, char** argv) {
 func generateNanoBenchMain(projectName, safeName string) string {
 	return fmt.Sprintf(`#include <nanobench.h>
 #include <%s/%s.hpp>
+#include <cstdlib>
+#include <fstream>
 #include <iostream>
 
 int main() {
@@ -1371,6 +1472,12 @@ int main() {
     bench.run("version", [] {
         ankerl::nanobench::doNotOptimizeAway(%s::version());
     });
+
+    // cpx bench --save/--compare reads JSON results from CPX_BENCH_OUT
+    if (const char* out = std::getenv("CPX_BENCH_OUT")) {
+        std::ofstream file(out);
+        bench.render(ankerl::nanobench::templates::json(), file);
+    }
     return 0;
 }
 `, projectName, projectName, safeName)
@@ -1,10 +1,12 @@
 package templates
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGenerateModuleBazel(t *testing.T) {
@@ -733,3 +735,417 @@ func TestGenerateCpxCI(t *testing.T) {
 	assert.Contains(t, result, "targets:")
 	assert.Contains(t, result, "build:")
 }
+
+func TestGeneratePCHCMake(t *testing.T) {
+	cmake := GeneratePCHCMake("my-app")
+	assert.Contains(t, cmake, `option(CPX_PCH "Precompile common headers" ON)`)
+	assert.Contains(t, cmake, "target_precompile_headers(my-app PRIVATE")
+	assert.Contains(t, cmake, "<vector>")
+	assert.Contains(t, cmake, `"${PROJECT_SOURCE_DIR}/include/my-app/my-app.hpp"`)
+	assert.NotContains(t, cmake, "<string_view>") // not available before C++17
+}
+
+func TestGenerateCpxYaml(t *testing.T) {
+	assert.Contains(t, GenerateCpxYaml(true), "pch: true")
+	assert.Contains(t, GenerateCpxYaml(false), "pch: false")
+}
+
+func TestGenerateComputeSources(t *testing.T) {
+	assert.Nil(t, GenerateComputeSources("my-app", ComputeNone))
+
+	tests := []struct {
+		backend    string
+		sourceFile string
+		contains   string
+	}{
+		{ComputeOpenMP, "parallel.cpp", "#pragma omp parallel for"},
+		{ComputeCUDA, "parallel.cu", "__global__ void vector_add_kernel"},
+		{ComputeSYCL, "parallel.cpp", "#include <sycl/sycl.hpp>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			sources := GenerateComputeSources("my-app", tt.backend)
+			require.NotNil(t, sources)
+			assert.Equal(t, tt.sourceFile, sources.SourceFile)
+			assert.Contains(t, sources.Source, tt.contains)
+			assert.Contains(t, sources.Source, "#include <my-app/parallel.hpp>")
+			assert.Contains(t, sources.Source, "namespace my_app {")
+			assert.Contains(t, sources.Header, "#ifndef MY_APP_PARALLEL_HPP")
+			assert.Contains(t, sources.Header, "void vector_add(const float* a, const float* b, float* out, std::size_t n);")
+		})
+	}
+}
+
+func TestGenerateComputeBuildFiles(t *testing.T) {
+	assert.Empty(t, GenerateComputeCMake("app", ComputeNone, 17))
+	assert.Empty(t, GenerateMesonComputeSrc("app", ComputeNone, 17))
+
+	cmake := GenerateComputeCMake("app", ComputeOpenMP, 17)
+	assert.Contains(t, cmake, "find_package(OpenMP REQUIRED)")
+	assert.Contains(t, cmake, "target_link_libraries(app PUBLIC OpenMP::OpenMP_CXX)")
+
+	cmake = GenerateComputeCMake("app", ComputeCUDA, 17)
+	assert.Contains(t, cmake, "enable_language(CUDA)")
+	assert.Contains(t, cmake, "target_sources(app PRIVATE src/parallel.cu)")
+	assert.Contains(t, cmake, "CUDA::cudart")
+	assert.Contains(t, cmake, "CUDA_STANDARD 17")
+	assert.Contains(t, GenerateComputeCMake("app", ComputeCUDA, 20), "CUDA_STANDARD 20")
+	assert.Contains(t, GenerateComputeCMake("app", ComputeCUDA, 23), "CUDA_STANDARD 20", "nvcc supports up to C++20")
+
+	cmake = GenerateComputeCMake("app", ComputeSYCL, 17)
+	assert.Contains(t, cmake, "target_compile_options(app PUBLIC -fsycl)")
+
+	meson := GenerateMesonComputeSrc("my-app", ComputeCUDA, 17)
+	assert.Contains(t, meson, "add_languages('cuda', native : false)")
+	assert.Contains(t, meson, "my_app_compute = static_library('my_app_compute',")
+	assert.Contains(t, meson, "files('parallel.cu')")
+	assert.Contains(t, meson, "my_app_compute_dep = declare_dependency(")
+	assert.Contains(t, meson, "override_options : ['cuda_std=c++17'],")
+	assert.Contains(t, GenerateMesonComputeSrc("app", ComputeCUDA, 14), "override_options : ['cuda_std=c++14'],")
+
+	meson = GenerateMesonComputeSrc("app", ComputeSYCL, 17)
+	assert.Contains(t, meson, "cpp_args : ['-fsycl'],")
+	assert.Contains(t, meson, "link_args : ['-fsycl'],")
+
+	bazel := GenerateBazelComputeSrc("app", ComputeOpenMP)
+	assert.Contains(t, bazel, `name = "app_parallel"`)
+	assert.Contains(t, bazel, `copts = ["-fopenmp"]`)
+	assert.Empty(t, GenerateBazelComputeSrc("app", ComputeCUDA))
+}
+
+func TestParseComputeBackend(t *testing.T) {
+	assert.Equal(t, ComputeNone, ParseComputeBackend("project(app)\n"))
+	assert.Equal(t, ComputeOpenMP, ParseComputeBackend(GenerateVcpkgCMakeLists("app", 17, true, false, "none", false, "0.1.0")+GenerateComputeCMake("app", ComputeOpenMP, 17)))
+	assert.Equal(t, ComputeCUDA, ParseComputeBackend(GenerateMesonComputeSrc("app", ComputeCUDA, 17)))
+	assert.Equal(t, ComputeSYCL, ParseComputeBackend(GenerateComputeCMake("app", ComputeSYCL, 17)))
+}
+
+func TestGenerateDevcontainer(t *testing.T) {
+	opts := ToolchainOptions{
+		ProjectName:     "app",
+		BuildSystem:     "cmake",
+		Compiler:        "clang",
+		CompilerVersion: "18",
+		CMakeVersion:    ">=3.30",
+		Vcpkg:           true,
+		VcpkgBaseline:   "abc123",
+	}
+
+	dockerfile := GenerateDevcontainerDockerfile(opts)
+	assert.Contains(t, dockerfile, "FROM "+devcontainerBaseImage)
+	assert.Contains(t, dockerfile, "clang-18")
+	assert.Contains(t, dockerfile, "ENV CC=clang-18 CXX=clang++-18")
+	assert.Contains(t, dockerfile, `"cmake>=3.30"`)
+	assert.NotContains(t, dockerfile, "    cmake \\", "pinned cmake comes from PyPI")
+	assert.Contains(t, dockerfile, "git -C /opt/vcpkg checkout abc123")
+	assert.Contains(t, dockerfile, "ENV VCPKG_ROOT=/opt/vcpkg")
+	assert.Contains(t, dockerfile, "/usr/local/bin/cpx")
+
+	devcontainer := GenerateDevcontainerJSON(opts)
+	var parsed map[string]any
+	require.NoError(t, json.Unmarshal([]byte(devcontainer), &parsed))
+	assert.Equal(t, "app", parsed["name"])
+	assert.Contains(t, devcontainer, "ms-vscode.cmake-tools")
+	assert.Contains(t, devcontainer, "vadimcn.vscode-lldb")
+	assert.Contains(t, devcontainer, `"cpx build && cpx compdb"`)
+	assert.Contains(t, devcontainer, "cpx-vcpkg-cache")
+
+	bazel := ToolchainOptions{ProjectName: "lib", BuildSystem: "bazel", Compiler: "gcc"}
+	dockerfile = GenerateDevcontainerDockerfile(bazel)
+	assert.Contains(t, dockerfile, "bazelisk")
+	assert.Contains(t, dockerfile, "ENV CC=gcc CXX=g++")
+	assert.NotContains(t, dockerfile, "vcpkg")
+	assert.NotContains(t, dockerfile, "cmake")
+	assert.Contains(t, GenerateDevcontainerJSON(bazel), "BazelBuild.vscode-bazel")
+
+	meson := ToolchainOptions{ProjectName: "app", BuildSystem: "meson", Compiler: "gcc", CompilerVersion: "14"}
+	dockerfile = GenerateDevcontainerDockerfile(meson)
+	assert.Contains(t, dockerfile, "g++-14")
+	assert.Contains(t, dockerfile, "meson")
+}
+
+func TestGenerateFlakeNix(t *testing.T) {
+	opts := ToolchainOptions{
+		ProjectName:     "my_app",
+		ProjectVersion:  "1.0.0",
+		BuildSystem:     "cmake",
+		Compiler:        "gcc",
+		CompilerVersion: "13",
+		Vcpkg:           true,
+		Dependencies:    []string{"fmt", "boost-asio", "boost-json", "nlohmann-json", "unknown-port"},
+	}
+	flake := GenerateFlakeNix(opts)
+	assert.Contains(t, flake, "stdenv = pkgs.gcc13Stdenv;")
+	assert.Contains(t, flake, "buildInputs = with pkgs; [ fmt boost nlohmann_json ];")
+	assert.Contains(t, flake, `no nixpkgs equivalent known for vcpkg port "unknown-port"`)
+	assert.Contains(t, flake, "[ cmake ninja clang-tools vcpkg ]")
+	assert.Contains(t, flake, "export VCPKG_ROOT=${pkgs.vcpkg}/share/vcpkg")
+	assert.Contains(t, flake, `pname = "my_app";`)
+	assert.Contains(t, flake, `version = "1.0.0";`)
+
+	clang := GenerateFlakeNix(ToolchainOptions{ProjectName: "app", BuildSystem: "meson", Compiler: "clang", CompilerVersion: "18"})
+	assert.Contains(t, clang, "stdenv = pkgs.llvmPackages_18.stdenv;")
+	assert.Contains(t, clang, "pkgs.llvmPackages_18.lldb")
+	assert.Contains(t, clang, "-Denable_tests=false")
+	assert.NotContains(t, clang, "VCPKG_ROOT")
+
+	bazel := GenerateFlakeNix(ToolchainOptions{ProjectName: "app", BuildSystem: "bazel", Compiler: "gcc"})
+	assert.Contains(t, bazel, "bazelisk")
+	assert.NotContains(t, bazel, "packages.default")
+}
+
+func TestGenerateDeployDockerfile(t *testing.T) {
+	opts := DeployOptions{
+		ToolchainOptions: ToolchainOptions{ProjectName: "MyApp", BuildSystem: "cmake", Vcpkg: true},
+		Binary:           "MyApp",
+		Target:           "linux-amd64",
+	}
+	dockerfile := GenerateDeployDockerfile(opts)
+	assert.Contains(t, dockerfile, "docker build -t myapp .")
+	assert.Contains(t, dockerfile, "ARG BUILD_IMAGE=cpx-linux-amd64")
+	assert.Contains(t, dockerfile, "FROM ${BUILD_IMAGE} AS build")
+	assert.Contains(t, dockerfile, "RUN vcpkg install --x-install-root=/vcpkg_installed")
+	assert.Contains(t, dockerfile, "install -D /build/MyApp /out/MyApp")
+	assert.Contains(t, dockerfile, "FROM --platform=linux/amd64 ubuntu:22.04")
+	assert.Contains(t, dockerfile, `ENTRYPOINT ["/usr/local/bin/MyApp"]`)
+
+	opts.BuildSystem, opts.Vcpkg, opts.Target = "meson", false, "linux-arm64-musl"
+	dockerfile = GenerateDeployDockerfile(opts)
+	assert.Contains(t, dockerfile, "meson compile -C /build")
+	assert.Contains(t, dockerfile, "FROM --platform=linux/arm64 alpine:3.20")
+	assert.Contains(t, dockerfile, "apk add --no-cache libstdc++")
+	assert.NotContains(t, dockerfile, "vcpkg")
+
+	opts.BuildSystem, opts.Target = "bazel", "linux-amd64"
+	assert.Contains(t, GenerateDeployDockerfile(opts), "bazel build -c opt //src:MyApp")
+}
+
+func TestGenerateGrpcSources(t *testing.T) {
+	sources := GenerateGrpcSources("my-svc", "catch2")
+	assert.Contains(t, sources.Proto, "package my_svc;")
+	assert.Contains(t, sources.Proto, "service Greeter")
+	assert.Contains(t, sources.Header, `#include "my-svc.grpc.pb.h"`)
+	assert.Contains(t, sources.Header, "class GreeterService final : public Greeter::Service")
+	assert.Contains(t, sources.Main, "my_svc::GreeterService service;")
+	assert.Contains(t, sources.Test, "catch2/catch_test_macros.hpp")
+	assert.Empty(t, GenerateGrpcSources("my-svc", "none").Test)
+
+	cmake := GenerateGrpcCMake("my-svc")
+	assert.Contains(t, cmake, "find_package(gRPC CONFIG REQUIRED)")
+	assert.Contains(t, cmake, "protobuf_generate(TARGET my-svc_proto LANGUAGE grpc")
+	assert.Contains(t, cmake, "target_link_libraries(my-svc PRIVATE my-svc_proto gRPC::grpc++_reflection)")
+	assert.Contains(t, GenerateGrpcTestCMake("my-svc"), "target_link_libraries(my-svc_tests PRIVATE my-svc_proto)")
+}
+
+func TestGenerateCLIAppSources(t *testing.T) {
+	sources := GenerateCLIAppSources("my-tool", "googletest")
+	assert.Contains(t, sources.Header, "#include <CLI/CLI.hpp>")
+	assert.Contains(t, sources.Header, "void setup_cli(CLI::App& app, Options& options);")
+	assert.Contains(t, sources.Source, "MY_TOOL_VERSION")
+	assert.Contains(t, sources.Main, "CLI11_PARSE(app, argc, argv);")
+	assert.Contains(t, sources.Test, "EXPECT_THROW(parse(\"greet --count 0\"), CLI::ValidationError);")
+	assert.Contains(t, GenerateCLIAppSources("my-tool", "doctest").Test, "DOCTEST_CONFIG_IMPLEMENT_WITH_MAIN")
+	assert.Empty(t, GenerateCLIAppSources("my-tool", "none").Test)
+
+	assert.Contains(t, GenerateCLIAppCMake("my-tool", true), "find_package(CLI11 CONFIG REQUIRED)")
+	assert.Contains(t, GenerateCLIAppCMake("my-tool", false), "GIT_TAG v"+cli11Version)
+	assert.Contains(t, GenerateCLIAppTestCMake("my-tool", "catch2"), "target_link_libraries(my-tool_cli_tests PRIVATE my-tool_cli Catch2::Catch2WithMain)")
+
+	meson := GenerateCLIAppMesonSrc("my-tool")
+	assert.Contains(t, meson, "cli11_dep = dependency('CLI11'")
+	assert.Contains(t, meson, "link_with : [my_tool_cli, my_tool_lib],")
+	assert.Contains(t, GenerateCLIAppMesonTests("my-tool", "googletest"), "dependencies : [cli11_dep, gtest_dep]")
+
+	assert.Contains(t, GenerateCLIAppBazelModule(), `bazel_dep(name = "cli11"`)
+	assert.Contains(t, GenerateCLIAppBazelSrc("my-tool"), `"@cli11",`)
+	assert.Contains(t, GenerateCLIAppBazelTests("my-tool", "catch2"), `"@catch2//:catch2_main",`)
+}
+
+func TestGenerateEmbeddedSources(t *testing.T) {
+	sources := GenerateEmbeddedSources("blinky")
+	assert.Contains(t, sources.Toolchain, "set(CMAKE_SYSTEM_NAME Generic)")
+	assert.Contains(t, sources.Toolchain, "--specs=nosys.specs")
+	assert.Contains(t, sources.Toolchain, "set(CMAKE_TRY_COMPILE_TARGET_TYPE STATIC_LIBRARY)")
+	assert.Contains(t, sources.LinkerScript, "FLASH (rx)")
+	assert.Contains(t, sources.Main, "#if defined(__arm__)")
+	assert.NotContains(t, sources.Source, "<iostream>")
+
+	cmake := GenerateEmbeddedCMake("blinky", true, false)
+	assert.Contains(t, cmake, "-T${LINKER_SCRIPT}")
+	assert.Contains(t, cmake, "${CMAKE_OBJCOPY} -O binary $<TARGET_FILE:blinky>")
+	assert.Contains(t, cmake, "else()\n    # Host builds only")
+	assert.Contains(t, cmake, "add_subdirectory(tests)")
+	assert.NotContains(t, cmake, "add_subdirectory(bench)")
+	assert.NotContains(t, GenerateEmbeddedCMake("blinky", false, false), "else()")
+}
+
+func TestGenerateClangd(t *testing.T) {
+	clangd := GenerateClangd(20, []string{"/work/app/include"}, nil)
+	assert.Contains(t, clangd, "CompilationDatabase: .")
+	assert.Contains(t, clangd, "- -std=c++20")
+	assert.Contains(t, clangd, "- -I/work/app/include")
+	assert.NotContains(t, clangd, "Suppress")
+
+	clangd = GenerateClangd(17, nil, []string{"unused-includes", "-Wunused-parameter"})
+	assert.Contains(t, clangd, "Suppress:\n    - unused-includes\n    - -Wunused-parameter\n")
+}
+
+func TestGenerateConvertedProject(t *testing.T) {
+	project := &ConvertedProject{
+		Name: "my-app", Version: "1.0.0", CppStandard: 20, IsExe: true,
+		Sources:       []string{"my-app.cpp", "util/io.cpp"},
+		TestFramework: "googletest", TestSources: []string{"test_main.cpp"},
+		BenchFramework: "google-benchmark", BenchSources: []string{"bench_main.cpp"},
+		Dependencies: []string{"fmt", "openssl", "acme-log"},
+	}
+
+	files, unmapped := GenerateConvertedCMake(project)
+	assert.Equal(t, []string{"acme-log"}, unmapped)
+	cmake := files["CMakeLists.txt"]
+	assert.Contains(t, cmake, "project(my-app VERSION 1.0.0 LANGUAGES CXX)")
+	assert.Contains(t, cmake, "find_package(OpenSSL REQUIRED)")
+	assert.Contains(t, cmake, "add_library(my-app_lib STATIC\n    src/my-app.cpp\n    src/util/io.cpp\n)")
+	assert.Contains(t, cmake, "target_link_libraries(my-app_lib PUBLIC fmt::fmt OpenSSL::SSL OpenSSL::Crypto)")
+	assert.Contains(t, cmake, "target_link_libraries(my-app PRIVATE my-app_lib)")
+	assert.Contains(t, files["tests/CMakeLists.txt"], "gtest_discover_tests(my-app_tests)")
+	assert.Contains(t, files["bench/CMakeLists.txt"], "target_link_libraries(my-app_bench PRIVATE my-app_lib)")
+
+	files, unmapped = GenerateConvertedBazel(project, map[string]string{"fmt": "11.0.2"})
+	assert.Equal(t, []string{"openssl", "acme-log"}, unmapped)
+	assert.Contains(t, files["MODULE.bazel"], `bazel_dep(name = "fmt", version = "11.0.2")`)
+	assert.Contains(t, files["MODULE.bazel"], `bazel_dep(name = "googletest"`)
+	assert.Contains(t, files["src/BUILD.bazel"], "\"my-app.cpp\",\n        \"util/io.cpp\",\n")
+	assert.Contains(t, files["src/BUILD.bazel"], `"@fmt",`)
+	assert.Contains(t, files["tests/BUILD.bazel"], `"@googletest//:gtest_main",`)
+
+	files, wraps, unmapped := GenerateConvertedMeson(project)
+	assert.Equal(t, []string{"acme-log"}, unmapped)
+	assert.Equal(t, []string{"fmt", "google-benchmark", "gtest", "openssl"}, wraps)
+	assert.Contains(t, files["meson.build"], "fmt_dep = dependency('fmt')\nopenssl_dep = dependency('openssl')\nproject_deps = [fmt_dep, openssl_dep]")
+	assert.Contains(t, files["meson.build"], "subdir('tests')\nsubdir('bench')\n")
+	assert.Contains(t, files["src/meson.build"], "my_app_lib = static_library('my-app_lib',")
+	assert.Contains(t, files["tests/meson.build"], "dependencies : [my_app_dep, gtest_dep]")
+
+	// Header-only libraries
+	project = &ConvertedProject{Name: "hdr", Version: "0.1.0", CppStandard: 17}
+	files, _ = GenerateConvertedCMake(project)
+	assert.Contains(t, files["CMakeLists.txt"], "add_library(hdr INTERFACE)")
+	assert.NotContains(t, files["CMakeLists.txt"], "add_subdirectory")
+	files, _, _ = GenerateConvertedMeson(project)
+	assert.NotContains(t, files["src/meson.build"], "static_library")
+	assert.NotContains(t, files, "tests/meson.build")
+
+	port, ok := PortForBCRModule("googletest")
+	assert.True(t, ok)
+	assert.Equal(t, "gtest", port)
+	port, ok = PortForWrap("google-benchmark")
+	assert.True(t, ok)
+	assert.Equal(t, "benchmark", port)
+}
+
+func TestParseCrossTarget(t *testing.T) {
+	tests := []struct {
+		triple  string
+		want    CrossTarget
+		triplet string
+	}{
+		{"aarch64-linux-gnu", CrossTarget{"aarch64-linux-gnu", "Linux", "aarch64", "aarch64", "little"}, "arm64-linux"},
+		{"arm-linux-gnueabihf", CrossTarget{"arm-linux-gnueabihf", "Linux", "arm", "arm", "little"}, "arm-linux"},
+		{"x86_64-w64-mingw32", CrossTarget{"x86_64-w64-mingw32", "Windows", "x86_64", "x86_64", "little"}, "x64-mingw-static"},
+		{"riscv64-unknown-linux-gnu", CrossTarget{"riscv64-unknown-linux-gnu", "Linux", "riscv64", "riscv64", "little"}, "riscv64-linux"},
+		{"arm-none-eabi", CrossTarget{"arm-none-eabi", "Generic", "arm", "arm", "little"}, ""},
+		{"powerpc64-linux-gnu", CrossTarget{"powerpc64-linux-gnu", "Linux", "powerpc64", "ppc64", "big"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.triple, func(t *testing.T) {
+			target, err := ParseCrossTarget(tt.triple)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, target)
+			assert.Equal(t, tt.triplet, target.VcpkgTriplet())
+		})
+	}
+
+	for _, triple := range []string{"aarch64", "sparc-linux-gnu", "aarch64-unknown-plan9"} {
+		_, err := ParseCrossTarget(triple)
+		assert.Error(t, err, triple)
+	}
+}
+
+func TestGenerateCrossToolchainFile(t *testing.T) {
+	target, err := ParseCrossTarget("aarch64-linux-gnu")
+	require.NoError(t, err)
+
+	content := GenerateCrossToolchainFile(target, "gcc", "")
+	assert.Contains(t, content, "set(CMAKE_SYSTEM_NAME Linux)")
+	assert.Contains(t, content, "set(CMAKE_SYSTEM_PROCESSOR aarch64)")
+	assert.Contains(t, content, "set(CMAKE_CXX_COMPILER aarch64-linux-gnu-g++)")
+	assert.Contains(t, content, "set(CMAKE_FIND_ROOT_PATH_MODE_PROGRAM NEVER)")
+	assert.NotContains(t, content, "CMAKE_SYSROOT")
+	assert.NotContains(t, content, "COMPILER_TARGET")
+
+	content = GenerateCrossToolchainFile(target, "clang", "/opt/sysroot")
+	assert.Contains(t, content, "set(CMAKE_CXX_COMPILER clang++)")
+	assert.Contains(t, content, "set(CMAKE_CXX_COMPILER_TARGET aarch64-linux-gnu)")
+	assert.Contains(t, content, "set(CMAKE_SYSROOT /opt/sysroot)")
+
+	bare, err := ParseCrossTarget("arm-none-eabi")
+	require.NoError(t, err)
+	assert.Contains(t, GenerateCrossToolchainFile(bare, "gcc", ""), "set(CMAKE_TRY_COMPILE_TARGET_TYPE STATIC_LIBRARY)")
+}
+
+func TestGenerateMesonCrossFile(t *testing.T) {
+	target, err := ParseCrossTarget("arm-linux-gnueabihf")
+	require.NoError(t, err)
+
+	content := GenerateMesonCrossFile(target, "gcc", "")
+	assert.Contains(t, content, "cpp = 'arm-linux-gnueabihf-g++'")
+	assert.Contains(t, content, "ar = 'arm-linux-gnueabihf-ar'")
+	assert.Contains(t, content, "system = 'linux'\ncpu_family = 'arm'\ncpu = 'arm'\nendian = 'little'")
+	assert.NotContains(t, content, "[built-in options]")
+
+	content = GenerateMesonCrossFile(target, "clang", "/opt/rpi")
+	assert.Contains(t, content, "ar = 'llvm-ar'")
+	assert.Contains(t, content, "sys_root = '/opt/rpi'")
+	assert.Contains(t, content, "cpp_args = ['--target=arm-linux-gnueabihf', '--sysroot=/opt/rpi']")
+}
+
+func TestGenerateDocConfigs(t *testing.T) {
+	assert.Contains(t, GenerateSphinxDoxyfile("demo", "1.0.0"), "GENERATE_XML           = YES")
+	assert.Contains(t, GenerateSphinxConf("demo", "1.0.0"), `version = release = "1.0.0"`)
+	assert.Equal(t, "demo documentation\n==================\n\nAPI reference\n-------------\n\n.. doxygenindex::\n", GenerateSphinxIndex("demo"))
+
+	mkdocs := GenerateMkDocsConfig("demo", "1.0.0")
+	assert.Contains(t, mkdocs, "  - mkdoxy:")
+	assert.Contains(t, mkdocs, "site_dir: docs/site")
+	assert.Contains(t, GenerateStandardeseConfig(), "prefix=docs/standardese/")
+}
+
+func TestGenerateDoxyfile(t *testing.T) {
+	doxyfile := GenerateDoxyfile("demo", "1.0.0", DoxygenOptions{})
+	assert.True(t, strings.HasPrefix(doxyfile, ManagedDocHeader))
+	assert.Contains(t, doxyfile, `PROJECT_NAME           = "demo"`)
+	assert.Contains(t, doxyfile, "INPUT                  = src include\n")
+	assert.Contains(t, doxyfile, "HAVE_DOT               = NO\n")
+	assert.NotContains(t, doxyfile, "EXCLUDE_PATTERNS")
+	assert.NotContains(t, doxyfile, "HTML_EXTRA_STYLESHEET")
+
+	doxyfile = GenerateDoxyfile("demo", "1.0.0", DoxygenOptions{
+		Input:      []string{"include", "docs/pages"},
+		Exclude:    []string{"*/detail/*", "third party/*"},
+		ThemeDir:   ".cache/doxygen-awesome-css",
+		Dot:        true,
+		CallGraphs: true,
+	})
+	assert.Contains(t, doxyfile, "INPUT                  = include docs/pages\n")
+	assert.Contains(t, doxyfile, "EXCLUDE_PATTERNS       = */detail/* \"third party/*\"\n")
+	assert.Contains(t, doxyfile, "HTML_EXTRA_STYLESHEET  = .cache/doxygen-awesome-css/doxygen-awesome.css .cache/doxygen-awesome-css/doxygen-awesome-sidebar-only.css\n")
+	assert.Contains(t, doxyfile, "GENERATE_TREEVIEW      = YES\n")
+	assert.Contains(t, doxyfile, "HAVE_DOT               = YES\n")
+	assert.Contains(t, doxyfile, "CALL_GRAPH             = YES\n")
+	assert.Contains(t, doxyfile, "DOT_IMAGE_FORMAT       = svg\n")
+
+	doxyfile = GenerateDoxyfile("demo", "1.0.0", DoxygenOptions{Dot: true, DotFormat: "png"})
+	assert.Contains(t, doxyfile, "CALL_GRAPH             = NO\n")
+	assert.Contains(t, doxyfile, "INTERACTIVE_SVG        = NO\n")
+}
@@ -0,0 +1,565 @@
+// Package toolchain downloads and manages toolchain binaries (CMake, Ninja,
+// LLVM and GCC) under the cpx config directory, and puts the installed ones
+// on PATH for builds so a new machine can be bootstrapped without a system
+// package manager.
+package toolchain
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/internal/pkg/tools"
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// Exec hook, replaced in tests
+var execCommand = exec.Command
+
+// Tools are the toolchains cpx can install
+var Tools = []string{"cmake", "ninja", "llvm", "gcc"}
+
+// commands are the commands each toolchain provides, as named in
+// .cpx-tools.yaml
+var commands = map[string][]string{
+	"cmake": {"cmake", "ctest", "cpack"},
+	"ninja": {"ninja"},
+	"llvm":  {"llvm", "clang", "clang++", "clang-tidy", "clang-format"},
+	"gcc":   {"gcc", "g++"},
+}
+
+// compilers maps the compiler toolchains to their C and C++ compilers
+var compilers = map[string][2]string{
+	"llvm": {"clang", "clang++"},
+	"gcc":  {"gcc", "g++"},
+}
+
+// Toolchain is an installed version of a tool
+type Toolchain struct {
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+	Path    string `json:"path"`
+}
+
+// BinDir returns the directory of the toolchain's executables
+func (t Toolchain) BinDir() string {
+	if t.Tool == "cmake" {
+		// The macOS archive is an application bundle
+		if bundle := filepath.Join(t.Path, "CMake.app", "Contents", "bin"); isDir(bundle) {
+			return bundle
+		}
+	}
+	return filepath.Join(t.Path, "bin")
+}
+
+// Dir returns the directory toolchains are installed to,
+// ~/.config/cpx/toolchains
+func Dir() (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "toolchains"), nil
+}
+
+// Valid reports whether cpx can install tool
+func Valid(tool string) bool {
+	_, ok := commands[tool]
+	return ok
+}
+
+// Source is where a toolchain version is downloaded from
+type Source struct {
+	URL string
+	// Into is the directory of the toolchain the archive is extracted
+	// into, e.g. "bin" for archives holding only an executable
+	Into string
+	// Checksums is the URL of the sha256sum-style file listing the
+	// archive's SHA-256
+	Checksums string
+	// Release is the GitHub API URL of the release, whose asset digests
+	// verify archives that publish no checksum file
+	Release string
+}
+
+// NormalizeVersion returns the version a toolchain is installed as. GCC
+// builds come from xPack, whose releases carry a build number
+// ("14.2.0" installs "14.2.0-1").
+func NormalizeVersion(tool, version string) string {
+	version = strings.TrimPrefix(version, "v")
+	if tool == "gcc" && !strings.Contains(version, "-") {
+		version += "-1"
+	}
+	return version
+}
+
+// versionRe matches the versions cpx installs. A version names a single
+// directory under the toolchain's, so it can't be "..", "." or hold a
+// separator.
+var versionRe = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z.+-]*$`)
+
+// checkVersion rejects versions that aren't a single path element
+func checkVersion(tool, version string) error {
+	if !versionRe.MatchString(NormalizeVersion(tool, version)) {
+		return exitcode.Errorf(exitcode.Usage, "invalid %s version %q\n  hint: use a release number such as 3.30.2", tool, version)
+	}
+	return nil
+}
+
+// SourceFor returns where a version of tool is downloaded from for a
+// platform (GOOS and GOARCH values)
+func SourceFor(tool, version, goos, goarch string) (Source, error) {
+	if err := checkVersion(tool, version); err != nil {
+		return Source{}, err
+	}
+	version = NormalizeVersion(tool, version)
+	platform := goos + "/" + goarch
+	pick := func(names map[string]string) (string, error) {
+		if name, ok := names[platform]; ok {
+			return name, nil
+		}
+		return "", exitcode.Errorf(exitcode.ToolchainMissing, "cpx can't install %s on %s\n  hint: install it with your system package manager", tool, platform)
+	}
+
+	switch tool {
+	case "cmake":
+		name, err := pick(map[string]string{
+			"linux/amd64":   "linux-x86_64.tar.gz",
+			"linux/arm64":   "linux-aarch64.tar.gz",
+			"darwin/amd64":  "macos-universal.tar.gz",
+			"darwin/arm64":  "macos-universal.tar.gz",
+			"windows/amd64": "windows-x86_64.zip",
+			"windows/arm64": "windows-arm64.zip",
+		})
+		base := fmt.Sprintf("https://github.com/Kitware/CMake/releases/download/v%[1]s/cmake-%[1]s", version)
+		return Source{URL: base + "-" + name, Checksums: base + "-SHA-256.txt"}, err
+	case "ninja":
+		name, err := pick(map[string]string{
+			"linux/amd64":   "ninja-linux.zip",
+			"linux/arm64":   "ninja-linux-aarch64.zip",
+			"darwin/amd64":  "ninja-mac.zip",
+			"darwin/arm64":  "ninja-mac.zip",
+			"windows/amd64": "ninja-win.zip",
+			"windows/arm64": "ninja-winarm64.zip",
+		})
+		return Source{
+			URL:     fmt.Sprintf("https://github.com/ninja-build/ninja/releases/download/v%s/%s", version, name),
+			Into:    "bin",
+			Release: "https://api.github.com/repos/ninja-build/ninja/releases/tags/v" + version,
+		}, err
+	case "llvm":
+		// LLVM publishes these archives from 18.1.8 on
+		name, err := pick(map[string]string{
+			"linux/amd64":   "Linux-X64",
+			"linux/arm64":   "Linux-ARM64",
+			"darwin/arm64":  "macOS-ARM64",
+			"windows/amd64": "Windows-X64",
+		})
+		return Source{
+			URL:     fmt.Sprintf("https://github.com/llvm/llvm-project/releases/download/llvmorg-%[1]s/LLVM-%[1]s-%s.tar.xz", version, name),
+			Release: "https://api.github.com/repos/llvm/llvm-project/releases/tags/llvmorg-" + version,
+		}, err
+	case "gcc":
+		name, err := pick(map[string]string{
+			"linux/amd64":   "linux-x64.tar.gz",
+			"linux/arm64":   "linux-arm64.tar.gz",
+			"darwin/amd64":  "darwin-x64.tar.gz",
+			"darwin/arm64":  "darwin-arm64.tar.gz",
+			"windows/amd64": "win32-x64.zip",
+		})
+		url := fmt.Sprintf("https://github.com/xpack-dev-tools/gcc-xpack/releases/download/v%[1]s/xpack-gcc-%[1]s-%s", version, name)
+		return Source{URL: url, Checksums: url + ".sha"}, err
+	}
+	return Source{}, exitcode.Errorf(exitcode.Usage, "unknown toolchain %q\n  hint: cpx installs %s", tool, strings.Join(Tools, ", "))
+}
+
+// Install downloads version of tool for the running platform and installs
+// it, replacing an existing installation of the same version. The archive
+// is verified against checksum (a hex SHA-256) when given, otherwise
+// against the checksum its project publishes.
+func Install(tool, version, checksum string) (Toolchain, error) {
+	source, err := SourceFor(tool, version, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return Toolchain{}, err
+	}
+	if err := offline.Required("installing " + tool + " " + version); err != nil {
+		return Toolchain{}, err
+	}
+	dir, err := Dir()
+	if err != nil {
+		return Toolchain{}, err
+	}
+	return install(dir, tool, NormalizeVersion(tool, version), source, checksum)
+}
+
+func install(dir, tool, version string, source Source, checksum string) (Toolchain, error) {
+	if checksum == "" {
+		var err error
+		if checksum, err = publishedChecksum(source); err != nil {
+			return Toolchain{}, err
+		}
+	}
+
+	toolDir := filepath.Join(dir, tool)
+	if err := os.MkdirAll(toolDir, 0755); err != nil {
+		return Toolchain{}, fmt.Errorf("failed to create %s: %w", toolDir, err)
+	}
+
+	archive, actual, err := download(source.URL, toolDir)
+	if err != nil {
+		return Toolchain{}, err
+	}
+	defer os.Remove(archive)
+	if !strings.EqualFold(actual, checksum) {
+		return Toolchain{}, fmt.Errorf("checksum mismatch for %s: expected %s, got %s\n  hint: the download may be corrupted or tampered with; try again later", path.Base(source.URL), checksum, actual)
+	}
+
+	staging, err := os.MkdirTemp(toolDir, ".install-")
+	if err != nil {
+		return Toolchain{}, err
+	}
+	defer os.RemoveAll(staging)
+	if err := extract(archive, source.URL, filepath.Join(staging, source.Into)); err != nil {
+		return Toolchain{}, fmt.Errorf("failed to extract %s: %w", path.Base(source.URL), err)
+	}
+
+	// Archives usually hold a single top-level directory
+	root := staging
+	if source.Into == "" {
+		if entries, err := os.ReadDir(staging); err == nil && len(entries) == 1 && entries[0].IsDir() {
+			root = filepath.Join(staging, entries[0].Name())
+		}
+	}
+
+	tc := Toolchain{Tool: tool, Version: version, Path: filepath.Join(toolDir, version)}
+	if err := os.RemoveAll(tc.Path); err != nil {
+		return Toolchain{}, err
+	}
+	if err := os.Rename(root, tc.Path); err != nil {
+		return Toolchain{}, fmt.Errorf("failed to install %s %s: %w", tool, version, err)
+	}
+	return tc, nil
+}
+
+// publishedChecksum returns the SHA-256 the project publishes for the
+// archive of source
+func publishedChecksum(source Source) (string, error) {
+	name := path.Base(source.URL)
+	unverified := func(reason string) error {
+		return fmt.Errorf("can't verify %s: %s\n  hint: pass the archive's SHA-256 with --sha256 to install it", name, reason)
+	}
+
+	switch {
+	case source.Checksums != "":
+		resp, err := get(source.Checksums)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to download %s: %w", source.Checksums, err)
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			// sha256sum marks binary mode with a * before the name
+			if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+				return fields[0], nil
+			}
+		}
+		return "", unverified(path.Base(source.Checksums) + " lists no checksum for it")
+	case source.Release != "":
+		resp, err := get(source.Release)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		var release struct {
+			Assets []struct {
+				Name   string `json:"name"`
+				Digest string `json:"digest"`
+			} `json:"assets"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", source.Release, err)
+		}
+		for _, asset := range release.Assets {
+			if asset.Name == name && strings.HasPrefix(asset.Digest, "sha256:") {
+				return strings.TrimPrefix(asset.Digest, "sha256:"), nil
+			}
+		}
+		return "", unverified("the release publishes no checksum for it")
+	}
+	return "", unverified("the project publishes no checksums")
+}
+
+// get requests url, failing unless it responds with 200 OK
+func get(url string) (*http.Response, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, exitcode.Errorf(exitcode.ToolchainMissing, "%s not found\n  hint: check the version; see the project's releases for the available ones", url)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s (status %d)", url, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// download fetches url into a temporary file in dir and returns its path
+// and SHA-256
+func download(url, dir string) (string, string, error) {
+	resp, err := get(url)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	f, err := os.CreateTemp(dir, ".download-")
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, hash), resp.Body); err != nil {
+		os.Remove(f.Name())
+		return "", "", fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return f.Name(), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// extract unpacks a .zip, .tar.gz or .tar.xz archive into dest. Tarballs are
+// extracted with the system tar, which handles xz and the links in them.
+func extract(archive, name, dest string) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	if strings.HasSuffix(name, ".zip") {
+		return extractZip(archive, dest)
+	}
+	out, err := execCommand("tar", "-xf", archive, "-C", dest).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w\n%s", err, out)
+	}
+	return nil
+}
+
+func extractZip(archive, dest string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		target := filepath.Join(dest, f.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path %q in archive", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := extractZipFile(f, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(f *zip.File, target string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	mode := f.Mode().Perm()
+	if filepath.Base(filepath.Dir(target)) == "bin" {
+		// Zips made on Windows don't record the executable bit
+		mode |= 0755
+	}
+	dst, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// Installed returns the installed toolchains by tool, newest version first
+func Installed() ([]Toolchain, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	var installed []Toolchain
+	for _, tool := range Tools {
+		entries, err := os.ReadDir(filepath.Join(dir, tool))
+		if err != nil {
+			continue
+		}
+		var versions []Toolchain
+		for _, entry := range entries {
+			if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+				versions = append(versions, Toolchain{Tool: tool, Version: entry.Name(), Path: filepath.Join(dir, tool, entry.Name())})
+			}
+		}
+		sort.SliceStable(versions, func(i, j int) bool {
+			return tools.Compare(baseVersion(versions[i].Version), baseVersion(versions[j].Version)) > 0
+		})
+		installed = append(installed, versions...)
+	}
+	return installed, nil
+}
+
+// Remove uninstalls a version of tool
+func Remove(tool, version string) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(tool, version); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, tool, NormalizeVersion(tool, version))
+	if !Valid(tool) || !isDir(path) {
+		return exitcode.Errorf(exitcode.Usage, "%s %s is not installed\n  hint: run 'cpx toolchain list'", tool, version)
+	}
+	return os.RemoveAll(path)
+}
+
+// Select picks the toolchain of each tool a build uses: the newest installed
+// version satisfying the project's constraint on any of the tool's commands
+// in .cpx-tools.yaml, or the newest version if the tool is not constrained
+func Select(installed []Toolchain, manifest *config.ToolsConfig) []Toolchain {
+	var selected []Toolchain
+	for _, tool := range Tools {
+		constraint := constraintOf(manifest, tool)
+		for _, tc := range installed {
+			if tc.Tool != tool {
+				continue
+			}
+			if constraint != "" {
+				if ok, err := tools.Satisfies(baseVersion(tc.Version), constraint); err != nil || !ok {
+					continue
+				}
+			}
+			selected = append(selected, tc)
+			break
+		}
+	}
+	return selected
+}
+
+// constraintOf returns the constraint of the manifest on any of the commands
+// of tool, or ""
+func constraintOf(manifest *config.ToolsConfig, tool string) string {
+	if manifest == nil {
+		return ""
+	}
+	for _, command := range commands[tool] {
+		if c, ok := manifest.Tools[command]; ok {
+			return c
+		}
+	}
+	return ""
+}
+
+// Activate puts the selected toolchains first on PATH. Unless CC or CXX is
+// set, it also makes CMake and Meson compile with a compiler toolchain: the
+// one .cpx-tools.yaml constrains, or the only one selected.
+func Activate(selected []Toolchain, manifest *config.ToolsConfig) {
+	if len(selected) == 0 {
+		return
+	}
+	dirs := make([]string, 0, len(selected)+1)
+	for _, tc := range selected {
+		dirs = append(dirs, tc.BinDir())
+	}
+	dirs = append(dirs, os.Getenv("PATH"))
+	os.Setenv("PATH", strings.Join(dirs, string(os.PathListSeparator)))
+
+	if os.Getenv("CC") != "" || os.Getenv("CXX") != "" {
+		return
+	}
+	var candidates []Toolchain
+	for _, tc := range selected {
+		if _, ok := compilers[tc.Tool]; !ok {
+			continue
+		}
+		if constraintOf(manifest, tc.Tool) != "" {
+			candidates = []Toolchain{tc}
+			break
+		}
+		candidates = append(candidates, tc)
+	}
+	if len(candidates) != 1 {
+		return
+	}
+	tc := candidates[0]
+	names := compilers[tc.Tool]
+	os.Setenv("CC", filepath.Join(tc.BinDir(), names[0]))
+	os.Setenv("CXX", filepath.Join(tc.BinDir(), names[1]))
+}
+
+// FindCompiler returns the C and C++ compilers of the newest installed
+// version of a compiler toolchain (llvm or gcc) matching version ("17",
+// "13.3"; "" matches any version)
+func FindCompiler(tool, version string) (cc, cxx string, ok bool) {
+	names, isCompiler := compilers[tool]
+	if !isCompiler {
+		return "", "", false
+	}
+	installed, err := Installed()
+	if err != nil {
+		return "", "", false
+	}
+	for _, tc := range installed {
+		if tc.Tool != tool {
+			continue
+		}
+		if version != "" {
+			if match, err := tools.Satisfies(baseVersion(tc.Version), version); err != nil || !match {
+				continue
+			}
+		}
+		return filepath.Join(tc.BinDir(), names[0]), filepath.Join(tc.BinDir(), names[1]), true
+	}
+	return "", "", false
+}
+
+// baseVersion strips the build number of xPack versions ("14.2.0-1")
+func baseVersion(version string) string {
+	base, _, _ := strings.Cut(version, "-")
+	return base
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,235 @@
+package toolchain
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/offline"
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceFor(t *testing.T) {
+	tests := []struct {
+		tool, version, goos, goarch string
+		want                        Source
+	}{
+		{"cmake", "3.30.2", "linux", "amd64", Source{
+			URL:       "https://github.com/Kitware/CMake/releases/download/v3.30.2/cmake-3.30.2-linux-x86_64.tar.gz",
+			Checksums: "https://github.com/Kitware/CMake/releases/download/v3.30.2/cmake-3.30.2-SHA-256.txt",
+		}},
+		{"cmake", "v3.30.2", "darwin", "arm64", Source{
+			URL:       "https://github.com/Kitware/CMake/releases/download/v3.30.2/cmake-3.30.2-macos-universal.tar.gz",
+			Checksums: "https://github.com/Kitware/CMake/releases/download/v3.30.2/cmake-3.30.2-SHA-256.txt",
+		}},
+		{"ninja", "1.12.1", "windows", "amd64", Source{
+			URL:     "https://github.com/ninja-build/ninja/releases/download/v1.12.1/ninja-win.zip",
+			Into:    "bin",
+			Release: "https://api.github.com/repos/ninja-build/ninja/releases/tags/v1.12.1",
+		}},
+		{"llvm", "19.1.7", "linux", "arm64", Source{
+			URL:     "https://github.com/llvm/llvm-project/releases/download/llvmorg-19.1.7/LLVM-19.1.7-Linux-ARM64.tar.xz",
+			Release: "https://api.github.com/repos/llvm/llvm-project/releases/tags/llvmorg-19.1.7",
+		}},
+		{"gcc", "14.2.0", "linux", "amd64", Source{
+			URL:       "https://github.com/xpack-dev-tools/gcc-xpack/releases/download/v14.2.0-1/xpack-gcc-14.2.0-1-linux-x64.tar.gz",
+			Checksums: "https://github.com/xpack-dev-tools/gcc-xpack/releases/download/v14.2.0-1/xpack-gcc-14.2.0-1-linux-x64.tar.gz.sha",
+		}},
+		{"gcc", "13.3.0-2", "darwin", "arm64", Source{
+			URL:       "https://github.com/xpack-dev-tools/gcc-xpack/releases/download/v13.3.0-2/xpack-gcc-13.3.0-2-darwin-arm64.tar.gz",
+			Checksums: "https://github.com/xpack-dev-tools/gcc-xpack/releases/download/v13.3.0-2/xpack-gcc-13.3.0-2-darwin-arm64.tar.gz.sha",
+		}},
+	}
+	for _, tt := range tests {
+		got, err := SourceFor(tt.tool, tt.version, tt.goos, tt.goarch)
+		require.NoError(t, err, tt.tool)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := SourceFor("llvm", "19.1.7", "darwin", "amd64")
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(err))
+	_, err = SourceFor("msvc", "17", "windows", "amd64")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
+
+func TestInstall(t *testing.T) {
+	var tgz bytes.Buffer
+	gz := gzip.NewWriter(&tgz)
+	tw := tar.NewWriter(gz)
+	for name, content := range map[string]string{
+		"cmake-3.30.2-linux-x86_64/bin/cmake":         "#!/bin/sh\n",
+		"cmake-3.30.2-linux-x86_64/share/cmake/x.txt": "x",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	var zipped bytes.Buffer
+	zw := zip.NewWriter(&zipped)
+	w, err := zw.Create("ninja")
+	require.NoError(t, err)
+	w.Write([]byte("binary"))
+	require.NoError(t, zw.Close())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cmake.tar.gz":
+			w.Write(tgz.Bytes())
+		case "/cmake-SHA-256.txt":
+			fmt.Fprintf(w, "%s  cmake.tar.gz\n", sha256Hex(tgz.Bytes()))
+		case "/ninja.zip":
+			w.Write(zipped.Bytes())
+		case "/ninja-release":
+			fmt.Fprintf(w, `{"assets": [{"name": "ninja.zip", "digest": "sha256:%s"}]}`, sha256Hex(zipped.Bytes()))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	tc, err := install(dir, "cmake", "3.30.2", Source{URL: server.URL + "/cmake.tar.gz", Checksums: server.URL + "/cmake-SHA-256.txt"}, "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "cmake", "3.30.2"), tc.Path)
+	assert.FileExists(t, filepath.Join(tc.BinDir(), "cmake"))
+	assert.FileExists(t, filepath.Join(tc.Path, "share", "cmake", "x.txt"))
+
+	tc, err = install(dir, "ninja", "1.12.1", Source{URL: server.URL + "/ninja.zip", Into: "bin", Release: server.URL + "/ninja-release"}, "")
+	require.NoError(t, err)
+	info, err := os.Stat(filepath.Join(tc.BinDir(), "ninja"))
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&0100, "ninja should be executable")
+
+	_, err = install(dir, "ninja", "0.0.1", Source{URL: server.URL + "/missing.zip", Into: "bin"}, sha256Hex(nil))
+	assert.Equal(t, exitcode.ToolchainMissing, exitcode.Of(err))
+	entries, err := os.ReadDir(filepath.Join(dir, "ninja"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "a failed install leaves nothing behind")
+
+	t.Setenv(offline.EnvVar, "1")
+	_, err = Install("cmake", "3.30.2", "")
+	assert.ErrorContains(t, err, "offline")
+}
+
+func TestInstallChecksum(t *testing.T) {
+	archive := []byte("tampered")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ninja.zip":
+			w.Write(archive)
+		case "/SHA-256.txt":
+			fmt.Fprintf(w, "%s  ninja.zip\n", sha256Hex([]byte("original")))
+		case "/release":
+			w.Write([]byte(`{"assets": [{"name": "ninja.zip"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+
+	_, err := install(dir, "ninja", "1.12.1", Source{URL: server.URL + "/ninja.zip", Into: "bin", Checksums: server.URL + "/SHA-256.txt"}, "")
+	assert.ErrorContains(t, err, "checksum mismatch for ninja.zip")
+	entries, err := os.ReadDir(filepath.Join(dir, "ninja"))
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a mismatched archive is not installed")
+
+	_, err = install(dir, "ninja", "1.12.1", Source{URL: server.URL + "/ninja.zip", Into: "bin"}, sha256Hex([]byte("original")))
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	// Without a published checksum, only a pinned one installs
+	_, err = install(dir, "ninja", "1.12.1", Source{URL: server.URL + "/ninja.zip", Into: "bin", Release: server.URL + "/release"}, "")
+	assert.ErrorContains(t, err, "--sha256")
+	_, err = install(dir, "ninja", "1.12.1", Source{URL: server.URL + "/ninja.zip", Into: "bin"}, "")
+	assert.ErrorContains(t, err, "--sha256")
+	assert.NoDirExists(t, filepath.Join(dir, "ninja", "1.12.1"))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSelectAndActivate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir, err := Dir()
+	require.NoError(t, err)
+	for _, v := range []string{"cmake/3.9.6", "cmake/3.30.2", "cmake/3.28.1", "ninja/1.12.1", "gcc/14.2.0-1", "gcc/.install-123"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, v, "bin"), 0755))
+	}
+
+	installed, err := Installed()
+	require.NoError(t, err)
+	var names []string
+	for _, tc := range installed {
+		names = append(names, tc.Tool+" "+tc.Version)
+	}
+	assert.Equal(t, []string{"cmake 3.30.2", "cmake 3.28.1", "cmake 3.9.6", "ninja 1.12.1", "gcc 14.2.0-1"}, names)
+
+	// The newest version, unless the project constrains the tool
+	selected := Select(installed, nil)
+	require.Len(t, selected, 3)
+	assert.Equal(t, "3.30.2", selected[0].Version)
+	manifest := &config.ToolsConfig{Tools: map[string]string{"cmake": "3.28", "g++": ">=15"}}
+	selected = Select(installed, manifest)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "3.28.1", selected[0].Version)
+	assert.Equal(t, "ninja", selected[1].Tool)
+
+	t.Setenv("PATH", "/usr/bin")
+	t.Setenv("CC", "")
+	t.Setenv("CXX", "")
+	Activate(Select(installed, nil), nil)
+	paths := filepath.SplitList(os.Getenv("PATH"))
+	assert.Equal(t, filepath.Join(dir, "cmake", "3.30.2", "bin"), paths[0])
+	assert.Equal(t, "/usr/bin", paths[len(paths)-1])
+	assert.Equal(t, filepath.Join(dir, "gcc", "14.2.0-1", "bin", "g++"), os.Getenv("CXX"))
+
+	// CC and CXX set by the user win
+	t.Setenv("CC", "cc")
+	t.Setenv("CXX", "c++")
+	Activate(Select(installed, nil), nil)
+	assert.Equal(t, "c++", os.Getenv("CXX"))
+
+	cc, cxx, ok := FindCompiler("gcc", "14")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(dir, "gcc", "14.2.0-1", "bin", "gcc"), cc)
+	assert.Equal(t, filepath.Join(dir, "gcc", "14.2.0-1", "bin", "g++"), cxx)
+	_, _, ok = FindCompiler("gcc", "13")
+	assert.False(t, ok)
+	_, _, ok = FindCompiler("cmake", "")
+	assert.False(t, ok)
+
+	require.NoError(t, Remove("gcc", "14.2.0"))
+	assert.NoDirExists(t, filepath.Join(dir, "gcc", "14.2.0-1"))
+	err = Remove("gcc", "14.2.0")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+	assert.True(t, strings.Contains(err.Error(), "not installed"))
+
+	// Versions are single directories: nothing outside one is removed
+	for _, version := range []string{"../..", "..", ".", "3.30.2/../..", "/tmp", ""} {
+		err = Remove("cmake", version)
+		assert.Equal(t, exitcode.Usage, exitcode.Of(err), version)
+	}
+	assert.DirExists(t, filepath.Join(dir, "cmake", "3.30.2"))
+	assert.DirExists(t, filepath.Join(dir, "cmake", "3.28.1"))
+	_, err = SourceFor("cmake", "../3.30.2", "linux", "amd64")
+	assert.Equal(t, exitcode.Usage, exitcode.Of(err))
+}
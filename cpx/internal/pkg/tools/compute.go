@@ -0,0 +1,71 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var nvccReleasePattern = regexp.MustCompile(`release (\d+(\.\d+)*)`)
+
+// openmpProbe is compiled to check that the C++ compiler supports OpenMP
+const openmpProbe = `#include <omp.h>
+int main() { return omp_get_max_threads() > 0 ? 0 : 1; }
+`
+
+// CheckComputeToolkit checks the toolkit a compute backend ("openmp", "cuda"
+// or "sycl") needs. The checks have no version constraint.
+func CheckComputeToolkit(backend string) []Check {
+	switch backend {
+	case "openmp":
+		return []Check{checkOpenMP()}
+	case "cuda":
+		check := Check{Tool: "nvcc", Status: StatusMissing}
+		if _, err := execLookPath("nvcc"); err == nil {
+			out, _ := execCommand("nvcc", "--version").CombinedOutput()
+			check.Status = StatusUnknown
+			if m := nvccReleasePattern.FindStringSubmatch(string(out)); m != nil {
+				check.Version, check.Status = m[1], StatusOK
+			}
+		}
+		return []Check{check}
+	case "sycl":
+		// oneAPI DPC++ or AdaptiveCpp
+		for _, compiler := range []string{"icpx", "acpp"} {
+			if version, err := DetectVersion(compiler); err == nil {
+				check := Check{Tool: compiler, Version: version, Status: StatusOK}
+				if version == "" {
+					check.Status = StatusUnknown
+				}
+				return []Check{check}
+			}
+		}
+		return []Check{{Tool: "icpx or acpp", Status: StatusMissing}}
+	default:
+		return nil
+	}
+}
+
+// checkOpenMP compiles and links a small OpenMP program with the default C++
+// compiler
+func checkOpenMP() Check {
+	check := Check{Tool: "openmp", Status: StatusMissing}
+	if _, err := execLookPath("c++"); err != nil {
+		return check
+	}
+
+	dir, err := os.MkdirTemp("", "cpx-openmp-*")
+	if err != nil {
+		check.Status = StatusUnknown
+		return check
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := execCommand("c++", "-fopenmp", "-x", "c++", "-", "-o", filepath.Join(dir, "probe"))
+	cmd.Stdin = strings.NewReader(openmpProbe)
+	if err := cmd.Run(); err == nil {
+		check.Status = StatusOK
+	}
+	return check
+}
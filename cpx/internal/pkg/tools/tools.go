@@ -0,0 +1,214 @@
+// Package tools detects installed tool versions and checks them against the
+// constraints in a project's .cpx-tools.yaml.
+package tools
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ozacod/cpx/pkg/config"
+)
+
+// Exec hooks, replaced in tests
+var (
+	execCommand  = exec.Command
+	execLookPath = exec.LookPath
+)
+
+// DefaultTools are the tools `cpx doctor` reports when the project has no
+// manifest, and the candidates `cpx doctor --record` pins
+var DefaultTools = []string{"cmake", "ninja", "c++", "clang-tidy", "clang-format", "bazel", "meson"}
+
+// Check statuses
+const (
+	StatusOK       = "ok"
+	StatusMismatch = "mismatch"
+	StatusMissing  = "missing"
+	StatusUnknown  = "unknown" // installed, but the version could not be read
+)
+
+// Check is the result of verifying one tool
+type Check struct {
+	Tool       string
+	Constraint string // empty if the tool is only reported, not required
+	Version    string
+	Status     string
+}
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+|\d+`)
+
+// ParseVersion extracts the first version number from `<tool> --version`
+// output, e.g. "cmake version 3.28.1" -> "3.28.1"
+func ParseVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if v := versionPattern.FindString(line); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// DetectVersion returns the installed version of tool, or an error if it is
+// not on PATH. The version is empty if it could not be parsed.
+func DetectVersion(tool string) (string, error) {
+	if _, err := execLookPath(tool); err != nil {
+		return "", err
+	}
+	out, err := execCommand(tool, "--version").CombinedOutput()
+	if err != nil && len(out) == 0 {
+		return "", nil
+	}
+	return ParseVersion(string(out)), nil
+}
+
+// Satisfies reports whether version meets constraint. A constraint is an
+// operator (>=, <=, >, <, =) followed by a version, or a bare version that
+// matches as a component prefix ("17" matches "17.0.6", not "170.1").
+func Satisfies(version, constraint string) (bool, error) {
+	constraint = strings.TrimSpace(constraint)
+	op := ""
+	for _, candidate := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(constraint, candidate) {
+			op = candidate
+			constraint = strings.TrimSpace(constraint[len(candidate):])
+			break
+		}
+	}
+
+	want, err := parseComponents(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+	have, err := parseComponents(version)
+	if err != nil {
+		return false, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	if op == "" || op == "=" {
+		if len(have) < len(want) {
+			return false, nil
+		}
+		return compareComponents(have[:len(want)], want) == 0, nil
+	}
+
+	cmp := compareComponents(have, want)
+	switch op {
+	case ">=":
+		return cmp >= 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp < 0, nil
+	}
+}
+
+// Compare compares two versions component-wise, returning -1, 0 or 1. A
+// version that can't be parsed sorts before every valid one.
+func Compare(a, b string) int {
+	x, errA := parseComponents(a)
+	y, errB := parseComponents(b)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return -1
+	case errB != nil:
+		return 1
+	}
+	return compareComponents(x, y)
+}
+
+func parseComponents(version string) ([]int, error) {
+	if version == "" {
+		return nil, fmt.Errorf("empty version")
+	}
+	parts := strings.Split(version, ".")
+	components := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, err
+		}
+		components[i] = n
+	}
+	return components, nil
+}
+
+// compareComponents compares versions component-wise; missing components count as 0
+func compareComponents(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		x, y := 0, 0
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// Verify checks every tool in the manifest, in alphabetical order
+func Verify(cfg *config.ToolsConfig) ([]Check, error) {
+	names := make([]string, 0, len(cfg.Tools))
+	for name := range cfg.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checks := make([]Check, 0, len(names))
+	for _, name := range names {
+		check := Check{Tool: name, Constraint: cfg.Tools[name]}
+		version, err := DetectVersion(name)
+		switch {
+		case err != nil:
+			check.Status = StatusMissing
+		case version == "":
+			check.Status = StatusUnknown
+		default:
+			check.Version = version
+			ok, err := Satisfies(version, check.Constraint)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			check.Status = StatusOK
+			if !ok {
+				check.Status = StatusMismatch
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// Failed returns the checks that are missing or do not satisfy their constraint
+func Failed(checks []Check) []Check {
+	var failed []Check
+	for _, c := range checks {
+		if c.Status == StatusMismatch || c.Status == StatusMissing {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// Pin returns a constraint pinning version to its major.minor release
+func Pin(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/ozacod/cpx/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected string
+	}{
+		{"CMake", "cmake version 3.28.1\n\nCMake suite maintained by Kitware", "3.28.1"},
+		{"Ninja", "1.11.1\n", "1.11.1"},
+		{"Clang", "Ubuntu clang version 17.0.6 (++20231208)\nTarget: x86_64", "17.0.6"},
+		{"GCC", "g++ (Ubuntu 13.2.0-4ubuntu3) 13.2.0\n", "13.2.0"},
+		{"Bazel", "bazel 7.1.0", "7.1.0"},
+		{"No version", "usage: tool [options]", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ParseVersion(tt.output))
+		})
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		expected   bool
+	}{
+		{"17.0.6", "17", true},
+		{"170.1", "17", false},
+		{"3.28.1", "3.28", true},
+		{"3.27.9", "3.28", false},
+		{"3.28.1", ">=3.20", true},
+		{"3.19", ">=3.20", false},
+		{"17.0.6", "<18", true},
+		{"18.1", "<18", false},
+		{"1.11.1", "> 1.11", true},
+		{"1.11", "<=1.11.0", true},
+		{"13.2.0", "=13.2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.constraint, func(t *testing.T) {
+			ok, err := Satisfies(tt.version, tt.constraint)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+		})
+	}
+
+	_, err := Satisfies("3.28", ">=latest")
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	assert.Equal(t, 1, Compare("3.30.2", "3.9"))
+	assert.Equal(t, 0, Compare("18", "18.0.0"))
+	assert.Equal(t, -1, Compare("1.11.1", "1.12"))
+	assert.Equal(t, -1, Compare("nightly", "1.0"))
+}
+
+func TestPin(t *testing.T) {
+	assert.Equal(t, "3.28", Pin("3.28.1"))
+	assert.Equal(t, "1.11", Pin("1.11"))
+	assert.Equal(t, "7", Pin("7"))
+}
+
+func TestVerify(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+
+	versions := map[string]string{"cmake": "cmake version 3.28.1", "ninja": "1.10.2", "weird": "no version here"}
+	execLookPath = func(file string) (string, error) {
+		if _, ok := versions[file]; ok {
+			return "/usr/bin/" + file, nil
+		}
+		return "", fmt.Errorf("%s: not found", file)
+	}
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", versions[name])
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	checks, err := Verify(&config.ToolsConfig{Tools: map[string]string{
+		"cmake":      ">=3.20",
+		"ninja":      "1.11",
+		"clang-tidy": "17",
+		"weird":      "1",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []Check{
+		{Tool: "clang-tidy", Constraint: "17", Status: StatusMissing},
+		{Tool: "cmake", Constraint: ">=3.20", Version: "3.28.1", Status: StatusOK},
+		{Tool: "ninja", Constraint: "1.11", Version: "1.10.2", Status: StatusMismatch},
+		{Tool: "weird", Constraint: "1", Status: StatusUnknown},
+	}, checks)
+	assert.Len(t, Failed(checks), 2)
+}
+
+// TestHelperProcess prints its argument, standing in for `<tool> --version`
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	fmt.Println(os.Args[len(os.Args)-1])
+	os.Exit(0)
+}
+
+func TestCheckComputeToolkit(t *testing.T) {
+	oldExecCommand := execCommand
+	oldExecLookPath := execLookPath
+	defer func() {
+		execCommand = oldExecCommand
+		execLookPath = oldExecLookPath
+	}()
+
+	installed := map[string]string{
+		"nvcc": "nvcc: NVIDIA (R) Cuda compiler driver\nCopyright (c) 2005-2023 NVIDIA Corporation\nCuda compilation tools, release 12.3, V12.3.107",
+	}
+	execLookPath = func(file string) (string, error) {
+		if _, ok := installed[file]; ok {
+			return "/usr/bin/" + file, nil
+		}
+		return "", fmt.Errorf("%s: not found", file)
+	}
+	execCommand = func(name string, arg ...string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess", "--", installed[name])
+		cmd.Env = append(os.Environ(), "GO_WANT_HELPER_PROCESS=1")
+		return cmd
+	}
+
+	assert.Equal(t, []Check{{Tool: "nvcc", Version: "12.3", Status: StatusOK}}, CheckComputeToolkit("cuda"))
+	assert.Equal(t, []Check{{Tool: "icpx or acpp", Status: StatusMissing}}, CheckComputeToolkit("sycl"))
+	assert.Equal(t, []Check{{Tool: "openmp", Status: StatusMissing}}, CheckComputeToolkit("openmp"))
+	assert.Nil(t, CheckComputeToolkit("none"))
+}
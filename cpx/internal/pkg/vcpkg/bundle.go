@@ -0,0 +1,257 @@
+package vcpkg
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// BundleDir holds an imported dependency bundle. While it exists, SetupEnv
+// points vcpkg at its downloads, binary cache and registries so that builds
+// work without network access.
+var BundleDir = filepath.Join(".cache", "vcpkg-bundle")
+
+// Bundle subdirectories, each mapped to a vcpkg environment variable
+const (
+	BundleDownloads  = "downloads"  // VCPKG_DOWNLOADS
+	BundleBinary     = "binary"     // VCPKG_BINARY_SOURCES
+	BundleRegistries = "registries" // X_VCPKG_REGISTRIES_CACHE
+)
+
+// bundleManifestFile describes the bundle inside the archive
+const bundleManifestFile = "bundle.json"
+
+// BundleManifest records what a dependency bundle was exported for
+type BundleManifest struct {
+	// ManifestHash is the sha256 of the vcpkg.json the bundle was exported from
+	ManifestHash string `json:"manifest_hash"`
+	// Baseline is the builtin-baseline of vcpkg.json, if any
+	Baseline string    `json:"baseline,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// NewBundleManifest describes a bundle for the vcpkg.json at manifestPath
+func NewBundleManifest(manifestPath string) (*BundleManifest, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Baseline string `json:"builtin-baseline"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestPath, err)
+	}
+
+	return &BundleManifest{
+		ManifestHash: manifestHash(data),
+		Baseline:     manifest.Baseline,
+		Created:      time.Now().UTC(),
+	}, nil
+}
+
+// Matches reports whether the bundle was exported for the vcpkg.json at
+// manifestPath
+func (m *BundleManifest) Matches(manifestPath string) (bool, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return false, err
+	}
+	return m.ManifestHash == manifestHash(data), nil
+}
+
+func manifestHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// BundleEnv returns the environment variables that make vcpkg use the
+// bundle in dir (an absolute path) instead of the network
+func BundleEnv(dir string) map[string]string {
+	return map[string]string{
+		"VCPKG_DOWNLOADS":          filepath.Join(dir, BundleDownloads),
+		"VCPKG_BINARY_SOURCES":     "clear;files," + filepath.Join(dir, BundleBinary) + ",read",
+		"X_VCPKG_REGISTRIES_CACHE": filepath.Join(dir, BundleRegistries),
+	}
+}
+
+// WriteBundle archives the bundle subdirectories of stagingDir together with
+// manifest into a tar file at path, gzip-compressed if path ends in .gz or .tgz
+func WriteBundle(path, stagingDir string, manifest *BundleManifest) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = cerr
+		}
+	}()
+
+	var w io.Writer = f
+	if isGzipPath(path) {
+		gz := gzip.NewWriter(f)
+		defer func() {
+			if cerr := gz.Close(); err == nil && cerr != nil {
+				err = cerr
+			}
+		}()
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	defer func() {
+		if cerr := tw.Close(); err == nil && cerr != nil {
+			err = cerr
+		}
+	}()
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", bundleManifestFile, err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: bundleManifestFile, Mode: 0644, Size: int64(len(data)), ModTime: manifest.Created}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	for _, sub := range []string{BundleDownloads, BundleBinary, BundleRegistries} {
+		if err := addTree(tw, stagingDir, sub); err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", sub, err)
+		}
+	}
+	return nil
+}
+
+// addTree adds the regular files and directories under root/sub to tw
+func addTree(tw *tar.Writer, root, sub string) error {
+	dir := filepath.Join(root, sub)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil // vcpkg caches hold no links worth keeping
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+// ExtractBundle unpacks the bundle at path into dest and returns its manifest
+func ExtractBundle(path, dest string) (*BundleManifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if isGzipPath(path) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var manifest *BundleManifest
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("invalid path %q in bundle", header.Name)
+		}
+
+		if name == bundleManifestFile {
+			manifest = &BundleManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", bundleManifestFile, err)
+			}
+			continue
+		}
+
+		target := filepath.Join(dest, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, target, header.FileInfo().Mode().Perm()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("%s is not a cpx dependency bundle (missing %s)", path, bundleManifestFile)
+	}
+	return manifest, nil
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func isGzipPath(path string) bool {
+	return strings.HasSuffix(path, ".gz") || strings.HasSuffix(path, ".tgz")
+}
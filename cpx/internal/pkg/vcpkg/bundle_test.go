@@ -0,0 +1,89 @@
+package vcpkg
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	for _, name := range []string{"deps.tar", "deps.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			manifestPath := filepath.Join(tmpDir, "vcpkg.json")
+			require.NoError(t, os.WriteFile(manifestPath, []byte(`{"name": "app", "builtin-baseline": "abc123", "dependencies": ["fmt"]}`), 0644))
+
+			staging := filepath.Join(tmpDir, "staging")
+			require.NoError(t, os.MkdirAll(filepath.Join(staging, BundleDownloads), 0755))
+			require.NoError(t, os.MkdirAll(filepath.Join(staging, BundleBinary, "ab"), 0755))
+			require.NoError(t, os.MkdirAll(filepath.Join(staging, "installed"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(staging, BundleDownloads, "fmt-10.tar.gz"), []byte("source"), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(staging, BundleBinary, "ab", "abcd.zip"), []byte("binary"), 0644))
+			require.NoError(t, os.WriteFile(filepath.Join(staging, "installed", "skip.txt"), []byte("x"), 0644))
+
+			manifest, err := NewBundleManifest(manifestPath)
+			require.NoError(t, err)
+			assert.Equal(t, "abc123", manifest.Baseline)
+
+			archive := filepath.Join(tmpDir, name)
+			require.NoError(t, WriteBundle(archive, staging, manifest))
+
+			dest := filepath.Join(tmpDir, "bundle")
+			extracted, err := ExtractBundle(archive, dest)
+			require.NoError(t, err)
+			assert.Equal(t, manifest.ManifestHash, extracted.ManifestHash)
+			assert.Equal(t, "abc123", extracted.Baseline)
+
+			data, err := os.ReadFile(filepath.Join(dest, BundleDownloads, "fmt-10.tar.gz"))
+			require.NoError(t, err)
+			assert.Equal(t, "source", string(data))
+			assert.FileExists(t, filepath.Join(dest, BundleBinary, "ab", "abcd.zip"))
+			assert.NoDirExists(t, filepath.Join(dest, "installed"))
+
+			ok, err := extracted.Matches(manifestPath)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			require.NoError(t, os.WriteFile(manifestPath, []byte(`{"name": "app"}`), 0644))
+			ok, err = extracted.Matches(manifestPath)
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestExtractBundleRejectsInvalidArchives(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	writeTar := func(name string, files map[string]string) string {
+		path := filepath.Join(tmpDir, name)
+		f, err := os.Create(path)
+		require.NoError(t, err)
+		tw := tar.NewWriter(f)
+		for fileName, content := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: fileName, Mode: 0644, Size: int64(len(content))}))
+			_, err := tw.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+		require.NoError(t, f.Close())
+		return path
+	}
+
+	_, err := ExtractBundle(writeTar("evil.tar", map[string]string{"../evil.txt": "x"}), filepath.Join(tmpDir, "out"))
+	assert.ErrorContains(t, err, "invalid path")
+	assert.NoFileExists(t, filepath.Join(tmpDir, "evil.txt"))
+
+	_, err = ExtractBundle(writeTar("plain.tar", map[string]string{"downloads/a": "x"}), filepath.Join(tmpDir, "out"))
+	assert.ErrorContains(t, err, "not a cpx dependency bundle")
+}
+
+func TestBundleEnv(t *testing.T) {
+	env := BundleEnv("/bundle")
+	assert.Equal(t, filepath.Join("/bundle", "downloads"), env["VCPKG_DOWNLOADS"])
+	assert.Equal(t, "clear;files,"+filepath.Join("/bundle", "binary")+",read", env["VCPKG_BINARY_SOURCES"])
+	assert.Equal(t, filepath.Join("/bundle", "registries"), env["X_VCPKG_REGISTRIES_CACHE"])
+}
@@ -8,6 +8,9 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+	"github.com/ozacod/cpx/internal/pkg/logging"
+	"github.com/ozacod/cpx/internal/pkg/offline"
 	"github.com/ozacod/cpx/pkg/config"
 )
 
@@ -25,13 +28,34 @@ func NewClient() (*Client, error) {
 	return &Client{globalConfig: globalConfig}, nil
 }
 
+// OfflineEnv is the vcpkg environment of offline mode. x-block-origin stops
+// vcpkg from downloading sources from their original URLs, so only files in
+// the downloads directory or an asset cache are used.
+var OfflineEnv = map[string]string{
+	"X_VCPKG_ASSET_SOURCES": "clear;x-block-origin",
+	"VCPKG_DISABLE_METRICS": "1",
+}
+
+// BinarySources returns VCPKG_BINARY_SOURCES for cache, replacing vcpkg's
+// default cache
+func BinarySources(cache config.BinaryCache) string {
+	return "clear;" + cache.VcpkgSource()
+}
+
+// BinaryCacheCredentials are the environment variables holding the
+// credentials of each kind of remote binary cache
+var BinaryCacheCredentials = map[string][]string{
+	"s3":  {"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "AWS_DEFAULT_REGION"},
+	"gcs": {"CLOUDSDK_AUTH_ACCESS_TOKEN"},
+}
+
 // SetupEnv sets VCPKG_ROOT and VCPKG_FEATURE_FLAGS environment variables from cpx config
 // This ensures CMake presets can find vcpkg and uses manifest mode consistently
 func (c *Client) SetupEnv() error {
 	// Set VCPKG_ROOT if not already set and we have it in config
 	if os.Getenv("VCPKG_ROOT") == "" {
 		if c.globalConfig.VcpkgRoot == "" {
-			return fmt.Errorf("vcpkg_root not set in config. Run: cpx config set-vcpkg-root <path>")
+			return exitcode.Errorf(exitcode.Config, "vcpkg_root not set in config. Run: cpx config set-vcpkg-root <path>")
 		}
 		if err := os.Setenv("VCPKG_ROOT", c.globalConfig.VcpkgRoot); err != nil {
 			return fmt.Errorf("failed to set VCPKG_ROOT: %w", err)
@@ -54,13 +78,49 @@ func (c *Client) SetupEnv() error {
 		}
 	}
 
-	if os.Getenv("CPX_DEBUG") != "" {
-		const Cyan = "\033[36m"
-		const Reset = "\033[0m"
-		fmt.Printf("%s[DEBUG] VCPKG Environment:%s\n", Cyan, Reset)
-		fmt.Printf("  VCPKG_ROOT=%s\n", os.Getenv("VCPKG_ROOT"))
-		fmt.Printf("  VCPKG_FEATURE_FLAGS=%s\n", os.Getenv("VCPKG_FEATURE_FLAGS"))
-		fmt.Printf("  VCPKG_DISABLE_REGISTRY_UPDATE=%s\n", os.Getenv("VCPKG_DISABLE_REGISTRY_UPDATE"))
+	// Offline: sources only come from the downloads directory and asset caches
+	if offline.Enabled() {
+		for key, value := range OfflineEnv {
+			if os.Getenv(key) != "" {
+				continue
+			}
+			if err := os.Setenv(key, value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key, err)
+			}
+		}
+	}
+
+	// Use an imported dependency bundle (cpx bundle import) instead of the network
+	if info, err := os.Stat(BundleDir); err == nil && info.IsDir() {
+		absBundleDir, err := filepath.Abs(BundleDir)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute bundle path: %w", err)
+		}
+		for key, value := range BundleEnv(absBundleDir) {
+			if os.Getenv(key) != "" {
+				continue
+			}
+			if err := os.Setenv(key, value); err != nil {
+				return fmt.Errorf("failed to set %s: %w", key, err)
+			}
+		}
+	}
+
+	// Use the binary cache of cpx config set-binary-cache; remote caches
+	// are skipped offline
+	if cache := c.globalConfig.BinaryCache; cache != nil && os.Getenv("VCPKG_BINARY_SOURCES") == "" {
+		if cache.Kind == "files" || !offline.Enabled() {
+			if err := os.Setenv("VCPKG_BINARY_SOURCES", BinarySources(*cache)); err != nil {
+				return fmt.Errorf("failed to set VCPKG_BINARY_SOURCES: %w", err)
+			}
+		}
+	}
+
+	logging.Debug("vcpkg environment:")
+	for _, name := range []string{"VCPKG_ROOT", "VCPKG_FEATURE_FLAGS", "VCPKG_DISABLE_REGISTRY_UPDATE", "VCPKG_BINARY_SOURCES"} {
+		if value := os.Getenv(name); value != "" || name != "VCPKG_BINARY_SOURCES" {
+			logging.Debug("  %s=%s", name, value)
+		}
 	}
 
 	return nil
@@ -78,7 +138,7 @@ func (c *Client) GetPath() (string, error) {
 	}
 
 	if vcpkgRoot == "" {
-		return "", fmt.Errorf("vcpkg_root not set in config. Run: cpx config set-vcpkg-root <path>")
+		return "", exitcode.Errorf(exitcode.Config, "vcpkg_root not set in config. Run: cpx config set-vcpkg-root <path>")
 	}
 
 	// Convert to absolute path
@@ -93,7 +153,7 @@ func (c *Client) GetPath() (string, error) {
 	}
 
 	if _, err := os.Stat(vcpkgPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("vcpkg not found at %s. Make sure vcpkg is installed and bootstrapped", vcpkgPath)
+		return "", exitcode.Errorf(exitcode.ToolchainMissing, "vcpkg not found at %s. Make sure vcpkg is installed and bootstrapped", vcpkgPath)
 	}
 
 	return vcpkgPath, nil
@@ -101,15 +161,35 @@ func (c *Client) GetPath() (string, error) {
 
 // RunCommand runs a vcpkg command
 func (c *Client) RunCommand(args []string) error {
-	vcpkgPath, err := c.GetPath()
+	cmd, err := c.command(args)
 	if err != nil {
 		return err
 	}
-
-	cmd := exec.Command(vcpkgPath, args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// Output runs a vcpkg command and returns its standard output; its standard
+// error still goes to the terminal
+func (c *Client) Output(args []string) ([]byte, error) {
+	cmd, err := c.command(args)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// command prepares a vcpkg command
+func (c *Client) command(args []string) (*exec.Cmd, error) {
+	vcpkgPath, err := c.GetPath()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(vcpkgPath, args...)
 	// Remove VCPKG_ROOT from environment to use the one from config
 	cmd.Env = os.Environ()
 	for i, env := range cmd.Env {
@@ -118,5 +198,5 @@ func (c *Client) RunCommand(args []string) error {
 			break
 		}
 	}
-	return cmd.Run()
+	return cmd, nil
 }
@@ -0,0 +1,288 @@
+package vcpkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IndexFile is the port index of cpx search and cpx info, in the cpx config
+// directory
+const IndexFile = "port-index.json"
+
+// indexVersion is the format of the index, raised when Port gains fields so
+// that older indexes are rebuilt
+const indexVersion = 1
+
+// Index holds every port of a vcpkg checkout, so that searches don't read
+// thousands of port manifests
+type Index struct {
+	Version   int    `json:"version"`
+	VcpkgRoot string `json:"vcpkg_root"`
+	// Commit is the commit of the checkout the index was built from; empty
+	// if the checkout isn't a git clone
+	Commit string `json:"commit"`
+	Ports  []Port `json:"ports"`
+
+	dependents map[string]int // see popularity
+}
+
+// checkoutCommit returns the commit of the vcpkg checkout in vcpkgRoot, or ""
+func checkoutCommit(vcpkgRoot string) string {
+	out, err := exec.Command("git", "-C", vcpkgRoot, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// BuildIndex indexes the ports of the vcpkg checkout in vcpkgRoot. Ports
+// without a valid vcpkg.json are left out, as are vcpkg's own helper ports.
+func BuildIndex(vcpkgRoot string) (*Index, error) {
+	entries, err := os.ReadDir(filepath.Join(vcpkgRoot, "ports"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ports directory: %w", err)
+	}
+	index := &Index{Version: indexVersion, VcpkgRoot: vcpkgRoot, Commit: checkoutCommit(vcpkgRoot), Ports: []Port{}}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), "vcpkg-") {
+			continue
+		}
+		port, err := LoadPort(vcpkgRoot, entry.Name())
+		if err != nil {
+			continue
+		}
+		index.Ports = append(index.Ports, *port)
+	}
+	sort.Slice(index.Ports, func(i, j int) bool { return index.Ports[i].Name < index.Ports[j].Name })
+	return index, nil
+}
+
+// LoadIndex reads the index at path
+func LoadIndex(path string) (*Index, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index Index
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return &index, nil
+}
+
+// Save writes the index to path
+func (i *Index) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(i)
+	if err != nil {
+		return fmt.Errorf("failed to encode the port index: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// Fresh reports whether the index is of the current state of the vcpkg
+// checkout in vcpkgRoot. Indexes of checkouts that aren't git clones, and
+// indexes written by other versions of cpx, are never fresh.
+func (i *Index) Fresh(vcpkgRoot string) bool {
+	return i.Version == indexVersion && i.VcpkgRoot == vcpkgRoot && i.Commit != "" && i.Commit == checkoutCommit(vcpkgRoot)
+}
+
+// Port returns the port called name
+func (i *Index) Port(name string) (*Port, bool) {
+	n := sort.Search(len(i.Ports), func(n int) bool { return i.Ports[n].Name >= name })
+	if n < len(i.Ports) && i.Ports[n].Name == name {
+		return &i.Ports[n], true
+	}
+	return nil, false
+}
+
+// SearchMatch is a port found by Index.Search
+type SearchMatch struct {
+	Port
+	// Matched are the byte offsets of the characters of the name that the
+	// query matched, for highlighting
+	Matched []int
+	score   int
+}
+
+// Match scores, best first. Ports of the same score are ordered by
+// popularity, then by the length of their name.
+const (
+	scoreExact       = 1000
+	scorePrefix      = 800
+	scoreSubstring   = 600
+	scoreWords       = 500 // every word of the query is in the name
+	scoreFuzzy       = 300 // letters in order, minus the gaps between them
+	scoreTypo        = 200
+	scoreDescription = 100
+)
+
+// Search returns the ports matching query, best matches first. Separators
+// don't matter: "json cpp" finds jsoncpp and "nlohmann json" nlohmann-json.
+func (i *Index) Search(query string) []SearchMatch {
+	words := strings.FieldsFunc(strings.ToLower(query), isSeparator)
+	if len(words) == 0 {
+		return nil
+	}
+	popularity := i.popularity()
+
+	var matches []SearchMatch
+	for n := range i.Ports {
+		if m, ok := matchPort(&i.Ports[n], words); ok {
+			matches = append(matches, m)
+		}
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].score != matches[b].score {
+			return matches[a].score > matches[b].score
+		}
+		if pa, pb := popularity[matches[a].Name], popularity[matches[b].Name]; pa != pb {
+			return pa > pb
+		}
+		return len(matches[a].Name) < len(matches[b].Name)
+	})
+	return matches
+}
+
+// popularity returns how many ports of the index depend on each port, the
+// measure of popularity available without network access
+func (i *Index) popularity() map[string]int {
+	if i.dependents == nil {
+		i.dependents = make(map[string]int)
+		for _, port := range i.Ports {
+			for _, dep := range port.Dependencies {
+				if !dep.Host {
+					i.dependents[dep.Name]++
+				}
+			}
+		}
+	}
+	return i.dependents
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == '-' || r == '_' || r == '.'
+}
+
+// compactName returns the name without separators, and the offset in name
+// of each of its bytes
+func compactName(name string) (string, []int) {
+	var b strings.Builder
+	offsets := make([]int, 0, len(name))
+	for i := 0; i < len(name); i++ {
+		if !isSeparator(rune(name[i])) {
+			b.WriteByte(name[i])
+			offsets = append(offsets, i)
+		}
+	}
+	return b.String(), offsets
+}
+
+// span returns the offsets from..to-1 through offsets
+func span(offsets []int, from, to int) []int {
+	matched := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		matched = append(matched, offsets[i])
+	}
+	return matched
+}
+
+// matchPort scores port against the lowercase words of a query
+func matchPort(port *Port, words []string) (SearchMatch, bool) {
+	m := SearchMatch{Port: *port}
+	query := strings.Join(words, "")
+	name, offsets := compactName(port.Name)
+
+	switch {
+	case name == query:
+		m.score, m.Matched = scoreExact, span(offsets, 0, len(name))
+	case strings.HasPrefix(name, query):
+		m.score, m.Matched = scorePrefix, span(offsets, 0, len(query))
+	case strings.Contains(name, query):
+		at := strings.Index(name, query)
+		m.score, m.Matched = scoreSubstring, span(offsets, at, at+len(query))
+	default:
+		if matched, ok := matchWords(port.Name, words); ok && len(words) > 1 {
+			m.score, m.Matched = scoreWords, matched
+		} else if positions, ok := subsequence(query, name); ok && len(query) >= 3 {
+			gaps := positions[len(positions)-1] - positions[0] + 1 - len(query)
+			m.score = scoreFuzzy - min(gaps, scoreFuzzy-scoreTypo-1)
+			for _, p := range positions {
+				m.Matched = append(m.Matched, offsets[p])
+			}
+		} else if len(query) >= 3 && withinOneEdit(query, name) {
+			m.score = scoreTypo
+		} else if _, ok := matchWords(strings.ToLower(port.Description), words); ok {
+			m.score = scoreDescription
+		} else {
+			return m, false
+		}
+	}
+	return m, true
+}
+
+// matchWords returns the offsets of each of words in s, if s contains them all
+func matchWords(s string, words []string) ([]int, bool) {
+	var matched []int
+	for _, word := range words {
+		at := strings.Index(s, word)
+		if at < 0 {
+			return nil, false
+		}
+		for i := at; i < at+len(word); i++ {
+			matched = append(matched, i)
+		}
+	}
+	sort.Ints(matched)
+	return matched, true
+}
+
+// subsequence returns the offsets in s of the letters of query, matched in
+// order, preferring a run of consecutive letters over the first occurrence
+func subsequence(query, s string) ([]int, bool) {
+	var best []int
+	for start := 0; start < len(s); start++ {
+		if s[start] != query[0] {
+			continue
+		}
+		positions := []int{start}
+		for i := start + 1; i < len(s) && len(positions) < len(query); i++ {
+			if s[i] == query[len(positions)] {
+				positions = append(positions, i)
+			}
+		}
+		if len(positions) < len(query) {
+			break
+		}
+		if best == nil || positions[len(positions)-1]-positions[0] < best[len(best)-1]-best[0] {
+			best = positions
+		}
+	}
+	return best, best != nil
+}
+
+// withinOneEdit reports whether a and b differ by at most one inserted,
+// removed or changed character
+func withinOneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return a[i+min(1, len(a)-i):] == b[i+min(1, len(b)-i):]
+	}
+	return a[i:] == b[i+1:]
+}
@@ -0,0 +1,137 @@
+package vcpkg
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePorts(t *testing.T, root string, manifests map[string]string) {
+	t.Helper()
+	for name, manifest := range manifests {
+		dir := filepath.Join(root, "ports", name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "vcpkg.json"), []byte(manifest), 0644))
+	}
+}
+
+func portNames(ports []Port) []string {
+	names := make([]string, len(ports))
+	for i, port := range ports {
+		names[i] = port.Name
+	}
+	return names
+}
+
+func matchNames(matches []SearchMatch) []string {
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.Name
+	}
+	return names
+}
+
+func TestIndexSearch(t *testing.T) {
+	root := t.TempDir()
+	writePorts(t, root, map[string]string{
+		"fmt":         `{"name": "fmt", "version": "11.0.2", "description": "Formatting library"}`,
+		"fmtlog":      `{"name": "fmtlog", "version": "2.2.1", "description": "Logging library"}`,
+		"spdlog":      `{"name": "spdlog", "version": "1.14.1", "description": "Fast logging library", "features": {"wchar": {"description": "x"}}}`,
+		"libfmt-ext":  `{"name": "libfmt-ext", "version": "1.0", "description": "Extensions"}`,
+		"vcpkg-cmake": `{"name": "vcpkg-cmake", "version": "2024-04-23"}`,
+		"broken":      `{`,
+	})
+
+	index, err := BuildIndex(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fmt", "fmtlog", "libfmt-ext", "spdlog"}, portNames(index.Ports))
+
+	assert.Equal(t, []string{"fmt", "fmtlog", "libfmt-ext"}, matchNames(index.Search("FMT")))
+	// Fuzzy: letters in order, one typo, then descriptions
+	assert.Equal(t, []string{"spdlog"}, matchNames(index.Search("spdlg")))
+	assert.Equal(t, []string{"spdlog"}, matchNames(index.Search("spdlof")))
+	assert.Equal(t, []string{"fmtlog", "spdlog"}, matchNames(index.Search("logging")))
+	assert.Empty(t, index.Search(" "))
+
+	port, ok := index.Port("spdlog")
+	require.True(t, ok)
+	assert.Equal(t, []PortFeature{{Name: "wchar", Description: "x"}}, port.Features)
+	_, ok = index.Port("zlib")
+	assert.False(t, ok)
+}
+
+func TestIndexSaveLoadFresh(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	root := t.TempDir()
+	writePorts(t, root, map[string]string{"fmt": `{"name": "fmt", "version": "11.0.2"}`})
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	git("init", "--quiet")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "ports")
+
+	index, err := BuildIndex(root)
+	require.NoError(t, err)
+	assert.NotEmpty(t, index.Commit)
+	path := filepath.Join(t.TempDir(), "cpx", IndexFile)
+	require.NoError(t, index.Save(path))
+
+	loaded, err := LoadIndex(path)
+	require.NoError(t, err)
+	assert.Equal(t, index, loaded)
+	assert.True(t, loaded.Fresh(root))
+	assert.False(t, loaded.Fresh(t.TempDir()))
+	loaded.Version = 0
+	assert.False(t, loaded.Fresh(root))
+	loaded.Version = indexVersion
+
+	// A new commit of the checkout makes the index stale
+	writePorts(t, root, map[string]string{"zlib": `{"name": "zlib", "version": "1.3.1"}`})
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "zlib")
+	assert.False(t, loaded.Fresh(root))
+}
+
+func TestIndexSearchRanking(t *testing.T) {
+	root := t.TempDir()
+	writePorts(t, root, map[string]string{
+		"jsoncpp":       `{"name": "jsoncpp", "version": "1.9.5"}`,
+		"nlohmann-json": `{"name": "nlohmann-json", "version": "3.11.3"}`,
+		"json-c":        `{"name": "json-c", "version": "0.17"}`,
+		"json-dto":      `{"name": "json-dto", "version": "0.3.4", "dependencies": ["rapidjson"]}`,
+		"rapidjson":     `{"name": "rapidjson", "version": "2023-07-17"}`,
+		"cpp-jwt":       `{"name": "cpp-jwt", "version": "1.4", "dependencies": ["nlohmann-json", {"name": "vcpkg-cmake", "host": true}]}`,
+		"crow":          `{"name": "crow", "version": "1.2.0", "dependencies": ["nlohmann-json"]}`,
+	})
+	index, err := BuildIndex(root)
+	require.NoError(t, err)
+
+	// Separators don't matter
+	matches := index.Search("json cpp")
+	require.NotEmpty(t, matches)
+	assert.Equal(t, "jsoncpp", matches[0].Name)
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, matches[0].Matched)
+	assert.Equal(t, "nlohmann-json", index.Search("nlohmann json")[0].Name)
+
+	// Among names matching alike, the ports more others depend on come
+	// first, then the shorter names
+	matches = index.Search("json")
+	assert.Equal(t, []string{"json-c", "jsoncpp", "json-dto", "nlohmann-json", "rapidjson"}, matchNames(matches))
+	matches = index.Search("son")
+	assert.Equal(t, []string{"nlohmann-json", "rapidjson", "json-c", "jsoncpp", "json-dto"}, matchNames(matches))
+	assert.Equal(t, []int{10, 11, 12}, matches[0].Matched)
+
+	// Words in any order
+	matches = index.Search("jwt cpp")
+	require.Len(t, matches, 1)
+	assert.Equal(t, []int{0, 1, 2, 4, 5, 6}, matches[0].Matched)
+}
@@ -0,0 +1,94 @@
+package vcpkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/ozacod/cpx/internal/pkg/exitcode"
+)
+
+// ReadManifest reads the vcpkg.json at path as a map, so that writing it back
+// keeps the fields cpx doesn't know
+func ReadManifest(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest map[string]any
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, exitcode.Errorf(exitcode.Config, "failed to parse %s: %v", filepath.Base(path), err)
+	}
+	return manifest, nil
+}
+
+// WriteManifest writes manifest to the vcpkg.json at path
+func WriteManifest(path string, manifest map[string]any) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", filepath.Base(path), err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// SetDependencyFeatures adds features to the dependency called name in the
+// vcpkg.json at path, turning a dependency given as a plain string into an
+// object. The dependency is added if it is missing.
+func SetDependencyFeatures(path, name string, features []string) error {
+	manifest, err := ReadManifest(path)
+	if err != nil {
+		return err
+	}
+
+	deps, _ := manifest["dependencies"].([]any)
+	index := -1
+	for i, dep := range deps {
+		depName, _ := dep.(string)
+		if entry, ok := dep.(map[string]any); ok {
+			depName, _ = entry["name"].(string)
+		}
+		if depName == name {
+			index = i
+			break
+		}
+	}
+
+	entry := map[string]any{"name": name}
+	if index >= 0 {
+		if existing, ok := deps[index].(map[string]any); ok {
+			entry = existing
+		}
+	} else {
+		index = len(deps)
+		deps = append(deps, entry)
+	}
+
+	// Features are names or objects with a name and a platform
+	list, _ := entry["features"].([]any)
+	var have []string
+	for _, f := range list {
+		if feature, ok := f.(string); ok {
+			have = append(have, feature)
+		} else if feature, ok := f.(map[string]any); ok {
+			if featureName, ok := feature["name"].(string); ok {
+				have = append(have, featureName)
+			}
+		}
+	}
+	for _, feature := range features {
+		if !slices.Contains(have, feature) {
+			list = append(list, feature)
+			have = append(have, feature)
+		}
+	}
+	entry["features"] = list
+
+	deps[index] = entry
+	manifest["dependencies"] = deps
+	return WriteManifest(path, manifest)
+}
@@ -0,0 +1,38 @@
+package vcpkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDependencyFeatures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vcpkg.json")
+	manifest := `{
+  "name": "app",
+  "dependencies": [
+    "boost",
+    {"name": "curl", "features": [{"name": "ssl", "platform": "!windows"}]}
+  ]
+}`
+	require.NoError(t, os.WriteFile(path, []byte(manifest), 0644))
+
+	require.NoError(t, SetDependencyFeatures(path, "boost", []string{"filesystem", "system"}))
+	require.NoError(t, SetDependencyFeatures(path, "curl", []string{"ssl", "http2"}))
+	require.NoError(t, SetDependencyFeatures(path, "fmt", []string{"core"}))
+
+	got, err := ReadManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, "app", got["name"])
+	assert.Equal(t, []any{
+		map[string]any{"name": "boost", "features": []any{"filesystem", "system"}},
+		map[string]any{"name": "curl", "features": []any{map[string]any{"name": "ssl", "platform": "!windows"}, "http2"}},
+		map[string]any{"name": "fmt", "features": []any{"core"}},
+	}, got["dependencies"])
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	assert.Error(t, SetDependencyFeatures(path, "boost", []string{"system"}))
+}
@@ -0,0 +1,240 @@
+package vcpkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Port describes a port of the vcpkg registry, read from
+// ports/<name>/vcpkg.json of the vcpkg checkout
+type Port struct {
+	Name         string           `json:"name"`
+	Version      string           `json:"version"`
+	Description  string           `json:"description"`
+	Homepage     string           `json:"homepage,omitempty"`
+	License      string           `json:"license,omitempty"`
+	Dependencies []PortDependency `json:"dependencies"`
+	// Supports is the platform expression of the triplets the port builds
+	// for (e.g. "!uwp & !arm"); empty if it supports all of them
+	Supports string        `json:"supports,omitempty"`
+	Features []PortFeature `json:"features"`
+	// DefaultFeatures are installed unless the project's vcpkg.json turns
+	// them off for the port
+	DefaultFeatures []string `json:"default_features,omitempty"`
+	// Usage holds the notes vcpkg prints after installing the port, usually
+	// the find_package and target_link_libraries calls it needs
+	Usage string `json:"usage,omitempty"`
+}
+
+// PortDependency is a dependency of a port
+type PortDependency struct {
+	Name string `json:"name"`
+	// Host dependencies are build tools, built for the host triplet
+	Host     bool     `json:"host,omitempty"`
+	Features []string `json:"features,omitempty"`
+	// Platform restricts the dependency to some triplets
+	Platform string `json:"platform,omitempty"`
+}
+
+// PortFeature is an optional feature of a port
+type PortFeature struct {
+	Name         string           `json:"name"`
+	Description  string           `json:"description"`
+	Dependencies []PortDependency `json:"dependencies,omitempty"`
+}
+
+// portFile is the structure of a port's vcpkg.json
+type portFile struct {
+	Name            string                     `json:"name"`
+	Version         string                     `json:"version"`
+	VersionSem      string                     `json:"version-semver"`
+	VersionDate     string                     `json:"version-date"`
+	VersionStr      string                     `json:"version-string"`
+	PortVersion     int                        `json:"port-version"`
+	Description     json.RawMessage            `json:"description"` // string or []string
+	Homepage        string                     `json:"homepage"`
+	License         *string                    `json:"license"`
+	Supports        string                     `json:"supports"`
+	Dependencies    []json.RawMessage          `json:"dependencies"` // name or object
+	Features        map[string]json.RawMessage `json:"features"`
+	DefaultFeatures []json.RawMessage          `json:"default-features"` // name or object
+}
+
+// joinDescription returns a description that is a string or a list of lines
+func joinDescription(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var lines []string
+	if json.Unmarshal(raw, &lines) == nil {
+		return strings.Join(lines, " ")
+	}
+	return ""
+}
+
+// parsePortDependency parses a dependency, which is a port name or an object.
+// Its features are names or objects with a name and a platform.
+func parsePortDependency(raw json.RawMessage) (PortDependency, error) {
+	var dep PortDependency
+	if json.Unmarshal(raw, &dep.Name) == nil {
+		return dep, nil
+	}
+	var obj struct {
+		Name     string            `json:"name"`
+		Host     bool              `json:"host"`
+		Features []json.RawMessage `json:"features"`
+		Platform string            `json:"platform"`
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return dep, err
+	}
+	dep = PortDependency{Name: obj.Name, Host: obj.Host, Platform: obj.Platform}
+	features, err := parseFeatureNames(obj.Features)
+	if err != nil {
+		return dep, err
+	}
+	dep.Features = features
+	return dep, nil
+}
+
+// parseFeatureNames returns the names of a list of features, which are names
+// or objects with a name and a platform
+func parseFeatureNames(list []json.RawMessage) ([]string, error) {
+	var names []string
+	for _, f := range list {
+		var feature struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(f, &feature.Name) != nil {
+			if err := json.Unmarshal(f, &feature); err != nil {
+				return nil, err
+			}
+		}
+		names = append(names, feature.Name)
+	}
+	return names, nil
+}
+
+// LoadPort reads the port called name from the vcpkg checkout in vcpkgRoot
+func LoadPort(vcpkgRoot, name string) (*Port, error) {
+	portDir := filepath.Join(vcpkgRoot, "ports", name)
+	data, err := os.ReadFile(filepath.Join(portDir, "vcpkg.json"))
+	if err != nil {
+		return nil, err
+	}
+	var file portFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s/vcpkg.json: %w", name, err)
+	}
+
+	port := &Port{
+		Name:         file.Name,
+		Description:  joinDescription(file.Description),
+		Homepage:     file.Homepage,
+		Supports:     file.Supports,
+		Dependencies: []PortDependency{},
+		Features:     []PortFeature{},
+	}
+	for _, v := range []string{file.Version, file.VersionSem, file.VersionDate, file.VersionStr} {
+		if v != "" {
+			port.Version = v
+			break
+		}
+	}
+	if file.PortVersion > 0 {
+		port.Version += fmt.Sprintf("#%d", file.PortVersion)
+	}
+	if file.License != nil {
+		port.License = *file.License
+	}
+
+	for _, raw := range file.Dependencies {
+		dep, err := parsePortDependency(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the dependencies of %s: %w", name, err)
+		}
+		port.Dependencies = append(port.Dependencies, dep)
+	}
+
+	if port.DefaultFeatures, err = parseFeatureNames(file.DefaultFeatures); err != nil {
+		return nil, fmt.Errorf("failed to parse the default features of %s: %w", name, err)
+	}
+
+	for featureName, raw := range file.Features {
+		var feature struct {
+			Description  json.RawMessage   `json:"description"`
+			Dependencies []json.RawMessage `json:"dependencies"`
+		}
+		_ = json.Unmarshal(raw, &feature)
+		portFeature := PortFeature{Name: featureName, Description: joinDescription(feature.Description)}
+		for _, raw := range feature.Dependencies {
+			dep, err := parsePortDependency(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the dependencies of %s[%s]: %w", name, featureName, err)
+			}
+			portFeature.Dependencies = append(portFeature.Dependencies, dep)
+		}
+		port.Features = append(port.Features, portFeature)
+	}
+	sort.Slice(port.Features, func(i, j int) bool { return port.Features[i].Name < port.Features[j].Name })
+
+	if usage, err := os.ReadFile(filepath.Join(portDir, "usage")); err == nil {
+		port.Usage = strings.TrimSpace(string(usage))
+	}
+	return port, nil
+}
+
+// PortVersion is a version of a port in the versions database of vcpkg
+type PortVersion struct {
+	Version     string
+	PortVersion int
+}
+
+// String returns the version with the port version after a #
+func (v PortVersion) String() string {
+	if v.PortVersion > 0 {
+		return fmt.Sprintf("%s#%d", v.Version, v.PortVersion)
+	}
+	return v.Version
+}
+
+// PortVersions returns the versions of the port called name known to the
+// vcpkg checkout in vcpkgRoot, newest first, from versions/<x>-/<name>.json
+func PortVersions(vcpkgRoot, name string) ([]PortVersion, error) {
+	if name == "" {
+		return nil, fmt.Errorf("empty port name")
+	}
+	data, err := os.ReadFile(filepath.Join(vcpkgRoot, "versions", name[:1]+"-", name+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Versions []struct {
+			Version     string `json:"version"`
+			VersionSem  string `json:"version-semver"`
+			VersionDate string `json:"version-date"`
+			VersionStr  string `json:"version-string"`
+			PortVersion int    `json:"port-version"`
+		} `json:"versions"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse the versions of %s: %w", name, err)
+	}
+	versions := make([]PortVersion, 0, len(file.Versions))
+	for _, v := range file.Versions {
+		version := PortVersion{PortVersion: v.PortVersion}
+		for _, s := range []string{v.Version, v.VersionSem, v.VersionDate, v.VersionStr} {
+			if s != "" {
+				version.Version = s
+				break
+			}
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
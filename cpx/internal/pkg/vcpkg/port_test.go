@@ -0,0 +1,86 @@
+package vcpkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPort(t *testing.T) {
+	root := t.TempDir()
+	portDir := filepath.Join(root, "ports", "spdlog")
+	require.NoError(t, os.MkdirAll(portDir, 0755))
+	manifest := `{
+  "name": "spdlog",
+  "version-semver": "1.14.1",
+  "port-version": 2,
+  "description": ["Very fast,", "header-only C++ logging library"],
+  "homepage": "https://github.com/gabime/spdlog",
+  "license": "MIT",
+  "supports": "!uwp",
+  "dependencies": [
+    {"name": "fmt", "features": ["core", {"name": "unicode", "platform": "windows"}]},
+    {"name": "vcpkg-cmake", "host": true},
+    "zlib"
+  ],
+  "features": {
+    "wchar": {"description": "Build with wchar_t (Windows only)", "supports": "windows"},
+    "benchmark": {"description": ["Use", "google benchmark"], "dependencies": ["benchmark", {"name": "fmt", "features": ["os"]}]}
+  },
+  "default-features": [{"name": "wchar", "platform": "windows"}]
+}`
+	require.NoError(t, os.WriteFile(filepath.Join(portDir, "vcpkg.json"), []byte(manifest), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(portDir, "usage"), []byte("find_package(spdlog CONFIG REQUIRED)\n"), 0644))
+
+	port, err := LoadPort(root, "spdlog")
+	require.NoError(t, err)
+	assert.Equal(t, &Port{
+		Name:        "spdlog",
+		Version:     "1.14.1#2",
+		Description: "Very fast, header-only C++ logging library",
+		Homepage:    "https://github.com/gabime/spdlog",
+		License:     "MIT",
+		Dependencies: []PortDependency{
+			{Name: "fmt", Features: []string{"core", "unicode"}},
+			{Name: "vcpkg-cmake", Host: true},
+			{Name: "zlib"},
+		},
+		Supports: "!uwp",
+		Features: []PortFeature{
+			{Name: "benchmark", Description: "Use google benchmark", Dependencies: []PortDependency{
+				{Name: "benchmark"},
+				{Name: "fmt", Features: []string{"os"}},
+			}},
+			{Name: "wchar", Description: "Build with wchar_t (Windows only)"},
+		},
+		DefaultFeatures: []string{"wchar"},
+		Usage:           "find_package(spdlog CONFIG REQUIRED)",
+	}, port)
+
+	_, err = LoadPort(root, "missing")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPortVersions(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "versions", "f-")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	db := `{"versions": [
+  {"git-tree": "c", "version": "11.0.2", "port-version": 1},
+  {"git-tree": "b", "version": "11.0.2", "port-version": 0},
+  {"git-tree": "a", "version-semver": "10.2.1", "port-version": 0}
+]}`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fmt.json"), []byte(db), 0644))
+
+	versions, err := PortVersions(root, "fmt")
+	require.NoError(t, err)
+	assert.Equal(t, []PortVersion{{"11.0.2", 1}, {"11.0.2", 0}, {"10.2.1", 0}}, versions)
+	assert.Equal(t, "11.0.2#1", versions[0].String())
+	assert.Equal(t, "10.2.1", versions[2].String())
+
+	_, err = PortVersions(root, "zlib")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
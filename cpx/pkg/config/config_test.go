@@ -121,3 +121,162 @@ func TestSaveGlobalConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadSaveTools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.ToolsFile)
+
+	_, err := config.LoadTools(path)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, config.SaveTools(&config.ToolsConfig{Tools: map[string]string{
+		"cmake": ">=3.20",
+		"ninja": "1.11",
+	}}, path))
+
+	loaded, err := config.LoadTools(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"cmake": ">=3.20", "ninja": "1.11"}, loaded.Tools)
+
+	require.NoError(t, os.WriteFile(path, []byte("tools: [\n"), 0644))
+	_, err = config.LoadTools(path)
+	assert.Error(t, err)
+}
+
+func TestLoadProject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.ProjectFile)
+
+	_, err := config.LoadProject(path)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.WriteFile(path, []byte("build:\n  pch: true\n"), 0644))
+	loaded, err := config.LoadProject(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.Build.PCH)
+	assert.Empty(t, loaded.Run.Profiles)
+
+	profiles := `run:
+  profiles:
+    staging:
+      args: ["--config", "staging.toml"]
+      env:
+        LOG_LEVEL: info
+      working_dir: deploy
+`
+	require.NoError(t, os.WriteFile(path, []byte(profiles), 0644))
+	loaded, err = config.LoadProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, config.RunProfile{
+		Args:       []string{"--config", "staging.toml"},
+		Env:        map[string]string{"LOG_LEVEL": "info"},
+		WorkingDir: "deploy",
+	}, loaded.Run.Profiles["staging"])
+
+	require.NoError(t, os.WriteFile(path, []byte("clangd:\n  suppress: [unused-includes, -Wunused-parameter]\n"), 0644))
+	loaded, err = config.LoadProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unused-includes", "-Wunused-parameter"}, loaded.Clangd.Suppress)
+
+	require.NoError(t, os.WriteFile(path, []byte("build: [\n"), 0644))
+	_, err = config.LoadProject(path)
+	assert.Error(t, err)
+}
+
+func TestSaveProjectBuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.ProjectFile)
+	content := `# cpx.yaml - Project configuration
+
+build:
+  # Precompile common headers (CMake projects)
+  pch: false
+run:
+  profiles:
+    dev:
+      args: [--verbose]
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	require.NoError(t, config.SaveProjectBuild(path, config.ProjectBuild{PCH: true, Unity: true}))
+
+	loaded, err := config.LoadProject(path)
+	require.NoError(t, err)
+	assert.Equal(t, config.ProjectBuild{PCH: true, Unity: true}, loaded.Build)
+	assert.Equal(t, []string{"--verbose"}, loaded.Run.Profiles["dev"].Args)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# Precompile common headers (CMake projects)")
+
+	// A file without a build section gets one
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	require.NoError(t, config.SaveProjectBuild(path, config.ProjectBuild{Unity: true}))
+	loaded, err = config.LoadProject(path)
+	require.NoError(t, err)
+	assert.True(t, loaded.Build.Unity)
+
+	require.NoError(t, os.WriteFile(path, []byte("- a\n"), 0644))
+	assert.Error(t, config.SaveProjectBuild(path, config.ProjectBuild{}))
+}
+
+func TestBinaryCacheVcpkgSource(t *testing.T) {
+	assert.Equal(t, "files,/srv/cache,readwrite", config.BinaryCache{Kind: "files", URL: "/srv/cache"}.VcpkgSource())
+	assert.Equal(t, "x-gcs,gs://acme/vcpkg,read", config.BinaryCache{Kind: "gcs", URL: "gs://acme/vcpkg", Mode: "read"}.VcpkgSource())
+	// vcpkg's separators in the URL are escaped
+	assert.Equal(t, "nuget,https://feed/a`,b`;c,write", config.BinaryCache{Kind: "nuget", URL: "https://feed/a,b;c", Mode: "write"}.VcpkgSource())
+}
+
+func TestLoadSaveLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.LockFile)
+	_, err := config.LoadLock(path)
+	assert.True(t, os.IsNotExist(err))
+
+	lock := &config.Lock{
+		Files: map[string]string{"vcpkg.json": "abc"},
+		Vcpkg: &config.VcpkgLock{Baseline: "c0ffee", Packages: map[string]string{"fmt": "11.0.2#1"}},
+	}
+	require.NoError(t, config.SaveLock(lock, path))
+	loaded, err := config.LoadLock(path)
+	require.NoError(t, err)
+	assert.Equal(t, lock, loaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("files: [\n"), 0644))
+	_, err = config.LoadLock(path)
+	assert.Error(t, err)
+}
+
+func TestCICells(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cpx.ci")
+	require.NoError(t, os.WriteFile(path, []byte(`targets:
+  - image: linux-amd64
+  - image: linux-amd64-musl
+    matrix:
+      build_types: [Release]
+matrix:
+  compilers: [gcc, clang]
+  standards: [17, 20]
+`), 0644))
+	ciConfig, err := config.LoadCI(path)
+	require.NoError(t, err)
+
+	cells := ciConfig.Cells(ciConfig.Targets[0])
+	require.Len(t, cells, 4)
+	var names []string
+	for _, cell := range cells {
+		names = append(names, cell.Name)
+		assert.Equal(t, "linux-amd64", cell.Base)
+		assert.Equal(t, "cpx-linux-amd64", cell.Tag, "the cells share the image")
+	}
+	assert.Equal(t, []string{"linux-amd64-gcc-cxx17", "linux-amd64-gcc-cxx20", "linux-amd64-clang-cxx17", "linux-amd64-clang-cxx20"}, names)
+	assert.Equal(t, "clang", cells[3].Compiler)
+	assert.Equal(t, 20, cells[3].Standard)
+
+	// The matrix of a target replaces the one of cpx.ci
+	cells = ciConfig.Cells(ciConfig.Targets[1])
+	require.Len(t, cells, 1)
+	assert.Equal(t, "linux-amd64-musl-release", cells[0].Name)
+	assert.Equal(t, "Release", cells[0].BuildType)
+	assert.Empty(t, cells[0].Compiler)
+
+	ciConfig.Matrix = nil
+	cells = ciConfig.Cells(ciConfig.Targets[0])
+	require.Len(t, cells, 1)
+	assert.Equal(t, "linux-amd64", cells[0].Name)
+}
@@ -0,0 +1,219 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the per-project cpx configuration
+const ProjectFile = "cpx.yaml"
+
+// ProjectConfig represents the cpx.yaml structure
+type ProjectConfig struct {
+	Build   ProjectBuild   `yaml:"build"`
+	Run     ProjectRun     `yaml:"run"`
+	Clangd  ProjectClangd  `yaml:"clangd"`
+	Doc     ProjectDoc     `yaml:"doc"`
+	Fmt     ProjectFmt     `yaml:"fmt"`
+	Analyze ProjectAnalyze `yaml:"analyze"`
+	Hooks   ProjectHooks   `yaml:"hooks"`
+}
+
+// ProjectHooks holds the git hooks cpx hooks install writes. Entries are
+// cpx checks (fmt, lint, test, flawfinder, cppcheck, check) or any other
+// command, e.g. "./scripts/check_licenses.sh", which blocks the commit or
+// push when it fails.
+type ProjectHooks struct {
+	// PreCommit runs before each commit (default: fmt, lint)
+	PreCommit []string `yaml:"precommit"`
+	// PrePush runs before each push (default: test)
+	PrePush []string `yaml:"prepush"`
+	// CommitMsg configures the commit-msg hook of cpx hooks install
+	// --commit-msg
+	CommitMsg CommitMsgRules `yaml:"commit_msg"`
+}
+
+// CommitMsgRules are the Conventional Commits rules of the commit-msg hook
+type CommitMsgRules struct {
+	// Types are the accepted commit types (default: feat, fix, docs, style,
+	// refactor, perf, test, build, ci, chore, revert)
+	Types []string `yaml:"types"`
+	// Scopes are the accepted scopes; any scope is accepted if empty, and
+	// the scope is always optional
+	Scopes []string `yaml:"scopes"`
+}
+
+// ProjectAnalyze holds the cpx analyze options of cpx.yaml
+type ProjectAnalyze struct {
+	// Semgrep are the semgrep rulesets cpx analyze runs: rule files or
+	// directories of the project, registry rulesets (e.g. "p/c") or URLs
+	Semgrep []string `yaml:"semgrep"`
+}
+
+// ProjectFmt holds the cpx fmt options of cpx.yaml
+type ProjectFmt struct {
+	// BuildFiles also formats CMake (gersemi or cmake-format), Bazel
+	// (buildifier) and Meson (muon fmt) build files, with the formatters
+	// that are installed
+	BuildFiles bool `yaml:"build_files"`
+}
+
+// ProjectBuild holds the build options of cpx.yaml
+type ProjectBuild struct {
+	// PCH enables precompiled headers (CMake projects, CPX_PCH option)
+	PCH bool `yaml:"pch"`
+	// Unity enables unity (jumbo) builds, like cpx build --unity
+	Unity bool `yaml:"unity"`
+	// Compiler selects the compiler, like cpx build --compiler (e.g.
+	// "clang-17", "gcc-13", "cl")
+	Compiler string `yaml:"compiler"`
+	// Preset is the CMake configure preset cpx configures with, set with
+	// cpx preset use ("default" if empty)
+	Preset string `yaml:"preset"`
+}
+
+// ProjectRun holds the cpx run options of cpx.yaml
+type ProjectRun struct {
+	// Profiles are named argument sets selected with cpx run --profile
+	Profiles map[string]RunProfile `yaml:"profiles"`
+}
+
+// RunProfile is a named set of arguments, environment variables and working
+// directory for cpx run
+type RunProfile struct {
+	Args []string          `yaml:"args"`
+	Env  map[string]string `yaml:"env"`
+	// WorkingDir is relative to the project root
+	WorkingDir string `yaml:"working_dir"`
+}
+
+// ProjectClangd holds the settings cpx gen clangd writes to .clangd
+type ProjectClangd struct {
+	// Suppress lists diagnostic ids clangd should not report
+	// (e.g. "unused-includes", "-Wunused-parameter")
+	Suppress []string `yaml:"suppress"`
+}
+
+// ProjectDoc holds the settings cpx doc writes to the Doxyfile
+type ProjectDoc struct {
+	// Theme is "awesome" for the doxygen-awesome-css theme, or empty for
+	// Doxygen's own
+	Theme string `yaml:"theme"`
+	// Input are the documented directories and files (default: src, include)
+	Input []string `yaml:"input"`
+	// Exclude are patterns of files not to document (e.g. "*/detail/*")
+	Exclude []string `yaml:"exclude"`
+	// Diagrams are the graphs Graphviz dot draws
+	Diagrams DocDiagrams `yaml:"diagrams"`
+}
+
+// DocDiagrams holds the Graphviz dot settings of cpx doc
+type DocDiagrams struct {
+	// Enabled draws class, collaboration and include graphs
+	Enabled bool `yaml:"enabled"`
+	// CallGraphs also draws the call and caller graphs of functions
+	CallGraphs bool `yaml:"call_graphs"`
+	// Format is the image format, svg (default) or png
+	Format string `yaml:"format"`
+}
+
+// LoadProject loads the project configuration from path
+func LoadProject(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ProjectConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ProjectFile, err)
+	}
+
+	return &config, nil
+}
+
+// SaveProjectBuild sets the build options of the cpx.yaml at path, keeping
+// its comments and other sections
+func SaveProjectBuild(path string, build ProjectBuild) error {
+	return updateProjectBuild(path, func(buildNode *yaml.Node) {
+		setMappingScalar(buildNode, "pch", strconv.FormatBool(build.PCH), "!!bool")
+		setMappingScalar(buildNode, "unity", strconv.FormatBool(build.Unity), "!!bool")
+	})
+}
+
+// SaveProjectPreset sets build.preset in the cpx.yaml at path, keeping its
+// comments and other sections
+func SaveProjectPreset(path, preset string) error {
+	return updateProjectBuild(path, func(buildNode *yaml.Node) {
+		setMappingScalar(buildNode, "preset", preset, "!!str")
+	})
+}
+
+// updateProjectBuild rewrites the build section of the cpx.yaml at path
+// with update
+func updateProjectBuild(path string, update func(buildNode *yaml.Node)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", ProjectFile, err)
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("failed to update %s: the top level is not a mapping", ProjectFile)
+	}
+
+	update(mappingValue(root, "build"))
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", ProjectFile, err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode %s: %w", ProjectFile, err)
+	}
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// mappingValue returns the mapping under key in m, adding it (or replacing a
+// value that isn't a mapping) if needed
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			value := m.Content[i+1]
+			if value.Kind != yaml.MappingNode {
+				*value = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+			return value
+		}
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// setMappingScalar sets key in m to a scalar value
+func setMappingScalar(m *yaml.Node, key, value, tag string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			node := m.Content[i+1]
+			node.Kind, node.Tag, node.Value, node.Style, node.Content = yaml.ScalarNode, tag, value, 0, nil
+			return
+		}
+	}
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+}
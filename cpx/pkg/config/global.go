@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -14,6 +15,76 @@ type GlobalConfig struct {
 	VcpkgRoot  string `yaml:"vcpkg_root"`
 	BcrRoot    string `yaml:"bcr_root"`    // Bazel Central Registry path
 	WrapdbRoot string `yaml:"wrapdb_root"` // Meson WrapDB path
+	// TemplateRepos are the git repositories `cpx new --template repo:name`
+	// instantiates templates from
+	TemplateRepos []TemplateRepo `yaml:"template_repos,omitempty"`
+	// Offline makes every command run as with --offline
+	Offline bool `yaml:"offline,omitempty"`
+	// BinaryCache is where vcpkg stores built packages, locally and in
+	// cpx ci builds; vcpkg's default cache if nil
+	BinaryCache *BinaryCache `yaml:"binary_cache,omitempty"`
+	// Telemetry opts in to anonymous usage events (see cpx config
+	// telemetry)
+	Telemetry bool `yaml:"telemetry,omitempty"`
+	// TelemetryEndpoint is the URL the usage events are sent to
+	TelemetryEndpoint string `yaml:"telemetry_endpoint,omitempty"`
+}
+
+// BinaryCacheKinds maps the kinds of binary cache to their vcpkg providers
+var BinaryCacheKinds = map[string]string{
+	"files": "files",
+	"nuget": "nuget",
+	"gcs":   "x-gcs",
+	"s3":    "x-aws",
+}
+
+// BinaryCache is a vcpkg binary cache
+type BinaryCache struct {
+	Kind string `yaml:"kind"` // files, nuget, gcs or s3
+	// URL is the directory of a files cache, the feed of a nuget cache or
+	// the gs:// or s3:// prefix of a bucket
+	URL  string `yaml:"url"`
+	Mode string `yaml:"mode,omitempty"` // read, write or readwrite (default)
+}
+
+// VcpkgSource returns the cache as a source of VCPKG_BINARY_SOURCES
+func (c BinaryCache) VcpkgSource() string {
+	mode := c.Mode
+	if mode == "" {
+		mode = "readwrite"
+	}
+	// vcpkg escapes its separators with backticks
+	url := strings.NewReplacer("`", "``", ",", "`,", ";", "`;").Replace(c.URL)
+	return BinaryCacheKinds[c.Kind] + "," + url + "," + mode
+}
+
+// TemplateRepo is a git repository of project templates, one per top-level
+// directory
+type TemplateRepo struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	Ref  string `yaml:"ref,omitempty"` // branch or tag; the default branch if empty
+}
+
+// TemplateRepo returns the template repository called name
+func (c *GlobalConfig) TemplateRepo(name string) (TemplateRepo, bool) {
+	for _, repo := range c.TemplateRepos {
+		if repo.Name == name {
+			return repo, true
+		}
+	}
+	return TemplateRepo{}, false
+}
+
+// SetTemplateRepo adds repo, replacing a repository of the same name
+func (c *GlobalConfig) SetTemplateRepo(repo TemplateRepo) {
+	for i := range c.TemplateRepos {
+		if c.TemplateRepos[i].Name == repo.Name {
+			c.TemplateRepos[i] = repo
+			return
+		}
+	}
+	c.TemplateRepos = append(c.TemplateRepos, repo)
 }
 
 // GetConfigDir returns the directory where cpx stores its global config
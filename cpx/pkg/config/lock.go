@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LockFile pins the exact dependency versions of a project
+const LockFile = "cpx.lock"
+
+// Lock represents the cpx.lock structure. cpx build checks that the files
+// declaring the dependencies still have the checksums recorded here.
+type Lock struct {
+	// Files maps the dependency files (vcpkg.json, MODULE.bazel,
+	// MODULE.bazel.lock, subprojects/*.wrap) to their sha256
+	Files map[string]string `yaml:"files"`
+	// Vcpkg is set for vcpkg projects
+	Vcpkg *VcpkgLock `yaml:"vcpkg,omitempty"`
+	// Wraps maps the Meson wraps to their WrapDB version or git revision
+	Wraps map[string]string `yaml:"wraps,omitempty"`
+}
+
+// VcpkgLock records the versions vcpkg.json was pinned to
+type VcpkgLock struct {
+	// Baseline is the builtin-baseline, a commit of the vcpkg registry
+	Baseline string `yaml:"baseline"`
+	// Packages maps the dependencies to their version (with #port-version)
+	Packages map[string]string `yaml:"packages"`
+}
+
+// LoadLock loads the lockfile from path
+func LoadLock(path string) (*Lock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", LockFile, err)
+	}
+	if lock.Files == nil {
+		lock.Files = make(map[string]string)
+	}
+
+	return &lock, nil
+}
+
+// SaveLock saves the lockfile to path
+func SaveLock(lock *Lock, path string) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", LockFile, err)
+	}
+
+	header := "# cpx.lock - generated by cpx lock; do not edit\n" +
+		"# Commit it: cpx build fails when the dependency files change without cpx lock\n\n"
+	if err := os.WriteFile(path, []byte(header+string(data)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", LockFile, err)
+	}
+
+	return nil
+}
@@ -14,6 +14,34 @@ type CIConfig struct {
 	Targets []CITarget `yaml:"targets"`
 	Build   CIBuild    `yaml:"build"`
 	Output  string     `yaml:"output"`
+	// Matrix expands every target into one build per cell
+	Matrix *CIMatrix `yaml:"matrix,omitempty"`
+	// Cache shares the image layers and vcpkg packages of the builds
+	// between developer machines and CI runners
+	Cache *CICache `yaml:"cache,omitempty"`
+}
+
+// CICache is a build cache of cpx ci shared through a registry and a vcpkg
+// binary cache
+type CICache struct {
+	// Registry is the image repository buildx keeps the layer cache of the
+	// target images in, one tag per image (e.g. ghcr.io/acme/app-cache)
+	Registry string `yaml:"registry,omitempty"`
+	// Mode is read, to only import the layer cache (e.g. on machines that
+	// can't push), or readwrite (default)
+	Mode string `yaml:"mode,omitempty"`
+	// BinaryCache is the vcpkg binary cache of the builds; it replaces the
+	// one of cpx config set-binary-cache
+	BinaryCache *BinaryCache `yaml:"binary_cache,omitempty"`
+}
+
+// CIMatrix lists the compilers, C++ standards and build types a target is
+// built with; a target builds once per combination. An empty list keeps the
+// default of the image and the build section.
+type CIMatrix struct {
+	Compilers  []string `yaml:"compilers,omitempty"`
+	Standards  []int    `yaml:"standards,omitempty"`
+	BuildTypes []string `yaml:"build_types,omitempty"`
 }
 
 // CITarget represents a cross-compilation target
@@ -21,6 +49,17 @@ type CITarget struct {
 	Name   string `yaml:"name,omitempty"`
 	Source string `yaml:"image"`
 	Tag    string `yaml:"tag,omitempty"`
+	// Platform is the Docker platform of the image (e.g. linux/riscv64);
+	// images of another architecture than the host's run under QEMU
+	Platform string `yaml:"platform,omitempty"`
+	// Matrix replaces the matrix of cpx.ci for this target
+	Matrix *CIMatrix `yaml:"matrix,omitempty"`
+
+	// The cell of a matrix build, set by Cells
+	Base      string `yaml:"-"`
+	Compiler  string `yaml:"-"`
+	Standard  int    `yaml:"-"`
+	BuildType string `yaml:"-"`
 }
 
 // CIBuild represents CI build configuration
@@ -75,6 +114,68 @@ func LoadCI(path string) (*CIConfig, error) {
 	return &config, nil
 }
 
+// Cells expands a target into the builds of its matrix, named
+// <target>-<compiler>-cxx<standard>-<build type> after the dimensions the
+// matrix sets (e.g. linux-amd64-clang-cxx20-debug). Without a matrix the
+// target is its only cell.
+func (c *CIConfig) Cells(target CITarget) []CITarget {
+	target.Base = target.Name
+	matrix := c.Matrix
+	if target.Matrix != nil {
+		matrix = target.Matrix
+	}
+	if matrix == nil {
+		return []CITarget{target}
+	}
+
+	cells := []CITarget{target}
+	if len(matrix.Compilers) > 0 {
+		var next []CITarget
+		for _, cell := range cells {
+			for _, compiler := range matrix.Compilers {
+				cell.Compiler = compiler
+				next = append(next, cell)
+			}
+		}
+		cells = next
+	}
+	if len(matrix.Standards) > 0 {
+		var next []CITarget
+		for _, cell := range cells {
+			for _, standard := range matrix.Standards {
+				cell.Standard = standard
+				next = append(next, cell)
+			}
+		}
+		cells = next
+	}
+	if len(matrix.BuildTypes) > 0 {
+		var next []CITarget
+		for _, cell := range cells {
+			for _, buildType := range matrix.BuildTypes {
+				cell.BuildType = buildType
+				next = append(next, cell)
+			}
+		}
+		cells = next
+	}
+
+	for i := range cells {
+		name := []string{target.Name}
+		if cells[i].Compiler != "" {
+			name = append(name, cells[i].Compiler)
+		}
+		if cells[i].Standard != 0 {
+			name = append(name, fmt.Sprintf("cxx%d", cells[i].Standard))
+		}
+		if cells[i].BuildType != "" {
+			name = append(name, strings.ToLower(cells[i].BuildType))
+		}
+		cells[i].Name = strings.Join(name, "-")
+	}
+	return cells
+}
+
 // SaveCI saves the CI configuration to cpx.ci
 func SaveCI(config *CIConfig, path string) error {
 	data, err := yaml.Marshal(config)
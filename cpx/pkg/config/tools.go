@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToolsFile is the per-project tool version manifest
+const ToolsFile = ".cpx-tools.yaml"
+
+// ToolsConfig represents the .cpx-tools.yaml structure: the versions of the
+// tools (cmake, ninja, compilers, clang tools, ...) a project must be built with
+type ToolsConfig struct {
+	// Tools maps a tool name to a version constraint, e.g. ">=3.20" or "17"
+	Tools map[string]string `yaml:"tools"`
+}
+
+// LoadTools loads the tool version manifest from path
+func LoadTools(path string) (*ToolsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ToolsConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ToolsFile, err)
+	}
+	if config.Tools == nil {
+		config.Tools = make(map[string]string)
+	}
+
+	return &config, nil
+}
+
+// SaveTools saves the tool version manifest to path
+func SaveTools(config *ToolsConfig, path string) error {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", ToolsFile, err)
+	}
+
+	header := "# .cpx-tools.yaml - Required tool versions\n" +
+		"# Constraints: \"17\" (17.x), \"3.28.1\" (exact prefix), \">=3.20\", \"<18\"\n\n"
+	content := header + string(data)
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", ToolsFile, err)
+	}
+
+	return nil
+}
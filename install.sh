@@ -477,6 +477,7 @@ install_dockerfiles() {
         "Dockerfile.linux-amd64-musl"
         "Dockerfile.linux-arm64"
         "Dockerfile.linux-arm64-musl"
+        "Dockerfile.linux-riscv64"
         "cpx.ci.example"
         "README.md"
     )